@@ -0,0 +1,97 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package anchor
+
+import (
+	"encoding/json"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// AnchorInfo is what the anchor chain records of a directory block: where its
+// key merkle root is anchored in Bitcoin and Ethereum, and what of that has
+// been checked.  The records of a block in each chain may come in separate
+// entries, and are gathered by Add.
+type AnchorInfo struct {
+	DBHeight uint32
+	KeyMR    string
+
+	Bitcoin  *BitcoinStruct  `json:",omitempty"`
+	Ethereum *EthereumStruct `json:",omitempty"`
+
+	KeyMRMatches     bool // KeyMR is that of the directory block at DBHeight
+	BitcoinVerified  bool // The Bitcoin transaction was found, and anchors KeyMR
+	EthereumVerified bool // Likewise the Ethereum transaction
+}
+
+// Names of the chains anchors are made in
+const (
+	ChainBitcoin  = "bitcoin"
+	ChainEthereum = "ethereum"
+)
+
+// AnchorInfoFetcher is a database that keeps the AnchorInfo of the directory
+// blocks, as the database overlay does.
+type AnchorInfoFetcher interface {
+	FetchAnchorInfo(dbHeight uint32) (*AnchorInfo, error)
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*AnchorInfo)(nil)
+var _ interfaces.Printable = (*AnchorInfo)(nil)
+
+func (a *AnchorInfo) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(AnchorInfo)
+}
+
+func (a *AnchorInfo) MarshalBinary() ([]byte, error) {
+	return json.Marshal(a)
+}
+
+func (a *AnchorInfo) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	return nil, json.Unmarshal(data, a)
+}
+
+func (a *AnchorInfo) UnmarshalBinary(data []byte) error {
+	_, err := a.UnmarshalBinaryData(data)
+	return err
+}
+
+func (a *AnchorInfo) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(a)
+}
+
+func (a *AnchorInfo) JSONString() (string, error) {
+	return primitives.EncodeJSONString(a)
+}
+
+func (a *AnchorInfo) String() string {
+	str, _ := a.JSONString()
+	return str
+}
+
+// Add takes the anchors of a record into the info.  A record for another
+// key merkle root replaces the info, unless the info's is known to match the
+// directory block, in which case the record is left out.  It returns whether
+// the record was taken.
+func (a *AnchorInfo) Add(ar *AnchorRecord) bool {
+	if a.KeyMR != ar.KeyMR {
+		if a.KeyMRMatches {
+			return false
+		}
+		*a = AnchorInfo{}
+	}
+	a.DBHeight = ar.DBHeight
+	a.KeyMR = ar.KeyMR
+	if ar.Bitcoin != nil {
+		a.Bitcoin = ar.Bitcoin
+		a.BitcoinVerified = false
+	}
+	if ar.Ethereum != nil {
+		a.Ethereum = ar.Ethereum
+		a.EthereumVerified = false
+	}
+	return true
+}
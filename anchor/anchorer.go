@@ -0,0 +1,265 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package anchor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// Anchorer is an external chain directory blocks can be anchored in.  It only
+// knows how to talk to its chain; when to anchor, what to send again, and
+// what has been confirmed is kept by a Scheduler.
+type Anchorer interface {
+	// SubmitAnchor sends the transaction that anchors the key merkle root of
+	// the directory block at the height, and returns its ID.
+	SubmitAnchor(height uint32, keyMR interfaces.IHash) (string, error)
+	// ConfirmAnchor looks up a sent transaction.  Once it is confirmed, its
+	// anchor is set in the record.
+	ConfirmAnchor(txid string, ar *AnchorRecord) (AnchorStatus, error)
+	// ParseAnchorRecord returns the ID of the transaction of the record that
+	// anchors in this chain, if it has one.
+	ParseAnchorRecord(ar *AnchorRecord) (string, bool)
+}
+
+// AnchorStatus is where a sent anchor transaction is at.
+type AnchorStatus int
+
+const (
+	AnchorPending   AnchorStatus = iota // Not yet mined, or not yet deep enough
+	AnchorConfirmed                     // Deep enough to be recorded
+	AnchorFailed                        // Must be sent again
+)
+
+// PollInterval is how often new directory blocks and the pending anchors are
+// checked for.
+var PollInterval = 15 * time.Second
+
+// RecordLookback is how many directory blocks below the highest saved the
+// Scheduler takes the anchors of from the anchor chain when it starts.
+var RecordLookback uint32 = 1000
+
+// RecordWriter writes the record of a confirmed anchor to the anchor chain.
+type RecordWriter func(ar *AnchorRecord) error
+
+// Scheduler anchors directory blocks through an Anchorer, sends again the
+// anchors that failed, and keeps the records of those confirmed.  If Write
+// is set, the records confirmed are written to the anchor chain with it, and
+// written again next time if that fails.
+type Scheduler struct {
+	Name     string
+	Anchorer Anchorer
+	Write    RecordWriter
+
+	mutex     sync.Mutex
+	pending   map[uint32]*pendingAnchor
+	confirmed map[uint32]*AnchorRecord
+	unwritten map[uint32]*AnchorRecord // Confirmed, but not yet written by Write
+}
+
+// pendingAnchor is an anchor that has been sent, but is not yet confirmed.
+type pendingAnchor struct {
+	KeyMR interfaces.IHash
+	TXID  string
+}
+
+func NewScheduler(name string, a Anchorer) *Scheduler {
+	s := new(Scheduler)
+	s.Name = name
+	s.Anchorer = a
+	s.pending = map[uint32]*pendingAnchor{}
+	s.confirmed = map[uint32]*AnchorRecord{}
+	s.unwritten = map[uint32]*AnchorRecord{}
+	return s
+}
+
+// Anchor submits the anchor of the directory block at the height, and
+// returns the ID of its transaction.  It is followed by CheckConfirmations
+// from then on.
+func (s *Scheduler) Anchor(height uint32, keyMR interfaces.IHash) (string, error) {
+	txid, err := s.Anchorer.SubmitAnchor(height, keyMR)
+	if err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending[height] = &pendingAnchor{KeyMR: keyMR, TXID: txid}
+	return txid, nil
+}
+
+// CheckConfirmations looks up the pending anchors.  Those confirmed are
+// recorded, and written to the anchor chain, and those that failed are sent
+// again.
+func (s *Scheduler) CheckConfirmations() error {
+	s.mutex.Lock()
+	pending := make(map[uint32]*pendingAnchor, len(s.pending))
+	for height, p := range s.pending {
+		pending[height] = p
+	}
+	s.mutex.Unlock()
+
+	for height, p := range pending {
+		ar := new(AnchorRecord)
+		ar.AnchorRecordVer = 1
+		ar.DBHeight = height
+		ar.KeyMR = p.KeyMR.String()
+		ar.RecordHeight = height
+
+		status, err := s.Anchorer.ConfirmAnchor(p.TXID, ar)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case AnchorFailed:
+			_, err = s.Anchor(height, p.KeyMR)
+			if err != nil {
+				return err
+			}
+		case AnchorConfirmed:
+			s.mutex.Lock()
+			delete(s.pending, height)
+			s.confirmed[height] = ar
+			if s.Write != nil {
+				s.unwritten[height] = ar
+			}
+			s.mutex.Unlock()
+		}
+	}
+	return s.writeConfirmed()
+}
+
+// writeConfirmed writes the records confirmed and not yet written to the
+// anchor chain.
+func (s *Scheduler) writeConfirmed() error {
+	s.mutex.Lock()
+	unwritten := make([]*AnchorRecord, 0, len(s.unwritten))
+	for _, ar := range s.unwritten {
+		unwritten = append(unwritten, ar)
+	}
+	s.mutex.Unlock()
+
+	for _, ar := range unwritten {
+		if err := s.Write(ar); err != nil {
+			return err
+		}
+		s.mutex.Lock()
+		delete(s.unwritten, ar.DBHeight)
+		s.mutex.Unlock()
+	}
+	return nil
+}
+
+// Record takes an anchor made elsewhere, such as by another node, so that
+// its directory block isn't anchored again.  It returns whether the record
+// has an anchor in the chain of the scheduler.
+func (s *Scheduler) Record(ar *AnchorRecord) bool {
+	if _, ok := s.Anchorer.ParseAnchorRecord(ar); !ok {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.pending, ar.DBHeight)
+	delete(s.unwritten, ar.DBHeight)
+	s.confirmed[ar.DBHeight] = ar
+	return true
+}
+
+// RecordSaved takes the anchor of the directory block at the height from
+// what the anchor chain in the database records, if it records one in the
+// chain of the scheduler.
+func (s *Scheduler) RecordSaved(db AnchorInfoFetcher, height uint32) (bool, error) {
+	info, err := db.FetchAnchorInfo(height)
+	if err != nil || info == nil || !info.KeyMRMatches {
+		return false, err
+	}
+	ar := new(AnchorRecord)
+	ar.AnchorRecordVer = 1
+	ar.DBHeight = info.DBHeight
+	ar.KeyMR = info.KeyMR
+	ar.Bitcoin = info.Bitcoin
+	ar.Ethereum = info.Ethereum
+	return s.Record(ar), nil
+}
+
+// Confirmed returns the record of the confirmed anchor of the directory block
+// at the height, or nil if it has none.
+func (s *Scheduler) Confirmed(height uint32) *AnchorRecord {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.confirmed[height]
+}
+
+// Pending returns the number of anchors sent but not yet confirmed.
+func (s *Scheduler) Pending() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.pending)
+}
+
+// Run anchors each directory block the node saves once it has caught up
+// with the network, and follows the anchors until they are confirmed.  The
+// blocks saved while syncing are left to the nodes that were running then.
+// A block the anchor chain already records an anchor of, by this node before
+// a restart or by another node, isn't anchored again.  It never returns, so
+// is run as a goroutine.
+func (s *Scheduler) Run(state interfaces.IState) {
+	var next uint32
+	started := false
+	recordSaved := func(height uint32) bool {
+		db, isFetcher := state.GetAndLockDB().(AnchorInfoFetcher)
+		defer state.UnlockDB()
+		if !isFetcher {
+			return false
+		}
+		ok, err := s.RecordSaved(db, height)
+		if err != nil {
+			fmt.Printf("%s anchor: %v\n", s.Name, err)
+		}
+		return ok
+	}
+	for {
+		time.Sleep(PollInterval)
+
+		err := s.CheckConfirmations()
+		if err != nil {
+			fmt.Printf("%s anchor: %v\n", s.Name, err)
+		}
+
+		saved := state.GetHighestSavedBlk()
+		if saved < state.GetHighestKnownBlock() {
+			continue
+		}
+		if !started {
+			from := uint32(0)
+			if saved > RecordLookback {
+				from = saved - RecordLookback
+			}
+			for h := from; h < saved; h++ {
+				recordSaved(h)
+			}
+			next = saved
+			started = true
+		}
+		for ; next <= saved; next++ {
+			if s.Confirmed(next) != nil || recordSaved(next) {
+				continue
+			}
+			dblock := state.GetDirectoryBlockByHeight(next)
+			if dblock == nil {
+				break
+			}
+			_, err = s.Anchor(next, dblock.GetKeyMR())
+			if err != nil {
+				fmt.Printf("%s anchor: %v\n", s.Name, err)
+				break // Tried again next time
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package anchor_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// fakeAnchorer is a chain that takes the statuses of its transactions from
+// the test.
+type fakeAnchorer struct {
+	sent   []string
+	status map[string]AnchorStatus
+}
+
+func (f *fakeAnchorer) SubmitAnchor(height uint32, keyMR interfaces.IHash) (string, error) {
+	txid := fmt.Sprintf("%d-%d", height, len(f.sent))
+	f.sent = append(f.sent, txid)
+	return txid, nil
+}
+
+func (f *fakeAnchorer) ConfirmAnchor(txid string, ar *AnchorRecord) (AnchorStatus, error) {
+	if f.status[txid] == AnchorConfirmed {
+		ar.Bitcoin = &BitcoinStruct{TXID: txid}
+	}
+	return f.status[txid], nil
+}
+
+func (f *fakeAnchorer) ParseAnchorRecord(ar *AnchorRecord) (string, bool) {
+	if ar.Bitcoin == nil {
+		return "", false
+	}
+	return ar.Bitcoin.TXID, true
+}
+
+func TestScheduler(t *testing.T) {
+	f := &fakeAnchorer{status: map[string]AnchorStatus{}}
+	s := NewScheduler("Fake", f)
+	keyMR := primitives.Sha([]byte("dblock"))
+
+	txid, _ := s.Anchor(4, keyMR)
+	f.status[txid] = AnchorFailed
+	if err := s.CheckConfirmations(); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.sent) != 2 || s.Pending() != 1 || s.Confirmed(4) != nil {
+		t.Fatalf("Failed anchor not sent again, sent %v", f.sent)
+	}
+
+	f.status[f.sent[1]] = AnchorConfirmed
+	if err := s.CheckConfirmations(); err != nil {
+		t.Fatal(err)
+	}
+	ar := s.Confirmed(4)
+	if ar == nil || s.Pending() != 0 {
+		t.Fatalf("Anchor not confirmed")
+	}
+	if ar.DBHeight != 4 || ar.KeyMR != keyMR.String() || ar.Bitcoin.TXID != f.sent[1] {
+		t.Errorf("Wrong record %v", ar)
+	}
+
+	s.Anchor(5, keyMR)
+	if s.Record(&AnchorRecord{DBHeight: 5, Ethereum: &EthereumStruct{}}) {
+		t.Errorf("Record of another chain taken")
+	}
+	if !s.Record(&AnchorRecord{DBHeight: 5, Bitcoin: &BitcoinStruct{TXID: "elsewhere"}}) {
+		t.Errorf("Record not taken")
+	}
+	if s.Pending() != 0 || s.Confirmed(5).Bitcoin.TXID != "elsewhere" {
+		t.Errorf("Recorded anchor still pending")
+	}
+}
+
+func TestSchedulerWrite(t *testing.T) {
+	f := &fakeAnchorer{status: map[string]AnchorStatus{}}
+	s := NewScheduler("Fake", f)
+	var written []*AnchorRecord
+	fail := true
+	s.Write = func(ar *AnchorRecord) error {
+		if fail {
+			return fmt.Errorf("No credits")
+		}
+		written = append(written, ar)
+		return nil
+	}
+
+	txid, _ := s.Anchor(6, primitives.Sha([]byte("dblock")))
+	f.status[txid] = AnchorConfirmed
+	if err := s.CheckConfirmations(); err == nil {
+		t.Errorf("No error writing the record")
+	}
+	if s.Confirmed(6) == nil {
+		t.Fatalf("Anchor not confirmed")
+	}
+
+	// Written again next time
+	fail = false
+	if err := s.CheckConfirmations(); err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 1 || written[0].DBHeight != 6 || written[0].Bitcoin.TXID != txid {
+		t.Errorf("Wrong records written %v", written)
+	}
+	if err := s.CheckConfirmations(); err != nil || len(written) != 1 {
+		t.Errorf("Record written twice")
+	}
+}
+
+// fakeInfo is a database of anchor infos.
+type fakeInfo map[uint32]*AnchorInfo
+
+func (f fakeInfo) FetchAnchorInfo(height uint32) (*AnchorInfo, error) {
+	return f[height], nil
+}
+
+func TestSchedulerRecordSaved(t *testing.T) {
+	s := NewScheduler("Fake", &fakeAnchorer{status: map[string]AnchorStatus{}})
+	db := fakeInfo{
+		1: {DBHeight: 1, KeyMR: "aa", KeyMRMatches: true, Bitcoin: &BitcoinStruct{TXID: "b1"}},
+		2: {DBHeight: 2, KeyMR: "bb", KeyMRMatches: false, Bitcoin: &BitcoinStruct{TXID: "b2"}},
+		3: {DBHeight: 3, KeyMR: "cc", KeyMRMatches: true, Ethereum: &EthereumStruct{TXID: "e3"}},
+	}
+	for height, expected := range map[uint32]bool{1: true, 2: false, 3: false, 4: false} {
+		ok, err := s.RecordSaved(db, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != expected || (s.Confirmed(height) != nil) != expected {
+			t.Errorf("Anchor at %d recorded %v, expected %v", height, ok, expected)
+		}
+	}
+	if s.Confirmed(1).KeyMR != "aa" || s.Confirmed(1).Bitcoin.TXID != "b1" {
+		t.Errorf("Wrong record %v", s.Confirmed(1))
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package anchor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// Gas price policies
+const (
+	GasPolicyNode  = "node"  // The price the Ethereum node suggests, up to EthereumGasPrice if set
+	GasPolicyFixed = "fixed" // Always EthereumGasPrice
+)
+
+// setAnchorSelector is the start of the keccak-256 hash of
+// "setAnchor(uint256,uint256)", which picks that method of the contract.
+const setAnchorSelector = "bbcc0c80"
+
+const (
+	DefaultEthereumGasLimit      = 100000
+	DefaultEthereumConfirmations = 12
+)
+
+// Config is the [Anchor] section of factomd.conf.  Bitcoin anchors are made
+// by the anchor service outside of factomd, so the Ethereum anchors run
+// alongside or instead of them.
+type Config struct {
+	EthereumEnabled       bool
+	EthereumRPC           string // URL of the JSON-RPC of the Ethereum node
+	EthereumAccount       string // Pays for the anchors; the node must hold it unlocked
+	EthereumContract      string // Address of the anchor contract
+	EthereumGasPolicy     string // node or fixed
+	EthereumGasPrice      uint64 // In wei
+	EthereumGasLimit      uint64
+	EthereumConfirmations int // Blocks deep an anchor must be to be confirmed
+
+	// The records of confirmed anchors are written to the anchor chain,
+	// signed by AnchorSigningKey, the hex private key of an anchor key, and
+	// paid for by EntryCreditKey, an Es private key; not written if either
+	// is empty
+	AnchorSigningKey string
+	EntryCreditKey   string
+
+	// JSON-RPC of the nodes the anchor records of the anchor chain are
+	// checked against as they are saved; empty not to check that chain
+	VerifyBitcoinRPC  string
+	VerifyEthereumRPC string
+}
+
+// EthereumAnchorer anchors the key merkle roots of directory blocks with
+// the setAnchor method of an Ethereum contract.  Transactions are signed by
+// the Ethereum node.
+type EthereumAnchorer struct {
+	Config Config
+	rpc    *rpcClient
+}
+
+var _ Anchorer = (*EthereumAnchorer)(nil)
+
+type ethereumReceipt struct {
+	TransactionHash  string `json:"transactionHash"`
+	TransactionIndex string `json:"transactionIndex"`
+	BlockHash        string `json:"blockHash"`
+	BlockNumber      string `json:"blockNumber"`
+	Status           string `json:"status"`
+}
+
+func NewEthereumAnchorer(c Config) *EthereumAnchorer {
+	if c.EthereumGasLimit == 0 {
+		c.EthereumGasLimit = DefaultEthereumGasLimit
+	}
+	if c.EthereumConfirmations <= 0 {
+		c.EthereumConfirmations = DefaultEthereumConfirmations
+	}
+	e := new(EthereumAnchorer)
+	e.Config = c
+	e.rpc = newRPCClient(c.EthereumRPC)
+	return e
+}
+
+// GasPrice returns the gas price to pay, in wei, by the gas policy.
+func (e *EthereumAnchorer) GasPrice() (uint64, error) {
+	switch e.Config.EthereumGasPolicy {
+	case GasPolicyNode, "":
+		price, err := e.rpc.callQuantity("eth_gasPrice", []interface{}{})
+		if err != nil {
+			return 0, err
+		}
+		if e.Config.EthereumGasPrice != 0 && price > e.Config.EthereumGasPrice {
+			price = e.Config.EthereumGasPrice
+		}
+		return price, nil
+	case GasPolicyFixed:
+		if e.Config.EthereumGasPrice == 0 {
+			return 0, fmt.Errorf("The fixed gas policy needs an EthereumGasPrice")
+		}
+		return e.Config.EthereumGasPrice, nil
+	}
+	return 0, fmt.Errorf("Unknown EthereumGasPolicy %q", e.Config.EthereumGasPolicy)
+}
+
+// SetAnchorData returns the call data of setAnchor(height, keyMR).
+func SetAnchorData(height uint32, keyMR interfaces.IHash) string {
+	return fmt.Sprintf("0x%s%064x%x", setAnchorSelector, height, keyMR.Bytes())
+}
+
+// SubmitAnchor sends the transaction that calls setAnchor(height, keyMR).
+func (e *EthereumAnchorer) SubmitAnchor(height uint32, keyMR interfaces.IHash) (string, error) {
+	price, err := e.GasPrice()
+	if err != nil {
+		return "", err
+	}
+	tx := map[string]string{
+		"from":     e.Config.EthereumAccount,
+		"to":       e.Config.EthereumContract,
+		"gas":      fmt.Sprintf("0x%x", e.Config.EthereumGasLimit),
+		"gasPrice": fmt.Sprintf("0x%x", price),
+		"data":     SetAnchorData(height, keyMR),
+	}
+	var txid string
+	err = e.rpc.call("eth_sendTransaction", []interface{}{tx}, &txid)
+	if err != nil {
+		return "", err
+	}
+	return txid, nil
+}
+
+// ConfirmAnchor looks up the receipt of the transaction.  It is confirmed
+// once it is EthereumConfirmations blocks deep.
+func (e *EthereumAnchorer) ConfirmAnchor(txid string, ar *AnchorRecord) (AnchorStatus, error) {
+	var receipt *ethereumReceipt
+	err := e.rpc.call("eth_getTransactionReceipt", []interface{}{txid}, &receipt)
+	if err != nil {
+		return AnchorPending, err
+	}
+	if receipt == nil { // Not yet mined
+		return AnchorPending, nil
+	}
+	if receipt.Status == "0x0" {
+		return AnchorFailed, nil
+	}
+	block, err := parseQuantity(receipt.BlockNumber)
+	if err != nil {
+		return AnchorPending, err
+	}
+	head, err := e.rpc.callQuantity("eth_blockNumber", []interface{}{})
+	if err != nil {
+		return AnchorPending, err
+	}
+	if head+1 < block+uint64(e.Config.EthereumConfirmations) {
+		return AnchorPending, nil
+	}
+	offset, err := parseQuantity(receipt.TransactionIndex)
+	if err != nil {
+		return AnchorPending, err
+	}
+
+	ar.Ethereum = new(EthereumStruct)
+	ar.Ethereum.Address = e.Config.EthereumContract
+	ar.Ethereum.TXID = receipt.TransactionHash
+	ar.Ethereum.BlockHeight = int64(block)
+	ar.Ethereum.BlockHash = receipt.BlockHash
+	ar.Ethereum.Offset = int64(offset)
+	return AnchorConfirmed, nil
+}
+
+// ParseAnchorRecord returns the Ethereum transaction of the record, if it
+// was made to the contract of the config.
+func (e *EthereumAnchorer) ParseAnchorRecord(ar *AnchorRecord) (string, bool) {
+	if ar.Ethereum == nil || !strings.EqualFold(ar.Ethereum.Address, e.Config.EthereumContract) {
+		return "", false
+	}
+	return ar.Ethereum.TXID, true
+}
@@ -0,0 +1,146 @@
+package anchor_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// fakeEthereumNode answers the JSON-RPC calls the anchor makes.
+type fakeEthereumNode struct {
+	mutex    sync.Mutex
+	head     uint64
+	gasPrice uint64
+	sent     []map[string]string
+	receipts map[string]map[string]string
+}
+
+func (n *fakeEthereumNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int               `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	var result interface{}
+	switch req.Method {
+	case "eth_gasPrice":
+		result = fmt.Sprintf("0x%x", n.gasPrice)
+	case "eth_blockNumber":
+		result = fmt.Sprintf("0x%x", n.head)
+	case "eth_sendTransaction":
+		tx := map[string]string{}
+		json.Unmarshal(req.Params[0], &tx)
+		n.sent = append(n.sent, tx)
+		result = fmt.Sprintf("0x%064x", len(n.sent))
+	case "eth_getTransactionReceipt":
+		var txid string
+		json.Unmarshal(req.Params[0], &txid)
+		if receipt, ok := n.receipts[txid]; ok {
+			result = receipt
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func TestEthereumAnchor(t *testing.T) {
+	node := &fakeEthereumNode{head: 100, gasPrice: 50, receipts: map[string]map[string]string{}}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	e := NewScheduler("Ethereum", NewEthereumAnchorer(Config{
+		EthereumRPC:       server.URL,
+		EthereumAccount:   "0x01",
+		EthereumContract:  "0x02",
+		EthereumGasPolicy: GasPolicyNode,
+		EthereumGasPrice:  40,
+	}))
+
+	keyMR := primitives.Sha([]byte("dblock"))
+	txid, err := e.Anchor(7, keyMR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := node.sent[0]
+	if sent["data"] != SetAnchorData(7, keyMR) || sent["to"] != "0x02" || sent["from"] != "0x01" {
+		t.Errorf("Wrong transaction sent %v", sent)
+	}
+	if sent["gasPrice"] != "0x28" || sent["gas"] != fmt.Sprintf("0x%x", DefaultEthereumGasLimit) {
+		t.Errorf("Wrong gas %v at %v, expected the price capped at 40", sent["gas"], sent["gasPrice"])
+	}
+
+	// Not yet mined, then mined but not deep enough
+	for _, mined := range []bool{false, true} {
+		if mined {
+			node.receipts[txid] = map[string]string{"transactionHash": txid, "transactionIndex": "0x3", "blockHash": "0xbb", "blockNumber": "0x5a", "status": "0x1"}
+		}
+		if err := e.CheckConfirmations(); err != nil {
+			t.Fatal(err)
+		}
+		if e.Confirmed(7) != nil || e.Pending() != 1 {
+			t.Errorf("Confirmed too soon")
+		}
+	}
+
+	node.head = 0x5a + DefaultEthereumConfirmations - 1
+	if err := e.CheckConfirmations(); err != nil {
+		t.Fatal(err)
+	}
+	ar := e.Confirmed(7)
+	if ar == nil || ar.Ethereum == nil || e.Pending() != 0 {
+		t.Fatalf("Not confirmed")
+	}
+	if ar.DBHeight != 7 || ar.KeyMR != keyMR.String() {
+		t.Errorf("Wrong record %v", ar)
+	}
+	anchor := ar.Ethereum
+	if anchor.TXID != txid || anchor.BlockHeight != 0x5a || anchor.BlockHash != "0xbb" || anchor.Offset != 3 || anchor.Address != "0x02" {
+		t.Errorf("Wrong anchor %+v", anchor)
+	}
+}
+
+func TestEthereumAnchorFailed(t *testing.T) {
+	node := &fakeEthereumNode{head: 100, receipts: map[string]map[string]string{}}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	e := NewScheduler("Ethereum", NewEthereumAnchorer(Config{EthereumRPC: server.URL, EthereumGasPolicy: GasPolicyFixed, EthereumGasPrice: 9}))
+	txid, err := e.Anchor(3, primitives.Sha([]byte("dblock")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.receipts[txid] = map[string]string{"transactionHash": txid, "transactionIndex": "0x0", "blockHash": "0xbb", "blockNumber": "0x10", "status": "0x0"}
+	if err := e.CheckConfirmations(); err != nil {
+		t.Fatal(err)
+	}
+	if len(node.sent) != 2 || node.sent[1]["data"] != node.sent[0]["data"] || node.sent[1]["gasPrice"] != "0x9" {
+		t.Errorf("Failed anchor not sent again %v", node.sent)
+	}
+	if e.Confirmed(3) != nil || e.Pending() != 1 {
+		t.Errorf("Failed anchor confirmed")
+	}
+
+	a := NewEthereumAnchorer(Config{EthereumRPC: server.URL, EthereumGasPolicy: GasPolicyFixed})
+	if _, err := a.GasPrice(); err == nil {
+		t.Errorf("No error on a fixed gas policy without a price")
+	}
+}
+
+func TestSetAnchorData(t *testing.T) {
+	keyMR, _ := primitives.HexToHash("980ab6d50d9fad574ad4df6dba06a8c02b1c67288ee5beab3fbfde2723f73ef6")
+	expected := "0xbbcc0c80" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"980ab6d50d9fad574ad4df6dba06a8c02b1c67288ee5beab3fbfde2723f73ef6"
+	if data := SetAnchorData(5, keyMR); data != expected {
+		t.Errorf("Call data is %s, expected %s", data, expected)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package anchor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rpcClient makes the JSON-RPC calls of an Ethereum or Bitcoin node.  A user
+// and password in the URL are sent as basic authentication.
+type rpcClient struct {
+	url    string
+	client *http.Client
+
+	mutex sync.Mutex
+	id    int
+}
+
+func newRPCClient(url string) *rpcClient {
+	c := new(rpcClient)
+	c.url = url
+	c.client = &http.Client{Timeout: 30 * time.Second}
+	return c
+}
+
+// call makes a JSON-RPC call, and decodes its result.
+func (c *rpcClient) call(method string, params []interface{}, result interface{}) error {
+	c.mutex.Lock()
+	c.id++
+	id := c.id
+	c.mutex.Unlock()
+
+	req, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Post(c.url, "application/json", bytes.NewReader(req))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var r struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return fmt.Errorf("%s: %s", method, err.Error())
+	}
+	if r.Error != nil {
+		return fmt.Errorf("%s: %s (%d)", method, r.Error.Message, r.Error.Code)
+	}
+	return json.Unmarshal(r.Result, result)
+}
+
+// callQuantity makes a JSON-RPC call of an Ethereum node that returns a
+// quantity.
+func (c *rpcClient) callQuantity(method string, params []interface{}) (uint64, error) {
+	var hex string
+	err := c.call(method, params, &hex)
+	if err != nil {
+		return 0, err
+	}
+	return parseQuantity(hex)
+}
+
+// parseQuantity parses a quantity of the Ethereum JSON-RPC, such as "0x1b4".
+func parseQuantity(hex string) (uint64, error) {
+	if !strings.HasPrefix(hex, "0x") {
+		return 0, fmt.Errorf("Quantity %q is not 0x hex", hex)
+	}
+	return strconv.ParseUint(hex[2:], 16, 64)
+}
@@ -0,0 +1,197 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package anchor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// Verifier looks up the anchors of anchor records in the chains they claim
+// to be in.  A chain without a node to ask is left unchecked.  The heights
+// of the records still to look up are queued in the verifier, so that they
+// can be looked up in the background, and again if a node can't be asked.
+type Verifier struct {
+	bitcoin  *rpcClient
+	ethereum *rpcClient
+
+	mutex   sync.Mutex
+	pending map[uint32]int // Heights queued, and the lookups made of each
+}
+
+// VerifyAttempts is how many times anchors not found in their chains are
+// looked up, in case the node asked is behind, before they are left
+// unverified.
+var VerifyAttempts = 10
+
+// NewVerifier returns the verifier of the VerifyBitcoinRPC and
+// VerifyEthereumRPC of the config, or nil if neither is set.
+func NewVerifier(c Config) *Verifier {
+	if c.VerifyBitcoinRPC == "" && c.VerifyEthereumRPC == "" {
+		return nil
+	}
+	v := new(Verifier)
+	v.pending = make(map[uint32]int)
+	if c.VerifyBitcoinRPC != "" {
+		v.bitcoin = newRPCClient(c.VerifyBitcoinRPC)
+	}
+	if c.VerifyEthereumRPC != "" {
+		v.ethereum = newRPCClient(c.VerifyEthereumRPC)
+	}
+	return v
+}
+
+// BitcoinOpReturn returns the hex of the OP_RETURN output script that anchors
+// a directory block in Bitcoin: "FA", the height in six bytes, and the key
+// merkle root.
+func BitcoinOpReturn(height uint32, keyMR interfaces.IHash) string {
+	return fmt.Sprintf("6a28%x%012x%x", "FA", height, keyMR.Bytes())
+}
+
+// Verify looks up the anchors of the info that are not yet verified, and
+// marks those that are in the blocks they claim, and anchor the info's key
+// merkle root.  The others are left unverified; an error is only returned
+// if a node couldn't be asked.
+func (v *Verifier) Verify(info *AnchorInfo) error {
+	keyMR, err := primitives.HexToHash(info.KeyMR)
+	if err != nil {
+		return err
+	}
+	if info.Bitcoin != nil && !info.BitcoinVerified && v.bitcoin != nil {
+		info.BitcoinVerified, err = v.verifyBitcoin(info.DBHeight, keyMR, info.Bitcoin)
+		if err != nil {
+			return err
+		}
+	}
+	if info.Ethereum != nil && !info.EthereumVerified && v.ethereum != nil {
+		info.EthereumVerified, err = v.verifyEthereum(info.DBHeight, keyMR, info.Ethereum)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verifiable returns true if the info matches its directory block, and has
+// anchors not yet verified in a chain with a node to ask.
+func (v *Verifier) Verifiable(info *AnchorInfo) bool {
+	if !info.KeyMRMatches {
+		return false
+	}
+	return (info.Bitcoin != nil && !info.BitcoinVerified && v.bitcoin != nil) ||
+		(info.Ethereum != nil && !info.EthereumVerified && v.ethereum != nil)
+}
+
+// Queue adds the height of a record to look up.
+func (v *Verifier) Queue(height uint32) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.pending[height] = 0
+}
+
+// Pending returns the heights queued, lowest first.
+func (v *Verifier) Pending() []uint32 {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	heights := make([]uint32, 0, len(v.pending))
+	for h := range v.pending {
+		heights = append(heights, h)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+// Tried records a lookup of the record at the height.  The height leaves
+// the queue once the record is done, or after VerifyAttempts lookups.
+func (v *Verifier) Tried(height uint32, done bool) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if _, ok := v.pending[height]; !ok {
+		return
+	}
+	v.pending[height]++
+	if done || v.pending[height] >= VerifyAttempts {
+		delete(v.pending, height)
+	}
+}
+
+// Confirmations returns how many blocks deep the Bitcoin and Ethereum anchors
+// of the info are, or 0 for a chain the info has no anchor in, or with no
+// node to ask.
+func (v *Verifier) Confirmations(info *AnchorInfo) (bitcoin int64, ethereum int64, err error) {
+	if info.Bitcoin != nil && v.bitcoin != nil {
+		var head int64
+		err = v.bitcoin.call("getblockcount", []interface{}{}, &head)
+		if err != nil {
+			return 0, 0, err
+		}
+		bitcoin = depth(head, int64(info.Bitcoin.BlockHeight))
+	}
+	if info.Ethereum != nil && v.ethereum != nil {
+		head, err := v.ethereum.callQuantity("eth_blockNumber", []interface{}{})
+		if err != nil {
+			return 0, 0, err
+		}
+		ethereum = depth(int64(head), info.Ethereum.BlockHeight)
+	}
+	return bitcoin, ethereum, nil
+}
+
+// depth returns the confirmations of a block at the height, with the chain
+// at the head.
+func depth(head, height int64) int64 {
+	if head < height {
+		return 0
+	}
+	return head - height + 1
+}
+
+func (v *Verifier) verifyBitcoin(height uint32, keyMR interfaces.IHash, b *BitcoinStruct) (bool, error) {
+	var tx *struct {
+		BlockHash string `json:"blockhash"`
+		Vout      []struct {
+			ScriptPubKey struct {
+				Hex string `json:"hex"`
+			} `json:"scriptPubKey"`
+		} `json:"vout"`
+	}
+	err := v.bitcoin.call("getrawtransaction", []interface{}{b.TXID, true}, &tx)
+	if err != nil {
+		return false, err
+	}
+	if tx == nil || tx.BlockHash != b.BlockHash {
+		return false, nil
+	}
+	script := BitcoinOpReturn(height, keyMR)
+	for _, out := range tx.Vout {
+		if out.ScriptPubKey.Hex == script {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (v *Verifier) verifyEthereum(height uint32, keyMR interfaces.IHash, e *EthereumStruct) (bool, error) {
+	var tx *struct {
+		To        string `json:"to"`
+		Input     string `json:"input"`
+		BlockHash string `json:"blockHash"`
+	}
+	err := v.ethereum.call("eth_getTransactionByHash", []interface{}{e.TXID}, &tx)
+	if err != nil {
+		return false, err
+	}
+	if tx == nil {
+		return false, nil
+	}
+	return strings.EqualFold(tx.To, e.Address) &&
+		strings.EqualFold(tx.BlockHash, e.BlockHash) &&
+		strings.EqualFold(tx.Input, SetAnchorData(height, keyMR)), nil
+}
@@ -0,0 +1,161 @@
+package anchor_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// fakeNode answers a JSON-RPC method with the result for the first param, or
+// for "" if it has none.
+func fakeNode(method string, results map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int           `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var result interface{}
+		if req.Method == method {
+			key := ""
+			if len(req.Params) > 0 {
+				key = req.Params[0].(string)
+			}
+			result = results[key]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "result": result})
+	}))
+}
+
+func TestVerifier(t *testing.T) {
+	keyMR := primitives.Sha([]byte("dblock"))
+
+	bitcoin := fakeNode("getrawtransaction", map[string]interface{}{
+		"aa": map[string]interface{}{"blockhash": "b1", "vout": []interface{}{
+			map[string]interface{}{"scriptPubKey": map[string]interface{}{"hex": "76a914"}},
+			map[string]interface{}{"scriptPubKey": map[string]interface{}{"hex": BitcoinOpReturn(7, keyMR)}},
+		}},
+	})
+	defer bitcoin.Close()
+	ethereum := fakeNode("eth_getTransactionByHash", map[string]interface{}{
+		"0xee": map[string]interface{}{"to": "0xC0", "blockHash": "0xb2", "input": SetAnchorData(7, keyMR)},
+	})
+	defer ethereum.Close()
+
+	if NewVerifier(Config{}) != nil {
+		t.Errorf("A verifier without nodes")
+	}
+	v := NewVerifier(Config{VerifyBitcoinRPC: bitcoin.URL, VerifyEthereumRPC: ethereum.URL})
+
+	info := &AnchorInfo{
+		DBHeight: 7,
+		KeyMR:    keyMR.String(),
+		Bitcoin:  &BitcoinStruct{TXID: "aa", BlockHash: "b1"},
+		Ethereum: &EthereumStruct{TXID: "0xee", BlockHash: "0xB2", Address: "0xc0"},
+	}
+	if err := v.Verify(info); err != nil {
+		t.Fatal(err)
+	}
+	if !info.BitcoinVerified || !info.EthereumVerified {
+		t.Errorf("Anchors not verified: %v", info)
+	}
+
+	// Anchors of another height, in another block, or not found
+	for _, info := range []*AnchorInfo{
+		{DBHeight: 8, KeyMR: keyMR.String(), Bitcoin: &BitcoinStruct{TXID: "aa", BlockHash: "b1"}, Ethereum: &EthereumStruct{TXID: "0xee", BlockHash: "0xb2", Address: "0xc0"}},
+		{DBHeight: 7, KeyMR: keyMR.String(), Bitcoin: &BitcoinStruct{TXID: "aa", BlockHash: "b9"}, Ethereum: &EthereumStruct{TXID: "0xee", BlockHash: "0xb9", Address: "0xc0"}},
+		{DBHeight: 7, KeyMR: keyMR.String(), Bitcoin: &BitcoinStruct{TXID: "bb", BlockHash: "b1"}, Ethereum: &EthereumStruct{TXID: "0xff", BlockHash: "0xb2", Address: "0xc0"}},
+	} {
+		if err := v.Verify(info); err != nil {
+			t.Fatal(err)
+		}
+		if info.BitcoinVerified || info.EthereumVerified {
+			t.Errorf("Bad anchors verified: %v", info)
+		}
+	}
+}
+
+func TestConfirmations(t *testing.T) {
+	bitcoin := fakeNode("getblockcount", map[string]interface{}{"": 110})
+	defer bitcoin.Close()
+	v := NewVerifier(Config{VerifyBitcoinRPC: bitcoin.URL})
+
+	info := &AnchorInfo{Bitcoin: &BitcoinStruct{BlockHeight: 101}, Ethereum: &EthereumStruct{BlockHeight: 5}}
+	btc, eth, err := v.Confirmations(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if btc != 10 || eth != 0 {
+		t.Errorf("Confirmations are %d and %d, expected 10 and 0 without an Ethereum node", btc, eth)
+	}
+}
+
+func TestAnchorInfoAdd(t *testing.T) {
+	info := new(AnchorInfo)
+	if !info.Add(&AnchorRecord{DBHeight: 3, KeyMR: "aa", Bitcoin: &BitcoinStruct{TXID: "1"}}) {
+		t.Errorf("Record left out")
+	}
+	info.BitcoinVerified = true
+	info.Add(&AnchorRecord{DBHeight: 3, KeyMR: "aa", Ethereum: &EthereumStruct{TXID: "2"}})
+	if info.Bitcoin == nil || !info.BitcoinVerified || info.Ethereum == nil {
+		t.Errorf("Anchors not gathered: %v", info)
+	}
+
+	// A record of another keyMR replaces one not known to match
+	info.Add(&AnchorRecord{DBHeight: 3, KeyMR: "bb", Bitcoin: &BitcoinStruct{TXID: "3"}})
+	if info.KeyMR != "bb" || info.Ethereum != nil || info.BitcoinVerified || info.Bitcoin.TXID != "3" {
+		t.Errorf("Anchors not replaced: %v", info)
+	}
+	info.KeyMRMatches = true
+	if info.Add(&AnchorRecord{DBHeight: 3, KeyMR: "cc"}) || info.KeyMR != "bb" {
+		t.Errorf("Anchors of a matching keyMR replaced: %v", info)
+	}
+
+	data, err := info.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info2 := new(AnchorInfo)
+	if err := info2.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if info2.String() != info.String() {
+		t.Errorf("Infos differ: %v and %v", info, info2)
+	}
+}
+
+func TestVerifierQueue(t *testing.T) {
+	defer func(n int) { VerifyAttempts = n }(VerifyAttempts)
+	VerifyAttempts = 2
+	v := NewVerifier(Config{VerifyBitcoinRPC: "http://localhost:1"})
+
+	info := &AnchorInfo{DBHeight: 5, KeyMRMatches: true, Bitcoin: &BitcoinStruct{TXID: "aa"}}
+	if !v.Verifiable(info) {
+		t.Errorf("Unverified anchors not verifiable")
+	}
+	info.Bitcoin, info.Ethereum = nil, &EthereumStruct{TXID: "0xee"}
+	if v.Verifiable(info) {
+		t.Errorf("An Ethereum anchor verifiable without an Ethereum node")
+	}
+
+	v.Queue(9)
+	v.Queue(5)
+	v.Queue(7)
+	if p := v.Pending(); len(p) != 3 || p[0] != 5 || p[1] != 7 || p[2] != 9 {
+		t.Errorf("Pending %v, expected [5 7 9]", p)
+	}
+	v.Tried(5, true)
+	v.Tried(7, false)
+	if p := v.Pending(); len(p) != 2 || p[0] != 7 {
+		t.Errorf("Pending %v, expected [7 9]", p)
+	}
+	v.Tried(7, false)
+	if p := v.Pending(); len(p) != 1 || p[0] != 9 {
+		t.Errorf("Pending %v, expected 7 dropped after %d attempts", p, VerifyAttempts)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package adminBlock
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// CoinbaseCancel strikes a coinbase payout before it matures.  The payout
+// is named by the height of the block whose coinbase pays it, and its index
+// among the outputs of that coinbase.  A payout that has already matured, or
+// was already struck, is left alone.
+type CoinbaseCancel struct {
+	DescriptorHeight uint32
+	DescriptorIndex  uint32
+}
+
+var _ interfaces.IABEntry = (*CoinbaseCancel)(nil)
+var _ interfaces.BinaryMarshallable = (*CoinbaseCancel)(nil)
+
+func NewCoinbaseCancel(descriptorHeight uint32, descriptorIndex uint32) (e *CoinbaseCancel) {
+	e = new(CoinbaseCancel)
+	e.DescriptorHeight = descriptorHeight
+	e.DescriptorIndex = descriptorIndex
+	return
+}
+
+func (e *CoinbaseCancel) UpdateState(state interfaces.IState) error {
+	if state.GetFactoidState().CancelCoinbasePayout(e.DescriptorHeight, e.DescriptorIndex) {
+		state.AddStatus(fmt.Sprintf("AdminBlock: Cancelled coinbase payout %d of block %d", e.DescriptorIndex, e.DescriptorHeight))
+	}
+	return nil
+}
+
+func (e *CoinbaseCancel) Type() byte {
+	return constants.TYPE_COINBASE_CANCEL
+}
+
+func (e *CoinbaseCancel) MarshalBinary() ([]byte, error) {
+	var buf primitives.Buffer
+
+	err := buf.PushByte(e.Type())
+	if err != nil {
+		return nil, err
+	}
+	err = buf.PushUInt32(e.DescriptorHeight)
+	if err != nil {
+		return nil, err
+	}
+	err = buf.PushUInt32(e.DescriptorIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.DeepCopyBytes(), nil
+}
+
+func (e *CoinbaseCancel) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	buf := primitives.NewBuffer(data)
+	b, err := buf.PopByte()
+	if err != nil {
+		return nil, err
+	}
+	if b != e.Type() {
+		return nil, fmt.Errorf("Invalid Entry type")
+	}
+
+	e.DescriptorHeight, err = buf.PopUInt32()
+	if err != nil {
+		return nil, err
+	}
+	e.DescriptorIndex, err = buf.PopUInt32()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.DeepCopyBytes(), nil
+}
+
+func (e *CoinbaseCancel) UnmarshalBinary(data []byte) (err error) {
+	_, err = e.UnmarshalBinaryData(data)
+	return
+}
+
+func (e *CoinbaseCancel) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(e)
+}
+
+func (e *CoinbaseCancel) JSONString() (string, error) {
+	return primitives.EncodeJSONString(e)
+}
+
+func (e *CoinbaseCancel) String() string {
+	str := fmt.Sprintf("    E: %35s -- height %d index %d", "Coinbase Cancel", e.DescriptorHeight, e.DescriptorIndex)
+	return str
+}
+
+func (e *CoinbaseCancel) IsInterpretable() bool {
+	return false
+}
+
+func (e *CoinbaseCancel) Interpret() string {
+	return ""
+}
+
+func (e *CoinbaseCancel) Hash() interfaces.IHash {
+	bin, err := e.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	return primitives.Sha(bin)
+}
@@ -0,0 +1,64 @@
+package adminBlock_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/constants"
+)
+
+func TestCoinbaseCancelMarshalUnmarshal(t *testing.T) {
+	a := NewCoinbaseCancel(1234, 5)
+	b, err := a.MarshalBinary()
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if b[0] != a.Type() {
+		t.Errorf("Invalid byte marshalled")
+	}
+
+	a2 := new(CoinbaseCancel)
+	rest, err := a2.UnmarshalBinaryData(b)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("Unexpected extra piece of data - %x", rest)
+	}
+	if a2.DescriptorHeight != 1234 || a2.DescriptorIndex != 5 {
+		t.Errorf("Wrong values unmarshalled - %d %d", a2.DescriptorHeight, a2.DescriptorIndex)
+	}
+
+	err = a2.UnmarshalBinary(b[:5])
+	if err == nil {
+		t.Errorf("No error on short data")
+	}
+	b[0] = (b[0] + 1) % 255
+	err = a2.UnmarshalBinary(b)
+	if err == nil {
+		t.Errorf("No error caught")
+	}
+}
+
+func TestCoinbaseCancelActivation(t *testing.T) {
+	defer constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+
+	ab := new(AdminBlock)
+	ab.Init()
+	ab.Header.SetDBHeight(10)
+	if err := ab.AddEntry(NewCoinbaseCancel(5, 0)); err != nil {
+		t.Fatalf("%v", err)
+	}
+	data, err := ab.MarshalBinary()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if _, err := UnmarshalABlock(data); err != nil {
+		t.Errorf("%v", err)
+	}
+	constants.SetActivationNetwork(constants.NETWORK_MAIN)
+	if _, err := UnmarshalABlock(data); err == nil {
+		t.Errorf("Took a coinbase cancel from a block before its activation")
+	}
+}
@@ -388,6 +388,13 @@ func (b *AdminBlock) UnmarshalBinaryData(data []byte) ([]byte, error) {
 			b.ABEntries[i] = new(AddFederatedServerBitcoinAnchorKey)
 		case constants.TYPE_SERVER_FAULT:
 			b.ABEntries[i] = new(ServerFault)
+		case constants.TYPE_COINBASE_CANCEL:
+			// Nodes older than the activation panic on the type, so none is
+			// taken from a block before it
+			if !constants.IsActive(constants.ACTIVATION_COINBASE_MATURITY, b.GetHeader().GetDBHeight()) {
+				return nil, fmt.Errorf("Coinbase cancel in admin block %d, before its activation", b.GetHeader().GetDBHeight())
+			}
+			b.ABEntries[i] = new(CoinbaseCancel)
 		default:
 			fmt.Printf("AB UNDEFINED ENTRY %x for block %v\n", t, b.GetHeader().GetDBHeight())
 			panic("Undefined Admin Block Entry Type")
@@ -0,0 +1,61 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package constants
+
+import (
+	"sync"
+)
+
+// An Activation is a change to the rules of consensus.  It takes effect at a
+// directory block height, the same on every node of a network, so that the
+// nodes running the new code agree with each other, and with the blocks the
+// old code built, until the height is reached.  Each network has a height of
+// its own for each activation.  Those not yet scheduled on MAIN and TEST are
+// at NEVER_ACTIVE; LOCAL and CUSTOM networks have them from their first block.
+type Activation int
+
+const (
+	ACTIVATION_COINBASE_MATURITY Activation = iota // Coinbase payouts mature, and may be cancelled in the admin block
+	ACTIVATION_VM_INDEX                            // Chains go to VMs by a seeded hash, and acks in the wrong VM are tossed
+	ACTIVATION_MULTISIG_RCD                        // Funds may be spent from type 2 (multisig) RCDs
+	ACTIVATION_EC_PURCHASE                         // Entry credits are bought at the rate of the factoid block, and recorded in the EC block
+	ACTIVATION_FBLOCK_VALIDATION                   // Factoid blocks with any invalid transaction are rejected
+)
+
+// NEVER_ACTIVE is the height of an activation not yet scheduled.
+const NEVER_ACTIVE = ^uint32(0)
+
+var activationHeights = map[Activation]map[int]uint32{
+	ACTIVATION_COINBASE_MATURITY: {NETWORK_MAIN: NEVER_ACTIVE, NETWORK_TEST: NEVER_ACTIVE},
+	ACTIVATION_VM_INDEX:          {NETWORK_MAIN: NEVER_ACTIVE, NETWORK_TEST: NEVER_ACTIVE},
+	ACTIVATION_MULTISIG_RCD:      {NETWORK_MAIN: NEVER_ACTIVE, NETWORK_TEST: NEVER_ACTIVE},
+	ACTIVATION_EC_PURCHASE:       {NETWORK_MAIN: NEVER_ACTIVE, NETWORK_TEST: NEVER_ACTIVE},
+	ACTIVATION_FBLOCK_VALIDATION: {NETWORK_MAIN: NEVER_ACTIVE, NETWORK_TEST: NEVER_ACTIVE},
+}
+
+var activationNetwork = NETWORK_LOCAL
+var activationMutex sync.RWMutex
+
+// SetActivationNetwork sets the network, NETWORK_MAIN, NETWORK_TEST,
+// NETWORK_LOCAL or NETWORK_CUSTOM, whose activation heights are used.
+func SetActivationNetwork(number int) {
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+	activationNetwork = number
+}
+
+// ActivationHeight returns the height at which the activation takes effect on
+// the network.
+func ActivationHeight(a Activation) uint32 {
+	activationMutex.RLock()
+	defer activationMutex.RUnlock()
+	return activationHeights[a][activationNetwork] // Missing networks are 0
+}
+
+// IsActive returns true if the activation is in effect in the directory
+// block at the height.
+func IsActive(a Activation, height uint32) bool {
+	return height >= ActivationHeight(a)
+}
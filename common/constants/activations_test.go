@@ -0,0 +1,20 @@
+package constants_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/common/constants"
+)
+
+func TestActivations(t *testing.T) {
+	defer SetActivationNetwork(NETWORK_LOCAL)
+
+	SetActivationNetwork(NETWORK_LOCAL)
+	if !IsActive(ACTIVATION_COINBASE_MATURITY, 0) {
+		t.Errorf("Coinbase maturity is not active on LOCAL")
+	}
+	SetActivationNetwork(NETWORK_MAIN)
+	if IsActive(ACTIVATION_COINBASE_MATURITY, 1000000) {
+		t.Errorf("Coinbase maturity is active on MAIN before it is scheduled")
+	}
+}
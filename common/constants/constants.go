@@ -43,9 +43,13 @@ const (
 
 	MISSING_ENTRY_BLOCKS //27
 	ENTRY_BLOCK_RESPONSE //28
+
+	STATE_SNAPSHOT_REQUEST  //29
+	STATE_SNAPSHOT_RESPONSE //30
+	ACK_BATCH_MSG           //31
 )
 
-const NUM_MESSAGES = 29
+const NUM_MESSAGES = 32
 
 const (
 	// Limits for keeping inputs from flooding our execution
@@ -82,6 +86,7 @@ const (
 	MARKER                  = 0x00                       // Byte used to mark minute boundries in Factoid blocks
 	TRANSACTION_PRIOR_LIMIT = int64(12 * 60 * 60 * 1000) // Transactions prior to 12hrs before a block are invalid
 	TRANSACTION_POST_LIMIT  = int64(12 * 60 * 60 * 1000) // Transactions after 12hrs following a block are invalid
+	COINBASE_MATURITY       = uint32(1000)               // Directory blocks before a coinbase payout can be spent
 
 	//Entry Credit Blocks (For now, everyone gets the same cap)
 	EC_CAP = 5 //Number of ECBlocks we start with.
@@ -157,6 +162,7 @@ const (
 	TYPE_ADD_FED_SERVER_KEY              // 8
 	TYPE_ADD_BTC_ANCHOR_KEY              // 9
 	TYPE_SERVER_FAULT
+	TYPE_COINBASE_CANCEL
 )
 
 //---------------------------------------------------------------------
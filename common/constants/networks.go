@@ -0,0 +1,113 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package constants
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// The ids of the p2p networks, in the header of each parcel
+const (
+	MAIN_P2P_NETWORK_ID  uint32 = 0xfeedbeef
+	TEST_P2P_NETWORK_ID  uint32 = 0xdeadbeef
+	LOCAL_P2P_NETWORK_ID uint32 = 0xbeaded
+)
+
+// NetworkDefaults are what a network name stands for: the ids of the
+// network, and the ports and seed its nodes use unless factomd.conf or the
+// command line says otherwise.
+type NetworkDefaults struct {
+	Name         string
+	Number       int    // NETWORK_MAIN, NETWORK_TEST, NETWORK_LOCAL or NETWORK_CUSTOM
+	NetworkID    uint32 // In the header of the directory blocks; 0 for a custom network, which takes it from -customnet
+	P2PNetworkID uint32 // Likewise, in the header of the parcels
+	P2PPort      string
+	APIPort      int
+	SeedURL      string
+	SpecialPeers string
+}
+
+const seedURLPrefix = "https://raw.githubusercontent.com/FactomProject/factomproject.github.io/master/seed/"
+
+var networks = map[string]*NetworkDefaults{
+	"MAIN": {
+		Name:         "MAIN",
+		Number:       NETWORK_MAIN,
+		NetworkID:    MAIN_NETWORK_ID,
+		P2PNetworkID: MAIN_P2P_NETWORK_ID,
+		P2PPort:      "8108",
+		APIPort:      8088,
+		SeedURL:      seedURLPrefix + "mainseed.txt",
+	},
+	"TEST": {
+		Name:         "TEST",
+		Number:       NETWORK_TEST,
+		NetworkID:    TEST_NETWORK_ID,
+		P2PNetworkID: TEST_P2P_NETWORK_ID,
+		P2PPort:      "8109",
+		APIPort:      8088,
+		SeedURL:      seedURLPrefix + "testseed.txt",
+	},
+	"LOCAL": {
+		Name:         "LOCAL",
+		Number:       NETWORK_LOCAL,
+		NetworkID:    LOCAL_NETWORK_ID,
+		P2PNetworkID: LOCAL_P2P_NETWORK_ID,
+		P2PPort:      "8110",
+		APIPort:      8088,
+		SeedURL:      seedURLPrefix + "localseed.txt",
+	},
+	"CUSTOM": {
+		Name:    "CUSTOM",
+		Number:  NETWORK_CUSTOM,
+		P2PPort: "8110",
+		APIPort: 8088,
+		SeedURL: seedURLPrefix + "localseed.txt",
+	},
+}
+var networksMutex sync.RWMutex
+
+// LookupNetwork returns the defaults of the network of the name, in any
+// case, or nil if the name is not known.  Custom networks are known by the
+// -customnet name they were registered with.
+func LookupNetwork(name string) *NetworkDefaults {
+	networksMutex.RLock()
+	defer networksMutex.RUnlock()
+	n, ok := networks[strings.ToUpper(name)]
+	if !ok {
+		return nil
+	}
+	c := *n
+	return &c
+}
+
+// RegisterNetwork adds a custom network, known by its -customnet name, or
+// replaces the defaults of one.  The settings left empty are those of
+// CUSTOM.  The built in networks cannot be replaced.
+func RegisterNetwork(n NetworkDefaults) error {
+	networksMutex.Lock()
+	defer networksMutex.Unlock()
+	switch strings.ToUpper(n.Name) {
+	case "MAIN", "TEST", "LOCAL", "CUSTOM", "":
+		return fmt.Errorf("%q cannot be registered as a custom network", n.Name)
+	}
+	custom := networks["CUSTOM"]
+	n.Number = NETWORK_CUSTOM
+	n.NetworkID = 0
+	n.P2PNetworkID = 0
+	if n.P2PPort == "" {
+		n.P2PPort = custom.P2PPort
+	}
+	if n.APIPort == 0 {
+		n.APIPort = custom.APIPort
+	}
+	if n.SeedURL == "" {
+		n.SeedURL = custom.SeedURL
+	}
+	networks[strings.ToUpper(n.Name)] = &n
+	return nil
+}
@@ -0,0 +1,30 @@
+package constants_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/common/constants"
+)
+
+func TestNetworks(t *testing.T) {
+	main := LookupNetwork("main")
+	if main == nil || main.NetworkID != MAIN_NETWORK_ID || main.P2PPort != "8108" || main.Number != NETWORK_MAIN {
+		t.Errorf("Wrong MAIN network %+v", main)
+	}
+	if LookupNetwork("unknown") != nil {
+		t.Errorf("Found an unknown network")
+	}
+
+	if err := RegisterNetwork(NetworkDefaults{Name: "MAIN", P2PPort: "9000"}); err == nil {
+		t.Errorf("MAIN was replaced")
+	}
+	if err := RegisterNetwork(NetworkDefaults{Name: "private", SpecialPeers: "10.0.0.1:8110"}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	private := LookupNetwork("PRIVATE")
+	custom := LookupNetwork("CUSTOM")
+	if private == nil || private.Number != NETWORK_CUSTOM || private.P2PPort != custom.P2PPort ||
+		private.SeedURL != custom.SeedURL || private.SpecialPeers != "10.0.0.1:8110" {
+		t.Errorf("Wrong private network %+v", private)
+	}
+}
@@ -96,7 +96,10 @@ func (e *EBlockBody) AddEndOfMinuteMarker(m byte) {
 	}
 
 	// check if the previous entry is a minute marker and return without
-	// writing if it is
+	// writing if it is.  An empty body has nothing to mark.
+	if len(e.EBEntries) == 0 {
+		return
+	}
 	prevEntry := e.EBEntries[len(e.EBEntries)-1]
 	if _, exist := mins[prevEntry.String()]; exist {
 		return
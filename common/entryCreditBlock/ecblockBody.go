@@ -48,6 +48,19 @@ func (e *ECBlockBody) AddEntry(entry interfaces.IECBlockEntry) {
 	e.Entries = append(e.Entries, entry)
 }
 
+// AddEndOfMinuteMarker adds a minute number entry to the body, marking the
+// end of minute m.  If the last entry is already the marker for m, nothing is
+// added, so processing the same End of Minute twice is harmless.
+func (e *ECBlockBody) AddEndOfMinuteMarker(m byte) {
+	if len(e.Entries) > 0 {
+		last, ok := e.Entries[len(e.Entries)-1].(*MinuteNumber)
+		if ok && last.Number == m {
+			return
+		}
+	}
+	e.AddEntry(NewMinuteNumber(m))
+}
+
 func (e *ECBlockBody) SetEntries(entries []interfaces.IECBlockEntry) {
 	e.Entries = entries
 }
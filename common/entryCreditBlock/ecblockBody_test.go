@@ -5,7 +5,29 @@
 package entryCreditBlock_test
 
 import (
-//"testing"
+	"testing"
 
-//. "github.com/FactomProject/factomd/common/entryCreditBlock"
+	. "github.com/FactomProject/factomd/common/entryCreditBlock"
 )
+
+func TestAddEndOfMinuteMarker(t *testing.T) {
+	body := NewECBlockBody()
+
+	body.AddEndOfMinuteMarker(1)
+	body.AddEndOfMinuteMarker(1)
+	body.AddEndOfMinuteMarker(2)
+
+	entries := body.GetEntries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", len(entries))
+	}
+	for i, e := range entries {
+		mn, ok := e.(*MinuteNumber)
+		if !ok {
+			t.Fatalf("Entry %v is not a minute number", i)
+		}
+		if int(mn.Number) != i+1 {
+			t.Errorf("Invalid minute number %v at %v", mn.Number, i)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factoid
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// SignatureWorkers is how many goroutines check the signatures of a block.
+var SignatureWorkers = runtime.NumCPU()
+
+// ValidateSignaturesConcurrently checks the signatures of the transactions of
+// a block, past the coinbase, handing them out to a pool of SignatureWorkers
+// goroutines.  If any fail, the error of the first of them in the block is
+// returned, so the result doesn't depend on which worker got there first.
+func ValidateSignaturesConcurrently(transactions []interfaces.ITransaction) error {
+	if len(transactions) < 2 {
+		return nil
+	}
+	workers := SignatureWorkers
+	if workers > len(transactions)-1 {
+		workers = len(transactions) - 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	errs := make([]error, len(transactions))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = transactions[i].ValidateSignatures()
+			}
+		}()
+	}
+	for i := 1; i < len(transactions); i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("Transaction %d of the block: %s", i, err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package factoid_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestValidateSignaturesConcurrently(t *testing.T) {
+	defer func(w int) { factoid.SignatureWorkers = w }(factoid.SignatureWorkers)
+
+	coinbase := new(factoid.Transaction)
+	coinbase.AddOutput(testHelper.NewFactoidAddress(0), 1000)
+	transactions := []interfaces.ITransaction{coinbase}
+	for i := uint64(1); i < 20; i++ {
+		tx := new(factoid.Transaction)
+		tx.AddInput(testHelper.NewFactoidAddress(i), 1000)
+		tx.AddOutput(testHelper.NewFactoidAddress(0), 1000)
+		testHelper.SignFactoidTransaction(i, tx)
+		transactions = append(transactions, tx)
+	}
+
+	for _, workers := range []int{0, 1, 4, 100} {
+		factoid.SignatureWorkers = workers
+		if err := factoid.ValidateSignaturesConcurrently(transactions); err != nil {
+			t.Errorf("%d workers: %v", workers, err)
+		}
+	}
+
+	// Change two transactions after they were signed
+	transactions[7].AddOutput(testHelper.NewFactoidAddress(0), 1)
+	transactions[12].AddOutput(testHelper.NewFactoidAddress(0), 1)
+	for _, workers := range []int{1, 4} {
+		factoid.SignatureWorkers = workers
+		err := factoid.ValidateSignaturesConcurrently(transactions)
+		if err == nil {
+			t.Errorf("%d workers: no error on bad signatures", workers)
+		} else if err.Error() != "Transaction 7 of the block: Missing 1 of 1 signatures" {
+			t.Errorf("%d workers: wrong error %v", workers, err)
+		}
+	}
+
+	if err := factoid.ValidateSignaturesConcurrently(transactions[:1]); err != nil {
+		t.Errorf("%v", err)
+	}
+}
@@ -485,6 +485,12 @@ func (b *FBlock) GetExchRate() uint64 {
 }
 
 func (b FBlock) ValidateTransaction(index int, trans interfaces.ITransaction) error {
+	return b.validateTransaction(index, trans, true)
+}
+
+// validateTransaction is ValidateTransaction, leaving out the signatures if
+// they have been checked already.
+func (b FBlock) validateTransaction(index int, trans interfaces.ITransaction, checkSigs bool) error {
 	// Calculate the fee due.
 	{
 		err := trans.Validate(index)
@@ -492,9 +498,12 @@ func (b FBlock) ValidateTransaction(index int, trans interfaces.ITransaction) er
 			return err
 		}
 	}
+	if err := CheckRCDsActive(trans, b.DBHeight); err != nil {
+		return err
+	}
 
 	//Ignore coinbase transaction's signatures
-	if len(b.Transactions) > 0 {
+	if checkSigs && len(b.Transactions) > 0 {
 		err := trans.ValidateSignatures()
 		if err != nil {
 			return err
@@ -533,7 +542,51 @@ func (b FBlock) ValidateTransaction(index int, trans interfaces.ITransaction) er
 	return nil
 }
 
+// Validate returns an error if any transaction of the block is invalid, or
+// the Merkle root of its body doesn't match them.  Before
+// ACTIVATION_FBLOCK_VALIDATION, blocks are validated as they always were, so
+// the blocks already built are still accepted.
 func (b FBlock) Validate() error {
+	if !constants.IsActive(constants.ACTIVATION_FBLOCK_VALIDATION, b.DBHeight) {
+		return b.validateLegacy()
+	}
+
+	// Checking the signatures is most of the work, so they are checked
+	// across all the cores first
+	if err := ValidateSignaturesConcurrently(b.Transactions); err != nil {
+		return err
+	}
+
+	for i, trans := range b.Transactions {
+		if i == 0 {
+			// The coinbase pays no fee, so only its form is checked
+			if len(trans.GetInputs()) != 0 {
+				return fmt.Errorf("Block has a coinbase transaction with inputs")
+			}
+			if err := trans.Validate(0); err != nil {
+				return fmt.Errorf("Transaction 0 of the block: %s", err.Error())
+			}
+			continue
+		}
+		if len(trans.GetInputs()) == 0 {
+			return fmt.Errorf("Block contains transactions without inputs")
+		}
+		if err := b.validateTransaction(i, trans, false); err != nil {
+			return fmt.Errorf("Transaction %d of the block: %s", i, err.Error())
+		}
+	}
+
+	mr := b.BodyMR
+	b.CalculateHashes()
+	if mr == nil || !mr.IsSameAs(b.BodyMR) {
+		return fmt.Errorf("This blocks Merkle Root of the transactions does not match the transactions")
+	}
+	return nil
+}
+
+// validateLegacy is Validate before ACTIVATION_FBLOCK_VALIDATION.  A block
+// with an invalid transaction passes.
+func (b FBlock) validateLegacy() error {
 	for i, trans := range b.Transactions {
 		if err := b.ValidateTransaction(i, trans); err != nil {
 			return nil
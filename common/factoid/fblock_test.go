@@ -6,8 +6,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/FactomProject/factomd/common/constants"
 	. "github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
 )
 
 func TestUnmarshalNilFBlock(t *testing.T) {
@@ -50,6 +53,10 @@ func TestMarshalUnmarshal(t *testing.T) {
 	t2.Hash = "35ac556392f934d702605eac3dac3138cdc134e3f188392afb550ee797d377f9"
 	ts = append(ts, t2)
 
+	// These are blocks of MAIN, so they are validated by its rules
+	constants.SetActivationNetwork(constants.NETWORK_MAIN)
+	defer constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+
 	for _, tBlock := range ts {
 		rawStr := tBlock.Raw
 		raw, err := hex.DecodeString(rawStr)
@@ -91,6 +98,58 @@ func TestMarshalUnmarshal(t *testing.T) {
 	}
 }
 
+func TestFBlockValidate(t *testing.T) {
+	newBlock := func(amount uint64) interfaces.IFBlock {
+		tx := new(Transaction)
+		tx.AddInput(testHelper.NewFactoidAddress(1), amount)
+		tx.AddOutput(testHelper.NewFactoidAddress(2), 1000)
+		tx.SetTimestamp(primitives.NewTimestampNow())
+		testHelper.SignFactoidTransaction(1, tx)
+
+		b := NewFBlock(nil)
+		b.SetExchRate(1000)
+		if err := b.AddCoinbase(GetCoinbase(primitives.NewTimestampNow())); err != nil {
+			t.Fatalf("%v", err)
+		}
+		b.(*FBlock).Transactions = append(b.GetTransactions(), tx)
+		b.(*FBlock).CalculateHashes()
+		return b
+	}
+
+	if err := newBlock(100000).Validate(); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	// A transaction that doesn't pay its fee fails the block, as a bad
+	// signature does
+	poor := newBlock(1000)
+	if err := poor.Validate(); err == nil {
+		t.Errorf("A transaction not paying its fee did not fail the block")
+	}
+	unsigned := newBlock(100000)
+	unsigned.GetTransactions()[1].AddOutput(testHelper.NewFactoidAddress(3), 1)
+	unsigned.(*FBlock).CalculateHashes()
+	if err := unsigned.Validate(); err == nil {
+		t.Errorf("A bad signature did not fail the block")
+	}
+
+	changed := newBlock(100000)
+	changed.(*FBlock).BodyMR = primitives.NewZeroHash()
+	if err := changed.Validate(); err == nil {
+		t.Errorf("A block with the wrong Merkle root passed")
+	}
+
+	// Before the activation, a block with an invalid transaction passes
+	constants.SetActivationNetwork(constants.NETWORK_MAIN)
+	defer constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+	if err := poor.Validate(); err != nil {
+		t.Errorf("%v", err)
+	}
+	if err := unsigned.Validate(); err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
 func TestGetEntryHashes(t *testing.T) {
 	f := GetDeterministicFBlock(t)
 	hashes := f.GetEntryHashes()
@@ -0,0 +1,197 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factoid
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+/**************************************
+ * MultiSignatureBlock
+ *
+ * The signature block of a type 2 (multisig) RCD.  The RCD only holds the
+ * addresses that may sign, so each signer reveals the RCD behind its
+ * address, and signs with the signature block that RCD calls for.  As the
+ * signer's RCD may itself be a multisig, multisigs nest.
+ *
+ * Marshalled as the number of signers (uint16), then for each signer the
+ * index of its address in the RCD (uint16), its RCD, and its signature block.
+ **************************************/
+
+type MultiSignatureBlock struct {
+	Signers []*MultiSigner
+}
+
+// MultiSigner is one of the signers of a multisig input.
+type MultiSigner struct {
+	Index    int                        // Of the address in the type 2 RCD
+	RCD      interfaces.IRCD            // The RCD that hashes to the address
+	SigBlock interfaces.ISignatureBlock // Signs for the RCD
+}
+
+var _ interfaces.ISignatureBlock = (*MultiSignatureBlock)(nil)
+
+// NewSignatureBlock returns an empty signature block of the kind the RCD
+// is signed with.
+func NewSignatureBlock(rcd interfaces.IRCD) interfaces.ISignatureBlock {
+	if _, ok := rcd.(*RCD_2); ok {
+		return new(MultiSignatureBlock)
+	}
+	return new(SignatureBlock)
+}
+
+// AddSigner adds the signature of the address at the given index of a type 2
+// RCD, made with the RCD and signature block behind that address.
+func (b *MultiSignatureBlock) AddSigner(index int, rcd interfaces.IRCD, sigblk interfaces.ISignatureBlock) {
+	s := new(MultiSigner)
+	s.Index = index
+	s.RCD = rcd
+	s.SigBlock = sigblk
+	b.Signers = append(b.Signers, s)
+}
+
+func (b *MultiSignatureBlock) IsSameAs(s interfaces.ISignatureBlock) bool {
+	if s == nil {
+		return b == nil
+	}
+	m, ok := s.(*MultiSignatureBlock)
+	if !ok || len(b.Signers) != len(m.Signers) {
+		return false
+	}
+	for i, signer := range b.Signers {
+		other := m.Signers[i]
+		if signer.Index != other.Index ||
+			!signer.RCD.IsSameAs(other.RCD) ||
+			!signer.SigBlock.IsSameAs(other.SigBlock) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddSignature does nothing, as each signature of a multisig goes with the
+// RCD of its signer.  Use AddSigner.
+func (b *MultiSignatureBlock) AddSignature(sig interfaces.ISignature) {
+}
+
+// GetSignature returns the first signature of the signer at the index.
+func (b MultiSignatureBlock) GetSignature(index int) interfaces.ISignature {
+	if len(b.Signers) <= index {
+		return nil
+	}
+	return b.Signers[index].SigBlock.GetSignature(0)
+}
+
+// GetSignatures returns the signatures of all the signers, nested ones
+// included.
+func (b MultiSignatureBlock) GetSignatures() []interfaces.ISignature {
+	var sigs []interfaces.ISignature
+	for _, signer := range b.Signers {
+		sigs = append(sigs, signer.SigBlock.GetSignatures()...)
+	}
+	return sigs
+}
+
+func (b *MultiSignatureBlock) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(b)
+}
+
+func (b *MultiSignatureBlock) JSONString() (string, error) {
+	return primitives.EncodeJSONString(b)
+}
+
+func (b MultiSignatureBlock) String() string {
+	txt, err := b.CustomMarshalText()
+	if err != nil {
+		return "<error>"
+	}
+	return string(txt)
+}
+
+func (b MultiSignatureBlock) MarshalBinary() ([]byte, error) {
+	buf := primitives.NewBuffer(nil)
+	err := buf.PushUInt16(uint16(len(b.Signers)))
+	if err != nil {
+		return nil, err
+	}
+	for _, signer := range b.Signers {
+		err = buf.PushUInt16(uint16(signer.Index))
+		if err != nil {
+			return nil, err
+		}
+		err = buf.PushBinaryMarshallable(signer.RCD)
+		if err != nil {
+			return nil, err
+		}
+		err = buf.PushBinaryMarshallable(signer.SigBlock)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (b *MultiSignatureBlock) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	buf := primitives.NewBuffer(data)
+	n, err := buf.PopUInt16()
+	if err != nil {
+		return nil, err
+	}
+	b.Signers = nil
+	for i := 0; i < int(n); i++ {
+		signer := new(MultiSigner)
+		index, err := buf.PopUInt16()
+		if err != nil {
+			return nil, err
+		}
+		signer.Index = int(index)
+
+		t, err := buf.PeekByte()
+		if err != nil {
+			return nil, err
+		}
+		if t != 1 && t != 2 {
+			return nil, fmt.Errorf("Invalid type byte for the RCD of signer %d: %x", i, t)
+		}
+		signer.RCD = CreateRCD([]byte{t})
+		err = buf.PopBinaryMarshallable(signer.RCD)
+		if err != nil {
+			return nil, err
+		}
+		signer.SigBlock = NewSignatureBlock(signer.RCD)
+		err = buf.PopBinaryMarshallable(signer.SigBlock)
+		if err != nil {
+			return nil, err
+		}
+		b.Signers = append(b.Signers, signer)
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (b *MultiSignatureBlock) UnmarshalBinary(data []byte) error {
+	_, err := b.UnmarshalBinaryData(data)
+	return err
+}
+
+func (b MultiSignatureBlock) CustomMarshalText() ([]byte, error) {
+	var out primitives.Buffer
+
+	out.WriteString("Multisig Signature Block: \n")
+	for _, signer := range b.Signers {
+		out.WriteString(fmt.Sprintf(" signer %d: ", signer.Index))
+		out.WriteString(signer.RCD.String())
+		txt, err := signer.SigBlock.CustomMarshalText()
+		if err != nil {
+			return nil, err
+		}
+		out.Write(txt)
+		out.WriteString("\n ")
+	}
+
+	return out.DeepCopyBytes(), nil
+}
@@ -48,14 +48,18 @@ func NewRCD_1(publicKey []byte) interfaces.IRCD {
 	return a
 }
 
-func NewRCD_2(n int, m int, addresses []interfaces.IAddress) (interfaces.IRCD, error) {
-	if len(addresses) != m {
+// NewRCD_2 returns a multisig RCD that takes m signatures of the n addresses.
+func NewRCD_2(m int, n int, addresses []interfaces.IAddress) (interfaces.IRCD, error) {
+	if len(addresses) != n {
 		return nil, fmt.Errorf("Improper number of addresses.  m = %d n = %d #addresses = %d", m, n, len(addresses))
 	}
+	if m < 1 || m > n || n > MaxRCD2Addresses {
+		return nil, fmt.Errorf("Improper number of signatures.  m = %d n = %d", m, n)
+	}
 
 	au := new(RCD_2)
-	au.N = n
 	au.M = m
+	au.N = n
 	au.N_Addresses = make([]interfaces.IAddress, len(addresses), len(addresses))
 	copy(au.N_Addresses, addresses)
 
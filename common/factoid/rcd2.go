@@ -9,6 +9,7 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 )
@@ -19,35 +20,44 @@ import (
 
 // Type 2 RCD implement multisig
 // m of n
-// Must have m addresses from which to choose, no fewer, no more
-// Must have n RCD, no fewer no more.
+// Must have n addresses from which to choose, no fewer, no more
+// Must have m of them sign, each with the RCD behind its address
+// (see MultiSignatureBlock).  Multisig RCDs may only be spent from once
+// ACTIVATION_MULTISIG_RCD is active (see CheckRCDsActive).
 // NOTE: This does mean you can have a multisig nested in a
 // multisig.  It just works.
 
 type RCD_2 struct {
 	M           int                   // Number signatures required
-	N           int                   // Total sigatures possible
+	N           int                   // Total signatures possible, one per address
 	N_Addresses []interfaces.IAddress // n addresses
 }
 
 var _ interfaces.IRCD = (*RCD_2)(nil)
 
-/*************************************
- *       Stubs
- *************************************/
+// The most addresses a type 2 RCD may have
+const MaxRCD2Addresses = 255
 
+/***************************************
+ *       Methods
+ ***************************************/
+
+// GetAddress returns the address funds are sent to, to be held by the
+// multisig.  As with type 1, it is the double sha256 of the marshalled RCD.
 func (b RCD_2) GetAddress() (interfaces.IAddress, error) {
-	return nil, nil
+	data, err := b.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return CreateAddress(primitives.Shad(data)), nil
 }
 
+// NumberOfSignatures returns how many signatures spending from the multisig
+// takes, at the least, which is what the fee charges for.
 func (b RCD_2) NumberOfSignatures() int {
-	return 1
+	return b.M
 }
 
-/***************************************
- *       Methods
- ***************************************/
-
 func (b RCD_2) IsSameAs(rcd interfaces.IRCD) bool {
 	return b.String() == rcd.String()
 }
@@ -57,8 +67,48 @@ func (b RCD_2) UnmarshalBinary(data []byte) error {
 	return err
 }
 
+// CheckSig is true if at least M different addresses of the RCD signed the
+// transaction.  Every signer must be one of the addresses, and its signature
+// must check out; one bad signer fails the whole block.
 func (b RCD_2) CheckSig(trans interfaces.ITransaction, sigblk interfaces.ISignatureBlock) bool {
-	return false
+	msb, ok := sigblk.(*MultiSignatureBlock)
+	if !ok || b.M < 1 || len(msb.Signers) < b.M {
+		return false
+	}
+
+	signed := make(map[int]bool)
+	for _, signer := range msb.Signers {
+		if signer.Index < 0 || signer.Index >= len(b.N_Addresses) || signed[signer.Index] {
+			return false
+		}
+		signed[signer.Index] = true
+
+		if signer.RCD == nil || signer.SigBlock == nil {
+			return false
+		}
+		address, err := signer.RCD.GetAddress()
+		if err != nil || address == nil || !address.IsSameAs(b.N_Addresses[signer.Index]) {
+			return false
+		}
+		if !signer.RCD.CheckSig(trans, signer.SigBlock) {
+			return false
+		}
+	}
+	return len(signed) >= b.M
+}
+
+// CheckRCDsActive returns an error if the transaction spends from a
+// multisig RCD in a block at a height before ACTIVATION_MULTISIG_RCD.
+func CheckRCDsActive(trans interfaces.ITransaction, height uint32) error {
+	if constants.IsActive(constants.ACTIVATION_MULTISIG_RCD, height) {
+		return nil
+	}
+	for _, rcd := range trans.GetRCDs() {
+		if _, ok := rcd.(*RCD_2); ok {
+			return fmt.Errorf("Multisig RCDs are not active at height %d", height)
+		}
+	}
+	return nil
 }
 
 func (e *RCD_2) JSONByte() ([]byte, error) {
@@ -98,10 +148,13 @@ func (t *RCD_2) UnmarshalBinaryData(data []byte) (newData []byte, err error) {
 		return nil, fmt.Errorf("Bad data fed to RCD_2 UnmarshalBinaryData()")
 	}
 
-	t.N, data = int(binary.BigEndian.Uint16(data[0:2])), data[2:]
 	t.M, data = int(binary.BigEndian.Uint16(data[0:2])), data[2:]
+	t.N, data = int(binary.BigEndian.Uint16(data[0:2])), data[2:]
+	if t.M < 1 || t.M > t.N || t.N > MaxRCD2Addresses {
+		return nil, fmt.Errorf("Bad multisig RCD, %d of %d signatures", t.M, t.N)
+	}
 
-	t.N_Addresses = make([]interfaces.IAddress, t.M, t.M)
+	t.N_Addresses = make([]interfaces.IAddress, t.N, t.N)
 
 	for i, _ := range t.N_Addresses {
 		t.N_Addresses[i] = new(Address)
@@ -118,9 +171,9 @@ func (a RCD_2) MarshalBinary() ([]byte, error) {
 	var out primitives.Buffer
 
 	binary.Write(&out, binary.BigEndian, uint8(2))
-	binary.Write(&out, binary.BigEndian, uint16(a.N))
 	binary.Write(&out, binary.BigEndian, uint16(a.M))
-	for i := 0; i < a.M; i++ {
+	binary.Write(&out, binary.BigEndian, uint16(a.N))
+	for i := 0; i < a.N; i++ {
 		data, err := a.N_Addresses[i].MarshalBinary()
 		if err != nil {
 			return nil, err
@@ -135,13 +188,13 @@ func (a RCD_2) CustomMarshalText() ([]byte, error) {
 	var out primitives.Buffer
 
 	primitives.WriteNumber8(&out, uint8(2)) // Type 2 Authorization
-	out.WriteString("\n n: ")
-	primitives.WriteNumber16(&out, uint16(a.N))
-	out.WriteString(" m: ")
+	out.WriteString("\n m: ")
 	primitives.WriteNumber16(&out, uint16(a.M))
+	out.WriteString(" n: ")
+	primitives.WriteNumber16(&out, uint16(a.N))
 	out.WriteString("\n")
-	for i := 0; i < a.M; i++ {
-		out.WriteString("  m: ")
+	for i := 0; i < a.N; i++ {
+		out.WriteString("  n: ")
 		out.WriteString(hex.EncodeToString(a.N_Addresses[i].Bytes()))
 		out.WriteString("\n")
 	}
@@ -8,8 +8,10 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/FactomProject/factomd/common/constants"
 	. "github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/testHelper"
 )
 
 func TestUnmarshalNilRCD_2(t *testing.T) {
@@ -94,17 +96,159 @@ func TestRCD2Clone(t *testing.T) {
 	}
 }
 
+func TestRCD2CheckSig(t *testing.T) {
+	var signers []interfaces.IRCD
+	var addresses []interfaces.IAddress
+	for i := 0; i < 3; i++ {
+		rcd := testHelper.NewFactoidRCDAddress(uint64(i))
+		address, err := rcd.GetAddress()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		signers = append(signers, rcd)
+		addresses = append(addresses, address)
+	}
+	multisig, err := NewRCD_2(2, 3, addresses)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	msAddress, err := multisig.GetAddress()
+	if err != nil || msAddress == nil {
+		t.Fatalf("No address for the multisig - %v", err)
+	}
+
+	tx := new(Transaction)
+	tx.AddInput(msAddress, 1000)
+	tx.AddOutput(testHelper.NewFactoidAddress(5), 900)
+	tx.AddAuthorization(multisig)
+	data, err := tx.MarshalBinarySig()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	sign := func(keys []uint64, indexes ...int) *MultiSignatureBlock {
+		b := new(MultiSignatureBlock)
+		for j, i := range indexes {
+			b.AddSigner(i, signers[i], NewSingleSignatureBlock(testHelper.NewPrivKey(keys[j]), data))
+		}
+		return b
+	}
+
+	toTest := []struct {
+		name  string
+		block *MultiSignatureBlock
+		valid bool
+	}{
+		{"2 of 3", sign([]uint64{0, 2}, 0, 2), true},
+		{"3 of 3", sign([]uint64{2, 0, 1}, 2, 0, 1), true},
+		{"1 of 3", sign([]uint64{1}, 1), false},
+		{"the same signer twice", sign([]uint64{1, 1}, 1, 1), false},
+		{"a wrong key", sign([]uint64{0, 0}, 0, 1), false},
+		{"no signers", new(MultiSignatureBlock), false},
+	}
+	for _, test := range toTest {
+		tx.SetSignatureBlock(0, test.block)
+		err := tx.ValidateSignatures()
+		if test.valid && err != nil {
+			t.Errorf("%s was rejected - %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s was accepted", test.name)
+		}
+	}
+
+	tx.SetSignatureBlock(0, sign([]uint64{0, 2}, 0, 2))
+	if err := tx.Validate(1); err != nil {
+		t.Errorf("%v", err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	tx2 := new(Transaction)
+	if err := tx2.UnmarshalBinary(raw); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !tx2.IsSameAs(tx) {
+		t.Errorf("The transaction changed in marshalling")
+	}
+	if err := tx2.ValidateSignatures(); err != nil {
+		t.Errorf("The unmarshalled transaction was rejected - %v", err)
+	}
+}
+
+func TestNewRCD2Bounds(t *testing.T) {
+	addresses := []interfaces.IAddress{nextAddress(), nextAddress()}
+	for _, m := range []int{0, 3} {
+		if _, err := NewRCD_2(m, 2, addresses); err == nil {
+			t.Errorf("A multisig taking %d of 2 signatures was made", m)
+		}
+	}
+}
+
+func TestRCD2Encoding(t *testing.T) {
+	addresses := []interfaces.IAddress{nextAddress(), nextAddress(), nextAddress()}
+	rcd, err := NewRCD_2(2, 3, addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := rcd.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The type, then m, the signatures required, then n, the addresses
+	if len(data) != 5+3*32 || data[0] != 2 || data[1] != 0 || data[2] != 2 || data[3] != 0 || data[4] != 3 {
+		t.Errorf("Wrong encoding %x", data[:5])
+	}
+	if rcd.NumberOfSignatures() != 2 {
+		t.Errorf("A 2 of 3 multisig charges for %d signatures", rcd.NumberOfSignatures())
+	}
+
+	// The fee charges for each signature required
+	single := new(Transaction)
+	single.AddInput(nextAddress(), 1000)
+	single.AddAuthorization(testHelper.NewFactoidRCDAddress(0))
+	multi := new(Transaction)
+	multi.AddInput(nextAddress(), 1000)
+	multi.AddAuthorization(rcd)
+	singleFee, _ := single.CalculateFeeParts(1000)
+	multiFee, _ := multi.CalculateFeeParts(1000)
+	if singleFee.Signatures != 1000 || multiFee.Signatures != 2000 {
+		t.Errorf("Signature fees are %d and %d, expected 1000 and 2000", singleFee.Signatures, multiFee.Signatures)
+	}
+}
+
+func TestRCD2Activation(t *testing.T) {
+	addresses := []interfaces.IAddress{nextAddress(), nextAddress()}
+	rcd, _ := NewRCD_2(1, 2, addresses)
+	tx := new(Transaction)
+	tx.AddInput(nextAddress(), 1000)
+	tx.AddAuthorization(rcd)
+
+	if err := CheckRCDsActive(tx, 10); err != nil {
+		t.Errorf("%v", err)
+	}
+	constants.SetActivationNetwork(constants.NETWORK_MAIN)
+	defer constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+	if err := CheckRCDsActive(tx, 10); err == nil {
+		t.Errorf("A multisig spent from before its activation")
+	}
+	tx.RCDs[0] = testHelper.NewFactoidRCDAddress(0)
+	if err := CheckRCDsActive(tx, 10); err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
 func nextAuth2_rcd2() *RCD_2 {
 	if r == nil {
 		r = rand.New(rand.NewSource(1))
 	}
-	n := r.Int()%4 + 1
-	m := r.Int()%4 + n
-	addresses := make([]interfaces.IAddress, m, m)
-	for j := 0; j < m; j++ {
+	m := r.Int()%4 + 1
+	n := r.Int()%4 + m
+	addresses := make([]interfaces.IAddress, n, n)
+	for j := 0; j < n; j++ {
 		addresses[j] = nextAddress()
 	}
 
-	rcd, _ := NewRCD_2(n, m, addresses)
+	rcd, _ := NewRCD_2(m, n, addresses)
 	return rcd.(*RCD_2)
 }
@@ -164,14 +164,23 @@ func (t *Transaction) SetTimestamp(ts interfaces.Timestamp) {
 
 func (t *Transaction) SetSignatureBlock(i int, sig interfaces.ISignatureBlock) {
 	for len(t.SigBlocks) <= i {
-		t.SigBlocks = append(t.SigBlocks, new(SignatureBlock))
+		t.SigBlocks = append(t.SigBlocks, t.newSignatureBlock(len(t.SigBlocks)))
 	}
 	t.SigBlocks[i] = sig
 }
 
+// newSignatureBlock returns an empty signature block of the kind the RCD of
+// the ith input is signed with.
+func (t *Transaction) newSignatureBlock(i int) interfaces.ISignatureBlock {
+	if i < len(t.RCDs) {
+		return NewSignatureBlock(t.RCDs[i])
+	}
+	return new(SignatureBlock)
+}
+
 func (t *Transaction) GetSignatureBlock(i int) interfaces.ISignatureBlock {
 	for len(t.SigBlocks) <= i {
-		t.SigBlocks = append(t.SigBlocks, new(SignatureBlock))
+		t.SigBlocks = append(t.SigBlocks, t.newSignatureBlock(len(t.SigBlocks)))
 	}
 	return t.SigBlocks[i]
 }
@@ -196,30 +205,89 @@ func (t *Transaction) AddRCD(rcd interfaces.IRCD) {
 //    all full nodes. A fee of 10 EC equivalent must be paid for each
 //    signature included.
 func (t Transaction) CalculateFee(factoshisPerEC uint64) (uint64, error) {
+	fee, err := t.CalculateFeeParts(factoshisPerEC)
+	if err != nil {
+		return 0, err
+	}
+	return fee.Total(), nil
+}
+
+// TransactionFee is the fee a transaction owes, by what it is charged for,
+// in factoshis.
+type TransactionFee struct {
+	Size       uint64 `json:"size"`       // For each KiB of the transaction
+	Outputs    uint64 `json:"outputs"`    // For each factoid and entry credit output
+	Signatures uint64 `json:"signatures"` // For each signature checked
+}
+
+func (f TransactionFee) Total() uint64 {
+	return f.Size + f.Outputs + f.Signatures
+}
+
+// CalculateFeeParts returns the fee of the transaction, as CalculateFee, by
+// what it is charged for.
+func (t Transaction) CalculateFeeParts(factoshisPerEC uint64) (TransactionFee, error) {
+	var fee TransactionFee
+
 	// First look at the size of the transaction, and make sure
 	// everything is inbounds.
 	data, err := t.MarshalBinary()
 	if err != nil {
-		return 0, fmt.Errorf("Can't Marshal the Transaction")
+		return fee, fmt.Errorf("Can't Marshal the Transaction")
 	}
 	if len(data) > constants.MAX_TRANSACTION_SIZE { // Can't be bigger than our limits
-		return 0, fmt.Errorf("Transaction is greater than the max transaction size")
+		return fee, fmt.Errorf("Transaction is greater than the max transaction size")
 	}
 	// Okay, we know the transaction is mostly good. Let's calculate
 	// fees.
-	var fee uint64
+	fee.Size = factoshisPerEC * uint64((len(data)+1023)/1024)
 
-	fee = factoshisPerEC * uint64((len(data)+1023)/1024)
-
-	fee += factoshisPerEC * 10 * uint64(len(t.Outputs)+len(t.OutECs))
+	fee.Outputs = factoshisPerEC * 10 * uint64(len(t.Outputs)+len(t.OutECs))
 
 	for _, rcd := range t.RCDs {
-		fee += factoshisPerEC * uint64(rcd.NumberOfSignatures())
+		fee.Signatures += factoshisPerEC * uint64(rcd.NumberOfSignatures())
 	}
 
 	return fee, nil
 }
 
+// ValidateFee returns an error if what the inputs of the transaction leave
+// over its outputs doesn't cover its fee at the given exchange rate.
+func ValidateFee(trans interfaces.ITransaction, factoshisPerEC uint64) error {
+	fee, err := trans.CalculateFee(factoshisPerEC)
+	if err != nil {
+		return err
+	}
+	inputs, err := trans.TotalInputs()
+	if err != nil {
+		return err
+	}
+	outputs, err := trans.TotalOutputs()
+	if err != nil {
+		return err
+	}
+	ecs, err := trans.TotalECs()
+	if err != nil {
+		return err
+	}
+	spent, err := ValidateAmounts(outputs, ecs)
+	if err != nil {
+		return err
+	}
+	owed, err := ValidateAmounts(spent, fee)
+	if err != nil {
+		return err
+	}
+	if inputs < owed {
+		var paid uint64
+		if inputs > spent {
+			paid = inputs - spent
+		}
+		return fmt.Errorf("The transaction pays %d in fees, %d are required", paid, fee)
+	}
+	return nil
+}
+
 // Checks that the sum of the given amounts do not cross
 // a signed boundry.  Returns false if invalid, and the
 // sum if valid.  Returns 0 and true if nothing is passed in.
@@ -389,7 +457,7 @@ func (t *Transaction) GetSignatureBlocks() []interfaces.ISignatureBlock {
 		return t.SigBlocks
 	}
 	for i := len(t.SigBlocks); i < len(t.Inputs); i++ { // If too short, then
-		t.SigBlocks = append(t.SigBlocks, new(SignatureBlock)) // pad it with
+		t.SigBlocks = append(t.SigBlocks, t.newSignatureBlock(len(t.SigBlocks))) // pad it with
 	} // signature blocks.
 	return t.SigBlocks
 }
@@ -497,7 +565,7 @@ func (t *Transaction) UnmarshalBinaryData(data []byte) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		t.SigBlocks[i] = new(SignatureBlock)
+		t.SigBlocks[i] = NewSignatureBlock(t.RCDs[i])
 		err = buf.PopBinaryMarshallable(t.SigBlocks[i])
 		if err != nil {
 			return nil, err
@@ -591,7 +659,7 @@ func (t Transaction) MarshalBinary() ([]byte, error) {
 		// we don't want to restrict what might be required to
 		// sign an input.
 		if len(t.SigBlocks) <= i {
-			t.SigBlocks = append(t.SigBlocks, new(SignatureBlock))
+			t.SigBlocks = append(t.SigBlocks, t.newSignatureBlock(len(t.SigBlocks)))
 		}
 		err = buf.PushBinaryMarshallable(t.SigBlocks[i])
 		if err != nil {
@@ -681,7 +749,7 @@ func (t *Transaction) CustomMarshalText() (text []byte, err error) {
 		out.Write(text)
 
 		for len(t.SigBlocks) <= i {
-			t.SigBlocks = append(t.SigBlocks, new(SignatureBlock))
+			t.SigBlocks = append(t.SigBlocks, t.newSignatureBlock(len(t.SigBlocks)))
 		}
 		text, err := t.SigBlocks[i].CustomMarshalText()
 		if err != nil {
@@ -0,0 +1,19 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package interfaces
+
+import (
+	"time"
+)
+
+// IClock is where a node gets the time.  Nodes run on the wall clock; tests
+// and simulations use one they can set and advance, so that the minutes, EOMs
+// and blocks happen when they say.
+type IClock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
@@ -12,14 +12,41 @@ type IDatabase interface {
 	Get(bucket, key []byte, destination BinaryMarshallable) (BinaryMarshallable, error)
 	Delete(bucket, key []byte) error
 	ListAllKeys(bucket []byte) ([][]byte, error)
+	// ForEachKey calls fn, in byte order, with every key in the bucket that is
+	// >= start and < limit.  A nil start or limit leaves that end of the range
+	// open.  Iteration stops at the first error returned by fn, and that error
+	// is returned.  fn must not write to the database.
+	ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error
 	GetAll(bucket []byte, sample BinaryMarshallableAndCopyable) ([]BinaryMarshallableAndCopyable, [][]byte, error)
 	Clear(bucket []byte) error
+	// PutInBatch writes all the records atomically: either all of them or none
+	// of them are written.  A record with nil Data deletes its key.
 	PutInBatch(records []Record) error
 	ListAllBuckets() ([][]byte, error)
 	Trim()
 	DoesKeyExist(bucket, key []byte) (bool, error)
+	// GetMulti gets the values of several keys of a bucket at once.  The values
+	// are in the order of the keys, with nil for keys that don't exist.
+	GetMulti(bucket []byte, keys [][]byte, sample BinaryMarshallableAndCopyable) ([]BinaryMarshallableAndCopyable, error)
+	// BucketStats counts the keys in a bucket, and the bytes of their keys and
+	// values as stored.
+	BucketStats(bucket []byte) (BucketStats, error)
+	// Compact reclaims the disk space of deleted and overwritten data.
+	Compact() error
+	// SetBulkLoad turns bulk loading on or off.  While bulk loading, writes
+	// are not flushed to disk one by one, so a crash can lose the latest of
+	// them.  Every call flushes everything written so far.
+	SetBulkLoad(bulk bool) error
 }
 
+type BucketStats struct {
+	Bucket    []byte
+	Keys      int
+	KeyBytes  int64
+	DataBytes int64
+}
+
+// Record is a write in a batch.  A nil Data deletes the key.
 type Record struct {
 	Bucket []byte
 	Key    []byte
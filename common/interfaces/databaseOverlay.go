@@ -14,26 +14,52 @@ import ()
 type DBOverlaySimple interface {
 	Close() error
 	DoesKeyExist(bucket, key []byte) (bool, error)
+	DoesDBlockExist(keyMR IHash) (bool, error)
+	DoesEntryExist(hash IHash) (bool, error)
+	DoesChainExist(chainID IHash) (bool, error)
 	ExecuteMultiBatch() error
+	CancelMultiBatch()
+	CheckSchema() error
 	FetchABlock(IHash) (IAdminBlock, error)
 	FetchABlockByHeight(blockHeight uint32) (IAdminBlock, error)
 	FetchDBKeyMRByHeight(dBlockHeight uint32) (dBlockKeyMR IHash, err error)
 	FetchDBlock(IHash) (IDirectoryBlock, error)
 	FetchDBlockByHeight(uint32) (IDirectoryBlock, error)
+	FetchDBlockHeightRange(startHeight, endHeight int64) ([]IHash, error)
+	FetchDBlocksInHeightRange(startHeight, endHeight int64) ([]IDirectoryBlock, error)
 	FetchDBlockHead() (IDirectoryBlock, error)
+	FetchDatabaseStats() ([]BucketStats, error)
+	Compact() error
+	SetBulkLoad(bulk bool) error
 	FetchEBlock(IHash) (IEntryBlock, error)
 	FetchEBlockHead(chainID IHash) (IEntryBlock, error)
 	FetchECBlock(IHash) (IEntryCreditBlock, error)
 	FetchECBlockByHeight(blockHeight uint32) (IEntryCreditBlock, error)
 	FetchECTransaction(hash IHash) (IECBlockEntry, error)
 	FetchEntry(IHash) (IEBEntry, error)
+	FetchEBlockEntries(eBlock IEntryBlock) ([]IEBEntry, error)
 	FetchFBlock(IHash) (IFBlock, error)
 	FetchFBlockByHeight(blockHeight uint32) (IFBlock, error)
 	FetchFactoidTransaction(hash IHash) (ITransaction, error)
 	FetchHeadIndexByChainID(chainID IHash) (IHash, error)
 	FetchIncludedIn(hash IHash) (IHash, error)
+	FetchEntryLocation(entryHash IHash) (eBlockKeyMR IHash, chainID IHash, dBlockHeight uint32, err error)
+	FetchTransactionLocation(txid IHash) (fBlockKeyMR IHash, offset uint32, dBlockHeight uint32, err error)
 	FetchPaidFor(hash IHash) (IHash, error)
+
+	SaveStateDiffMultiBatch(ablock IAdminBlock, fblock IFBlock, ecblock IEntryCreditBlock) error
+	FetchStateDiff(height uint32) (*StateDiff, error)
+	SavePendingCommits(commits map[[32]byte][]byte) error
+	FetchPendingCommits() (map[[32]byte][]byte, error)
+	SaveCheckpoint(snapshot []byte) error
+	FetchCheckpoint() ([]byte, error)
+	FetchFactoidBalanceAtHeight(address IHash, height uint32) (int64, error)
+	FetchECBalanceAtHeight(address IHash, height uint32) (int64, error)
+	SaveChainStatsMultiBatch(eblock IEntryBlock, entries []IEBEntry) error
+	FetchChainStats(chainID IHash) (*ChainStats, error)
 	FetchAllEBlocksByChain(IHash) ([]IEntryBlock, error)
+	FetchEBlocksByChainFrom(chainID IHash, startHeight uint32, limit int) ([]IEntryBlock, error)
+	FetchChainIDs(after IHash, limit int) ([]IHash, error)
 	InsertEntryMultiBatch(entry IEBEntry) error
 	ProcessABlockMultiBatch(block DatabaseBatchable) error
 	ProcessDBlockMultiBatch(block DatabaseBlockWithEntries) error
@@ -55,11 +81,20 @@ type DBOverlay interface {
 	IDatabase
 
 	FetchHeadIndexByChainID(chainID IHash) (IHash, error)
+
+	// DoesChainExist checks if a chain has a head, without loading the head block.
+	DoesChainExist(chainID IHash) (bool, error)
+
+	// CheckSchema brings the database layout up to the version of this binary,
+	// and returns an error if the database is newer than the binary.
+	CheckSchema() error
+
 	SetExportData(path string)
 
 	StartMultiBatch()
 	PutInMultiBatch(records []Record)
 	ExecuteMultiBatch() error
+	CancelMultiBatch()
 	GetEntryType(hash IHash) (IHash, error)
 
 	//**********************************Entry**********************************//
@@ -70,6 +105,11 @@ type DBOverlay interface {
 
 	// FetchEntry gets an entry by hash from the database.
 	FetchEntry(IHash) (IEBEntry, error)
+	// FetchEBlockEntries gets the entries of an entry block that are in the database.
+	FetchEBlockEntries(eBlock IEntryBlock) ([]IEBEntry, error)
+
+	// DoesEntryExist checks if an entry is in the database, without loading it.
+	DoesEntryExist(hash IHash) (bool, error)
 
 	FetchAllEntriesByChainID(chainID IHash) ([]IEBEntry, error)
 
@@ -99,6 +139,14 @@ type DBOverlay interface {
 	// FetchAllEBlocksByChain gets all of the blocks by chain id
 	FetchAllEBlocksByChain(IHash) ([]IEntryBlock, error)
 
+	// FetchEBlocksByChainFrom gets at most limit blocks of a chain, oldest
+	// first, from the one in directory block startHeight on.
+	FetchEBlocksByChainFrom(chainID IHash, startHeight uint32, limit int) ([]IEntryBlock, error)
+
+	// FetchChainIDs gets at most limit entry chain IDs that come after the
+	// given one in byte order.
+	FetchChainIDs(after IHash, limit int) ([]IHash, error)
+
 	SaveEBlockHead(block DatabaseBlockWithEntries, checkForDuplicateEntries bool) error
 
 	FetchEBlockHead(chainID IHash) (IEntryBlock, error)
@@ -118,12 +166,21 @@ type DBOverlay interface {
 	// more are present, use -1 as endHeight.
 	FetchDBlockHeightRange(startHeight, endHeight int64) ([]IHash, error)
 
+	// FetchDatabaseStats gets the statistics of every bucket in the database.
+	FetchDatabaseStats() ([]BucketStats, error)
+
+	// FetchDBlocksInHeightRange gets the directory blocks of FetchDBlockHeightRange.
+	FetchDBlocksInHeightRange(startHeight, endHeight int64) ([]IDirectoryBlock, error)
+
 	// FetchBlockHeightByKeyMR returns the block height for the given hash.  This is
 	// part of the database.Db interface implementation.
 	FetchDBlockHeightByKeyMR(IHash) (int64, error)
 
 	FetchDBlock(IHash) (IDirectoryBlock, error)
 
+	// DoesDBlockExist checks if a directory block is in the database, without loading it.
+	DoesDBlockExist(keyMR IHash) (bool, error)
+
 	// FetchDBlock gets an entry by hash from the database.
 	FetchDBlockByPrimary(IHash) (IDirectoryBlock, error)
 
@@ -250,12 +307,86 @@ type DBOverlay interface {
 	FetchIncludedIn(hash IHash) (IHash, error)
 	RebuildDirBlockInfo() error
 
+	// FetchEntryLocation returns the entry block keyMR, chain ID and directory
+	// block height of an entry from a single index lookup.
+	FetchEntryLocation(entryHash IHash) (eBlockKeyMR IHash, chainID IHash, dBlockHeight uint32, err error)
+
+	// FetchTransactionLocation returns the factoid block keyMR, offset in the
+	// block and directory block height of a factoid transaction from a single
+	// index lookup.
+	FetchTransactionLocation(txid IHash) (fBlockKeyMR IHash, offset uint32, dBlockHeight uint32, err error)
+
+	// SaveStateDiffMultiBatch records, in the current multi batch, the
+	// changes the admin, factoid and entry credit blocks of a height make to
+	// the state, and the balance histories of the addresses they change.
+	SaveStateDiffMultiBatch(ablock IAdminBlock, fblock IFBlock, ecblock IEntryCreditBlock) error
+
+	// FetchStateDiff returns the changes the blocks of a height made to the
+	// state, or nil if none were recorded.
+	FetchStateDiff(height uint32) (*StateDiff, error)
+
+	// SavePendingCommits writes the commits awaiting their reveals,
+	// marshalled and by entry hash; a nil commit deletes the one saved.
+	// FetchPendingCommits returns them all.
+	SavePendingCommits(commits map[[32]byte][]byte) error
+	FetchPendingCommits() (map[[32]byte][]byte, error)
+
+	// SaveCheckpoint writes the marshalled dbstate, with its saved state, of
+	// the block the database starts at, for a node that did not start at the
+	// genesis block.  FetchCheckpoint returns it, or nil for a database that
+	// starts at the genesis block.
+	SaveCheckpoint(snapshot []byte) error
+	FetchCheckpoint() ([]byte, error)
+
+	// FetchFactoidBalanceAtHeight and FetchECBalanceAtHeight return the
+	// balance of an address once the blocks at the height were applied.
+	FetchFactoidBalanceAtHeight(address IHash, height uint32) (int64, error)
+	FetchECBalanceAtHeight(address IHash, height uint32) (int64, error)
+
+	// SaveChainStatsMultiBatch adds, in the current multi batch, an entry
+	// block to the statistics of its chain.  The entries saved in the same
+	// batch must be given, as they can't be read back yet.
+	SaveChainStatsMultiBatch(eblock IEntryBlock, entries []IEBEntry) error
+
+	// FetchChainStats returns the statistics of a chain, and nil if the
+	// database has no entry block of it.
+	FetchChainStats(chainID IHash) (*ChainStats, error)
+
 	FetchPaidFor(hash IHash) (IHash, error)
 
 	FetchFactoidTransaction(hash IHash) (ITransaction, error)
 	FetchECTransaction(hash IHash) (IECBlockEntry, error)
 }
 
+// ChainStats are what the entry blocks of a chain saved so far add up to.
+// Bytes counts the entries as marshalled, of those the node had when it saved
+// their block.
+type ChainStats struct {
+	Entries     uint64
+	Bytes       uint64
+	EBlocks     uint32
+	FirstHeight uint32 // The directory block height of the first entry block
+	LastHeight  uint32 // The directory block height of the last entry block
+}
+
+// A StateDiff is what the blocks of a height changed in the state: the
+// balances of addresses, the authority set and the entry credit rate.
+// Applied in order from height 0, the diffs rebuild the state at any height
+// without replaying the blocks.
+type StateDiff struct {
+	Height        uint32
+	FactoidDeltas []BalanceChange // In the order of the addresses
+	ECDeltas      []BalanceChange // In the order of the addresses
+	Authority     [][]byte        // The admin block entries that change the authority set, marshalled
+	ECRate        uint64          // The factoshis per entry credit of the factoid block, 0 if there was none
+}
+
+// A BalanceChange is the change to the balance of an address.
+type BalanceChange struct {
+	Address [32]byte
+	Delta   int64
+}
+
 type ISCDatabaseOverlay interface {
 	DBOverlay
 
@@ -26,4 +26,6 @@ type IPendingEntry struct {
 	EntryHash IHash
 	ChainID   IHash
 	Status    string
+	DBHeight  uint32 // Block the entry is pending for
+	Minute    int    // Minute the entry was acknowledged in, -1 if it isn't yet
 }
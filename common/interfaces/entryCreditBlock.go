@@ -51,6 +51,9 @@ type IECBlockBody interface {
 	GetEntries() []IECBlockEntry
 	SetEntries([]IECBlockEntry)
 	AddEntry(IECBlockEntry)
+	// AddEndOfMinuteMarker adds the minute number entry for the given minute,
+	// unless it is already the last entry in the body.
+	AddEndOfMinuteMarker(m byte)
 	IsSameAs(IECBlockBody) bool
 }
 
@@ -17,6 +17,14 @@ type IFactoidState interface {
 	GetFactoidBalance(address [32]byte) int64
 	GetECBalance(address [32]byte) int64
 
+	// Get the balance of an address less the coinbase payouts to it that
+	// have yet to mature
+	GetSpendableFactoidBalance(address [32]byte) int64
+
+	// Strike a coinbase payout that has yet to mature, and take it off the
+	// balance of its address.  Returns true if the payout was struck.
+	CancelCoinbasePayout(descriptorHeight uint32, index uint32) bool
+
 	// Add a transaction   Useful for catching up with the network.
 	AddTransactionBlock(IFBlock) error
 	AddECBlock(IEntryCreditBlock) error
@@ -28,6 +36,10 @@ type IFactoidState interface {
 	// Return zero len string if the balance of an address covers each input
 	Validate(int, ITransaction) error
 
+	// Returns true if the transaction is in a recent block, and so can't go
+	// into another
+	IsRecentTransaction(txid IHash) bool
+
 	// Check the transaction timestamp for to ensure it can be included
 	// in the current   Transactions that are too old, or dated to
 	// far in the future cannot be included in the current block
@@ -8,7 +8,7 @@ type IProcessList interface {
 	GetNewEntry(key [32]byte) IEntry
 	LenNewEntries() int
 	Complete() bool
-	VMIndexFor(hash []byte) int
+	VMIndexFor(minute int, hash []byte) int
 	SortFedServers()
 	SortAuditServers()
 	SortDBSigs()
@@ -47,6 +47,29 @@ type IProcessList interface {
 	String() string
 }
 
+// VMStatus is how far a VM of a process list has got, and whether its
+// leader is faulted.
+type VMStatus struct {
+	VMIndex      int
+	Leader       IHash // Identity chain of the leader of the VM this minute
+	Height       int   // Messages processed
+	ListLength   int   // Messages acknowledged
+	LeaderMinute int
+	Synced       bool
+	WhenFaulted  int64 // 0 unless the VM is faulted
+	FaultFlag    int   // 0 for a missing EOM, 1 for a negotiation issue
+}
+
+// ElectionStatus is the state of the replacement of faulted leaders in the
+// current process list.
+type ElectionStatus struct {
+	DBHeight      uint32
+	Minute        int
+	AmINegotiator bool
+	CurrentFault  IMsg // The fault being negotiated, if any
+	VMs           []VMStatus
+}
+
 type IRequest interface {
 	//Key() (thekey [32]byte)
 }
@@ -4,6 +4,10 @@
 
 package interfaces
 
+import (
+	"time"
+)
+
 type DBStateSent struct {
 	DBHeight uint32
 	Sent     Timestamp
@@ -69,8 +73,17 @@ type IState interface {
 	GetRpcPass() string
 	SetRpcAuthHash(authHash []byte)
 	GetRpcAuthHash() []byte
+	GetRpcToken() string
+	GetRpcAdminToken() string
+	GetApiKeys() map[string]*ApiKey // By name
+	GetRpcPublicReads() bool
 	GetTlsInfo() (bool, string, string)
 	GetFactomdLocations() string
+	GetCorsDomains() []string
+	GetCorsMethods() string
+	GetApiRateLimits() (read int, submit int)
+	GetApiSunsets() map[string]time.Time // When API versions, such as "v1", will be removed
+	GetProfilerInfo() (enabled bool, path string)
 
 	// Routine for handling the syncroniztion of the leader and follower processes
 	// and how they process messages.
@@ -113,6 +126,9 @@ type IState interface {
 	AckQueue() chan IMsg // Leader Queue
 	MsgQueue() chan IMsg // Follower Queue
 
+	// Logs what becomes of a message an API request submits
+	TraceRequest(msgHash IHash, requestID string)
+
 	// Lists and Maps
 	// =====
 	GetAuditHeartBeats() []IMsg // The checklist of HeartBeats for this period
@@ -158,6 +174,7 @@ type IState interface {
 	ComputeVMIndex(hash []byte) int // Returns the VMIndex determined by some hash (usually) for the current processlist
 	IsLeader() bool                 // Returns true if this is the leader in the current minute
 	GetLeaderVM() int               // Get the Leader VM (only good within a minute)
+	GetNodeRole() string            // "leader", "audit" or "follower", by the current process list
 	// Returns the list of VirtualServers at a given directory block height and minute
 	GetVirtualServers(dbheight uint32, minute int, identityChainID IHash) (found bool, index int)
 	// Returns true if between minutes
@@ -210,6 +227,10 @@ type IState interface {
 	FollowerExecuteCommitChain(IMsg)  // CommitChain needs to look for a Reveal Entry
 	FollowerExecuteCommitEntry(IMsg)  // CommitEntry needs to look for a Reveal Entry
 	FollowerExecuteRevealEntry(IMsg)
+	FollowerExecuteStateSnapshot(IMsg)
+	FollowerExecuteAckBatch(IMsg) // Start from the snapshot at a trusted checkpoint
+
+	GetStateSnapshot(dbheight uint32) []byte // The snapshot at the height, or nil if we no longer have it
 
 	ProcessAddServer(dbheight uint32, addServerMsg IMsg) bool
 	ProcessRemoveServer(dbheight uint32, removeServerMsg IMsg) bool
@@ -233,6 +254,8 @@ type IState interface {
 
 	GetTimestamp() Timestamp
 	GetTimeOffset() Timestamp
+	GetClock() IClock
+	SetClock(IClock)
 
 	GetTrueLeaderHeight() uint32
 	Print(a ...interface{}) (n int, err error)
@@ -256,6 +279,7 @@ type IState interface {
 	//For ACK
 	GetACKStatus(hash IHash) (int, IHash, Timestamp, Timestamp, error)
 	GetSpecificACKStatus(hash IHash) (int, IHash, Timestamp, Timestamp, error)
+	GetAckLocation(hash IHash) (status int, dbheight uint32, minute int, keymr IHash, err error)
 	FetchPaidFor(hash IHash) (IHash, error)
 	FetchFactoidTransactionByHash(hash IHash) (ITransaction, error)
 	FetchECTransactionByHash(hash IHash) (IECBlockEntry, error)
@@ -293,4 +317,90 @@ type IState interface {
 	// Access to Holding Queue
 	LoadHoldingMap() map[[32]byte]IMsg
 	LoadAcksMap() map[[32]byte]IMsg
+
+	// For the debug API
+	GetElectionStatus() *ElectionStatus
+	GetReplayStats() *ReplayStats
+	SetMsgTracing(on bool)
+	GetMsgTrace(msgHash IHash) []MsgTraceStage
+
+	// The fork found, or nil if there is none, for the health check, and
+	// clearing it, so that blocks are saved again
+	GetForkStatus() *ForkStatus
+	ClearForkStatus()
+
+	// The supply statistics, or nil if they are off or not yet ready
+	GetSupplyStats() *SupplyStats
+
+	// The blocks peers may start at with this node's snapshots
+	GetCheckpoints() []*Checkpoint
+
+	// Writes the consensus state to a JSON file, and returns its name
+	DumpState() (string, error)
+}
+
+// ApiKey is a named key that a downstream client presents to the API as
+// "Authorization: Bearer <Key>".  It gives the methods of authenticated
+// clients, but never the admin ones.
+type ApiKey struct {
+	Name      string
+	Key       string
+	RateLimit int      // Requests a second, or 0 for the limits of the API
+	Methods   []string // The v2 methods, or the APIs (v1, graphql, metrics), it may call; nil for all
+}
+
+// SupplyStats describe the factoids and entry credits in existence once the
+// blocks at Height were processed, and the largest factoid balances.
+type SupplyStats struct {
+	Height           uint32
+	FactoidSupply    int64 // Factoshis in all the factoid addresses
+	ECSupply         int64 // Entry credits in all the EC addresses
+	FactoidAddresses int   // Factoid addresses with a balance
+	ECAddresses      int   // EC addresses with a balance
+	RichList         []AddressBalance
+}
+
+// AddressBalance is the balance of an address.
+type AddressBalance struct {
+	Address [32]byte
+	Balance int64
+}
+
+// ReplayStats describe the replay filter, which keeps the hashes it has seen
+// in a bucket per minute around Center.
+type ReplayStats struct {
+	Basetime int   // Minute of the first bucket
+	Center   int   // The current minute
+	Hashes   int   // Hashes in all the buckets
+	Buckets  []int // Hashes in each bucket
+}
+
+// MsgTraceStage is when a traced message reached a stage on its way into a
+// block.
+type MsgTraceStage struct {
+	Stage string
+	Time  time.Time
+}
+
+// Checkpoint is a block a new node may start at in place of the genesis
+// block, with the StartAt values of its config.
+type Checkpoint struct {
+	DBHeight     uint32
+	KeyMR        string
+	SnapshotHash string // Of the balances, servers and authorities after the block
+}
+
+// ForkStatus is two different directory blocks signed for the same height.
+type ForkStatus struct {
+	DBHeight uint32
+	Blocks   []ForkBlock // The block seen first, and the one that differs
+	Detected time.Time
+}
+
+// ForkBlock is a directory block signed for a height, where it was seen, and
+// the identity chain IDs of the federated servers that signed it.
+type ForkBlock struct {
+	KeyMR   string
+	Source  string
+	Signers []string
 }
@@ -90,4 +90,6 @@ type ITransaction interface {
 type IPendingTransaction struct {
 	TransactionID IHash
 	Status        string
+	DBHeight      uint32 // Block the transaction is pending for
+	Minute        int    // Minute the transaction was acknowledged in, -1 if it isn't yet
 }
@@ -40,7 +40,7 @@ type MessageBase struct {
 func resend(state interfaces.IState, msg interfaces.IMsg, cnt int, delay int) {
 	for i := 0; i < cnt; i++ {
 		state.NetworkOutMsgQueue().Enqueue(msg)
-		time.Sleep(time.Duration(delay) * time.Second)
+		state.GetClock().Sleep(time.Duration(delay) * time.Second)
 	}
 }
 
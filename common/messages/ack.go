@@ -33,6 +33,7 @@ type Ack struct {
 	authvalid   bool
 	Response    bool // A response to a missing data request
 	BalanceHash interfaces.IHash
+	batch       *AckBatch // The batch signed for the ack, if it has no signature of its own
 }
 
 var _ interfaces.IMsg = (*Ack)(nil)
@@ -88,6 +89,11 @@ func (m *Ack) Validate(state interfaces.IState) int {
 	}
 
 	if !m.authvalid {
+		// An ack without a signature can't be placed into a process list
+		if m.Signature == nil {
+			return -1
+		}
+
 		// Check signature
 		bytes, err := m.MarshalForSignature()
 		if err != nil {
@@ -111,6 +117,18 @@ func (m *Ack) Validate(state interfaces.IState) int {
 	return 1
 }
 
+// GetBatch returns the batch that carries the ack, or nil if the ack is sent
+// on its own.
+func (m *Ack) GetBatch() *AckBatch {
+	return m.batch
+}
+
+// SetAuthValid marks the ack as signed by its leader, when that is known
+// without its signature, as for acks we saved ourselves.
+func (m *Ack) SetAuthValid() {
+	m.authvalid = true
+}
+
 // Returns true if this is a message for this server to execute as
 // a leader.
 func (m *Ack) ComputeVMIndex(state interfaces.IState) {
@@ -131,6 +149,11 @@ func (e *Ack) Process(dbheight uint32, state interfaces.IState) bool {
 	panic("Ack object should never have its Process() method called")
 }
 
+func (e *Ack) MarshalJSON() ([]byte, error) {
+	type expanded Ack
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *Ack) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -0,0 +1,399 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package messages
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+//Acknowledges a run of consecutive process list entries of one VM, under one
+//signature of the leader.  The acks in it carry no signature of their own.
+
+type AckBatch struct {
+	MessageBase
+	Timestamp interfaces.Timestamp // When the leader signed the batch
+	DBHeight  uint32               // Directory Block Height that owns the acks
+	Height    uint32               // Height of the first ack in the process list
+	Salt      [8]byte              // Eight bytes of the salt of the leader
+
+	Acks []*Ack // The acks, in the order of their heights
+
+	Signature interfaces.IFullSignature
+}
+
+var _ interfaces.IMsg = (*AckBatch)(nil)
+var _ Signable = (*AckBatch)(nil)
+
+// MaxAckBatch is the most acks a batch may hold.
+const MaxAckBatch = 1000
+
+func (a *AckBatch) IsSameAs(b *AckBatch) bool {
+	if b == nil {
+		return false
+	}
+	if a.VMIndex != b.VMIndex || a.DBHeight != b.DBHeight || a.Height != b.Height || a.Salt != b.Salt {
+		return false
+	}
+	if a.Timestamp.GetTimeMilli() != b.Timestamp.GetTimeMilli() {
+		return false
+	}
+	if !a.LeaderChainID.IsSameAs(b.LeaderChainID) {
+		return false
+	}
+	if len(a.Acks) != len(b.Acks) {
+		return false
+	}
+	for i := range a.Acks {
+		if !a.Acks[i].IsSameAs(b.Acks[i]) {
+			return false
+		}
+	}
+	if a.Signature == nil && b.Signature != nil {
+		return false
+	}
+	if a.Signature != nil && !a.Signature.IsSameAs(b.Signature) {
+		return false
+	}
+	return true
+}
+
+func (m *AckBatch) GetRepeatHash() interfaces.IHash {
+	return m.GetMsgHash()
+}
+
+func (m *AckBatch) GetHash() interfaces.IHash {
+	return m.GetMsgHash()
+}
+
+func (m *AckBatch) GetMsgHash() interfaces.IHash {
+	if m.MsgHash == nil {
+		data, err := m.MarshalForSignature()
+		if err != nil {
+			return nil
+		}
+		m.MsgHash = primitives.Sha(data)
+	}
+	return m.MsgHash
+}
+
+func (m *AckBatch) Type() byte {
+	return constants.ACK_BATCH_MSG
+}
+
+func (m *AckBatch) GetTimestamp() interfaces.Timestamp {
+	return m.Timestamp
+}
+
+func (m *AckBatch) VerifySignature() (bool, error) {
+	return VerifyMessage(m)
+}
+
+// Validate the message, given the state.  Three possible results:
+//  < 0 -- Message is invalid.  Discard
+//  0   -- Cannot tell if message is Valid
+//  1   -- Message is valid
+func (m *AckBatch) Validate(state interfaces.IState) int {
+	if len(m.Acks) == 0 || len(m.Acks) > MaxAckBatch {
+		return -1
+	}
+	// If too old, it isn't valid.
+	if m.DBHeight <= state.GetHighestSavedBlk() {
+		return -1
+	}
+	// The VMIndex has to be valid, as for an ack
+	_, err := state.GetMsg(m.VMIndex, int(m.DBHeight), int(m.Height))
+	if err != nil {
+		return -1
+	}
+
+	if !m.IsValid() {
+		if m.Signature == nil {
+			return -1
+		}
+		bytes, err := m.MarshalForSignature()
+		if err != nil {
+			return -1
+		}
+		signed, err := state.VerifyAuthoritySignature(bytes, m.Signature.GetSignature(), m.DBHeight)
+		if err != nil || signed <= 0 {
+			return -1
+		}
+		m.SetValid()
+	}
+
+	// The signature of the batch covers every ack in it
+	for _, ack := range m.Acks {
+		ack.authvalid = true
+	}
+	return 1
+}
+
+func (m *AckBatch) ComputeVMIndex(state interfaces.IState) {
+}
+
+// Execute the leader functions of the given message
+// Leader, follower, do the same thing.
+func (m *AckBatch) LeaderExecute(state interfaces.IState) {
+	m.FollowerExecute(state)
+}
+
+func (m *AckBatch) FollowerExecute(state interfaces.IState) {
+	state.FollowerExecuteAckBatch(m)
+}
+
+// Acknowledgements do not go into the process list.
+func (e *AckBatch) Process(dbheight uint32, state interfaces.IState) bool {
+	panic("AckBatch object should never have its Process() method called")
+}
+
+func (e *AckBatch) MarshalJSON() ([]byte, error) {
+	type expanded AckBatch
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
+func (e *AckBatch) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(e)
+}
+
+func (e *AckBatch) JSONString() (string, error) {
+	return primitives.EncodeJSONString(e)
+}
+
+func (m *AckBatch) Sign(key interfaces.Signer) error {
+	signature, err := SignSignable(m, key)
+	if err != nil {
+		return err
+	}
+	m.Signature = signature
+	return nil
+}
+
+func (m *AckBatch) GetSignature() interfaces.IFullSignature {
+	return m.Signature
+}
+
+func (m *AckBatch) UnmarshalBinaryData(data []byte) (newData []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Error unmarshalling Ack Batch: %v", r)
+		}
+	}()
+	newData = data
+	if newData[0] != m.Type() {
+		return nil, fmt.Errorf("Invalid Message type")
+	}
+	newData = newData[1:]
+
+	m.VMIndex, newData = int(newData[0]), newData[1:]
+
+	m.Timestamp = new(primitives.Timestamp)
+	newData, err = m.Timestamp.UnmarshalBinaryData(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	copy(m.Salt[:], newData[:8])
+	newData = newData[8:]
+
+	m.LeaderChainID = new(primitives.Hash)
+	newData, err = m.LeaderChainID.UnmarshalBinaryData(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	m.DBHeight, newData = binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+	m.Height, newData = binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+
+	count, newData := binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+	if count > MaxAckBatch {
+		return nil, fmt.Errorf("Ack Batch of %d acks, more than the maximum of %d", count, MaxAckBatch)
+	}
+
+	m.Acks = make([]*Ack, count)
+	for i := range m.Acks {
+		ack := new(Ack)
+		ack.VMIndex = m.VMIndex
+		ack.LeaderChainID = m.LeaderChainID
+		ack.DBHeight = m.DBHeight
+		ack.Height = m.Height + uint32(i)
+		ack.Salt = m.Salt
+		ack.batch = m
+
+		ack.Timestamp = new(primitives.Timestamp)
+		newData, err = ack.Timestamp.UnmarshalBinaryData(newData)
+		if err != nil {
+			return nil, err
+		}
+		ack.SaltNumber, newData = binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+		ack.Minute, newData = newData[0], newData[1:]
+
+		ack.MessageHash = new(primitives.Hash)
+		newData, err = ack.MessageHash.UnmarshalBinaryData(newData)
+		if err != nil {
+			return nil, err
+		}
+		newData, err = ack.GetFullMsgHash().UnmarshalBinaryData(newData)
+		if err != nil {
+			return nil, err
+		}
+		ack.SerialHash = new(primitives.Hash)
+		newData, err = ack.SerialHash.UnmarshalBinaryData(newData)
+		if err != nil {
+			return nil, err
+		}
+
+		hasBalance := newData[0]
+		newData = newData[1:]
+		if hasBalance == 1 {
+			ack.BalanceHash = new(primitives.Hash)
+			newData, err = ack.BalanceHash.UnmarshalBinaryData(newData)
+			if err != nil {
+				return nil, err
+			}
+		}
+		ack.GetMsgHash() // Fills in the data area, as for an ack on its own
+		m.Acks[i] = ack
+	}
+
+	if len(newData) > 0 {
+		m.Signature = new(primitives.Signature)
+		newData, err = m.Signature.UnmarshalBinaryData(newData)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (m *AckBatch) UnmarshalBinary(data []byte) error {
+	_, err := m.UnmarshalBinaryData(data)
+	return err
+}
+
+func (m *AckBatch) MarshalForSignature() ([]byte, error) {
+	var buf primitives.Buffer
+
+	binary.Write(&buf, binary.BigEndian, m.Type())
+	binary.Write(&buf, binary.BigEndian, byte(m.VMIndex))
+
+	t := m.GetTimestamp()
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(data)
+
+	buf.Write(m.Salt[:8])
+
+	data, err = m.LeaderChainID.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(data)
+
+	binary.Write(&buf, binary.BigEndian, m.DBHeight)
+	binary.Write(&buf, binary.BigEndian, m.Height)
+	binary.Write(&buf, binary.BigEndian, uint32(len(m.Acks)))
+
+	for _, ack := range m.Acks {
+		data, err = ack.GetTimestamp().MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		binary.Write(&buf, binary.BigEndian, ack.SaltNumber)
+		binary.Write(&buf, binary.BigEndian, ack.Minute)
+
+		for _, h := range []interfaces.IHash{ack.MessageHash, ack.GetFullMsgHash(), ack.SerialHash} {
+			data, err = h.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(data)
+		}
+
+		if ack.BalanceHash == nil {
+			buf.WriteByte(0)
+		} else {
+			buf.WriteByte(1)
+			buf.Write(ack.BalanceHash.Bytes())
+		}
+	}
+
+	return buf.DeepCopyBytes(), nil
+}
+
+func (m *AckBatch) MarshalBinary() (data []byte, err error) {
+	resp, err := m.MarshalForSignature()
+	if err != nil {
+		return nil, err
+	}
+	sig := m.GetSignature()
+
+	if sig != nil {
+		sigBytes, err := sig.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return append(resp, sigBytes...), nil
+	}
+	return resp, nil
+}
+
+func (m *AckBatch) String() string {
+	return fmt.Sprintf("%6s-VM%3d: PL:%5d-%5d DBHt:%5d -- Leader[:3]=%x",
+		"ACKS",
+		m.VMIndex,
+		m.Height,
+		m.Height+uint32(len(m.Acks))-1,
+		m.DBHeight,
+		m.LeaderChainID.Bytes()[:3])
+}
+
+// AckOf returns the ack in the batch of the message with the hash.
+func (m *AckBatch) AckOf(msgHash interfaces.IHash) (*Ack, bool) {
+	for _, ack := range m.Acks {
+		if ack.MessageHash.IsSameAs(msgHash) {
+			return ack, true
+		}
+	}
+	return nil, false
+}
+
+// NewAckBatch bundles the acks, which must be the consecutive unsigned acks
+// of a VM, into a batch signed by the leader.  Each ack then refers to the
+// batch, which is what is sent out for it.
+func NewAckBatch(state interfaces.IState, acks []*Ack) (*AckBatch, error) {
+	if len(acks) == 0 || len(acks) > MaxAckBatch {
+		return nil, fmt.Errorf("Cannot batch %d acks", len(acks))
+	}
+	first := acks[0]
+	for i, ack := range acks {
+		if ack.VMIndex != first.VMIndex || ack.DBHeight != first.DBHeight || ack.Height != first.Height+uint32(i) {
+			return nil, fmt.Errorf("Ack %d of the batch does not follow the one before", i)
+		}
+	}
+
+	m := new(AckBatch)
+	m.Timestamp = state.GetTimestamp()
+	m.VMIndex = first.VMIndex
+	m.LeaderChainID = first.LeaderChainID
+	m.DBHeight = first.DBHeight
+	m.Height = first.Height
+	m.Salt = first.Salt
+	m.Acks = acks
+	if err := m.Sign(state); err != nil {
+		return nil, err
+	}
+	for _, ack := range acks {
+		ack.batch = m
+	}
+	return m, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package messages_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	. "github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestUnmarshalNilAckBatch(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("Panic caught during the test - %v", r)
+		}
+	}()
+
+	a := new(AckBatch)
+	err := a.UnmarshalBinary(nil)
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+
+	err = a.UnmarshalBinary([]byte{})
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+}
+
+func TestMarshalUnmarshalAckBatch(t *testing.T) {
+	acks := newBatchedAcks(5)
+	hashes := make([][32]byte, len(acks))
+	for i, ack := range acks {
+		hashes[i] = ack.GetMsgHash().Fixed()
+	}
+
+	batch, err := NewAckBatch(testHelper.CreateEmptyTestState(), acks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ack := range acks {
+		if ack.GetBatch() != batch {
+			t.Error("Ack does not refer to its batch")
+		}
+	}
+
+	hex, err := batch.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := UnmarshalMessage(hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type() != constants.ACK_BATCH_MSG {
+		t.Error("Invalid message type unmarshalled")
+	}
+	batch2 := msg.(*AckBatch)
+
+	hex2, err := batch2.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hex, hex2) {
+		t.Error("Hexes do not match")
+	}
+	if !batch.IsSameAs(batch2) {
+		t.Error("AckBatch messages are not identical")
+	}
+	if batch2.Signature == nil {
+		t.Error("Signature missing")
+	}
+
+	// The acks out of the batch are those that went in
+	for i, ack := range batch2.Acks {
+		if ack.GetMsgHash().Fixed() != hashes[i] {
+			t.Errorf("Ack %d has a different hash out of the batch", i)
+		}
+		if ack.GetSignature() != nil || ack.GetBatch() != batch2 {
+			t.Errorf("Ack %d should have no signature, and refer to its batch", i)
+		}
+		found, ok := batch2.AckOf(ack.MessageHash)
+		if !ok || found != ack {
+			t.Errorf("AckOf did not find ack %d", i)
+		}
+	}
+}
+
+func TestNewAckBatchNotConsecutive(t *testing.T) {
+	acks := newBatchedAcks(3)
+	acks[2].Height++
+	if _, err := NewAckBatch(testHelper.CreateEmptyTestState(), acks); err == nil {
+		t.Error("Expected an error for a gap in the heights")
+	}
+	if _, err := NewAckBatch(testHelper.CreateEmptyTestState(), nil); err == nil {
+		t.Error("Expected an error for an empty batch")
+	}
+}
+
+func TestMissingMsgResponseWithAckBatch(t *testing.T) {
+	acks := newBatchedAcks(2)
+	batch, err := NewAckBatch(testHelper.CreateEmptyTestState(), acks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mmr := new(MissingMsgResponse)
+	mmr.Timestamp = primitives.NewTimestampNow()
+	mmr.AckResponse = batch
+	mmr.MsgResponse = newEOM()
+
+	hex, err := mmr.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mmr2 := new(MissingMsgResponse)
+	if err = mmr2.UnmarshalBinary(hex); err != nil {
+		t.Fatal(err)
+	}
+	batch2, ok := mmr2.AckResponse.(*AckBatch)
+	if !ok {
+		t.Fatalf("Expected an AckBatch, got %T", mmr2.AckResponse)
+	}
+	if !batch.IsSameAs(batch2) {
+		t.Error("AckBatch messages are not identical")
+	}
+}
+
+// newBatchedAcks returns consecutive unsigned acks of a VM.
+func newBatchedAcks(n int) []*Ack {
+	var acks []*Ack
+	for i := 0; i < n; i++ {
+		ack := newAck()
+		ack.Height += uint32(i)
+		ack.Minute = 3
+		ack.SaltNumber = uint32(i)
+		ack.MessageHash = primitives.Sha([]byte{byte(i)})
+		if i == 0 {
+			ack.BalanceHash = primitives.Sha([]byte("balances"))
+		}
+		acks = append(acks, ack)
+	}
+	return acks
+}
@@ -95,6 +95,11 @@ func (e *AddServerMsg) Process(dbheight uint32, state interfaces.IState) bool {
 	return state.ProcessAddServer(dbheight, e)
 }
 
+func (e *AddServerMsg) MarshalJSON() ([]byte, error) {
+	type expanded AddServerMsg
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *AddServerMsg) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -203,6 +203,11 @@ func (m *AuditServerFault) LeaderExecute(state interfaces.IState) {
 func (m *AuditServerFault) FollowerExecute(interfaces.IState) {
 }
 
+func (e *AuditServerFault) MarshalJSON() ([]byte, error) {
+	type expanded AuditServerFault
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *AuditServerFault) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -100,6 +100,11 @@ func (e *Bounce) Process(dbheight uint32, state interfaces.IState) bool {
 	return true
 }
 
+func (e *Bounce) MarshalJSON() ([]byte, error) {
+	type expanded Bounce
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *Bounce) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -151,6 +156,9 @@ func (m *Bounce) UnmarshalBinaryData(data []byte) (newData []byte, err error) {
 	}
 
 	lenData, newData := binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+	if int(lenData) > len(newData) {
+		return nil, fmt.Errorf("Bounce claims %d bytes of data but only %d remain", lenData, len(newData))
+	}
 
 	m.Data = make([]byte, lenData)
 	copy(m.Data, newData)
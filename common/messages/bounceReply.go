@@ -91,6 +91,11 @@ func (e *BounceReply) Process(dbheight uint32, state interfaces.IState) bool {
 	return true
 }
 
+func (e *BounceReply) MarshalJSON() ([]byte, error) {
+	type expanded BounceReply
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *BounceReply) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	. "github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
 )
 
 func TestUnmarshalNilBounce(t *testing.T) {
@@ -28,3 +29,19 @@ func TestUnmarshalNilBounce(t *testing.T) {
 		t.Errorf("Error is nil when it shouldn't be")
 	}
 }
+
+func TestUnmarshalBounceHugeData(t *testing.T) {
+	b := new(Bounce)
+	b.Name = "x"
+	b.Timestamp = primitives.NewTimestampNow()
+	hex, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Claim four gigabytes of data, in place of none
+	copy(hex[len(hex)-4:], []byte{0xff, 0xff, 0xff, 0xff})
+
+	if err := new(Bounce).UnmarshalBinary(hex); err == nil {
+		t.Errorf("Unmarshalled a bounce claiming more data than it carries")
+	}
+}
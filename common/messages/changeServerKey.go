@@ -116,6 +116,11 @@ func (e *ChangeServerKeyMsg) Process(dbheight uint32, state interfaces.IState) b
 	return state.ProcessChangeServerKey(dbheight, e)
 }
 
+func (e *ChangeServerKeyMsg) MarshalJSON() ([]byte, error) {
+	type expanded ChangeServerKeyMsg
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *ChangeServerKeyMsg) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -135,6 +135,11 @@ func (m *CommitChainMsg) FollowerExecute(state interfaces.IState) {
 	state.FollowerExecuteMsg(m)
 }
 
+func (e *CommitChainMsg) MarshalJSON() ([]byte, error) {
+	type expanded CommitChainMsg
+	return marshalMsgJSON(e.Type(), (*expanded)(e), nil)
+}
+
 func (e *CommitChainMsg) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
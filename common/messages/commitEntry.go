@@ -232,6 +232,11 @@ func (m *CommitEntryMsg) FollowerExecute(state interfaces.IState) {
 	state.FollowerExecuteMsg(m)
 }
 
+func (e *CommitEntryMsg) MarshalJSON() ([]byte, error) {
+	type expanded CommitEntryMsg
+	return marshalMsgJSON(e.Type(), (*expanded)(e), nil)
+}
+
 func (e *CommitEntryMsg) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
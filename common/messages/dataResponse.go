@@ -146,6 +146,11 @@ func (e *DataResponse) Process(dbheight uint32, state interfaces.IState) bool {
 	panic("Should never have its Process() method called")
 }
 
+func (e *DataResponse) MarshalJSON() ([]byte, error) {
+	type expanded DataResponse
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *DataResponse) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -430,6 +430,11 @@ func (e *DBStateMsg) Process(dbheight uint32, state interfaces.IState) bool {
 	panic("DBStatemsg should never have its Process() method called")
 }
 
+func (e *DBStateMsg) MarshalJSON() ([]byte, error) {
+	type expanded DBStateMsg
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *DBStateMsg) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -489,7 +494,7 @@ func (m *DBStateMsg) UnmarshalBinaryData(data []byte) (newData []byte, err error
 		eBlock := entryBlock.NewEBlock()
 		newData, err = eBlock.UnmarshalBinaryData(newData)
 		if err != nil {
-			panic(err.Error())
+			return nil, err
 		}
 		m.EBlocks = append(m.EBlocks, eBlock)
 	}
@@ -499,10 +504,13 @@ func (m *DBStateMsg) UnmarshalBinaryData(data []byte) (newData []byte, err error
 	for i := uint32(0); i < entryCount; i++ {
 		var entrySize uint32
 		entrySize, newData = binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+		if int(entrySize) > len(newData) {
+			return nil, fmt.Errorf("Entry %d claims %d bytes but only %d remain", i, entrySize, len(newData))
+		}
 		entry := entryBlock.NewEntry()
 		newData, err = newData[int(entrySize):], entry.UnmarshalBinary(newData[:int(entrySize)])
 		if err != nil {
-			panic(err.Error())
+			return nil, err
 		}
 		m.Entries = append(m.Entries, entry)
 	}
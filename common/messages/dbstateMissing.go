@@ -172,6 +172,11 @@ func (e *DBStateMissing) Process(dbheight uint32, state interfaces.IState) bool
 	panic("Ack object should never have its Process() method called")
 }
 
+func (e *DBStateMissing) MarshalJSON() ([]byte, error) {
+	type expanded DBStateMissing
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *DBStateMissing) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -365,6 +365,11 @@ func (m *DirectoryBlockSignature) String() string {
 
 }
 
+func (e *DirectoryBlockSignature) MarshalJSON() ([]byte, error) {
+	type expanded DirectoryBlockSignature
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *DirectoryBlockSignature) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
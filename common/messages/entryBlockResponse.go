@@ -141,6 +141,11 @@ func (e *EntryBlockResponse) Process(dbheight uint32, state interfaces.IState) b
 	panic("Ack object should never have its Process() method called")
 }
 
+func (e *EntryBlockResponse) MarshalJSON() ([]byte, error) {
+	type expanded EntryBlockResponse
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *EntryBlockResponse) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
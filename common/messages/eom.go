@@ -151,6 +151,11 @@ func (m *EOM) FollowerExecute(state interfaces.IState) {
 	state.FollowerExecuteEOM(m)
 }
 
+func (e *EOM) MarshalJSON() ([]byte, error) {
+	type expanded EOM
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *EOM) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -198,6 +198,11 @@ func (m *EOMTimeout) LeaderExecute(state interfaces.IState) {
 func (m *EOMTimeout) FollowerExecute(interfaces.IState) {
 }
 
+func (e *EOMTimeout) MarshalJSON() ([]byte, error) {
+	type expanded EOMTimeout
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *EOMTimeout) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
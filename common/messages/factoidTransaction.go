@@ -102,6 +102,11 @@ func (m *FactoidTransaction) Validate(state interfaces.IState) int {
 		return -1 // No, object!
 	}
 
+	// Is the transaction a replay of one already in a block?
+	if state.GetFactoidState().IsRecentTransaction(m.Transaction.GetSigHash()) {
+		return -1
+	}
+
 	// Is the transaction valid at this point in time?
 	err = state.GetFactoidState().Validate(1, m.Transaction)
 	if err != nil {
@@ -198,6 +203,11 @@ func (m *FactoidTransaction) String() string {
 		m.GetHash().Bytes()[:3])
 }
 
+func (e *FactoidTransaction) MarshalJSON() ([]byte, error) {
+	type expanded FactoidTransaction
+	return marshalMsgJSON(e.Type(), (*expanded)(e), nil)
+}
+
 func (e *FactoidTransaction) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -43,7 +43,7 @@ type FullServerFault struct {
 	//Local FaultState information (not marshalled)
 	AmINegotiator bool
 	MyVoteTallied bool
-	LocalVoteMap  map[[32]byte]interfaces.IFullSignature
+	LocalVoteMap  map[[32]byte]interfaces.IFullSignature `json:"-"`
 	PledgeDone    bool
 	LastMatch     int64
 }
@@ -599,6 +599,11 @@ func (m *FullServerFault) FollowerExecute(state interfaces.IState) {
 	state.FollowerExecuteFullFault(m)
 }
 
+func (e *FullServerFault) MarshalJSON() ([]byte, error) {
+	type expanded FullServerFault
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *FullServerFault) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
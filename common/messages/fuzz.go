@@ -0,0 +1,64 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// +build gofuzz
+
+package messages
+
+import (
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// The go-fuzz harnesses for the messages a peer sends us.  Build and run them
+// with
+//
+//	go-fuzz-build -func Fuzz github.com/FactomProject/factomd/common/messages
+//	go-fuzz -bin=messages-fuzz.zip -workdir=fuzz/messages
+//
+// Every crasher found is a panic or a hang on bytes from the network, and
+// should be fixed in the message's UnmarshalBinaryData with a regression test.
+
+// Fuzz unmarshals the data as the network would, which reaches every message
+// type routed by UnmarshalMessage through its type byte.
+func Fuzz(data []byte) int {
+	msg, err := UnmarshalMessage(data)
+	if err != nil {
+		return 0
+	}
+	return fuzzUse(msg)
+}
+
+// FuzzEntryBlocks covers the entry block messages, which UnmarshalMessage
+// does not route but which still parse bytes from peers.
+func FuzzEntryBlocks(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+	var msg interfaces.IMsg
+	switch data[0] {
+	case constants.MISSING_ENTRY_BLOCKS:
+		msg = new(MissingEntryBlocks)
+	case constants.ENTRY_BLOCK_RESPONSE:
+		msg = new(EntryBlockResponse)
+	default:
+		return -1
+	}
+	if _, err := msg.UnmarshalBinaryData(data); err != nil {
+		return 0
+	}
+	return fuzzUse(msg)
+}
+
+// fuzzUse does what a node does with a message it has just unmarshalled,
+// so that half-built messages which panic later are found too.
+func fuzzUse(msg interfaces.IMsg) int {
+	_ = msg.String()
+	if _, err := msg.MarshalBinary(); err != nil {
+		return 0
+	}
+	_ = msg.GetHash()
+	_ = msg.GetMsgHash()
+	return 1
+}
@@ -28,6 +28,11 @@ func UnmarshalMessageData(data []byte) (newdata []byte, msg interfaces.IMsg, err
 	}
 	messageType := data[0]
 
+	// Reject oversized messages before we spend any effort on them
+	if err := CheckMessageSize(data); err != nil {
+		return data, nil, err
+	}
+
 	switch messageType {
 	case constants.EOM_MSG:
 		msg = new(EOM)
@@ -81,6 +86,12 @@ func UnmarshalMessageData(data []byte) (newdata []byte, msg interfaces.IMsg, err
 		msg = new(Bounce)
 	case constants.BOUNCEREPLY_MSG:
 		msg = new(BounceReply)
+	case constants.STATE_SNAPSHOT_REQUEST:
+		msg = new(StateSnapshotRequest)
+	case constants.STATE_SNAPSHOT_RESPONSE:
+		msg = new(StateSnapshotResponse)
+	case constants.ACK_BATCH_MSG:
+		msg = new(AckBatch)
 	default:
 		fmt.Sprintf("Transaction Failed to Validate %x", data[0])
 		return data, nil, fmt.Errorf("Unknown message type %d %x", messageType, data[0])
@@ -142,15 +153,124 @@ func MessageName(Type byte) string {
 		return "DBState Missing"
 	case constants.DBSTATE_MSG:
 		return "DBState"
+	case constants.ADDSERVER_MSG:
+		return "Add Server"
+	case constants.CHANGESERVER_KEY_MSG:
+		return "Change Server Key"
+	case constants.REMOVESERVER_MSG:
+		return "Remove Server"
 	case constants.BOUNCE_MSG:
 		return "Bounce Message"
 	case constants.BOUNCEREPLY_MSG:
 		return "Bounce Reply Message"
+	case constants.MISSING_ENTRY_BLOCKS:
+		return "Missing Entry Blocks"
+	case constants.ENTRY_BLOCK_RESPONSE:
+		return "Entry Block Response"
+	case constants.STATE_SNAPSHOT_REQUEST:
+		return "State Snapshot Request"
+	case constants.STATE_SNAPSHOT_RESPONSE:
+		return "State Snapshot Response"
+	case constants.ACK_BATCH_MSG:
+		return "Ack Batch"
 	default:
 		return "Unknown:" + fmt.Sprintf(" %d", Type)
 	}
 }
 
+const (
+	kb = 1024
+	mb = 1024 * kb
+)
+
+// MaxMessageSize returns the largest marshalled size we accept for a message
+// of the given type.  Messages bigger than this are rejected before we try to
+// unmarshal them.  Returns 0 for unknown message types.
+func MaxMessageSize(Type byte) int {
+	switch Type {
+	case constants.EOM_MSG:
+		return 1 * kb
+	case constants.ACK_MSG:
+		return 16 * kb // Carries a variable length data area
+	case constants.AUDIT_SERVER_FAULT_MSG:
+		return 1 * kb
+	case constants.FED_SERVER_FAULT_MSG:
+		return 1 * kb
+	case constants.FULL_SERVER_FAULT_MSG:
+		return 64 * kb // Carries a list of signatures from the federated servers
+	case constants.COMMIT_CHAIN_MSG:
+		return 1 * kb
+	case constants.COMMIT_ENTRY_MSG:
+		return 1 * kb
+	case constants.DIRECTORY_BLOCK_SIGNATURE_MSG:
+		return 2 * kb
+	case constants.EOM_TIMEOUT_MSG:
+		return 1 * kb
+	case constants.FACTOID_TRANSACTION_MSG:
+		return constants.MAX_TRANSACTION_SIZE + 1*kb
+	case constants.HEARTBEAT_MSG:
+		return 1 * kb
+	case constants.INVALID_DIRECTORY_BLOCK_MSG:
+		return 1 * kb
+	case constants.MISSING_MSG:
+		return 16 * kb // Can ask for many process list heights
+	case constants.MISSING_MSG_RESPONSE:
+		return 64 * kb // Carries both an ack and the message
+	case constants.MISSING_DATA:
+		return 1 * kb
+	case constants.DATA_RESPONSE:
+		return 4 * mb // Can carry a whole entry block
+	case constants.REVEAL_ENTRY_MSG:
+		return 12 * kb // 10K entry plus overhead
+	case constants.REQUEST_BLOCK_MSG:
+		return 1 * kb
+	case constants.SIGNATURE_TIMEOUT_MSG:
+		return 1 * kb
+	case constants.DBSTATE_MISSING_MSG:
+		return 1 * kb
+	case constants.DBSTATE_MSG:
+		return 256 * mb // Whole blocks, with all their entries
+	case constants.ADDSERVER_MSG:
+		return 1 * kb
+	case constants.CHANGESERVER_KEY_MSG:
+		return 1 * kb
+	case constants.REMOVESERVER_MSG:
+		return 1 * kb
+	case constants.BOUNCE_MSG:
+		return 1 * mb
+	case constants.BOUNCEREPLY_MSG:
+		return 1 * mb
+	case constants.MISSING_ENTRY_BLOCKS:
+		return 1 * kb
+	case constants.ENTRY_BLOCK_RESPONSE:
+		return 256 * mb // Many entry blocks, with all their entries
+	case constants.STATE_SNAPSHOT_REQUEST:
+		return 1 * kb
+	case constants.STATE_SNAPSHOT_RESPONSE:
+		return 256 * mb // Every balance, identity and server
+	case constants.ACK_BATCH_MSG:
+		return 192 * kb // MaxAckBatch acks of under 150 bytes each
+	default:
+		return 0
+	}
+}
+
+// CheckMessageSize makes sure the given marshalled message is no larger than
+// what its type allows, without unmarshalling it.
+func CheckMessageSize(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("No data provided")
+	}
+	max := MaxMessageSize(data[0])
+	if max == 0 {
+		return fmt.Errorf("Unknown message type %d %x", data[0], data[0])
+	}
+	if len(data) > max {
+		return fmt.Errorf("Message of type %s is %d bytes, more than the maximum of %d", MessageName(data[0]), len(data), max)
+	}
+	return nil
+}
+
 type Signable interface {
 	Sign(interfaces.Signer) error
 	MarshalForSignature() ([]byte, error)
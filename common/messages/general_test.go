@@ -7,6 +7,8 @@ package messages_test
 import (
 	"testing"
 
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
 	. "github.com/FactomProject/factomd/common/messages"
 )
 
@@ -27,3 +29,41 @@ func TestUnmarshalNil(t *testing.T) {
 		t.Errorf("Error is nil when it shouldn't be")
 	}
 }
+
+func TestUnmarshalOversized(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("Panic caught during the test - %v", r)
+		}
+	}()
+
+	data := make([]byte, 512*1024)
+	data[0] = constants.REMOVESERVER_MSG
+	_, _, err := UnmarshalMessageData(data)
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+
+	err = CheckMessageSize(data)
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+
+	err = CheckMessageSize(data[:MaxMessageSize(constants.REMOVESERVER_MSG)])
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	for _, msg := range []interfaces.IMsg{newSignedEOM(), newSignedAck(), newSignedCommitChain(), newSignedHeartbeat(), newRevealEntry()} {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Errorf("%v", err)
+			continue
+		}
+		if len(data) > MaxMessageSize(msg.Type()) {
+			t.Errorf("%v is %d bytes, over its maximum of %d", MessageName(msg.Type()), len(data), MaxMessageSize(msg.Type()))
+		}
+	}
+}
@@ -282,6 +282,11 @@ func (m *Heartbeat) FollowerExecute(state interfaces.IState) {
 	}
 }
 
+func (e *Heartbeat) MarshalJSON() ([]byte, error) {
+	type expanded Heartbeat
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *Heartbeat) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -208,6 +208,11 @@ func (m *InvalidDirectoryBlock) LeaderExecute(state interfaces.IState) {
 func (m *InvalidDirectoryBlock) FollowerExecute(interfaces.IState) {
 }
 
+func (e *InvalidDirectoryBlock) MarshalJSON() ([]byte, error) {
+	type expanded InvalidDirectoryBlock
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *InvalidDirectoryBlock) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -0,0 +1,59 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package messages
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// The canonical JSON form of a message is the JSON of all of its exported
+// fields, with keys sorted, plus:
+//
+//   "Type"     - the name of the message type, as returned by MessageName
+//   "TypeByte" - the numeric message type
+//   "Timestamp" - the message timestamp in ISO 8601 (UTC, millisecond precision)
+//
+// Hashes, keys and signatures are encoded as hex strings by their own
+// MarshalText functions.
+
+// MsgJSONTimeFormat is the layout used for every timestamp in message JSON.
+const MsgJSONTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// JSONTimestamp formats a message timestamp the way the canonical JSON does.
+func JSONTimestamp(ts interfaces.Timestamp) string {
+	return time.Unix(0, ts.GetTimeMilli()*int64(time.Millisecond)).UTC().Format(MsgJSONTimeFormat)
+}
+
+// marshalMsgJSON builds the canonical JSON for a message.  expanded must be the
+// message converted to a type without a MarshalJSON function, so the default
+// encoding can be used for the fields.  ts may be nil for messages that do not
+// carry their own timestamp.
+func marshalMsgJSON(msgType byte, expanded interface{}, ts interfaces.Timestamp) ([]byte, error) {
+	data, err := json.Marshal(expanded)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for k, v := range raw {
+		fields[k] = v
+	}
+
+	fields["Type"] = MessageName(msgType)
+	fields["TypeByte"] = msgType
+	if ts != nil {
+		fields["Timestamp"] = JSONTimestamp(ts)
+	}
+
+	// encoding/json sorts map keys, which keeps the output stable
+	return json.Marshal(fields)
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package messages_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	. "github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+func TestMessageJSON(t *testing.T) {
+	msgs := []interfaces.IMsg{
+		newAck(),
+		newSignedAck(),
+		newAddServer(),
+		newAuditServerFault(),
+		newChangeServerKey(),
+		newCommitChain(),
+		newCommitEntry(),
+		newDataResponseEntry(),
+		newDBStateMissing(),
+		newDirectoryBlockSignature(),
+		newEOMTimeout(),
+		newSignedEOM(),
+		newFactoidTransaction(),
+		newHeartbeat(),
+		newInvalidDirectoryBlock(),
+		newMissingData(),
+		newMissingMsg(),
+		newRequestBlock(),
+		newRevealEntry(),
+		newSignatureTimeout(),
+	}
+
+	for _, msg := range msgs {
+		j1, err := msg.JSONByte()
+		if err != nil {
+			t.Errorf("%v - %v", MessageName(msg.Type()), err)
+			continue
+		}
+		j2, err := msg.JSONByte()
+		if err != nil {
+			t.Errorf("%v - %v", MessageName(msg.Type()), err)
+			continue
+		}
+		if string(j1) != string(j2) {
+			t.Errorf("%v - JSON is not stable", MessageName(msg.Type()))
+		}
+
+		fields := map[string]interface{}{}
+		err = json.Unmarshal(j1, &fields)
+		if err != nil {
+			t.Errorf("%v - %v", MessageName(msg.Type()), err)
+			continue
+		}
+		if fields["Type"] != MessageName(msg.Type()) {
+			t.Errorf("%v - invalid Type %v", MessageName(msg.Type()), fields["Type"])
+		}
+		if fields["TypeByte"] != float64(msg.Type()) {
+			t.Errorf("%v - invalid TypeByte %v", MessageName(msg.Type()), fields["TypeByte"])
+		}
+	}
+}
+
+func TestMessageJSONFields(t *testing.T) {
+	eom := newEOM()
+
+	fields := map[string]interface{}{}
+	j, err := eom.JSONByte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(j, &fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fields["Timestamp"] != JSONTimestamp(eom.Timestamp) {
+		t.Errorf("Invalid Timestamp %v", fields["Timestamp"])
+	}
+	if fields["ChainID"] != "deadbeef00000000000000000000000000000000000000000000000000000000" {
+		t.Errorf("Invalid ChainID %v", fields["ChainID"])
+	}
+}
+
+func TestJSONTimestamp(t *testing.T) {
+	ts := primitives.NewTimestampFromMilliseconds(1483228800123)
+	if JSONTimestamp(ts) != "2017-01-01T00:00:00.123Z" {
+		t.Errorf("Invalid timestamp %v", JSONTimestamp(ts))
+	}
+}
@@ -172,6 +172,11 @@ func (m *MissingData) FollowerExecute(state interfaces.IState) {
 	return
 }
 
+func (e *MissingData) MarshalJSON() ([]byte, error) {
+	type expanded MissingData
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *MissingData) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
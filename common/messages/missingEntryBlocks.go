@@ -144,6 +144,11 @@ func (e *MissingEntryBlocks) Process(dbheight uint32, state interfaces.IState) b
 	panic("Ack object should never have its Process() method called")
 }
 
+func (e *MissingEntryBlocks) MarshalJSON() ([]byte, error) {
+	type expanded MissingEntryBlocks
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *MissingEntryBlocks) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -127,6 +127,9 @@ func (m *MissingMsg) UnmarshalBinaryData(data []byte) (newData []byte, err error
 
 	// Get all the missing messages...
 	lenl, newData := binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+	if int(lenl) > len(newData)/4 {
+		return nil, fmt.Errorf("MissingMsg claims %d heights but only %d bytes remain", lenl, len(newData))
+	}
 	for i := 0; i < int(lenl); i++ {
 		var height uint32
 		height, newData = binary.BigEndian.Uint32(newData[0:4]), newData[4:]
@@ -225,6 +228,11 @@ func (m *MissingMsg) FollowerExecute(state interfaces.IState) {
 	state.FollowerExecuteMissingMsg(m)
 }
 
+func (e *MissingMsg) MarshalJSON() ([]byte, error) {
+	type expanded MissingMsg
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *MissingMsg) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -108,8 +108,13 @@ func (m *MissingMsgResponse) UnmarshalBinaryData(data []byte) (newData []byte, e
 
 	b, newData := newData[0], newData[1:]
 
-	if b == 1 {
+	switch b {
+	case 1:
 		m.AckResponse = new(Ack)
+	case 2:
+		m.AckResponse = new(AckBatch) // The batch that signs for the ack
+	}
+	if m.AckResponse != nil {
 		newData, err = m.AckResponse.UnmarshalBinaryData(newData)
 
 		if err != nil {
@@ -149,7 +154,11 @@ func (m *MissingMsgResponse) MarshalBinary() ([]byte, error) {
 	if m.AckResponse == nil {
 		buf.WriteByte(0)
 	} else {
-		buf.WriteByte(1)
+		if _, ok := m.AckResponse.(*AckBatch); ok {
+			buf.WriteByte(2)
+		} else {
+			buf.WriteByte(1)
+		}
 
 		ackData, err := m.AckResponse.MarshalBinary()
 		if err != nil {
@@ -220,6 +229,11 @@ func (m *MissingMsgResponse) FollowerExecute(state interfaces.IState) {
 	return
 }
 
+func (e *MissingMsgResponse) MarshalJSON() ([]byte, error) {
+	type expanded MissingMsgResponse
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *MissingMsgResponse) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
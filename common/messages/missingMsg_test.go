@@ -69,6 +69,19 @@ func TestMarshalUnmarshalMissingMsg(t *testing.T) {
 	}
 }
 
+func TestUnmarshalMissingMsgHugeCount(t *testing.T) {
+	hex, err := newMissingMsg().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Claim four billion heights, in place of the one sent
+	copy(hex[len(hex)-8:], []byte{0xff, 0xff, 0xff, 0xff})
+
+	if err := new(MissingMsg).UnmarshalBinary(hex); err == nil {
+		t.Errorf("Unmarshalled a message claiming more heights than it carries")
+	}
+}
+
 func newMissingMsg() *MissingMsg {
 	msg := new(MissingMsg)
 	msg.Timestamp = primitives.NewTimestampNow()
@@ -100,6 +100,11 @@ func (e *RemoveServerMsg) Process(dbheight uint32, state interfaces.IState) bool
 	return state.ProcessRemoveServer(dbheight, e)
 }
 
+func (e *RemoveServerMsg) MarshalJSON() ([]byte, error) {
+	type expanded RemoveServerMsg
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *RemoveServerMsg) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -162,6 +162,11 @@ func (m *RequestBlock) LeaderExecute(state interfaces.IState) {
 func (m *RequestBlock) FollowerExecute(interfaces.IState) {
 }
 
+func (e *RequestBlock) MarshalJSON() ([]byte, error) {
+	type expanded RequestBlock
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *RequestBlock) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
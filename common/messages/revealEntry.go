@@ -5,8 +5,12 @@
 package messages
 
 import (
+	"bytes"
+	"compress/flate"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"io/ioutil"
 
 	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/entryBlock"
@@ -32,6 +36,21 @@ type RevealEntryMsg struct {
 
 var _ interfaces.IMsg = (*RevealEntryMsg)(nil)
 
+// RevealEntryCompressOver, if above 0, is the size of entry content in bytes
+// over which a reveal goes out with its content compressed.  Large JSON and
+// document entries shrink several times over.  Nodes older than compression
+// reject the flagged version byte, so it is off until every node of the
+// network has been upgraded.
+var RevealEntryCompressOver = 0
+
+const (
+	// Set in the version byte of the entry of a reveal whose content is
+	// compressed with DEFLATE.  Entries themselves are all version 0.
+	CompressedEntryFlag = 0x80
+	// The most bytes the content of a compressed reveal may expand to.
+	MaxRevealContent = 10240
+)
+
 func (m *RevealEntryMsg) IsSameAs(msg interfaces.IMsg) bool {
 	m2, ok := msg.(*RevealEntryMsg)
 	if !ok {
@@ -119,12 +138,12 @@ func (m *RevealEntryMsg) Validate(state interfaces.IState) int {
 			eb = eb_db2
 		}
 		if eb == nil {
-			eb, _ = db.FetchEBlockHead(m.Entry.GetChainID())
-		}
-
-		if eb == nil {
-			// No chain, we have to leave it be and maybe one will be made.
-			return 0
+			// Only the chain head index is needed, not the head block itself
+			exists, _ := db.DoesChainExist(m.Entry.GetChainID())
+			if !exists {
+				// No chain, we have to leave it be and maybe one will be made.
+				return 0
+			}
 		}
 		return 1
 	}
@@ -153,6 +172,11 @@ func (m *RevealEntryMsg) FollowerExecute(state interfaces.IState) {
 	state.FollowerExecuteRevealEntry(m)
 }
 
+func (e *RevealEntryMsg) MarshalJSON() ([]byte, error) {
+	type expanded RevealEntryMsg
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *RevealEntryMsg) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -189,6 +213,13 @@ func (m *RevealEntryMsg) UnmarshalBinaryData(data []byte) (newData []byte, err e
 	if err != nil {
 		return nil, err
 	}
+	if e.Version&CompressedEntryFlag != 0 {
+		e.Version &^= CompressedEntryFlag
+		e.Content.Bytes, err = expandContent(e.Content.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	}
 	m.Entry = e
 
 	return newData, nil
@@ -215,11 +246,69 @@ func (m *RevealEntryMsg) MarshalBinary() (data []byte, err error) {
 	if err != nil {
 		return nil, err
 	}
+	if RevealEntryCompressOver > 0 {
+		data, err = compressEntry(data)
+		if err != nil {
+			return nil, err
+		}
+	}
 	buf.Write(data)
 
 	return buf.DeepCopyBytes(), nil
 }
 
+// compressEntry compresses the content of the marshalled entry, if it is over
+// RevealEntryCompressOver and gets smaller, and flags it in the version byte.
+// The version, chain ID and external IDs are left as they are.
+func compressEntry(data []byte) ([]byte, error) {
+	if len(data) < 35 || data[0]&CompressedEntryFlag != 0 {
+		return data, nil
+	}
+	header := 35 + int(binary.BigEndian.Uint16(data[33:35]))
+	if header > len(data) {
+		return data, nil
+	}
+	content := data[header:]
+	if len(content) <= RevealEntryCompressOver {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(content); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	if buf.Len() >= len(content) {
+		return data, nil
+	}
+
+	compressed := make([]byte, 0, header+buf.Len())
+	compressed = append(compressed, data[:header]...)
+	compressed[0] |= CompressedEntryFlag
+	return append(compressed, buf.Bytes()...), nil
+}
+
+// expandContent decompresses the content of a compressed reveal, refusing
+// any that expands past MaxRevealContent.
+func expandContent(compressed []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(compressed))
+	defer r.Close()
+	content, err := ioutil.ReadAll(io.LimitReader(r, MaxRevealContent+1))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid compressed entry content: %v", err)
+	}
+	if len(content) > MaxRevealContent {
+		return nil, fmt.Errorf("Compressed entry content expands past %d bytes", MaxRevealContent)
+	}
+	return content, nil
+}
+
 func (m *RevealEntryMsg) String() string {
 	if m.GetLeaderChainID() == nil {
 		m.SetLeaderChainID(primitives.NewZeroHash())
@@ -5,6 +5,7 @@
 package messages_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/FactomProject/factomd/common/constants"
@@ -65,6 +66,75 @@ func TestMarshalUnmarshalRevealEntry(t *testing.T) {
 	}
 }
 
+func TestMarshalUnmarshalCompressedRevealEntry(t *testing.T) {
+	defer func(over int) { RevealEntryCompressOver = over }(RevealEntryCompressOver)
+
+	re := newRevealEntry()
+	re.Entry.(*entryBlock.Entry).Content.Bytes = bytes.Repeat([]byte(`{"document":"text","more":"text"}`), 200)
+	hash := re.Entry.GetHash().Fixed()
+
+	plain, err := re.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	RevealEntryCompressOver = 1024
+	hex, err := re.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hex) >= len(plain)/5 {
+		t.Errorf("Compressed reveal of %d bytes, from %d", len(hex), len(plain))
+	}
+
+	re2, err := UnmarshalMessage(hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := re2.(*RevealEntryMsg).Entry.(*entryBlock.Entry)
+	if entry.Version != 0 {
+		t.Errorf("Entry version %d, expected 0", entry.Version)
+	}
+	if entry.GetHash().Fixed() != hash {
+		t.Error("Entry hash changed through compression")
+	}
+	if !entry.IsSameAs(re.Entry.(*entryBlock.Entry)) {
+		t.Error("Entries do not match")
+	}
+
+	// Small entries, and nodes not compressing, send the entry as it is
+	RevealEntryCompressOver = 0
+	hex2, err := re2.(*RevealEntryMsg).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hex2, plain) {
+		t.Error("Uncompressed reveal does not match")
+	}
+	RevealEntryCompressOver = 1024
+	small := newRevealEntry()
+	hex, _ = small.MarshalBinary()
+	RevealEntryCompressOver = 0
+	hex2, _ = small.MarshalBinary()
+	if !bytes.Equal(hex, hex2) {
+		t.Error("Small reveal was compressed")
+	}
+}
+
+func TestCompressedRevealEntryTooLarge(t *testing.T) {
+	defer func(over int) { RevealEntryCompressOver = over }(RevealEntryCompressOver)
+
+	re := newRevealEntry()
+	re.Entry.(*entryBlock.Entry).Content.Bytes = make([]byte, MaxRevealContent+1)
+	RevealEntryCompressOver = 1024
+	hex, err := re.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = UnmarshalMessage(hex); err == nil {
+		t.Error("Expected an error for content expanding past the limit")
+	}
+}
+
 func newRevealEntry() *RevealEntryMsg {
 	re := new(RevealEntryMsg)
 
@@ -284,6 +284,11 @@ func (m *ServerFault) FollowerExecute(state interfaces.IState) {
 	state.FollowerExecuteSFault(m)
 }
 
+func (e *ServerFault) MarshalJSON() ([]byte, error) {
+	type expanded ServerFault
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *ServerFault) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
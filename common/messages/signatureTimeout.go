@@ -201,6 +201,11 @@ func (m *SignatureTimeout) LeaderExecute(state interfaces.IState) {
 func (m *SignatureTimeout) FollowerExecute(interfaces.IState) {
 }
 
+func (e *SignatureTimeout) MarshalJSON() ([]byte, error) {
+	type expanded SignatureTimeout
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
 func (e *SignatureTimeout) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
@@ -0,0 +1,186 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package messages
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+//Requests the state snapshot at a DBlock height, from a node starting at a
+//trusted checkpoint in place of the genesis block
+
+type StateSnapshotRequest struct {
+	MessageBase
+	Timestamp interfaces.Timestamp
+
+	DBHeight uint32 // Height of the checkpoint
+
+	//Not signed!
+}
+
+var _ interfaces.IMsg = (*StateSnapshotRequest)(nil)
+
+func (a *StateSnapshotRequest) IsSameAs(b *StateSnapshotRequest) bool {
+	if b == nil {
+		return false
+	}
+	if a.Timestamp.GetTimeMilli() != b.Timestamp.GetTimeMilli() {
+		return false
+	}
+	if a.DBHeight != b.DBHeight {
+		return false
+	}
+
+	return true
+}
+
+func (m *StateSnapshotRequest) GetRepeatHash() interfaces.IHash {
+	return m.GetMsgHash()
+}
+
+func (m *StateSnapshotRequest) GetHash() interfaces.IHash {
+	return m.GetMsgHash()
+}
+
+func (m *StateSnapshotRequest) GetMsgHash() interfaces.IHash {
+	if m.MsgHash == nil {
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return nil
+		}
+		m.MsgHash = primitives.Sha(data)
+	}
+	return m.MsgHash
+}
+
+func (m *StateSnapshotRequest) Type() byte {
+	return constants.STATE_SNAPSHOT_REQUEST
+}
+
+func (m *StateSnapshotRequest) GetTimestamp() interfaces.Timestamp {
+	return m.Timestamp
+}
+
+// Validate the message, given the state.  Three possible results:
+//  < 0 -- Message is invalid.  Discard
+//  0   -- Cannot tell if message is Valid
+//  1   -- Message is valid
+func (m *StateSnapshotRequest) Validate(state interfaces.IState) int {
+	return 1
+}
+
+func (m *StateSnapshotRequest) ComputeVMIndex(state interfaces.IState) {
+}
+
+// Execute the leader functions of the given message
+func (m *StateSnapshotRequest) LeaderExecute(state interfaces.IState) {
+	m.FollowerExecute(state)
+}
+
+// FollowerExecute answers with the snapshot, if we still have the state at
+// the height asked for.
+func (m *StateSnapshotRequest) FollowerExecute(state interfaces.IState) {
+	if state.NetworkOutMsgQueue().Length() > 1000 {
+		return
+	}
+	snapshot := state.GetStateSnapshot(m.DBHeight)
+	if snapshot == nil {
+		return
+	}
+
+	resp := NewStateSnapshotResponse(state, m.DBHeight, snapshot)
+	resp.SetOrigin(m.GetOrigin())
+	resp.SetNetworkOrigin(m.GetNetworkOrigin())
+	resp.SendOut(state, resp)
+	state.IncDBStateAnswerCnt()
+}
+
+// Acknowledgements do not go into the process list.
+func (e *StateSnapshotRequest) Process(dbheight uint32, state interfaces.IState) bool {
+	panic("StateSnapshotRequest object should never have its Process() method called")
+}
+
+func (e *StateSnapshotRequest) MarshalJSON() ([]byte, error) {
+	type expanded StateSnapshotRequest
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
+func (e *StateSnapshotRequest) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(e)
+}
+
+func (e *StateSnapshotRequest) JSONString() (string, error) {
+	return primitives.EncodeJSONString(e)
+}
+
+func (m *StateSnapshotRequest) UnmarshalBinaryData(data []byte) (newData []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Error unmarshalling State Snapshot Request Message: %v", r)
+		}
+	}()
+	newData = data
+	if newData[0] != m.Type() {
+		return nil, fmt.Errorf("Invalid Message type")
+	}
+	newData = newData[1:]
+
+	m.Peer2Peer = true // This is always a Peer2peer message
+
+	m.Timestamp = new(primitives.Timestamp)
+	newData, err = m.Timestamp.UnmarshalBinaryData(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	m.DBHeight, newData = binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+
+	return
+}
+
+func (m *StateSnapshotRequest) UnmarshalBinary(data []byte) error {
+	_, err := m.UnmarshalBinaryData(data)
+	return err
+}
+
+func (m *StateSnapshotRequest) MarshalForSignature() ([]byte, error) {
+	var buf primitives.Buffer
+
+	binary.Write(&buf, binary.BigEndian, m.Type())
+
+	t := m.GetTimestamp()
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(data)
+
+	binary.Write(&buf, binary.BigEndian, m.DBHeight)
+
+	return buf.DeepCopyBytes(), nil
+}
+
+func (m *StateSnapshotRequest) MarshalBinary() ([]byte, error) {
+	return m.MarshalForSignature()
+}
+
+func (m *StateSnapshotRequest) String() string {
+	return fmt.Sprintf("StateSnapshotRequest: %d", m.DBHeight)
+}
+
+func NewStateSnapshotRequest(state interfaces.IState, dbheight uint32) interfaces.IMsg {
+	msg := new(StateSnapshotRequest)
+
+	msg.Peer2Peer = true // Always a peer2peer request.
+	msg.Timestamp = state.GetTimestamp()
+	msg.DBHeight = dbheight
+
+	return msg
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package messages_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	. "github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+func TestUnmarshalNilStateSnapshotRequest(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("Panic caught during the test - %v", r)
+		}
+	}()
+
+	a := new(StateSnapshotRequest)
+	err := a.UnmarshalBinary(nil)
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+
+	err = a.UnmarshalBinary([]byte{})
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+}
+
+func TestMarshalUnmarshalStateSnapshotRequest(t *testing.T) {
+	msg := newStateSnapshotRequest()
+
+	hex, err := msg.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+
+	msg2, err := UnmarshalMessage(hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg2.Type() != constants.STATE_SNAPSHOT_REQUEST {
+		t.Error("Invalid message type unmarshalled")
+	}
+
+	hex2, err := msg2.(*StateSnapshotRequest).MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(hex, hex2) {
+		t.Error("Hexes do not match")
+	}
+
+	if msg.IsSameAs(msg2.(*StateSnapshotRequest)) != true {
+		t.Errorf("StateSnapshotRequest messages are not identical")
+	}
+}
+
+func newStateSnapshotRequest() *StateSnapshotRequest {
+	msg := new(StateSnapshotRequest)
+	msg.Timestamp = primitives.NewTimestampNow()
+
+	msg.DBHeight = 0x01234567
+
+	return msg
+}
@@ -0,0 +1,194 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package messages
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+//Carries the state snapshot at a DBlock height: the blocks at that height and
+//the balances, identities and servers after them.  The snapshot is opaque
+//here; the state checks it against its trusted checkpoint.
+
+type StateSnapshotResponse struct {
+	MessageBase
+	Timestamp interfaces.Timestamp
+
+	DBHeight uint32
+	Snapshot []byte
+
+	//Not signed!
+}
+
+var _ interfaces.IMsg = (*StateSnapshotResponse)(nil)
+
+func (a *StateSnapshotResponse) IsSameAs(b *StateSnapshotResponse) bool {
+	if b == nil {
+		return false
+	}
+	if a.Timestamp.GetTimeMilli() != b.Timestamp.GetTimeMilli() {
+		return false
+	}
+	if a.DBHeight != b.DBHeight {
+		return false
+	}
+	if !bytes.Equal(a.Snapshot, b.Snapshot) {
+		return false
+	}
+
+	return true
+}
+
+func (m *StateSnapshotResponse) GetRepeatHash() interfaces.IHash {
+	return m.GetMsgHash()
+}
+
+func (m *StateSnapshotResponse) GetHash() interfaces.IHash {
+	return m.GetMsgHash()
+}
+
+func (m *StateSnapshotResponse) GetMsgHash() interfaces.IHash {
+	if m.MsgHash == nil {
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return nil
+		}
+		m.MsgHash = primitives.Sha(data)
+	}
+	return m.MsgHash
+}
+
+func (m *StateSnapshotResponse) Type() byte {
+	return constants.STATE_SNAPSHOT_RESPONSE
+}
+
+func (m *StateSnapshotResponse) GetTimestamp() interfaces.Timestamp {
+	return m.Timestamp
+}
+
+// Validate the message, given the state.  Three possible results:
+//  < 0 -- Message is invalid.  Discard
+//  0   -- Cannot tell if message is Valid
+//  1   -- Message is valid
+func (m *StateSnapshotResponse) Validate(state interfaces.IState) int {
+	if len(m.Snapshot) == 0 {
+		return -1
+	}
+	return 1
+}
+
+func (m *StateSnapshotResponse) ComputeVMIndex(state interfaces.IState) {
+}
+
+// Execute the leader functions of the given message
+func (m *StateSnapshotResponse) LeaderExecute(state interfaces.IState) {
+	m.FollowerExecute(state)
+}
+
+func (m *StateSnapshotResponse) FollowerExecute(state interfaces.IState) {
+	state.FollowerExecuteStateSnapshot(m)
+}
+
+// Acknowledgements do not go into the process list.
+func (e *StateSnapshotResponse) Process(dbheight uint32, state interfaces.IState) bool {
+	panic("StateSnapshotResponse object should never have its Process() method called")
+}
+
+func (e *StateSnapshotResponse) MarshalJSON() ([]byte, error) {
+	type expanded StateSnapshotResponse
+	return marshalMsgJSON(e.Type(), (*expanded)(e), e.Timestamp)
+}
+
+func (e *StateSnapshotResponse) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(e)
+}
+
+func (e *StateSnapshotResponse) JSONString() (string, error) {
+	return primitives.EncodeJSONString(e)
+}
+
+func (m *StateSnapshotResponse) UnmarshalBinaryData(data []byte) (newData []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Error unmarshalling State Snapshot Response Message: %v", r)
+		}
+	}()
+	newData = data
+	if newData[0] != m.Type() {
+		return nil, fmt.Errorf("Invalid Message type")
+	}
+	newData = newData[1:]
+
+	m.Peer2Peer = true // This is always a Peer2peer message
+
+	m.Timestamp = new(primitives.Timestamp)
+	newData, err = m.Timestamp.UnmarshalBinaryData(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	m.DBHeight, newData = binary.BigEndian.Uint32(newData[0:4]), newData[4:]
+
+	buf := primitives.NewBuffer(newData)
+	m.Snapshot, err = buf.PopBytes()
+	if err != nil {
+		return nil, err
+	}
+	newData = buf.DeepCopyBytes()
+
+	return
+}
+
+func (m *StateSnapshotResponse) UnmarshalBinary(data []byte) error {
+	_, err := m.UnmarshalBinaryData(data)
+	return err
+}
+
+func (m *StateSnapshotResponse) MarshalForSignature() ([]byte, error) {
+	var buf primitives.Buffer
+
+	binary.Write(&buf, binary.BigEndian, m.Type())
+
+	t := m.GetTimestamp()
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(data)
+
+	binary.Write(&buf, binary.BigEndian, m.DBHeight)
+
+	err = buf.PushBytes(m.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.DeepCopyBytes(), nil
+}
+
+func (m *StateSnapshotResponse) MarshalBinary() ([]byte, error) {
+	return m.MarshalForSignature()
+}
+
+func (m *StateSnapshotResponse) String() string {
+	return fmt.Sprintf("StateSnapshotResponse: %d, %d bytes", m.DBHeight, len(m.Snapshot))
+}
+
+func NewStateSnapshotResponse(state interfaces.IState, dbheight uint32, snapshot []byte) interfaces.IMsg {
+	msg := new(StateSnapshotResponse)
+
+	msg.Peer2Peer = true // Always a peer2peer response.
+	msg.Timestamp = state.GetTimestamp()
+	msg.DBHeight = dbheight
+	msg.Snapshot = snapshot
+
+	return msg
+}
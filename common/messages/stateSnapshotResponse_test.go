@@ -0,0 +1,72 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package messages_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	. "github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+func TestUnmarshalNilStateSnapshotResponse(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("Panic caught during the test - %v", r)
+		}
+	}()
+
+	a := new(StateSnapshotResponse)
+	err := a.UnmarshalBinary(nil)
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+
+	err = a.UnmarshalBinary([]byte{})
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+}
+
+func TestMarshalUnmarshalStateSnapshotResponse(t *testing.T) {
+	msg := newStateSnapshotResponse()
+
+	hex, err := msg.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+
+	msg2, err := UnmarshalMessage(hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg2.Type() != constants.STATE_SNAPSHOT_RESPONSE {
+		t.Error("Invalid message type unmarshalled")
+	}
+
+	hex2, err := msg2.(*StateSnapshotResponse).MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(hex, hex2) {
+		t.Error("Hexes do not match")
+	}
+
+	if msg.IsSameAs(msg2.(*StateSnapshotResponse)) != true {
+		t.Errorf("StateSnapshotResponse messages are not identical")
+	}
+}
+
+func newStateSnapshotResponse() *StateSnapshotResponse {
+	msg := new(StateSnapshotResponse)
+	msg.Timestamp = primitives.NewTimestampNow()
+
+	msg.DBHeight = 0x01234567
+	msg.Snapshot = []byte("a snapshot of the state")
+
+	return msg
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package primitives
+
+import (
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// WallClock is the time of the system.
+type WallClock struct{}
+
+var _ interfaces.IClock = WallClock{}
+
+func (WallClock) Now() time.Time                         { return time.Now() }
+func (WallClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (WallClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (WallClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// NewTimestampFromClock returns the time of the clock as a Timestamp.
+func NewTimestampFromClock(c interfaces.IClock) *Timestamp {
+	return NewTimestampFromMilliseconds(uint64(c.Now().UnixNano() / 1e6))
+}
+
+// FakeClock is a clock that only moves when it is told to.  Sleep and After
+// wait until the clock is advanced past their end.
+type FakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []*fakeClockWaiter
+}
+
+type fakeClockWaiter struct {
+	until time.Time
+	c     chan time.Time
+}
+
+var _ interfaces.IClock = (*FakeClock)(nil)
+
+// NewFakeClock returns a fake clock stopped at the time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := new(FakeClock)
+	c.now = now
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	w := &fakeClockWaiter{until: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.c <- c.now
+		return w.c
+	}
+	c.waiters = append(c.waiters, w)
+	return w.c
+}
+
+// Advance moves the clock forward, waking the sleepers it passes.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to the time, waking the sleepers it passes.
+func (c *FakeClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.set(now)
+}
+
+func (c *FakeClock) set(now time.Time) {
+	c.now = now
+	var waiting []*fakeClockWaiter
+	for _, w := range c.waiters {
+		if w.until.After(now) {
+			waiting = append(waiting, w)
+			continue
+		}
+		w.c <- now
+	}
+	c.waiters = waiting
+}
+
+// Sleepers returns how many Sleep and After calls are waiting on the clock,
+// so a test can advance it once the node is waiting.
+func (c *FakeClock) Sleepers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.waiters)
+}
@@ -0,0 +1,58 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package primitives_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factomd/common/primitives"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	if !c.Now().Equal(start) {
+		t.Errorf("Clock started at %v, not %v", c.Now(), start)
+	}
+
+	after := c.After(time.Minute)
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(2 * time.Minute)
+		close(done)
+	}()
+	for c.Sleepers() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	c.Advance(time.Minute)
+	select {
+	case now := <-after:
+		if !now.Equal(start.Add(time.Minute)) {
+			t.Errorf("After woke at %v", now)
+		}
+	default:
+		t.Errorf("After did not wake when the clock passed it")
+	}
+	select {
+	case <-done:
+		t.Errorf("Sleep woke before the clock passed it")
+	default:
+	}
+
+	c.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Sleep did not wake when the clock passed it")
+	}
+	if c.Since(start) != 2*time.Minute {
+		t.Errorf("Since is %v, not 2m", c.Since(start))
+	}
+	if ts := NewTimestampFromClock(c); ts.GetTimeMilli() != start.Add(2*time.Minute).UnixNano()/1e6 {
+		t.Errorf("Timestamp of the clock is %v", ts)
+	}
+}
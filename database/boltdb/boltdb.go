@@ -5,6 +5,7 @@
 package boltdb
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
 
@@ -122,6 +123,38 @@ func (db *BoltDB) Get(bucket []byte, key []byte, destination interfaces.BinaryMa
 	return destination, nil
 }
 
+// GetMulti reads every key in one transaction.
+func (db *BoltDB) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	answer := make([]interfaces.BinaryMarshallableAndCopyable, len(keys))
+	err := db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		for i, key := range keys {
+			v := b.Get(key)
+			if v == nil {
+				continue
+			}
+			// v is only valid during the transaction
+			tmp := sample.New()
+			_, err := tmp.UnmarshalBinaryData(append([]byte{}, v...))
+			if err != nil {
+				return err
+			}
+			answer[i] = tmp
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
 func (db *BoltDB) Put(bucket []byte, key []byte, data interfaces.BinaryMarshallable) error {
 	db.Sem.Lock()
 	defer db.Sem.Unlock()
@@ -153,6 +186,13 @@ func (db *BoltDB) PutInBatch(records []interfaces.Record) error {
 				return err
 			}
 			b := tx.Bucket(v.Bucket)
+			if v.Data == nil {
+				err = b.Delete(v.Key)
+				if err != nil {
+					return err
+				}
+				continue
+			}
 			hex, err := v.Data.MarshalBinary()
 			if err != nil {
 				return err
@@ -195,7 +235,10 @@ func (db *BoltDB) ListAllKeys(bucket []byte) (keys [][]byte, err error) {
 			//fmt.Println("bucket 0x" + hex.EncodeToString(bucket) + " not found")
 		} else {
 			b.ForEach(func(k, v []byte) error {
-				keys = append(keys, k)
+				// Keys are only valid for the life of the transaction
+				key := make([]byte, len(k))
+				copy(key, k)
+				keys = append(keys, key)
 				return nil
 			})
 		}
@@ -204,6 +247,37 @@ func (db *BoltDB) ListAllKeys(bucket []byte) (keys [][]byte, err error) {
 	return
 }
 
+func (db *BoltDB) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	return db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		var k []byte
+		if start == nil {
+			k, _ = c.First()
+		} else {
+			k, _ = c.Seek(start)
+		}
+		for ; k != nil; k, _ = c.Next() {
+			if limit != nil && bytes.Compare(k, limit) >= 0 {
+				break
+			}
+			key := make([]byte, len(k))
+			copy(key, k)
+			err := fn(key)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (db *BoltDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
 	db.Sem.Lock()
 	defer db.Sem.Unlock()
@@ -290,3 +364,39 @@ func (db *BoltDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 
 	return true, nil
 }
+
+func (db *BoltDB) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	stats := interfaces.BucketStats{Bucket: bucket}
+	err := db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			stats.Keys++
+			stats.KeyBytes += int64(len(k))
+			stats.DataBytes += int64(len(v))
+			return nil
+		})
+	})
+	return stats, err
+}
+
+// Bolt reuses the pages it frees, but never shrinks its file.  It can only be
+// compacted by copying it, e.g. with -migratedb or -exportdb and -importdb.
+func (db *BoltDB) Compact() error {
+	return fmt.Errorf("BoltDB can't be compacted in place, export and import it to reclaim space")
+}
+
+// SetBulkLoad turns off the sync of the file at the end of every transaction
+// while bulk loading, which is most of the cost of a small transaction.
+func (db *BoltDB) SetBulkLoad(bulk bool) error {
+	db.Sem.Lock()
+	defer db.Sem.Unlock()
+
+	db.db.NoSync = bulk
+	return db.db.Sync()
+}
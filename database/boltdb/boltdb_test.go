@@ -221,3 +221,33 @@ func TestGetAll(t *testing.T) {
 		}
 	}
 }
+
+func TestBulkLoad(t *testing.T) {
+	m := NewBoltDB(nil, dbFilename)
+
+	bucket := []byte("bucket")
+	if err := m.SetBulkLoad(true); err != nil {
+		t.Errorf("%v", err)
+	}
+	for i := 0; i < 10; i++ {
+		err := m.PutInBatch([]interfaces.Record{{Bucket: bucket, Key: []byte{byte(i)}, Data: &TestData{Str: fmt.Sprintf("%d", i)}}})
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+	if err := m.SetBulkLoad(false); err != nil {
+		t.Errorf("%v", err)
+	}
+	m.Close()
+
+	// Everything written while bulk loading is there after reopening
+	m = NewBoltDB(nil, dbFilename)
+	defer CleanupTest(t, m)
+	keys, err := m.ListAllKeys(bucket)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(keys) != 10 {
+		t.Errorf("Found %d keys, expected 10", len(keys))
+	}
+}
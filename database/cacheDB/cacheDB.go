@@ -0,0 +1,304 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package cacheDB
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// CacheDB is a read cache in front of another database.  It keeps the raw
+// values of the most recently fetched keys, up to a fixed number, and drops the
+// least recently used when it is full.  Writes go straight through to the
+// database and drop the keys they touch from the cache, so the cache never
+// holds a value the database doesn't.
+type CacheDB struct {
+	Sem  sync.Mutex
+	db   interfaces.IDatabase
+	size int
+	lru  *list.List               // Most recently used at the front
+	keys map[string]*list.Element // Bucket and key -> element of lru
+
+	hits   uint64
+	misses uint64
+	writes uint64 // Counts writes, so a Get can tell if its value went stale
+}
+
+// cached is a value in the cache.
+type cached struct {
+	key  string
+	data []byte
+}
+
+var _ interfaces.IDatabase = (*CacheDB)(nil)
+
+// NewCacheDB returns a CacheDB in front of db that holds up to size values.
+func NewCacheDB(db interfaces.IDatabase, size int) *CacheDB {
+	c := new(CacheDB)
+	c.db = db
+	c.size = size
+	c.lru = list.New()
+	c.keys = map[string]*list.Element{}
+	return c
+}
+
+// cacheKey combines a bucket and key.  The bucket length comes first, so
+// different bucket and key pairs can't give the same cache key.
+func cacheKey(bucket, key []byte) string {
+	return string(append(append([]byte{byte(len(bucket) >> 8), byte(len(bucket))}, bucket...), key...))
+}
+
+// Stats returns the number of gets served from the cache, the number that had
+// to go to the database, and the number of values cached.
+func (c *CacheDB) Stats() (hits uint64, misses uint64, entries int) {
+	c.Sem.Lock()
+	defer c.Sem.Unlock()
+	return c.hits, c.misses, c.lru.Len()
+}
+
+func (c *CacheDB) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	k := cacheKey(bucket, key)
+
+	c.Sem.Lock()
+	if e, ok := c.keys[k]; ok {
+		c.lru.MoveToFront(e)
+		data := append([]byte{}, e.Value.(*cached).data...)
+		c.hits++
+		c.Sem.Unlock()
+		CacheDBHits.Inc()
+
+		err := destination.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return destination, nil
+	}
+	c.misses++
+	writes := c.writes
+	c.Sem.Unlock()
+	CacheDBMisses.Inc()
+
+	raw, err := c.db.Get(bucket, key, new(primitives.ByteSlice))
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	data := raw.(*primitives.ByteSlice).Bytes
+	err = destination.UnmarshalBinary(append([]byte{}, data...))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Sem.Lock()
+	if c.writes == writes {
+		c.add(k, data)
+	}
+	c.Sem.Unlock()
+	return destination, nil
+}
+
+// GetMulti serves what it can from the cache, and gets the rest from the
+// database in one call.
+func (c *CacheDB) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	raw := make([][]byte, len(keys))
+	missing := [][]byte{}
+	missingAt := []int{}
+
+	c.Sem.Lock()
+	for i, key := range keys {
+		if e, ok := c.keys[cacheKey(bucket, key)]; ok {
+			c.lru.MoveToFront(e)
+			raw[i] = append([]byte{}, e.Value.(*cached).data...)
+			c.hits++
+			CacheDBHits.Inc()
+			continue
+		}
+		c.misses++
+		CacheDBMisses.Inc()
+		missing = append(missing, key)
+		missingAt = append(missingAt, i)
+	}
+	writes := c.writes
+	c.Sem.Unlock()
+
+	if len(missing) > 0 {
+		fetched, err := c.db.GetMulti(bucket, missing, new(primitives.ByteSlice))
+		if err != nil {
+			return nil, err
+		}
+		c.Sem.Lock()
+		for j, v := range fetched {
+			if v == nil {
+				continue
+			}
+			data := v.(*primitives.ByteSlice).Bytes
+			raw[missingAt[j]] = append([]byte{}, data...)
+			if c.writes == writes {
+				c.add(cacheKey(bucket, missing[j]), data)
+			}
+		}
+		c.Sem.Unlock()
+	}
+
+	answer := make([]interfaces.BinaryMarshallableAndCopyable, len(keys))
+	for i, data := range raw {
+		if data == nil {
+			continue
+		}
+		tmp := sample.New()
+		err := tmp.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		answer[i] = tmp
+	}
+	return answer, nil
+}
+
+// add caches a value, dropping the least recently used one if the cache is
+// full.  The caller must hold the lock.
+func (c *CacheDB) add(k string, data []byte) {
+	if e, ok := c.keys[k]; ok {
+		e.Value.(*cached).data = data
+		c.lru.MoveToFront(e)
+		return
+	}
+	if c.size <= 0 {
+		return
+	}
+	for c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+	}
+	c.keys[k] = c.lru.PushFront(&cached{key: k, data: data})
+	CacheDBEntries.Inc()
+}
+
+// remove drops an element from the cache.  The caller must hold the lock.
+func (c *CacheDB) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.keys, e.Value.(*cached).key)
+	CacheDBEntries.Dec()
+}
+
+// forget drops a key from the cache, if it is there, after it is written.  The
+// caller must hold the lock.
+func (c *CacheDB) forget(bucket, key []byte) {
+	c.writes++
+	if e, ok := c.keys[cacheKey(bucket, key)]; ok {
+		c.remove(e)
+	}
+}
+
+// purge drops everything from the cache.  The caller must hold the lock.
+func (c *CacheDB) purge() {
+	c.writes++
+	CacheDBEntries.Sub(float64(c.lru.Len()))
+	c.lru.Init()
+	c.keys = map[string]*list.Element{}
+}
+
+// writeThrough runs a write to the database without the lock, so that gets
+// served from the cache don't wait on the disk.  What the write touches is
+// dropped from the cache both before and after it.  A Get that read the
+// database while the write was under way doesn't cache what it read, as the
+// write count moved on since it looked.
+func (c *CacheDB) writeThrough(forget func(), write func() error) error {
+	c.Sem.Lock()
+	forget()
+	c.Sem.Unlock()
+
+	err := write()
+
+	c.Sem.Lock()
+	forget()
+	c.Sem.Unlock()
+	return err
+}
+
+// Put writes through to the database.
+func (c *CacheDB) Put(bucket, key []byte, data interfaces.BinaryMarshallable) error {
+	return c.writeThrough(func() { c.forget(bucket, key) }, func() error {
+		return c.db.Put(bucket, key, data)
+	})
+}
+
+func (c *CacheDB) PutInBatch(records []interfaces.Record) error {
+	forget := func() {
+		for _, r := range records {
+			c.forget(r.Bucket, r.Key)
+		}
+	}
+	return c.writeThrough(forget, func() error {
+		return c.db.PutInBatch(records)
+	})
+}
+
+func (c *CacheDB) Delete(bucket, key []byte) error {
+	return c.writeThrough(func() { c.forget(bucket, key) }, func() error {
+		return c.db.Delete(bucket, key)
+	})
+}
+
+func (c *CacheDB) Clear(bucket []byte) error {
+	return c.writeThrough(c.purge, func() error {
+		return c.db.Clear(bucket)
+	})
+}
+
+func (c *CacheDB) Close() error {
+	c.Sem.Lock()
+	defer c.Sem.Unlock()
+
+	c.purge()
+	return c.db.Close()
+}
+
+func (c *CacheDB) ListAllKeys(bucket []byte) ([][]byte, error) {
+	return c.db.ListAllKeys(bucket)
+}
+
+func (c *CacheDB) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	return c.db.ForEachKey(bucket, start, limit, fn)
+}
+
+func (c *CacheDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	return c.db.GetAll(bucket, sample)
+}
+
+func (c *CacheDB) ListAllBuckets() ([][]byte, error) {
+	return c.db.ListAllBuckets()
+}
+
+// The cache has a fixed size, so there is nothing to trim but the database.
+func (c *CacheDB) Trim() {
+	c.db.Trim()
+}
+
+func (c *CacheDB) DoesKeyExist(bucket, key []byte) (bool, error) {
+	c.Sem.Lock()
+	_, ok := c.keys[cacheKey(bucket, key)]
+	c.Sem.Unlock()
+	if ok {
+		return true, nil
+	}
+	return c.db.DoesKeyExist(bucket, key)
+}
+
+func (c *CacheDB) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	return c.db.BucketStats(bucket)
+}
+
+func (c *CacheDB) Compact() error {
+	return c.db.Compact()
+}
+
+func (c *CacheDB) SetBulkLoad(bulk bool) error {
+	return c.db.SetBulkLoad(bulk)
+}
@@ -0,0 +1,148 @@
+package cacheDB_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/cacheDB"
+	"github.com/FactomProject/factomd/database/mapdb"
+)
+
+func TestCacheDB(t *testing.T) {
+	m := new(mapdb.MapDB)
+	m.Init(nil)
+	c := NewCacheDB(m, 2)
+
+	bucket := []byte("bucket")
+	values := []interfaces.IHash{primitives.RandomHash(), primitives.RandomHash(), primitives.RandomHash()}
+	for i, v := range values {
+		if err := c.Put(bucket, []byte{byte(i)}, v); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	get := func(i int) interfaces.IHash {
+		v, err := c.Get(bucket, []byte{byte(i)}, new(primitives.Hash))
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+		if v == nil {
+			return nil
+		}
+		return v.(interfaces.IHash)
+	}
+
+	// Miss, then hit
+	for n := 0; n < 2; n++ {
+		if v := get(0); v == nil || !v.IsSameAs(values[0]) {
+			t.Errorf("Got %v, expected %v", v, values[0])
+		}
+	}
+	hits, misses, entries := c.Stats()
+	if hits != 1 || misses != 1 || entries != 1 {
+		t.Errorf("Hits %d, misses %d, entries %d, expected 1, 1, 1", hits, misses, entries)
+	}
+
+	// 0 is used more recently than 1, so 1 is dropped for 2
+	get(1)
+	get(0)
+	get(2)
+	get(0)
+	get(1)
+	hits, misses, entries = c.Stats()
+	if hits != 3 || misses != 4 || entries != 2 {
+		t.Errorf("Hits %d, misses %d, entries %d, expected 3, 4, 2", hits, misses, entries)
+	}
+
+	// Writes replace what is cached
+	newValue := primitives.RandomHash()
+	if err := c.Put(bucket, []byte{0}, newValue); err != nil {
+		t.Errorf("%v", err)
+	}
+	if v := get(0); v == nil || !v.IsSameAs(newValue) {
+		t.Errorf("Got %v after Put, expected %v", v, newValue)
+	}
+
+	err := c.PutInBatch([]interfaces.Record{{Bucket: bucket, Key: []byte{0}, Data: values[0]}})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if v := get(0); v == nil || !v.IsSameAs(values[0]) {
+		t.Errorf("Got %v after PutInBatch, expected %v", v, values[0])
+	}
+
+	if err = c.Delete(bucket, []byte{0}); err != nil {
+		t.Errorf("%v", err)
+	}
+	if v := get(0); v != nil {
+		t.Errorf("Got %v after Delete, expected nothing", v)
+	}
+	exists, _ := c.DoesKeyExist(bucket, []byte{0})
+	if exists {
+		t.Errorf("Deleted key exists")
+	}
+}
+
+// blockingDB holds each Put until it is let go.
+type blockingDB struct {
+	*mapdb.MapDB
+	started chan bool
+	release chan bool
+}
+
+func (b *blockingDB) Put(bucket, key []byte, data interfaces.BinaryMarshallable) error {
+	b.started <- true
+	<-b.release
+	return b.MapDB.Put(bucket, key, data)
+}
+
+func TestCacheDBReadsDuringWrite(t *testing.T) {
+	m := new(mapdb.MapDB)
+	m.Init(nil)
+	b := &blockingDB{MapDB: m, started: make(chan bool), release: make(chan bool)}
+	c := NewCacheDB(b, 10)
+
+	bucket := []byte("bucket")
+	cachedValue := primitives.RandomHash()
+	if err := m.Put(bucket, []byte{1}, cachedValue); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := c.Get(bucket, []byte{1}, new(primitives.Hash)); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	newValue := primitives.RandomHash()
+	done := make(chan error)
+	go func() { done <- c.Put(bucket, []byte{2}, newValue) }()
+	<-b.started
+
+	// The write is held up, but what is cached is still served
+	got := make(chan interfaces.BinaryMarshallable)
+	go func() {
+		v, _ := c.Get(bucket, []byte{1}, new(primitives.Hash))
+		got <- v
+	}()
+	select {
+	case v := <-got:
+		if v == nil || !v.(interfaces.IHash).IsSameAs(cachedValue) {
+			t.Errorf("Got %v, expected %v", v, cachedValue)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("A cached Get waited on a write")
+	}
+
+	// A Get of the key being written, while it is written, doesn't keep
+	// the old value
+	if v, _ := c.Get(bucket, []byte{2}, new(primitives.Hash)); v != nil {
+		t.Errorf("Got %v before the write, expected nothing", v)
+	}
+	b.release <- true
+	if err := <-done; err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v, _ := c.Get(bucket, []byte{2}, new(primitives.Hash)); v == nil || !v.(interfaces.IHash).IsSameAs(newValue) {
+		t.Errorf("Got %v after the write, expected %v", v, newValue)
+	}
+}
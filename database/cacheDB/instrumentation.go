@@ -0,0 +1,35 @@
+package cacheDB
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	CacheDBHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_database_cache_hits",
+		Help: "Counts gets served from the database read cache",
+	})
+	CacheDBMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_database_cache_misses",
+		Help: "Counts gets that missed the database read cache",
+	})
+	CacheDBEntries = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "factomd_database_cache_entries",
+		Help: "Number of values in the database read cache",
+	})
+)
+
+var registered = false
+
+// RegisterPrometheus registers the variables to be exposed. This can only be run once, hence the
+// boolean flag to prevent panics if launched more than once. This is called in NetStart
+func RegisterPrometheus() {
+	if registered {
+		return
+	}
+	registered = true
+
+	prometheus.MustRegister(CacheDBHits)
+	prometheus.MustRegister(CacheDBMisses)
+	prometheus.MustRegister(CacheDBEntries)
+}
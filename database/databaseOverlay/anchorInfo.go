@@ -6,11 +6,14 @@ package databaseOverlay
 
 import (
 	//"fmt"
+	"bytes"
+	"encoding/binary"
 	"github.com/FactomProject/factomd/anchor"
 	"github.com/FactomProject/factomd/common/directoryBlock/dbInfo"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	"sort"
+	"time"
 )
 
 var AnchorBlockID string = "df3ade9eec4b08d5379cc64270c30ea7315d8a8a1a69efe2b98a60ecdd69e604"
@@ -20,6 +23,14 @@ var AnchorSigKeys []string = []string{
 }
 var AnchorSigPublicKeys []interfaces.Verifier
 
+// AnchorVerifier, if not nil, looks up the anchors of the anchor records in
+// Bitcoin and Ethereum, in the background by VerifyAnchorInfo.
+var AnchorVerifier *anchor.Verifier
+
+// AnchorVerifyInterval is how long VerifyAnchorInfo waits between lookups
+// of the records queued.
+var AnchorVerifyInterval = time.Minute
+
 func init() {
 	for _, v := range AnchorSigKeys {
 		pubKey := new(primitives.PublicKey)
@@ -44,18 +55,39 @@ func (dbo *Overlay) RebuildDirBlockInfo() error {
 	return nil
 }
 
-func (dbo *Overlay) SaveAnchorInfoFromEntry(entry interfaces.IEBEntry) error {
+// validAnchorRecord returns the anchor record of an anchor chain entry, or
+// nil if the entry isn't one signed by an anchor key.
+func validAnchorRecord(entry interfaces.IEBEntry) (*anchor.AnchorRecord, error) {
 	if entry.DatabasePrimaryIndex().String() == "24674e6bc3094eb773297de955ee095a05830e431da13a37382dcdc89d73c7d7" {
-		return nil
+		return nil, nil
 	}
 	ar, ok, err := anchor.UnmarshalAndValidateAnchorEntryAnyVersion(entry, AnchorSigPublicKeys)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if ok == false {
-		return nil
+		return nil, nil
+	}
+	return ar, nil
+}
+
+func (dbo *Overlay) SaveAnchorInfoFromEntry(entry interfaces.IEBEntry) error {
+	ar, err := validAnchorRecord(entry)
+	if err != nil || ar == nil {
+		return err
+	}
+	record, err := dbo.anchorInfoRecord(ar, nil)
+	if err != nil {
+		return err
 	}
-	if ar == nil {
+	if record != nil {
+		err = dbo.PutInBatch([]interfaces.Record{*record})
+		if err != nil {
+			return err
+		}
+		queueAnchorInfo(record)
+	}
+	if ar.Bitcoin == nil {
 		return nil
 	}
 	dbi, err := AnchorRecordToDirBlockInfo(ar)
@@ -66,17 +98,20 @@ func (dbo *Overlay) SaveAnchorInfoFromEntry(entry interfaces.IEBEntry) error {
 }
 
 func (dbo *Overlay) SaveAnchorInfoFromEntryMultiBatch(entry interfaces.IEBEntry) error {
-	if entry.DatabasePrimaryIndex().String() == "24674e6bc3094eb773297de955ee095a05830e431da13a37382dcdc89d73c7d7" {
-		return nil
+	ar, err := validAnchorRecord(entry)
+	if err != nil || ar == nil {
+		return err
 	}
-	ar, ok, err := anchor.UnmarshalAndValidateAnchorEntryAnyVersion(entry, AnchorSigPublicKeys)
+	// The batch may already hold a record of the height, from another entry
+	record, err := dbo.anchorInfoRecord(ar, dbo.MultiBatch)
 	if err != nil {
 		return err
 	}
-	if ok == false {
-		return nil
+	if record != nil {
+		dbo.PutInMultiBatch([]interfaces.Record{*record})
+		queueAnchorInfo(record)
 	}
-	if ar == nil {
+	if ar.Bitcoin == nil {
 		return nil
 	}
 	dbi, err := AnchorRecordToDirBlockInfo(ar)
@@ -86,6 +121,203 @@ func (dbo *Overlay) SaveAnchorInfoFromEntryMultiBatch(entry interfaces.IEBEntry)
 	return dbo.ProcessDirBlockInfoMultiBatch(dbi)
 }
 
+func anchorInfoKey(dbHeight uint32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, dbHeight)
+	return key
+}
+
+// anchorInfoRecord takes an anchor record into the ANCHOR_INFO record of its
+// directory block, which is the last one of the height in the pending
+// records, if any, or the one saved.  The key merkle root is checked against
+// the directory block saved at its height.  It returns nil if the record is
+// left out, for another key merkle root than the one known to match.
+func (dbo *Overlay) anchorInfoRecord(ar *anchor.AnchorRecord, pending []interfaces.Record) (*interfaces.Record, error) {
+	key := anchorInfoKey(ar.DBHeight)
+	var info *anchor.AnchorInfo
+	for i := len(pending) - 1; i >= 0; i-- {
+		r := pending[i]
+		if bytes.Equal(r.Bucket, ANCHOR_INFO) && bytes.Equal(r.Key, key) {
+			copied := *r.Data.(*anchor.AnchorInfo)
+			info = &copied
+			break
+		}
+	}
+	if info == nil {
+		var err error
+		info, err = dbo.FetchAnchorInfo(ar.DBHeight)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if info == nil {
+		info = new(anchor.AnchorInfo)
+	}
+	if !info.Add(ar) {
+		return nil, nil
+	}
+
+	keyMR, err := dbo.FetchDBKeyMRByHeight(ar.DBHeight)
+	if err != nil {
+		return nil, err
+	}
+	info.KeyMRMatches = keyMR != nil && keyMR.String() == ar.KeyMR
+	return &interfaces.Record{Bucket: ANCHOR_INFO, Key: key, Data: info}, nil
+}
+
+// queueAnchorInfo queues the record of an anchor info for AnchorVerifier,
+// if set, to look up.
+func queueAnchorInfo(record *interfaces.Record) {
+	if info := record.Data.(*anchor.AnchorInfo); AnchorVerifier != nil && AnchorVerifier.Verifiable(info) {
+		AnchorVerifier.Queue(info.DBHeight)
+	}
+}
+
+// VerifyAnchorInfo has AnchorVerifier, if set, look up the anchors of the
+// ANCHOR_INFO records, in the background.  Those saved before it started are
+// found and queued first.  Every AnchorVerifyInterval the records queued are
+// looked up, and the anchors found saved; a record whose node can't be asked
+// is tried again the next time.  It doesn't return unless AnchorVerifier is
+// nil, so it is run in a goroutine.
+func (dbo *Overlay) VerifyAnchorInfo() {
+	v := AnchorVerifier
+	if v == nil {
+		return
+	}
+	if err := dbo.queueUnverifiedAnchorInfo(v); err != nil {
+		dbLog.Warningf("Could not find the anchor records to verify: %v", err)
+	}
+	for {
+		if err := dbo.verifyAnchorInfo(v); err != nil {
+			dbLog.Warningf("Could not verify the anchor records: %v", err)
+		}
+		time.Sleep(AnchorVerifyInterval)
+	}
+}
+
+func (dbo *Overlay) queueUnverifiedAnchorInfo(v *anchor.Verifier) error {
+	keys, err := dbo.ListAllKeys(ANCHOR_INFO)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if len(key) != 4 {
+			continue
+		}
+		info, err := dbo.FetchAnchorInfo(binary.BigEndian.Uint32(key))
+		if err != nil {
+			return err
+		}
+		if info != nil && v.Verifiable(info) {
+			v.Queue(info.DBHeight)
+		}
+	}
+	return nil
+}
+
+// verifyAnchorInfo looks up the records queued, lowest first.  It stops at
+// the first node that can't be asked.
+func (dbo *Overlay) verifyAnchorInfo(v *anchor.Verifier) error {
+	for _, height := range v.Pending() {
+		info, err := dbo.fetchAnchorInfoSaved(height)
+		if err != nil {
+			return err
+		}
+		if info == nil || !v.Verifiable(info) {
+			v.Tried(height, true)
+			continue
+		}
+		verr := v.Verify(info)
+		saved, err := dbo.saveAnchorVerified(info)
+		if err != nil {
+			return err
+		}
+		if verr != nil {
+			return verr
+		}
+		v.Tried(height, saved == nil || !v.Verifiable(saved))
+	}
+	return nil
+}
+
+// fetchAnchorInfoSaved is FetchAnchorInfo once any multi batch being built
+// is written, so the record has the anchors queued with it.
+func (dbo *Overlay) fetchAnchorInfoSaved(height uint32) (*anchor.AnchorInfo, error) {
+	dbo.BatchSemaphore.Lock()
+	defer dbo.BatchSemaphore.Unlock()
+	return dbo.FetchAnchorInfo(height)
+}
+
+// saveAnchorVerified marks the anchors verified in the info verified in the
+// saved record too, if it still holds them, and returns the record saved.
+func (dbo *Overlay) saveAnchorVerified(info *anchor.AnchorInfo) (*anchor.AnchorInfo, error) {
+	dbo.BatchSemaphore.Lock()
+	defer dbo.BatchSemaphore.Unlock()
+	saved, err := dbo.FetchAnchorInfo(info.DBHeight)
+	if err != nil || saved == nil || saved.KeyMR != info.KeyMR {
+		return saved, err
+	}
+	changed := false
+	if info.BitcoinVerified && !saved.BitcoinVerified && saved.Bitcoin != nil && *saved.Bitcoin == *info.Bitcoin {
+		saved.BitcoinVerified, changed = true, true
+	}
+	if info.EthereumVerified && !saved.EthereumVerified && saved.Ethereum != nil && *saved.Ethereum == *info.Ethereum {
+		saved.EthereumVerified, changed = true, true
+	}
+	if !changed {
+		return saved, nil
+	}
+	return saved, dbo.Put(ANCHOR_INFO, anchorInfoKey(info.DBHeight), saved)
+}
+
+var _ anchor.AnchorInfoFetcher = (*Overlay)(nil)
+
+// FetchAnchorInfo returns what the anchor chain records of the directory
+// block at a height, or nil if it records nothing.
+func (dbo *Overlay) FetchAnchorInfo(dbHeight uint32) (*anchor.AnchorInfo, error) {
+	info, err := dbo.Get(ANCHOR_INFO, anchorInfoKey(dbHeight), new(anchor.AnchorInfo))
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, nil
+	}
+	return info.(*anchor.AnchorInfo), nil
+}
+
+// indexAnchorInfo builds the ANCHOR_INFO records from the anchor chain
+// entries already in the database.
+func (dbo *Overlay) indexAnchorInfo() error {
+	chainID, err := primitives.HexToHash(AnchorBlockID)
+	if err != nil {
+		return err
+	}
+	entries, err := dbo.FetchAllEntriesByChainID(chainID)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		ar, err := validAnchorRecord(entry)
+		if err != nil {
+			return err
+		}
+		if ar == nil {
+			continue
+		}
+		record, err := dbo.anchorInfoRecord(ar, nil)
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			err = dbo.PutInBatch([]interfaces.Record{*record})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (dbo *Overlay) FetchAllAnchorInfo() ([]*anchor.AnchorRecord, error) {
 	chainID, err := primitives.NewShaHashFromStr(AnchorBlockID)
 	if err != nil {
@@ -6,6 +6,8 @@ package databaseOverlay_test
 
 import (
 	"github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/testHelper"
 	"testing"
 )
@@ -119,3 +121,107 @@ func CreateAnchors() []*anchor.AnchorRecord {
 
 	return answer
 }
+
+func TestAnchorInfoIndex(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+
+	// Each block anchors the one before it
+	for height := uint32(0); height < uint32(testHelper.BlockCount-1); height++ {
+		info, err := dbo.FetchAnchorInfo(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info == nil {
+			t.Errorf("No anchor info at %d", height)
+			continue
+		}
+		keyMR, err := dbo.FetchDBKeyMRByHeight(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.DBHeight != height || info.KeyMR != keyMR.String() || !info.KeyMRMatches {
+			t.Errorf("Wrong anchor info at %d: %v", height, info)
+		}
+		if info.Bitcoin == nil || info.Bitcoin.BlockHeight != int32(height) || info.BitcoinVerified {
+			t.Errorf("Wrong Bitcoin anchor at %d: %v", height, info.Bitcoin)
+		}
+	}
+	info, err := dbo.FetchAnchorInfo(uint32(testHelper.BlockCount))
+	if err != nil || info != nil {
+		t.Errorf("Anchor info %v, %v for a height not anchored", info, err)
+	}
+
+	// A record of another keyMR is left out, while an Ethereum one is added
+	ar := new(anchor.AnchorRecord)
+	ar.AnchorRecordVer = 1
+	ar.DBHeight = 2
+	ar.KeyMR = primitives.NewZeroHash().String()
+	ar.Bitcoin = &anchor.BitcoinStruct{TXID: ar.KeyMR, BlockHash: ar.KeyMR}
+	err = dbo.SaveAnchorInfoFromEntry(anchorEntry(ar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyMR, _ := dbo.FetchDBKeyMRByHeight(2)
+	ar.KeyMR = keyMR.String()
+	ar.Bitcoin = nil
+	ar.Ethereum = &anchor.EthereumStruct{TXID: "0x01", BlockHeight: 5}
+	err = dbo.SaveAnchorInfoFromEntry(anchorEntry(ar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err = dbo.FetchAnchorInfo(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.KeyMR != keyMR.String() || info.Bitcoin == nil || info.Bitcoin.BlockHeight != 2 {
+		t.Errorf("The Bitcoin anchor was replaced: %v", info)
+	}
+	if info.Ethereum == nil || info.Ethereum.TXID != "0x01" {
+		t.Errorf("The Ethereum anchor was not added: %v", info)
+	}
+}
+
+func TestAnchorInfoMultiBatch(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	height := uint32(testHelper.BlockCount + 5)
+
+	// Two entries anchoring the same height, saved in the same batch
+	ar := new(anchor.AnchorRecord)
+	ar.AnchorRecordVer = 1
+	ar.DBHeight = height
+	ar.KeyMR = primitives.NewZeroHash().String()
+	ar.Bitcoin = &anchor.BitcoinStruct{TXID: ar.KeyMR, BlockHash: ar.KeyMR}
+	dbo.StartMultiBatch()
+	if err := dbo.SaveAnchorInfoFromEntryMultiBatch(anchorEntry(ar)); err != nil {
+		t.Fatal(err)
+	}
+	ar.Bitcoin = nil
+	ar.Ethereum = &anchor.EthereumStruct{TXID: "0x02"}
+	if err := dbo.SaveAnchorInfoFromEntryMultiBatch(anchorEntry(ar)); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbo.ExecuteMultiBatch(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := dbo.FetchAnchorInfo(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || info.Bitcoin == nil || info.Ethereum == nil || info.Ethereum.TXID != "0x02" {
+		t.Errorf("The anchors of one batch were not gathered: %v", info)
+	}
+}
+
+// anchorEntry returns an anchor chain entry of a record, signed by the test
+// anchor key.
+func anchorEntry(ar *anchor.AnchorRecord) *entryBlock.Entry {
+	data, err := ar.MarshalAndSign(testHelper.NewPrimitivesPrivateKey(0))
+	if err != nil {
+		panic(err)
+	}
+	entry := entryBlock.NewEntry()
+	entry.ChainID = testHelper.GetAnchorChainID()
+	entry.Content = primitives.ByteSlice{Bytes: data}
+	return entry
+}
@@ -0,0 +1,203 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// BalanceDelta is the FACTOID_BALANCE_HISTORY or EC_BALANCE_HISTORY record
+// of an address at a height: the change to its balance in the block at that
+// height.  The records are keyed by the address and then the height, so a
+// block writes one small record for each address it changes, and the records
+// of an address are read back in the order of their heights.
+type BalanceDelta struct {
+	Delta int64
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*BalanceDelta)(nil)
+
+func (d *BalanceDelta) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(BalanceDelta)
+}
+
+func (d *BalanceDelta) MarshalBinary() ([]byte, error) {
+	buf := primitives.NewBuffer(nil)
+	err := buf.PushInt64(d.Delta)
+	if err != nil {
+		return nil, err
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (d *BalanceDelta) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	buf := primitives.NewBuffer(data)
+	var err error
+	d.Delta, err = buf.PopInt64()
+	if err != nil {
+		return nil, err
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (d *BalanceDelta) UnmarshalBinary(data []byte) error {
+	_, err := d.UnmarshalBinaryData(data)
+	return err
+}
+
+// balanceHistoryKey is the key of the balance change of an address at a
+// height.
+func balanceHistoryKey(address []byte, height uint32) []byte {
+	key := make([]byte, len(address)+4)
+	copy(key, address)
+	binary.BigEndian.PutUint32(key[len(address):], height)
+	return key
+}
+
+// BalanceDeltas returns the changes a factoid block and the entry credit
+// block of the same height make to the factoid and entry credit balances.
+// Entry credits are bought at the rate of the factoid block.  The
+// IncreaseBalance entries of the EC block are left out, as the purchases
+// they record are counted with the factoid transactions.
+func BalanceDeltas(fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock) (fct map[[32]byte]int64, ec map[[32]byte]int64) {
+	fct = map[[32]byte]int64{}
+	ec = map[[32]byte]int64{}
+	if fblock != nil {
+		rate := fblock.GetExchRate()
+		for _, trans := range fblock.GetTransactions() {
+			for _, input := range trans.GetInputs() {
+				fct[input.GetAddress().Fixed()] -= int64(input.GetAmount())
+			}
+			for _, output := range trans.GetOutputs() {
+				fct[output.GetAddress().Fixed()] += int64(output.GetAmount())
+			}
+			if rate == 0 {
+				continue
+			}
+			for _, ecOut := range trans.GetECOutputs() {
+				ec[ecOut.GetAddress().Fixed()] += int64(ecOut.GetAmount() / rate)
+			}
+		}
+	}
+	if ecblock != nil {
+		for _, entry := range ecblock.GetEntries() {
+			switch e := entry.(type) {
+			case *entryCreditBlock.CommitChain:
+				ec[e.ECPubKey.Fixed()] -= int64(e.Credits)
+			case *entryCreditBlock.CommitEntry:
+				ec[e.ECPubKey.Fixed()] -= int64(e.Credits)
+			}
+		}
+	}
+	return fct, ec
+}
+
+// balanceHistoryRecords returns the records of the changes the state diff
+// makes to the balances of its addresses.  Saving a block again writes the
+// same keys, replacing its changes.
+func balanceHistoryRecords(diff *interfaces.StateDiff) []interfaces.Record {
+	batch := []interfaces.Record{}
+	for _, changes := range []struct {
+		bucket  []byte
+		changes []interfaces.BalanceChange
+	}{{FACTOID_BALANCE_HISTORY, diff.FactoidDeltas}, {EC_BALANCE_HISTORY, diff.ECDeltas}} {
+		for _, c := range changes.changes {
+			batch = append(batch, interfaces.Record{
+				Bucket: changes.bucket,
+				Key:    balanceHistoryKey(c.Address[:], diff.Height),
+				Data:   &BalanceDelta{Delta: c.Delta},
+			})
+		}
+	}
+	return batch
+}
+
+// SaveBalanceHistory records the changes the factoid and entry credit blocks
+// of a height make to the balances of their addresses.  Blocks saved by the
+// node record them with their state diff, in SaveStateDiffMultiBatch.
+func (db *Overlay) SaveBalanceHistory(fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock) error {
+	diff, err := NewStateDiff(nil, fblock, ecblock)
+	if err != nil || diff == nil {
+		return err
+	}
+	return db.DB.PutInBatch(balanceHistoryRecords(diff))
+}
+
+// balanceAt returns the balance of an address once the block at the height
+// was applied, the sum of its changes up to then.
+func (db *Overlay) balanceAt(bucket []byte, address []byte, height uint32) (int64, error) {
+	// Collect the keys first, the database can't be read from inside ForEachKey
+	keys := [][]byte{}
+	past := fmt.Errorf("past the height")
+	err := db.DB.ForEachKey(bucket, balanceHistoryKey(address, 0), nil, func(key []byte) error {
+		if len(key) != len(address)+4 || !bytes.Equal(key[:len(address)], address) {
+			return past
+		}
+		if binary.BigEndian.Uint32(key[len(address):]) > height {
+			return past
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil && err != past {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	deltas, err := db.DB.GetMulti(bucket, keys, new(BalanceDelta))
+	if err != nil {
+		return 0, err
+	}
+	var balance int64
+	for _, d := range deltas {
+		if d != nil {
+			balance += d.(*BalanceDelta).Delta
+		}
+	}
+	return balance, nil
+}
+
+// FetchFactoidBalanceAtHeight returns the factoid balance of an address once
+// the block at the height was applied.
+func (db *Overlay) FetchFactoidBalanceAtHeight(address interfaces.IHash, height uint32) (int64, error) {
+	return db.balanceAt(FACTOID_BALANCE_HISTORY, address.Bytes(), height)
+}
+
+// FetchECBalanceAtHeight returns the entry credit balance of an address once
+// the block at the height was applied.
+func (db *Overlay) FetchECBalanceAtHeight(address interfaces.IHash, height uint32) (int64, error) {
+	return db.balanceAt(EC_BALANCE_HISTORY, address.Bytes(), height)
+}
+
+// indexBalanceHistory builds the balance histories of the blocks already in
+// the database, one height at a time, from height 0 up to the first height
+// missing a factoid block.
+func (db *Overlay) indexBalanceHistory() error {
+	for height := uint32(0); ; height++ {
+		fblock, err := db.FetchFBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		if fblock == nil {
+			return nil
+		}
+		ecblock, err := db.FetchECBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		err = db.SaveBalanceHistory(fblock, ecblock)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestBalanceHistory(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	// The balances of every address after each height
+	fct := []map[[32]byte]int64{}
+	ec := []map[[32]byte]int64{}
+	runningFct := map[[32]byte]int64{}
+	runningEC := map[[32]byte]int64{}
+	for _, block := range blocks {
+		err := dbo.SaveBalanceHistory(block.FBlock, block.ECBlock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, e := BalanceDeltas(block.FBlock, block.ECBlock)
+		fct = append(fct, map[[32]byte]int64{})
+		ec = append(ec, map[[32]byte]int64{})
+		for adr, delta := range f {
+			runningFct[adr] += delta
+		}
+		for adr, delta := range e {
+			runningEC[adr] += delta
+		}
+		for adr, balance := range runningFct {
+			fct[len(fct)-1][adr] = balance
+		}
+		for adr, balance := range runningEC {
+			ec[len(ec)-1][adr] = balance
+		}
+	}
+	if len(runningFct) == 0 {
+		t.Fatalf("The test blocks changed no balances")
+	}
+
+	for i, block := range blocks {
+		height := block.FBlock.GetDatabaseHeight()
+		for adr, expected := range fct[i] {
+			balance, err := dbo.FetchFactoidBalanceAtHeight(primitives.NewHash(adr[:]), height)
+			if err != nil {
+				t.Error(err)
+			}
+			if balance != expected {
+				t.Errorf("Factoid balance at %d is %d, expected %d", height, balance, expected)
+			}
+		}
+		for adr, expected := range ec[i] {
+			balance, err := dbo.FetchECBalanceAtHeight(primitives.NewHash(adr[:]), height)
+			if err != nil {
+				t.Error(err)
+			}
+			if balance != expected {
+				t.Errorf("EC balance at %d is %d, expected %d", height, balance, expected)
+			}
+		}
+	}
+}
+
+func TestBalanceHistoryResave(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	for _, block := range blocks {
+		if err := dbo.SaveBalanceHistory(block.FBlock, block.ECBlock); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, err := dbo.ListAllKeys(FACTOID_BALANCE_HISTORY)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving a block again replaces its changes, rather than adding to them
+	block := blocks[len(blocks)/2]
+	if err := dbo.SaveBalanceHistory(block.FBlock, block.ECBlock); err != nil {
+		t.Fatal(err)
+	}
+	keys2, err := dbo.ListAllKeys(FACTOID_BALANCE_HISTORY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != len(keys2) {
+		t.Errorf("Saving a block again went from %d records to %d", len(keys), len(keys2))
+	}
+
+	last := blocks[len(blocks)-1].FBlock.GetDatabaseHeight()
+	f, _ := BalanceDeltas(block.FBlock, block.ECBlock)
+	for adr := range f {
+		var expected int64
+		for _, b := range blocks {
+			d, _ := BalanceDeltas(b.FBlock, b.ECBlock)
+			expected += d[adr]
+		}
+		balance, err := dbo.FetchFactoidBalanceAtHeight(primitives.NewHash(adr[:]), last)
+		if err != nil {
+			t.Error(err)
+		}
+		if balance != expected {
+			t.Errorf("Factoid balance is %d, expected %d", balance, expected)
+		}
+	}
+}
+
+func TestMarshalUnmarshalBalanceDelta(t *testing.T) {
+	d := &BalanceDelta{Delta: -12345}
+	data, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2 := new(BalanceDelta)
+	rest, err := d2.UnmarshalBinaryData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%v bytes left over", len(rest))
+	}
+	if d2.Delta != d.Delta {
+		t.Errorf("Deltas differ, %d and %d", d.Delta, d2.Delta)
+	}
+
+	_, err = d2.UnmarshalBinaryData(data[:len(data)-1])
+	if err == nil {
+		t.Errorf("No error on short data")
+	}
+}
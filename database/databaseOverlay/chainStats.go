@@ -0,0 +1,223 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"bytes"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// ChainStatsRecord is the CHAIN_STATS record of a chain.
+type ChainStatsRecord struct {
+	interfaces.ChainStats
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*ChainStatsRecord)(nil)
+
+func (r *ChainStatsRecord) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(ChainStatsRecord)
+}
+
+func (r *ChainStatsRecord) MarshalBinary() ([]byte, error) {
+	buf := primitives.NewBuffer(nil)
+	for _, v := range []uint64{r.Entries, r.Bytes} {
+		err := buf.PushUInt64(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range []uint32{r.EBlocks, r.FirstHeight, r.LastHeight} {
+		err := buf.PushUInt32(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (r *ChainStatsRecord) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	buf := primitives.NewBuffer(data)
+	var err error
+	for _, v := range []*uint64{&r.Entries, &r.Bytes} {
+		*v, err = buf.PopUInt64()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range []*uint32{&r.EBlocks, &r.FirstHeight, &r.LastHeight} {
+		*v, err = buf.PopUInt32()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (r *ChainStatsRecord) UnmarshalBinary(data []byte) error {
+	_, err := r.UnmarshalBinaryData(data)
+	return err
+}
+
+// chainStatsRecords returns the record of the chain of the entry block, with
+// the block added.  The size of each entry is taken from entries if it is
+// there, and from the database if not; entries the node doesn't have count
+// for nothing.  A block at or below the last height already counted is left
+// out, so saving a block again doesn't count it twice.
+func (db *Overlay) chainStatsRecords(eblock interfaces.IEntryBlock, entries []interfaces.IEBEntry) ([]interfaces.Record, error) {
+	if eblock == nil {
+		return nil, nil
+	}
+	chainID := eblock.GetChainID()
+	height := eblock.GetHeader().GetDBHeight()
+
+	stats, err := db.fetchChainStats(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if stats.EBlocks > 0 && height <= stats.LastHeight {
+		return nil, nil
+	}
+
+	given := map[[32]byte]interfaces.IEBEntry{}
+	for _, e := range entries {
+		if e != nil {
+			given[e.GetHash().Fixed()] = e
+		}
+	}
+	for _, hash := range eblock.GetEntryHashes() {
+		if hash.IsMinuteMarker() {
+			continue
+		}
+		stats.Entries++
+		entry, ok := given[hash.Fixed()]
+		if !ok {
+			entry, err = db.FetchEntry(hash)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if entry == nil {
+			continue
+		}
+		data, err := entry.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		stats.Bytes += uint64(len(data))
+	}
+	if stats.EBlocks == 0 {
+		stats.FirstHeight = height
+	}
+	stats.EBlocks++
+	stats.LastHeight = height
+
+	return []interfaces.Record{{Bucket: CHAIN_STATS, Key: chainID.Bytes(), Data: stats}}, nil
+}
+
+// entryStatsRecords returns the record of the chain of an entry saved after
+// its entry block, with the size of the entry added.  An entry block counts
+// the entries the node has when it is saved, so those entry syncing gets
+// later are added as they come.  An entry saved already, or whose block isn't
+// saved yet, is left out, as it was or will be counted with the block.
+func (db *Overlay) entryStatsRecords(entry interfaces.IEBEntry) ([]interfaces.Record, error) {
+	hash := entry.DatabasePrimaryIndex()
+	exists, err := db.DB.DoesKeyExist(entry.GetChainID().Bytes(), hash.Bytes())
+	if err != nil || exists {
+		return nil, err
+	}
+	keyMR, chainID, _, err := db.FetchEntryLocation(hash)
+	if err != nil || keyMR == nil {
+		return nil, err
+	}
+	stats, err := db.fetchChainStats(chainID)
+	if err != nil || stats.EBlocks == 0 {
+		return nil, err
+	}
+	data, err := entry.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	stats.Bytes += uint64(len(data))
+	return []interfaces.Record{{Bucket: CHAIN_STATS, Key: chainID.Bytes(), Data: stats}}, nil
+}
+
+// SaveChainStats adds an entry block to the statistics of its chain.
+func (db *Overlay) SaveChainStats(eblock interfaces.IEntryBlock, entries []interfaces.IEBEntry) error {
+	batch, err := db.chainStatsRecords(eblock, entries)
+	if err != nil {
+		return err
+	}
+	return db.DB.PutInBatch(batch)
+}
+
+// SaveChainStatsMultiBatch is SaveChainStats in the current multi batch.  The
+// statistics are read from the database, so entry blocks of a chain must go
+// in a batch after that of the block before, and entries saved in the same
+// batch must be given.
+func (db *Overlay) SaveChainStatsMultiBatch(eblock interfaces.IEntryBlock, entries []interfaces.IEBEntry) error {
+	batch, err := db.chainStatsRecords(eblock, entries)
+	if err != nil {
+		return err
+	}
+	db.PutInMultiBatch(batch)
+	return nil
+}
+
+func (db *Overlay) fetchChainStats(chainID interfaces.IHash) (*ChainStatsRecord, error) {
+	stats, err := db.DB.Get(CHAIN_STATS, chainID.Bytes(), new(ChainStatsRecord))
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		return new(ChainStatsRecord), nil
+	}
+	return stats.(*ChainStatsRecord), nil
+}
+
+// FetchChainStats returns the statistics of a chain, and nil if the database
+// has no entry block of it.
+func (db *Overlay) FetchChainStats(chainID interfaces.IHash) (*interfaces.ChainStats, error) {
+	stats, err := db.fetchChainStats(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if stats.EBlocks == 0 {
+		return nil, nil
+	}
+	return &stats.ChainStats, nil
+}
+
+// indexChainStats builds the statistics of the chains from the entry blocks
+// already in the database, one directory block at a time, from height 0 up to
+// the first missing height.
+func (db *Overlay) indexChainStats() error {
+	for height := uint32(0); ; height++ {
+		dblock, err := db.FetchDBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		if dblock == nil {
+			return nil
+		}
+		for _, entry := range dblock.GetDBEntries() {
+			chainID := entry.GetChainID().Bytes()
+			if bytes.Equal(chainID, constants.ADMIN_CHAINID) || bytes.Equal(chainID, constants.FACTOID_CHAINID) ||
+				bytes.Equal(chainID, constants.EC_CHAINID) {
+				continue
+			}
+			eblock, err := db.FetchEBlock(entry.GetKeyMR())
+			if err != nil {
+				return err
+			}
+			err = db.SaveChainStats(eblock, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestChainStats(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	expected := map[[32]byte]*interfaces.ChainStats{}
+	for _, block := range blocks {
+		entries := []interfaces.IEBEntry{}
+		sizes := map[[32]byte]int{}
+		for _, e := range block.Entries {
+			entries = append(entries, e)
+			data, err := e.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sizes[e.GetHash().Fixed()] = len(data)
+		}
+
+		for _, eblock := range []*entryBlock.EBlock{block.EBlock, block.AnchorEBlock} {
+			err := dbo.SaveChainStats(eblock, entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Saving a block again doesn't count it twice
+			err = dbo.SaveChainStats(eblock, entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			chainID := eblock.GetChainID().Fixed()
+			stats := expected[chainID]
+			if stats == nil {
+				stats = &interfaces.ChainStats{FirstHeight: eblock.GetHeader().GetDBHeight()}
+				expected[chainID] = stats
+			}
+			for _, hash := range eblock.GetEntryHashes() {
+				if hash.IsMinuteMarker() {
+					continue
+				}
+				stats.Entries++
+				stats.Bytes += uint64(sizes[hash.Fixed()])
+			}
+			stats.EBlocks++
+			stats.LastHeight = eblock.GetHeader().GetDBHeight()
+		}
+	}
+	if len(expected) != 2 {
+		t.Fatalf("The test blocks have %d chains, expected 2", len(expected))
+	}
+
+	for chainID, stats := range expected {
+		got, err := dbo.FetchChainStats(primitives.NewHash(chainID[:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil {
+			t.Errorf("No statistics for chain %x", chainID)
+			continue
+		}
+		if *got != *stats {
+			t.Errorf("Chain %x has the statistics %+v, expected %+v", chainID, *got, *stats)
+		}
+		if stats.Bytes == 0 {
+			t.Errorf("Chain %x counted no bytes", chainID)
+		}
+	}
+
+	got, err := dbo.FetchChainStats(primitives.NewZeroHash())
+	if err != nil {
+		t.Error(err)
+	}
+	if got != nil {
+		t.Errorf("Statistics for a chain with no entry blocks: %+v", *got)
+	}
+}
+
+func TestChainStatsRecordMarshal(t *testing.T) {
+	r := new(ChainStatsRecord)
+	r.Entries = 12
+	r.Bytes = 1 << 40
+	r.EBlocks = 3
+	r.FirstHeight = 7
+	r.LastHeight = 100
+
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2 := new(ChainStatsRecord)
+	rest, err := r2.UnmarshalBinaryData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%v bytes left over", len(rest))
+	}
+	if r2.ChainStats != r.ChainStats {
+		t.Errorf("Records differ: %+v, expected %+v", r2.ChainStats, r.ChainStats)
+	}
+
+	_, err = r2.UnmarshalBinaryData(data[:len(data)-1])
+	if err == nil {
+		t.Errorf("No error on short data")
+	}
+}
+
+func TestChainStatsLateEntries(t *testing.T) {
+	block := testHelper.CreateFullTestBlockSet()[0]
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	// The entry block is saved before the node has any of its entries
+	dbo.StartMultiBatch()
+	if err := dbo.ProcessEBlockMultiBatch(block.EBlock, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbo.SaveChainStatsMultiBatch(block.EBlock, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbo.ExecuteMultiBatch(); err != nil {
+		t.Fatal(err)
+	}
+	chainID := block.EBlock.GetChainID()
+	stats, err := dbo.FetchChainStats(chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats == nil || stats.Bytes != 0 {
+		t.Fatalf("Expected no bytes counted, got %+v", stats)
+	}
+
+	// The entries entry syncing gets later are counted as they are saved,
+	// once each
+	var size uint64
+	for _, e := range block.Entries {
+		if !e.GetChainID().IsSameAs(chainID) {
+			continue
+		}
+		data, err := e.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += uint64(len(data))
+		for i := 0; i < 2; i++ {
+			if err := dbo.InsertEntry(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if size == 0 {
+		t.Fatal("The test block has no entries")
+	}
+	stats, err = dbo.FetchChainStats(chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Bytes != size {
+		t.Errorf("Counted %d bytes, expected %d", stats.Bytes, size)
+	}
+}
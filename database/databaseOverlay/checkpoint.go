@@ -0,0 +1,29 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+var checkpointKey = []byte("StartAt")
+
+// SaveCheckpoint writes the snapshot of the block the database starts at: the
+// marshalled dbstate, with its saved state, of the trusted checkpoint a node
+// started at in place of the genesis block.  Nothing below it is in the
+// database, so a node restarting on the database starts from it again.
+func (db *Overlay) SaveCheckpoint(snapshot []byte) error {
+	return db.DB.Put(CHECKPOINT, checkpointKey, &primitives.ByteSlice{Bytes: snapshot})
+}
+
+// FetchCheckpoint returns the snapshot written by SaveCheckpoint, or nil if the
+// database starts at the genesis block.
+func (db *Overlay) FetchCheckpoint() ([]byte, error) {
+	data, err := db.DB.Get(CHECKPOINT, checkpointKey, new(primitives.ByteSlice))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return data.(*primitives.ByteSlice).Bytes, nil
+}
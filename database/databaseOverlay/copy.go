@@ -0,0 +1,186 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// CopyBatchSize is the number of records written to the destination database
+// per batch by CopyDatabase.
+var CopyBatchSize = 1000
+
+// CopyProgress is called by CopyDatabase and VerifyCopy after each batch of a
+// bucket, with the number of keys handled so far in that bucket.  done is true
+// on the last call for a bucket.
+type CopyProgress func(bucket []byte, keys int, done bool)
+
+// IsEntryBucket tells if a bucket holds entries.  The entries of a chain are
+// kept in a bucket named by the chain ID, which is longer than any of the
+// fixed bucket names, or the same prefixed by a network namespace.
+func IsEntryBucket(bucket []byte) bool {
+	return len(stripNamespace(bucket)) == constants.HASH_LENGTH
+}
+
+// KnownBuckets returns every bucket the overlay writes to in db: the fixed
+// buckets, plus the entry bucket and the entry block number bucket of each
+// chain with a chain head.  It is used for databases, like LevelDB, that
+// can't list their own buckets.
+func KnownBuckets(db interfaces.IDatabase) ([][]byte, error) {
+	buckets := [][]byte{}
+	for name := range ConstantNamesMap {
+		buckets = append(buckets, []byte(name))
+	}
+
+	chains, err := db.ListAllKeys(CHAIN_HEAD)
+	if err != nil {
+		return nil, err
+	}
+	for _, chainID := range chains {
+		buckets = append(buckets, chainID)
+		buckets = append(buckets, append(append([]byte{}, ENTRYBLOCK_CHAIN_NUMBER...), chainID...))
+	}
+	return buckets, nil
+}
+
+// copyBuckets lists the buckets of db, falling back on KnownBuckets when the
+// database can't list them.
+func copyBuckets(db interfaces.IDatabase) ([][]byte, error) {
+	buckets, err := db.ListAllBuckets()
+	if err != nil {
+		return KnownBuckets(db)
+	}
+	return buckets, nil
+}
+
+// forEachKeyBatch calls fn with the keys of the bucket in byte order,
+// CopyBatchSize of them at a time, so no bucket is held in memory whole.
+// last is true for the last of them.  The keys of a batch are collected
+// before fn is called, as the database can't be read from inside ForEachKey.
+func forEachKeyBatch(db interfaces.IDatabase, bucket []byte, fn func(keys [][]byte, last bool) error) error {
+	var start []byte
+	full := fmt.Errorf("batch full")
+	for {
+		keys := [][]byte{}
+		err := db.ForEachKey(bucket, start, nil, func(key []byte) error {
+			if len(keys) >= CopyBatchSize {
+				return full
+			}
+			keys = append(keys, key)
+			return nil
+		})
+		if err != nil && err != full {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		if err := fn(keys, err != full); err != nil {
+			return err
+		}
+		if err != full {
+			return nil
+		}
+		// The next batch starts just past the last key of this one
+		start = append(append([]byte{}, keys[len(keys)-1]...), 0)
+	}
+}
+
+// CopyDatabase copies every key of every bucket in from into to, as raw bytes,
+// and returns the total number of keys copied.  progress may be nil.
+func CopyDatabase(from, to interfaces.IDatabase, progress CopyProgress) (total int, err error) {
+	buckets, err := copyBuckets(from)
+	if err != nil {
+		return 0, err
+	}
+
+	// The copy is flushed to disk once, at the end, rather than batch by batch
+	if err := to.SetBulkLoad(true); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err2 := to.SetBulkLoad(false); err == nil {
+			err = err2
+		}
+	}()
+
+	for _, bucket := range buckets {
+		copied := 0
+		err = forEachKeyBatch(from, bucket, func(keys [][]byte, last bool) error {
+			batch := []interfaces.Record{}
+			for _, key := range keys {
+				data, err := from.Get(bucket, key, new(primitives.ByteSlice))
+				if err != nil {
+					return err
+				}
+				if data == nil {
+					return fmt.Errorf("Key %x in bucket %x disappeared while copying", key, bucket)
+				}
+				batch = append(batch, interfaces.Record{Bucket: bucket, Key: key, Data: data})
+			}
+			err := to.PutInBatch(batch)
+			if err != nil {
+				return err
+			}
+			copied += len(batch)
+			total += len(batch)
+			if progress != nil {
+				progress(bucket, copied, last)
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// VerifyCopy checks that every key of every bucket in from exists in to with the
+// same value, and returns the number of keys checked.  The first difference
+// found is returned as an error.  progress may be nil.
+func VerifyCopy(from, to interfaces.IDatabase, progress CopyProgress) (int, error) {
+	buckets, err := copyBuckets(from)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, bucket := range buckets {
+		checked := 0
+		err = forEachKeyBatch(from, bucket, func(keys [][]byte, last bool) error {
+			for _, key := range keys {
+				want, err := from.Get(bucket, key, new(primitives.ByteSlice))
+				if err != nil {
+					return err
+				}
+				got, err := to.Get(bucket, key, new(primitives.ByteSlice))
+				if err != nil {
+					return err
+				}
+				if got == nil {
+					return fmt.Errorf("Key %x in bucket %x is missing from the copy", key, bucket)
+				}
+				if want != nil && !want.(*primitives.ByteSlice).IsSameAs(got.(*primitives.ByteSlice)) {
+					return fmt.Errorf("Key %x in bucket %x has a different value in the copy", key, bucket)
+				}
+				checked++
+				total++
+			}
+			if progress != nil {
+				progress(bucket, checked, last)
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
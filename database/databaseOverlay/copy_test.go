@@ -0,0 +1,107 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+)
+
+func TestCopyDatabase(t *testing.T) {
+	from := new(mapdb.MapDB)
+	from.Init(nil)
+	to := new(mapdb.MapDB)
+	to.Init(nil)
+
+	buckets := [][]byte{[]byte("one"), []byte("two")}
+	for _, bucket := range buckets {
+		for i := 0; i < 25; i++ {
+			err := from.Put(bucket, []byte{byte(i)}, primitives.RandomByteSlice())
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+
+	old := CopyBatchSize
+	CopyBatchSize = 10
+	defer func() { CopyBatchSize = old }()
+
+	calls := 0
+	copied, err := CopyDatabase(from, to, func(bucket []byte, keys int, done bool) {
+		calls++
+	})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if copied != 50 {
+		t.Errorf("Copied %v keys, expected 50", copied)
+	}
+	if calls != 6 {
+		t.Errorf("Progress called %v times, expected 6", calls)
+	}
+
+	checked, err := VerifyCopy(from, to, nil)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if checked != 50 {
+		t.Errorf("Checked %v keys, expected 50", checked)
+	}
+
+	err = to.Delete(buckets[1], []byte{7})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	_, err = VerifyCopy(from, to, nil)
+	if err == nil {
+		t.Errorf("Missing key not detected")
+	}
+
+	_, err = CopyDatabase(from, to, nil)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	err = to.Put(buckets[0], []byte{3}, primitives.RandomByteSlice())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	_, err = VerifyCopy(from, to, nil)
+	if err == nil {
+		t.Errorf("Changed value not detected")
+	}
+}
+
+func TestKnownBuckets(t *testing.T) {
+	db := new(mapdb.MapDB)
+	db.Init(nil)
+	chainID := primitives.Sha([]byte("chain"))
+	if err := db.Put(CHAIN_HEAD, chainID.Bytes(), primitives.NewZeroHash()); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	buckets, err := KnownBuckets(db)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	found := map[string]bool{}
+	for _, bucket := range buckets {
+		found[string(bucket)] = true
+	}
+	// The entries of the chain, and its entry block numbers, are in buckets
+	// of their own
+	if !found[string(chainID.Bytes())] {
+		t.Errorf("The entry bucket of the chain is not known")
+	}
+	if !found[string(append(append([]byte{}, ENTRYBLOCK_CHAIN_NUMBER...), chainID.Bytes()...))] {
+		t.Errorf("The entry block number bucket of the chain is not known")
+	}
+	if !found[string(DIRECTORYBLOCK)] {
+		t.Errorf("The directory block bucket is not known")
+	}
+}
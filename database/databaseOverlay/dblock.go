@@ -9,6 +9,7 @@
 package databaseOverlay
 
 import (
+	"fmt"
 	"sort"
 
 	"github.com/FactomProject/factomd/common/directoryBlock"
@@ -59,6 +60,28 @@ func (db *Overlay) FetchDBlockHeightRange(startHeight, endHeight int64) ([]inter
 	return db.FetchBlockIndexesInHeightRange(DIRECTORYBLOCK_NUMBER, startHeight, endHeight)
 }
 
+// FetchDBlocksInHeightRange loads the directory blocks in a range of heights,
+// with the same range rules as FetchDBlockHeightRange.
+func (db *Overlay) FetchDBlocksInHeightRange(startHeight, endHeight int64) ([]interfaces.IDirectoryBlock, error) {
+	keyMRs, err := db.FetchDBlockHeightRange(startHeight, endHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]interfaces.IDirectoryBlock, 0, len(keyMRs))
+	for _, keyMR := range keyMRs {
+		block, err := db.FetchDBlock(keyMR)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("DBlock %v is indexed but not found", keyMR)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
 // FetchBlockHeightByKeyMR returns the block height for the given hash.  This is
 // part of the database.Db interface implementation.
 func (db *Overlay) FetchDBlockHeightByKeyMR(sha interfaces.IHash) (int64, error) {
@@ -86,6 +109,12 @@ func (db *Overlay) FetchDBlock(hash interfaces.IHash) (interfaces.IDirectoryBloc
 	return db.FetchDBlockBySecondary(hash)
 }
 
+// DoesDBlockExist checks if a directory block with the given keyMR is in the
+// database, without loading it.
+func (db *Overlay) DoesDBlockExist(keyMR interfaces.IHash) (bool, error) {
+	return db.DoesKeyExist(DIRECTORYBLOCK, keyMR.Bytes())
+}
+
 // FetchDBlock gets an entry by hash from the database.
 func (db *Overlay) FetchDBlockByPrimary(keyMR interfaces.IHash) (interfaces.IDirectoryBlock, error) {
 	block, err := db.FetchBlock(DIRECTORYBLOCK, keyMR, new(directoryBlock.DirectoryBlock))
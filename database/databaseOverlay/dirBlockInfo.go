@@ -22,10 +22,8 @@ func (db *Overlay) ProcessDirBlockInfoBatch(block interfaces.IDirBlockInfo) erro
 
 func (db *Overlay) ProcessDirBlockInfoMultiBatch(block interfaces.IDirBlockInfo) error {
 	if block.GetBTCConfirmed() == true {
-		err := db.Delete(DIRBLOCKINFO_UNCONFIRMED, block.DatabasePrimaryIndex().Bytes())
-		if err != nil {
-			return err
-		}
+		// Deleted in the batch, so the block can't be left both unconfirmed and confirmed
+		db.PutInMultiBatch([]interfaces.Record{{Bucket: DIRBLOCKINFO_UNCONFIRMED, Key: block.DatabasePrimaryIndex().Bytes()}})
 		return db.ProcessBlockMultiBatchWithoutHead(DIRBLOCKINFO, DIRBLOCKINFO_NUMBER, DIRBLOCKINFO_SECONDARYINDEX, block)
 	} else {
 		return db.ProcessBlockMultiBatchWithoutHead(DIRBLOCKINFO_UNCONFIRMED, DIRBLOCKINFO_NUMBER, DIRBLOCKINFO_SECONDARYINDEX, block)
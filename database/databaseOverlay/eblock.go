@@ -1,6 +1,9 @@
 package databaseOverlay
 
 import (
+	"encoding/binary"
+	"fmt"
+
 	"github.com/FactomProject/factomd/common/entryBlock"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
@@ -18,7 +21,11 @@ func (db *Overlay) ProcessEBlockBatch(eblock interfaces.DatabaseBlockWithEntries
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlock(eblock, checkForDuplicateEntries)
+	err = db.SaveIncludedInMultiFromBlock(eblock, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	return db.SaveEntryLocationsFromBlock(eblock, checkForDuplicateEntries)
 }
 
 func (db *Overlay) ProcessEBlockBatchWithoutHead(eblock interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) error {
@@ -28,7 +35,11 @@ func (db *Overlay) ProcessEBlockBatchWithoutHead(eblock interfaces.DatabaseBlock
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlock(eblock, checkForDuplicateEntries)
+	err = db.SaveIncludedInMultiFromBlock(eblock, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	return db.SaveEntryLocationsFromBlock(eblock, checkForDuplicateEntries)
 }
 
 func (db *Overlay) ProcessEBlockMultiBatchWithoutHead(eblock interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) error {
@@ -38,7 +49,11 @@ func (db *Overlay) ProcessEBlockMultiBatchWithoutHead(eblock interfaces.Database
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlockMultiBatch(eblock, checkForDuplicateEntries)
+	err = db.SaveIncludedInMultiFromBlockMultiBatch(eblock, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	return db.SaveEntryLocationsFromBlockMultiBatch(eblock, checkForDuplicateEntries)
 }
 
 func (db *Overlay) ProcessEBlockMultiBatch(eblock interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) error {
@@ -48,7 +63,11 @@ func (db *Overlay) ProcessEBlockMultiBatch(eblock interfaces.DatabaseBlockWithEn
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlockMultiBatch(eblock, checkForDuplicateEntries)
+	err = db.SaveIncludedInMultiFromBlockMultiBatch(eblock, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	return db.SaveEntryLocationsFromBlockMultiBatch(eblock, checkForDuplicateEntries)
 }
 
 func (db *Overlay) FetchEBlock(hash interfaces.IHash) (interfaces.IEntryBlock, error) {
@@ -112,6 +131,47 @@ func (db *Overlay) FetchAllEBlocksByChain(chainID interfaces.IHash) ([]interface
 	return list, nil
 }
 
+// FetchEBlocksByChainFrom gets the blocks of a chain from the one in directory
+// block startHeight on, oldest first, and at most limit of them.
+func (db *Overlay) FetchEBlocksByChainFrom(chainID interfaces.IHash, startHeight uint32, limit int) ([]interfaces.IEntryBlock, error) {
+	bucket := append(append([]byte{}, ENTRYBLOCK_CHAIN_NUMBER...), chainID.Bytes()...)
+	start := make([]byte, 4)
+	binary.BigEndian.PutUint32(start, startHeight)
+
+	// Collect the heights first, the database can't be read from inside ForEachKey
+	keys := [][]byte{}
+	full := fmt.Errorf("full")
+	err := db.DB.ForEachKey(bucket, start, nil, func(key []byte) error {
+		if len(keys) >= limit {
+			return full
+		}
+		keys = append(keys, append([]byte{}, key...))
+		return nil
+	})
+	if err != nil && err != full {
+		return nil, err
+	}
+
+	keyMRs, err := db.DB.GetMulti(bucket, keys, new(primitives.Hash))
+	if err != nil {
+		return nil, err
+	}
+	list := []interfaces.IEntryBlock{}
+	for _, keyMR := range keyMRs {
+		if keyMR == nil {
+			continue
+		}
+		block, err := db.FetchEBlock(keyMR.(interfaces.IHash))
+		if err != nil {
+			return nil, err
+		}
+		if block != nil {
+			list = append(list, block)
+		}
+	}
+	return list, nil
+}
+
 func (db *Overlay) SaveEBlockHead(block interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) error {
 	return db.ProcessEBlockBatch(block, checkForDuplicateEntries)
 }
@@ -134,8 +194,7 @@ func (db *Overlay) FetchAllEBlockChainIDs() ([]interfaces.IHash, error) {
 	}
 	entries := []interfaces.IHash{}
 	for _, h := range ids {
-		str := h.String()
-		if strings.Contains(str, "000000000000000000000000000000000000000000000000000000000000000") {
+		if isBasicChainID(h) {
 			//skipping basic blocks
 			continue
 		}
@@ -143,3 +202,40 @@ func (db *Overlay) FetchAllEBlockChainIDs() ([]interfaces.IHash, error) {
 	}
 	return entries, nil
 }
+
+// FetchChainIDs gets the IDs of the entry chains that come after the given
+// one in byte order, and at most limit of them.  A nil after starts at the
+// first chain.
+func (db *Overlay) FetchChainIDs(after interfaces.IHash, limit int) ([]interfaces.IHash, error) {
+	var start []byte
+	if after != nil {
+		// The smallest key greater than after
+		start = append(append([]byte{}, after.Bytes()...), 0)
+	}
+
+	ids := []interfaces.IHash{}
+	full := fmt.Errorf("full")
+	err := db.DB.ForEachKey(CHAIN_HEAD, start, nil, func(key []byte) error {
+		if len(ids) >= limit {
+			return full
+		}
+		h, err := primitives.NewShaHash(key)
+		if err != nil {
+			return err
+		}
+		if !isBasicChainID(h) {
+			ids = append(ids, h)
+		}
+		return nil
+	})
+	if err != nil && err != full {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// isBasicChainID is true for the chains of the admin, entry credit and
+// factoid blocks, which are all but one byte zeros.
+func isBasicChainID(h interfaces.IHash) bool {
+	return strings.Contains(h.String(), "000000000000000000000000000000000000000000000000000000000000000")
+}
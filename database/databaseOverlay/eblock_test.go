@@ -211,3 +211,61 @@ func TestFetchAllEBlockChainIDs(t *testing.T) {
 		t.Errorf("Got wrong number of chains - %v", len(chains))
 	}
 }
+
+func TestChainHeadDoesNotMoveBack(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	blocks := []*EBlock{}
+	var prev *EBlock = nil
+	for i := 0; i < 5; i++ {
+		prev, _ = testHelper.CreateTestEntryBlock(prev)
+		blocks = append(blocks, prev)
+	}
+
+	exists, err := dbo.DoesChainExist(prev.GetChainID())
+	if err != nil {
+		t.Error(err)
+	}
+	if exists {
+		t.Error("Chain exists before any block was saved")
+	}
+
+	for _, b := range blocks {
+		err = dbo.ProcessEBlockBatch(b, false)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Saving an older block again, as syncing can, keeps the newest head
+	err = dbo.ProcessEBlockBatch(blocks[2], false)
+	if err != nil {
+		t.Error(err)
+	}
+	dbo.StartMultiBatch()
+	err = dbo.ProcessEBlockMultiBatch(blocks[1], false)
+	if err != nil {
+		t.Error(err)
+	}
+	err = dbo.ExecuteMultiBatch()
+	if err != nil {
+		t.Error(err)
+	}
+
+	head, err := dbo.FetchHeadIndexByChainID(prev.GetChainID())
+	if err != nil {
+		t.Error(err)
+	}
+	if head == nil || head.IsSameAs(blocks[4].DatabasePrimaryIndex()) == false {
+		t.Errorf("Chain head moved back to %v", head)
+	}
+
+	exists, err = dbo.DoesChainExist(prev.GetChainID())
+	if err != nil {
+		t.Error(err)
+	}
+	if !exists {
+		t.Error("Chain doesn't exist")
+	}
+}
@@ -19,8 +19,14 @@ func (db *Overlay) InsertEntry(entry interfaces.IEBEntry) error {
 	batch := []interfaces.Record{}
 	batch = append(batch, interfaces.Record{entry.GetChainID().Bytes(), entry.DatabasePrimaryIndex().Bytes(), entry})
 	batch = append(batch, interfaces.Record{ENTRY, entry.DatabasePrimaryIndex().Bytes(), entry.GetChainIDHash()})
+	stats, err := db.entryStatsRecords(entry)
+	if err != nil {
+		// The statistics are only reported, so the entry is saved without them
+		dbLog.Warningf("Could not count entry %v in the chain statistics: %v", entry.GetHash(), err)
+	}
+	batch = append(batch, stats...)
 
-	err := db.PutInBatch(batch)
+	err = db.PutInBatch(batch)
 	if err != nil {
 		return err
 	}
@@ -42,6 +48,12 @@ func (db *Overlay) InsertEntryMultiBatch(entry interfaces.IEBEntry) error {
 	batch := []interfaces.Record{}
 	batch = append(batch, interfaces.Record{entry.GetChainID().Bytes(), entry.DatabasePrimaryIndex().Bytes(), entry})
 	batch = append(batch, interfaces.Record{ENTRY, entry.DatabasePrimaryIndex().Bytes(), entry.GetChainIDHash()})
+	stats, err := db.entryStatsRecords(entry)
+	if err != nil {
+		// The statistics are only reported, so the entry is saved without them
+		dbLog.Warningf("Could not count entry %v in the chain statistics: %v", entry.GetHash(), err)
+	}
+	batch = append(batch, stats...)
 
 	db.PutInMultiBatch(batch)
 	if entry.GetChainID().String() == AnchorBlockID {
@@ -71,6 +83,36 @@ func (db *Overlay) FetchEntry(hash interfaces.IHash) (interfaces.IEBEntry, error
 	return entry.(interfaces.IEBEntry), nil
 }
 
+// DoesEntryExist checks if an entry is in the database, without loading it.
+func (db *Overlay) DoesEntryExist(hash interfaces.IHash) (bool, error) {
+	return db.DoesKeyExist(ENTRY, hash.Bytes())
+}
+
+// FetchEBlockEntries fetches the entries of an entry block that are in the
+// database, in order, in one read of the chain's bucket.  Minute markers and
+// entries the database doesn't have are left out.
+func (db *Overlay) FetchEBlockEntries(eBlock interfaces.IEntryBlock) ([]interfaces.IEBEntry, error) {
+	keys := [][]byte{}
+	for _, hash := range eBlock.GetEntryHashes() {
+		if hash.IsMinuteMarker() {
+			continue
+		}
+		keys = append(keys, hash.Bytes())
+	}
+
+	list, err := db.GetMulti(eBlock.GetChainID().Bytes(), keys, entryBlock.NewEntry())
+	if err != nil {
+		return nil, err
+	}
+	entries := []interfaces.IEBEntry{}
+	for _, v := range list {
+		if v != nil {
+			entries = append(entries, v.(interfaces.IEBEntry))
+		}
+	}
+	return entries, nil
+}
+
 func (db *Overlay) FetchAllEntriesByChainID(chainID interfaces.IHash) ([]interfaces.IEBEntry, error) {
 	list, err := db.FetchAllBlocksFromBucket(chainID.Bytes(), entryBlock.NewEntry())
 	if err != nil {
@@ -0,0 +1,114 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// EntryLocation is the ENTRY_LOCATION index record of an entry: the entry
+// block it is in, that block's chain, and the directory block height.
+type EntryLocation struct {
+	EBlockKeyMR  interfaces.IHash
+	ChainID      interfaces.IHash
+	DBlockHeight uint32
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*EntryLocation)(nil)
+
+func (e *EntryLocation) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(EntryLocation)
+}
+
+func (e *EntryLocation) MarshalBinary() ([]byte, error) {
+	if e.EBlockKeyMR == nil || e.ChainID == nil {
+		return nil, fmt.Errorf("EntryLocation is incomplete")
+	}
+	data := make([]byte, 0, 68)
+	data = append(data, e.EBlockKeyMR.Bytes()...)
+	data = append(data, e.ChainID.Bytes()...)
+	height := make([]byte, 4)
+	binary.BigEndian.PutUint32(height, e.DBlockHeight)
+	return append(data, height...), nil
+}
+
+func (e *EntryLocation) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	if len(data) < 68 {
+		return nil, fmt.Errorf("EntryLocation needs 68 bytes, got %d", len(data))
+	}
+	e.EBlockKeyMR = primitives.NewHash(data[:32])
+	e.ChainID = primitives.NewHash(data[32:64])
+	e.DBlockHeight = binary.BigEndian.Uint32(data[64:68])
+	return data[68:], nil
+}
+
+func (e *EntryLocation) UnmarshalBinary(data []byte) error {
+	_, err := e.UnmarshalBinaryData(data)
+	return err
+}
+
+// entryLocationRecords returns the ENTRY_LOCATION records of the entries in an
+// entry block.  Like IncludedIn, an entry already indexed keeps its first
+// location when checkForDuplicateEntries is set.
+func (db *Overlay) entryLocationRecords(eblock interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) ([]interfaces.Record, error) {
+	location := new(EntryLocation)
+	location.EBlockKeyMR = eblock.DatabasePrimaryIndex()
+	location.ChainID = eblock.GetChainID()
+	location.DBlockHeight = eblock.GetDatabaseHeight()
+
+	batch := []interfaces.Record{}
+	for _, entry := range eblock.GetEntryHashes() {
+		if entry.IsMinuteMarker() == true {
+			continue
+		}
+		if checkForDuplicateEntries == true {
+			exists, err := db.DoesKeyExist(ENTRY_LOCATION, entry.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			if exists == true {
+				continue
+			}
+		}
+		batch = append(batch, interfaces.Record{Bucket: ENTRY_LOCATION, Key: entry.Bytes(), Data: location})
+	}
+	return batch, nil
+}
+
+func (db *Overlay) SaveEntryLocationsFromBlock(eblock interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) error {
+	batch, err := db.entryLocationRecords(eblock, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	return db.DB.PutInBatch(batch)
+}
+
+func (db *Overlay) SaveEntryLocationsFromBlockMultiBatch(eblock interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) error {
+	batch, err := db.entryLocationRecords(eblock, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	db.PutInMultiBatch(batch)
+	return nil
+}
+
+// FetchEntryLocation returns the keyMR and chain of the entry block an entry is
+// in, and the directory block height, without loading any block.  A nil
+// eBlockKeyMR means the entry isn't indexed.
+func (db *Overlay) FetchEntryLocation(entryHash interfaces.IHash) (eBlockKeyMR interfaces.IHash, chainID interfaces.IHash, dBlockHeight uint32, err error) {
+	location, err := db.DB.Get(ENTRY_LOCATION, entryHash.Bytes(), new(EntryLocation))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if location == nil {
+		return nil, nil, 0, nil
+	}
+	l := location.(*EntryLocation)
+	return l.EBlockKeyMR, l.ChainID, l.DBlockHeight, nil
+}
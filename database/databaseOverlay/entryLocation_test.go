@@ -0,0 +1,90 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestEntryLocation(t *testing.T) {
+	blocks := []*EBlock{}
+	max := 10
+	var prev *EBlock = nil
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	for i := 0; i < max; i++ {
+		prev, _ = testHelper.CreateTestEntryBlock(prev)
+		blocks = append(blocks, prev)
+		err := dbo.SaveEBlockHead(prev, false)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	for _, block := range blocks {
+		for _, entry := range block.GetEntryHashes() {
+			keyMR, chainID, height, err := dbo.FetchEntryLocation(entry)
+			if err != nil {
+				t.Error(err)
+			}
+			if keyMR == nil {
+				t.Errorf("Entry %v not indexed", entry)
+				continue
+			}
+			if keyMR.IsSameAs(block.DatabasePrimaryIndex()) == false {
+				t.Error("Wrong entry block keyMR")
+			}
+			if chainID.IsSameAs(block.GetChainID()) == false {
+				t.Error("Wrong chain ID")
+			}
+			if height != block.GetDatabaseHeight() {
+				t.Errorf("Wrong height %v, expected %v", height, block.GetDatabaseHeight())
+			}
+		}
+	}
+
+	keyMR, _, _, err := dbo.FetchEntryLocation(primitives.RandomHash())
+	if err != nil {
+		t.Error(err)
+	}
+	if keyMR != nil {
+		t.Error("Found a location for an unknown entry")
+	}
+}
+
+func TestEntryLocationMarshal(t *testing.T) {
+	l := new(EntryLocation)
+	l.EBlockKeyMR = primitives.RandomHash()
+	l.ChainID = primitives.RandomHash()
+	l.DBlockHeight = 123456
+
+	data, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2 := new(EntryLocation)
+	rest, err := l2.UnmarshalBinaryData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%v bytes left over", len(rest))
+	}
+	if !l.EBlockKeyMR.IsSameAs(l2.EBlockKeyMR) || !l.ChainID.IsSameAs(l2.ChainID) || l.DBlockHeight != l2.DBlockHeight {
+		t.Errorf("Locations differ")
+	}
+
+	_, err = l2.UnmarshalBinaryData(data[:67])
+	if err == nil {
+		t.Errorf("No error on short data")
+	}
+}
@@ -125,3 +125,62 @@ func TestLoadUnknownEntries(t *testing.T) {
 		}
 	}
 }
+
+func TestDoesEntryExist(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	entry := testHelper.CreateFirstTestEntry()
+	exists, err := dbo.DoesEntryExist(entry.GetHash())
+	if err != nil {
+		t.Error(err)
+	}
+	if exists {
+		t.Error("Entry exists before it was inserted")
+	}
+
+	err = dbo.InsertEntry(entry)
+	if err != nil {
+		t.Error(err)
+	}
+	exists, err = dbo.DoesEntryExist(entry.GetHash())
+	if err != nil {
+		t.Error(err)
+	}
+	if !exists {
+		t.Error("Entry does not exist after it was inserted")
+	}
+}
+
+func TestFetchEBlockEntries(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	eBlock := entryBlock.NewEBlock()
+	eBlock.Header.SetChainID(testHelper.GetChainID())
+	entries := []*entryBlock.Entry{}
+	for i := uint32(1); i <= 3; i++ {
+		entry := testHelper.CreateTestEntry(i)
+		eBlock.AddEBEntry(entry)
+		entries = append(entries, entry)
+	}
+	eBlock.AddEndOfMinuteMarker(1)
+
+	// The second entry is missing
+	for _, entry := range []*entryBlock.Entry{entries[0], entries[2]} {
+		if err := dbo.InsertEntry(entry); err != nil {
+			t.Error(err)
+		}
+	}
+
+	got, err := dbo.FetchEBlockEntries(eBlock)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Got %d entries, expected 2", len(got))
+	}
+	if !got[0].GetHash().IsSameAs(entries[0].GetHash()) || !got[1].GetHash().IsSameAs(entries[2].GetHash()) {
+		t.Errorf("Got entries %v and %v, expected %v and %v", got[0].GetHash(), got[1].GetHash(), entries[0].GetHash(), entries[2].GetHash())
+	}
+}
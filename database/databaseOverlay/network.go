@@ -0,0 +1,202 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+var networkIDKey = []byte("NetworkID")
+
+// namespaceMarker starts the name of every bucket of a network namespace.
+// The fixed buckets of the overlay are named in ASCII, but the entry buckets
+// are named by chain IDs, which may start with it too, so a namespaced entry
+// bucket is told apart by its length as well.
+const namespaceMarker = 0x00
+
+// namespaceLength is the length of the prefix of a namespaced bucket: the
+// marker and the 4 byte network ID.
+const namespaceLength = 5
+
+// NetworkNamespace returns the part of dbase that holds the blockchain of the
+// network with the given ID, so the blocks of two networks never mix in one
+// database.  The buckets of the database, as they are, belong to a single
+// network: the one recorded in the database, or for a database from before
+// the network was recorded, the one of its highest directory block, or for an
+// empty database, the first one to use it.  Every other network gets buckets
+// of its own, whose names are prefixed by a 0x00 byte and its network ID.
+//
+// An error is returned if the namespace of the network holds the directory
+// blocks of another network.
+func NetworkNamespace(dbase interfaces.IDatabase, networkID uint32) (interfaces.IDatabase, error) {
+	owner, err := rootNetworkID(dbase, networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := dbase
+	if owner != networkID {
+		dbLog.Warningf("The database holds the blockchain of network %x; the blocks of network %x are kept apart from it", owner, networkID)
+		namespace = &namespaceDB{db: dbase, prefix: namespacePrefix(networkID)}
+	}
+
+	head, err := NewOverlay(namespace).FetchDBlockHead()
+	if err != nil {
+		return nil, err
+	}
+	if head != nil && head.GetHeader().GetNetworkID() != networkID {
+		return nil, fmt.Errorf("The database holds directory blocks of network %x where those of network %x belong", head.GetHeader().GetNetworkID(), networkID)
+	}
+	return namespace, nil
+}
+
+// rootNetworkID returns the ID of the network the unprefixed buckets of dbase
+// belong to, and records it if it isn't recorded yet.  An empty database is
+// claimed for networkID.
+func rootNetworkID(dbase interfaces.IDatabase, networkID uint32) (uint32, error) {
+	stored, err := dbase.Get(NETWORK, networkIDKey, new(primitives.ByteSlice))
+	if err != nil {
+		return 0, err
+	}
+	if stored != nil {
+		data := stored.(*primitives.ByteSlice).Bytes
+		if len(data) != 4 {
+			return 0, fmt.Errorf("The network ID in the database is %d bytes, expected 4", len(data))
+		}
+		return binary.BigEndian.Uint32(data), nil
+	}
+
+	owner := networkID
+	head, err := NewOverlay(dbase).FetchDBlockHead()
+	if err != nil {
+		return 0, err
+	}
+	if head != nil {
+		owner = head.GetHeader().GetNetworkID()
+	}
+
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, owner)
+	return owner, dbase.Put(NETWORK, networkIDKey, &primitives.ByteSlice{Bytes: data})
+}
+
+func namespacePrefix(networkID uint32) []byte {
+	prefix := make([]byte, namespaceLength)
+	prefix[0] = namespaceMarker
+	binary.BigEndian.PutUint32(prefix[1:], networkID)
+	return prefix
+}
+
+// stripNamespace returns the name of an entry bucket without its namespace
+// prefix, if it has one.  Any other bucket is returned as is.
+func stripNamespace(bucket []byte) []byte {
+	if len(bucket) == namespaceLength+constants.HASH_LENGTH && bucket[0] == namespaceMarker {
+		return bucket[namespaceLength:]
+	}
+	return bucket
+}
+
+// namespaceDB is the namespace of a network in a database shared with
+// others.  Every bucket name is prefixed on the way in, and the prefix is
+// taken off the bucket names that come back out.
+type namespaceDB struct {
+	db     interfaces.IDatabase
+	prefix []byte
+}
+
+var _ interfaces.IDatabase = (*namespaceDB)(nil)
+
+func (n *namespaceDB) bucket(bucket []byte) []byte {
+	b := make([]byte, 0, len(n.prefix)+len(bucket))
+	b = append(b, n.prefix...)
+	return append(b, bucket...)
+}
+
+func (n *namespaceDB) Close() error {
+	return n.db.Close()
+}
+
+func (n *namespaceDB) Put(bucket, key []byte, data interfaces.BinaryMarshallable) error {
+	return n.db.Put(n.bucket(bucket), key, data)
+}
+
+func (n *namespaceDB) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	return n.db.Get(n.bucket(bucket), key, destination)
+}
+
+func (n *namespaceDB) Delete(bucket, key []byte) error {
+	return n.db.Delete(n.bucket(bucket), key)
+}
+
+func (n *namespaceDB) ListAllKeys(bucket []byte) ([][]byte, error) {
+	return n.db.ListAllKeys(n.bucket(bucket))
+}
+
+func (n *namespaceDB) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	return n.db.ForEachKey(n.bucket(bucket), start, limit, fn)
+}
+
+func (n *namespaceDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	return n.db.GetAll(n.bucket(bucket), sample)
+}
+
+func (n *namespaceDB) Clear(bucket []byte) error {
+	return n.db.Clear(n.bucket(bucket))
+}
+
+func (n *namespaceDB) PutInBatch(records []interfaces.Record) error {
+	prefixed := make([]interfaces.Record, len(records))
+	for i, r := range records {
+		prefixed[i] = interfaces.Record{Bucket: n.bucket(r.Bucket), Key: r.Key, Data: r.Data}
+	}
+	return n.db.PutInBatch(prefixed)
+}
+
+// ListAllBuckets lists the buckets of the namespace only.
+func (n *namespaceDB) ListAllBuckets() ([][]byte, error) {
+	all, err := n.db.ListAllBuckets()
+	if err != nil {
+		return nil, err
+	}
+	buckets := [][]byte{}
+	for _, b := range all {
+		if len(b) > len(n.prefix) && bytes.HasPrefix(b, n.prefix) {
+			buckets = append(buckets, b[len(n.prefix):])
+		}
+	}
+	return buckets, nil
+}
+
+func (n *namespaceDB) Trim() {
+	n.db.Trim()
+}
+
+func (n *namespaceDB) DoesKeyExist(bucket, key []byte) (bool, error) {
+	return n.db.DoesKeyExist(n.bucket(bucket), key)
+}
+
+func (n *namespaceDB) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	return n.db.GetMulti(n.bucket(bucket), keys, sample)
+}
+
+func (n *namespaceDB) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	stats, err := n.db.BucketStats(n.bucket(bucket))
+	stats.Bucket = bucket
+	return stats, err
+}
+
+func (n *namespaceDB) Compact() error {
+	return n.db.Compact()
+}
+
+func (n *namespaceDB) SetBulkLoad(bulk bool) error {
+	return n.db.SetBulkLoad(bulk)
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestNetworkNamespace(t *testing.T) {
+	// A database from before namespaces belongs to the network of its blocks
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	local, err := NetworkNamespace(dbo.DB, constants.LOCAL_NETWORK_ID)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if local != dbo.DB {
+		t.Errorf("The LOCAL blocks were moved out of the root of the database")
+	}
+
+	main, err := NetworkNamespace(dbo.DB, constants.MAIN_NETWORK_ID)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if head, err := NewOverlay(main).FetchDBlockHead(); err != nil || head != nil {
+		t.Errorf("MAIN reads the LOCAL blocks: %v %v", head, err)
+	}
+
+	chainID := testHelper.GetChainID().Bytes()
+	if err := main.Put(chainID, []byte("key"), &primitives.ByteSlice{Bytes: []byte("MAIN")}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if data, _ := local.Get(chainID, []byte("key"), new(primitives.ByteSlice)); data != nil {
+		t.Errorf("LOCAL reads the records of MAIN")
+	}
+	data, err := main.Get(chainID, []byte("key"), new(primitives.ByteSlice))
+	if err != nil || data == nil || string(data.(*primitives.ByteSlice).Bytes) != "MAIN" {
+		t.Errorf("MAIN can't read its own record: %v %v", data, err)
+	}
+	buckets, err := main.ListAllBuckets()
+	if err != nil || len(buckets) != 1 || string(buckets[0]) != string(chainID) {
+		t.Errorf("MAIN lists the buckets %x, expected only %x: %v", buckets, chainID, err)
+	}
+
+	// The namespaced bucket of a chain still holds entries
+	all, _ := dbo.DB.ListAllBuckets()
+	found := false
+	for _, b := range all {
+		if len(b) > len(chainID) && IsEntryBucket(b) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("The namespaced bucket of a chain is not an entry bucket")
+	}
+
+	// Reopening the database finds each network where it was
+	if again, err := NetworkNamespace(dbo.DB, constants.LOCAL_NETWORK_ID); err != nil || again != dbo.DB {
+		t.Errorf("LOCAL moved on reopening: %v", err)
+	}
+}
+
+func TestNetworkNamespaceEmpty(t *testing.T) {
+	db := new(mapdb.MapDB)
+	db.Init(nil)
+
+	// The first network to use an empty database gets its root
+	main, err := NetworkNamespace(db, constants.MAIN_NETWORK_ID)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if main != db {
+		t.Errorf("MAIN did not get the root of an empty database")
+	}
+	if test, err := NetworkNamespace(db, constants.TEST_NETWORK_ID); err != nil || test == db {
+		t.Errorf("TEST shares the root with MAIN: %v", err)
+	}
+}
+
+func TestNetworkNamespaceWrongBlocks(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, constants.MAIN_NETWORK_ID)
+	if err := dbo.DB.Put(NETWORK, []byte("NetworkID"), &primitives.ByteSlice{Bytes: data}); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if _, err := NetworkNamespace(dbo.DB, constants.MAIN_NETWORK_ID); err == nil {
+		t.Errorf("MAIN was given a root of LOCAL blocks")
+	}
+}
+
+func TestIsEntryBucketZeroChainID(t *testing.T) {
+	// A chain ID may start with the byte that marks a namespaced bucket
+	chainID := make([]byte, constants.HASH_LENGTH)
+	chainID[1] = 0x12
+	if !IsEntryBucket(chainID) {
+		t.Errorf("The bucket of chain %x is not an entry bucket", chainID)
+	}
+
+	db := new(mapdb.MapDB)
+	db.Init(nil)
+	if _, err := NetworkNamespace(db, constants.MAIN_NETWORK_ID); err != nil {
+		t.Fatalf("%v", err)
+	}
+	local, err := NetworkNamespace(db, constants.LOCAL_NETWORK_ID)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := local.Put(chainID, []byte("key"), &primitives.ByteSlice{Bytes: []byte("entry")}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := local.Put(DIRECTORYBLOCK, []byte("key"), &primitives.ByteSlice{Bytes: []byte("block")}); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	all, _ := db.ListAllBuckets()
+	for _, b := range all {
+		entries := len(b) > len(chainID) && b[len(b)-len(chainID)] == 0 && b[len(b)-len(chainID)+1] == 0x12
+		if IsEntryBucket(b) != entries {
+			t.Errorf("IsEntryBucket(%x) is %v", b, !entries)
+		}
+	}
+}
@@ -9,8 +9,10 @@
 package databaseOverlay
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"sync"
 
 	"github.com/FactomProject/factomd/common/constants"
@@ -66,6 +68,40 @@ var (
 
 	//Which EC transaction paid for this Entry
 	PAID_FOR = []byte("PaidFor")
+
+	//Which entry block, chain and directory block height an Entry is in
+	ENTRY_LOCATION = []byte("EntryLocation")
+
+	//Which factoid block, offset in it and directory block height a factoid transaction is in
+	TRANSACTION_LOCATION = []byte("TransactionLocation")
+
+	//The change to the balance of an address in each block that changed it
+	FACTOID_BALANCE_HISTORY = []byte("FactoidBalanceHistory")
+	EC_BALANCE_HISTORY      = []byte("ECBalanceHistory")
+
+	//The entry count, size and first and last heights of each chain
+	CHAIN_STATS = []byte("ChainStats")
+
+	//The commits awaiting their reveals, by entry hash
+	PENDING_COMMITS = []byte("PendingCommits")
+
+	//What the blocks of each height changed in the state, by height
+	STATE_DIFF = []byte("StateDiff")
+
+	//The balances of every address every StateSnapshotInterval heights
+	STATE_SNAPSHOT = []byte("StateSnapshot")
+
+	//What the anchor chain records of each directory block, by height
+	ANCHOR_INFO = []byte("AnchorInfo")
+
+	//The ID of the network the unprefixed buckets hold the blockchain of
+	NETWORK = []byte("Network")
+
+	//The snapshot of the block the database starts at, if not the genesis block
+	CHECKPOINT = []byte("Checkpoint")
+
+	//The version of the layout of the database
+	SCHEMA = []byte("Schema")
 )
 
 var ConstantNamesMap map[string]string
@@ -104,6 +140,19 @@ func init() {
 	ConstantNamesMap[string(INCLUDED_IN)] = "IncludedIn"
 
 	ConstantNamesMap[string(PAID_FOR)] = "PaidFor"
+
+	ConstantNamesMap[string(ENTRY_LOCATION)] = "EntryLocation"
+	ConstantNamesMap[string(TRANSACTION_LOCATION)] = "TransactionLocation"
+	ConstantNamesMap[string(FACTOID_BALANCE_HISTORY)] = "FactoidBalanceHistory"
+	ConstantNamesMap[string(EC_BALANCE_HISTORY)] = "ECBalanceHistory"
+	ConstantNamesMap[string(CHAIN_STATS)] = "ChainStats"
+	ConstantNamesMap[string(PENDING_COMMITS)] = "PendingCommits"
+	ConstantNamesMap[string(STATE_DIFF)] = "StateDiff"
+	ConstantNamesMap[string(STATE_SNAPSHOT)] = "StateSnapshot"
+	ConstantNamesMap[string(ANCHOR_INFO)] = "AnchorInfo"
+	ConstantNamesMap[string(NETWORK)] = "Network"
+	ConstantNamesMap[string(CHECKPOINT)] = "Checkpoint"
+	ConstantNamesMap[string(SCHEMA)] = "Schema"
 }
 
 type Overlay struct {
@@ -139,6 +188,15 @@ func (db *Overlay) PutInMultiBatch(records []interfaces.Record) {
 	db.MultiBatch = append(db.MultiBatch, records...)
 }
 
+// CancelMultiBatch drops the records of the current multi batch without
+// writing any of them, and releases the batch.
+func (db *Overlay) CancelMultiBatch() {
+	db.MultiBatch = nil
+	db.BatchSemaphore.Unlock()
+}
+
+// ExecuteMultiBatch writes every record of the current multi batch in one
+// atomic PutInBatch, so a block and all its indexes are saved, or not at all.
 func (db *Overlay) ExecuteMultiBatch() error {
 	defer func() {
 		db.MultiBatch = nil
@@ -159,6 +217,10 @@ func (db *Overlay) ListAllKeys(bucket []byte) ([][]byte, error) {
 	return db.DB.ListAllKeys(bucket)
 }
 
+func (db *Overlay) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	return db.DB.ForEachKey(bucket, start, limit, fn)
+}
+
 func (db *Overlay) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
 	return db.DB.GetAll(bucket, sample)
 }
@@ -182,6 +244,28 @@ func (db *Overlay) Trim() {
 	db.DB.Trim()
 }
 
+func (db *Overlay) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	return db.DB.BucketStats(bucket)
+}
+
+// Compact the underlying database.  Batches wait for the compaction to finish.
+func (db *Overlay) Compact() error {
+	db.BatchSemaphore.Lock()
+	defer db.BatchSemaphore.Unlock()
+	return db.DB.Compact()
+}
+
+// SetBulkLoad waits for the current batch, so the flush includes all of it.
+func (db *Overlay) SetBulkLoad(bulk bool) error {
+	db.BatchSemaphore.Lock()
+	defer db.BatchSemaphore.Unlock()
+	return db.DB.SetBulkLoad(bulk)
+}
+
+func (db *Overlay) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	return db.DB.GetMulti(bucket, keys, sample)
+}
+
 func (db *Overlay) Delete(bucket, key []byte) error {
 	return db.DB.Delete(bucket, key)
 }
@@ -300,7 +384,13 @@ func (db *Overlay) ProcessBlockMultiBatch(blockBucket, numberBucket, secondaryIn
 		batch = append(batch, interfaces.Record{secondaryIndexBucket, block.DatabaseSecondaryIndex().Bytes(), block.DatabasePrimaryIndex()})
 	}
 
-	batch = append(batch, interfaces.Record{CHAIN_HEAD, block.GetChainID().Bytes(), block.DatabasePrimaryIndex()})
+	newer, err := db.hasNewerBlock(numberBucket, block.GetDatabaseHeight())
+	if err != nil {
+		return err
+	}
+	if !newer {
+		batch = append(batch, interfaces.Record{CHAIN_HEAD, block.GetChainID().Bytes(), block.DatabasePrimaryIndex()})
+	}
 
 	db.PutInMultiBatch(batch)
 
@@ -332,9 +422,15 @@ func (db *Overlay) ProcessBlockBatch(blockBucket, numberBucket, secondaryIndexBu
 		batch = append(batch, interfaces.Record{secondaryIndexBucket, block.DatabaseSecondaryIndex().Bytes(), block.DatabasePrimaryIndex()})
 	}
 
-	batch = append(batch, interfaces.Record{CHAIN_HEAD, block.GetChainID().Bytes(), block.DatabasePrimaryIndex()})
+	newer, err := db.hasNewerBlock(numberBucket, block.GetDatabaseHeight())
+	if err != nil {
+		return err
+	}
+	if !newer {
+		batch = append(batch, interfaces.Record{CHAIN_HEAD, block.GetChainID().Bytes(), block.DatabasePrimaryIndex()})
+	}
 
-	err := db.PutInBatch(batch)
+	err = db.PutInBatch(batch)
 	if err != nil {
 		return err
 	}
@@ -398,6 +494,41 @@ func (db *Overlay) ProcessBlockMultiBatchWithoutHead(blockBucket, numberBucket,
 	return nil
 }
 
+// hasNewerBlock returns true if a block higher than height is already indexed
+// in numberBucket, in the database or in the pending multi batch.  Saving an
+// older block, as happens while syncing, must not move the chain head back.
+func (db *Overlay) hasNewerBlock(numberBucket []byte, height uint32) (bool, error) {
+	if numberBucket == nil || height == math.MaxUint32 {
+		return false, nil
+	}
+	start := make([]byte, 4)
+	binary.BigEndian.PutUint32(start, height+1)
+
+	for _, r := range db.MultiBatch {
+		if bytes.Equal(r.Bucket, numberBucket) && bytes.Compare(r.Key, start) >= 0 {
+			return true, nil
+		}
+	}
+
+	found := fmt.Errorf("found")
+	err := db.DB.ForEachKey(numberBucket, start, nil, func(key []byte) error {
+		return found
+	})
+	if err == found {
+		return true, nil
+	}
+	return false, err
+}
+
+// DoesChainExist returns true if the chain has a head, without loading the
+// head block.
+func (db *Overlay) DoesChainExist(chainID interfaces.IHash) (bool, error) {
+	if chainID == nil {
+		return false, nil
+	}
+	return db.DoesKeyExist(CHAIN_HEAD, chainID.Bytes())
+}
+
 // FetchHeadMRByChainID gets an index of the highest block from the database.
 func (db *Overlay) FetchHeadIndexByChainID(chainID interfaces.IHash) (interfaces.IHash, error) {
 	if chainID == nil {
@@ -430,6 +561,8 @@ func (db *Overlay) FetchChainHeadByChainID(bucket []byte, chainID interfaces.IHa
 }
 
 //Use endHeight of -1 (or other negative numbers) to fetch all / as many entries as possibe
+//The indexes are read with one ranged scan of numberBucket, and the result
+//stops at the first missing height.
 func (db *Overlay) FetchBlockIndexesInHeightRange(numberBucket []byte, startHeight, endHeight int64) ([]interfaces.IHash, error) {
 	var endidx int64
 	if endHeight < 0 {
@@ -437,10 +570,37 @@ func (db *Overlay) FetchBlockIndexesInHeightRange(numberBucket []byte, startHeig
 	} else {
 		endidx = endHeight
 	}
+	if startHeight < 0 || startHeight > math.MaxUint32 || endidx <= startHeight {
+		return []interfaces.IHash{}, nil
+	}
+
+	start := make([]byte, 4)
+	binary.BigEndian.PutUint32(start, uint32(startHeight))
+	var limit []byte
+	if endidx <= math.MaxUint32 {
+		limit = make([]byte, 4)
+		binary.BigEndian.PutUint32(limit, uint32(endidx))
+	}
+
+	// Collect the heights first, the database can't be read from inside ForEachKey
+	heights := []uint32{}
+	next := uint32(startHeight)
+	gap := fmt.Errorf("gap")
+	err := db.DB.ForEachKey(numberBucket, start, limit, func(key []byte) error {
+		if len(key) != 4 || binary.BigEndian.Uint32(key) != next {
+			return gap
+		}
+		heights = append(heights, next)
+		next++
+		return nil
+	})
+	if err != nil && err != gap {
+		return nil, err
+	}
 
-	shalist := make([]interfaces.IHash, 0, endidx-startHeight)
-	for height := startHeight; height < endidx; height++ {
-		dbhash, err := db.FetchBlockIndexByHeight(numberBucket, uint32(height))
+	shalist := make([]interfaces.IHash, 0, len(heights))
+	for _, height := range heights {
+		dbhash, err := db.FetchBlockIndexByHeight(numberBucket, height)
 		if err != nil {
 			return nil, err
 		}
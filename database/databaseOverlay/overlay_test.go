@@ -259,6 +259,49 @@ func TestMultiBatch(t *testing.T) {
 	}
 }
 
+func TestMultiBatchDeleteAndCancel(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+
+	key := []byte("key")
+	err := dbo.Put(TestBucket, key, primitives.NewZeroHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is written until the batch is executed, and a cancelled batch
+	// writes nothing at all
+	dbo.StartMultiBatch()
+	dbo.PutInMultiBatch([]interfaces.Record{{Bucket: TestBucket, Key: key}, {Bucket: TestBucket, Key: []byte("other"), Data: primitives.NewZeroHash()}})
+	exists, _ := dbo.DoesKeyExist(TestBucket, key)
+	if exists == false {
+		t.Errorf("Key was deleted before the batch was executed")
+	}
+	dbo.CancelMultiBatch()
+
+	exists, _ = dbo.DoesKeyExist(TestBucket, key)
+	if exists == false {
+		t.Errorf("Key was deleted by a cancelled batch")
+	}
+	exists, _ = dbo.DoesKeyExist(TestBucket, []byte("other"))
+	if exists == true {
+		t.Errorf("Key was written by a cancelled batch")
+	}
+
+	dbo.StartMultiBatch()
+	dbo.PutInMultiBatch([]interfaces.Record{{Bucket: TestBucket, Key: key}, {Bucket: TestBucket, Key: []byte("other"), Data: primitives.NewZeroHash()}})
+	if err = dbo.ExecuteMultiBatch(); err != nil {
+		t.Error(err)
+	}
+	exists, _ = dbo.DoesKeyExist(TestBucket, key)
+	if exists == true {
+		t.Errorf("Key was not deleted by the batch")
+	}
+	exists, _ = dbo.DoesKeyExist(TestBucket, []byte("other"))
+	if exists == false {
+		t.Errorf("Key was not written by the batch")
+	}
+}
+
 func TestInsertFetch(t *testing.T) {
 	dbo := createOverlay()
 	defer dbo.Close()
@@ -405,6 +448,23 @@ func TestFetchBy(t *testing.T) {
 			t.Error("Index from batch is not equal")
 		}
 	}
+
+	fetchedIndexes, err = dbo.FetchBlockIndexesInHeightRange(TestNumberBucket, int64(startIndex), -1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(fetchedIndexes) != max-startIndex {
+		t.Errorf("Fetched %d indexes to the end, expected %d", len(fetchedIndexes), max-startIndex)
+	}
+	for _, r := range [][2]int64{{5, 5}, {5, 2}, {-3, 2}, {int64(max), -1}} {
+		fetchedIndexes, err = dbo.FetchBlockIndexesInHeightRange(TestNumberBucket, r[0], r[1])
+		if err != nil {
+			t.Error(err)
+		}
+		if len(fetchedIndexes) != 0 {
+			t.Errorf("Range %v returned %d indexes, expected none", r, len(fetchedIndexes))
+		}
+	}
 }
 
 func createOverlay() *Overlay {
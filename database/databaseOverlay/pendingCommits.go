@@ -0,0 +1,48 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// SavePendingCommits writes the commits awaiting their reveals, marshalled and
+// by entry hash, in one batch.  A nil commit deletes the one saved for the
+// hash.
+func (db *Overlay) SavePendingCommits(commits map[[32]byte][]byte) error {
+	batch := []interfaces.Record{}
+	for hash, data := range commits {
+		key := hash
+		record := interfaces.Record{Bucket: PENDING_COMMITS, Key: key[:]}
+		if data != nil {
+			record.Data = &primitives.ByteSlice{Bytes: data}
+		}
+		batch = append(batch, record)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return db.DB.PutInBatch(batch)
+}
+
+// FetchPendingCommits returns every commit saved as awaiting its reveal,
+// marshalled and by entry hash.
+func (db *Overlay) FetchPendingCommits() (map[[32]byte][]byte, error) {
+	values, keys, err := db.DB.GetAll(PENDING_COMMITS, new(primitives.ByteSlice))
+	if err != nil {
+		return nil, err
+	}
+	commits := map[[32]byte][]byte{}
+	for i, v := range values {
+		if len(keys[i]) != 32 {
+			continue
+		}
+		var hash [32]byte
+		copy(hash[:], keys[i])
+		commits[hash] = v.(*primitives.ByteSlice).Bytes
+	}
+	return commits, nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// indexBuckets are the buckets Reindex drops and rebuilds.  Everything else is
+// either block or entry data, which the indexes are rebuilt from, or is not
+// derived from the blocks (anchors, the network ID).
+var indexBuckets = [][]byte{
+	DIRECTORYBLOCK_NUMBER, DIRECTORYBLOCK_SECONDARYINDEX,
+	ADMINBLOCK_NUMBER, ADMINBLOCK_SECONDARYINDEX,
+	FACTOIDBLOCK_NUMBER, FACTOIDBLOCK_SECONDARYINDEX,
+	ENTRYCREDITBLOCK_NUMBER, ENTRYCREDITBLOCK_SECONDARYINDEX,
+	ENTRYBLOCK_SECONDARYINDEX,
+	CHAIN_HEAD,
+	ENTRY,
+	INCLUDED_IN,
+	PAID_FOR,
+	ENTRY_LOCATION,
+	TRANSACTION_LOCATION,
+	FACTOID_BALANCE_HISTORY, EC_BALANCE_HISTORY,
+	STATE_DIFF, STATE_SNAPSHOT,
+	CHAIN_STATS,
+}
+
+// Reindex drops every index of the database and rebuilds it from the blocks
+// and entries saved in it.  The directory blocks are found by scanning the
+// directory block bucket, and replayed from height 0 up to the first missing
+// height.  Each height is saved in its own batch, as the node would have saved
+// it.  progress, if not nil, is called after each height.  It returns the
+// number of directory blocks replayed.
+func (db *Overlay) Reindex(progress func(height uint32, top uint32)) (int, error) {
+	keyMRs, err := db.scanDBlockHeights()
+	if err != nil {
+		return 0, err
+	}
+	if len(keyMRs) == 0 {
+		return 0, fmt.Errorf("The database has no directory blocks to reindex from")
+	}
+	top := uint32(len(keyMRs) - 1)
+
+	// The entry block number bucket of each chain has to be found before the
+	// chain heads are dropped
+	buckets, err := copyBuckets(db.DB)
+	if err != nil {
+		return 0, err
+	}
+	drop := append([][]byte{}, indexBuckets...)
+	for _, bucket := range buckets {
+		if len(bucket) > len(ENTRYBLOCK_CHAIN_NUMBER) && bytes.HasPrefix(bucket, ENTRYBLOCK_CHAIN_NUMBER) {
+			drop = append(drop, bucket)
+		}
+	}
+	for _, bucket := range drop {
+		if err = db.DB.Clear(bucket); err != nil {
+			return 0, err
+		}
+	}
+
+	for height, keyMR := range keyMRs {
+		if err = db.reindexDBlock(keyMR); err != nil {
+			return height, fmt.Errorf("DBlock %d (keyMR %v): %v", height, keyMR, err)
+		}
+		if progress != nil {
+			progress(uint32(height), top)
+		}
+	}
+	return len(keyMRs), nil
+}
+
+// scanDBlockHeights reads every directory block in the database and returns
+// their keyMRs by height, from 0 up to the first missing height.
+func (db *Overlay) scanDBlockHeights() ([]interfaces.IHash, error) {
+	keys, err := db.DB.ListAllKeys(DIRECTORYBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	heights := map[uint32]interfaces.IHash{}
+	for _, key := range keys {
+		block, err := db.DB.Get(DIRECTORYBLOCK, key, new(directoryBlock.DirectoryBlock))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			continue
+		}
+		dblock := block.(interfaces.IDirectoryBlock)
+		height := dblock.GetDatabaseHeight()
+		if other, ok := heights[height]; ok {
+			return nil, fmt.Errorf("DBlocks %v and %v are both at height %d", other, dblock.DatabasePrimaryIndex(), height)
+		}
+		heights[height] = dblock.DatabasePrimaryIndex()
+	}
+
+	list := []uint32{}
+	for height := range heights {
+		list = append(list, height)
+	}
+	sort.Sort(heightList(list))
+
+	keyMRs := []interfaces.IHash{}
+	for i, height := range list {
+		if height != uint32(i) {
+			break
+		}
+		keyMRs = append(keyMRs, heights[height])
+	}
+	return keyMRs, nil
+}
+
+type heightList []uint32
+
+func (h heightList) Len() int           { return len(h) }
+func (h heightList) Less(i, j int) bool { return h[i] < h[j] }
+func (h heightList) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+// reindexDBlock saves the indexes of a directory block, and of every block and
+// entry it points to, in one batch.
+func (db *Overlay) reindexDBlock(keyMR interfaces.IHash) error {
+	dblock, err := db.FetchDBlockByPrimary(keyMR)
+	if err != nil {
+		return err
+	}
+	if dblock == nil {
+		return fmt.Errorf("Not found")
+	}
+
+	db.StartMultiBatch()
+	for _, entry := range dblock.GetDBEntries() {
+		err = db.reindexChildBlock(entry)
+		if err != nil {
+			db.CancelMultiBatch()
+			return err
+		}
+	}
+	err = db.reindexStateDiff(dblock)
+	if err != nil {
+		db.CancelMultiBatch()
+		return err
+	}
+	err = db.ProcessDBlockMultiBatch(dblock)
+	if err != nil {
+		db.CancelMultiBatch()
+		return err
+	}
+	return db.ExecuteMultiBatch()
+}
+
+// reindexStateDiff adds the state diff of the admin, factoid and entry
+// credit blocks a directory block points to, and the balance changes in it,
+// to the current batch.  The blocks are fetched by keyMR, as their height
+// indexes are in the batch too.
+func (db *Overlay) reindexStateDiff(dblock interfaces.IDirectoryBlock) error {
+	var ablock interfaces.IAdminBlock
+	var fblock interfaces.IFBlock
+	var ecblock interfaces.IEntryCreditBlock
+	var err error
+	for _, entry := range dblock.GetDBEntries() {
+		chainID := entry.GetChainID().Bytes()
+		switch {
+		case bytes.Equal(chainID, constants.ADMIN_CHAINID):
+			ablock, err = db.FetchABlockByPrimary(entry.GetKeyMR())
+		case bytes.Equal(chainID, constants.EC_CHAINID):
+			ecblock, err = db.FetchECBlockByPrimary(entry.GetKeyMR())
+		case bytes.Equal(chainID, constants.FACTOID_CHAINID):
+			fblock, err = db.FetchFBlockByPrimary(entry.GetKeyMR())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return db.SaveStateDiffMultiBatch(ablock, fblock, ecblock)
+}
+
+// reindexChildBlock adds the indexes of a block a directory block points to to
+// the current batch.  The caller must have started the batch.
+func (db *Overlay) reindexChildBlock(entry interfaces.IDBEntry) error {
+	chainID := entry.GetChainID().Bytes()
+	switch {
+	case bytes.Equal(chainID, constants.ADMIN_CHAINID):
+		block, err := db.FetchABlockByPrimary(entry.GetKeyMR())
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("ABlock %v not found", entry.GetKeyMR())
+		}
+		return db.ProcessABlockMultiBatch(block)
+	case bytes.Equal(chainID, constants.EC_CHAINID):
+		block, err := db.FetchECBlockByPrimary(entry.GetKeyMR())
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("ECBlock %v not found", entry.GetKeyMR())
+		}
+		return db.ProcessECBlockMultiBatch(block, false)
+	case bytes.Equal(chainID, constants.FACTOID_CHAINID):
+		block, err := db.FetchFBlockByPrimary(entry.GetKeyMR())
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("FBlock %v not found", entry.GetKeyMR())
+		}
+		return db.ProcessFBlockMultiBatch(block)
+	}
+
+	block, err := db.FetchEBlockByPrimary(entry.GetKeyMR())
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return fmt.Errorf("EBlock %v not found", entry.GetKeyMR())
+	}
+	err = db.ProcessEBlockMultiBatch(block, true)
+	if err != nil {
+		return err
+	}
+
+	// Entries are saved in the bucket of their chain, and indexed by hash in
+	// ENTRY.  Entries the node never got are left out, as they were before.
+	batch := []interfaces.Record{}
+	for _, hash := range block.GetEntryHashes() {
+		if hash.IsMinuteMarker() {
+			continue
+		}
+		exists, err := db.DB.DoesKeyExist(block.GetChainID().Bytes(), hash.Bytes())
+		if err != nil {
+			return err
+		}
+		if exists {
+			batch = append(batch, interfaces.Record{Bucket: ENTRY, Key: hash.Bytes(), Data: block.GetChainID()})
+		}
+	}
+	db.PutInMultiBatch(batch)
+	return db.SaveChainStatsMultiBatch(block, nil)
+}
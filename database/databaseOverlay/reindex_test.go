@@ -0,0 +1,85 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestReindex(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+
+	head, err := dbo.FetchDBlockHead()
+	if err != nil || head == nil {
+		t.Fatalf("No DBlock head: %v", err)
+	}
+	top := head.GetDatabaseHeight()
+	keyMRs, err := dbo.FetchDBlockHeightRange(0, -1)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	chains, err := dbo.FetchAllEBlockChainIDs()
+	if err != nil || len(chains) == 0 {
+		t.Fatalf("No chains: %v", err)
+	}
+	heads := map[[32]byte]string{}
+	for _, chain := range chains {
+		eblock, err := dbo.FetchEBlockHead(chain)
+		if err != nil || eblock == nil {
+			t.Fatalf("No head for chain %v: %v", chain, err)
+		}
+		heads[chain.Fixed()] = eblock.DatabasePrimaryIndex().String()
+	}
+	entries, err := dbo.FetchAllEntriesByChainID(chains[0])
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("No entries: %v", err)
+	}
+
+	// Break the indexes
+	dbo.Clear(DIRECTORYBLOCK_NUMBER)
+	dbo.Clear(INCLUDED_IN)
+	dbo.Clear(ENTRY_LOCATION)
+	dbo.Put(CHAIN_HEAD, chains[0].Bytes(), primitives.RandomHash())
+	dbo.Delete(ENTRY, entries[0].GetHash().Bytes())
+
+	n, err := dbo.Reindex(nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if n != int(top)+1 {
+		t.Errorf("Reindexed %d blocks, expected %d", n, top+1)
+	}
+
+	for height, keyMR := range keyMRs {
+		got, err := dbo.FetchDBKeyMRByHeight(uint32(height))
+		if err != nil || got == nil || !got.IsSameAs(keyMR) {
+			t.Errorf("KeyMR at height %d is %v, expected %v (%v)", height, got, keyMR, err)
+		}
+	}
+	for _, chain := range chains {
+		eblock, err := dbo.FetchEBlockHead(chain)
+		if err != nil || eblock == nil || eblock.DatabasePrimaryIndex().String() != heads[chain.Fixed()] {
+			t.Errorf("Head of chain %v is %v, expected %v (%v)", chain, eblock, heads[chain.Fixed()], err)
+		}
+	}
+	for _, entry := range entries {
+		got, err := dbo.FetchEntry(entry.GetHash())
+		if err != nil || got == nil {
+			t.Errorf("Entry %v not found after reindex: %v", entry.GetHash(), err)
+		}
+		in, err := dbo.FetchIncludedIn(entry.GetHash())
+		if err != nil || in == nil {
+			t.Errorf("Entry %v not included in a block after reindex: %v", entry.GetHash(), err)
+		}
+		eBlockKeyMR, _, _, err := dbo.FetchEntryLocation(entry.GetHash())
+		if err != nil || eBlockKeyMR == nil {
+			t.Errorf("Entry %v has no location after reindex: %v", entry.GetHash(), err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/log"
+)
+
+var dbLog = log.ForPackage(log.PackageDB)
+
+var schemaVersionKey = []byte("Version")
+
+// SchemaMigration brings a database from one version of the layout to the
+// next.
+type SchemaMigration struct {
+	Description string
+	Migrate     func(db *Overlay) error
+}
+
+// SchemaMigrations are the changes made to the layout of the database, in
+// order.  Migration i takes a database from version i to version i+1, so the
+// version of this binary is the number of migrations.  A change to the layout
+// is shipped by adding a migration to the end; migrations already shipped must
+// never change.
+var SchemaMigrations = []SchemaMigration{
+	{
+		Description: "Start versioning the database layout",
+		Migrate:     func(db *Overlay) error { return nil },
+	},
+	{
+		Description: "Index factoid transactions by transaction ID",
+		Migrate:     func(db *Overlay) error { return db.indexTransactionLocations() },
+	},
+	{
+		Description: "Record the balance changes of each address by height",
+		Migrate:     func(db *Overlay) error { return db.indexBalanceHistory() },
+	},
+	{
+		Description: "Index the anchor records of the anchor chain by height",
+		Migrate:     func(db *Overlay) error { return db.indexAnchorInfo() },
+	},
+	{
+		Description: "Record the entry count and size of each chain",
+		Migrate:     func(db *Overlay) error { return db.indexChainStats() },
+	},
+	{
+		// The diffs of the blocks already saved take a while, so they are
+		// recorded in the background, by IndexStateDiffs
+		Description: "Record what the blocks of each height changed in the state",
+		Migrate:     func(db *Overlay) error { return nil },
+	},
+}
+
+// SchemaVersion is the version of the database layout this binary writes.
+func SchemaVersion() uint32 {
+	return uint32(len(SchemaMigrations))
+}
+
+// CheckSchema runs SchemaMigrations on the database.
+func (db *Overlay) CheckSchema() error {
+	return db.MigrateSchema(SchemaMigrations)
+}
+
+// MigrateSchema brings the database up to the last of the given migrations.
+// A new database is stamped with the last version as is.  A database without
+// a version, but with blocks, is from before versioning, and is at version 0.
+// The version is saved after each migration, so an interrupted migration is
+// picked up where it stopped.  A database with a version past the last
+// migration was written by a newer binary, and is refused.
+func (db *Overlay) MigrateSchema(migrations []SchemaMigration) error {
+	latest := uint32(len(migrations))
+
+	version, found, err := db.schemaVersion()
+	if err != nil {
+		return err
+	}
+	if !found {
+		head, err := db.FetchDBlockHead()
+		if err != nil {
+			return err
+		}
+		if head == nil {
+			return db.saveSchemaVersion(latest)
+		}
+	}
+	if version > latest {
+		return fmt.Errorf("The database layout is version %d, but this factomd only understands up to version %d", version, latest)
+	}
+
+	for ; version < latest; version++ {
+		m := migrations[version]
+		dbLog.Noticef("Migrating the database to version %d: %s", version+1, m.Description)
+		err = m.Migrate(db)
+		if err != nil {
+			return fmt.Errorf("Migrating the database from version %d (%s): %v", version, m.Description, err)
+		}
+		err = db.saveSchemaVersion(version + 1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the version saved in the database, and whether there
+// is one.
+func (db *Overlay) schemaVersion() (uint32, bool, error) {
+	stored, err := db.DB.Get(SCHEMA, schemaVersionKey, new(primitives.ByteSlice))
+	if err != nil {
+		return 0, false, err
+	}
+	if stored == nil {
+		return 0, false, nil
+	}
+	data := stored.(*primitives.ByteSlice).Bytes
+	if len(data) != 4 {
+		return 0, false, fmt.Errorf("The schema version in the database is %d bytes, expected 4", len(data))
+	}
+	return binary.BigEndian.Uint32(data), true, nil
+}
+
+func (db *Overlay) saveSchemaVersion(version uint32) error {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, version)
+	return db.DB.Put(SCHEMA, schemaVersionKey, &primitives.ByteSlice{Bytes: data})
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestMigrateSchema(t *testing.T) {
+	ran := []int{}
+	migration := func(i int) SchemaMigration {
+		return SchemaMigration{
+			Description: fmt.Sprintf("Migration %d", i),
+			Migrate: func(db *Overlay) error {
+				ran = append(ran, i)
+				return nil
+			},
+		}
+	}
+	migrations := []SchemaMigration{migration(0), migration(1)}
+
+	// A new database is stamped without migrating
+	dbo := createOverlay()
+	if err := dbo.MigrateSchema(migrations); err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("Ran migrations %v on a new database", ran)
+	}
+
+	// A database from before versioning runs every migration, once
+	ran = nil
+	dbo = testHelper.CreateAndPopulateTestDatabaseOverlay()
+	if err := dbo.MigrateSchema(migrations); err != nil {
+		t.Errorf("%v", err)
+	}
+	if err := dbo.MigrateSchema(migrations); err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(ran) != 2 || ran[0] != 0 || ran[1] != 1 {
+		t.Errorf("Ran migrations %v, expected [0 1]", ran)
+	}
+
+	// A new migration runs alone
+	ran = nil
+	migrations = append(migrations, migration(2))
+	if err := dbo.MigrateSchema(migrations); err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(ran) != 1 || ran[0] != 2 {
+		t.Errorf("Ran migrations %v, expected [2]", ran)
+	}
+
+	// A failed migration stops, and is run again next time
+	ran = nil
+	migrations = append(migrations, SchemaMigration{
+		Description: "Failing",
+		Migrate:     func(db *Overlay) error { return fmt.Errorf("failed") },
+	}, migration(4))
+	if err := dbo.MigrateSchema(migrations); err == nil {
+		t.Errorf("A failed migration returned no error")
+	}
+	if len(ran) != 0 {
+		t.Errorf("Ran migrations %v after a failed one", ran)
+	}
+
+	// A binary older than the database refuses it
+	if err := dbo.MigrateSchema(migrations[:2]); err == nil {
+		t.Errorf("A database newer than the migrations was accepted")
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// A snapshot file is the SnapshotMagic, a uint32 version, and then one record
+// per key: the bucket, key and value, each as a uint32 length and its bytes.
+// The records end with a bucket length of snapshotEnd, followed by the uint64
+// number of records and the SHA256 of everything before the checksum.  All
+// numbers are big-endian.
+var SnapshotMagic = []byte("FCTDBSNP")
+
+// SnapshotVersion is the version of the snapshot format written by
+// ExportDatabase.
+const SnapshotVersion uint32 = 1
+
+// snapshotEnd marks the end of the records in a snapshot.
+const snapshotEnd uint32 = 0xFFFFFFFF
+
+// maxSnapshotField is the largest bucket, key or value a snapshot may hold, so
+// a corrupt length can't make ReadSnapshot allocate without bound.
+const maxSnapshotField = 1 << 30
+
+// snapshotWriter writes to a buffered file while hashing what it writes.
+type snapshotWriter struct {
+	w    *bufio.Writer
+	hash hash.Hash
+	err  error
+}
+
+func (s *snapshotWriter) write(data []byte) {
+	if s.err != nil {
+		return
+	}
+	s.hash.Write(data)
+	_, s.err = s.w.Write(data)
+}
+
+func (s *snapshotWriter) writeUint32(n uint32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	s.write(b)
+}
+
+func (s *snapshotWriter) writeField(data []byte) {
+	s.writeUint32(uint32(len(data)))
+	s.write(data)
+}
+
+// ExportDatabase writes every key of every bucket in db to w as a snapshot,
+// and returns the number of keys written.  progress may be nil.
+func ExportDatabase(db interfaces.IDatabase, w io.Writer, progress CopyProgress) (int, error) {
+	buckets, err := copyBuckets(db)
+	if err != nil {
+		return 0, err
+	}
+
+	s := &snapshotWriter{w: bufio.NewWriter(w), hash: sha256.New()}
+	s.write(SnapshotMagic)
+	s.writeUint32(SnapshotVersion)
+
+	total := 0
+	for _, bucket := range buckets {
+		keys, err := db.ListAllKeys(bucket)
+		if err != nil {
+			return total, err
+		}
+
+		for i, key := range keys {
+			data, err := db.Get(bucket, key, new(primitives.ByteSlice))
+			if err != nil {
+				return total, err
+			}
+			if data == nil {
+				return total, fmt.Errorf("Key %x in bucket %x disappeared while exporting", key, bucket)
+			}
+			s.writeField(bucket)
+			s.writeField(key)
+			s.writeField(data.(*primitives.ByteSlice).Bytes)
+			if s.err != nil {
+				return total, s.err
+			}
+			total++
+
+			if progress != nil && ((i+1)%CopyBatchSize == 0 || i == len(keys)-1) {
+				progress(bucket, i+1, i == len(keys)-1)
+			}
+		}
+	}
+
+	s.writeUint32(snapshotEnd)
+	count := make([]byte, 8)
+	binary.BigEndian.PutUint64(count, uint64(total))
+	s.write(count)
+	if s.err != nil {
+		return total, s.err
+	}
+	if _, err = s.w.Write(s.hash.Sum(nil)); err != nil {
+		return total, err
+	}
+	return total, s.w.Flush()
+}
+
+// snapshotReader reads from a buffered file while hashing what it reads.
+type snapshotReader struct {
+	r    *bufio.Reader
+	hash hash.Hash
+}
+
+func (s *snapshotReader) read(n int) ([]byte, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("Snapshot is truncated: %v", err)
+	}
+	s.hash.Write(data)
+	return data, nil
+}
+
+func (s *snapshotReader) readUint32() (uint32, error) {
+	b, err := s.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (s *snapshotReader) readField(n uint32) ([]byte, error) {
+	if n > maxSnapshotField {
+		return nil, fmt.Errorf("Snapshot field of %d bytes is too large", n)
+	}
+	return s.read(int(n))
+}
+
+// ReadSnapshot reads a snapshot from r, calling fn for every record, and
+// returns the number of records read.  The version, record count and checksum
+// are only known to be good once ReadSnapshot returns without error, so use
+// it with a nil fn to verify a snapshot before acting on its records.
+func ReadSnapshot(r io.Reader, fn func(bucket, key, value []byte) error) (int, error) {
+	s := &snapshotReader{r: bufio.NewReader(r), hash: sha256.New()}
+
+	magic, err := s.read(len(SnapshotMagic))
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(magic, SnapshotMagic) {
+		return 0, fmt.Errorf("Not a database snapshot")
+	}
+	version, err := s.readUint32()
+	if err != nil {
+		return 0, err
+	}
+	if version != SnapshotVersion {
+		return 0, fmt.Errorf("Unsupported snapshot version %d, expected %d", version, SnapshotVersion)
+	}
+
+	total := 0
+	for {
+		n, err := s.readUint32()
+		if err != nil {
+			return total, err
+		}
+		if n == snapshotEnd {
+			break
+		}
+		bucket, err := s.readField(n)
+		if err != nil {
+			return total, err
+		}
+		if n, err = s.readUint32(); err != nil {
+			return total, err
+		}
+		key, err := s.readField(n)
+		if err != nil {
+			return total, err
+		}
+		if n, err = s.readUint32(); err != nil {
+			return total, err
+		}
+		value, err := s.readField(n)
+		if err != nil {
+			return total, err
+		}
+
+		if fn != nil {
+			if err = fn(bucket, key, value); err != nil {
+				return total, err
+			}
+		}
+		total++
+	}
+
+	count, err := s.read(8)
+	if err != nil {
+		return total, err
+	}
+	if binary.BigEndian.Uint64(count) != uint64(total) {
+		return total, fmt.Errorf("Snapshot holds %d records, but its trailer says %d", total, binary.BigEndian.Uint64(count))
+	}
+	sum := s.hash.Sum(nil)
+	checksum := make([]byte, len(sum))
+	if _, err = io.ReadFull(s.r, checksum); err != nil {
+		return total, fmt.Errorf("Snapshot is truncated: missing checksum")
+	}
+	if !bytes.Equal(sum, checksum) {
+		return total, fmt.Errorf("Snapshot checksum mismatch, the file is corrupt")
+	}
+	return total, nil
+}
+
+// ImportDatabase writes the records of the snapshot in r into db, in batches of
+// CopyBatchSize, and returns the number of keys written.  The snapshot should
+// be checked with ReadSnapshot first, as records are written before the
+// checksum is read.  progress may be nil.
+func ImportDatabase(r io.Reader, db interfaces.IDatabase, progress CopyProgress) (int, error) {
+	total := 0
+	batch := []interfaces.Record{}
+	var bucket []byte
+	keys := 0
+
+	flush := func(done bool) error {
+		if len(batch) > 0 {
+			if err := db.PutInBatch(batch); err != nil {
+				return err
+			}
+			total += len(batch)
+			batch = []interfaces.Record{}
+		}
+		if progress != nil && bucket != nil {
+			progress(bucket, keys, done)
+		}
+		return nil
+	}
+
+	_, err := ReadSnapshot(r, func(b, key, value []byte) error {
+		if bucket != nil && !bytes.Equal(b, bucket) {
+			if err := flush(true); err != nil {
+				return err
+			}
+			keys = 0
+		}
+		bucket = b
+		keys++
+		batch = append(batch, interfaces.Record{Bucket: b, Key: key, Data: &primitives.ByteSlice{Bytes: value}})
+		if len(batch) >= CopyBatchSize {
+			return flush(false)
+		}
+		return nil
+	})
+	if err != nil {
+		return total, err
+	}
+	return total, flush(true)
+}
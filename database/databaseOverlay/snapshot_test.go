@@ -0,0 +1,85 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+)
+
+func TestExportImportDatabase(t *testing.T) {
+	from := new(mapdb.MapDB)
+	from.Init(nil)
+
+	buckets := [][]byte{[]byte("one"), []byte("two")}
+	for _, bucket := range buckets {
+		for i := 0; i < 25; i++ {
+			err := from.Put(bucket, []byte{byte(i)}, primitives.RandomByteSlice())
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+
+	old := CopyBatchSize
+	CopyBatchSize = 10
+	defer func() { CopyBatchSize = old }()
+
+	buf := new(bytes.Buffer)
+	exported, err := ExportDatabase(from, buf, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if exported != 50 {
+		t.Errorf("Exported %v keys, expected 50", exported)
+	}
+	snapshot := buf.Bytes()
+
+	n, err := ReadSnapshot(bytes.NewReader(snapshot), nil)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if n != 50 {
+		t.Errorf("Read %v records, expected 50", n)
+	}
+
+	to := new(mapdb.MapDB)
+	to.Init(nil)
+	calls := 0
+	imported, err := ImportDatabase(bytes.NewReader(snapshot), to, func(bucket []byte, keys int, done bool) {
+		calls++
+	})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if imported != 50 {
+		t.Errorf("Imported %v keys, expected 50", imported)
+	}
+	if calls != 6 {
+		t.Errorf("Progress called %v times, expected 6", calls)
+	}
+	if _, err = VerifyCopy(from, to, nil); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	corrupt := append([]byte{}, snapshot...)
+	corrupt[len(SnapshotMagic)+20] ^= 0xFF
+	if _, err = ReadSnapshot(bytes.NewReader(corrupt), nil); err == nil {
+		t.Errorf("Corrupt snapshot was read without error")
+	}
+	if _, err = ReadSnapshot(bytes.NewReader(snapshot[:len(snapshot)-1]), nil); err == nil {
+		t.Errorf("Truncated snapshot was read without error")
+	}
+
+	version := append([]byte{}, snapshot...)
+	version[len(SnapshotMagic)+3]++
+	if _, err = ReadSnapshot(bytes.NewReader(version), nil); err == nil {
+		t.Errorf("Snapshot of an unknown version was read without error")
+	}
+}
@@ -0,0 +1,587 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// StateDiffRecord is the STATE_DIFF record of a height.
+type StateDiffRecord struct {
+	interfaces.StateDiff
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*StateDiffRecord)(nil)
+
+func (r *StateDiffRecord) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(StateDiffRecord)
+}
+
+func (r *StateDiffRecord) MarshalBinary() ([]byte, error) {
+	buf := primitives.NewBuffer(nil)
+	err := buf.PushUInt32(r.Height)
+	if err != nil {
+		return nil, err
+	}
+	for _, changes := range [][]interfaces.BalanceChange{r.FactoidDeltas, r.ECDeltas} {
+		err = buf.PushUInt32(uint32(len(changes)))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			err = buf.Push(c.Address[:])
+			if err != nil {
+				return nil, err
+			}
+			err = buf.PushInt64(c.Delta)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	err = buf.PushUInt32(uint32(len(r.Authority)))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range r.Authority {
+		err = buf.PushBytes(entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = buf.PushUInt64(r.ECRate)
+	if err != nil {
+		return nil, err
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (r *StateDiffRecord) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	buf := primitives.NewBuffer(data)
+	var err error
+	r.Height, err = buf.PopUInt32()
+	if err != nil {
+		return nil, err
+	}
+	for _, changes := range []*[]interfaces.BalanceChange{&r.FactoidDeltas, &r.ECDeltas} {
+		n, err := buf.PopUInt32()
+		if err != nil {
+			return nil, err
+		}
+		if int(n) > buf.Len()/40 {
+			return nil, fmt.Errorf("StateDiff of %d balance changes is longer than its data", n)
+		}
+		*changes = make([]interfaces.BalanceChange, n)
+		for i := range *changes {
+			c := &(*changes)[i]
+			err = buf.Pop(c.Address[:])
+			if err != nil {
+				return nil, err
+			}
+			c.Delta, err = buf.PopInt64()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	n, err := buf.PopUInt32()
+	if err != nil {
+		return nil, err
+	}
+	if int(n) > buf.Len() {
+		return nil, fmt.Errorf("StateDiff of %d authority changes is longer than its data", n)
+	}
+	r.Authority = make([][]byte, n)
+	for i := range r.Authority {
+		r.Authority[i], err = buf.PopBytes()
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.ECRate, err = buf.PopUInt64()
+	if err != nil {
+		return nil, err
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (r *StateDiffRecord) UnmarshalBinary(data []byte) error {
+	_, err := r.UnmarshalBinaryData(data)
+	return err
+}
+
+// StateSnapshotInterval is how many heights apart the STATE_SNAPSHOT records
+// are.  The balances at a height are rebuilt from the snapshot before it, and
+// at most that many diffs.
+var StateSnapshotInterval uint32 = 1000
+
+// StateSnapshot is the STATE_SNAPSHOT record of a height: the balance of
+// every address once the blocks of the height were applied, and the entry
+// credit rate then.  Addresses with no balance are left out.
+type StateSnapshot struct {
+	Height   uint32
+	Factoids map[[32]byte]int64
+	ECs      map[[32]byte]int64
+	ECRate   uint64
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*StateSnapshot)(nil)
+
+func (r *StateSnapshot) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(StateSnapshot)
+}
+
+func (r *StateSnapshot) MarshalBinary() ([]byte, error) {
+	buf := primitives.NewBuffer(nil)
+	err := buf.PushUInt32(r.Height)
+	if err != nil {
+		return nil, err
+	}
+	for _, balances := range []map[[32]byte]int64{r.Factoids, r.ECs} {
+		// The balances are written in the order of their addresses, so
+		// the same balances always marshal the same
+		changes := balanceChanges(balances)
+		err = buf.PushUInt32(uint32(len(changes)))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			err = buf.Push(c.Address[:])
+			if err != nil {
+				return nil, err
+			}
+			err = buf.PushInt64(c.Delta)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	err = buf.PushUInt64(r.ECRate)
+	if err != nil {
+		return nil, err
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (r *StateSnapshot) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	buf := primitives.NewBuffer(data)
+	var err error
+	r.Height, err = buf.PopUInt32()
+	if err != nil {
+		return nil, err
+	}
+	for _, balances := range []*map[[32]byte]int64{&r.Factoids, &r.ECs} {
+		n, err := buf.PopUInt32()
+		if err != nil {
+			return nil, err
+		}
+		if int(n) > buf.Len()/40 {
+			return nil, fmt.Errorf("StateSnapshot of %d balances is longer than its data", n)
+		}
+		*balances = make(map[[32]byte]int64, n)
+		for i := uint32(0); i < n; i++ {
+			var adr [32]byte
+			err = buf.Pop(adr[:])
+			if err != nil {
+				return nil, err
+			}
+			(*balances)[adr], err = buf.PopInt64()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	r.ECRate, err = buf.PopUInt64()
+	if err != nil {
+		return nil, err
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (r *StateSnapshot) UnmarshalBinary(data []byte) error {
+	_, err := r.UnmarshalBinaryData(data)
+	return err
+}
+
+// Apply adds the changes of the diff of the next height to the snapshot.
+func (r *StateSnapshot) Apply(diff *interfaces.StateDiff) {
+	if r.Factoids == nil {
+		r.Factoids = map[[32]byte]int64{}
+	}
+	if r.ECs == nil {
+		r.ECs = map[[32]byte]int64{}
+	}
+	r.Height = diff.Height
+	for _, c := range diff.FactoidDeltas {
+		r.Factoids[c.Address] += c.Delta
+	}
+	for _, c := range diff.ECDeltas {
+		r.ECs[c.Address] += c.Delta
+	}
+	if diff.ECRate != 0 {
+		r.ECRate = diff.ECRate
+	}
+}
+
+// authorityEntryTypes are the admin block entries that change the authority
+// set: the servers, their keys and their number.
+var authorityEntryTypes = map[byte]bool{
+	constants.TYPE_ADD_MATRYOSHKA:     true,
+	constants.TYPE_ADD_SERVER_COUNT:   true,
+	constants.TYPE_ADD_FED_SERVER:     true,
+	constants.TYPE_ADD_AUDIT_SERVER:   true,
+	constants.TYPE_REMOVE_FED_SERVER:  true,
+	constants.TYPE_ADD_FED_SERVER_KEY: true,
+	constants.TYPE_ADD_BTC_ANCHOR_KEY: true,
+}
+
+// NewStateDiff returns the changes the admin, factoid and entry credit blocks
+// of a height make to the state, or nil if there are no blocks.  The coinbase
+// payouts the admin block cancels are left out, as finding them takes the
+// blocks before; the diffs saved by the Overlay have them.
+func NewStateDiff(ablock interfaces.IAdminBlock, fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock) (*interfaces.StateDiff, error) {
+	return newStateDiff(ablock, fblock, ecblock, nil)
+}
+
+// newStateDiff is NewStateDiff, with the factoids of the cancelled coinbase
+// payouts taken off their addresses.
+func newStateDiff(ablock interfaces.IAdminBlock, fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock, cancelled map[[32]byte]int64) (*interfaces.StateDiff, error) {
+	diff := new(interfaces.StateDiff)
+	switch {
+	case fblock != nil:
+		diff.Height = fblock.GetDatabaseHeight()
+		diff.ECRate = fblock.GetExchRate()
+	case ecblock != nil:
+		diff.Height = ecblock.GetDatabaseHeight()
+	case ablock != nil:
+		diff.Height = ablock.GetDBHeight()
+	default:
+		return nil, nil
+	}
+
+	fct, ec := BalanceDeltas(fblock, ecblock)
+	for adr, amount := range cancelled {
+		fct[adr] -= amount
+	}
+	diff.FactoidDeltas = balanceChanges(fct)
+	diff.ECDeltas = balanceChanges(ec)
+
+	if ablock != nil {
+		for _, entry := range ablock.GetABEntries() {
+			if !authorityEntryTypes[entry.Type()] {
+				continue
+			}
+			data, err := entry.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			diff.Authority = append(diff.Authority, data)
+		}
+	}
+	return diff, nil
+}
+
+// stateDiff is NewStateDiff, with the coinbase payouts the admin block
+// cancels taken off their addresses.
+func (db *Overlay) stateDiff(ablock interfaces.IAdminBlock, fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock) (*interfaces.StateDiff, error) {
+	cancelled, err := db.cancelledPayouts(ablock)
+	if err != nil {
+		return nil, err
+	}
+	return newStateDiff(ablock, fblock, ecblock, cancelled)
+}
+
+// cancelledPayouts returns the factoids of the coinbase payouts the
+// CoinbaseCancel entries of the admin block strike, by address.  As in the
+// state, a payout is struck only once coinbase maturity is active, while it
+// has yet to mature, and if it wasn't struck before.
+func (db *Overlay) cancelledPayouts(ablock interfaces.IAdminBlock) (map[[32]byte]int64, error) {
+	if ablock == nil {
+		return nil, nil
+	}
+	height := ablock.GetDBHeight()
+	if !constants.IsActive(constants.ACTIVATION_COINBASE_MATURITY, height) {
+		return nil, nil
+	}
+
+	var cancelled map[[32]byte]int64
+	struck := map[[2]uint32]bool{}
+	for _, entry := range ablock.GetABEntries() {
+		c, ok := entry.(*adminBlock.CoinbaseCancel)
+		if !ok {
+			continue
+		}
+		payout := [2]uint32{c.DescriptorHeight, c.DescriptorIndex}
+		if c.DescriptorHeight >= height || height > c.DescriptorHeight+constants.COINBASE_MATURITY || struck[payout] {
+			continue
+		}
+		struck[payout] = true
+		before, err := db.payoutCancelled(c, height)
+		if err != nil {
+			return nil, err
+		}
+		if before {
+			continue
+		}
+
+		fblock, err := db.FetchFBlockByHeight(c.DescriptorHeight)
+		if err != nil {
+			return nil, err
+		}
+		if fblock == nil || len(fblock.GetTransactions()) == 0 {
+			continue
+		}
+		outputs := fblock.GetTransactions()[0].GetOutputs()
+		if int(c.DescriptorIndex) >= len(outputs) {
+			continue
+		}
+		if cancelled == nil {
+			cancelled = map[[32]byte]int64{}
+		}
+		output := outputs[c.DescriptorIndex]
+		cancelled[output.GetAddress().Fixed()] += int64(output.GetAmount())
+	}
+	return cancelled, nil
+}
+
+// payoutCancelled returns true if the payout of the cancel was struck by the
+// admin block of a height after its own, and before the given one.
+func (db *Overlay) payoutCancelled(c *adminBlock.CoinbaseCancel, height uint32) (bool, error) {
+	for h := c.DescriptorHeight + 1; h < height; h++ {
+		ablock, err := db.FetchABlockByHeight(h)
+		if err != nil {
+			return false, err
+		}
+		if ablock == nil {
+			continue
+		}
+		for _, entry := range ablock.GetABEntries() {
+			if e, ok := entry.(*adminBlock.CoinbaseCancel); ok && e.DescriptorHeight == c.DescriptorHeight && e.DescriptorIndex == c.DescriptorIndex {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// balanceChanges returns the deltas that aren't zero, in the order of their
+// addresses.
+func balanceChanges(deltas map[[32]byte]int64) []interfaces.BalanceChange {
+	changes := []interfaces.BalanceChange{}
+	for adr, delta := range deltas {
+		if delta != 0 {
+			changes = append(changes, interfaces.BalanceChange{Address: adr, Delta: delta})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return bytes.Compare(changes[i].Address[:], changes[j].Address[:]) < 0 })
+	return changes
+}
+
+func stateDiffKey(height uint32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, height)
+	return key
+}
+
+func stateSnapshotHeight(height uint32) bool {
+	return height > 0 && StateSnapshotInterval > 0 && height%StateSnapshotInterval == 0
+}
+
+// SaveStateDiff records the changes the blocks of a height make to the state,
+// and the snapshot of the state, if one is kept at the height.
+func (db *Overlay) SaveStateDiff(ablock interfaces.IAdminBlock, fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock) error {
+	diff, err := db.stateDiff(ablock, fblock, ecblock)
+	if err != nil || diff == nil {
+		return err
+	}
+	batch := []interfaces.Record{{Bucket: STATE_DIFF, Key: stateDiffKey(diff.Height), Data: &StateDiffRecord{*diff}}}
+	batch = append(batch, db.stateSnapshotRecords(diff)...)
+	return db.DB.PutInBatch(batch)
+}
+
+// SaveStateDiffMultiBatch is SaveStateDiff in the current multi batch, with
+// the changes it makes to the balance histories of its addresses.  The
+// snapshot is built from the diffs of the heights before, so they must be
+// saved already.
+func (db *Overlay) SaveStateDiffMultiBatch(ablock interfaces.IAdminBlock, fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock) error {
+	diff, err := db.stateDiff(ablock, fblock, ecblock)
+	if err != nil || diff == nil {
+		return err
+	}
+	batch := balanceHistoryRecords(diff)
+	batch = append(batch, interfaces.Record{Bucket: STATE_DIFF, Key: stateDiffKey(diff.Height), Data: &StateDiffRecord{*diff}})
+	batch = append(batch, db.stateSnapshotRecords(diff)...)
+	db.PutInMultiBatch(batch)
+	return nil
+}
+
+// stateSnapshotRecords returns the snapshot record of the height of the diff,
+// if one is kept there.  A snapshot that can't be built, as the diffs before
+// are still being recorded by IndexStateDiffs, is left to it.
+func (db *Overlay) stateSnapshotRecords(diff *interfaces.StateDiff) []interfaces.Record {
+	if !stateSnapshotHeight(diff.Height) {
+		return nil
+	}
+	fct, ec, rate, err := db.FetchBalancesAtHeight(diff.Height - 1)
+	if err != nil {
+		dbLog.Warningf("No state snapshot at height %d: %v", diff.Height, err)
+		return nil
+	}
+	snapshot := &StateSnapshot{Factoids: fct, ECs: ec, ECRate: rate}
+	snapshot.Apply(diff)
+	return []interfaces.Record{{Bucket: STATE_SNAPSHOT, Key: stateDiffKey(diff.Height), Data: snapshot}}
+}
+
+// FetchStateDiff returns the changes the blocks of a height made to the
+// state, or nil if none were recorded.
+func (db *Overlay) FetchStateDiff(height uint32) (*interfaces.StateDiff, error) {
+	diff, err := db.DB.Get(STATE_DIFF, stateDiffKey(height), new(StateDiffRecord))
+	if err != nil {
+		return nil, err
+	}
+	if diff == nil {
+		return nil, nil
+	}
+	return &diff.(*StateDiffRecord).StateDiff, nil
+}
+
+// FetchStateSnapshot returns the snapshot of the state at a height, or nil if
+// none was recorded.
+func (db *Overlay) FetchStateSnapshot(height uint32) (*StateSnapshot, error) {
+	snapshot, err := db.DB.Get(STATE_SNAPSHOT, stateDiffKey(height), new(StateSnapshot))
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, nil
+	}
+	return snapshot.(*StateSnapshot), nil
+}
+
+// FetchBalancesAtHeight rebuilds the factoid and entry credit balances of
+// every address once the blocks at the height were applied, and returns the
+// entry credit rate then.  The diffs are applied from the nearest snapshot
+// at or before the height, or from height 0 if there is none.
+func (db *Overlay) FetchBalancesAtHeight(height uint32) (fct map[[32]byte]int64, ec map[[32]byte]int64, rate uint64, err error) {
+	snapshot := new(StateSnapshot)
+	from := uint32(0)
+	if StateSnapshotInterval > 0 {
+		for h := height - height%StateSnapshotInterval; h > 0; h -= StateSnapshotInterval {
+			found, err := db.FetchStateSnapshot(h)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			if found != nil {
+				snapshot = found
+				from = h + 1
+				break
+			}
+		}
+	}
+
+	for h := from; h <= height; h++ {
+		diff, err := db.FetchStateDiff(h)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if diff == nil {
+			return nil, nil, 0, fmt.Errorf("No state diff at height %d", h)
+		}
+		snapshot.Apply(diff)
+	}
+	if snapshot.Factoids == nil {
+		snapshot.Factoids = map[[32]byte]int64{}
+	}
+	if snapshot.ECs == nil {
+		snapshot.ECs = map[[32]byte]int64{}
+	}
+	return snapshot.Factoids, snapshot.ECs, snapshot.ECRate, nil
+}
+
+// IndexStateDiffs records the state diffs and snapshots of the blocks saved
+// before the node recorded them itself, from height 0 up to the first missing
+// directory block.  Heights already recorded are skipped, so a run that was
+// interrupted picks up where it stopped.  It reads every block, so it is run
+// in a goroutine.
+func (db *Overlay) IndexStateDiffs() {
+	n, err := db.indexStateDiffs()
+	if err != nil {
+		dbLog.Warningf("Could not record the state diffs: %v", err)
+		return
+	}
+	if n > 0 {
+		dbLog.Noticef("Recorded the state diffs of %d heights", n)
+	}
+}
+
+// indexStateDiffs is IndexStateDiffs, returning the number of diffs it
+// recorded.  The balances are carried from one height to the next, rather
+// than read back for each snapshot.
+func (db *Overlay) indexStateDiffs() (int, error) {
+	recorded := 0
+	running := new(StateSnapshot)
+	for height := uint32(0); ; height++ {
+		dblock, err := db.FetchDBlockByHeight(height)
+		if err != nil {
+			return recorded, err
+		}
+		if dblock == nil {
+			return recorded, nil
+		}
+
+		diff, err := db.FetchStateDiff(height)
+		if err != nil {
+			return recorded, err
+		}
+		if diff == nil {
+			ablock, err := db.FetchABlockByHeight(height)
+			if err != nil {
+				return recorded, err
+			}
+			fblock, err := db.FetchFBlockByHeight(height)
+			if err != nil {
+				return recorded, err
+			}
+			ecblock, err := db.FetchECBlockByHeight(height)
+			if err != nil {
+				return recorded, err
+			}
+			diff, err = db.stateDiff(ablock, fblock, ecblock)
+			if err != nil {
+				return recorded, err
+			}
+			if diff == nil {
+				return recorded, fmt.Errorf("No blocks at height %d", height)
+			}
+			err = db.DB.Put(STATE_DIFF, stateDiffKey(height), &StateDiffRecord{*diff})
+			if err != nil {
+				return recorded, err
+			}
+			recorded++
+		}
+
+		running.Apply(diff)
+		if stateSnapshotHeight(height) {
+			exists, err := db.DB.DoesKeyExist(STATE_SNAPSHOT, stateDiffKey(height))
+			if err != nil {
+				return recorded, err
+			}
+			if !exists {
+				err = db.DB.Put(STATE_SNAPSHOT, stateDiffKey(height), running)
+				if err != nil {
+					return recorded, err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestStateDiff(t *testing.T) {
+	// Keep snapshots every few heights, so some balances are rebuilt from one
+	defer func(i uint32) { StateSnapshotInterval = i }(StateSnapshotInterval)
+	StateSnapshotInterval = 3
+
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	runningFct := map[[32]byte]int64{}
+	runningEC := map[[32]byte]int64{}
+	for _, block := range blocks {
+		dbo.StartMultiBatch()
+		err := dbo.SaveStateDiffMultiBatch(block.ABlock, block.FBlock, block.ECBlock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = dbo.ExecuteMultiBatch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, e := BalanceDeltas(block.FBlock, block.ECBlock)
+		for adr, delta := range f {
+			runningFct[adr] += delta
+		}
+		for adr, delta := range e {
+			runningEC[adr] += delta
+		}
+	}
+
+	last := blocks[len(blocks)-1].FBlock.GetDatabaseHeight()
+	fct, ec, rate, err := dbo.FetchBalancesAtHeight(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for adr, expected := range runningFct {
+		if fct[adr] != expected {
+			t.Errorf("Factoid balance of %x is %d, expected %d", adr, fct[adr], expected)
+		}
+		// The balance histories come from the same diffs
+		balance, err := dbo.FetchFactoidBalanceAtHeight(primitives.NewHash(adr[:]), last)
+		if err != nil {
+			t.Error(err)
+		}
+		if balance != expected {
+			t.Errorf("Factoid balance history of %x is %d, expected %d", adr, balance, expected)
+		}
+	}
+	for adr, expected := range runningEC {
+		if ec[adr] != expected {
+			t.Errorf("EC balance of %x is %d, expected %d", adr, ec[adr], expected)
+		}
+	}
+	if rate != blocks[len(blocks)-1].FBlock.GetExchRate() {
+		t.Errorf("EC rate is %d, expected %d", rate, blocks[len(blocks)-1].FBlock.GetExchRate())
+	}
+
+	diff, err := dbo.FetchStateDiff(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil || diff.Height != 0 {
+		t.Fatalf("Expected the diff of height 0, got %+v", diff)
+	}
+	if _, _, _, err = dbo.FetchBalancesAtHeight(last + 1); err == nil {
+		t.Error("Expected an error for a height without a diff")
+	}
+
+	// With the diffs before the last snapshot gone, the balances are still
+	// rebuilt from it
+	snapshot, err := dbo.FetchStateSnapshot(last - last%3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot == nil {
+		t.Fatalf("No snapshot at height %d", last-last%3)
+	}
+	for h := uint32(0); h < snapshot.Height; h++ {
+		if err := dbo.Delete(STATE_DIFF, []byte{0, 0, 0, byte(h)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fct2, ec2, _, err := dbo.FetchBalancesAtHeight(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for adr, expected := range runningFct {
+		if fct2[adr] != expected {
+			t.Errorf("Factoid balance of %x from the snapshot is %d, expected %d", adr, fct2[adr], expected)
+		}
+	}
+	for adr, expected := range runningEC {
+		if ec2[adr] != expected {
+			t.Errorf("EC balance of %x from the snapshot is %d, expected %d", adr, ec2[adr], expected)
+		}
+	}
+}
+
+func TestIndexStateDiffs(t *testing.T) {
+	defer func(i uint32) { StateSnapshotInterval = i }(StateSnapshotInterval)
+	StateSnapshotInterval = 4
+
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	defer dbo.Close()
+	dbo.IndexStateDiffs()
+
+	blocks := testHelper.CreateFullTestBlockSet()
+	for _, block := range blocks {
+		height := block.FBlock.GetDatabaseHeight()
+		diff, err := dbo.FetchStateDiff(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := NewStateDiff(block.ABlock, block.FBlock, block.ECBlock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff == nil || len(diff.FactoidDeltas) != len(expected.FactoidDeltas) || len(diff.ECDeltas) != len(expected.ECDeltas) {
+			t.Errorf("Wrong diff at height %d: %+v", height, diff)
+		}
+		snapshot, err := dbo.FetchStateSnapshot(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (snapshot != nil) != (height > 0 && height%4 == 0) {
+			t.Errorf("Snapshot at height %d is %+v", height, snapshot)
+		}
+	}
+}
+
+func TestStateDiffCoinbaseCancel(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	adr := testHelper.NewFactoidAddress(1).Fixed()
+	fblock := testHelper.CreateTestFactoidBlockWithCoinbase(nil, testHelper.NewFactoidAddress(1), 5000)
+
+	// The admin blocks of heights 1 and 2 both cancel the payout of height 0
+	var prev interfaces.IAdminBlock
+	for h := uint32(0); h < 3; h++ {
+		ablock := adminBlock.NewAdminBlock(prev)
+		var fb interfaces.IFBlock
+		if h == 0 {
+			fb = fblock
+		} else if err := ablock.AddABEntry(adminBlock.NewCoinbaseCancel(0, 0)); err != nil {
+			t.Fatal(err)
+		}
+		dbo.StartMultiBatch()
+		if err := dbo.ProcessABlockMultiBatch(ablock); err != nil {
+			t.Fatal(err)
+		}
+		if fb != nil {
+			if err := dbo.ProcessFBlockMultiBatch(fb); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := dbo.SaveStateDiffMultiBatch(ablock, fb, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := dbo.ExecuteMultiBatch(); err != nil {
+			t.Fatal(err)
+		}
+		prev = ablock
+	}
+
+	diff, err := dbo.FetchStateDiff(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.FactoidDeltas) != 1 || diff.FactoidDeltas[0].Address != adr || diff.FactoidDeltas[0].Delta != -5000 {
+		t.Errorf("The cancel did not strike the payout: %+v", diff.FactoidDeltas)
+	}
+	diff, err = dbo.FetchStateDiff(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.FactoidDeltas) != 0 {
+		t.Errorf("A payout was struck twice: %+v", diff.FactoidDeltas)
+	}
+	fct, _, _, err := dbo.FetchBalancesAtHeight(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fct[adr] != 0 {
+		t.Errorf("Balance is %d after the cancel, expected 0", fct[adr])
+	}
+
+	// Before coinbase maturity is active, cancels strike nothing
+	constants.SetActivationNetwork(constants.NETWORK_MAIN)
+	defer constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+	ablock, err := dbo.FetchABlockByHeight(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dbo.SaveStateDiff(ablock, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = dbo.FetchStateDiff(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.FactoidDeltas) != 0 {
+		t.Errorf("A cancel struck a payout before the activation: %+v", diff.FactoidDeltas)
+	}
+}
+
+func TestMarshalUnmarshalStateSnapshot(t *testing.T) {
+	r := &StateSnapshot{
+		Height:   9,
+		Factoids: map[[32]byte]int64{{1}: 100, {2}: 200},
+		ECs:      map[[32]byte]int64{{3}: 3},
+		ECRate:   1000,
+	}
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2 := new(StateSnapshot)
+	rest, err := r2.UnmarshalBinaryData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%v bytes left over", len(rest))
+	}
+	data2, err := r2.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !primitives.AreBytesEqual(data, data2) {
+		t.Error("State snapshots differ")
+	}
+	if r2.Height != 9 || r2.ECRate != 1000 || r2.Factoids[[32]byte{2}] != 200 || r2.ECs[[32]byte{3}] != 3 {
+		t.Errorf("State snapshots differ: %+v", r2)
+	}
+
+	_, err = r2.UnmarshalBinaryData(data[:len(data)-1])
+	if err == nil {
+		t.Errorf("No error on short data")
+	}
+}
+
+func TestMarshalUnmarshalStateDiff(t *testing.T) {
+	block := testHelper.CreateFullTestBlockSet()[1]
+	diff, err := NewStateDiff(block.ABlock, block.FBlock, block.ECBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff.Authority = append(diff.Authority, []byte{5, 1, 2, 3})
+
+	r := &StateDiffRecord{*diff}
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2 := new(StateDiffRecord)
+	rest, err := r2.UnmarshalBinaryData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%v bytes left over", len(rest))
+	}
+	data2, err := r2.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !primitives.AreBytesEqual(data, data2) {
+		t.Error("State diffs differ")
+	}
+	if r2.Height != diff.Height || r2.ECRate != diff.ECRate || len(r2.Authority) != len(diff.Authority) {
+		t.Errorf("State diffs differ: %+v", r2)
+	}
+
+	_, err = r2.UnmarshalBinaryData(data[:len(data)-1])
+	if err == nil {
+		t.Errorf("No error on short data")
+	}
+}
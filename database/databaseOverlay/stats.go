@@ -0,0 +1,56 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/util"
+)
+
+// FetchDatabaseStats returns the statistics of every bucket in the database,
+// sorted by bucket name.  The entry block number buckets of the individual
+// chains are added up under ENTRYBLOCK_CHAIN_NUMBER.
+func (db *Overlay) FetchDatabaseStats() ([]interfaces.BucketStats, error) {
+	buckets, err := copyBuckets(db.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]*interfaces.BucketStats{}
+	for _, bucket := range buckets {
+		stats, err := db.DB.BucketStats(bucket)
+		if err != nil {
+			return nil, err
+		}
+
+		name := bucket
+		if len(bucket) == len(ENTRYBLOCK_CHAIN_NUMBER)+32 && bytes.HasPrefix(bucket, ENTRYBLOCK_CHAIN_NUMBER) {
+			name = ENTRYBLOCK_CHAIN_NUMBER
+		}
+		total, ok := byName[string(name)]
+		if !ok {
+			total = &interfaces.BucketStats{Bucket: name}
+			byName[string(name)] = total
+		}
+		total.Keys += stats.Keys
+		total.KeyBytes += stats.KeyBytes
+		total.DataBytes += stats.DataBytes
+	}
+
+	names := [][]byte{}
+	for name := range byName {
+		names = append(names, []byte(name))
+	}
+	sort.Sort(util.ByByteArray(names))
+
+	answer := []interfaces.BucketStats{}
+	for _, name := range names {
+		answer = append(answer, *byName[string(name)])
+	}
+	return answer, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+func TestFetchDatabaseStats(t *testing.T) {
+	dbo := createOverlay()
+
+	data := &primitives.ByteSlice{Bytes: []byte{1, 2, 3}}
+	for i := 0; i < 5; i++ {
+		err := dbo.Put(ENTRY, []byte{byte(i), 0}, data)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		chainID := primitives.RandomHash()
+		err := dbo.Put(CHAIN_HEAD, chainID.Bytes(), chainID)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = dbo.Put(append(append([]byte{}, ENTRYBLOCK_CHAIN_NUMBER...), chainID.Bytes()...), []byte{0, 0, 0, 0}, data)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	stats, err := dbo.FetchDatabaseStats()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	found := 0
+	for i, s := range stats {
+		if i > 0 && bytes.Compare(stats[i-1].Bucket, s.Bucket) >= 0 {
+			t.Errorf("Buckets are not sorted")
+		}
+		switch string(s.Bucket) {
+		case string(ENTRY):
+			found++
+			if s.Keys != 5 || s.KeyBytes != 10 || s.DataBytes != 15 {
+				t.Errorf("Entry bucket stats are %+v", s)
+			}
+		case string(ENTRYBLOCK_CHAIN_NUMBER):
+			found++
+			if s.Keys != 3 || s.KeyBytes != 12 || s.DataBytes != 9 {
+				t.Errorf("Chain number bucket stats are %+v", s)
+			}
+		case string(CHAIN_HEAD):
+			found++
+			if s.Keys != 3 {
+				t.Errorf("Chain head bucket stats are %+v", s)
+			}
+		default:
+			if bytes.HasPrefix(s.Bucket, ENTRYBLOCK_CHAIN_NUMBER) {
+				t.Errorf("Chain number bucket %x was not added up", s.Bucket)
+			}
+		}
+	}
+	if found != 3 {
+		t.Errorf("Found %d of the 3 buckets written", found)
+	}
+
+	if err = dbo.Compact(); err != nil {
+		t.Errorf("%v", err)
+	}
+}
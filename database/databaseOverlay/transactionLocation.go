@@ -0,0 +1,127 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// TransactionLocation is the TRANSACTION_LOCATION index record of a factoid
+// transaction: the factoid block it is in, its offset among the transactions
+// of that block, and the directory block height.
+type TransactionLocation struct {
+	FBlockKeyMR  interfaces.IHash
+	Offset       uint32
+	DBlockHeight uint32
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*TransactionLocation)(nil)
+
+func (l *TransactionLocation) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(TransactionLocation)
+}
+
+func (l *TransactionLocation) MarshalBinary() ([]byte, error) {
+	if l.FBlockKeyMR == nil {
+		return nil, fmt.Errorf("TransactionLocation is incomplete")
+	}
+	data := make([]byte, 40)
+	copy(data, l.FBlockKeyMR.Bytes())
+	binary.BigEndian.PutUint32(data[32:36], l.Offset)
+	binary.BigEndian.PutUint32(data[36:40], l.DBlockHeight)
+	return data, nil
+}
+
+func (l *TransactionLocation) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	if len(data) < 40 {
+		return nil, fmt.Errorf("TransactionLocation needs 40 bytes, got %d", len(data))
+	}
+	l.FBlockKeyMR = primitives.NewHash(data[:32])
+	l.Offset = binary.BigEndian.Uint32(data[32:36])
+	l.DBlockHeight = binary.BigEndian.Uint32(data[36:40])
+	return data[40:], nil
+}
+
+func (l *TransactionLocation) UnmarshalBinary(data []byte) error {
+	_, err := l.UnmarshalBinaryData(data)
+	return err
+}
+
+// transactionLocationRecords returns the TRANSACTION_LOCATION records of the
+// transactions in a factoid block, by transaction ID.
+func (db *Overlay) transactionLocationRecords(block interfaces.DatabaseBlockWithEntries) ([]interfaces.Record, error) {
+	fblock, ok := block.(interfaces.IFBlock)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a factoid block", block)
+	}
+	keyMR := fblock.DatabasePrimaryIndex()
+	height := fblock.GetDatabaseHeight()
+
+	batch := []interfaces.Record{}
+	for i, tx := range fblock.GetTransactions() {
+		location := new(TransactionLocation)
+		location.FBlockKeyMR = keyMR
+		location.Offset = uint32(i)
+		location.DBlockHeight = height
+		batch = append(batch, interfaces.Record{Bucket: TRANSACTION_LOCATION, Key: tx.GetSigHash().Bytes(), Data: location})
+	}
+	return batch, nil
+}
+
+func (db *Overlay) SaveTransactionLocationsFromBlock(block interfaces.DatabaseBlockWithEntries) error {
+	batch, err := db.transactionLocationRecords(block)
+	if err != nil {
+		return err
+	}
+	return db.DB.PutInBatch(batch)
+}
+
+func (db *Overlay) SaveTransactionLocationsFromBlockMultiBatch(block interfaces.DatabaseBlockWithEntries) error {
+	batch, err := db.transactionLocationRecords(block)
+	if err != nil {
+		return err
+	}
+	db.PutInMultiBatch(batch)
+	return nil
+}
+
+// FetchTransactionLocation returns the keyMR of the factoid block a
+// transaction is in, its offset in the block, and the directory block height,
+// without loading any block.  A nil fBlockKeyMR means the transaction isn't
+// indexed.
+func (db *Overlay) FetchTransactionLocation(txid interfaces.IHash) (fBlockKeyMR interfaces.IHash, offset uint32, dBlockHeight uint32, err error) {
+	location, err := db.DB.Get(TRANSACTION_LOCATION, txid.Bytes(), new(TransactionLocation))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if location == nil {
+		return nil, 0, 0, nil
+	}
+	l := location.(*TransactionLocation)
+	return l.FBlockKeyMR, l.Offset, l.DBlockHeight, nil
+}
+
+// indexTransactionLocations builds the TRANSACTION_LOCATION index of the
+// factoid blocks already in the database, one block at a time from height 0
+// up to the first missing height.
+func (db *Overlay) indexTransactionLocations() error {
+	for height := uint32(0); ; height++ {
+		block, err := db.FetchFBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return nil
+		}
+		err = db.SaveTransactionLocationsFromBlock(block)
+		if err != nil {
+			return err
+		}
+	}
+}
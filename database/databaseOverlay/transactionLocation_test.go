@@ -0,0 +1,84 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestTransactionLocation(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+
+	for _, block := range blocks {
+		for i, tx := range block.FBlock.GetTransactions() {
+			keyMR, offset, height, err := dbo.FetchTransactionLocation(tx.GetSigHash())
+			if err != nil {
+				t.Error(err)
+			}
+			if keyMR == nil {
+				t.Errorf("Tx %v not indexed", tx.GetSigHash())
+				continue
+			}
+			if keyMR.IsSameAs(block.FBlock.DatabasePrimaryIndex()) == false {
+				t.Error("Wrong factoid block keyMR")
+			}
+			if offset != uint32(i) {
+				t.Errorf("Wrong offset %v, expected %v", offset, i)
+			}
+			if height != block.FBlock.GetDatabaseHeight() {
+				t.Errorf("Wrong height %v, expected %v", height, block.FBlock.GetDatabaseHeight())
+			}
+
+			dTx, err := dbo.FetchFactoidTransaction(tx.GetSigHash())
+			if err != nil {
+				t.Error(err)
+			}
+			if dTx == nil || dTx.GetSigHash().IsSameAs(tx.GetSigHash()) == false {
+				t.Errorf("Tx %v not fetched by its ID", tx.GetSigHash())
+			}
+		}
+	}
+
+	keyMR, _, _, err := dbo.FetchTransactionLocation(primitives.RandomHash())
+	if err != nil {
+		t.Error(err)
+	}
+	if keyMR != nil {
+		t.Error("Found a location for an unknown transaction")
+	}
+}
+
+func TestTransactionLocationMarshal(t *testing.T) {
+	l := new(TransactionLocation)
+	l.FBlockKeyMR = primitives.RandomHash()
+	l.Offset = 7
+	l.DBlockHeight = 123456
+
+	data, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2 := new(TransactionLocation)
+	rest, err := l2.UnmarshalBinaryData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%v bytes left over", len(rest))
+	}
+	if !l.FBlockKeyMR.IsSameAs(l2.FBlockKeyMR) || l.Offset != l2.Offset || l.DBlockHeight != l2.DBlockHeight {
+		t.Errorf("Locations differ")
+	}
+
+	_, err = l2.UnmarshalBinaryData(data[:39])
+	if err == nil {
+		t.Errorf("No error on short data")
+	}
+}
@@ -7,6 +7,26 @@ import (
 )
 
 func (db *Overlay) FetchFactoidTransaction(hash interfaces.IHash) (interfaces.ITransaction, error) {
+	// Transactions are indexed by transaction ID with their offset in the
+	// block, so the block needn't be searched
+	keyMR, offset, _, err := db.FetchTransactionLocation(hash)
+	if err != nil {
+		return nil, err
+	}
+	if keyMR != nil {
+		block, err := db.FetchFBlockByPrimary(keyMR)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("Block not found, should not happen")
+		}
+		txs := block.GetTransactions()
+		if int(offset) < len(txs) && txs[offset].GetSigHash().IsSameAs(hash) {
+			return txs[offset], nil
+		}
+	}
+
 	in, err := db.FetchIncludedIn(hash)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,310 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package hybridDB
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/mapdb"
+)
+
+// EncryptionKeyEnv is the environment variable that can hold the database
+// encryption key, as 64 hex characters.  It takes precedence over the key file
+// and the key in the configuration.
+const EncryptionKeyEnv = "FACTOMD_DB_KEY"
+
+// EncryptionKeySize is the size of the AES-256 database encryption key.
+const EncryptionKeySize = 32
+
+// encryptedValueVersion is the first byte of every encrypted value, so the
+// format can change without guessing.
+const encryptedValueVersion byte = 1
+
+var (
+	encryptionCheckBucket = []byte("Encryption")
+	encryptionCheckKey    = []byte("Check")
+	encryptionCheckValue  = []byte("factomd database encryption check")
+
+	// The chain heads, which every database with blocks has
+	chainHeadBucket = []byte("ChainHead")
+)
+
+// LoadEncryptionKey returns the database encryption key from the environment,
+// from keyFile, or from hexKey, in that order.  It returns nil, and no error,
+// if no key is supplied anywhere, meaning the database isn't encrypted.
+func LoadEncryptionKey(hexKey string, keyFile string) ([]byte, error) {
+	source := "configuration"
+	if env := os.Getenv(EncryptionKeyEnv); env != "" {
+		source = EncryptionKeyEnv
+		hexKey = env
+	} else if keyFile != "" {
+		data, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		source = keyFile
+		hexKey = string(data)
+	}
+
+	hexKey = strings.TrimSpace(hexKey)
+	if hexKey == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("Database encryption key from %s is not hex: %v", source, err)
+	}
+	if len(key) != EncryptionKeySize {
+		return nil, fmt.Errorf("Database encryption key from %s is %d bytes, expected %d", source, len(key), EncryptionKeySize)
+	}
+	return key, nil
+}
+
+// NewEncryptedHybridDB returns a HybridDB in front of persistent that encrypts
+// every value it writes to persistent with AES-256-GCM.  Buckets and keys are
+// not encrypted, as the database has to be able to look them up.  The values
+// are bound to their bucket and key, so they can't be moved around on disk.
+//
+// A check value is stored the first time the key is used, and a database
+// encrypted with another key is refused when it is opened.  So is a database
+// with blocks but no check value, whose blocks were written unencrypted.
+func NewEncryptedHybridDB(persistent interfaces.IDatabase, key []byte) (*HybridDB, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	e := &encryptedDB{db: persistent, aead: aead}
+
+	check, err := e.Get(encryptionCheckBucket, encryptionCheckKey, new(primitives.ByteSlice))
+	if err != nil {
+		return nil, fmt.Errorf("The database was not encrypted with this key: %v", err)
+	}
+	if check == nil {
+		unencrypted, err := holdsBlocks(persistent)
+		if err != nil {
+			return nil, err
+		}
+		if unencrypted {
+			return nil, fmt.Errorf("The database holds blocks written without encryption")
+		}
+		err = e.Put(encryptionCheckBucket, encryptionCheckKey, &primitives.ByteSlice{Bytes: encryptionCheckValue})
+		if err != nil {
+			return nil, err
+		}
+	} else if !primitives.AreBytesEqual(check.(*primitives.ByteSlice).Bytes, encryptionCheckValue) {
+		return nil, fmt.Errorf("The database was not encrypted with this key")
+	}
+
+	answer := new(HybridDB)
+	m := new(mapdb.MapDB)
+	m.Init(nil)
+	answer.temporaryStorage = m
+	answer.persistentStorage = e
+	return answer, nil
+}
+
+// holdsBlocks returns true if the database has any chain head.
+func holdsBlocks(db interfaces.IDatabase) (bool, error) {
+	found := false
+	errFound := fmt.Errorf("found")
+	err := db.ForEachKey(chainHeadBucket, nil, nil, func(key []byte) error {
+		found = true
+		return errFound
+	})
+	if err != nil && err != errFound {
+		return false, err
+	}
+	return found, nil
+}
+
+// encryptedDB encrypts the values of the database it wraps.
+type encryptedDB struct {
+	db   interfaces.IDatabase
+	aead cipher.AEAD
+}
+
+var _ interfaces.IDatabase = (*encryptedDB)(nil)
+
+// additionalData binds a value to its bucket and key.
+func additionalData(bucket, key []byte) []byte {
+	ad := make([]byte, 4, 4+len(bucket)+len(key))
+	binary.BigEndian.PutUint32(ad, uint32(len(bucket)))
+	ad = append(ad, bucket...)
+	return append(ad, key...)
+}
+
+func (e *encryptedDB) seal(bucket, key []byte, data interfaces.BinaryMarshallable) (*primitives.ByteSlice, error) {
+	plain, err := data.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := append([]byte{encryptedValueVersion}, nonce...)
+	sealed = e.aead.Seal(sealed, nonce, plain, additionalData(bucket, key))
+	return &primitives.ByteSlice{Bytes: sealed}, nil
+}
+
+func (e *encryptedDB) open(bucket, key, sealed []byte) ([]byte, error) {
+	n := e.aead.NonceSize()
+	if len(sealed) < 1+n || sealed[0] != encryptedValueVersion {
+		return nil, fmt.Errorf("Key %x in bucket %x is not an encrypted value", key, bucket)
+	}
+	plain, err := e.aead.Open(nil, sealed[1:1+n], sealed[1+n:], additionalData(bucket, key))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decrypt key %x in bucket %x: %v", key, bucket, err)
+	}
+	return plain, nil
+}
+
+func (e *encryptedDB) Put(bucket, key []byte, data interfaces.BinaryMarshallable) error {
+	sealed, err := e.seal(bucket, key, data)
+	if err != nil {
+		return err
+	}
+	return e.db.Put(bucket, key, sealed)
+}
+
+func (e *encryptedDB) PutInBatch(records []interfaces.Record) error {
+	batch := make([]interfaces.Record, 0, len(records))
+	for _, r := range records {
+		if r.Data == nil {
+			batch = append(batch, r)
+			continue
+		}
+		sealed, err := e.seal(r.Bucket, r.Key, r.Data)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, interfaces.Record{Bucket: r.Bucket, Key: r.Key, Data: sealed})
+	}
+	return e.db.PutInBatch(batch)
+}
+
+func (e *encryptedDB) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	sealed, err := e.db.Get(bucket, key, new(primitives.ByteSlice))
+	if err != nil {
+		return nil, err
+	}
+	if sealed == nil {
+		return nil, nil
+	}
+	plain, err := e.open(bucket, key, sealed.(*primitives.ByteSlice).Bytes)
+	if err != nil {
+		return nil, err
+	}
+	err = destination.UnmarshalBinary(plain)
+	if err != nil {
+		return nil, err
+	}
+	return destination, nil
+}
+
+func (e *encryptedDB) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	sealed, err := e.db.GetMulti(bucket, keys, new(primitives.ByteSlice))
+	if err != nil {
+		return nil, err
+	}
+	answer := make([]interfaces.BinaryMarshallableAndCopyable, len(keys))
+	for i, v := range sealed {
+		if v == nil {
+			continue
+		}
+		plain, err := e.open(bucket, keys[i], v.(*primitives.ByteSlice).Bytes)
+		if err != nil {
+			return nil, err
+		}
+		tmp := sample.New()
+		err = tmp.UnmarshalBinary(plain)
+		if err != nil {
+			return nil, err
+		}
+		answer[i] = tmp
+	}
+	return answer, nil
+}
+
+func (e *encryptedDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	keys, err := e.db.ListAllKeys(bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	answer := []interfaces.BinaryMarshallableAndCopyable{}
+	found := [][]byte{}
+	for _, k := range keys {
+		v, err := e.Get(bucket, k, sample.New())
+		if err != nil {
+			return nil, nil, err
+		}
+		if v == nil {
+			continue
+		}
+		answer = append(answer, v.(interfaces.BinaryMarshallableAndCopyable))
+		found = append(found, k)
+	}
+	return answer, found, nil
+}
+
+func (e *encryptedDB) Close() error {
+	return e.db.Close()
+}
+
+func (e *encryptedDB) Delete(bucket, key []byte) error {
+	return e.db.Delete(bucket, key)
+}
+
+func (e *encryptedDB) ListAllKeys(bucket []byte) ([][]byte, error) {
+	return e.db.ListAllKeys(bucket)
+}
+
+func (e *encryptedDB) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	return e.db.ForEachKey(bucket, start, limit, fn)
+}
+
+func (e *encryptedDB) Clear(bucket []byte) error {
+	return e.db.Clear(bucket)
+}
+
+func (e *encryptedDB) ListAllBuckets() ([][]byte, error) {
+	return e.db.ListAllBuckets()
+}
+
+func (e *encryptedDB) Trim() {
+	e.db.Trim()
+}
+
+func (e *encryptedDB) DoesKeyExist(bucket, key []byte) (bool, error) {
+	return e.db.DoesKeyExist(bucket, key)
+}
+
+// BucketStats reports the sizes as stored, that is encrypted.
+func (e *encryptedDB) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	return e.db.BucketStats(bucket)
+}
+
+func (e *encryptedDB) Compact() error {
+	return e.db.Compact()
+}
+
+func (e *encryptedDB) SetBulkLoad(bulk bool) error {
+	return e.db.SetBulkLoad(bulk)
+}
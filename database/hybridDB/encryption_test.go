@@ -0,0 +1,132 @@
+package hybridDB_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/hybridDB"
+	"github.com/FactomProject/factomd/database/mapdb"
+)
+
+func TestEncryptedHybridDB(t *testing.T) {
+	persistent := new(mapdb.MapDB)
+	persistent.Init(nil)
+
+	key := primitives.RandomHash().Bytes()
+	db, err := NewEncryptedHybridDB(persistent, key)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	bucket := []byte("bucket")
+	data := &TestData{Str: "a value that should not be on disk"}
+	err = db.Put(bucket, []byte("one"), data)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	err = db.PutInBatch([]interfaces.Record{{Bucket: bucket, Key: []byte("two"), Data: data}})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	for _, k := range []string{"one", "two"} {
+		raw, err := persistent.Get(bucket, []byte(k), new(primitives.ByteSlice))
+		if err != nil || raw == nil {
+			t.Fatalf("Key %s missing from the persistent database: %v", k, err)
+		}
+		if bytes.Contains(raw.(*primitives.ByteSlice).Bytes, []byte(data.Str)) {
+			t.Errorf("Key %s is stored unencrypted", k)
+		}
+	}
+
+	// A fresh HybridDB has nothing cached, so this reads through the encryption
+	db, err = NewEncryptedHybridDB(persistent, key)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	got, err := db.Get(bucket, []byte("two"), new(TestData))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if got == nil || got.(*TestData).Str != data.Str {
+		t.Errorf("Decrypted value is %v", got)
+	}
+	all, keys, err := db.GetAll(bucket, new(TestData))
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(all) != 2 || len(keys) != 2 {
+		t.Errorf("GetAll returned %d values and %d keys, expected 2", len(all), len(keys))
+	}
+
+	// Values are bound to their key
+	raw, _ := persistent.Get(bucket, []byte("one"), new(primitives.ByteSlice))
+	persistent.Put(bucket, []byte("two"), raw.(*primitives.ByteSlice))
+	db, _ = NewEncryptedHybridDB(persistent, key)
+	if _, err = db.Get(bucket, []byte("two"), new(TestData)); err == nil {
+		t.Errorf("A value moved to another key was decrypted")
+	}
+
+	if _, err = NewEncryptedHybridDB(persistent, primitives.RandomHash().Bytes()); err == nil {
+		t.Errorf("The database was opened with the wrong key")
+	}
+}
+
+func TestEncryptedHybridDBUnencryptedBlocks(t *testing.T) {
+	persistent := new(mapdb.MapDB)
+	persistent.Init(nil)
+	persistent.Put([]byte("ChainHead"), []byte("chain"), &primitives.ByteSlice{Bytes: []byte("head")})
+
+	if _, err := NewEncryptedHybridDB(persistent, primitives.RandomHash().Bytes()); err == nil {
+		t.Errorf("A database of unencrypted blocks was opened encrypted")
+	}
+	if check, _ := persistent.Get([]byte("Encryption"), []byte("Check"), new(primitives.ByteSlice)); check != nil {
+		t.Errorf("A database of unencrypted blocks was stamped as encrypted")
+	}
+}
+
+func TestLoadEncryptionKey(t *testing.T) {
+	old := os.Getenv(EncryptionKeyEnv)
+	defer os.Setenv(EncryptionKeyEnv, old)
+	os.Setenv(EncryptionKeyEnv, "")
+
+	key, err := LoadEncryptionKey("", "")
+	if key != nil || err != nil {
+		t.Errorf("Expected no key and no error, got %x, %v", key, err)
+	}
+
+	configKey := primitives.RandomHash().Bytes()
+	key, err = LoadEncryptionKey(hex.EncodeToString(configKey), "")
+	if err != nil || !bytes.Equal(key, configKey) {
+		t.Errorf("Key from the configuration is %x, %v", key, err)
+	}
+	if _, err = LoadEncryptionKey("abcd", ""); err == nil {
+		t.Errorf("A short key was accepted")
+	}
+
+	fileKey := primitives.RandomHash().Bytes()
+	f, err := ioutil.TempFile("", "dbkey")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(hex.EncodeToString(fileKey) + "\n")
+	f.Close()
+
+	key, err = LoadEncryptionKey(hex.EncodeToString(configKey), f.Name())
+	if err != nil || !bytes.Equal(key, fileKey) {
+		t.Errorf("Key from the file is %x, %v", key, err)
+	}
+
+	envKey := primitives.RandomHash().Bytes()
+	os.Setenv(EncryptionKeyEnv, hex.EncodeToString(envKey))
+	key, err = LoadEncryptionKey(hex.EncodeToString(configKey), f.Name())
+	if err != nil || !bytes.Equal(key, envKey) {
+		t.Errorf("Key from the environment is %x, %v", key, err)
+	}
+}
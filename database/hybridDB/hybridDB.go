@@ -128,6 +128,14 @@ func (db *HybridDB) Get(bucket, key []byte, destination interfaces.BinaryMarshal
 	return answer, nil
 }
 
+// GetMulti reads the persistent storage, without filling the temporary storage.
+func (db *HybridDB) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	return db.persistentStorage.GetMulti(bucket, keys, sample)
+}
+
 func (db *HybridDB) Delete(bucket, key []byte) error {
 	db.Sem.Lock()
 	defer db.Sem.Unlock()
@@ -151,6 +159,13 @@ func (db *HybridDB) ListAllKeys(bucket []byte) ([][]byte, error) {
 	return db.persistentStorage.ListAllKeys(bucket)
 }
 
+func (db *HybridDB) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	return db.persistentStorage.ForEachKey(bucket, start, limit, fn)
+}
+
 func (db *HybridDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
 	db.Sem.RLock()
 	defer db.Sem.RUnlock()
@@ -183,3 +198,24 @@ func (db *HybridDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	}
 	return exist, nil
 }
+
+func (db *HybridDB) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	return db.persistentStorage.BucketStats(bucket)
+}
+
+func (db *HybridDB) Compact() error {
+	db.Sem.Lock()
+	defer db.Sem.Unlock()
+
+	return db.persistentStorage.Compact()
+}
+
+func (db *HybridDB) SetBulkLoad(bulk bool) error {
+	db.Sem.Lock()
+	defer db.Sem.Unlock()
+
+	return db.persistentStorage.SetBulkLoad(bulk)
+}
@@ -17,6 +17,24 @@ var (
 		Name: "factomd_database_leveldb_cacheblock",
 		Help: "Memory used by Level DB for caching",
 	})
+
+	// Latencies
+	LevelDBGetTime = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_database_leveldb_get_ns",
+		Help: "Time it takes to get a value from the database",
+	})
+	LevelDBPutTime = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_database_leveldb_put_ns",
+		Help: "Time it takes to put a value to the database",
+	})
+	LevelDBBatchTime = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_database_leveldb_batch_ns",
+		Help: "Time it takes to write a batch of records to the database",
+	})
+	LevelDBDeleteTime = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_database_leveldb_delete_ns",
+		Help: "Time it takes to delete a value from the database",
+	})
 )
 
 var registered = false
@@ -33,4 +51,8 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(LevelDBGets)
 	prometheus.MustRegister(LevelDBPuts)
 	prometheus.MustRegister(LevelDBCacheblock)
+	prometheus.MustRegister(LevelDBGetTime)
+	prometheus.MustRegister(LevelDBPutTime)
+	prometheus.MustRegister(LevelDBBatchTime)
+	prometheus.MustRegister(LevelDBDeleteTime)
 }
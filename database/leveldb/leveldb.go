@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/goleveldb/leveldb"
@@ -66,6 +67,9 @@ func (db *LevelDB) Delete(bucket []byte, key []byte) error {
 	db.dbLock.Lock()
 	defer db.dbLock.Unlock()
 
+	n := time.Now()
+	defer func() { LevelDBDeleteTime.Observe(float64(time.Since(n).Nanoseconds())) }()
+
 	ldbKey := CombineBucketAndKey(bucket, key)
 	err := db.lDB.Delete(ldbKey, db.wo)
 	return err
@@ -93,6 +97,8 @@ func (db *LevelDB) Get(bucket []byte, key []byte, destination interfaces.BinaryM
 	defer db.dbLock.RUnlock()
 
 	LevelDBGets.Inc()
+	n := time.Now()
+	defer func() { LevelDBGetTime.Observe(float64(time.Since(n).Nanoseconds())) }()
 
 	ldbKey := CombineBucketAndKey(bucket, key)
 	data, err := db.lDB.Get(ldbKey, db.ro)
@@ -111,6 +117,38 @@ func (db *LevelDB) Get(bucket []byte, key []byte, destination interfaces.BinaryM
 	return destination, nil
 }
 
+// GetMulti reads every key from one snapshot, so the values are consistent with
+// each other.
+func (db *LevelDB) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	snap, err := db.lDB.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Release()
+
+	answer := make([]interfaces.BinaryMarshallableAndCopyable, len(keys))
+	for i, key := range keys {
+		LevelDBGets.Inc()
+		data, err := snap.Get(CombineBucketAndKey(bucket, key), db.ro)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				continue
+			}
+			return nil, err
+		}
+		tmp := sample.New()
+		_, err = tmp.UnmarshalBinaryData(data)
+		if err != nil {
+			return nil, err
+		}
+		answer[i] = tmp
+	}
+	return answer, nil
+}
+
 func (db *LevelDB) Put(bucket []byte, key []byte, data interfaces.BinaryMarshallable) error {
 	db.dbLock.Lock()
 	defer db.dbLock.Unlock()
@@ -122,6 +160,8 @@ func (db *LevelDB) Put(bucket []byte, key []byte, data interfaces.BinaryMarshall
 	defer db.lbatch.Reset()
 
 	LevelDBPuts.Inc()
+	n := time.Now()
+	defer func() { LevelDBPutTime.Observe(float64(time.Since(n).Nanoseconds())) }()
 
 	ldbKey := CombineBucketAndKey(bucket, key)
 	hex, err := data.MarshalBinary()
@@ -147,8 +187,15 @@ func (db *LevelDB) PutInBatch(records []interfaces.Record) error {
 
 	defer db.lbatch.Reset()
 
+	n := time.Now()
+	defer func() { LevelDBBatchTime.Observe(float64(time.Since(n).Nanoseconds())) }()
+
 	for _, v := range records {
 		ldbKey := CombineBucketAndKey(v.Bucket, v.Key)
+		if v.Data == nil {
+			db.lbatch.Delete(ldbKey)
+			continue
+		}
 		hex, err := v.Data.MarshalBinary()
 		if err != nil {
 			return err
@@ -219,6 +266,36 @@ func (db *LevelDB) ListAllKeys(bucket []byte) (keys [][]byte, err error) {
 	return answer, nil
 }
 
+func (db *LevelDB) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	ldbKey := ExtendBucket(bucket)
+
+	// Build the range keys in their own slices, so they can't share memory
+	fromKey := append(append([]byte{}, ldbKey...), start...)
+	var toKey []byte
+	if limit == nil {
+		toKey = addOneToByteArray(ldbKey)
+	} else {
+		toKey = append(append([]byte{}, ldbKey...), limit...)
+	}
+
+	iter := db.lDB.NewIterator(&util.Range{Start: fromKey, Limit: toKey}, db.ro)
+	defer iter.Release()
+
+	for iter.Next() {
+		key := iter.Key()
+		tmp := make([]byte, len(key[len(ldbKey):]))
+		copy(tmp, key[len(ldbKey):])
+		err := fn(tmp)
+		if err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
 func (db *LevelDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
 	db.dbLock.RLock()
 	defer db.dbLock.RUnlock()
@@ -284,6 +361,7 @@ func NewLevelDB(filename string, create bool) (interfaces.IDatabase, error) {
 		return nil, err
 	}
 	db.lDB = tlDB
+	db.wo = &opt.WriteOptions{Sync: true} // Until SetBulkLoad
 
 	return db, nil
 }
@@ -308,3 +386,45 @@ func (db *LevelDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	ldbKey := CombineBucketAndKey(bucket, key)
 	return db.lDB.Has(ldbKey, db.ro)
 }
+
+func (db *LevelDB) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	ldbKey := ExtendBucket(bucket)
+	iter := db.lDB.NewIterator(&util.Range{Start: ldbKey, Limit: addOneToByteArray(ldbKey)}, db.ro)
+	defer iter.Release()
+
+	stats := interfaces.BucketStats{Bucket: bucket}
+	for iter.Next() {
+		stats.Keys++
+		stats.KeyBytes += int64(len(iter.Key()) - len(ldbKey))
+		stats.DataBytes += int64(len(iter.Value()))
+	}
+	return stats, iter.Error()
+}
+
+// Compact compacts the whole key range, which rewrites the tables to drop
+// deleted and overwritten values.
+func (db *LevelDB) Compact() error {
+	db.dbLock.Lock()
+	defer db.dbLock.Unlock()
+
+	return db.lDB.CompactRange(util.Range{})
+}
+
+// syncKey is deleted, with a synced write, to flush the journal, as LevelDB
+// has no sync of its own and skips empty batches.  No bucket has its name.
+var syncKey = CombineBucketAndKey([]byte("LevelDBSync"), nil)
+
+// SetBulkLoad stops syncing the journal after every write while bulk loading,
+// and flushes everything written so far.
+func (db *LevelDB) SetBulkLoad(bulk bool) error {
+	db.dbLock.Lock()
+	defer db.dbLock.Unlock()
+
+	db.wo = &opt.WriteOptions{Sync: !bulk}
+	batch := new(leveldb.Batch)
+	batch.Delete(syncKey)
+	return db.lDB.Write(batch, &opt.WriteOptions{Sync: true})
+}
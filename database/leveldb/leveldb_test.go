@@ -233,3 +233,47 @@ func TestGetAll(t *testing.T) {
 		}
 	}
 }
+
+func TestSetBulkLoad(t *testing.T) {
+	m, err := NewLevelDB(dbFilename, true)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	bucket := []byte("bucket")
+
+	if err := m.SetBulkLoad(true); err != nil {
+		t.Fatalf("%v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := m.Put(bucket, []byte(fmt.Sprintf("key%d", i)), &TestData{Str: "bulk"}); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err := m.SetBulkLoad(false); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := m.Put(bucket, []byte("synced"), &TestData{Str: "synced"}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// Everything written is there once the database is opened again
+	m, err = NewLevelDB(dbFilename, false)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer CleanupTest(t, m)
+	keys, err := m.ListAllKeys(bucket)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(keys) != 101 {
+		t.Errorf("Expected 101 keys, got %d", len(keys))
+	}
+	data, err := m.Get(bucket, []byte("synced"), new(TestData))
+	if err != nil || data == nil || data.(*TestData).Str != "synced" {
+		t.Errorf("Got %v, %v", data, err)
+	}
+}
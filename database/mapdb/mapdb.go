@@ -5,6 +5,7 @@
 package mapdb
 
 import (
+	"bytes"
 	"sort"
 	"sync"
 
@@ -12,6 +13,9 @@ import (
 	"github.com/FactomProject/factomd/util"
 )
 
+// MapDB is an in memory database, with the same semantics as the Bolt and
+// LevelDB backends.  Only writes modify the maps, so reads only need the read
+// lock, and reading a bucket that doesn't exist simply finds nothing.
 type MapDB struct {
 	Sem   sync.RWMutex
 	Cache map[string]map[string][]byte // Our Cache
@@ -24,12 +28,6 @@ func (MapDB) Close() error {
 }
 
 func (db *MapDB) ListAllBuckets() ([][]byte, error) {
-	if db.Cache == nil {
-		db.Sem.Lock()
-		db.Cache = map[string]map[string][]byte{}
-		db.Sem.Unlock()
-	}
-
 	db.Sem.RLock()
 	defer db.Sem.RUnlock()
 
@@ -45,22 +43,6 @@ func (db *MapDB) ListAllBuckets() ([][]byte, error) {
 func (db *MapDB) Trim() {
 }
 
-func (db *MapDB) createCache(bucket []byte) {
-	if db.Cache == nil {
-		db.Sem.Lock()
-		db.Cache = map[string]map[string][]byte{}
-		db.Sem.Unlock()
-	}
-	db.Sem.RLock()
-	_, ok := db.Cache[string(bucket)]
-	db.Sem.RUnlock()
-	if ok == false {
-		db.Sem.Lock()
-		db.Cache[string(bucket)] = map[string][]byte{}
-		db.Sem.Unlock()
-	}
-}
-
 func (db *MapDB) Init(bucketList [][]byte) {
 	db.Sem.Lock()
 	defer db.Sem.Unlock()
@@ -105,32 +87,46 @@ func (db *MapDB) rawPut(bucket, key []byte, data interfaces.BinaryMarshallable)
 	return nil
 }
 
+// PutInBatch writes all the records, or none of them.  Everything is marshalled
+// before the first record is written, so a bad record can't leave a partial
+// batch behind.  Records with nil Data are deleted.
 func (db *MapDB) PutInBatch(records []interfaces.Record) error {
+	data := make([][]byte, len(records))
+	for i, v := range records {
+		if v.Data == nil {
+			continue
+		}
+		hex, err := v.Data.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		data[i] = hex
+	}
+
 	db.Sem.Lock()
 	defer db.Sem.Unlock()
 
-	for _, v := range records {
-		err := db.rawPut(v.Bucket, v.Key, v.Data)
-		if err != nil {
-			return err
+	if db.Cache == nil {
+		db.Cache = map[string]map[string][]byte{}
+	}
+	for i, v := range records {
+		if v.Data == nil {
+			delete(db.Cache[string(v.Bucket)], string(v.Key))
+			continue
 		}
+		_, ok := db.Cache[string(v.Bucket)]
+		if ok == false {
+			db.Cache[string(v.Bucket)] = map[string][]byte{}
+		}
+		db.Cache[string(v.Bucket)][string(v.Key)] = data[i]
 	}
 	return nil
 }
 
 func (db *MapDB) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
-	db.createCache(bucket)
-
 	db.Sem.RLock()
 	defer db.Sem.RUnlock()
 
-	if db.Cache == nil {
-		db.Cache = map[string]map[string][]byte{}
-	}
-	_, ok := db.Cache[string(bucket)]
-	if ok == false {
-		db.Cache[string(bucket)] = map[string][]byte{}
-	}
 	v, ok := db.Cache[string(bucket)][string(key)]
 	if ok == false {
 		return nil, nil
@@ -145,6 +141,26 @@ func (db *MapDB) Get(bucket, key []byte, destination interfaces.BinaryMarshallab
 	return destination, nil
 }
 
+func (db *MapDB) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	answer := make([]interfaces.BinaryMarshallableAndCopyable, len(keys))
+	for i, key := range keys {
+		v := db.Cache[string(bucket)][string(key)]
+		if v == nil {
+			continue
+		}
+		tmp := sample.New()
+		_, err := tmp.UnmarshalBinaryData(v)
+		if err != nil {
+			return nil, err
+		}
+		answer[i] = tmp
+	}
+	return answer, nil
+}
+
 func (db *MapDB) Delete(bucket, key []byte) error {
 	db.Sem.Lock()
 	defer db.Sem.Unlock()
@@ -161,18 +177,14 @@ func (db *MapDB) Delete(bucket, key []byte) error {
 }
 
 func (db *MapDB) ListAllKeys(bucket []byte) ([][]byte, error) {
-	db.createCache(bucket)
-
 	db.Sem.RLock()
 	defer db.Sem.RUnlock()
 
-	if db.Cache == nil {
-		db.Cache = map[string]map[string][]byte{}
-	}
-	_, ok := db.Cache[string(bucket)]
-	if ok == false {
-		db.Cache[string(bucket)] = map[string][]byte{}
-	}
+	return db.listAllKeys(bucket), nil
+}
+
+// listAllKeys returns the sorted keys of a bucket.  The caller must hold the lock.
+func (db *MapDB) listAllKeys(bucket []byte) [][]byte {
 	answer := [][]byte{}
 	for k, _ := range db.Cache[string(bucket)] {
 		answer = append(answer, []byte(k))
@@ -180,27 +192,42 @@ func (db *MapDB) ListAllKeys(bucket []byte) ([][]byte, error) {
 
 	sort.Sort(util.ByByteArray(answer))
 
-	return answer, nil
+	return answer
 }
 
-func (db *MapDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
-	db.createCache(bucket)
-
+func (db *MapDB) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	// We don't hold the lock while calling fn, so take a sorted copy of the
+	// keys in range first.
 	db.Sem.RLock()
-	defer db.Sem.RUnlock()
-
-	if db.Cache == nil {
-		db.Cache = map[string]map[string][]byte{}
-	}
-	_, ok := db.Cache[string(bucket)]
-	if ok == false {
-		db.Cache[string(bucket)] = map[string][]byte{}
+	keys := [][]byte{}
+	for k := range db.Cache[string(bucket)] {
+		key := []byte(k)
+		if start != nil && bytes.Compare(key, start) < 0 {
+			continue
+		}
+		if limit != nil && bytes.Compare(key, limit) >= 0 {
+			continue
+		}
+		keys = append(keys, key)
 	}
+	db.Sem.RUnlock()
 
-	keys, err := db.ListAllKeys(bucket)
-	if err != nil {
-		return nil, nil, err
+	sort.Sort(util.ByByteArray(keys))
+
+	for _, k := range keys {
+		err := fn(k)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+func (db *MapDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	keys := db.listAllKeys(bucket)
 
 	answer := []interfaces.BinaryMarshallableAndCopyable{}
 	for _, k := range keys {
@@ -227,18 +254,9 @@ func (db *MapDB) Clear(bucket []byte) error {
 }
 
 func (db *MapDB) DoesKeyExist(bucket, key []byte) (bool, error) {
-	db.createCache(bucket)
-
 	db.Sem.RLock()
 	defer db.Sem.RUnlock()
 
-	if db.Cache == nil {
-		db.Cache = map[string]map[string][]byte{}
-	}
-	_, ok := db.Cache[string(bucket)]
-	if ok == false {
-		db.Cache[string(bucket)] = map[string][]byte{}
-	}
 	data, ok := db.Cache[string(bucket)][string(key)]
 	if ok == false {
 		return false, nil
@@ -251,3 +269,26 @@ func (db *MapDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	}
 	return true, nil
 }
+
+func (db *MapDB) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	stats := interfaces.BucketStats{Bucket: bucket}
+	for k, v := range db.Cache[string(bucket)] {
+		stats.Keys++
+		stats.KeyBytes += int64(len(k))
+		stats.DataBytes += int64(len(v))
+	}
+	return stats, nil
+}
+
+// Nothing to reclaim in memory.
+func (db *MapDB) Compact() error {
+	return nil
+}
+
+// Nothing is written to disk.
+func (db *MapDB) SetBulkLoad(bulk bool) error {
+	return nil
+}
@@ -247,3 +247,167 @@ func TestGetAll(t *testing.T) {
 		}
 	}
 }
+
+type BadData struct {
+	TestData
+}
+
+func (t *BadData) MarshalBinary() ([]byte, error) {
+	return nil, fmt.Errorf("Can't marshal BadData")
+}
+
+func TestPutInBatchIsAtomic(t *testing.T) {
+	m := new(MapDB)
+
+	bucket := []byte("bucket")
+	good := new(TestData)
+	good.Str = "good"
+
+	batch := []interfaces.Record{}
+	batch = append(batch, interfaces.Record{bucket, []byte("good"), good})
+	batch = append(batch, interfaces.Record{bucket, []byte("bad"), new(BadData)})
+
+	err := m.PutInBatch(batch)
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+
+	resp, err := m.Get(bucket, []byte("good"), new(TestData))
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if resp != nil {
+		t.Errorf("Part of a failed batch was written")
+	}
+
+	err = m.PutInBatch(batch[:1])
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	resp, err = m.Get(bucket, []byte("good"), new(TestData))
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if resp == nil || resp.(*TestData).Str != "good" {
+		t.Errorf("Batch was not written")
+	}
+}
+
+func TestForEachKey(t *testing.T) {
+	m := new(MapDB)
+
+	bucket := []byte("bucket")
+	for _, k := range []string{"b1", "a1", "a3", "a2", "c1"} {
+		test := new(TestData)
+		test.Str = k
+		err := m.Put(bucket, []byte(k), test)
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+
+	keys := []string{}
+	err := m.ForEachKey(bucket, []byte("a"), []byte("b"), func(key []byte) error {
+		keys = append(keys, string(key))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if fmt.Sprintf("%v", keys) != "[a1 a2 a3]" {
+		t.Errorf("Invalid keys %v", keys)
+	}
+
+	keys = []string{}
+	err = m.ForEachKey(bucket, []byte("b"), nil, func(key []byte) error {
+		keys = append(keys, string(key))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if fmt.Sprintf("%v", keys) != "[b1 c1]" {
+		t.Errorf("Invalid keys %v", keys)
+	}
+
+	stop := fmt.Errorf("stop")
+	count := 0
+	err = m.ForEachKey(bucket, nil, nil, func(key []byte) error {
+		count++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("Expected the error from fn, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Iteration didn't stop, count %v", count)
+	}
+}
+
+func TestReadsDoNotCreateBuckets(t *testing.T) {
+	m := new(MapDB)
+	m.Init(nil)
+
+	bucket := []byte("bucket")
+
+	resp, err := m.Get(bucket, []byte("key"), new(TestData))
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if resp != nil {
+		t.Errorf("resp is not nil while it should be")
+	}
+	exists, err := m.DoesKeyExist(bucket, []byte("key"))
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if exists {
+		t.Errorf("Key exists in an empty database")
+	}
+	all, _, err := m.GetAll(bucket, new(TestData))
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("Got %v entries from an empty bucket", len(all))
+	}
+
+	buckets, err := m.ListAllBuckets()
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(buckets) != 0 {
+		t.Errorf("Reads created %v buckets", len(buckets))
+	}
+}
+
+func TestGetMulti(t *testing.T) {
+	m := new(MapDB)
+	m.Init(nil)
+
+	bucket := []byte("bucket")
+	m.Put(bucket, []byte("one"), &TestData{Str: "1"})
+	m.Put(bucket, []byte("three"), &TestData{Str: "3"})
+
+	values, err := m.GetMulti(bucket, [][]byte{[]byte("one"), []byte("two"), []byte("three")}, new(TestData))
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(values) != 3 {
+		t.Fatalf("Got %d values, expected 3", len(values))
+	}
+	if values[0] == nil || values[0].(*TestData).Str != "1" {
+		t.Errorf("Got %v for one", values[0])
+	}
+	if values[1] != nil {
+		t.Errorf("Got %v for a missing key", values[1])
+	}
+	if values[2] == nil || values[2].(*TestData).Str != "3" {
+		t.Errorf("Got %v for three", values[2])
+	}
+
+	values, err = m.GetMulti([]byte("nothing"), [][]byte{[]byte("one")}, new(TestData))
+	if err != nil || len(values) != 1 || values[0] != nil {
+		t.Errorf("Got %v, %v from a missing bucket", values, err)
+	}
+}
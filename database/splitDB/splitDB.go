@@ -0,0 +1,151 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package splitDB
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// SplitDB keeps some buckets in a second database.  The node uses it to put
+// the entries, which make up most of the data but are rarely read, on other
+// storage than the blocks and indexes it reads all the time.
+type SplitDB struct {
+	blocks  interfaces.IDatabase
+	entries interfaces.IDatabase
+	isEntry func(bucket []byte) bool // True for the buckets kept in entries
+}
+
+var _ interfaces.IDatabase = (*SplitDB)(nil)
+
+// NewSplitDB returns a database that keeps the buckets isEntry is true for in
+// entries, and every other bucket in blocks.
+func NewSplitDB(blocks, entries interfaces.IDatabase, isEntry func(bucket []byte) bool) *SplitDB {
+	s := new(SplitDB)
+	s.blocks = blocks
+	s.entries = entries
+	s.isEntry = isEntry
+	return s
+}
+
+// db returns the database a bucket is kept in.
+func (s *SplitDB) db(bucket []byte) interfaces.IDatabase {
+	if s.isEntry(bucket) {
+		return s.entries
+	}
+	return s.blocks
+}
+
+func (s *SplitDB) Put(bucket, key []byte, data interfaces.BinaryMarshallable) error {
+	return s.db(bucket).Put(bucket, key, data)
+}
+
+// PutInBatch writes the entry records first, then the rest.  Each half is
+// atomic, but the two are not atomic together.  As the entries go first, a
+// failure can leave entries nothing points to, but never an index pointing to
+// a missing entry.
+func (s *SplitDB) PutInBatch(records []interfaces.Record) error {
+	blocks := []interfaces.Record{}
+	entries := []interfaces.Record{}
+	for _, r := range records {
+		if s.isEntry(r.Bucket) {
+			entries = append(entries, r)
+		} else {
+			blocks = append(blocks, r)
+		}
+	}
+
+	if len(entries) > 0 {
+		err := s.entries.PutInBatch(entries)
+		if err != nil {
+			return err
+		}
+	}
+	if len(blocks) > 0 {
+		return s.blocks.PutInBatch(blocks)
+	}
+	return nil
+}
+
+func (s *SplitDB) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	return s.db(bucket).Get(bucket, key, destination)
+}
+
+func (s *SplitDB) GetMulti(bucket []byte, keys [][]byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
+	return s.db(bucket).GetMulti(bucket, keys, sample)
+}
+
+func (s *SplitDB) Delete(bucket, key []byte) error {
+	return s.db(bucket).Delete(bucket, key)
+}
+
+func (s *SplitDB) ListAllKeys(bucket []byte) ([][]byte, error) {
+	return s.db(bucket).ListAllKeys(bucket)
+}
+
+func (s *SplitDB) ForEachKey(bucket, start, limit []byte, fn func(key []byte) error) error {
+	return s.db(bucket).ForEachKey(bucket, start, limit, fn)
+}
+
+func (s *SplitDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	return s.db(bucket).GetAll(bucket, sample)
+}
+
+func (s *SplitDB) Clear(bucket []byte) error {
+	return s.db(bucket).Clear(bucket)
+}
+
+// ListAllBuckets lists the buckets of both databases.  It fails if either
+// database can't list its buckets.
+func (s *SplitDB) ListAllBuckets() ([][]byte, error) {
+	buckets, err := s.blocks.ListAllBuckets()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.entries.ListAllBuckets()
+	if err != nil {
+		return nil, err
+	}
+	return append(buckets, entries...), nil
+}
+
+func (s *SplitDB) DoesKeyExist(bucket, key []byte) (bool, error) {
+	return s.db(bucket).DoesKeyExist(bucket, key)
+}
+
+func (s *SplitDB) BucketStats(bucket []byte) (interfaces.BucketStats, error) {
+	return s.db(bucket).BucketStats(bucket)
+}
+
+func (s *SplitDB) Trim() {
+	s.blocks.Trim()
+	s.entries.Trim()
+}
+
+// Compact compacts both databases, and returns the first error.
+func (s *SplitDB) Compact() error {
+	err := s.blocks.Compact()
+	if err2 := s.entries.Compact(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// SetBulkLoad sets both databases, and returns the first error.
+func (s *SplitDB) SetBulkLoad(bulk bool) error {
+	err := s.blocks.SetBulkLoad(bulk)
+	if err2 := s.entries.SetBulkLoad(bulk); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// Close closes both databases, and returns the first error.
+func (s *SplitDB) Close() error {
+	err := s.blocks.Close()
+	if err2 := s.entries.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package splitDB_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/mapdb"
+	. "github.com/FactomProject/factomd/database/splitDB"
+)
+
+func TestSplitDB(t *testing.T) {
+	blocks := new(mapdb.MapDB)
+	blocks.Init(nil)
+	entries := new(mapdb.MapDB)
+	entries.Init(nil)
+
+	entryBucket := []byte("entries")
+	blockBucket := []byte("blocks")
+	db := NewSplitDB(blocks, entries, func(bucket []byte) bool {
+		return bytes.Equal(bucket, entryBucket)
+	})
+
+	entry := primitives.RandomHash()
+	block := primitives.RandomHash()
+	err := db.PutInBatch([]interfaces.Record{
+		{Bucket: entryBucket, Key: []byte("key"), Data: entry},
+		{Bucket: blockBucket, Key: []byte("key"), Data: block},
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// Each record is only in its own database
+	for _, c := range []struct {
+		db     interfaces.IDatabase
+		bucket []byte
+		in     bool
+	}{
+		{entries, entryBucket, true},
+		{entries, blockBucket, false},
+		{blocks, blockBucket, true},
+		{blocks, entryBucket, false},
+	} {
+		exists, err := c.db.DoesKeyExist(c.bucket, []byte("key"))
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+		if exists != c.in {
+			t.Errorf("Bucket %s: exists is %v, expected %v", c.bucket, exists, c.in)
+		}
+	}
+
+	got, err := db.Get(entryBucket, []byte("key"), new(primitives.Hash))
+	if err != nil || got == nil || !got.(interfaces.IHash).IsSameAs(entry) {
+		t.Errorf("Got entry %v, %v, expected %v", got, err, entry)
+	}
+	got, err = db.Get(blockBucket, []byte("key"), new(primitives.Hash))
+	if err != nil || got == nil || !got.(interfaces.IHash).IsSameAs(block) {
+		t.Errorf("Got block %v, %v, expected %v", got, err, block)
+	}
+
+	buckets, err := db.ListAllBuckets()
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(buckets) != 2 {
+		t.Errorf("Listed %d buckets, expected 2", len(buckets))
+	}
+
+	// Deletes in a batch go to the right database too
+	err = db.PutInBatch([]interfaces.Record{{Bucket: entryBucket, Key: []byte("key")}})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	exists, _ := entries.DoesKeyExist(entryBucket, []byte("key"))
+	if exists {
+		t.Errorf("Entry was not deleted")
+	}
+}
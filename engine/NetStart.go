@@ -10,19 +10,26 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"runtime"
 	"time"
 
 	"math"
 
 	"bufio"
 
+	"github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/controlPanel"
+	"github.com/FactomProject/factomd/database/cacheDB"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/factomd/grpcapi"
 	"github.com/FactomProject/factomd/p2p"
 	"github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/telemetry"
 	"github.com/FactomProject/factomd/util"
 	"github.com/FactomProject/factomd/wsapi"
 )
@@ -48,6 +55,7 @@ func NetStart(s *state.State) {
 	waitEntriesPtr := flag.Bool("waitentries", false, "Wait for Entries to be validated prior to execution of messages")
 	listenToPtr := flag.Int("node", 0, "Node Number the simulator will set as the focus")
 	cntPtr := flag.Int("count", 1, "The number of nodes to generate")
+	flag.IntVar(cntPtr, "sim-count", 1, "Same as count")
 	netPtr := flag.String("net", "tree", "The default algorithm to build the network connections")
 	fnetPtr := flag.String("fnet", "", "Read the given file to build the network connections")
 	dropPtr := flag.Int("drop", 0, "Number of messages to drop out of every thousand")
@@ -56,7 +64,14 @@ func NetStart(s *state.State) {
 	followerPtr := flag.Bool("follower", false, "If true, force node to be a follower.  Only used when replaying a journal.")
 	leaderPtr := flag.Bool("leader", true, "If true, force node to be a leader.  Only used when replaying a journal.")
 	dbPtr := flag.String("db", "", "Override the Database in the Config file and use this Database implementation")
+	dbPathPtr := flag.String("dbpath", "", "Override the LdbPath and BoltDBPath in the Config file and keep the database in this directory")
+	dataDirPtr := flag.String("datadir", "", "Keep factomd.conf, and the database, peers, logs, checkpoints and keys of each network, in this directory, in place of ~/.factom/m2")
 	cloneDBPtr := flag.String("clonedb", "", "Override the main node and use this database for the clones in a Network.")
+	checkDBPtr := flag.Bool("checkdb", false, "Check the integrity of the blockchain in the database and exit")
+	reindexPtr := flag.Bool("reindex", false, "Rebuild the database indexes from the saved blocks and exit")
+	migrateDBPtr := flag.String("migratedb", "", "Copy the database into another backend and exit, e.g. \"from=bolt to=ldb\"")
+	exportDBPtr := flag.String("exportdb", "", "Write the database to the given snapshot file and exit")
+	importDBPtr := flag.String("importdb", "", "Load the given snapshot file into an empty database and exit")
 	portOverridePtr := flag.Int("port", 0, "Address to serve WSAPI on")
 	networkNamePtr := flag.String("network", "", "Network to join: MAIN, TEST or LOCAL")
 	networkPortOverridePtr := flag.Int("networkPort", 0, "Address for p2p network to listen on.")
@@ -69,9 +84,11 @@ func NetStart(s *state.State) {
 	netdebugPtr := flag.Int("netdebug", 0, "0-5: 0 = quiet, >0 = increasing levels of logging")
 	exclusivePtr := flag.Bool("exclusive", false, "If true, we only dial out to special/trusted peers.")
 	prefixNodePtr := flag.String("prefix", "", "Prefix the Factom Node Names with this value; used to create leaderless networks.")
+	nodeNamePtr := flag.String("nodename", "", "Name of the main node, in place of FNode0. Simulated nodes keep their own names.")
 	rotatePtr := flag.Bool("rotate", false, "If true, responsiblity is owned by one leader, and rotated over the leaders.")
 	timeOffsetPtr := flag.Int("timedelta", 0, "Maximum timeDelta in milliseconds to offset each node.  Simulates deltas in system clocks over a network.")
 	keepMismatchPtr := flag.Bool("keepmismatch", false, "If true, do not discard DBStates even when a majority of DBSignatures have a different hash")
+	checkInvariantsPtr := flag.Bool("checkinvariants", false, "If true, check balances, acknowledgements and the saved blocks after each block, and crash with a dump if they are wrong")
 	startDelayPtr := flag.Int("startdelay", 10, "Delay to start processing messages, in seconds")
 	deadlinePtr := flag.Int("deadline", 1000, "Timeout Delay in milliseconds used on Reads and Writes to the network comm")
 	customNetPtr := flag.String("customnet", "", "This string specifies a custom blockchain network ID.")
@@ -100,7 +117,15 @@ func NetStart(s *state.State) {
 	follower := *followerPtr
 	leader := *leaderPtr
 	db := *dbPtr
+	dbPath := *dbPathPtr
+	util.DataDir = *dataDirPtr
+	util.CustomNet = *customNetPtr
 	cloneDB := *cloneDBPtr
+	migrateDB := *migrateDBPtr
+	checkDB := *checkDBPtr
+	reindex := *reindexPtr
+	exportDB := *exportDBPtr
+	importDB := *importDBPtr
 	portOverride := *portOverridePtr
 	peers := *peersPtr
 	networkName := *networkNamePtr
@@ -113,9 +138,11 @@ func NetStart(s *state.State) {
 	netdebug := *netdebugPtr
 	exclusive := *exclusivePtr
 	prefix := *prefixNodePtr
+	nodeName := *nodeNamePtr
 	rotate := *rotatePtr
 	timeOffset := *timeOffsetPtr
 	keepMismatch := *keepMismatchPtr
+	checkInvariants := *checkInvariantsPtr
 	startDelay := int64(*startDelayPtr)
 	deadline := *deadlinePtr
 	customNet := primitives.Sha([]byte(*customNetPtr)).Bytes()[:4]
@@ -132,7 +159,21 @@ func NetStart(s *state.State) {
 	s.AddPrefix(prefix)
 	FactomConfigFilename := util.GetConfigFilename("m2")
 	fmt.Println(fmt.Sprintf("factom config: %s", FactomConfigFilename))
+	// On the first run, write the defaults to factomd.conf for editing
+	firstRun, err := util.WriteDefaultConfig(FactomConfigFilename)
+	if err != nil {
+		fmt.Printf("Cannot write the default config to %s: %v; running with the defaults\n", FactomConfigFilename, err)
+	} else if firstRun {
+		fmt.Printf("No config found; wrote the defaults to %s\n", FactomConfigFilename)
+	}
 	s.LoadConfig(FactomConfigFilename, networkName)
+	if problems := s.GetCfg().(*util.FactomdConfig).Validate(s.Network); len(problems) > 0 {
+		fmt.Printf("%d problems with %s:\n", len(problems), FactomConfigFilename)
+		for _, p := range problems {
+			fmt.Printf("  %v\n", p)
+		}
+		os.Exit(1)
+	}
 	s.OneLeader = rotate
 	s.TimeOffset = primitives.NewTimestampFromMilliseconds(uint64(timeOffset))
 	s.StartDelayLimit = startDelay * 1000
@@ -143,6 +184,26 @@ func NetStart(s *state.State) {
 		s.LogPath = "stdout"
 	}
 
+	if dbPath != "" {
+		s.LdbPath = dbPath
+		s.BoltDBPath = dbPath
+	}
+	if nodeName != "" {
+		s.FactomNodeName = nodeName
+	}
+	if util.DataDir != "" {
+		if err := util.GetDataPaths(s.Network).Create(); err != nil {
+			fmt.Printf("Cannot make the directories of %s: %v\n", util.DataDir, err)
+			os.Exit(1)
+		}
+	} else if firstRun {
+		for _, dir := range []string{s.LogPath, s.LdbPath, s.BoltDBPath} {
+			if dir != "stdout" {
+				os.MkdirAll(dir, 0755)
+			}
+		}
+	}
+
 	// Set the wait for entries flag
 	s.WaitForEntries = waitEntries
 
@@ -212,6 +273,7 @@ func NetStart(s *state.State) {
 	AddInterruptHandler(func() {
 		fmt.Print("<Break>\n")
 		fmt.Print("Gracefully shutting down the server...\n")
+		stopService()
 		for _, fnode := range fnodes {
 			fmt.Print("Shutting Down: ", fnode.State.FactomNodeName, "\r\n")
 			fnode.State.ShutdownChan <- 0
@@ -223,8 +285,10 @@ func NetStart(s *state.State) {
 		}
 		fmt.Print("Waiting...\r\n")
 		time.Sleep(3 * time.Second)
+		serviceStopped()
 		os.Exit(0)
 	})
+	AddLogLevelHandler(FactomConfigFilename)
 
 	if journal != "" {
 		if s.DBType != "Map" {
@@ -241,26 +305,83 @@ func NetStart(s *state.State) {
 	}
 
 	s.KeepMismatch = keepMismatch
+	s.CheckInvariants = checkInvariants
+	// The custom network's ID is needed to open its part of the database
+	s.CustomNetworkID = customNet
 
 	if len(db) > 0 {
-		s.DBType = db
-	} else {
-		db = s.DBType
+		s.DBType = state.NormalizeDBType(db)
 	}
+	db = s.DBType
 
 	if len(cloneDB) > 0 {
-		s.CloneDBType = cloneDB
+		s.CloneDBType = state.NormalizeDBType(cloneDB)
 	} else {
 		s.CloneDBType = db
 	}
 
+	if len(migrateDB) > 0 {
+		from, to, err := state.ParseMigrateDB(migrateDB)
+		if err == nil {
+			err = s.MigrateDB(from, to, os.Stdout)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(exportDB) > 0 {
+		err := s.ExportDB(exportDB, os.Stdout)
+		if err != nil {
+			fmt.Println("Database export failed:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(importDB) > 0 {
+		err := s.ImportDB(importDB, os.Stdout)
+		if err != nil {
+			fmt.Println("Database import failed:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if reindex {
+		err := s.Reindex(os.Stdout)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if checkDB {
+		err := s.CheckDB(os.Stdout)
+		if err != nil {
+			fmt.Println("Database check failed:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	pnet := net
 	if len(fnet) > 0 {
 		pnet = fnet
 		net = "file"
 	}
 
-	go StartProfiler(*memProfileRate)
+	if s.ProfilerEnabled {
+		go StartProfiler(*memProfileRate, s.ProfilerBlockRate)
+	} else {
+		runtime.MemProfileRate = *memProfileRate
+	}
+
+	// The anchor records saved are looked up in the chains they claim
+	databaseOverlay.AnchorVerifier = anchor.NewVerifier(s.AnchorConfig)
 
 	s.AddPrefix(prefix)
 	s.SetOut(false)
@@ -294,6 +415,8 @@ func NetStart(s *state.State) {
 	os.Stderr.WriteString(fmt.Sprintf("%20s %v\n", "rotate", rotate))
 	os.Stderr.WriteString(fmt.Sprintf("%20s %v\n", "timeOffset", timeOffset))
 	os.Stderr.WriteString(fmt.Sprintf("%20s %v\n", "keepMismatch", keepMismatch))
+	os.Stderr.WriteString(fmt.Sprintf("%20s %v\n", "checkInvariants", checkInvariants))
+	os.Stderr.WriteString(fmt.Sprintf("%20s %v\n", "followerOnly", s.FollowerOnly))
 	os.Stderr.WriteString(fmt.Sprintf("%20s %v\n", "startDelay", startDelay))
 	os.Stderr.WriteString(fmt.Sprintf("%20s %v\n", "Network", s.Network))
 	os.Stderr.WriteString(fmt.Sprintf("%20s %x\n", "customnet", customNet))
@@ -358,12 +481,23 @@ func NetStart(s *state.State) {
 		seedURL = s.LocalSeedURL
 		networkPort = s.LocalNetworkPort
 		specialPeers = s.LocalSpecialPeers
+		// A custom network of factomd.conf has p2p settings of its own
+		if n := constants.LookupNetwork(*customNetPtr); n != nil && n.Number == constants.NETWORK_CUSTOM && n.Name != "CUSTOM" {
+			seedURL = n.SeedURL
+			networkPort = n.P2PPort
+			specialPeers = n.SpecialPeers
+		}
 	default:
 		panic("Invalid Network choice in Config File or command line. Choose MAIN, TEST, LOCAL, or CUSTOM")
 	}
 
 	connectionMetricsChannel := make(chan interface{}, p2p.StandardChannelSize)
 	p2p.NetworkDeadline = time.Duration(deadline) * time.Millisecond
+	if len(s.NetworkFaults) > 0 {
+		seed := time.Now().UnixNano()
+		fmt.Printf("Injecting network faults for testing, seed %d\n", seed)
+		p2p.Faults = p2p.NewFaultInjector(s.NetworkFaults, seed)
+	}
 
 	if enableNet {
 		if 0 < networkPortOverride {
@@ -379,6 +513,7 @@ func NetStart(s *state.State) {
 			ConnectionMetricsChannel: connectionMetricsChannel,
 			LogPath:                  s.LogPath,
 			LogLevel:                 s.LogLevel,
+			LightServer:              &LightServer{State: fnodes[0].State},
 		}
 		p2pNetwork = new(p2p.Controller).Init(ci)
 		fnodes[0].State.NetworkControler = p2pNetwork
@@ -398,7 +533,7 @@ func NetStart(s *state.State) {
 		p2pProxy.StartProxy()
 		// Command line peers lets us manually set special peers
 		p2pNetwork.DialSpecialPeersString(peers)
-		go networkHousekeeping() // This goroutine executes once a second to keep the proxy apprised of the network status.
+		go networkHousekeeping(fnodes[0].State) // This goroutine executes once a second to keep the proxy apprised of the network status.
 	}
 
 	switch net {
@@ -516,6 +651,36 @@ func NetStart(s *state.State) {
 
 	// Start the webserver
 	go wsapi.Start(fnodes[0].State)
+	if fnodes[0].State.GRPCPort != 0 {
+		go grpcapi.Start(fnodes[0].State, fnodes[0].State.GRPCPort)
+	}
+	go superviseService(fnodes[0].State)
+	if fnodes[0].State.RichListEnabled {
+		go fnodes[0].State.RunSupplyStats()
+	}
+	if fnodes[0].State.AnchorConfig.EthereumEnabled {
+		scheduler := anchor.NewScheduler("Ethereum", anchor.NewEthereumAnchorer(fnodes[0].State.AnchorConfig))
+		write, err := NewAnchorRecordWriter(fnodes[0].State, fnodes[0].State.AnchorConfig)
+		if err != nil {
+			fmt.Printf("Ethereum anchors are not written to the anchor chain: %v\n", err)
+		}
+		scheduler.Write = write
+		go scheduler.Run(fnodes[0].State)
+	}
+	if fnodes[0].State.TelemetryConfig.Enabled {
+		peers := func() int {
+			if p2pNetwork == nil {
+				return 0
+			}
+			return p2pNetwork.GetNumberConnections()
+		}
+		reporter, err := telemetry.NewReporter(fnodes[0].State.TelemetryConfig, fnodes[0].State, peers)
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			go reporter.Run()
+		}
+	}
 
 	// Start prometheus on port
 	launchPrometheus(9876)
@@ -523,6 +688,7 @@ func NetStart(s *state.State) {
 	state.RegisterPrometheus()
 	p2p.RegisterPrometheus()
 	leveldb.RegisterPrometheus()
+	cacheDB.RegisterPrometheus()
 	RegisterPrometheus()
 
 	go controlPanel.ServeControlPanel(fnodes[0].State.ControlPanelChannel, fnodes[0].State, connectionMetricsChannel, p2pNetwork, Build)
@@ -608,9 +774,11 @@ func setupFirstAuthority(s *state.State) {
 	s.Authorities = append(s.Authorities, &auth)
 }
 
-func networkHousekeeping() {
+func networkHousekeeping(s *state.State) {
 	for {
 		time.Sleep(1 * time.Second)
-		p2pProxy.SetWeight(p2pNetwork.GetNumberConnections())
+		peers := p2pNetwork.GetNumberConnections()
+		p2pProxy.SetWeight(peers)
+		s.CheckIsolation(peers)
 	}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/log"
+	"github.com/FactomProject/factomd/state"
 )
 
 var _ = log.Printf
@@ -35,7 +36,7 @@ func Peers(fnode *FactomNode) {
 				}
 				repeatHash := msg.GetRepeatHash()
 				if repeatHash == nil {
-					fmt.Println("dddd ERROR!", msg.String())
+					fnode.State.Log().WithField(log.FieldMsgType, messages.MessageName(msg.Type())).Errorf("API message without a repeat hash: %s", msg.String())
 					break
 				}
 				cnt++
@@ -77,10 +78,11 @@ func Peers(fnode *FactomNode) {
 				}
 
 				if err != nil {
-					fmt.Println("ERROR recieving message on", fnode.State.FactomNodeName+":", err)
+					fnode.State.Log().Errorf("Receiving a message from the network: %v", err)
 					break
 				}
 				msg.SetOrigin(i + 1)
+				fnode.State.TraceMsg(msg, state.TraceReceived)
 				if fnode.State.Replay.IsTSValid_(constants.NETWORK_REPLAY, msg.GetRepeatHash().Fixed(),
 					msg.GetTimestamp(),
 					fnode.State.GetTimestamp()) {
@@ -134,8 +136,8 @@ func NetworkOutputs(fnode *FactomNode) {
 				if msg.GetRepeatHash() == nil {
 					continue
 				}
-				_, ok := msg.(*messages.Ack)
-				if ok {
+				switch msg.(type) {
+				case *messages.Ack, *messages.AckBatch:
 					fnode.State.Replay.IsTSValid_(
 						constants.NETWORK_REPLAY,
 						msg.GetRepeatHash().Fixed(),
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"syscall"
 )
 
 // interruptChannel is used to receive SIGINT (Ctrl+C) signals.
@@ -21,6 +22,10 @@ var interruptChannel chan os.Signal
 // to be invoked on SIGINT (Ctrl+C) signals.
 var addHandlerChannel = make(chan func())
 
+// shutdownChannel is used to shut down as on a SIGINT, when the service
+// manager stops factomd.
+var shutdownChannel = make(chan struct{}, 1)
+
 // mainInterruptHandler listens for SIGINT (Ctrl+C) signals on the
 // interruptChannel and invokes the registered interruptCallbacks accordingly.
 // It also listens for callback registration.  It must be run as a goroutine.
@@ -35,23 +40,34 @@ func mainInterruptHandler() {
 	// immediately.
 	var isShutdown bool
 
+	shutdown := func() {
+		// Run handlers in LIFO order.
+		for i := range interruptCallbacks {
+			idx := len(interruptCallbacks) - 1 - i
+			callback := interruptCallbacks[idx]
+			callback()
+		}
+	}
+
 	for {
 		select {
-		case <-interruptChannel:
+		case sig := <-interruptChannel:
 			// Ignore more than one shutdown signal.
 			if isShutdown {
 				fmt.Println("Ctrl+C Already being processed!")
 				continue
 			}
 			isShutdown = true
-			fmt.Println("Received SIGINT (Ctrl+C).  Shutting down...")
+			fmt.Printf("Received %v.  Shutting down...\n", sig)
+			shutdown()
 
-			// Run handlers in LIFO order.
-			for i := range interruptCallbacks {
-				idx := len(interruptCallbacks) - 1 - i
-				callback := interruptCallbacks[idx]
-				callback()
+		case <-shutdownChannel:
+			if isShutdown {
+				continue
 			}
+			isShutdown = true
+			fmt.Println("Stopped by the service manager.  Shutting down...")
+			shutdown()
 
 		case handler := <-addHandlerChannel:
 			// The shutdown signal has already been received, so
@@ -72,9 +88,22 @@ func AddInterruptHandler(handler func()) {
 	// all other callbacks and exits if not already done.
 	if interruptChannel == nil {
 		interruptChannel = make(chan os.Signal, 1)
-		signal.Notify(interruptChannel, os.Interrupt)
+		// systemd and other service managers stop factomd with a SIGTERM
+		signal.Notify(interruptChannel, os.Interrupt, syscall.SIGTERM)
 		go mainInterruptHandler()
 	}
 
 	addHandlerChannel <- handler
 }
+
+// requestShutdown shuts factomd down as a SIGINT (Ctrl+C) does, running the
+// interrupt handlers.
+func requestShutdown() {
+	if interruptChannel == nil {
+		os.Exit(0)
+	}
+	select {
+	case shutdownChannel <- struct{}{}:
+	default:
+	}
+}
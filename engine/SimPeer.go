@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/p2p"
 	"math/rand"
 	"time"
 )
@@ -121,12 +122,22 @@ func (f *SimPeer) Send(msg interfaces.IMsg) error {
 		return err
 	}
 	if len(f.BroadcastOut) < 9000 {
-		packet := SimPacket{data: data, sent: time.Now().UnixNano() / 1000000}
-		f.BroadcastOut <- &packet
+		if p2p.Faults != nil {
+			// The faults of the peer are those of the node sending, as they are
+			// those of the remote address over the network
+			p2p.Faults.Inject(f.FromName, data, f.sendPacket)
+		} else {
+			f.sendPacket(data)
+		}
 	}
 	return nil
 }
 
+func (f *SimPeer) sendPacket(data []byte) {
+	packet := SimPacket{data: data, sent: time.Now().UnixNano() / 1000000}
+	f.BroadcastOut <- &packet
+}
+
 // Non-blocking return value from channel.
 func (f *SimPeer) Recieve() (interfaces.IMsg, error) {
 	if f.Delayed == nil {
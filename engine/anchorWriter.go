@@ -0,0 +1,79 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/util"
+)
+
+// NewAnchorRecordWriter returns the writer of the records of confirmed
+// anchors to the anchor chain.  Each is an entry signed by the anchor key
+// AnchorSigningKey, committed and paid for by EntryCreditKey, and sent
+// through the node's API queue like those of a client.  It returns nil if
+// either key isn't set.
+func NewAnchorRecordWriter(state interfaces.IState, c anchor.Config) (anchor.RecordWriter, error) {
+	if c.AnchorSigningKey == "" || c.EntryCreditKey == "" {
+		return nil, nil
+	}
+	signer, err := primitives.NewPrivateKeyFromHex(c.AnchorSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("AnchorSigningKey: %v", err)
+	}
+	ecKey, err := primitives.HumanReadableECPrivateKeyToPrivateKey(c.EntryCreditKey)
+	if err != nil {
+		return nil, fmt.Errorf("EntryCreditKey: %v", err)
+	}
+	chainID, err := primitives.HexToHash(databaseOverlay.AnchorBlockID)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ar *anchor.AnchorRecord) error {
+		content, err := ar.MarshalAndSign(signer)
+		if err != nil {
+			return err
+		}
+		entry := entryBlock.NewEntry()
+		entry.ChainID = chainID
+		entry.Content = primitives.ByteSlice{Bytes: content}
+		data, err := entry.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		commit := entryCreditBlock.NewCommitEntry()
+		var milli [8]byte
+		binary.BigEndian.PutUint64(milli[:], uint64(state.GetTimestamp().GetTimeMilli()))
+		copy(commit.MilliTime[:], milli[2:])
+		commit.EntryHash = entry.GetHash()
+		if commit.Credits, err = util.EntryCost(data); err != nil {
+			return err
+		}
+		if err := commit.Sign(ecKey); err != nil {
+			return err
+		}
+
+		commitMsg := new(messages.CommitEntryMsg)
+		commitMsg.CommitEntry = commit
+		state.APIQueue() <- commitMsg
+		state.IncECommits()
+
+		reveal := new(messages.RevealEntryMsg)
+		reveal.Entry = entry
+		reveal.Timestamp = state.GetTimestamp()
+		state.APIQueue() <- reveal
+		return nil
+	}, nil
+}
@@ -0,0 +1,53 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/engine"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestAnchorRecordWriter(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	write, err := NewAnchorRecordWriter(state, anchor.Config{})
+	if err != nil || write != nil {
+		t.Errorf("A writer without keys")
+	}
+
+	key := testHelper.NewPrimitivesPrivateKey(0)
+	ecKey, err := primitives.PrivateKeyStringToHumanReadableECPrivateKey(testHelper.NewPrimitivesPrivateKey(1).PrivateKeyString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	write, err = NewAnchorRecordWriter(state, anchor.Config{AnchorSigningKey: key.PrivateKeyString(), EntryCreditKey: ecKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ar := &anchor.AnchorRecord{AnchorRecordVer: 1, DBHeight: 3, KeyMR: primitives.NewZeroHash().String(), RecordHeight: 3,
+		Ethereum: &anchor.EthereumStruct{TXID: "0xee", BlockHeight: 9}}
+	if err := write(ar); err != nil {
+		t.Fatal(err)
+	}
+
+	commit := (<-state.APIQueue()).(*messages.CommitEntryMsg)
+	reveal := (<-state.APIQueue()).(*messages.RevealEntryMsg)
+	if err := commit.CommitEntry.ValidateSignatures(); err != nil {
+		t.Errorf("Bad commit signature: %v", err)
+	}
+	if !commit.CommitEntry.EntryHash.IsSameAs(reveal.Entry.GetHash()) {
+		t.Errorf("The commit is of another entry")
+	}
+	written, ok, err := anchor.UnmarshalAndValidateAnchorEntryAnyVersion(reveal.Entry, []interfaces.Verifier{key.Pub})
+	if err != nil || !ok {
+		t.Fatalf("Anchor entry not valid: %v", err)
+	}
+	if written.DBHeight != 3 || written.Ethereum == nil || written.Ethereum.TXID != "0xee" {
+		t.Errorf("Wrong record written %v", written)
+	}
+}
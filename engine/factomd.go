@@ -18,9 +18,9 @@ import (
 
 var _ = fmt.Print
 
-// winServiceMain is only invoked on Windows.  It detects when btcd is running
+// winServiceMain is only invoked on Windows.  It detects when factomd is running
 // as a service and reacts accordingly.
-//var winServiceMain func() (bool, error)
+var winServiceMain func() (bool, error)
 
 // Build sets the factomd build id using git's SHA
 // $ go install -ldflags "-X github.com/FactomProject/factomd/engine.Build=`git rev-parse HEAD`"
@@ -29,6 +29,23 @@ var _ = fmt.Print
 var Build string
 
 func Factomd() {
+	RunSubcommand(os.Args)
+
+	// Under the Windows service manager, the service runs the node
+	if winServiceMain != nil {
+		isService, err := winServiceMain()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if isService {
+			os.Exit(0)
+		}
+	}
+	factomdMain()
+}
+
+func factomdMain() {
 	log.Print("//////////////////////// Copyright 2017 Factom Foundation")
 	log.Print("//////////////////////// Use of this source code is governed by the MIT")
 	log.Print("//////////////////////// license that can be found in the LICENSE file.")
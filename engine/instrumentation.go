@@ -20,6 +20,11 @@ var (
 		Name: "factomd_state_broadcast_in_drop_total",
 		Help: "How many messages are dropped due to full queues",
 	})
+
+	BroadCastInSizeDrop = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_state_broadcast_in_size_drop_total",
+		Help: "How many messages are dropped for being over the size limit of their type",
+	})
 )
 
 var registered = false
@@ -35,4 +40,5 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(RepeatMsgs)
 	prometheus.MustRegister(BroadInCastQueue)
 	prometheus.MustRegister(BroadCastInQueueDrop)
+	prometheus.MustRegister(BroadCastInSizeDrop)
 }
@@ -0,0 +1,71 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/p2p"
+	"github.com/FactomProject/factomd/receipts"
+)
+
+// LightServer answers the requests of light clients from the database of a
+// node.
+type LightServer struct {
+	State interfaces.IState
+}
+
+var _ p2p.LightServer = (*LightServer)(nil)
+
+func (l *LightServer) DirectoryBlockHeaders(start uint32, count uint32) ([][]byte, error) {
+	dbase := l.State.GetAndLockDB()
+	defer l.State.UnlockDB()
+
+	headers := [][]byte{}
+	for height := start; height-start < count; height++ {
+		dblock, err := dbase.FetchDBlockByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		if dblock == nil {
+			break
+		}
+		header, err := dblock.GetHeader().MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+func (l *LightServer) ChainHead(chainID []byte) ([]byte, error) {
+	dbase := l.State.GetAndLockDB()
+	defer l.State.UnlockDB()
+
+	head, err := dbase.FetchHeadIndexByChainID(primitives.NewHash(chainID))
+	if err != nil || head == nil {
+		return nil, err
+	}
+	return head.Bytes(), nil
+}
+
+// EntryProof returns the JSON of the minimal receipt of the entry, which
+// holds the directory block key merkle root the client checks it against.
+func (l *LightServer) EntryProof(entryHash []byte) ([]byte, error) {
+	dbase := l.State.GetAndLockDB()
+	defer l.State.UnlockDB()
+
+	hash := primitives.NewHash(entryHash)
+	eblock, err := dbase.FetchIncludedIn(hash)
+	if err != nil || eblock == nil {
+		return nil, err
+	}
+	receipt, err := receipts.CreateMinimalReceipt(dbase, hash)
+	if err != nil {
+		return nil, err
+	}
+	return receipt.JSONByte()
+}
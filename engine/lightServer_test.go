@@ -0,0 +1,61 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	. "github.com/FactomProject/factomd/engine"
+	"github.com/FactomProject/factomd/receipts"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestLightServer(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	l := &LightServer{State: state}
+
+	headers, err := l.DirectoryBlockHeaders(1, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != testHelper.BlockCount-1 {
+		t.Fatalf("%d headers, expected %d", len(headers), testHelper.BlockCount-1)
+	}
+	header := directoryBlock.NewDBlockHeader()
+	if err := header.UnmarshalBinary(headers[0]); err != nil {
+		t.Fatal(err)
+	}
+	if header.GetDBHeight() != 1 {
+		t.Errorf("First header is at %d, expected 1", header.GetDBHeight())
+	}
+
+	blocks := testHelper.CreateFullTestBlockSet()
+	eblock := blocks[0].EBlock
+	head, err := l.ChainHead(eblock.GetChainID().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head == nil {
+		t.Errorf("No head of chain %v", eblock.GetChainID())
+	}
+	head, err = l.ChainHead(make([]byte, 32))
+	if err != nil || head != nil {
+		t.Errorf("Head %x of an unknown chain: %v", head, err)
+	}
+
+	entry := blocks[0].Entries[0].DatabasePrimaryIndex()
+	proof, err := l.EntryProof(entry.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := receipts.DecodeReceiptString(string(proof))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := receipt.Validate(); err != nil {
+		t.Error(err)
+	}
+	proof, err = l.EntryProof(make([]byte, 32))
+	if err != nil || proof != nil {
+		t.Errorf("Proof %s of an unknown entry: %v", proof, err)
+	}
+}
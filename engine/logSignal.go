@@ -0,0 +1,41 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package engine
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/FactomProject/factomd/log"
+	"github.com/FactomProject/factomd/util"
+)
+
+// AddLogLevelHandler makes a SIGUSR1 set the console log format and package
+// levels again from the [log] section of the config file, so an operator can
+// turn on the debug lines of a package on a running node:
+//
+//	kill -USR1 <pid>
+func AddLogLevelHandler(filename string) {
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	go func() {
+		for range usr1 {
+			reloadLogLevels(filename)
+		}
+	}()
+}
+
+func reloadLogLevels(filename string) {
+	cfg := util.ReadConfig(filename)
+	log.SetFormat(cfg.Log.LogFormat)
+	for pkg, level := range cfg.PackageLogLevels() {
+		log.SetPackageLevel(pkg, level)
+	}
+	log.ForPackage("engine").Noticef("Received SIGUSR1, the log levels are now %v", log.PackageLevels())
+}
@@ -0,0 +1,10 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package engine
+
+// AddLogLevelHandler does nothing, as there is no SIGUSR1 on Windows.  The
+// log levels can still be changed with the set-log-level debug API method.
+func AddLogLevelHandler(filename string) {
+}
@@ -0,0 +1,185 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// The ports of the nodes of a new network, inside their containers
+var (
+	newNetworkAPIPort          = constants.LookupNetwork("CUSTOM").APIPort
+	newNetworkControlPanelPort = 8090
+	newNetworkP2PPort          = constants.LookupNetwork("CUSTOM").P2PPort // In LocalNetworkPort, as CUSTOM networks use it
+)
+
+// NetworkSpec is the genesis specification of a private network, written
+// to genesis.json by newnetwork.  It holds no private keys; those are only
+// in the factomd.conf of each node.
+type NetworkSpec struct {
+	Network           string // Always CUSTOM
+	CustomNet         string // The -customnet name all the nodes run with
+	NetworkID         string // The first 4 bytes of the sha256 of CustomNet
+	BootstrapIdentity string // The identity of the authority of the genesis block
+	BootstrapKey      string // The public key that signs the first blocks
+	Nodes             []NetworkNode
+}
+
+// NetworkNode is a node of a private network.
+type NetworkNode struct {
+	Name            string
+	NodeMode        string // SERVER for the bootstrap authority, FULL for the others
+	IdentityChainID string
+	PublicKey       string
+	privateKey      string
+}
+
+// NewNetwork writes the genesis specification, the keys and config of each
+// node, and a docker-compose.yml that runs them, of a new CUSTOM network.
+// The first node is the authority of the genesis block.  The others start
+// as followers, with keys of their own; each can be promoted once an
+// identity for it is registered in the chain.
+func NewNetwork(args []string) error {
+	flags := flag.NewFlagSet("newnetwork", flag.ExitOnError)
+	name := flags.String("name", "private", "Name of the network, passed to each node as -customnet")
+	count := flags.Int("nodes", 3, "Number of nodes")
+	dir := flags.String("dir", "", "Directory to write the network to; the name of the network if empty")
+	image := flags.String("image", "factominc/factomd", "Docker image of factomd for docker-compose.yml")
+	flags.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("The network needs a -name")
+	}
+	if *count < 1 {
+		return fmt.Errorf("The network needs at least 1 node")
+	}
+	if *dir == "" {
+		*dir = *name
+	}
+	if entries, err := ioutil.ReadDir(*dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", *dir)
+	}
+
+	spec, err := NewNetworkSpec(*name, *count)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(*dir, "genesis.json"), data, 0644); err != nil {
+		return err
+	}
+	for _, node := range spec.Nodes {
+		if err := os.MkdirAll(filepath.Join(*dir, node.Name), 0700); err != nil {
+			return err
+		}
+		// The config holds the private key of the node
+		err := ioutil.WriteFile(filepath.Join(*dir, node.Name, "factomd.conf"), []byte(spec.NodeConfig(node)), 0600)
+		if err != nil {
+			return err
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(*dir, "docker-compose.yml"), []byte(spec.DockerCompose(*image)), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote the %d node network %q (network ID %s) to %s\n", len(spec.Nodes), spec.CustomNet, spec.NetworkID, *dir)
+	fmt.Printf("Start it with: cd %s && docker-compose up\n", *dir)
+	fmt.Printf("The API of %s is on localhost:%d\n", spec.Nodes[0].Name, newNetworkAPIPort+10000)
+	return nil
+}
+
+// NewNetworkSpec returns the specification of a new network, with fresh keys
+// for each node.
+func NewNetworkSpec(name string, count int) (*NetworkSpec, error) {
+	spec := new(NetworkSpec)
+	spec.Network = "CUSTOM"
+	spec.CustomNet = name
+	spec.NetworkID = fmt.Sprintf("%x", primitives.Sha([]byte(name)).Bytes()[:4])
+	for i := 0; i < count; i++ {
+		key := new(primitives.PrivateKey)
+		if err := key.GenerateKey(); err != nil {
+			return nil, err
+		}
+		node := NetworkNode{Name: fmt.Sprintf("node%d", i), NodeMode: "FULL"}
+		// The genesis admin block of a CUSTOM network names FNode0 as its
+		// authority, so the bootstrap identity is that of FNode0.  The
+		// identities of the others are placeholders until registered.
+		node.IdentityChainID = primitives.Sha([]byte(fmt.Sprintf("%s-FNode%d", name, i))).String()
+		if i == 0 {
+			node.NodeMode = "SERVER"
+			node.IdentityChainID = primitives.Sha([]byte("FNode0")).String()
+			spec.BootstrapIdentity = node.IdentityChainID
+			spec.BootstrapKey = key.PublicKeyString()
+		}
+		node.PublicKey = key.PublicKeyString()
+		node.privateKey = key.PrivateKeyString()
+		spec.Nodes = append(spec.Nodes, node)
+	}
+	return spec, nil
+}
+
+// NodeConfig returns the factomd.conf of the node.  Only the settings the
+// network needs are set; the rest are the defaults.
+func (spec *NetworkSpec) NodeConfig(node NetworkNode) string {
+	var peers []string
+	for _, other := range spec.Nodes {
+		if other.Name != node.Name {
+			peers = append(peers, fmt.Sprintf("%s:%s", other.Name, newNetworkP2PPort))
+		}
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "; factomd.conf of %s of the %s network, written by factomd newnetwork.\n", node.Name, spec.CustomNet)
+	fmt.Fprintf(&out, "; Run the node with -network=CUSTOM -customnet=%s\n", spec.CustomNet)
+	fmt.Fprintf(&out, "[app]\n")
+	fmt.Fprintf(&out, "PortNumber                            = %d\n", newNetworkAPIPort)
+	fmt.Fprintf(&out, "ControlPanelPort                      = %d\n", newNetworkControlPanelPort)
+	fmt.Fprintf(&out, "Network                               = CUSTOM\n")
+	fmt.Fprintf(&out, "LocalNetworkPort                      = %s\n", newNetworkP2PPort)
+	fmt.Fprintf(&out, "LocalSeedURL                          = \"\"\n")
+	fmt.Fprintf(&out, "LocalSpecialPeers                     = \"%s\"\n", strings.Join(peers, " "))
+	fmt.Fprintf(&out, "CustomBootstrapIdentity               = %s\n", spec.BootstrapIdentity)
+	fmt.Fprintf(&out, "CustomBootstrapKey                    = %s\n", spec.BootstrapKey)
+	fmt.Fprintf(&out, "NodeMode                              = %s\n", node.NodeMode)
+	fmt.Fprintf(&out, "IdentityChainID                       = %s\n", node.IdentityChainID)
+	fmt.Fprintf(&out, "LocalServerPrivKey                    = %s\n", node.privateKey)
+	fmt.Fprintf(&out, "LocalServerPublicKey                  = %s\n", node.PublicKey)
+	return out.String()
+}
+
+// DockerCompose returns the docker-compose.yml that runs the nodes, each
+// with its directory as its -datadir.  The API and control panel of
+// node N are published on 18088+10N and 18090+10N.
+func (spec *NetworkSpec) DockerCompose(image string) string {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "version: \"3\"\n")
+	fmt.Fprintf(&out, "services:\n")
+	for i, node := range spec.Nodes {
+		fmt.Fprintf(&out, "  %s:\n", node.Name)
+		fmt.Fprintf(&out, "    image: %s\n", image)
+		fmt.Fprintf(&out, "    command: [\"-network=CUSTOM\", \"-customnet=%s\", \"-datadir=/data\"]\n", spec.CustomNet)
+		fmt.Fprintf(&out, "    volumes:\n")
+		fmt.Fprintf(&out, "      - ./%s:/data\n", node.Name)
+		fmt.Fprintf(&out, "    ports:\n")
+		fmt.Fprintf(&out, "      - \"%d:%d\"\n", newNetworkAPIPort+10000+10*i, newNetworkAPIPort)
+		fmt.Fprintf(&out, "      - \"%d:%d\"\n", newNetworkControlPanelPort+10000+10*i, newNetworkControlPanelPort)
+	}
+	return out.String()
+}
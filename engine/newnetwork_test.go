@@ -0,0 +1,40 @@
+package engine_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/engine"
+)
+
+func TestNewNetworkSpec(t *testing.T) {
+	spec, err := NewNetworkSpec("testnet", 3)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(spec.Nodes) != 3 {
+		t.Fatalf("%d nodes", len(spec.Nodes))
+	}
+	if spec.BootstrapIdentity != primitives.Sha([]byte("FNode0")).String() || spec.BootstrapKey != spec.Nodes[0].PublicKey {
+		t.Errorf("Wrong bootstrap identity or key: %+v", spec)
+	}
+	if spec.Nodes[0].NodeMode != "SERVER" || spec.Nodes[1].NodeMode != "FULL" || spec.Nodes[1].PublicKey == spec.Nodes[2].PublicKey {
+		t.Errorf("Wrong nodes: %+v", spec.Nodes)
+	}
+
+	conf := spec.NodeConfig(spec.Nodes[1])
+	for _, line := range []string{
+		"Network                               = CUSTOM",
+		"LocalSpecialPeers                     = \"node0:8110 node2:8110\"",
+		"CustomBootstrapKey                    = " + spec.BootstrapKey,
+		"LocalServerPublicKey                  = " + spec.Nodes[1].PublicKey,
+	} {
+		if !strings.Contains(conf, line) {
+			t.Errorf("The config of node1 lacks %q:\n%s", line, conf)
+		}
+	}
+	if compose := spec.DockerCompose("factomd"); !strings.Contains(compose, "\"-customnet=testnet\"") || !strings.Contains(compose, "\"18108:8088\"") {
+		t.Errorf("Wrong docker-compose.yml:\n%s", compose)
+	}
+}
@@ -8,23 +8,38 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	_ "net/http/pprof"
+	"net/http/pprof"
 	"runtime"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-// StartProfiler runs the go pprof tool
+// StartProfiler serves the go pprof handlers on localhost only, when
+// ProfilerEnabled is set in factomd.conf
 // `go tool pprof http://localhost:6060/debug/pprof/profile`
+// `go tool pprof http://localhost:6060/debug/pprof/heap`
+// `go tool pprof http://localhost:6060/debug/pprof/goroutine`
+// `go tool pprof http://localhost:6060/debug/pprof/block`
 // https://golang.org/pkg/net/http/pprof/
-func StartProfiler(mpr int) {
-	_ = log.Print
+//
+// The block profile is empty unless blockRate is above 0.
+func StartProfiler(mpr int, blockRate int) {
 	runtime.MemProfileRate = mpr
-	log.Println(http.ListenAndServe(fmt.Sprintf("localhost:%s", logPort), nil))
-	//runtime.SetBlockProfileRate(100000)
+	runtime.SetBlockProfileRate(blockRate)
+
+	// Not the DefaultServeMux, which the pprof package registers on, so the
+	// profiles are only served here.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	log.Println(http.ListenAndServe(fmt.Sprintf("localhost:%s", logPort), mux))
 }
 
 func launchPrometheus(port int) {
-	http.Handle("/metrics", prometheus.Handler())
-	go http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.Handler())
+	go http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
 }
@@ -0,0 +1,86 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/wsapi"
+)
+
+// serviceReady and serviceStopped tell the Windows service manager that the
+// node is running, and that it stopped.  service_windows.go sets them.
+var (
+	serviceReady   = func() {}
+	serviceStopped = func() {}
+)
+
+// sdNotify sends the state, such as READY=1, to systemd, if it started
+// factomd as a Type=notify service.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:] // An abstract socket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// watchdogInterval returns how often to tell systemd the node is well, half
+// of the WatchdogSec of the service, or 0 if there is no watchdog.
+func watchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// superviseService tells the service manager the node is ready once it has
+// loaded its database, and then pings the systemd watchdog.  A node that finds
+// a fork keeps pinging, and reports the fork in its status: a restart would
+// lose the fork it found and save blocks again, so the operator must look.
+// It must be run as a goroutine.
+func superviseService(s *state.State) {
+	for !s.DBFinished {
+		time.Sleep(time.Second)
+	}
+	sdNotify(fmt.Sprintf("READY=1\nSTATUS=Loaded the database to height %d", s.GetHighestSavedBlk()))
+	serviceReady()
+
+	interval := watchdogInterval()
+	if interval == 0 {
+		return
+	}
+	for range time.Tick(interval) {
+		status := fmt.Sprintf("Running at height %d", s.GetHighestSavedBlk())
+		if _, health := wsapi.Health(s); health.Fork != nil {
+			status = fmt.Sprintf("Halted on a fork at height %d; see /healthz", health.Fork.DBHeight)
+		}
+		sdNotify("WATCHDOG=1\nSTATUS=" + status)
+	}
+}
+
+// stopService tells the service manager the node is stopping.
+func stopService() {
+	sdNotify("STOPPING=1")
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+//go:build windows
+// +build windows
+
+package engine
+
+import (
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// serviceName is the name factomd is installed as a Windows service with:
+//
+//	sc create factomd binPath= "C:\factom\factomd.exe -network=MAIN" start= auto
+const serviceName = "factomd"
+
+// The ends of the Windows service: ready is closed once the node has loaded
+// its database, stopped once the graceful shutdown is done, and done once
+// the service manager has been told it stopped.
+var (
+	serviceReadyChan   = make(chan struct{})
+	serviceStoppedChan = make(chan struct{})
+	serviceDoneChan    = make(chan struct{})
+	runningAsService   bool
+)
+
+func init() {
+	winServiceMain = serviceMain
+	serviceReady = func() { close(serviceReadyChan) }
+	serviceStopped = func() {
+		if !runningAsService {
+			return
+		}
+		close(serviceStoppedChan)
+		select {
+		case <-serviceDoneChan:
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// serviceMain runs the node as a Windows service, if the service manager
+// started factomd, and returns whether it did.
+func serviceMain() (bool, error) {
+	interactive, err := svc.IsAnInteractiveSession()
+	if err != nil {
+		return false, err
+	}
+	if interactive {
+		return false, nil
+	}
+	runningAsService = true
+	return true, svc.Run(serviceName, new(factomdService))
+}
+
+// factomdService runs the node for the service manager.  Stop and shutdown
+// shut the node down as Ctrl+C does; pause cuts the node off the network,
+// and continue joins it again.
+type factomdService struct{}
+
+func (f *factomdService) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue
+	defer close(serviceDoneChan)
+
+	changes <- svc.Status{State: svc.StartPending, WaitHint: 30000}
+	go factomdMain()
+
+	ready := serviceReadyChan
+	for {
+		select {
+		case <-ready:
+			ready = nil
+			changes <- svc.Status{State: svc.Running, Accepts: accepted}
+		case c := <-requests:
+			switch c.Cmd {
+			case svc.Interrogate:
+				changes <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending, WaitHint: 10000}
+				requestShutdown()
+			case svc.Pause:
+				setNetworkOff(true)
+				changes <- svc.Status{State: svc.Paused, Accepts: accepted}
+			case svc.Continue:
+				setNetworkOff(false)
+				changes <- svc.Status{State: svc.Running, Accepts: accepted}
+			}
+		case <-serviceStoppedChan:
+			changes <- svc.Status{State: svc.Stopped}
+			return false, 0
+		}
+	}
+}
+
+// setNetworkOff cuts the nodes off the network, or joins them again.
+func setNetworkOff(off bool) {
+	for _, fnode := range fnodes {
+		fnode.State.SetNetStateOff(off)
+	}
+}
@@ -555,7 +555,7 @@ func SimControl(listenTo int) {
 					} else if b[1] == 'c' {
 						f := fnodes[listenTo]
 						fmt.Println("Commits:")
-						for _, c := range f.State.Commits {
+						for _, c := range f.State.Commits.Copy() {
 							if c != nil {
 								os.Stderr.WriteString("  " + (c.String()))
 								cc, ok1 := c.(*messages.CommitChainMsg)
@@ -0,0 +1,286 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/telemetry"
+	"github.com/FactomProject/factomd/util"
+)
+
+// The subcommands factomd runs in place of a node, as the first argument:
+//
+//	factomd version
+//	factomd checkdb [flags]
+//	factomd reindex [flags]
+//	factomd exportdb [flags] <file>
+//	factomd importdb [flags] <file>
+//	factomd peers [-host localhost]
+//	factomd status [-host localhost]
+//	factomd dumpstate [-host localhost]
+//	factomd newnetwork [-name private] [-nodes 3] [-dir private]
+//
+// The database ones take the flags of the node, such as -db and -network, to
+// find the database.  peers, status and dumpstate ask a node that is running,
+// with the ports and credentials of factomd.conf.
+var subcommandUsage = map[string]string{
+	"version":    "Print the version of factomd and exit",
+	"checkdb":    "Check the integrity of the blockchain in the database and exit",
+	"reindex":    "Rebuild the database indexes from the saved blocks and exit",
+	"exportdb":   "Write the database to the given snapshot file and exit",
+	"importdb":   "Load the given snapshot file into an empty database and exit",
+	"peers":      "List the peers of the running node",
+	"status":     "Print the version, heights and health of the running node",
+	"dumpstate":  "Have the running node write its consensus state to a JSON file in its log directory",
+	"newnetwork": "Write the genesis, keys, configs and docker-compose.yml of a new private network",
+}
+
+// RunSubcommand runs the subcommand of the arguments, if the first one is
+// one, and exits.  The database subcommands are instead turned into the
+// flags of the node that do the same, in os.Args, and left to NetStart.
+func RunSubcommand(args []string) {
+	if len(args) < 2 {
+		return
+	}
+	name, rest := args[1], args[2:]
+	switch name {
+	case "help":
+		fmt.Println("Usage: factomd [subcommand] [flags]")
+		fmt.Println("Without a subcommand, factomd runs a node.  The subcommands are:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, sub := range []string{"version", "checkdb", "reindex", "exportdb", "importdb", "peers", "status", "dumpstate", "newnetwork"} {
+			fmt.Fprintf(w, "  %s\t%s\n", sub, subcommandUsage[sub])
+		}
+		w.Flush()
+		fmt.Println("Run factomd -h for the flags of the node.")
+		os.Exit(0)
+	case "version":
+		fmt.Printf("factomd %s\n", telemetry.VersionString(constants.FACTOMD_VERSION))
+		fmt.Printf("Build:  %s\n", Build)
+		fmt.Printf("Go:     %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		os.Exit(0)
+	case "checkdb", "reindex":
+		os.Args = append([]string{args[0], "-" + name}, rest...)
+	case "exportdb", "importdb":
+		if len(rest) == 0 || strings.HasPrefix(rest[len(rest)-1], "-") {
+			fmt.Printf("Usage: factomd %s [flags] <file>\n", name)
+			os.Exit(1)
+		}
+		file := rest[len(rest)-1]
+		os.Args = append(append([]string{args[0]}, rest[:len(rest)-1]...), "-"+name+"="+file)
+	case "newnetwork":
+		if err := NewNetwork(rest); err != nil {
+			fmt.Printf("factomd newnetwork: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "peers", "status", "dumpstate":
+		if err := runNodeQuery(name, rest); err != nil {
+			fmt.Printf("factomd %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+}
+
+// nodeClient asks a running node through its API and control panel.
+type nodeClient struct {
+	host   string
+	state  *state.State // Holds the ports and credentials of factomd.conf
+	scheme string
+	client *http.Client
+	admin  bool // Send the admin token of factomd.conf, if there is one
+}
+
+func runNodeQuery(name string, args []string) error {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	host := flags.String("host", "localhost", "Host of the running node")
+	port := flags.Int("port", 0, "Port of the API of the node, if not that of factomd.conf")
+	cpPort := flags.Int("ControlPanelPort", 0, "Port of the control panel of the node, if not that of factomd.conf")
+	flags.StringVar(&util.DataDir, "datadir", "", "Directory of the factomd.conf of the node, if not ~/.factom/m2")
+	flags.Parse(args)
+
+	c, err := newNodeClient(*host)
+	if err != nil {
+		return err
+	}
+	if 0 < *port {
+		c.state.SetPort(*port)
+	}
+	if 0 < *cpPort {
+		c.state.ControlPanelPort = *cpPort
+	}
+	switch name {
+	case "peers":
+		return c.printPeers()
+	case "dumpstate":
+		return c.dumpState()
+	}
+	return c.printStatus()
+}
+
+func newNodeClient(host string) (*nodeClient, error) {
+	s := new(state.State)
+	s.LoadConfig(util.GetConfigFilename("m2"), "")
+
+	c := new(nodeClient)
+	c.host = host
+	c.state = s
+	c.scheme = "http"
+	c.client = &http.Client{Timeout: 10 * time.Second}
+	if tlsEnabled, _, certFile := s.GetTlsInfo(); tlsEnabled {
+		cert, err := ioutil.ReadFile(certFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(cert)
+		c.scheme = "https"
+		c.client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	return c, nil
+}
+
+// do sends the request with the credentials of the node, and decodes the
+// JSON answer into result.
+func (c *nodeClient) do(req *http.Request, result interface{}) error {
+	if token := c.state.GetRpcAdminToken(); c.admin && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if user := c.state.GetRpcUser(); user != "" {
+		req.SetBasicAuth(user, c.state.GetRpcPass())
+	} else if token := c.state.GetRpcToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Cannot reach the node: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("The node refused the credentials of factomd.conf")
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// callV2 calls a method of the v2 API.
+func (c *nodeClient) callV2(method string, result interface{}) error {
+	data, err := primitives.NewJSON2Request(method, 0, nil).JSONByte()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s://%s:%d/v2", c.scheme, c.host, c.state.GetPort()), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp := new(primitives.JSON2Response)
+	resp.Result = result
+	if err := c.do(req, resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %s", method, resp.Error.Message)
+	}
+	return nil
+}
+
+// dumpState has the node write its consensus state, and prints the file.
+func (c *nodeClient) dumpState() error {
+	c.admin = true
+	resp := new(struct {
+		File string `json:"file"`
+	})
+	if err := c.callV2("dump-state", resp); err != nil {
+		return err
+	}
+	fmt.Printf("The node wrote its state to %s\n", resp.File)
+	return nil
+}
+
+func (c *nodeClient) printStatus() error {
+	properties := new(struct {
+		FactomdVersion string `json:"factomdversion"`
+		ApiVersion     string `json:"factomdapiversion"`
+		NetworkName    string `json:"networkname"`
+		NodeRole       string `json:"noderole"`
+	})
+	if err := c.callV2("properties", properties); err != nil {
+		return err
+	}
+	heights := new(struct {
+		DirectoryBlockHeight int64 `json:"directoryblockheight"`
+		LeaderHeight         int64 `json:"leaderheight"`
+		EntryBlockHeight     int64 `json:"entryblockheight"`
+		EntryHeight          int64 `json:"entryheight"`
+	})
+	if err := c.callV2("heights", heights); err != nil {
+		return err
+	}
+	health := new(struct{ Status string })
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s://%s:%d/healthz", c.scheme, c.host, c.state.GetPort()), nil)
+	if err != nil {
+		return err
+	}
+	if err := c.do(req, health); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Version\t%s (API %s)\n", properties.FactomdVersion, properties.ApiVersion)
+	fmt.Fprintf(w, "Network\t%s\n", properties.NetworkName)
+	fmt.Fprintf(w, "Role\t%s\n", properties.NodeRole)
+	fmt.Fprintf(w, "Health\t%s\n", health.Status)
+	fmt.Fprintf(w, "Directory block height\t%d (leader at %d)\n", heights.DirectoryBlockHeight, heights.LeaderHeight)
+	fmt.Fprintf(w, "Entry block height\t%d\n", heights.EntryBlockHeight)
+	fmt.Fprintf(w, "Entry height\t%d\n", heights.EntryHeight)
+	return w.Flush()
+}
+
+// printPeers lists the peers from the control panel, which is where the
+// node shows them.
+func (c *nodeClient) printPeers() error {
+	var peers []struct {
+		Connection struct {
+			BytesSent       uint32
+			BytesReceived   uint32
+			PeerAddress     string
+			PeerQuality     int32
+			ConnectionState string
+		}
+		ConnectionTimeFormatted string
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s://%s:%d/factomd?item=peers", c.scheme, c.host, c.state.ControlPanelPort), nil)
+	if err != nil {
+		return err
+	}
+	if err := c.do(req, &peers); err != nil {
+		return fmt.Errorf("%v (is the control panel enabled?)", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ADDRESS\tSTATE\tCONNECTED\tQUALITY\tSENT\tRECEIVED\n")
+	for _, p := range peers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\n", p.Connection.PeerAddress, p.Connection.ConnectionState,
+			p.ConnectionTimeFormatted, p.Connection.PeerQuality, p.Connection.BytesSent, p.Connection.BytesReceived)
+	}
+	fmt.Fprintf(w, "%d peers\n", len(peers))
+	return w.Flush()
+}
@@ -0,0 +1,29 @@
+package engine_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	. "github.com/FactomProject/factomd/engine"
+)
+
+func TestRunSubcommand(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	for _, c := range []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"factomd", "-count=2"}, []string{"factomd", "-count=2"}},
+		{[]string{"factomd", "checkdb", "-db=Bolt"}, []string{"factomd", "-checkdb", "-db=Bolt"}},
+		{[]string{"factomd", "exportdb", "-network=TEST", "snap.db"}, []string{"factomd", "-network=TEST", "-exportdb=snap.db"}},
+	} {
+		os.Args = c.args
+		RunSubcommand(c.args)
+		if !reflect.DeepEqual(os.Args, c.expected) {
+			t.Errorf("%v became %v, expected %v", c.args, os.Args, c.expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package events
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// EncodeJSON encodes an event as a line of JSON.
+func EncodeJSON(event *Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// EncodeProtobuf encodes an event as the Event message of
+// grpcapi/factomd.proto, after its length as a varint, so that a stream of
+// them can be split.
+func EncodeProtobuf(event *Event) ([]byte, error) {
+	var msg []byte
+	msg = appendString(msg, 1, event.Type)
+	msg = appendString(msg, 2, event.Node)
+	msg = appendVarint(msg, 3, uint64(event.Time))
+	msg = appendVarint(msg, 4, uint64(event.Height))
+	for _, field := range []struct {
+		number int
+		hex    string
+	}{{5, event.KeyMR}, {6, event.ChainID}, {7, event.EntryHash}} {
+		b, err := hex.DecodeString(field.hex)
+		if err != nil {
+			return nil, err
+		}
+		msg = appendBytes(msg, field.number, b)
+	}
+	msg = appendString(msg, 8, event.State)
+	msg = appendString(msg, 9, event.Message)
+
+	data := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(msg))
+	data = data[:binary.PutUvarint(data, uint64(len(msg)))]
+	return append(data, msg...), nil
+}
+
+// The fields are left out if they are zero, as proto3 does.
+
+func appendVarint(msg []byte, number int, v uint64) []byte {
+	if v == 0 {
+		return msg
+	}
+	var buf [binary.MaxVarintLen64]byte
+	msg = append(msg, buf[:binary.PutUvarint(buf[:], uint64(number<<3))]...)
+	return append(msg, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendBytes(msg []byte, number int, b []byte) []byte {
+	if len(b) == 0 {
+		return msg
+	}
+	var buf [binary.MaxVarintLen64]byte
+	msg = append(msg, buf[:binary.PutUvarint(buf[:], uint64(number<<3|2))]...)
+	msg = append(msg, buf[:binary.PutUvarint(buf[:], uint64(len(b)))]...)
+	return append(msg, b...)
+}
+
+func appendString(msg []byte, number int, s string) []byte {
+	return appendBytes(msg, number, []byte(s))
+}
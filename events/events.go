@@ -0,0 +1,277 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package events sends what happens on a node to outside brokers, such as
+// the queues of an indexing pipeline.  Each sink is configured with a
+// section of factomd.conf:
+//
+//	[EventSink "indexer"]
+//	Kind    = tcp
+//	Address = "localhost:8040"
+//	Format  = json
+//	Events  = "dblock, entry-reveal"
+//
+// The webhook kind POSTs each event, as JSON, to the URL of its Address, so
+// that alerts can page the operators:
+//
+//	[EventSink "pager"]
+//	Kind    = webhook
+//	Address = "https://alerts.example.com/factomd"
+//	Events  = "leader-fault, isolated, fork, db-write-failure, sync-stalled"
+//
+// The kafka kind sends to a topic, with the Address "<broker>[,<broker>...]/<topic>",
+// and the nats kind to a subject, with "nats://<host>:<port>/<subject>".  The
+// zeromq kind publishes on a PUB socket bound to an endpoint such as
+// "tcp://*:5556", and as it needs libzmq, is only in builds with the zeromq
+// tag.  Other kinds are added with RegisterSink.
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	DBlock      = "dblock"       // A directory block was saved
+	EBlock      = "eblock"       // An entry block was saved, with its directory block
+	EntryReveal = "entry-reveal" // An entry was added to the process list
+	NodeState   = "node-state"   // The node became a leader, an audit server or a follower
+)
+
+// Alerts: events that something is wrong with the node or the network, with
+// a Message for the operator
+const (
+	LeaderFault    = "leader-fault"     // A federated server was found faulted; ChainID is its identity
+	Isolated       = "isolated"         // The node has had no peers for a while
+	Fork           = "fork"             // Two directory blocks were signed for Height, and saving has halted
+	DBWriteFailure = "db-write-failure" // A block couldn't be saved to the database
+	SyncStalled    = "sync-stalled"     // No block has been saved for a while
+
+	InvariantViolated = "invariant-violated" // With -checkinvariants, something that must hold after a block didn't
+)
+
+type Event struct {
+	Type      string `json:"type"`
+	Node      string `json:"node"`
+	Time      int64  `json:"time"` // Unix milliseconds
+	Height    uint32 `json:"height"`
+	KeyMR     string `json:"keymr,omitempty"`
+	ChainID   string `json:"chainid,omitempty"`
+	EntryHash string `json:"entryhash,omitempty"`
+	State     string `json:"state,omitempty"`   // For node-state: "leader", "audit" or "follower"
+	Message   string `json:"message,omitempty"` // For alerts
+}
+
+// SinkConfig is an [EventSink "name"] section of factomd.conf.
+type SinkConfig struct {
+	Kind    string
+	Address string
+	Format  string // json (the default) or protobuf
+	Events  string // Comma separated event types, or empty for all
+}
+
+// Sink delivers encoded events to a broker.  Send is only called by one
+// goroutine, and Close once it is done.
+type Sink interface {
+	Send(data []byte) error
+	Close() error
+}
+
+// SinkFactory makes a sink of a kind for the Address of its config.
+type SinkFactory func(address string) (Sink, error)
+
+var sinkFactories = map[string]SinkFactory{
+	"tcp":     NewTCPSink,
+	"webhook": NewWebhookSink,
+	"kafka":   NewKafkaSink,
+	"nats":    NewNATSSink,
+}
+var sinkFactoriesMutex sync.Mutex
+
+// RegisterSink adds a kind of sink.
+func RegisterSink(kind string, factory SinkFactory) {
+	sinkFactoriesMutex.Lock()
+	defer sinkFactoriesMutex.Unlock()
+	sinkFactories[strings.ToLower(kind)] = factory
+}
+
+// SinkBufferSize is the number of events queued for a sink before new ones
+// are dropped.
+var SinkBufferSize = 10000
+
+// Emitter sends the events of a node to its sinks.  A nil Emitter drops
+// them, so that nodes without sinks needn't check.
+type Emitter struct {
+	node  string
+	sinks []*sinkQueue
+}
+
+type sinkQueue struct {
+	name    string
+	sink    Sink
+	encode  func(*Event) ([]byte, error)
+	types   map[string]bool // nil for all
+	events  chan *Event
+	pending sync.WaitGroup // Events queued or being sent
+	mutex   sync.Mutex
+	dropped int
+}
+
+// NewEmitter starts the sinks of a node, in the order of their names.  It
+// returns nil if there are none.  A sink that can't be made is left out,
+// and reported in the error.
+func NewEmitter(node string, configs map[string]*SinkConfig) (*Emitter, error) {
+	names := []string{}
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	e := &Emitter{node: node}
+	var errs []string
+	for _, name := range names {
+		q, err := newSinkQueue(name, configs[name])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("event sink %q: %v", name, err))
+			continue
+		}
+		e.sinks = append(e.sinks, q)
+		go q.run()
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	if len(e.sinks) == 0 {
+		return nil, err
+	}
+	return e, err
+}
+
+func newSinkQueue(name string, config *SinkConfig) (*sinkQueue, error) {
+	q := &sinkQueue{name: name, events: make(chan *Event, SinkBufferSize)}
+
+	switch strings.ToLower(config.Format) {
+	case "", "json":
+		q.encode = EncodeJSON
+	case "protobuf":
+		q.encode = EncodeProtobuf
+	default:
+		return nil, fmt.Errorf("unknown format %q", config.Format)
+	}
+
+	for _, t := range strings.Split(config.Events, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			if q.types == nil {
+				q.types = map[string]bool{}
+			}
+			q.types[t] = true
+		}
+	}
+
+	sinkFactoriesMutex.Lock()
+	factory := sinkFactories[strings.ToLower(config.Kind)]
+	sinkFactoriesMutex.Unlock()
+	if factory == nil {
+		return nil, fmt.Errorf("unknown kind %q", config.Kind)
+	}
+	sink, err := factory(config.Address)
+	if err != nil {
+		return nil, err
+	}
+	q.sink = sink
+	return q, nil
+}
+
+// Emit queues an event for the sinks that want it.  It never blocks: if a
+// sink is too far behind, the event is dropped for it.
+func (e *Emitter) Emit(event *Event) {
+	if e == nil {
+		return
+	}
+	event.Node = e.node
+	if event.Time == 0 {
+		event.Time = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	for _, q := range e.sinks {
+		if q.types != nil && !q.types[event.Type] {
+			continue
+		}
+		q.pending.Add(1)
+		select {
+		case q.events <- event:
+		default:
+			q.pending.Done()
+			q.mutex.Lock()
+			q.dropped++
+			q.mutex.Unlock()
+		}
+	}
+}
+
+// Dropped returns how many events each sink dropped, by name.
+func (e *Emitter) Dropped() map[string]int {
+	dropped := map[string]int{}
+	if e == nil {
+		return dropped
+	}
+	for _, q := range e.sinks {
+		q.mutex.Lock()
+		dropped[q.name] = q.dropped
+		q.mutex.Unlock()
+	}
+	return dropped
+}
+
+// Flush waits until the sinks have sent the events queued, or the timeout
+// passes, and returns whether they were all sent.  It is for alerts sent
+// just before the node stops.
+func (e *Emitter) Flush(timeout time.Duration) bool {
+	if e == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		for _, q := range e.sinks {
+			q.pending.Wait()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Close stops the sinks once they have sent the queued events.  The Emitter
+// can't be used after.
+func (e *Emitter) Close() {
+	if e == nil {
+		return
+	}
+	for _, q := range e.sinks {
+		close(q.events)
+	}
+}
+
+func (q *sinkQueue) run() {
+	defer q.sink.Close()
+	for event := range q.events {
+		data, err := q.encode(event)
+		if err == nil {
+			err = q.sink.Send(data)
+		}
+		if err != nil {
+			fmt.Printf("Event sink %s: %v\n", q.name, err)
+		}
+		q.pending.Done()
+	}
+}
@@ -0,0 +1,217 @@
+package events_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factomd/events"
+)
+
+func TestTCPSink(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer l.Close()
+
+	e, err := NewEmitter("FNode0", map[string]*SinkConfig{
+		"test": {Kind: "tcp", Address: l.Addr().String(), Events: "dblock, node-state"},
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer e.Close()
+
+	e.Emit(&Event{Type: EntryReveal, Height: 1})
+	e.Emit(&Event{Type: DBlock, Height: 2, KeyMR: "00"})
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	event := new(Event)
+	if err := json.Unmarshal(line, event); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if event.Type != DBlock || event.Height != 2 || event.Node != "FNode0" || event.Time == 0 {
+		t.Errorf("Wrong event %s", line)
+	}
+}
+
+func TestNewEmitterErrors(t *testing.T) {
+	e, err := NewEmitter("FNode0", nil)
+	if e != nil || err != nil {
+		t.Errorf("Got %v, %v without sinks", e, err)
+	}
+	// A nil emitter drops events
+	e.Emit(&Event{Type: DBlock})
+
+	e, err = NewEmitter("FNode0", map[string]*SinkConfig{
+		"kind":    {Kind: "carrier-pigeon", Address: "localhost:1"},
+		"format":  {Kind: "tcp", Address: "localhost:1", Format: "xml"},
+		"address": {Kind: "tcp", Address: "localhost"},
+		"topic":   {Kind: "kafka", Address: "localhost:9092"},
+		"broker":  {Kind: "kafka", Address: "localhost/factomd"},
+		"subject": {Kind: "nats", Address: "nats://localhost:4222"},
+		"scheme":  {Kind: "nats", Address: "localhost:4222/factomd"},
+	})
+	if e != nil || err == nil {
+		t.Errorf("Got %v, %v for bad sinks", e, err)
+	}
+}
+
+func TestEncodeProtobuf(t *testing.T) {
+	event := &Event{Type: DBlock, Node: "FNode0", Time: 1500000000000, Height: 300, KeyMR: "0102"}
+	data, err := EncodeProtobuf(event)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	size, n := binary.Uvarint(data)
+	if n <= 0 || int(size) != len(data)-n {
+		t.Fatalf("Wrong length prefix %d for %d bytes", size, len(data)-n)
+	}
+
+	// type = "dblock" is field 1, bytes
+	msg := data[n:]
+	if msg[0] != 1<<3|2 || msg[1] != byte(len(DBlock)) || string(msg[2:2+len(DBlock)]) != DBlock {
+		t.Errorf("Wrong type field %x", msg)
+	}
+	// key_mr is field 5, bytes, after the height (field 4, varint 300 = ac 02)
+	if want := []byte{4 << 3, 0xac, 0x02, 5<<3 | 2, 2, 1, 2}; string(msg[len(msg)-len(want):]) != string(want) {
+		t.Errorf("Wrong height or key MR %x", msg)
+	}
+
+	if _, err := EncodeProtobuf(&Event{Type: DBlock, KeyMR: "not hex"}); err == nil {
+		t.Errorf("No error for a key MR that isn't hex")
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Wrong request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	e, err := NewEmitter("FNode0", map[string]*SinkConfig{
+		"pager": {Kind: "webhook", Address: server.URL, Events: "fork, sync-stalled"},
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer e.Close()
+
+	e.Emit(&Event{Type: DBlock, Height: 1})
+	e.Emit(&Event{Type: Fork, Height: 2, Message: "Two blocks"})
+	if !e.Flush(5 * time.Second) {
+		t.Fatalf("Alert not sent")
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("%d events posted", len(bodies))
+	}
+	event := new(Event)
+	if err := json.Unmarshal(<-bodies, event); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if event.Type != Fork || event.Height != 2 || event.Message != "Two blocks" || event.Node != "FNode0" {
+		t.Errorf("Wrong event %v", event)
+	}
+
+	_, err = NewEmitter("FNode0", map[string]*SinkConfig{
+		"pager": {Kind: "webhook", Address: "localhost:80"},
+	})
+	if err == nil {
+		t.Errorf("No error for a webhook without a URL")
+	}
+}
+
+// natsServer speaks enough of the NATS protocol for a client to publish, and
+// sends the payloads published.
+func natsServer(t *testing.T, l net.Listener, payloads chan []byte) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte("INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n"))
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "PING":
+			conn.Write([]byte("PONG\r\n"))
+		case fields[0] == "PUB" && len(fields) == 3:
+			n, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, n+2) // And the CRLF
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			if fields[1] != "factomd.events" {
+				t.Errorf("Published to %s", fields[1])
+			}
+			payloads <- payload[:n]
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer l.Close()
+	payloads := make(chan []byte, 10)
+	go natsServer(t, l, payloads)
+
+	e, err := NewEmitter("FNode0", map[string]*SinkConfig{
+		"indexer": {Kind: "nats", Address: "nats://" + l.Addr().String() + "/factomd.events"},
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer e.Close()
+
+	e.Emit(&Event{Type: EntryReveal, Height: 7, EntryHash: "0a"})
+	if !e.Flush(5 * time.Second) {
+		t.Fatalf("Event not sent")
+	}
+	select {
+	case payload := <-payloads:
+		event := new(Event)
+		if err := json.Unmarshal(payload, event); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if event.Type != EntryReveal || event.Height != 7 || event.EntryHash != "0a" {
+			t.Errorf("Wrong event %v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Nothing published")
+	}
+}
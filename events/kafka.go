@@ -0,0 +1,75 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package events
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// KafkaTimeout bounds connecting to the brokers, and each send.
+var KafkaTimeout = 10 * time.Second
+
+// kafkaSink sends each event as a message to a Kafka topic, once all of the
+// in sync replicas have it.  The producer is made again after an error, and
+// the event being sent then is lost.
+type kafkaSink struct {
+	brokers  []string
+	topic    string
+	producer sarama.SyncProducer
+}
+
+// NewKafkaSink makes a sink for the address "<broker>[,<broker>...]/<topic>",
+// such as "kafka1:9092,kafka2:9092/factomd".  It connects when it first sends.
+func NewKafkaSink(address string) (Sink, error) {
+	slash := strings.LastIndex(address, "/")
+	if slash < 0 || slash == len(address)-1 {
+		return nil, fmt.Errorf("invalid address %q, expected <broker>[,<broker>...]/<topic>", address)
+	}
+	s := &kafkaSink{topic: address[slash+1:]}
+	for _, broker := range strings.Split(address[:slash], ",") {
+		broker = strings.TrimSpace(broker)
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			return nil, fmt.Errorf("invalid broker %q", broker)
+		}
+		s.brokers = append(s.brokers, broker)
+	}
+	return s, nil
+}
+
+func (s *kafkaSink) Send(data []byte) error {
+	if s.producer == nil {
+		config := sarama.NewConfig()
+		config.Net.DialTimeout = KafkaTimeout
+		config.Net.WriteTimeout = KafkaTimeout
+		config.Net.ReadTimeout = KafkaTimeout
+		config.Producer.Timeout = KafkaTimeout
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Producer.Return.Successes = true // For a SyncProducer
+		producer, err := sarama.NewSyncProducer(s.brokers, config)
+		if err != nil {
+			return err
+		}
+		s.producer = producer
+	}
+	_, _, err := s.producer.SendMessage(&sarama.ProducerMessage{Topic: s.topic, Value: sarama.ByteEncoder(data)})
+	if err != nil {
+		s.producer.Close()
+		s.producer = nil
+		return err
+	}
+	return nil
+}
+
+func (s *kafkaSink) Close() error {
+	if s.producer == nil {
+		return nil
+	}
+	return s.producer.Close()
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nats-io/go-nats"
+)
+
+// NATSTimeout bounds connecting to the server, and waiting for it to take
+// each event.
+var NATSTimeout = 5 * time.Second
+
+// natsSink publishes each event to a NATS subject.  The client reconnects on
+// its own after the server goes away, and sends the events published
+// meanwhile once it is back.
+type natsSink struct {
+	server  string
+	subject string
+	conn    *nats.Conn
+}
+
+// NewNATSSink makes a sink for the URL of the server with the subject as its
+// path, such as "nats://localhost:4222/factomd.events".  It connects when it
+// first sends.
+func NewNATSSink(address string) (Sink, error) {
+	u, err := url.Parse(address)
+	subject := ""
+	if err == nil {
+		subject = strings.TrimPrefix(u.Path, "/")
+	}
+	if err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" || subject == "" {
+		return nil, fmt.Errorf("invalid address %q, expected nats://<host>:<port>/<subject>", address)
+	}
+	u.Path = ""
+	return &natsSink{server: u.String(), subject: subject}, nil
+}
+
+func (s *natsSink) Send(data []byte) error {
+	if s.conn == nil {
+		conn, err := nats.Connect(s.server, nats.Timeout(NATSTimeout), nats.MaxReconnects(-1))
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if err := s.conn.Publish(s.subject, data); err != nil {
+		return err
+	}
+	return s.conn.FlushTimeout(NATSTimeout)
+}
+
+func (s *natsSink) Close() error {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	return nil
+}
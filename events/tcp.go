@@ -0,0 +1,53 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package events
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// TCPTimeout bounds connecting to and writing to a TCP sink.
+var TCPTimeout = 5 * time.Second
+
+// tcpSink writes the events to a TCP connection, which it makes again after
+// an error.  The event being sent then is lost.
+type tcpSink struct {
+	address string
+	conn    net.Conn
+}
+
+// NewTCPSink makes a sink that connects to address when it first sends.
+func NewTCPSink(address string) (Sink, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+	return &tcpSink{address: address}, nil
+}
+
+func (s *tcpSink) Send(data []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout("tcp", s.address, TCPTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(TCPTimeout))
+	if _, err := s.conn.Write(data); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *tcpSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebhookTimeout bounds each POST to a webhook.
+var WebhookTimeout = 10 * time.Second
+
+// webhookSink POSTs each event to a URL.  An event the webhook doesn't take
+// is lost.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink makes a sink that POSTs to the http or https URL.  The
+// events should be encoded as JSON, as that is the content type sent.
+func NewWebhookSink(address string) (Sink, error) {
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", address)
+	}
+	return &webhookSink{url: address, client: &http.Client{Timeout: WebhookTimeout}}, nil
+}
+
+func (s *webhookSink) Send(data []byte) error {
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+//go:build zeromq
+// +build zeromq
+
+package events
+
+import (
+	zmq "github.com/pebbe/zmq4"
+)
+
+// The ZeroMQ client links libzmq, so its sink is only in builds with the
+// zeromq tag:
+//
+//	go build -tags zeromq
+
+func init() {
+	RegisterSink("zeromq", NewZeroMQSink)
+}
+
+// zeromqSink publishes each event as a message of a PUB socket.  Subscribers
+// that are too slow miss events, as ZeroMQ drops them past its high water
+// mark rather than blocking.
+type zeromqSink struct {
+	socket *zmq.Socket
+}
+
+// NewZeroMQSink makes a sink whose PUB socket binds to the endpoint, such as
+// "tcp://*:5556", for subscribers to connect to.  Each event is a message of
+// its own, so subscribing to "" gets them all.
+func NewZeroMQSink(address string) (Sink, error) {
+	socket, err := zmq.NewSocket(zmq.PUB)
+	if err != nil {
+		return nil, err
+	}
+	if err := socket.Bind(address); err != nil {
+		socket.Close()
+		return nil, err
+	}
+	return &zeromqSink{socket: socket}, nil
+}
+
+func (s *zeromqSink) Send(data []byte) error {
+	_, err := s.socket.SendBytes(data, 0)
+	return err
+}
+
+func (s *zeromqSink) Close() error {
+	return s.socket.Close()
+}
@@ -0,0 +1,16 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package grpcapi serves the gRPC API of factomd, whose schema is
+// factomd.proto.  It has the query and submission methods of wsapi, answered
+// by the same handlers, and streams the events of the /live WebSocket, for
+// clients in languages with gRPC code generators.  It is served on GRPCPort,
+// if that is set, with the logins, tokens and TLS of the JSON API.
+//
+// The Go code of the schema is generated with protoc and protoc-gen-go:
+//
+//	go generate github.com/FactomProject/factomd/grpcapi
+package grpcapi
+
+//go:generate protoc --go_out=plugins=grpc:. factomd.proto
@@ -0,0 +1,1461 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// The Go code of factomd.proto, in the form protoc-gen-go gives it, so that
+// go generate may replace it.
+
+package grpcapi
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+const _ = proto.ProtoPackageIsVersion2
+
+type HeightsRequest struct {
+}
+
+func (m *HeightsRequest) Reset()         { *m = HeightsRequest{} }
+func (m *HeightsRequest) String() string { return proto.CompactTextString(m) }
+func (*HeightsRequest) ProtoMessage()    {}
+
+type HeightsResponse struct {
+	DirectoryBlockHeight int64 `protobuf:"varint,1,opt,name=directory_block_height,json=directoryBlockHeight,proto3" json:"directory_block_height,omitempty"`
+	LeaderHeight         int64 `protobuf:"varint,2,opt,name=leader_height,json=leaderHeight,proto3" json:"leader_height,omitempty"`
+	EntryBlockHeight     int64 `protobuf:"varint,3,opt,name=entry_block_height,json=entryBlockHeight,proto3" json:"entry_block_height,omitempty"`
+	EntryHeight          int64 `protobuf:"varint,4,opt,name=entry_height,json=entryHeight,proto3" json:"entry_height,omitempty"`
+	MissingEntryCount    int64 `protobuf:"varint,5,opt,name=missing_entry_count,json=missingEntryCount,proto3" json:"missing_entry_count,omitempty"`
+}
+
+func (m *HeightsResponse) Reset()         { *m = HeightsResponse{} }
+func (m *HeightsResponse) String() string { return proto.CompactTextString(m) }
+func (*HeightsResponse) ProtoMessage()    {}
+
+func (m *HeightsResponse) GetDirectoryBlockHeight() int64 {
+	if m != nil {
+		return m.DirectoryBlockHeight
+	}
+	return 0
+}
+
+func (m *HeightsResponse) GetLeaderHeight() int64 {
+	if m != nil {
+		return m.LeaderHeight
+	}
+	return 0
+}
+
+func (m *HeightsResponse) GetEntryBlockHeight() int64 {
+	if m != nil {
+		return m.EntryBlockHeight
+	}
+	return 0
+}
+
+func (m *HeightsResponse) GetEntryHeight() int64 {
+	if m != nil {
+		return m.EntryHeight
+	}
+	return 0
+}
+
+func (m *HeightsResponse) GetMissingEntryCount() int64 {
+	if m != nil {
+		return m.MissingEntryCount
+	}
+	return 0
+}
+
+type PropertiesRequest struct {
+}
+
+func (m *PropertiesRequest) Reset()         { *m = PropertiesRequest{} }
+func (m *PropertiesRequest) String() string { return proto.CompactTextString(m) }
+func (*PropertiesRequest) ProtoMessage()    {}
+
+type PropertiesResponse struct {
+	FactomdVersion  string `protobuf:"bytes,1,opt,name=factomd_version,json=factomdVersion,proto3" json:"factomd_version,omitempty"`
+	ApiVersion      string `protobuf:"bytes,2,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
+	ProtocolVersion int32  `protobuf:"varint,3,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	NetworkName     string `protobuf:"bytes,4,opt,name=network_name,json=networkName,proto3" json:"network_name,omitempty"`
+	NodeRole        string `protobuf:"bytes,5,opt,name=node_role,json=nodeRole,proto3" json:"node_role,omitempty"`
+}
+
+func (m *PropertiesResponse) Reset()         { *m = PropertiesResponse{} }
+func (m *PropertiesResponse) String() string { return proto.CompactTextString(m) }
+func (*PropertiesResponse) ProtoMessage()    {}
+
+func (m *PropertiesResponse) GetFactomdVersion() string {
+	if m != nil {
+		return m.FactomdVersion
+	}
+	return ""
+}
+
+func (m *PropertiesResponse) GetApiVersion() string {
+	if m != nil {
+		return m.ApiVersion
+	}
+	return ""
+}
+
+func (m *PropertiesResponse) GetProtocolVersion() int32 {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *PropertiesResponse) GetNetworkName() string {
+	if m != nil {
+		return m.NetworkName
+	}
+	return ""
+}
+
+func (m *PropertiesResponse) GetNodeRole() string {
+	if m != nil {
+		return m.NodeRole
+	}
+	return ""
+}
+
+type KeyMRRequest struct {
+	KeyMr []byte `protobuf:"bytes,1,opt,name=key_mr,json=keyMr,proto3" json:"key_mr,omitempty"`
+}
+
+func (m *KeyMRRequest) Reset()         { *m = KeyMRRequest{} }
+func (m *KeyMRRequest) String() string { return proto.CompactTextString(m) }
+func (*KeyMRRequest) ProtoMessage()    {}
+
+func (m *KeyMRRequest) GetKeyMr() []byte {
+	if m != nil {
+		return m.KeyMr
+	}
+	return nil
+}
+
+type HashRequest struct {
+	Hash []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *HashRequest) Reset()         { *m = HashRequest{} }
+func (m *HashRequest) String() string { return proto.CompactTextString(m) }
+func (*HashRequest) ProtoMessage()    {}
+
+func (m *HashRequest) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type ChainIDRequest struct {
+	ChainId []byte `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+}
+
+func (m *ChainIDRequest) Reset()         { *m = ChainIDRequest{} }
+func (m *ChainIDRequest) String() string { return proto.CompactTextString(m) }
+func (*ChainIDRequest) ProtoMessage()    {}
+
+func (m *ChainIDRequest) GetChainId() []byte {
+	if m != nil {
+		return m.ChainId
+	}
+	return nil
+}
+
+type AddressRequest struct {
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (m *AddressRequest) Reset()         { *m = AddressRequest{} }
+func (m *AddressRequest) String() string { return proto.CompactTextString(m) }
+func (*AddressRequest) ProtoMessage()    {}
+
+func (m *AddressRequest) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+type EBlockAddr struct {
+	ChainId []byte `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	KeyMr   []byte `protobuf:"bytes,2,opt,name=key_mr,json=keyMr,proto3" json:"key_mr,omitempty"`
+}
+
+func (m *EBlockAddr) Reset()         { *m = EBlockAddr{} }
+func (m *EBlockAddr) String() string { return proto.CompactTextString(m) }
+func (*EBlockAddr) ProtoMessage()    {}
+
+func (m *EBlockAddr) GetChainId() []byte {
+	if m != nil {
+		return m.ChainId
+	}
+	return nil
+}
+
+func (m *EBlockAddr) GetKeyMr() []byte {
+	if m != nil {
+		return m.KeyMr
+	}
+	return nil
+}
+
+type DirectoryBlockResponse struct {
+	PrevBlockKeyMr []byte        `protobuf:"bytes,1,opt,name=prev_block_key_mr,json=prevBlockKeyMr,proto3" json:"prev_block_key_mr,omitempty"`
+	SequenceNumber int64         `protobuf:"varint,2,opt,name=sequence_number,json=sequenceNumber,proto3" json:"sequence_number,omitempty"`
+	Timestamp      int64         `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	EntryBlockList []*EBlockAddr `protobuf:"bytes,4,rep,name=entry_block_list,json=entryBlockList" json:"entry_block_list,omitempty"`
+}
+
+func (m *DirectoryBlockResponse) Reset()         { *m = DirectoryBlockResponse{} }
+func (m *DirectoryBlockResponse) String() string { return proto.CompactTextString(m) }
+func (*DirectoryBlockResponse) ProtoMessage()    {}
+
+func (m *DirectoryBlockResponse) GetPrevBlockKeyMr() []byte {
+	if m != nil {
+		return m.PrevBlockKeyMr
+	}
+	return nil
+}
+
+func (m *DirectoryBlockResponse) GetSequenceNumber() int64 {
+	if m != nil {
+		return m.SequenceNumber
+	}
+	return 0
+}
+
+func (m *DirectoryBlockResponse) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *DirectoryBlockResponse) GetEntryBlockList() []*EBlockAddr {
+	if m != nil {
+		return m.EntryBlockList
+	}
+	return nil
+}
+
+type EntryBlockResponse struct {
+	BlockSequenceNumber int64        `protobuf:"varint,1,opt,name=block_sequence_number,json=blockSequenceNumber,proto3" json:"block_sequence_number,omitempty"`
+	ChainId             []byte       `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	PrevKeyMr           []byte       `protobuf:"bytes,3,opt,name=prev_key_mr,json=prevKeyMr,proto3" json:"prev_key_mr,omitempty"`
+	Timestamp           int64        `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	DbHeight            int64        `protobuf:"varint,5,opt,name=db_height,json=dbHeight,proto3" json:"db_height,omitempty"`
+	EntryList           []*EntryAddr `protobuf:"bytes,6,rep,name=entry_list,json=entryList" json:"entry_list,omitempty"`
+}
+
+func (m *EntryBlockResponse) Reset()         { *m = EntryBlockResponse{} }
+func (m *EntryBlockResponse) String() string { return proto.CompactTextString(m) }
+func (*EntryBlockResponse) ProtoMessage()    {}
+
+func (m *EntryBlockResponse) GetBlockSequenceNumber() int64 {
+	if m != nil {
+		return m.BlockSequenceNumber
+	}
+	return 0
+}
+
+func (m *EntryBlockResponse) GetChainId() []byte {
+	if m != nil {
+		return m.ChainId
+	}
+	return nil
+}
+
+func (m *EntryBlockResponse) GetPrevKeyMr() []byte {
+	if m != nil {
+		return m.PrevKeyMr
+	}
+	return nil
+}
+
+func (m *EntryBlockResponse) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *EntryBlockResponse) GetDbHeight() int64 {
+	if m != nil {
+		return m.DbHeight
+	}
+	return 0
+}
+
+func (m *EntryBlockResponse) GetEntryList() []*EntryAddr {
+	if m != nil {
+		return m.EntryList
+	}
+	return nil
+}
+
+type EntryAddr struct {
+	EntryHash []byte `protobuf:"bytes,1,opt,name=entry_hash,json=entryHash,proto3" json:"entry_hash,omitempty"`
+	Timestamp int64  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (m *EntryAddr) Reset()         { *m = EntryAddr{} }
+func (m *EntryAddr) String() string { return proto.CompactTextString(m) }
+func (*EntryAddr) ProtoMessage()    {}
+
+func (m *EntryAddr) GetEntryHash() []byte {
+	if m != nil {
+		return m.EntryHash
+	}
+	return nil
+}
+
+func (m *EntryAddr) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+type EntryResponse struct {
+	ChainId []byte   `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Content []byte   `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	ExtIds  [][]byte `protobuf:"bytes,3,rep,name=ext_ids,json=extIds,proto3" json:"ext_ids,omitempty"`
+}
+
+func (m *EntryResponse) Reset()         { *m = EntryResponse{} }
+func (m *EntryResponse) String() string { return proto.CompactTextString(m) }
+func (*EntryResponse) ProtoMessage()    {}
+
+func (m *EntryResponse) GetChainId() []byte {
+	if m != nil {
+		return m.ChainId
+	}
+	return nil
+}
+
+func (m *EntryResponse) GetContent() []byte {
+	if m != nil {
+		return m.Content
+	}
+	return nil
+}
+
+func (m *EntryResponse) GetExtIds() [][]byte {
+	if m != nil {
+		return m.ExtIds
+	}
+	return nil
+}
+
+type ChainHeadResponse struct {
+	ChainHead          []byte `protobuf:"bytes,1,opt,name=chain_head,json=chainHead,proto3" json:"chain_head,omitempty"`
+	ChainInProcessList bool   `protobuf:"varint,2,opt,name=chain_in_process_list,json=chainInProcessList,proto3" json:"chain_in_process_list,omitempty"`
+}
+
+func (m *ChainHeadResponse) Reset()         { *m = ChainHeadResponse{} }
+func (m *ChainHeadResponse) String() string { return proto.CompactTextString(m) }
+func (*ChainHeadResponse) ProtoMessage()    {}
+
+func (m *ChainHeadResponse) GetChainHead() []byte {
+	if m != nil {
+		return m.ChainHead
+	}
+	return nil
+}
+
+func (m *ChainHeadResponse) GetChainInProcessList() bool {
+	if m != nil {
+		return m.ChainInProcessList
+	}
+	return false
+}
+
+type TransactionResponse struct {
+	// The marshalled factoid or entry credit transaction, or entry
+	Transaction                    []byte `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	IncludedInTransactionBlock     []byte `protobuf:"bytes,2,opt,name=included_in_transaction_block,json=includedInTransactionBlock,proto3" json:"included_in_transaction_block,omitempty"`
+	IncludedInDirectoryBlock       []byte `protobuf:"bytes,3,opt,name=included_in_directory_block,json=includedInDirectoryBlock,proto3" json:"included_in_directory_block,omitempty"`
+	IncludedInDirectoryBlockHeight int64  `protobuf:"varint,4,opt,name=included_in_directory_block_height,json=includedInDirectoryBlockHeight,proto3" json:"included_in_directory_block_height,omitempty"`
+}
+
+func (m *TransactionResponse) Reset()         { *m = TransactionResponse{} }
+func (m *TransactionResponse) String() string { return proto.CompactTextString(m) }
+func (*TransactionResponse) ProtoMessage()    {}
+
+func (m *TransactionResponse) GetTransaction() []byte {
+	if m != nil {
+		return m.Transaction
+	}
+	return nil
+}
+
+func (m *TransactionResponse) GetIncludedInTransactionBlock() []byte {
+	if m != nil {
+		return m.IncludedInTransactionBlock
+	}
+	return nil
+}
+
+func (m *TransactionResponse) GetIncludedInDirectoryBlock() []byte {
+	if m != nil {
+		return m.IncludedInDirectoryBlock
+	}
+	return nil
+}
+
+func (m *TransactionResponse) GetIncludedInDirectoryBlockHeight() int64 {
+	if m != nil {
+		return m.IncludedInDirectoryBlockHeight
+	}
+	return 0
+}
+
+type BalanceResponse struct {
+	Balance int64 `protobuf:"varint,1,opt,name=balance,proto3" json:"balance,omitempty"`
+}
+
+func (m *BalanceResponse) Reset()         { *m = BalanceResponse{} }
+func (m *BalanceResponse) String() string { return proto.CompactTextString(m) }
+func (*BalanceResponse) ProtoMessage()    {}
+
+func (m *BalanceResponse) GetBalance() int64 {
+	if m != nil {
+		return m.Balance
+	}
+	return 0
+}
+
+type EntryCreditRateRequest struct {
+}
+
+func (m *EntryCreditRateRequest) Reset()         { *m = EntryCreditRateRequest{} }
+func (m *EntryCreditRateRequest) String() string { return proto.CompactTextString(m) }
+func (*EntryCreditRateRequest) ProtoMessage()    {}
+
+type EntryCreditRateResponse struct {
+	Rate int64 `protobuf:"varint,1,opt,name=rate,proto3" json:"rate,omitempty"`
+}
+
+func (m *EntryCreditRateResponse) Reset()         { *m = EntryCreditRateResponse{} }
+func (m *EntryCreditRateResponse) String() string { return proto.CompactTextString(m) }
+func (*EntryCreditRateResponse) ProtoMessage()    {}
+
+func (m *EntryCreditRateResponse) GetRate() int64 {
+	if m != nil {
+		return m.Rate
+	}
+	return 0
+}
+
+type AckRequest struct {
+	Hash    []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	ChainId []byte `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	// The full transaction or commit, to ack one that hasn't arrived yet
+	FullTransaction []byte `protobuf:"bytes,3,opt,name=full_transaction,json=fullTransaction,proto3" json:"full_transaction,omitempty"`
+}
+
+func (m *AckRequest) Reset()         { *m = AckRequest{} }
+func (m *AckRequest) String() string { return proto.CompactTextString(m) }
+func (*AckRequest) ProtoMessage()    {}
+
+func (m *AckRequest) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *AckRequest) GetChainId() []byte {
+	if m != nil {
+		return m.ChainId
+	}
+	return nil
+}
+
+func (m *AckRequest) GetFullTransaction() []byte {
+	if m != nil {
+		return m.FullTransaction
+	}
+	return nil
+}
+
+type AckResponse struct {
+	// "Unknown", "NotConfirmed", "TransactionACK" or "DBlockConfirmed"
+	Status    string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	BlockDate int64  `protobuf:"varint,2,opt,name=block_date,json=blockDate,proto3" json:"block_date,omitempty"`
+	DbHeight  uint32 `protobuf:"varint,3,opt,name=db_height,json=dbHeight,proto3" json:"db_height,omitempty"`
+	Minute    int32  `protobuf:"varint,4,opt,name=minute,proto3" json:"minute,omitempty"`
+}
+
+func (m *AckResponse) Reset()         { *m = AckResponse{} }
+func (m *AckResponse) String() string { return proto.CompactTextString(m) }
+func (*AckResponse) ProtoMessage()    {}
+
+func (m *AckResponse) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *AckResponse) GetBlockDate() int64 {
+	if m != nil {
+		return m.BlockDate
+	}
+	return 0
+}
+
+func (m *AckResponse) GetDbHeight() uint32 {
+	if m != nil {
+		return m.DbHeight
+	}
+	return 0
+}
+
+func (m *AckResponse) GetMinute() int32 {
+	if m != nil {
+		return m.Minute
+	}
+	return 0
+}
+
+type MessageRequest struct {
+	// The marshalled commit
+	Message []byte `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (m *MessageRequest) Reset()         { *m = MessageRequest{} }
+func (m *MessageRequest) String() string { return proto.CompactTextString(m) }
+func (*MessageRequest) ProtoMessage()    {}
+
+func (m *MessageRequest) GetMessage() []byte {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+type CommitResponse struct {
+	TxId      []byte `protobuf:"bytes,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	EntryHash []byte `protobuf:"bytes,2,opt,name=entry_hash,json=entryHash,proto3" json:"entry_hash,omitempty"`
+	// For commit-chain
+	ChainIdHash []byte `protobuf:"bytes,3,opt,name=chain_id_hash,json=chainIdHash,proto3" json:"chain_id_hash,omitempty"`
+}
+
+func (m *CommitResponse) Reset()         { *m = CommitResponse{} }
+func (m *CommitResponse) String() string { return proto.CompactTextString(m) }
+func (*CommitResponse) ProtoMessage()    {}
+
+func (m *CommitResponse) GetTxId() []byte {
+	if m != nil {
+		return m.TxId
+	}
+	return nil
+}
+
+func (m *CommitResponse) GetEntryHash() []byte {
+	if m != nil {
+		return m.EntryHash
+	}
+	return nil
+}
+
+func (m *CommitResponse) GetChainIdHash() []byte {
+	if m != nil {
+		return m.ChainIdHash
+	}
+	return nil
+}
+
+type EntryRequest struct {
+	// The marshalled entry
+	Entry []byte `protobuf:"bytes,1,opt,name=entry,proto3" json:"entry,omitempty"`
+}
+
+func (m *EntryRequest) Reset()         { *m = EntryRequest{} }
+func (m *EntryRequest) String() string { return proto.CompactTextString(m) }
+func (*EntryRequest) ProtoMessage()    {}
+
+func (m *EntryRequest) GetEntry() []byte {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
+}
+
+type RevealResponse struct {
+	EntryHash []byte `protobuf:"bytes,1,opt,name=entry_hash,json=entryHash,proto3" json:"entry_hash,omitempty"`
+	ChainId   []byte `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+}
+
+func (m *RevealResponse) Reset()         { *m = RevealResponse{} }
+func (m *RevealResponse) String() string { return proto.CompactTextString(m) }
+func (*RevealResponse) ProtoMessage()    {}
+
+func (m *RevealResponse) GetEntryHash() []byte {
+	if m != nil {
+		return m.EntryHash
+	}
+	return nil
+}
+
+func (m *RevealResponse) GetChainId() []byte {
+	if m != nil {
+		return m.ChainId
+	}
+	return nil
+}
+
+type TransactionRequest struct {
+	// The marshalled factoid transaction
+	Transaction []byte `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+}
+
+func (m *TransactionRequest) Reset()         { *m = TransactionRequest{} }
+func (m *TransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*TransactionRequest) ProtoMessage()    {}
+
+func (m *TransactionRequest) GetTransaction() []byte {
+	if m != nil {
+		return m.Transaction
+	}
+	return nil
+}
+
+type FactoidSubmitResponse struct {
+	TxId []byte `protobuf:"bytes,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+}
+
+func (m *FactoidSubmitResponse) Reset()         { *m = FactoidSubmitResponse{} }
+func (m *FactoidSubmitResponse) String() string { return proto.CompactTextString(m) }
+func (*FactoidSubmitResponse) ProtoMessage()    {}
+
+func (m *FactoidSubmitResponse) GetTxId() []byte {
+	if m != nil {
+		return m.TxId
+	}
+	return nil
+}
+
+type LiveRequest struct {
+	ChainIds [][]byte `protobuf:"bytes,1,rep,name=chain_ids,json=chainIds,proto3" json:"chain_ids,omitempty"`
+}
+
+func (m *LiveRequest) Reset()         { *m = LiveRequest{} }
+func (m *LiveRequest) String() string { return proto.CompactTextString(m) }
+func (*LiveRequest) ProtoMessage()    {}
+
+func (m *LiveRequest) GetChainIds() [][]byte {
+	if m != nil {
+		return m.ChainIds
+	}
+	return nil
+}
+
+// What the event sinks of factomd send with Format = protobuf, each after its
+// length as a varint
+type Event struct {
+	// "dblock", "eblock", "entry-reveal" or "node-state", or the alerts "leader-fault", "isolated", "fork",
+	// "db-write-failure" or "sync-stalled"
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Node string `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	// Unix milliseconds
+	Time      int64  `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Height    uint32 `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	KeyMr     []byte `protobuf:"bytes,5,opt,name=key_mr,json=keyMr,proto3" json:"key_mr,omitempty"`
+	ChainId   []byte `protobuf:"bytes,6,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	EntryHash []byte `protobuf:"bytes,7,opt,name=entry_hash,json=entryHash,proto3" json:"entry_hash,omitempty"`
+	// For node-state: "leader", "audit" or "follower"
+	State string `protobuf:"bytes,8,opt,name=state,proto3" json:"state,omitempty"`
+	// For alerts
+	Message string `protobuf:"bytes,9,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+
+func (m *Event) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Event) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *Event) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *Event) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *Event) GetKeyMr() []byte {
+	if m != nil {
+		return m.KeyMr
+	}
+	return nil
+}
+
+func (m *Event) GetChainId() []byte {
+	if m != nil {
+		return m.ChainId
+	}
+	return nil
+}
+
+func (m *Event) GetEntryHash() []byte {
+	if m != nil {
+		return m.EntryHash
+	}
+	return nil
+}
+
+func (m *Event) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *Event) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type LiveEvent struct {
+	// "dblock", "eblock", "transaction" or "authority", as on /live
+	Event   string `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	Height  uint32 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	KeyMr   []byte `protobuf:"bytes,3,opt,name=key_mr,json=keyMr,proto3" json:"key_mr,omitempty"`
+	ChainId []byte `protobuf:"bytes,4,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	TxId    []byte `protobuf:"bytes,5,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Change  string `protobuf:"bytes,6,opt,name=change,proto3" json:"change,omitempty"`
+}
+
+func (m *LiveEvent) Reset()         { *m = LiveEvent{} }
+func (m *LiveEvent) String() string { return proto.CompactTextString(m) }
+func (*LiveEvent) ProtoMessage()    {}
+
+func (m *LiveEvent) GetEvent() string {
+	if m != nil {
+		return m.Event
+	}
+	return ""
+}
+
+func (m *LiveEvent) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *LiveEvent) GetKeyMr() []byte {
+	if m != nil {
+		return m.KeyMr
+	}
+	return nil
+}
+
+func (m *LiveEvent) GetChainId() []byte {
+	if m != nil {
+		return m.ChainId
+	}
+	return nil
+}
+
+func (m *LiveEvent) GetTxId() []byte {
+	if m != nil {
+		return m.TxId
+	}
+	return nil
+}
+
+func (m *LiveEvent) GetChange() string {
+	if m != nil {
+		return m.Change
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*HeightsRequest)(nil), "grpcapi.HeightsRequest")
+	proto.RegisterType((*HeightsResponse)(nil), "grpcapi.HeightsResponse")
+	proto.RegisterType((*PropertiesRequest)(nil), "grpcapi.PropertiesRequest")
+	proto.RegisterType((*PropertiesResponse)(nil), "grpcapi.PropertiesResponse")
+	proto.RegisterType((*KeyMRRequest)(nil), "grpcapi.KeyMRRequest")
+	proto.RegisterType((*HashRequest)(nil), "grpcapi.HashRequest")
+	proto.RegisterType((*ChainIDRequest)(nil), "grpcapi.ChainIDRequest")
+	proto.RegisterType((*AddressRequest)(nil), "grpcapi.AddressRequest")
+	proto.RegisterType((*EBlockAddr)(nil), "grpcapi.EBlockAddr")
+	proto.RegisterType((*DirectoryBlockResponse)(nil), "grpcapi.DirectoryBlockResponse")
+	proto.RegisterType((*EntryBlockResponse)(nil), "grpcapi.EntryBlockResponse")
+	proto.RegisterType((*EntryAddr)(nil), "grpcapi.EntryAddr")
+	proto.RegisterType((*EntryResponse)(nil), "grpcapi.EntryResponse")
+	proto.RegisterType((*ChainHeadResponse)(nil), "grpcapi.ChainHeadResponse")
+	proto.RegisterType((*TransactionResponse)(nil), "grpcapi.TransactionResponse")
+	proto.RegisterType((*BalanceResponse)(nil), "grpcapi.BalanceResponse")
+	proto.RegisterType((*EntryCreditRateRequest)(nil), "grpcapi.EntryCreditRateRequest")
+	proto.RegisterType((*EntryCreditRateResponse)(nil), "grpcapi.EntryCreditRateResponse")
+	proto.RegisterType((*AckRequest)(nil), "grpcapi.AckRequest")
+	proto.RegisterType((*AckResponse)(nil), "grpcapi.AckResponse")
+	proto.RegisterType((*MessageRequest)(nil), "grpcapi.MessageRequest")
+	proto.RegisterType((*CommitResponse)(nil), "grpcapi.CommitResponse")
+	proto.RegisterType((*EntryRequest)(nil), "grpcapi.EntryRequest")
+	proto.RegisterType((*RevealResponse)(nil), "grpcapi.RevealResponse")
+	proto.RegisterType((*TransactionRequest)(nil), "grpcapi.TransactionRequest")
+	proto.RegisterType((*FactoidSubmitResponse)(nil), "grpcapi.FactoidSubmitResponse")
+	proto.RegisterType((*LiveRequest)(nil), "grpcapi.LiveRequest")
+	proto.RegisterType((*Event)(nil), "grpcapi.Event")
+	proto.RegisterType((*LiveEvent)(nil), "grpcapi.LiveEvent")
+}
+
+// Client API for Factomd service
+
+type FactomdClient interface {
+	Heights(ctx context.Context, in *HeightsRequest, opts ...grpc.CallOption) (*HeightsResponse, error)
+	Properties(ctx context.Context, in *PropertiesRequest, opts ...grpc.CallOption) (*PropertiesResponse, error)
+	DirectoryBlock(ctx context.Context, in *KeyMRRequest, opts ...grpc.CallOption) (*DirectoryBlockResponse, error)
+	EntryBlock(ctx context.Context, in *KeyMRRequest, opts ...grpc.CallOption) (*EntryBlockResponse, error)
+	Entry(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*EntryResponse, error)
+	ChainHead(ctx context.Context, in *ChainIDRequest, opts ...grpc.CallOption) (*ChainHeadResponse, error)
+	Transaction(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	FactoidBalance(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
+	EntryCreditBalance(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
+	EntryCreditRate(ctx context.Context, in *EntryCreditRateRequest, opts ...grpc.CallOption) (*EntryCreditRateResponse, error)
+	EntryAck(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error)
+	FactoidAck(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error)
+	CommitChain(ctx context.Context, in *MessageRequest, opts ...grpc.CallOption) (*CommitResponse, error)
+	CommitEntry(ctx context.Context, in *MessageRequest, opts ...grpc.CallOption) (*CommitResponse, error)
+	RevealEntry(ctx context.Context, in *EntryRequest, opts ...grpc.CallOption) (*RevealResponse, error)
+	FactoidSubmit(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*FactoidSubmitResponse, error)
+	Live(ctx context.Context, in *LiveRequest, opts ...grpc.CallOption) (Factomd_LiveClient, error)
+}
+
+type factomdClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewFactomdClient(cc *grpc.ClientConn) FactomdClient {
+	return &factomdClient{cc}
+}
+
+func (c *factomdClient) Heights(ctx context.Context, in *HeightsRequest, opts ...grpc.CallOption) (*HeightsResponse, error) {
+	out := new(HeightsResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/Heights", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) Properties(ctx context.Context, in *PropertiesRequest, opts ...grpc.CallOption) (*PropertiesResponse, error) {
+	out := new(PropertiesResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/Properties", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) DirectoryBlock(ctx context.Context, in *KeyMRRequest, opts ...grpc.CallOption) (*DirectoryBlockResponse, error) {
+	out := new(DirectoryBlockResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/DirectoryBlock", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) EntryBlock(ctx context.Context, in *KeyMRRequest, opts ...grpc.CallOption) (*EntryBlockResponse, error) {
+	out := new(EntryBlockResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/EntryBlock", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) Entry(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*EntryResponse, error) {
+	out := new(EntryResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/Entry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) ChainHead(ctx context.Context, in *ChainIDRequest, opts ...grpc.CallOption) (*ChainHeadResponse, error) {
+	out := new(ChainHeadResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/ChainHead", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) Transaction(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/Transaction", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) FactoidBalance(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*BalanceResponse, error) {
+	out := new(BalanceResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/FactoidBalance", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) EntryCreditBalance(ctx context.Context, in *AddressRequest, opts ...grpc.CallOption) (*BalanceResponse, error) {
+	out := new(BalanceResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/EntryCreditBalance", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) EntryCreditRate(ctx context.Context, in *EntryCreditRateRequest, opts ...grpc.CallOption) (*EntryCreditRateResponse, error) {
+	out := new(EntryCreditRateResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/EntryCreditRate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) EntryAck(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error) {
+	out := new(AckResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/EntryAck", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) FactoidAck(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error) {
+	out := new(AckResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/FactoidAck", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) CommitChain(ctx context.Context, in *MessageRequest, opts ...grpc.CallOption) (*CommitResponse, error) {
+	out := new(CommitResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/CommitChain", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) CommitEntry(ctx context.Context, in *MessageRequest, opts ...grpc.CallOption) (*CommitResponse, error) {
+	out := new(CommitResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/CommitEntry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) RevealEntry(ctx context.Context, in *EntryRequest, opts ...grpc.CallOption) (*RevealResponse, error) {
+	out := new(RevealResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/RevealEntry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) FactoidSubmit(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*FactoidSubmitResponse, error) {
+	out := new(FactoidSubmitResponse)
+	err := grpc.Invoke(ctx, "/grpcapi.Factomd/FactoidSubmit", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *factomdClient) Live(ctx context.Context, in *LiveRequest, opts ...grpc.CallOption) (Factomd_LiveClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Factomd_serviceDesc.Streams[0], c.cc, "/grpcapi.Factomd/Live", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &factomdLiveClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Factomd_LiveClient interface {
+	Recv() (*LiveEvent, error)
+	grpc.ClientStream
+}
+
+type factomdLiveClient struct {
+	grpc.ClientStream
+}
+
+func (x *factomdLiveClient) Recv() (*LiveEvent, error) {
+	m := new(LiveEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for Factomd service
+
+type FactomdServer interface {
+	Heights(context.Context, *HeightsRequest) (*HeightsResponse, error)
+	Properties(context.Context, *PropertiesRequest) (*PropertiesResponse, error)
+	DirectoryBlock(context.Context, *KeyMRRequest) (*DirectoryBlockResponse, error)
+	EntryBlock(context.Context, *KeyMRRequest) (*EntryBlockResponse, error)
+	Entry(context.Context, *HashRequest) (*EntryResponse, error)
+	ChainHead(context.Context, *ChainIDRequest) (*ChainHeadResponse, error)
+	Transaction(context.Context, *HashRequest) (*TransactionResponse, error)
+	FactoidBalance(context.Context, *AddressRequest) (*BalanceResponse, error)
+	EntryCreditBalance(context.Context, *AddressRequest) (*BalanceResponse, error)
+	EntryCreditRate(context.Context, *EntryCreditRateRequest) (*EntryCreditRateResponse, error)
+	EntryAck(context.Context, *AckRequest) (*AckResponse, error)
+	FactoidAck(context.Context, *AckRequest) (*AckResponse, error)
+	CommitChain(context.Context, *MessageRequest) (*CommitResponse, error)
+	CommitEntry(context.Context, *MessageRequest) (*CommitResponse, error)
+	RevealEntry(context.Context, *EntryRequest) (*RevealResponse, error)
+	FactoidSubmit(context.Context, *TransactionRequest) (*FactoidSubmitResponse, error)
+	Live(*LiveRequest, Factomd_LiveServer) error
+}
+
+func RegisterFactomdServer(s *grpc.Server, srv FactomdServer) {
+	s.RegisterService(&_Factomd_serviceDesc, srv)
+}
+
+func _Factomd_Heights_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeightsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).Heights(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/Heights",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).Heights(ctx, req.(*HeightsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_Properties_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PropertiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).Properties(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/Properties",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).Properties(ctx, req.(*PropertiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_DirectoryBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyMRRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).DirectoryBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/DirectoryBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).DirectoryBlock(ctx, req.(*KeyMRRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_EntryBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyMRRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).EntryBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/EntryBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).EntryBlock(ctx, req.(*KeyMRRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_Entry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).Entry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/Entry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).Entry(ctx, req.(*HashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_ChainHead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChainIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).ChainHead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/ChainHead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).ChainHead(ctx, req.(*ChainIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_Transaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).Transaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/Transaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).Transaction(ctx, req.(*HashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_FactoidBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).FactoidBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/FactoidBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).FactoidBalance(ctx, req.(*AddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_EntryCreditBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).EntryCreditBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/EntryCreditBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).EntryCreditBalance(ctx, req.(*AddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_EntryCreditRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EntryCreditRateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).EntryCreditRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/EntryCreditRate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).EntryCreditRate(ctx, req.(*EntryCreditRateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_EntryAck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).EntryAck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/EntryAck",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).EntryAck(ctx, req.(*AckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_FactoidAck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).FactoidAck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/FactoidAck",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).FactoidAck(ctx, req.(*AckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_CommitChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).CommitChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/CommitChain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).CommitChain(ctx, req.(*MessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_CommitEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).CommitEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/CommitEntry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).CommitEntry(ctx, req.(*MessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_RevealEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).RevealEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/RevealEntry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).RevealEntry(ctx, req.(*EntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_FactoidSubmit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FactomdServer).FactoidSubmit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcapi.Factomd/FactoidSubmit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FactomdServer).FactoidSubmit(ctx, req.(*TransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Factomd_Live_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LiveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FactomdServer).Live(m, &factomdLiveServer{stream})
+}
+
+type Factomd_LiveServer interface {
+	Send(*LiveEvent) error
+	grpc.ServerStream
+}
+
+type factomdLiveServer struct {
+	grpc.ServerStream
+}
+
+func (x *factomdLiveServer) Send(m *LiveEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Factomd_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "grpcapi.Factomd",
+	HandlerType: (*FactomdServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Heights",
+			Handler:    _Factomd_Heights_Handler,
+		},
+		{
+			MethodName: "Properties",
+			Handler:    _Factomd_Properties_Handler,
+		},
+		{
+			MethodName: "DirectoryBlock",
+			Handler:    _Factomd_DirectoryBlock_Handler,
+		},
+		{
+			MethodName: "EntryBlock",
+			Handler:    _Factomd_EntryBlock_Handler,
+		},
+		{
+			MethodName: "Entry",
+			Handler:    _Factomd_Entry_Handler,
+		},
+		{
+			MethodName: "ChainHead",
+			Handler:    _Factomd_ChainHead_Handler,
+		},
+		{
+			MethodName: "Transaction",
+			Handler:    _Factomd_Transaction_Handler,
+		},
+		{
+			MethodName: "FactoidBalance",
+			Handler:    _Factomd_FactoidBalance_Handler,
+		},
+		{
+			MethodName: "EntryCreditBalance",
+			Handler:    _Factomd_EntryCreditBalance_Handler,
+		},
+		{
+			MethodName: "EntryCreditRate",
+			Handler:    _Factomd_EntryCreditRate_Handler,
+		},
+		{
+			MethodName: "EntryAck",
+			Handler:    _Factomd_EntryAck_Handler,
+		},
+		{
+			MethodName: "FactoidAck",
+			Handler:    _Factomd_FactoidAck_Handler,
+		},
+		{
+			MethodName: "CommitChain",
+			Handler:    _Factomd_CommitChain_Handler,
+		},
+		{
+			MethodName: "CommitEntry",
+			Handler:    _Factomd_CommitEntry_Handler,
+		},
+		{
+			MethodName: "RevealEntry",
+			Handler:    _Factomd_RevealEntry_Handler,
+		},
+		{
+			MethodName: "FactoidSubmit",
+			Handler:    _Factomd_FactoidSubmit_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Live",
+			Handler:       _Factomd_Live_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "factomd.proto",
+}
@@ -0,0 +1,381 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package grpcapi
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/log"
+	"github.com/FactomProject/factomd/wsapi"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+var grpcLog = log.ForPackage(log.PackageWsapi).WithField("api", "grpc")
+
+// Server answers the gRPC API of a node with the v2 handlers of wsapi, so
+// that both APIs give the same answers.  Clients authenticate as they do for
+// /v2, with the Authorization header in the metadata of each call, and may
+// call the methods of the v2 method of the same name.
+type Server struct {
+	state interfaces.IState
+}
+
+func NewServer(state interfaces.IState) *Server {
+	return &Server{state: state}
+}
+
+// Start serves the gRPC API of the node on the port, encrypted if the node's
+// API is.  It only returns if the server fails.
+func Start(state interfaces.IState, port int) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		grpcLog.WithField("port", port).Errorf("Could not start the gRPC server: %v", err)
+		return
+	}
+
+	var opts []grpc.ServerOption
+	if tlsIsEnabled, tlsPrivate, tlsPublic := state.GetTlsInfo(); tlsIsEnabled {
+		// The key pair is made by wsapi if there isn't one
+		keypair, err := tls.LoadX509KeyPair(tlsPublic, tlsPrivate)
+		if err != nil {
+			grpcLog.WithField("port", port).Errorf("Could not load the TLS key pair: %v", err)
+			return
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{keypair},
+			MinVersion:   tls.VersionTLS12,
+		})))
+	}
+
+	server := grpc.NewServer(opts...)
+	RegisterFactomdServer(server, NewServer(state))
+	grpcLog.WithField("port", port).Infof("Starting gRPC server")
+	if err := server.Serve(listener); err != nil {
+		grpcLog.WithField("port", port).Errorf("gRPC server stopped: %v", err)
+	}
+}
+
+// call checks that the client of ctx may call the v2 method, then calls the
+// handler with params and maps its result into resp, a response of wsapi.
+func (s *Server) call(ctx context.Context, method string, handler func(interfaces.IState, interface{}) (interface{}, *primitives.JSONError), params interface{}, resp interface{}) error {
+	if err := s.authorize(ctx, method); err != nil {
+		return err
+	}
+	result, jsonErr := handler(s.state, params)
+	if jsonErr != nil {
+		return statusError(jsonErr)
+	}
+	if err := wsapi.MapToObject(result, resp); err != nil {
+		return grpc.Errorf(codes.Internal, "%v", err)
+	}
+	return nil
+}
+
+func (s *Server) authorize(ctx context.Context, method string) error {
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["authorization"]) > 0 {
+		authorization = md["authorization"][0]
+	}
+	if !wsapi.MethodAllowed(s.state, authorization, method) {
+		if authorization == "" {
+			return grpc.Errorf(codes.Unauthenticated, "%s needs a login or token", method)
+		}
+		return grpc.Errorf(codes.PermissionDenied, "%s is not allowed", method)
+	}
+	return nil
+}
+
+// statusError maps the errors of the v2 API to the status codes of gRPC.
+func statusError(err *primitives.JSONError) error {
+	code := codes.Internal
+	switch err.Code {
+	case -32602, -32600:
+		code = codes.InvalidArgument
+	case -32008, -32009:
+		code = codes.NotFound
+	}
+	if err.Data != nil {
+		return grpc.Errorf(code, "%s: %v", err.Message, err.Data)
+	}
+	return grpc.Errorf(code, "%s", err.Message)
+}
+
+// The hex of the answers of wsapi is well formed, so errors are not checked.
+func unhex(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+func (s *Server) Heights(ctx context.Context, req *HeightsRequest) (*HeightsResponse, error) {
+	r := new(wsapi.HeightsResponse)
+	if err := s.call(ctx, "heights", wsapi.HandleV2Heights, nil, r); err != nil {
+		return nil, err
+	}
+	return &HeightsResponse{
+		DirectoryBlockHeight: r.DirectoryBlockHeight,
+		LeaderHeight:         r.LeaderHeight,
+		EntryBlockHeight:     r.EntryBlockHeight,
+		EntryHeight:          r.EntryHeight,
+		MissingEntryCount:    r.MissingEntryCount,
+	}, nil
+}
+
+func (s *Server) Properties(ctx context.Context, req *PropertiesRequest) (*PropertiesResponse, error) {
+	r := new(wsapi.PropertiesResponse)
+	if err := s.call(ctx, "properties", wsapi.HandleV2Properties, nil, r); err != nil {
+		return nil, err
+	}
+	return &PropertiesResponse{
+		FactomdVersion:  r.FactomdVersion,
+		ApiVersion:      r.ApiVersion,
+		ProtocolVersion: int32(r.ProtocolVersion),
+		NetworkName:     r.NetworkName,
+		NodeRole:        r.NodeRole,
+	}, nil
+}
+
+func (s *Server) DirectoryBlock(ctx context.Context, req *KeyMRRequest) (*DirectoryBlockResponse, error) {
+	r := new(wsapi.DirectoryBlockResponse)
+	params := &wsapi.KeyMRRequest{KeyMR: hex.EncodeToString(req.KeyMr)}
+	if err := s.call(ctx, "directory-block", wsapi.HandleV2DirectoryBlock, params, r); err != nil {
+		return nil, err
+	}
+	resp := &DirectoryBlockResponse{
+		PrevBlockKeyMr: unhex(r.Header.PrevBlockKeyMR),
+		SequenceNumber: r.Header.SequenceNumber,
+		Timestamp:      r.Header.Timestamp,
+	}
+	for _, eb := range r.EntryBlockList {
+		resp.EntryBlockList = append(resp.EntryBlockList, &EBlockAddr{ChainId: unhex(eb.ChainID), KeyMr: unhex(eb.KeyMR)})
+	}
+	return resp, nil
+}
+
+func (s *Server) EntryBlock(ctx context.Context, req *KeyMRRequest) (*EntryBlockResponse, error) {
+	r := new(wsapi.EntryBlockResponse)
+	params := &wsapi.KeyMRRequest{KeyMR: hex.EncodeToString(req.KeyMr)}
+	if err := s.call(ctx, "entry-block", wsapi.HandleV2EntryBlock, params, r); err != nil {
+		return nil, err
+	}
+	resp := &EntryBlockResponse{
+		BlockSequenceNumber: r.Header.BlockSequenceNumber,
+		ChainId:             unhex(r.Header.ChainID),
+		PrevKeyMr:           unhex(r.Header.PrevKeyMR),
+		Timestamp:           r.Header.Timestamp,
+		DbHeight:            r.Header.DBHeight,
+	}
+	for _, e := range r.EntryList {
+		resp.EntryList = append(resp.EntryList, &EntryAddr{EntryHash: unhex(e.EntryHash), Timestamp: e.Timestamp})
+	}
+	return resp, nil
+}
+
+func (s *Server) Entry(ctx context.Context, req *HashRequest) (*EntryResponse, error) {
+	r := new(wsapi.EntryResponse)
+	params := &wsapi.HashRequest{Hash: hex.EncodeToString(req.Hash)}
+	if err := s.call(ctx, "entry", wsapi.HandleV2Entry, params, r); err != nil {
+		return nil, err
+	}
+	resp := &EntryResponse{ChainId: unhex(r.ChainID), Content: unhex(r.Content)}
+	for _, extID := range r.ExtIDs {
+		resp.ExtIds = append(resp.ExtIds, unhex(extID))
+	}
+	return resp, nil
+}
+
+func (s *Server) ChainHead(ctx context.Context, req *ChainIDRequest) (*ChainHeadResponse, error) {
+	r := new(wsapi.ChainHeadResponse)
+	params := &wsapi.ChainIDRequest{ChainID: hex.EncodeToString(req.ChainId)}
+	if err := s.call(ctx, "chain-head", wsapi.HandleV2ChainHead, params, r); err != nil {
+		return nil, err
+	}
+	return &ChainHeadResponse{ChainHead: unhex(r.ChainHead), ChainInProcessList: r.ChainInProcessList}, nil
+}
+
+func (s *Server) Transaction(ctx context.Context, req *HashRequest) (*TransactionResponse, error) {
+	if err := s.authorize(ctx, "transaction"); err != nil {
+		return nil, err
+	}
+	params := &wsapi.HashRequest{Hash: hex.EncodeToString(req.Hash)}
+	result, jsonErr := wsapi.HandleV2GetTranasction(s.state, params)
+	if jsonErr != nil {
+		return nil, statusError(jsonErr)
+	}
+	// The transaction is an interface, so the answer is taken as it is
+	r := result.(*wsapi.TransactionResponse)
+	var tx interfaces.BinaryMarshallable
+	switch {
+	case r.FactoidTransaction != nil:
+		tx = r.FactoidTransaction
+	case r.ECTranasction != nil:
+		tx = r.ECTranasction
+	case r.Entry != nil:
+		tx = r.Entry
+	default:
+		return nil, grpc.Errorf(codes.NotFound, "Transaction not found")
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, "%v", err)
+	}
+	return &TransactionResponse{
+		Transaction:                    data,
+		IncludedInTransactionBlock:     unhex(r.IncludedInTransactionBlock),
+		IncludedInDirectoryBlock:       unhex(r.IncludedInDirectoryBlock),
+		IncludedInDirectoryBlockHeight: r.IncludedInDirectoryBlockHeight,
+	}, nil
+}
+
+func (s *Server) FactoidBalance(ctx context.Context, req *AddressRequest) (*BalanceResponse, error) {
+	r := new(wsapi.FactoidBalanceResponse)
+	params := &wsapi.AddressRequest{Address: req.Address}
+	if err := s.call(ctx, "factoid-balance", wsapi.HandleV2FactoidBalance, params, r); err != nil {
+		return nil, err
+	}
+	return &BalanceResponse{Balance: r.Balance}, nil
+}
+
+func (s *Server) EntryCreditBalance(ctx context.Context, req *AddressRequest) (*BalanceResponse, error) {
+	r := new(wsapi.EntryCreditBalanceResponse)
+	params := &wsapi.AddressRequest{Address: req.Address}
+	if err := s.call(ctx, "entry-credit-balance", wsapi.HandleV2EntryCreditBalance, params, r); err != nil {
+		return nil, err
+	}
+	return &BalanceResponse{Balance: r.Balance}, nil
+}
+
+func (s *Server) EntryCreditRate(ctx context.Context, req *EntryCreditRateRequest) (*EntryCreditRateResponse, error) {
+	r := new(wsapi.EntryCreditRateResponse)
+	if err := s.call(ctx, "entry-credit-rate", wsapi.HandleV2EntryCreditRate, nil, r); err != nil {
+		return nil, err
+	}
+	return &EntryCreditRateResponse{Rate: r.Rate}, nil
+}
+
+// EntryAck is the entry-ack method of v2.  The chain ID of the request isn't
+// needed, as the commit or entry is found by its hash.
+func (s *Server) EntryAck(ctx context.Context, req *AckRequest) (*AckResponse, error) {
+	r := new(wsapi.EntryStatus)
+	params := &wsapi.AckRequest{TxID: hex.EncodeToString(req.Hash), FullTransaction: hex.EncodeToString(req.FullTransaction)}
+	if err := s.call(ctx, "entry-ack", wsapi.HandleV2EntryACK, params, r); err != nil {
+		return nil, err
+	}
+	return s.ackResponse(r.EntryHash, r.EntryData), nil
+}
+
+func (s *Server) FactoidAck(ctx context.Context, req *AckRequest) (*AckResponse, error) {
+	r := new(wsapi.FactoidTxStatus)
+	params := &wsapi.AckRequest{TxID: hex.EncodeToString(req.Hash), FullTransaction: hex.EncodeToString(req.FullTransaction)}
+	if err := s.call(ctx, "factoid-ack", wsapi.HandleV2FactoidACK, params, r); err != nil {
+		return nil, err
+	}
+	return s.ackResponse(r.TxID, r.GeneralTransactionData), nil
+}
+
+// ackResponse adds where the ack of the hash is, as the ack method of v2
+// gives it, to the status.
+func (s *Server) ackResponse(hash string, status wsapi.GeneralTransactionData) *AckResponse {
+	resp := &AckResponse{Status: status.Status, BlockDate: status.BlockDate}
+	h, err := primitives.HexToHash(hash)
+	if err != nil {
+		return resp
+	}
+	stage, dbheight, minute, _, err := s.state.GetAckLocation(h)
+	if err != nil {
+		return resp
+	}
+	switch stage {
+	case constants.AckStatusACK:
+		resp.DbHeight, resp.Minute = dbheight, int32(minute)
+	case constants.AckStatusDBlockConfirmed:
+		resp.DbHeight = dbheight
+	}
+	return resp
+}
+
+func (s *Server) CommitChain(ctx context.Context, req *MessageRequest) (*CommitResponse, error) {
+	r := new(wsapi.CommitChainResponse)
+	params := &wsapi.MessageRequest{Message: hex.EncodeToString(req.Message)}
+	if err := s.call(ctx, "commit-chain", wsapi.HandleV2CommitChain, params, r); err != nil {
+		return nil, err
+	}
+	return &CommitResponse{TxId: unhex(r.TxID), EntryHash: unhex(r.EntryHash), ChainIdHash: unhex(r.ChainIDHash)}, nil
+}
+
+func (s *Server) CommitEntry(ctx context.Context, req *MessageRequest) (*CommitResponse, error) {
+	r := new(wsapi.CommitEntryResponse)
+	params := &wsapi.MessageRequest{Message: hex.EncodeToString(req.Message)}
+	if err := s.call(ctx, "commit-entry", wsapi.HandleV2CommitEntry, params, r); err != nil {
+		return nil, err
+	}
+	return &CommitResponse{TxId: unhex(r.TxID), EntryHash: unhex(r.EntryHash)}, nil
+}
+
+func (s *Server) RevealEntry(ctx context.Context, req *EntryRequest) (*RevealResponse, error) {
+	r := new(wsapi.RevealEntryResponse)
+	params := &wsapi.EntryRequest{Entry: hex.EncodeToString(req.Entry)}
+	if err := s.call(ctx, "reveal-entry", wsapi.HandleV2RevealEntry, params, r); err != nil {
+		return nil, err
+	}
+	return &RevealResponse{EntryHash: unhex(r.EntryHash), ChainId: unhex(r.ChainID)}, nil
+}
+
+func (s *Server) FactoidSubmit(ctx context.Context, req *TransactionRequest) (*FactoidSubmitResponse, error) {
+	r := new(wsapi.FactoidSubmitResponse)
+	params := &wsapi.TransactionRequest{Transaction: hex.EncodeToString(req.Transaction)}
+	if err := s.call(ctx, "factoid-submit", wsapi.HandleV2FactoidSubmit, params, r); err != nil {
+		return nil, err
+	}
+	return &FactoidSubmitResponse{TxId: unhex(r.TxID)}, nil
+}
+
+// Live streams the events of /live until the client goes away, or falls
+// LiveBufferSize events behind, when the stream ends with ResourceExhausted.
+func (s *Server) Live(req *LiveRequest, stream Factomd_LiveServer) error {
+	if err := s.authorize(stream.Context(), "live"); err != nil {
+		return err
+	}
+	var chainIDs []string
+	for _, chainID := range req.ChainIds {
+		chainIDs = append(chainIDs, hex.EncodeToString(chainID))
+	}
+	sub := wsapi.SubscribeLive(s.state, chainIDs)
+	defer sub.Close()
+
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return grpc.Errorf(codes.ResourceExhausted, "Fell more than %d events behind", wsapi.LiveBufferSize)
+			}
+			if err := stream.Send(liveEvent(e)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func liveEvent(e *wsapi.LiveEvent) *LiveEvent {
+	return &LiveEvent{
+		Event:   e.Event,
+		Height:  e.Height,
+		KeyMr:   unhex(e.KeyMR),
+		ChainId: unhex(e.ChainID),
+		TxId:    unhex(e.TxID),
+		Change:  e.Change,
+	}
+}
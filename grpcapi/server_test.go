@@ -0,0 +1,110 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package grpcapi_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factomd/grpcapi"
+	"github.com/FactomProject/factomd/testHelper"
+	"github.com/FactomProject/factomd/wsapi"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestDirectoryBlock(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	server := NewServer(state)
+
+	dblock, err := state.DB.FetchDBlockByHeight(1)
+	if err != nil || dblock == nil {
+		t.Fatalf("No directory block 1: %v", err)
+	}
+	resp, err := server.DirectoryBlock(context.Background(), &KeyMRRequest{KeyMr: dblock.GetKeyMR().Bytes()})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if resp.SequenceNumber != 1 || !bytes.Equal(resp.PrevBlockKeyMr, dblock.GetHeader().GetPrevKeyMR().Bytes()) {
+		t.Errorf("Got the wrong directory block: %v", resp)
+	}
+	if len(resp.EntryBlockList) != len(dblock.GetDBEntries()) {
+		t.Errorf("Got %d entry blocks, expected %d", len(resp.EntryBlockList), len(dblock.GetDBEntries()))
+	}
+
+	_, err = server.DirectoryBlock(context.Background(), &KeyMRRequest{KeyMr: make([]byte, 32)})
+	if grpc.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a missing block, got %v", err)
+	}
+	_, err = server.DirectoryBlock(context.Background(), &KeyMRRequest{KeyMr: []byte{1}})
+	if grpc.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a short key MR, got %v", err)
+	}
+}
+
+// liveStream is the server side of a Live call, without a connection.
+type liveStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *LiveEvent
+}
+
+func (s *liveStream) Context() context.Context { return s.ctx }
+
+func (s *liveStream) Send(e *LiveEvent) error {
+	s.events <- e
+	return nil
+}
+
+func TestLive(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	server := NewServer(state)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &liveStream{ctx: ctx, events: make(chan *LiveEvent, 100)}
+	done := make(chan error)
+	go func() {
+		done <- server.Live(&LiveRequest{ChainIds: [][]byte{testHelper.GetChainID().Bytes()}}, stream)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	set := testHelper.CreateTestBlockSet(nil)
+	wsapi.PublishDBState(state, set.DBlock, set.ABlock, set.FBlock)
+
+	select {
+	case e := <-stream.events:
+		if e.Event != wsapi.LiveDBlock || !bytes.Equal(e.KeyMr, set.DBlock.GetKeyMR().Bytes()) {
+			t.Errorf("The first event is not the directory block: %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("No event was streamed")
+	}
+	time.Sleep(100 * time.Millisecond)
+	eblocks := 0
+	for len(stream.events) > 0 {
+		e := <-stream.events
+		if e.Event == wsapi.LiveEBlock {
+			eblocks++
+			if !bytes.Equal(e.ChainId, testHelper.GetChainID().Bytes()) {
+				t.Errorf("Got an entry block of chain %x, which is not subscribed", e.ChainId)
+			}
+		}
+	}
+	if eblocks == 0 {
+		t.Error("Got no entry block of the subscribed chain")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Live did not end with its stream")
+	}
+}
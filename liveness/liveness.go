@@ -0,0 +1,209 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package liveness has audit servers write what they saw of the federated
+// servers in each directory block to a chain, each record signed by the
+// audit server, so that anyone can audit from the chain how available the
+// leaders have been.
+package liveness
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/log"
+	"github.com/FactomProject/factomd/util"
+)
+
+// ExtID is the first external ID of every liveness record.  The second is
+// the public key of the audit server, and the third its signature of the
+// content.
+const ExtID = "factomd liveness"
+
+// QueueSize is the number of observations waiting to be written before new
+// ones are dropped.
+var QueueSize = 16
+
+// An Observation is what an audit server saw of the federated servers in a
+// directory block.  It is the content of a liveness record, as JSON.
+type Observation struct {
+	Height  uint32   `json:"height"`  // Of the directory block
+	Auditor string   `json:"auditor"` // Identity chain of the audit server
+	Time    int64    `json:"time"`    // Unix milliseconds, when the block was saved
+	Servers []Server `json:"servers"`
+}
+
+// Server is what was seen of a federated server in the block.
+type Server struct {
+	ChainID        string `json:"chainid"`
+	VMIndex        int    `json:"vm"`             // Its VM in minute 0, or -1
+	Messages       int    `json:"messages"`       // Acknowledged in its VM
+	SignedPrevious bool   `json:"signedprevious"` // Its signature of the block before is in the admin block
+	Faulted        bool   `json:"faulted"`        // Its VM was faulted when the block ended
+}
+
+// Recorder writes the observations of an audit server to the liveness
+// chain, paid for from an entry credit key.  Observations are queued by the
+// state loop and written by a goroutine of their own.
+type Recorder struct {
+	state   interfaces.IState
+	chainID interfaces.IHash
+	ecKey   []byte
+	blocks  uint32
+
+	queue chan *Observation
+	once  sync.Once
+
+	mutex   sync.Mutex
+	written int
+	dropped int
+}
+
+// NewRecorder returns a recorder of an observation every so many blocks to
+// the chain, paid for by the human readable entry credit private key.
+func NewRecorder(state interfaces.IState, chainID string, ecKey string, blocks int) (*Recorder, error) {
+	if blocks < 1 {
+		return nil, fmt.Errorf("Cannot record liveness every %d blocks", blocks)
+	}
+	r := new(Recorder)
+	r.state = state
+	r.blocks = uint32(blocks)
+	r.queue = make(chan *Observation, QueueSize)
+
+	var err error
+	r.chainID, err = primitives.HexToHash(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid liveness chain %q: %v", chainID, err)
+	}
+	r.ecKey, err = primitives.HumanReadableECPrivateKeyToPrivateKey(ecKey)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid liveness entry credit key: %v", err)
+	}
+	return r, nil
+}
+
+// Due is true if the block at the height is one to record.
+func (r *Recorder) Due(height uint32) bool {
+	return height%r.blocks == 0
+}
+
+// Record queues the observation to be written, and starts the writing on
+// the first.  It never blocks; if too many are waiting, it is dropped.
+func (r *Recorder) Record(o *Observation) {
+	r.once.Do(func() { go r.run() })
+	select {
+	case r.queue <- o:
+	default:
+		r.mutex.Lock()
+		r.dropped++
+		r.mutex.Unlock()
+	}
+}
+
+// Counts returns the number of observations submitted, and dropped.
+func (r *Recorder) Counts() (written int, dropped int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.written, r.dropped
+}
+
+func (r *Recorder) run() {
+	for o := range r.queue {
+		if err := r.submit(o); err != nil {
+			log.Printf("Liveness record of block %d not written: %v\n", o.Height, err)
+			continue
+		}
+		r.mutex.Lock()
+		r.written++
+		r.mutex.Unlock()
+	}
+}
+
+// submit commits and reveals the record of the observation.
+func (r *Recorder) submit(o *Observation) error {
+	entry, err := r.NewEntry(o)
+	if err != nil {
+		return err
+	}
+	data, err := entry.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	cost, err := util.EntryCost(data)
+	if err != nil {
+		return err
+	}
+
+	commit := entryCreditBlock.NewCommitEntry()
+	commit.MilliTime = r.milliTime()
+	commit.EntryHash = entry.GetHash()
+	commit.Credits = cost
+	if err := commit.Sign(r.ecKey); err != nil {
+		return err
+	}
+
+	msg := new(messages.CommitEntryMsg)
+	msg.CommitEntry = commit
+	reveal := new(messages.RevealEntryMsg)
+	reveal.Entry = entry
+	reveal.Timestamp = r.state.GetTimestamp()
+	r.state.APIQueue() <- msg
+	r.state.APIQueue() <- reveal
+	return nil
+}
+
+// NewEntry returns the record of the observation, signed by the node.
+func (r *Recorder) NewEntry(o *Observation) (*entryBlock.Entry, error) {
+	content, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	sig := r.state.Sign(content)
+
+	entry := entryBlock.NewEntry()
+	entry.ChainID = r.chainID
+	entry.ExtIDs = []primitives.ByteSlice{
+		{Bytes: []byte(ExtID)},
+		{Bytes: sig.GetKey()},
+		{Bytes: sig.GetSignature()[:]},
+	}
+	entry.Content = primitives.ByteSlice{Bytes: content}
+	return entry, nil
+}
+
+// milliTime is the time of a commit, in milliseconds in 6 bytes.
+func (r *Recorder) milliTime() *primitives.ByteSlice6 {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(r.state.GetClock().Now().UnixNano()/1e6))
+	t := new(primitives.ByteSlice6)
+	copy(t[:], b[2:])
+	return t
+}
+
+// Verify checks the signature of a liveness record, and returns its
+// observation and the public key that signed it.  Whether the key was that
+// of the Auditor, an audit server at the Height, is for the caller to check
+// against the identity chains.
+func Verify(entry interfaces.IEBEntry) (*Observation, []byte, error) {
+	extIDs := entry.ExternalIDs()
+	if len(extIDs) != 3 || string(extIDs[0]) != ExtID {
+		return nil, nil, fmt.Errorf("Not a liveness record")
+	}
+	key, sig := extIDs[1], extIDs[2]
+	if err := primitives.VerifySignature(entry.GetContent(), key, sig); err != nil {
+		return nil, nil, err
+	}
+	o := new(Observation)
+	if err := json.Unmarshal(entry.GetContent(), o); err != nil {
+		return nil, nil, err
+	}
+	return o, key, nil
+}
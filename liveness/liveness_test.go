@@ -0,0 +1,77 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package liveness_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/liveness"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func newRecorder(t *testing.T, blocks int) *Recorder {
+	ecKey, err := primitives.PrivateKeyStringToHumanReadableECPrivateKey(primitives.Sha([]byte("liveness")).String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRecorder(testHelper.CreateEmptyTestState(), primitives.Sha([]byte("chain")).String(), ecKey, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestNewRecorder(t *testing.T) {
+	s := testHelper.CreateEmptyTestState()
+	if _, err := NewRecorder(s, "abc", "", 1); err == nil {
+		t.Error("Expected an error for an invalid chain")
+	}
+	if _, err := NewRecorder(s, primitives.Sha([]byte("chain")).String(), "Es123", 1); err == nil {
+		t.Error("Expected an error for an invalid entry credit key")
+	}
+
+	r := newRecorder(t, 10)
+	if r.Due(15) || !r.Due(20) {
+		t.Error("Expected every tenth block to be due")
+	}
+}
+
+func TestLivenessRecord(t *testing.T) {
+	r := newRecorder(t, 1)
+	o := &Observation{
+		Height:  100,
+		Auditor: primitives.Sha([]byte("audit")).String(),
+		Time:    1500000000000,
+		Servers: []Server{
+			{ChainID: primitives.Sha([]byte("fed0")).String(), VMIndex: 0, Messages: 25, SignedPrevious: true},
+			{ChainID: primitives.Sha([]byte("fed1")).String(), VMIndex: 1, Faulted: true},
+		},
+	}
+
+	entry, err := r.NewEntry(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o2, key, err := Verify(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 {
+		t.Errorf("Expected the public key of the node, got %x", key)
+	}
+	if o2.Height != o.Height || o2.Auditor != o.Auditor || len(o2.Servers) != 2 || o2.Servers[0] != o.Servers[0] || o2.Servers[1] != o.Servers[1] {
+		t.Errorf("Observation changed through the record: %+v", o2)
+	}
+
+	entry.Content.Bytes[0] = ' '
+	if _, _, err := Verify(entry); err == nil {
+		t.Error("Expected an error for a record changed after signing")
+	}
+	entry.ExtIDs = entry.ExtIDs[:1]
+	if _, _, err := Verify(entry); err == nil {
+		t.Error("Expected an error for a record without a signature")
+	}
+}
@@ -0,0 +1,605 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package loadgen submits chains, entries and factoid transactions to the
+// node it runs in at a steady rate, and measures how long they take to be
+// acknowledged and saved in a directory block, to benchmark the throughput
+// of consensus on a test network.
+package loadgen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/util"
+)
+
+// SandKey is the private key of the address the genesis block of the test
+// networks funds, which pays for the load.
+const SandKey = "Fs3E9gV6DXsYzf7Fqx1fVBQPQXV695eP3k5XbmHEZVRLkMdD9qCK"
+
+// The kinds of submission
+const (
+	Chain       = "chain"
+	Entry       = "entry"
+	Transaction = "transaction"
+	Purchase    = "purchase" // Entry credits bought to pay for the chains and entries
+)
+
+// ecBatch is how many entry credits are bought at a time
+const ecBatch = 10000
+
+// maxEntrySize leaves room in the 10KB an entry may be for its external IDs
+const maxEntrySize = 10000
+
+// Config is what a load generator submits, and how fast.
+type Config struct {
+	Rate         float64 `json:"rate"`         // Submissions a second
+	Seconds      int     `json:"seconds"`      // How long to submit for, or until stopped if 0
+	Chains       int     `json:"chains"`       // The shares of the submissions of each kind
+	Entries      int     `json:"entries"`      //
+	Transactions int     `json:"transactions"` //
+	EntrySize    int     `json:"entrysize"`    // Bytes of content in each entry
+}
+
+// DefaultConfig is mostly entries, with a chain and a transaction in ten.
+var DefaultConfig = Config{Rate: 10, Chains: 1, Entries: 8, Transactions: 1, EntrySize: 100}
+
+// Latency sums up how long submissions took to reach a status.
+type Latency struct {
+	Count   int   `json:"count"`
+	MeanMs  int64 `json:"meanms"`
+	MinMs   int64 `json:"minms"`
+	MaxMs   int64 `json:"maxms"`
+	totalMs int64
+}
+
+// Add counts a submission that took d.
+func (l *Latency) Add(d time.Duration) {
+	ms := int64(d / time.Millisecond)
+	if l.Count == 0 || ms < l.MinMs {
+		l.MinMs = ms
+	}
+	if ms > l.MaxMs {
+		l.MaxMs = ms
+	}
+	l.Count++
+	l.totalMs += ms
+	l.MeanMs = l.totalMs / int64(l.Count)
+}
+
+// Stats is what a load generator has submitted, and what became of it.
+type Stats struct {
+	Running        bool           `json:"running"` // Still submitting, or waiting on the submissions
+	Config         Config         `json:"config"`
+	Seconds        float64        `json:"seconds"` // Since it started
+	Submitted      map[string]int `json:"submitted"`
+	Acked          map[string]int `json:"acked"`
+	Confirmed      map[string]int `json:"confirmed"`
+	Invalid        map[string]int `json:"invalid"`
+	Pending        int            `json:"pending"`
+	AckLatency     Latency        `json:"acklatency"`     // From submission to acknowledgement
+	ConfirmLatency Latency        `json:"confirmlatency"` // From submission to a saved directory block
+	Throughput     float64        `json:"throughput"`     // Confirmed a second
+	Error          string         `json:"error,omitempty"`
+}
+
+type submission struct {
+	kind  string
+	sent  time.Time
+	acked bool
+}
+
+// Generator submits the load of a Config to a node.
+type Generator struct {
+	state  interfaces.IState
+	config Config
+
+	fctKey  []byte
+	fctRCD  interfaces.IRCD
+	fctAddr interfaces.IAddress
+	ecKey   []byte
+	ecAddr  interfaces.IAddress
+
+	chainID interfaces.IHash // The chain the entries go to
+	n       uint64           // Submissions so far, to keep them unique
+	credits int              // Entry credits bought and not yet spent
+
+	mutex   sync.Mutex
+	pending map[[32]byte]*submission
+	stats   Stats
+	started time.Time
+	stop    chan struct{}
+}
+
+var (
+	generatorsMutex sync.Mutex
+	generators      = map[interfaces.IState]*Generator{}
+)
+
+// Start starts a load generator on the node, in place of any it has running.
+func Start(state interfaces.IState, config Config) (*Generator, error) {
+	g, err := NewGenerator(state, config)
+	if err != nil {
+		return nil, err
+	}
+
+	generatorsMutex.Lock()
+	defer generatorsMutex.Unlock()
+	if old := generators[state]; old != nil {
+		old.Stop()
+	}
+	generators[state] = g
+	go g.run()
+	return g, nil
+}
+
+// Stop stops the load generator of the node, if it has one.
+func Stop(state interfaces.IState) {
+	generatorsMutex.Lock()
+	defer generatorsMutex.Unlock()
+	if g := generators[state]; g != nil {
+		g.Stop()
+	}
+}
+
+// Running returns the load generator last started on the node, or nil.
+func Running(state interfaces.IState) *Generator {
+	generatorsMutex.Lock()
+	defer generatorsMutex.Unlock()
+	return generators[state]
+}
+
+// NewGenerator returns a generator of the load of the config, paid for from
+// the sand address, which is only funded on test networks.
+func NewGenerator(state interfaces.IState, config Config) (*Generator, error) {
+	if state.GetNetworkName() == "MAIN" {
+		return nil, fmt.Errorf("The load generator cannot run on the main network")
+	}
+	if config.Rate <= 0 {
+		return nil, fmt.Errorf("Rate %v is not positive", config.Rate)
+	}
+	if config.Seconds < 0 || config.Chains < 0 || config.Entries < 0 || config.Transactions < 0 {
+		return nil, fmt.Errorf("Seconds and shares cannot be negative")
+	}
+	if config.Chains+config.Entries+config.Transactions == 0 {
+		config.Chains, config.Entries, config.Transactions = DefaultConfig.Chains, DefaultConfig.Entries, DefaultConfig.Transactions
+	}
+	if config.EntrySize <= 0 {
+		config.EntrySize = DefaultConfig.EntrySize
+	}
+	if config.EntrySize > maxEntrySize {
+		return nil, fmt.Errorf("Entries of %d bytes are too large", config.EntrySize)
+	}
+
+	g := new(Generator)
+	g.state = state
+	g.config = config
+	g.pending = make(map[[32]byte]*submission)
+	g.stop = make(chan struct{})
+
+	var err error
+	g.fctKey, err = primitives.HumanReadableFactoidPrivateKeyToPrivateKey(SandKey)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := primitives.PrivateKeyToPublicKey(g.fctKey)
+	if err != nil {
+		return nil, err
+	}
+	g.fctRCD = factoid.NewRCD_1(pub)
+	g.fctAddr, err = g.fctRCD.GetAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	g.ecKey = primitives.Sha([]byte("factomd load generator")).Bytes()
+	pub, err = primitives.PrivateKeyToPublicKey(g.ecKey)
+	if err != nil {
+		return nil, err
+	}
+	g.ecAddr = factoid.NewAddress(pub)
+
+	g.stats.Config = config
+	g.stats.Submitted = map[string]int{}
+	g.stats.Acked = map[string]int{}
+	g.stats.Confirmed = map[string]int{}
+	g.stats.Invalid = map[string]int{}
+	return g, nil
+}
+
+// Stop stops the submissions, and the watch on those still pending.
+func (g *Generator) Stop() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	select {
+	case <-g.stop:
+	default:
+		close(g.stop)
+	}
+}
+
+// Stats returns what the generator has submitted so far, and what became of
+// it.
+func (g *Generator) Stats() Stats {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	s := g.stats
+	s.Submitted = copyCounts(g.stats.Submitted)
+	s.Acked = copyCounts(g.stats.Acked)
+	s.Confirmed = copyCounts(g.stats.Confirmed)
+	s.Invalid = copyCounts(g.stats.Invalid)
+	s.Pending = len(g.pending)
+	if !g.started.IsZero() {
+		s.Seconds = g.state.GetClock().Since(g.started).Seconds()
+	}
+	if s.Seconds > 0 {
+		s.Throughput = float64(g.stats.ConfirmLatency.Count) / s.Seconds
+	}
+	return s
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	c := make(map[string]int, len(counts))
+	for k, v := range counts {
+		c[k] = v
+	}
+	return c
+}
+
+func (g *Generator) stopped() bool {
+	select {
+	case <-g.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (g *Generator) setRunning(running bool, err error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.stats.Running = running
+	if err != nil {
+		g.stats.Error = err.Error()
+	}
+}
+
+// run buys the entry credits and creates the chain the entries go to, then
+// submits at the rate of the config, and watches the submissions until they
+// are all confirmed or the generator is stopped.
+func (g *Generator) run() {
+	clock := g.state.GetClock()
+	g.mutex.Lock()
+	g.started = clock.Now()
+	g.stats.Running = true
+	g.mutex.Unlock()
+
+	if err := g.setUp(); err != nil {
+		g.setRunning(false, err)
+		return
+	}
+
+	interval := time.Duration(float64(time.Second) / g.config.Rate)
+	var end time.Time
+	if g.config.Seconds > 0 {
+		end = clock.Now().Add(time.Duration(g.config.Seconds) * time.Second)
+	}
+	lastPoll := clock.Now()
+	for !g.stopped() {
+		now := clock.Now()
+		submitting := end.IsZero() || now.Before(end)
+		if !submitting && g.Stats().Pending == 0 {
+			break
+		}
+		if submitting {
+			if err := g.submitNext(); err != nil {
+				g.setRunning(false, err)
+				return
+			}
+		}
+		if now.Sub(lastPoll) >= time.Second || !submitting {
+			g.poll()
+			lastPoll = now
+		}
+		if submitting {
+			clock.Sleep(interval)
+		} else {
+			clock.Sleep(time.Second)
+		}
+	}
+	g.setRunning(false, nil)
+}
+
+// setUp buys the first entry credits and creates the chain of the entries,
+// and waits for them to be acknowledged.
+func (g *Generator) setUp() error {
+	if err := g.buyCredits(); err != nil {
+		return err
+	}
+	if g.config.Entries > 0 {
+		if err := g.submitChain(true); err != nil {
+			return err
+		}
+	}
+	for !g.stopped() {
+		g.poll()
+		g.mutex.Lock()
+		waiting := 0
+		for _, s := range g.pending {
+			if !s.acked {
+				waiting++
+			}
+		}
+		invalid := g.stats.Invalid[Purchase] + g.stats.Invalid[Chain]
+		g.mutex.Unlock()
+		if invalid > 0 {
+			return fmt.Errorf("The node rejected the entry credit purchase or the chain of the load")
+		}
+		if waiting == 0 {
+			return nil
+		}
+		g.state.GetClock().Sleep(time.Second)
+	}
+	return nil
+}
+
+// submitNext submits the next of the kinds of submission, in proportion to
+// their shares.
+func (g *Generator) submitNext() error {
+	if g.credits < ecBatch/2 {
+		if err := g.buyCredits(); err != nil {
+			return err
+		}
+	}
+	i := int(g.n % uint64(g.config.Chains+g.config.Entries+g.config.Transactions))
+	switch {
+	case i < g.config.Chains:
+		return g.submitChain(false)
+	case i < g.config.Chains+g.config.Entries:
+		return g.submitEntry()
+	default:
+		return g.submitTransaction()
+	}
+}
+
+// track records the time a submission was made, to time its confirmation.
+func (g *Generator) track(kind string, hash interfaces.IHash) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.pending[hash.Fixed()] = &submission{kind: kind, sent: g.state.GetClock().Now()}
+	g.stats.Submitted[kind]++
+}
+
+// poll checks the submissions not yet confirmed.
+func (g *Generator) poll() {
+	g.mutex.Lock()
+	hashes := make([][32]byte, 0, len(g.pending))
+	for h := range g.pending {
+		hashes = append(hashes, h)
+	}
+	g.mutex.Unlock()
+
+	for _, h := range hashes {
+		hash := primitives.NewHash(h[:])
+		status, _, _, _, err := g.state.GetACKStatus(hash)
+		if err != nil {
+			continue
+		}
+		g.update(h, status)
+	}
+}
+
+// update records the status of a submission, and how long it took to get
+// there.
+func (g *Generator) update(h [32]byte, status int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	s := g.pending[h]
+	if s == nil {
+		return
+	}
+	took := g.state.GetClock().Since(s.sent)
+	switch status {
+	case constants.AckStatusInvalid:
+		g.stats.Invalid[s.kind]++
+		delete(g.pending, h)
+	case constants.AckStatusACK, constants.AckStatus1Minute, constants.AckStatusDBlockConfirmed:
+		if !s.acked {
+			s.acked = true
+			g.stats.Acked[s.kind]++
+			g.stats.AckLatency.Add(took)
+		}
+		if status == constants.AckStatusDBlockConfirmed {
+			g.stats.Confirmed[s.kind]++
+			g.stats.ConfirmLatency.Add(took)
+			delete(g.pending, h)
+		}
+	}
+}
+
+func (g *Generator) submit(kind string, hash interfaces.IHash, msgs ...interfaces.IMsg) {
+	g.n++
+	g.track(kind, hash)
+	for _, msg := range msgs {
+		g.state.APIQueue() <- msg
+	}
+}
+
+// buyCredits converts factoids of the sand address into entry credits.
+func (g *Generator) buyCredits() error {
+	rate := g.state.GetFactoshisPerEC()
+	tx, err := g.NewTransaction(func(tx *factoid.Transaction) {
+		tx.AddECOutput(g.ecAddr, ecBatch*rate)
+	}, ecBatch*rate)
+	if err != nil {
+		return err
+	}
+	msg := new(messages.FactoidTransaction)
+	msg.Transaction = tx
+	g.submit(Purchase, tx.GetSigHash(), msg)
+	g.credits += ecBatch
+	return nil
+}
+
+func (g *Generator) submitTransaction() error {
+	amount := 1 + g.n%1000
+	tx, err := g.NewTransaction(func(tx *factoid.Transaction) {
+		tx.AddOutput(g.fctAddr, amount)
+	}, amount)
+	if err != nil {
+		return err
+	}
+	msg := new(messages.FactoidTransaction)
+	msg.Transaction = tx
+	g.submit(Transaction, tx.GetSigHash(), msg)
+	return nil
+}
+
+// submitChain creates a chain, which becomes the chain the entries go to if
+// it is the first.
+func (g *Generator) submitChain(first bool) error {
+	entry := g.NewEntry(nil)
+	commit, err := g.NewCommitChain(entry)
+	if err != nil {
+		return err
+	}
+	if first {
+		g.chainID = entry.GetChainID()
+	}
+	g.credits -= int(commit.Credits)
+
+	msg := new(messages.CommitChainMsg)
+	msg.CommitChain = commit
+	reveal := new(messages.RevealEntryMsg)
+	reveal.Entry = entry
+	reveal.Timestamp = g.state.GetTimestamp()
+	g.submit(Chain, entry.GetHash(), msg, reveal)
+	return nil
+}
+
+func (g *Generator) submitEntry() error {
+	entry := g.NewEntry(g.chainID)
+	commit, err := g.NewCommitEntry(entry)
+	if err != nil {
+		return err
+	}
+	g.credits -= int(commit.Credits)
+
+	msg := new(messages.CommitEntryMsg)
+	msg.CommitEntry = commit
+	reveal := new(messages.RevealEntryMsg)
+	reveal.Entry = entry
+	reveal.Timestamp = g.state.GetTimestamp()
+	g.submit(Entry, entry.GetHash(), msg, reveal)
+	return nil
+}
+
+// NewEntry returns the next entry of the load, in the chain, or the first
+// entry of a new chain if the chain is nil.
+func (g *Generator) NewEntry(chainID interfaces.IHash) *entryBlock.Entry {
+	entry := entryBlock.NewEntry()
+	stamp := fmt.Sprintf("%d %d", g.state.GetClock().Now().UnixNano(), g.n)
+	entry.ExtIDs = []primitives.ByteSlice{
+		{Bytes: []byte("factomd load generator")},
+		{Bytes: []byte(stamp)},
+	}
+	content := make([]byte, g.config.EntrySize)
+	copy(content, stamp)
+	entry.Content = primitives.ByteSlice{Bytes: content}
+	if chainID == nil {
+		chainID = entryBlock.NewChainID(entry)
+	}
+	entry.ChainID = chainID
+	return entry
+}
+
+// NewCommitChain returns the signed commit of the chain the entry creates.
+func (g *Generator) NewCommitChain(entry *entryBlock.Entry) (*entryCreditBlock.CommitChain, error) {
+	cost, err := entryCost(entry)
+	if err != nil {
+		return nil, err
+	}
+	commit := entryCreditBlock.NewCommitChain()
+	commit.MilliTime = g.milliTime()
+	commit.ChainIDHash = primitives.Sha(entry.GetChainID().Bytes())
+	commit.Weld = entry.GetWeldHash()
+	commit.EntryHash = entry.GetHash()
+	commit.Credits = cost + 10
+	if err := commit.Sign(g.ecKey); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// NewCommitEntry returns the signed commit of the entry.
+func (g *Generator) NewCommitEntry(entry *entryBlock.Entry) (*entryCreditBlock.CommitEntry, error) {
+	cost, err := entryCost(entry)
+	if err != nil {
+		return nil, err
+	}
+	commit := entryCreditBlock.NewCommitEntry()
+	commit.MilliTime = g.milliTime()
+	commit.EntryHash = entry.GetHash()
+	commit.Credits = cost
+	if err := commit.Sign(g.ecKey); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// NewTransaction returns a signed transaction from the sand address, of the
+// amount to the outputs that addOutputs adds, and the fee.
+func (g *Generator) NewTransaction(addOutputs func(*factoid.Transaction), amount uint64) (*factoid.Transaction, error) {
+	tx := new(factoid.Transaction)
+	tx.AddInput(g.fctAddr, amount)
+	addOutputs(tx)
+	tx.AddAuthorization(g.fctRCD)
+	tx.SetTimestamp(primitives.NewTimestampFromClock(g.state.GetClock()))
+
+	fee, err := tx.CalculateFee(g.state.GetFactoshisPerEC())
+	if err != nil {
+		return nil, err
+	}
+	in, err := tx.GetInput(0)
+	if err != nil {
+		return nil, err
+	}
+	in.SetAmount(amount + fee)
+
+	data, err := tx.MarshalBinarySig()
+	if err != nil {
+		return nil, err
+	}
+	tx.SetSignatureBlock(0, factoid.NewSingleSignatureBlock(g.fctKey, data))
+	return tx, nil
+}
+
+// milliTime is the time of a commit, in milliseconds in 6 bytes.
+func (g *Generator) milliTime() *primitives.ByteSlice6 {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(g.state.GetClock().Now().UnixNano()/1e6))
+	t := new(primitives.ByteSlice6)
+	copy(t[:], b[2:])
+	return t
+}
+
+func entryCost(entry *entryBlock.Entry) (uint8, error) {
+	data, err := entry.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	return util.EntryCost(data)
+}
@@ -0,0 +1,73 @@
+package loadgen_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/factoid"
+	. "github.com/FactomProject/factomd/loadgen"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestGeneratorSubmissions(t *testing.T) {
+	s := testHelper.CreateEmptyTestState()
+	g, err := NewGenerator(s, DefaultConfig)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	chain := g.NewEntry(nil)
+	commitChain, err := g.NewCommitChain(chain)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !commitChain.IsValid() {
+		t.Errorf("Commit of the chain is not valid")
+	}
+	if !commitChain.EntryHash.IsSameAs(chain.GetHash()) {
+		t.Errorf("Commit of the chain is for another entry")
+	}
+
+	entry := g.NewEntry(chain.GetChainID())
+	if !entry.GetChainID().IsSameAs(chain.GetChainID()) {
+		t.Errorf("Entry is not in the chain")
+	}
+	commitEntry, err := g.NewCommitEntry(entry)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !commitEntry.IsValid() {
+		t.Errorf("Commit of the entry is not valid")
+	}
+
+	tx, err := g.NewTransaction(func(tx *factoid.Transaction) {}, 0)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := tx.ValidateSignatures(); err != nil {
+		t.Errorf("%v", err)
+	}
+	fee, err := tx.CalculateFee(s.GetFactoshisPerEC())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if in, _ := tx.GetInput(0); in.GetAmount() != fee {
+		t.Errorf("Transaction pays %d, not the fee of %d", in.GetAmount(), fee)
+	}
+
+	s.NetworkNumber = constants.NETWORK_MAIN
+	if _, err := NewGenerator(s, DefaultConfig); err == nil {
+		t.Errorf("Load generator made for the main network")
+	}
+}
+
+func TestLatency(t *testing.T) {
+	var l Latency
+	for _, ms := range []time.Duration{30, 10, 20} {
+		l.Add(ms * time.Millisecond)
+	}
+	if l.Count != 3 || l.MinMs != 10 || l.MaxMs != 30 || l.MeanMs != 20 {
+		t.Errorf("Wrong latency %+v", l)
+	}
+}
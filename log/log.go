@@ -70,10 +70,6 @@ func Printf(format string, args ...interface{}) {
 	printf(LogLevel == DebugLog, format, args...)
 }
 
-func Printfln(format string, args ...interface{}) {
-	printf(LogLevel == DebugLog, format+"\n", args...)
-}
-
 func Debug(format string, args ...interface{}) {
 	printf(true, format+"\n", args...)
 }
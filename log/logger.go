@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -212,30 +213,45 @@ var levelPrefix = map[Level]string{
 	DebugLvl:     "DEBUG",
 }
 
-func levelFromString(levelName string) (level Level) {
-	switch levelName {
+// ParseLevel returns the level of its name, one of debug, info, notice,
+// warning, error, critical, alert, emergency and none.
+func ParseLevel(levelName string) (Level, error) {
+	switch strings.ToLower(levelName) {
 	case "debug":
-		level = DebugLvl
+		return DebugLvl, nil
 	case "info":
-		level = InfoLvl
+		return InfoLvl, nil
 	case "notice":
-		level = NoticeLvl
+		return NoticeLvl, nil
 	case "warning":
-		level = WarningLvl
+		return WarningLvl, nil
 	case "error":
-		level = ErrorLvl
+		return ErrorLvl, nil
 	case "critical":
-		level = CriticalLvl
+		return CriticalLvl, nil
 	case "alert":
-		level = AlertLvl
+		return AlertLvl, nil
 	case "emergency":
-		level = EmergencyLvl
+		return EmergencyLvl, nil
 	case "none":
-		level = None
-	default:
-		fmt.Fprintf(os.Stderr, "Invalid level value %q, allowed values are: debug, info, notice, warning, error, critical, alert, emergency and none\n", levelName)
+		return None, nil
+	}
+	return WarningLvl, fmt.Errorf("Invalid level value %q, allowed values are: debug, info, notice, warning, error, critical, alert, emergency and none", levelName)
+}
+
+// LevelName returns the name of the level, as ParseLevel takes it.
+func LevelName(level Level) string {
+	if prefix, ok := levelPrefix[level]; ok {
+		return strings.ToLower(prefix)
+	}
+	return "none"
+}
+
+func levelFromString(levelName string) Level {
+	level, err := ParseLevel(levelName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, "Using log level of warning")
-		level = WarningLvl
 	}
-	return
+	return level
 }
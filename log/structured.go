@@ -0,0 +1,216 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fields are the keys and values a structured log line is about.
+type Fields map[string]interface{}
+
+// The fields most lines are about
+const (
+	FieldNodeID  = "node"
+	FieldHeight  = "height"
+	FieldMsgType = "msgtype"
+)
+
+// The packages with a level of their own in factomd.conf.  Any other package
+// logs at the default level.
+const (
+	PackageP2P   = "p2p"
+	PackageState = "state"
+	PackageWsapi = "wsapi"
+	PackageDB    = "db"
+)
+
+// Packages are the packages with a level of their own.
+var Packages = []string{PackageP2P, PackageState, PackageWsapi, PackageDB}
+
+// DefaultPackageLevel is the level of the packages not set otherwise.
+var DefaultPackageLevel = InfoLvl
+
+// The settings shared by all structured loggers
+var (
+	structuredMutex  sync.Mutex
+	structuredOut    io.Writer = os.Stdout
+	structuredJSON   bool
+	structuredLevels = map[string]Level{}
+)
+
+// SetOutput sets where the structured loggers write to.
+func SetOutput(w io.Writer) {
+	structuredMutex.Lock()
+	defer structuredMutex.Unlock()
+	structuredOut = w
+}
+
+// SetFormat sets the structured loggers to write JSON lines for "json", and
+// text otherwise.
+func SetFormat(format string) {
+	structuredMutex.Lock()
+	defer structuredMutex.Unlock()
+	structuredJSON = strings.ToLower(format) == "json"
+}
+
+// SetPackageLevel sets the level of the structured loggers of a package.  An
+// empty level leaves the package at the default.
+func SetPackageLevel(pkg string, level string) {
+	structuredMutex.Lock()
+	defer structuredMutex.Unlock()
+	if level == "" {
+		delete(structuredLevels, pkg)
+		return
+	}
+	structuredLevels[pkg] = levelFromString(strings.ToLower(level))
+}
+
+// ChangePackageLevel is SetPackageLevel for a running node: an unknown level
+// is an error, and leaves the package at the level it is at.
+func ChangePackageLevel(pkg string, level string) error {
+	if level == "" {
+		SetPackageLevel(pkg, "")
+		return nil
+	}
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	structuredMutex.Lock()
+	defer structuredMutex.Unlock()
+	structuredLevels[pkg] = l
+	return nil
+}
+
+// PackageLevels returns the names of the levels of the Packages, and of any
+// other package set to a level of its own.
+func PackageLevels() map[string]string {
+	levels := make(map[string]string)
+	for _, pkg := range Packages {
+		levels[pkg] = LevelName(PackageLevel(pkg))
+	}
+	structuredMutex.Lock()
+	defer structuredMutex.Unlock()
+	for pkg, level := range structuredLevels {
+		levels[pkg] = LevelName(level)
+	}
+	return levels
+}
+
+// PackageLevel returns the level of the structured loggers of a package.
+func PackageLevel(pkg string) Level {
+	structuredMutex.Lock()
+	defer structuredMutex.Unlock()
+	if level, ok := structuredLevels[pkg]; ok {
+		return level
+	}
+	return DefaultPackageLevel
+}
+
+// A Logger writes structured lines for a package, each with the fields of the
+// logger.  Unlike the FLogger, it never exits the program.
+type Logger struct {
+	pkg    string
+	fields Fields
+}
+
+// ForPackage returns the logger of a package, without fields.
+func ForPackage(pkg string) *Logger {
+	return &Logger{pkg: pkg, fields: Fields{}}
+}
+
+// WithField returns a logger that adds the field to those of this one.
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return l.WithFields(Fields{key: value})
+}
+
+// WithFields returns a logger that adds the fields to those of this one.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	all := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		all[k] = v
+	}
+	for k, v := range fields {
+		all[k] = v
+	}
+	return &Logger{pkg: l.pkg, fields: all}
+}
+
+// Enabled returns whether lines of the level are written for the package.
+func (l *Logger) Enabled(level Level) bool {
+	return level <= PackageLevel(l.pkg)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(ErrorLvl, format, args...)
+}
+
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	l.logf(WarningLvl, format, args...)
+}
+
+func (l *Logger) Noticef(format string, args ...interface{}) {
+	l.logf(NoticeLvl, format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(InfoLvl, format, args...)
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(DebugLvl, format, args...)
+}
+
+// Logf writes a line at the level, if it is enabled.
+func (l *Logger) Logf(level Level, format string, args ...interface{}) {
+	l.logf(level, format, args...)
+}
+
+func (l *Logger) logf(level Level, format string, args ...interface{}) {
+	// Do not do overhead of formatting a string if not going to log
+	if !l.Enabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	now := time.Now().Format(time.RFC3339)
+
+	structuredMutex.Lock()
+	defer structuredMutex.Unlock()
+	if structuredJSON {
+		line := make(map[string]interface{}, len(l.fields)+4)
+		for k, v := range l.fields {
+			line[k] = v
+		}
+		line["time"] = now
+		line["level"] = strings.ToLower(levelPrefix[level])
+		line["package"] = l.pkg
+		line["msg"] = msg
+		data, err := json.Marshal(line)
+		if err != nil {
+			data, _ = json.Marshal(map[string]string{"time": now, "level": "error", "package": l.pkg, "msg": err.Error()})
+		}
+		fmt.Fprintf(structuredOut, "%s\n", data)
+		return
+	}
+
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var fields string
+	for _, k := range keys {
+		fields += fmt.Sprintf(" %s=%v", k, l.fields[k])
+	}
+	fmt.Fprintf(structuredOut, "%s [%s] %s: %s%s\n", now, levelPrefix[level], l.pkg, msg, fields)
+}
@@ -0,0 +1,81 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/FactomProject/factomd/log"
+)
+
+func TestStructuredLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	SetOutput(buf)
+	defer SetOutput(os.Stdout)
+	defer SetPackageLevel(PackageState, "")
+	SetPackageLevel(PackageState, "warning")
+
+	l := ForPackage(PackageState).WithFields(Fields{FieldNodeID: "FNode0", FieldHeight: 7})
+	l.Infof("not written")
+	if buf.Len() != 0 {
+		t.Errorf("Info written at warning: %s", buf)
+	}
+	l.WithField(FieldMsgType, "EOM").Warningf("written %d", 1)
+	line := buf.String()
+	if !strings.Contains(line, "[WARNING] state: written 1 height=7 msgtype=EOM node=FNode0\n") {
+		t.Errorf("Wrong text line %q", line)
+	}
+
+	// Other packages log at the default level
+	buf.Reset()
+	ForPackage(PackageP2P).Infof("written")
+	if buf.Len() == 0 {
+		t.Errorf("Info not written at the default level")
+	}
+
+	SetFormat("json")
+	defer SetFormat("text")
+	buf.Reset()
+	l.Errorf("as %s", "json")
+	var fields map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("%v: %s", err, buf)
+	}
+	if fields["level"] != "error" || fields["package"] != "state" || fields["msg"] != "as json" || fields["node"] != "FNode0" || fields["height"] != 7.0 {
+		t.Errorf("Wrong JSON line %s", buf)
+	}
+}
+
+func TestChangePackageLevel(t *testing.T) {
+	defer SetPackageLevel(PackageP2P, "")
+
+	err := ChangePackageLevel(PackageP2P, "DEBUG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PackageLevel(PackageP2P) != DebugLvl {
+		t.Errorf("Level is %v", PackageLevel(PackageP2P))
+	}
+	err = ChangePackageLevel(PackageP2P, "loud")
+	if err == nil {
+		t.Errorf("No error for an unknown level")
+	}
+	if PackageLevel(PackageP2P) != DebugLvl {
+		t.Errorf("Unknown level changed the level to %v", PackageLevel(PackageP2P))
+	}
+
+	levels := PackageLevels()
+	if levels[PackageP2P] != "debug" || levels[PackageState] != "info" {
+		t.Errorf("Wrong levels %v", levels)
+	}
+
+	err = ChangePackageLevel(PackageP2P, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PackageLevel(PackageP2P) != DefaultPackageLevel {
+		t.Errorf("Level not reset, is %v", PackageLevel(PackageP2P))
+	}
+}
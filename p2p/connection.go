@@ -195,10 +195,10 @@ func (c *Connection) commonInit(peer Peer) {
 	c.SendChannel = make(chan interface{}, StandardChannelSize)
 	c.ReceiveChannel = make(chan interface{}, StandardChannelSize)
 	c.ReceiveParcel = make(chan *Parcel, StandardChannelSize)
-	c.metrics = ConnectionMetrics{MomentConnected: time.Now()}
-	c.timeLastMetrics = time.Now()
-	c.timeLastAttempt = time.Now()
-	c.timeLastStatus = time.Now()
+	c.metrics = ConnectionMetrics{MomentConnected: Clock.Now()}
+	c.timeLastMetrics = Clock.Now()
+	c.timeLastAttempt = Clock.Now()
+	c.timeLastStatus = Clock.Now()
 }
 
 func (c *Connection) Start() {
@@ -228,7 +228,7 @@ func (c *Connection) runLoop() {
 		for {
 			select {
 			case m := <-c.ReceiveParcel:
-				c.TimeLastpacket = time.Now()
+				c.TimeLastpacket = Clock.Now()
 				c.handleParcel(*m)
 
 			default:
@@ -248,7 +248,7 @@ func (c *Connection) runLoop() {
 		case ConnectionOnline:
 			p2pConnectionRunLoopOnline.Inc()
 			c.pingPeer() // sends a ping periodically if things have been quiet
-			if PeerSaveInterval < time.Since(c.timeLastUpdate) {
+			if PeerSaveInterval < Clock.Since(c.timeLastUpdate) {
 				c.updatePeer() // every PeerSaveInterval * 0.90 we send an update peer to the controller.
 			}
 
@@ -289,7 +289,7 @@ func (c *Connection) dialLoop() {
 	defer p2pConnectionDialLoop.Dec()
 
 	for {
-		c.timeLastAttempt = time.Now()
+		c.timeLastAttempt = Clock.Now()
 		if c.dial() {
 			c.goOnline()
 			return
@@ -326,7 +326,7 @@ func (c *Connection) dial() bool {
 // Called when we are online and connected to the peer.
 func (c *Connection) goOnline() {
 	p2pConnectionOnlineCall.Inc()
-	now := time.Now()
+	now := Clock.Now()
 	c.encoder = gob.NewEncoder(c.conn)
 	c.decoder = gob.NewDecoder(c.conn)
 	c.attempts = 0
@@ -487,8 +487,17 @@ func (c *Connection) processReceives() {
 				c.metrics.BytesReceived += message.Header.Length
 				c.metrics.MessagesReceived += 1
 				message.Header.PeerAddress = c.peer.Address
-				c.ReceiveParcel <- &message
-				c.TimeLastpacket = time.Now()
+				if Faults != nil {
+					received := message
+					Faults.Inject(c.peer.Address, message.Payload, func(payload []byte) {
+						parcel := received
+						parcel.Payload = payload
+						c.ReceiveParcel <- &parcel
+					})
+				} else {
+					c.ReceiveParcel <- &message
+				}
+				c.TimeLastpacket = Clock.Now()
 			default:
 				c.Errors <- err
 			}
@@ -552,9 +561,9 @@ func (c *Connection) handleParcel(parcel Parcel) {
 		return
 	case ParcelValid:
 		parcel.Trace("Connection.handleParcel()-ParcelValid", "I")
-		c.peer.LastContact = time.Now() // We only update for valid messages (incluidng pings and heartbeats)
-		c.attempts = 0                  // reset since we are clearly in touch now.
-		c.peer.merit()                  // Increase peer quality score.
+		c.peer.LastContact = Clock.Now() // We only update for valid messages (incluidng pings and heartbeats)
+		c.attempts = 0                   // reset since we are clearly in touch now.
+		c.peer.merit()                   // Increase peer quality score.
 		debug(c.peer.PeerIdent(), "Connection.handleParcel() got ParcelValid %s", parcel.MessageType())
 		if Notes <= CurrentLoggingLevel {
 			parcel.PrintMessageType()
@@ -620,6 +629,10 @@ func (c *Connection) handleParcelTypes(parcel Parcel) {
 		BlockFreeChannelSend(c.ReceiveChannel, ConnectionParcel{Parcel: parcel}) // Controller handles these.
 	case TypePeerResponse:
 		BlockFreeChannelSend(c.ReceiveChannel, ConnectionParcel{Parcel: parcel}) // Controller handles these.
+	case TypeHeadersRequest, TypeChainHeadRequest, TypeProofRequest:
+		BlockFreeChannelSend(c.ReceiveChannel, ConnectionParcel{Parcel: parcel}) // Controller handles these.
+	case TypeHeadersResponse, TypeChainHeadResponse, TypeProofResponse: // We are a full node, not a light client
+		return
 	case TypeMessage:
 		c.peer.QualityScore = c.peer.QualityScore + 1
 		// Store our connection ID so the controller can direct response to us.
@@ -638,8 +651,8 @@ func (c *Connection) handleParcelTypes(parcel Parcel) {
 }
 
 func (c *Connection) pingPeer() {
-	durationLastContact := time.Since(c.peer.LastContact)
-	durationLastPing := time.Since(c.timeLastPing)
+	durationLastContact := Clock.Since(c.peer.LastContact)
+	durationLastPing := Clock.Since(c.timeLastPing)
 	if PingInterval < durationLastContact && PingInterval < durationLastPing {
 		if MaxNumberOfRedialAttempts < c.attempts {
 			c.goOffline()
@@ -647,7 +660,7 @@ func (c *Connection) pingPeer() {
 		} else {
 			parcel := NewParcel(CurrentNetwork, []byte("Ping"))
 			parcel.Header.Type = TypePing
-			c.timeLastPing = time.Now()
+			c.timeLastPing = Clock.Now()
 			c.attempts++
 			BlockFreeChannelSend(c.SendChannel, ConnectionParcel{Parcel: *parcel})
 		}
@@ -655,13 +668,13 @@ func (c *Connection) pingPeer() {
 }
 
 func (c *Connection) updatePeer() {
-	c.timeLastUpdate = time.Now()
+	c.timeLastUpdate = Clock.Now()
 	BlockFreeChannelSend(c.ReceiveChannel, ConnectionCommand{Command: ConnectionUpdatingPeer, Peer: c.peer})
 }
 
 func (c *Connection) updateStats() {
-	if time.Second < time.Since(c.timeLastMetrics) {
-		c.timeLastMetrics = time.Now()
+	if time.Second < Clock.Since(c.timeLastMetrics) {
+		c.timeLastMetrics = Clock.Now()
 		c.metrics.PeerAddress = c.peer.Address
 		c.metrics.PeerQuality = c.peer.QualityScore
 		c.metrics.ConnectionState = connectionStateStrings[c.state]
@@ -676,9 +689,9 @@ func (c *Connection) ConnectionState() string {
 }
 
 func (c *Connection) connectionStatusReport() {
-	reportDuration := time.Since(c.timeLastStatus)
+	reportDuration := Clock.Since(c.timeLastStatus)
 	if reportDuration > ConnectionStatusInterval {
-		c.timeLastStatus = time.Now()
-		significant("connection-report", "\n\n===============================================================================\n     Connection: %s\n          State: %s\n          Notes: %s\n           Hash: %s\n     Persistent: %t\n       Outgoing: %t\n ReceiveChannel: %d\n    SendChannel: %d\n\tConnStatusInterval:\t%s\n\treportDuration:\t\t%s\n\tTime Online:\t\t%s \nMsgs/Bytes: %d / %d \n==============================================================================\n\n", c.peer.AddressPort(), c.ConnectionState(), c.Notes(), c.peer.Hash[0:12], c.IsPersistent(), c.IsOutGoing(), len(c.ReceiveChannel), len(c.SendChannel), ConnectionStatusInterval.String(), reportDuration.String(), time.Since(c.timeLastAttempt), c.metrics.MessagesReceived+c.metrics.MessagesSent, c.metrics.BytesSent+c.metrics.BytesReceived)
+		c.timeLastStatus = Clock.Now()
+		significant("connection-report", "\n\n===============================================================================\n     Connection: %s\n          State: %s\n          Notes: %s\n           Hash: %s\n     Persistent: %t\n       Outgoing: %t\n ReceiveChannel: %d\n    SendChannel: %d\n\tConnStatusInterval:\t%s\n\treportDuration:\t\t%s\n\tTime Online:\t\t%s \nMsgs/Bytes: %d / %d \n==============================================================================\n\n", c.peer.AddressPort(), c.ConnectionState(), c.Notes(), c.peer.Hash[0:12], c.IsPersistent(), c.IsOutGoing(), len(c.ReceiveChannel), len(c.SendChannel), ConnectionStatusInterval.String(), reportDuration.String(), Clock.Since(c.timeLastAttempt), c.metrics.MessagesReceived+c.metrics.MessagesSent, c.metrics.BytesSent+c.metrics.BytesReceived)
 	}
 }
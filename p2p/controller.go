@@ -53,6 +53,9 @@ type Controller struct {
 	lastPeerRequest            time.Time       // Last time we asked peers about the peers they know about.
 	specialPeersString         string          // configuration set special peers
 	partsAssembler             *PartsAssembler // a data structure that assembles full messages from received message parts
+	lightServer                LightServer     // answers the requests of light clients, if set
+	lightRequests              chan lightRequest
+	lightLimiter               *LightLimiter
 
 	// Logger
 	Logger *log.FLogger
@@ -68,6 +71,7 @@ type ControllerInit struct {
 	ConnectionMetricsChannel chan interface{} // Channel on which we put the connection metrics map, periodically.
 	LogPath                  string           // Path for logs
 	LogLevel                 string           // Logging level
+	LightServer              LightServer      // Answers the requests of light clients; nil not to serve them
 }
 
 // CommandDialPeer is used to instruct the Controller to dial a peer address
@@ -185,13 +189,16 @@ func (c *Controller) Init(ci ControllerInit) *Controller {
 	c.listenPort = ci.Port
 	NetworkListenPort = ci.Port
 	c.lastPeerManagement = time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
-	c.lastPeerRequest = time.Now()
+	c.lastPeerRequest = Clock.Now()
 	CurrentNetwork = ci.Network
 	OnlySpecialPeers = ci.Exclusive
 	c.specialPeersString = ci.SpecialPeers
-	c.lastDiscoveryRequest = time.Now() // Discovery does its own on startup.
-	c.lastConnectionMetricsUpdate = time.Now()
+	c.lastDiscoveryRequest = Clock.Now() // Discovery does its own on startup.
+	c.lastConnectionMetricsUpdate = Clock.Now()
 	c.partsAssembler = new(PartsAssembler).Init()
+	c.lightServer = ci.LightServer
+	c.lightRequests = make(chan lightRequest, LightQueueSize)
+	c.lightLimiter = NewLightLimiter(LightRequestsPerSecond, LightRequestBurst)
 	discovery := new(Discovery).Init(ci.PeersFile, ci.SeedURL)
 	c.discovery = *discovery
 	c.Logger = log.NewLogFromConfig(ci.LogPath, ci.LogLevel, "Networking")
@@ -203,12 +210,17 @@ func (c *Controller) Init(ci ControllerInit) *Controller {
 // StartNetwork configures the network, starts the runloop
 func (c *Controller) StartNetwork() {
 	significant("ctrlr", "Controller.StartNetwork(%s)", " ")
-	c.lastStatusReport = time.Now()
+	c.lastStatusReport = Clock.Now()
 	// start listening on port given
 	c.listen()
 	// Dial the peers in from configuration
 	c.DialSpecialPeersString(c.specialPeersString)
-	// Start the runloop
+	// Start the light client workers and the runloop
+	if c.lightServer != nil {
+		for i := 0; i < LightWorkers; i++ {
+			go c.lightWorker()
+		}
+	}
 	go c.runloop()
 }
 
@@ -222,7 +234,7 @@ func (c *Controller) DialSpecialPeersString(peersString string) {
 		ipPort := strings.Split(peerAddress, ":")
 		if len(ipPort) == 2 {
 			peer := new(Peer).Init(ipPort[0], ipPort[1], 0, SpecialPeer, 0)
-			peer.Source["Local-Configuration"] = time.Now()
+			peer.Source["Local-Configuration"] = Clock.Now()
 			c.DialPeer(*peer, true) // these are persistent connections
 		} else {
 			logfatal("Controller", "Error: %s is not a valid peer, use format: 127.0.0.1:8999", peerAddress)
@@ -522,12 +534,47 @@ func (c *Controller) handleParcelReceive(message interface{}, peerHash string, c
 	case TypePeerResponse:
 		// Add these peers to our known peers
 		c.discovery.LearnPeers(parcel)
+	case TypeHeadersRequest, TypeChainHeadRequest, TypeProofRequest:
+		if c.lightServer == nil {
+			break
+		}
+		if !c.lightLimiter.Allow(peerHash, Clock.Now()) {
+			note("ctrlr", "Controller.handleParcelReceive() %s from %s is over the rate limit", CommandStrings[parcel.Header.Type], connection.peer.PeerIdent())
+			break
+		}
+		// Looking up the blocks may take a while, so it's done by the workers, off the runloop.
+		select {
+		case c.lightRequests <- lightRequest{parcel: parcel, connection: connection}:
+		default:
+			note("ctrlr", "Controller.handleParcelReceive() dropped %s from %s, the light client workers are busy", CommandStrings[parcel.Header.Type], connection.peer.PeerIdent())
+		}
 	default:
 		logfatal("ctrlr", "handleParcelReceive() unknown parcel.Header.Type?: %+v ", parcel)
 	}
 
 }
 
+// lightWorker answers the light client requests queued by the runloop.
+func (c *Controller) lightWorker() {
+	for request := range c.lightRequests {
+		c.serveLight(request.parcel, request.connection)
+	}
+}
+
+// serveLight answers a light client request on the connection that asked.
+func (c *Controller) serveLight(parcel Parcel, connection Connection) {
+	response, err := LightResponse(c.lightServer, parcel)
+	if err != nil {
+		significant("ctrlr", "Controller.serveLight() %s from %s failed: %v", CommandStrings[parcel.Header.Type], connection.peer.PeerIdent(), err)
+		return
+	}
+	if response == nil {
+		note("ctrlr", "Controller.serveLight() malformed %s from %s", CommandStrings[parcel.Header.Type], connection.peer.PeerIdent())
+		return
+	}
+	BlockFreeChannelSend(connection.SendChannel, ConnectionParcel{Parcel: *response})
+}
+
 func (c *Controller) handleConnectionCommand(command ConnectionCommand, connection Connection) {
 	switch command.Command {
 	case ConnectionUpdateMetrics:
@@ -536,6 +583,7 @@ func (c *Controller) handleConnectionCommand(command ConnectionCommand, connecti
 		delete(c.connectionsByAddress, connection.peer.Address)
 		delete(c.connections, connection.peer.Hash)
 		delete(c.connectionMetrics, connection.peer.Hash)
+		c.lightLimiter.Forget(connection.peer.Hash)
 		go connection.goShutdown()
 	case ConnectionUpdatingPeer:
 		c.discovery.updatePeer(command.Peer)
@@ -561,7 +609,7 @@ func (c *Controller) handleCommand(command interface{}) {
 		addPort := strings.Split(conn.RemoteAddr().String(), ":")
 		// Port initially stored will be the connection port (not the listen port), but peer will update it on first message.
 		peer := new(Peer).Init(addPort[0], addPort[1], 0, RegularPeer, 0)
-		peer.Source["Accept()"] = time.Now()
+		peer.Source["Accept()"] = Clock.Now()
 		connection := new(Connection).InitWithConn(conn, *peer)
 		connection.Logger = c.Logger
 		connection.Start()
@@ -601,13 +649,13 @@ func (c *Controller) applicationPeerUpdate(qualityDelta int32, peerHash string)
 }
 
 func (c *Controller) managePeers() {
-	managementDuration := time.Since(c.lastPeerManagement)
+	managementDuration := Clock.Since(c.lastPeerManagement)
 	if PeerSaveInterval < managementDuration {
 		dot("&&s\n")
-		c.lastPeerManagement = time.Now()
+		c.lastPeerManagement = Clock.Now()
 		significant("ctrlr", "managePeers() time since last peer management: %s", managementDuration.String())
 		// If it's been awhile, update peers from the DNS seed.
-		discoveryDuration := time.Since(c.lastDiscoveryRequest)
+		discoveryDuration := Clock.Since(c.lastDiscoveryRequest)
 		if PeerDiscoveryInterval < discoveryDuration {
 			note("ctrlr", "calling c.discovery.DiscoverPeersFromSeed()")
 			c.discovery.DiscoverPeersFromSeed()
@@ -620,7 +668,7 @@ func (c *Controller) managePeers() {
 			// Get list of peers ordered by quality from discovery
 			c.fillOutgoingSlots(NumberPeersToConnect - c.numberOutgoingConnections)
 		}
-		duration := time.Since(c.discovery.lastPeerSave)
+		duration := Clock.Since(c.discovery.lastPeerSave)
 		// Every so often, tell the discovery service to save peers.
 		if PeerSaveInterval < duration {
 			note("controller", "Saving peers")
@@ -628,9 +676,9 @@ func (c *Controller) managePeers() {
 			c.discovery.PrintPeers() // No-op if debugging off.
 		}
 		dot("&&u\n")
-		duration = time.Since(c.lastPeerRequest)
+		duration = Clock.Since(c.lastPeerRequest)
 		if PeerRequestInterval < duration {
-			c.lastPeerRequest = time.Now()
+			c.lastPeerRequest = Clock.Now()
 			parcelp := NewParcel(CurrentNetwork, []byte("Peer Request"))
 			parcel := *parcelp
 			parcel.Header.Type = TypePeerRequest
@@ -691,9 +739,9 @@ func (c *Controller) fillOutgoingSlots(openSlots int) {
 }
 
 func (c *Controller) updateMetrics() {
-	if time.Second < time.Since(c.lastConnectionMetricsUpdate) {
+	if time.Second < Clock.Since(c.lastConnectionMetricsUpdate) {
 		dot("@@8\n")
-		c.lastConnectionMetricsUpdate = time.Now()
+		c.lastConnectionMetricsUpdate = Clock.Now()
 		// Apparently golang doesn't make a deep copy when sending structs over channels. Bad golang.
 		newMetrics := make(map[string]ConnectionMetrics)
 		for key, value := range c.connections {
@@ -728,10 +776,10 @@ func (c *Controller) shutdown() {
 }
 
 func (c *Controller) networkStatusReport() {
-	durationSinceLastReport := time.Since(c.lastStatusReport)
+	durationSinceLastReport := Clock.Since(c.lastStatusReport)
 	note("ctrlr", "networkStatusReport() NetworkStatusInterval: %s durationSinceLastReport: %s c.lastStatusReport: %s", NetworkStatusInterval.String(), durationSinceLastReport.String(), c.lastStatusReport.String())
 	if durationSinceLastReport > NetworkStatusInterval {
-		c.lastStatusReport = time.Now()
+		c.lastStatusReport = Clock.Now()
 		c.updateConnectionCounts()
 		silence("ctrlr", "\n\n\n\n")
 		silence("ctrlr", "###################################")
@@ -751,10 +799,10 @@ func (c *Controller) networkStatusReport() {
 		for _, v := range c.connections {
 			metrics, present := c.connectionMetrics[v.peer.Hash]
 			if !present {
-				metrics = ConnectionMetrics{MomentConnected: time.Now(), ConnectionState: "No Metrics", ConnectionNotes: "No Metrics"}
+				metrics = ConnectionMetrics{MomentConnected: Clock.Now(), ConnectionState: "No Metrics", ConnectionNotes: "No Metrics"}
 			}
 			silence("ctrlr", "Location: %d", v.peer.Location)
-			silence("ctrlr", "%s\t%s\t%s\t%s", v.peer.PeerFixedIdent(), time.Since(metrics.MomentConnected), metrics.ConnectionState, metrics.ConnectionNotes)
+			silence("ctrlr", "%s\t%s\t%s\t%s", v.peer.PeerFixedIdent(), Clock.Since(metrics.MomentConnected), metrics.ConnectionState, metrics.ConnectionNotes)
 			silence("ctrlr", "IsOutgoing: %t\tIsOnline: %t\tStatus: %s Quality: %d", v.IsOutGoing(), v.IsOnline(), v.StatusString(), metrics.PeerQuality)
 			silence("ctrlr", "Sent/Recv: %d / %d\t\t Chan Send/Recv: %d / %d", metrics.MessagesSent, metrics.MessagesReceived, len(v.SendChannel), len(v.ReceiveChannel))
 			silence("ctrlr", ".")
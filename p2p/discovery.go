@@ -98,7 +98,7 @@ func (d *Discovery) LoadPeers() {
 // SavePeers just saves our known peers out to disk. Called periodically.
 func (d *Discovery) SavePeers() {
 	// save known peers to peers.json
-	d.lastPeerSave = time.Now()
+	d.lastPeerSave = Clock.Now()
 	file, err := os.Create(d.peersFilePath)
 	if nil != err {
 		logerror("discovery", "Discover.SavePeers() File write error on file: %s, Error: %+v", d.peersFilePath, err)
@@ -115,7 +115,7 @@ func (d *Discovery) SavePeers() {
 			qualityPeers[peer.AddressPort()] = peer
 			note("discovery", "SavePeers() saved peer in peers.json: %+v", peer)
 
-		case time.Since(peer.LastContact) > time.Hour*168:
+		case Clock.Since(peer.LastContact) > time.Hour*168:
 			note("discovery", "SavePeers() DID NOT SAVE peer in peers.json. Last Contact greater than 168 hours. Peer: %+v", peer)
 			break
 		case MinumumQualityScore > peer.QualityScore:
@@ -150,7 +150,7 @@ func (d *Discovery) LearnPeers(parcel Parcel) {
 			alreadyKnownPeer := d.getPeer(value.Address)
 			d.updatePeer(d.updatePeerSource(alreadyKnownPeer, parcel.Header.PeerAddress))
 		default:
-			value.Source = map[string]time.Time{parcel.Header.PeerAddress: time.Now()}
+			value.Source = map[string]time.Time{parcel.Header.PeerAddress: Clock.Now()}
 			d.updatePeer(value)
 			note("discovery", "Discovery.LearnPeers !!!!!!!!!!!!! Discoverd new PEER!   %+v ", value)
 		}
@@ -165,7 +165,7 @@ func (d *Discovery) updatePeerSource(peer Peer, source string) Peer {
 	}
 	_, sp := peer.Source[source]
 	if !sp {
-		peer.Source[source] = time.Now()
+		peer.Source[source] = Clock.Now()
 	}
 	return peer
 }
@@ -322,7 +322,7 @@ func (d *Discovery) DiscoverPeersFromSeed() {
 		if 2 == len(ipAndPort) {
 			peerp := new(Peer).Init(ipAndPort[0], ipAndPort[1], 0, RegularPeer, 0)
 			peer := *peerp
-			peer.LastContact = time.Now()
+			peer.LastContact = Clock.Now()
 			d.updatePeer(d.updatePeerSource(peer, "DNS-Seed"))
 		}
 	}
@@ -0,0 +1,131 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package p2p
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FaultConfig is a [NetworkFault "peer"] section of factomd.conf: how often
+// the parcels from the peer are dropped, delayed, duplicated, reordered or
+// corrupted.  Each is a probability from 0 to 1.  The section named "default"
+// applies to the peers without one of their own.
+type FaultConfig struct {
+	Drop       float64
+	Delay      float64
+	MaxDelayMs int // Delayed parcels wait up to this long
+	Duplicate  float64
+	Reorder    float64
+	Corrupt    float64
+}
+
+// DefaultFaultPeer names the faults of the peers without faults of their own.
+const DefaultFaultPeer = "default"
+
+// Faults mistreats the parcels received, to test the network under bad
+// conditions.  Nil, as it is unless factomd.conf has NetworkFault sections,
+// leaves the parcels alone.
+var Faults *FaultInjector
+
+// FaultInjector drops, delays, duplicates, reorders and corrupts payloads
+// from peers, with the probabilities of their FaultConfig.
+type FaultInjector struct {
+	mutex  sync.Mutex
+	peers  map[string]*FaultConfig
+	rng    *rand.Rand
+	held   map[string]func() // The payload of each peer held back to be reordered
+	counts map[string]int    // The faults injected, by kind
+}
+
+// NewFaultInjector returns an injector of the faults of the peers.  The seed
+// makes the faults repeatable from run to run.
+func NewFaultInjector(peers map[string]*FaultConfig, seed int64) *FaultInjector {
+	f := new(FaultInjector)
+	f.peers = peers
+	f.rng = rand.New(rand.NewSource(seed))
+	f.held = make(map[string]func())
+	f.counts = make(map[string]int)
+	return f
+}
+
+// Inject hands the payload from the peer to deliver, once, more than once, or
+// not at all, possibly late, out of order, or corrupted.  Delayed payloads are
+// delivered from another goroutine.
+func (f *FaultInjector) Inject(peer string, payload []byte, deliver func([]byte)) {
+	for _, send := range f.faults(peer, payload, deliver) {
+		send()
+	}
+}
+
+// faults decides what becomes of the payload, and returns the deliveries to
+// make now, which are made outside the lock.
+func (f *FaultInjector) faults(peer string, payload []byte, deliver func([]byte)) []func() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	c := f.peers[peer]
+	if c == nil {
+		c = f.peers[DefaultFaultPeer]
+	}
+	if c == nil {
+		return []func(){func() { deliver(payload) }}
+	}
+
+	if f.chance(c.Drop) {
+		f.counts["drop"]++
+		return nil
+	}
+	if f.chance(c.Corrupt) && len(payload) > 0 {
+		f.counts["corrupt"]++
+		corrupted := append([]byte(nil), payload...)
+		corrupted[f.rng.Intn(len(corrupted))] ^= byte(1 + f.rng.Intn(255))
+		payload = corrupted
+	}
+	send := func() { deliver(payload) }
+	if f.chance(c.Duplicate) {
+		f.counts["duplicate"]++
+		send = func() { deliver(payload); deliver(payload) }
+	}
+	if f.chance(c.Delay) && c.MaxDelayMs > 0 {
+		f.counts["delay"]++
+		delay := time.Duration(f.rng.Intn(c.MaxDelayMs)+1) * time.Millisecond
+		later := send
+		go func() {
+			Clock.Sleep(delay)
+			later()
+		}()
+		return nil
+	}
+
+	// A payload held back is delivered after the next one from the peer
+	held := f.held[peer]
+	delete(f.held, peer)
+	if held == nil && f.chance(c.Reorder) {
+		f.counts["reorder"]++
+		f.held[peer] = send
+		return nil
+	}
+	if held != nil {
+		return []func(){send, held}
+	}
+	return []func(){send}
+}
+
+// Counts returns how many of each fault have been injected.
+func (f *FaultInjector) Counts() map[string]int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	counts := make(map[string]int, len(f.counts))
+	for kind, n := range f.counts {
+		counts[kind] = n
+	}
+	return counts
+}
+
+func (f *FaultInjector) chance(p float64) bool {
+	return p > 0 && f.rng.Float64() < p
+}
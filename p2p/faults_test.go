@@ -0,0 +1,57 @@
+package p2p_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/FactomProject/factomd/p2p"
+)
+
+func TestFaultInjector(t *testing.T) {
+	var delivered [][]byte
+	deliver := func(payload []byte) { delivered = append(delivered, payload) }
+
+	f := NewFaultInjector(map[string]*FaultConfig{
+		"dropper":   {Drop: 1},
+		"doubler":   {Duplicate: 1},
+		"reorderer": {Reorder: 1},
+		"corrupter": {Corrupt: 1},
+	}, 1)
+
+	f.Inject("dropper", []byte("a"), deliver)
+	if len(delivered) != 0 {
+		t.Errorf("Dropped payload was delivered")
+	}
+
+	f.Inject("other", []byte("a"), deliver)
+	if len(delivered) != 1 {
+		t.Errorf("Payload from a peer without faults was not delivered once")
+	}
+
+	delivered = nil
+	f.Inject("doubler", []byte("a"), deliver)
+	if len(delivered) != 2 {
+		t.Errorf("Duplicated payload was delivered %d times", len(delivered))
+	}
+
+	delivered = nil
+	f.Inject("reorderer", []byte("a"), deliver)
+	f.Inject("reorderer", []byte("b"), deliver)
+	if len(delivered) != 2 || string(delivered[0]) != "b" || string(delivered[1]) != "a" {
+		t.Errorf("Payloads were not reordered: %q", delivered)
+	}
+
+	delivered = nil
+	payload := []byte("abcd")
+	f.Inject("corrupter", payload, deliver)
+	if len(delivered) != 1 || bytes.Equal(delivered[0], payload) || string(payload) != "abcd" {
+		t.Errorf("Payload was not corrupted in a copy: %q", delivered)
+	}
+
+	counts := f.Counts()
+	for _, kind := range []string{"drop", "duplicate", "reorder", "corrupt"} {
+		if counts[kind] != 1 {
+			t.Errorf("%d %s faults counted, not 1", counts[kind], kind)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// +build gofuzz
+
+package p2p
+
+import (
+	"bytes"
+	"encoding/gob"
+	"hash/crc32"
+)
+
+// Fuzz is the go-fuzz harness for the parcels a peer sends us.  Parcels are
+// gobs on the wire, so it decodes the data the way Connection.processReceives
+// does, then checks it and feeds message parts to the assembler.
+//
+//	go-fuzz-build github.com/FactomProject/factomd/p2p
+//	go-fuzz -bin=p2p-fuzz.zip -workdir=fuzz/p2p
+func Fuzz(data []byte) int {
+	var parcel Parcel
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&parcel); err != nil {
+		return 0
+	}
+	_ = parcel.String()
+	_ = parcel.MessageType()
+
+	if parcel.Header.Length != uint32(len(parcel.Payload)) || parcel.Header.Crc32 != crc32.Checksum(parcel.Payload, CRCKoopmanTable) {
+		return 0
+	}
+	if parcel.Header.Type == TypeMessagePart {
+		new(PartsAssembler).Init().handlePart(parcel)
+	}
+	return 1
+}
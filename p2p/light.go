@@ -0,0 +1,207 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package p2p
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// This file contains the commands light clients use to follow the chain without the entry bodies: the
+// directory block headers, the chain heads, and proofs that entries are in the chain.  The requests are
+// answered by the LightServer of the Controller, on the connection that asked.  A pool of LightWorkers
+// answers them, and each peer is held to LightRequestsPerSecond, so light clients can't tie up the node.
+
+// MaxLightHeaders is the most directory block headers sent in answer to one request.
+const MaxLightHeaders = 1000
+
+var (
+	LightWorkers           = 4    // Goroutines answering light client requests
+	LightQueueSize         = 100  // Requests waiting for a worker; more are dropped
+	LightRequestsPerSecond = 10.0 // Requests a peer may make each second, on average
+	LightRequestBurst      = 20   // Requests a peer may make at once
+)
+
+// LightServer looks up what light clients ask for.  It is given to the Controller by the application,
+// which holds the blocks.
+type LightServer interface {
+	// DirectoryBlockHeaders returns the marshalled headers of the directory blocks from the start height
+	// on, up to count of them, stopping at the first block it doesn't have.
+	DirectoryBlockHeaders(start uint32, count uint32) ([][]byte, error)
+	// ChainHead returns the key merkle root of the head entry block of the chain, or nil if the chain is
+	// not known.
+	ChainHead(chainID []byte) ([]byte, error)
+	// EntryProof returns the receipt of the entry, which ties it to a directory block, or nil if the
+	// entry is not known.
+	EntryProof(entryHash []byte) ([]byte, error)
+}
+
+// NewHeadersRequest returns the parcel asking for count directory block headers from the start height on.
+func NewHeadersRequest(start uint32, count uint32) *Parcel {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint32(payload, start)
+	binary.BigEndian.PutUint32(payload[4:], count)
+	parcel := NewParcel(CurrentNetwork, payload)
+	parcel.Header.Type = TypeHeadersRequest
+	return parcel
+}
+
+// NewChainHeadRequest returns the parcel asking for the head of the chain.
+func NewChainHeadRequest(chainID []byte) *Parcel {
+	parcel := NewParcel(CurrentNetwork, chainID)
+	parcel.Header.Type = TypeChainHeadRequest
+	return parcel
+}
+
+// NewProofRequest returns the parcel asking for the proof of the entry.
+func NewProofRequest(entryHash []byte) *Parcel {
+	parcel := NewParcel(CurrentNetwork, entryHash)
+	parcel.Header.Type = TypeProofRequest
+	return parcel
+}
+
+// ParseHeadersResponse returns the start height and the marshalled headers of a headers response.
+func ParseHeadersResponse(payload []byte) (uint32, [][]byte, error) {
+	if len(payload) < 4 {
+		return 0, nil, fmt.Errorf("Headers response of %d bytes is too short", len(payload))
+	}
+	start := binary.BigEndian.Uint32(payload)
+	headers := [][]byte{}
+	for rest := payload[4:]; len(rest) > 0; {
+		if len(rest) < 4 {
+			return 0, nil, fmt.Errorf("Headers response cut short")
+		}
+		size := binary.BigEndian.Uint32(rest)
+		rest = rest[4:]
+		if uint32(len(rest)) < size {
+			return 0, nil, fmt.Errorf("Headers response cut short")
+		}
+		headers = append(headers, rest[:size])
+		rest = rest[size:]
+	}
+	return start, headers, nil
+}
+
+// ParseKeyedResponse returns the chain ID or entry hash a chain head or proof response is for, and
+// what was found for it, which is empty if nothing was.
+func ParseKeyedResponse(payload []byte) ([]byte, []byte, error) {
+	if len(payload) < 32 {
+		return nil, nil, fmt.Errorf("Response of %d bytes is too short", len(payload))
+	}
+	return payload[:32], payload[32:], nil
+}
+
+// LightResponse returns the answer of the server to a light client request, or nil if the request is
+// malformed.
+func LightResponse(server LightServer, parcel Parcel) (*Parcel, error) {
+	var payload bytes.Buffer
+	var responseType ParcelCommandType
+	switch parcel.Header.Type {
+	case TypeHeadersRequest:
+		if len(parcel.Payload) != 8 {
+			return nil, nil
+		}
+		start := binary.BigEndian.Uint32(parcel.Payload)
+		count := binary.BigEndian.Uint32(parcel.Payload[4:])
+		if count > MaxLightHeaders {
+			count = MaxLightHeaders
+		}
+		headers, err := server.DirectoryBlockHeaders(start, count)
+		if err != nil {
+			return nil, err
+		}
+		binary.Write(&payload, binary.BigEndian, start)
+		for _, header := range headers {
+			binary.Write(&payload, binary.BigEndian, uint32(len(header)))
+			payload.Write(header)
+		}
+		responseType = TypeHeadersResponse
+	case TypeChainHeadRequest:
+		if len(parcel.Payload) != 32 {
+			return nil, nil
+		}
+		head, err := server.ChainHead(parcel.Payload)
+		if err != nil {
+			return nil, err
+		}
+		payload.Write(parcel.Payload)
+		payload.Write(head)
+		responseType = TypeChainHeadResponse
+	case TypeProofRequest:
+		if len(parcel.Payload) != 32 {
+			return nil, nil
+		}
+		proof, err := server.EntryProof(parcel.Payload)
+		if err != nil {
+			return nil, err
+		}
+		payload.Write(parcel.Payload)
+		payload.Write(proof)
+		responseType = TypeProofResponse
+	default:
+		return nil, nil
+	}
+	response := NewParcel(CurrentNetwork, payload.Bytes())
+	response.Header.Type = responseType
+	return response, nil
+}
+
+// lightRequest is a light client request waiting for a worker.
+type lightRequest struct {
+	parcel     Parcel
+	connection Connection
+}
+
+// LightLimiter limits how often each peer may ask for something, with a
+// bucket of tokens per peer.  A bucket holds up to burst tokens, and refills
+// at perSecond tokens a second; each request takes one.  It is only used from
+// the runloop, so it isn't locked.
+type LightLimiter struct {
+	perSecond float64
+	burst     float64
+	buckets   map[string]*lightBucket
+}
+
+type lightBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewLightLimiter(perSecond float64, burst int) *LightLimiter {
+	l := new(LightLimiter)
+	l.perSecond = perSecond
+	l.burst = float64(burst)
+	l.buckets = make(map[string]*lightBucket)
+	return l
+}
+
+// Allow takes a token from the bucket of the peer, and returns false if
+// there is none.
+func (l *LightLimiter) Allow(peer string, now time.Time) bool {
+	b, ok := l.buckets[peer]
+	if !ok {
+		b = &lightBucket{tokens: l.burst, last: now}
+		l.buckets[peer] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * l.perSecond
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Forget drops the bucket of a peer that has gone.
+func (l *LightLimiter) Forget(peer string) {
+	delete(l.buckets, peer)
+}
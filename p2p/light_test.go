@@ -0,0 +1,116 @@
+package p2p_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factomd/p2p"
+)
+
+// fakeLightServer has directory blocks up to height 9, and one chain and entry.
+type fakeLightServer struct{}
+
+func (fakeLightServer) DirectoryBlockHeaders(start uint32, count uint32) ([][]byte, error) {
+	headers := [][]byte{}
+	for h := start; h < start+count && h < 10; h++ {
+		headers = append(headers, []byte(fmt.Sprintf("header %d", h)))
+	}
+	return headers, nil
+}
+
+func (fakeLightServer) ChainHead(chainID []byte) ([]byte, error) {
+	if chainID[0] != 1 {
+		return nil, nil
+	}
+	return bytes.Repeat([]byte{2}, 32), nil
+}
+
+func (fakeLightServer) EntryProof(entryHash []byte) ([]byte, error) {
+	if entryHash[0] != 3 {
+		return nil, nil
+	}
+	return []byte(`{"receipt":1}`), nil
+}
+
+func TestLightResponse(t *testing.T) {
+	response, err := LightResponse(fakeLightServer{}, *NewHeadersRequest(8, 5))
+	if err != nil || response == nil {
+		t.Fatalf("No response: %v", err)
+	}
+	if response.Header.Type != TypeHeadersResponse {
+		t.Errorf("Response is a %s", CommandStrings[response.Header.Type])
+	}
+	start, headers, err := ParseHeadersResponse(response.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 8 || len(headers) != 2 || string(headers[0]) != "header 8" || string(headers[1]) != "header 9" {
+		t.Errorf("Wrong headers from %d: %q", start, headers)
+	}
+
+	chainID := bytes.Repeat([]byte{1}, 32)
+	response, err = LightResponse(fakeLightServer{}, *NewChainHeadRequest(chainID))
+	if err != nil || response == nil || response.Header.Type != TypeChainHeadResponse {
+		t.Fatalf("No chain head response: %v", err)
+	}
+	key, head, err := ParseKeyedResponse(response.Payload)
+	if err != nil || !bytes.Equal(key, chainID) || !bytes.Equal(head, bytes.Repeat([]byte{2}, 32)) {
+		t.Errorf("Wrong chain head %x of %x: %v", head, key, err)
+	}
+
+	response, err = LightResponse(fakeLightServer{}, *NewProofRequest(bytes.Repeat([]byte{4}, 32)))
+	if err != nil || response == nil || response.Header.Type != TypeProofResponse {
+		t.Fatalf("No proof response: %v", err)
+	}
+	if _, proof, _ := ParseKeyedResponse(response.Payload); len(proof) != 0 {
+		t.Errorf("Proof %s of an unknown entry", proof)
+	}
+	response, _ = LightResponse(fakeLightServer{}, *NewProofRequest(bytes.Repeat([]byte{3}, 32)))
+	if _, proof, _ := ParseKeyedResponse(response.Payload); string(proof) != `{"receipt":1}` {
+		t.Errorf("Wrong proof %s", proof)
+	}
+
+	// Malformed requests are not answered
+	response, err = LightResponse(fakeLightServer{}, *NewChainHeadRequest([]byte{1}))
+	if response != nil || err != nil {
+		t.Errorf("Malformed request answered")
+	}
+}
+
+func TestLightLimiter(t *testing.T) {
+	l := NewLightLimiter(2, 3)
+	now := time.Unix(1000, 0)
+
+	// A peer may make burst requests at once, and no more
+	for i := 0; i < 3; i++ {
+		if !l.Allow("a", now) {
+			t.Fatalf("Request %d of the burst refused", i)
+		}
+	}
+	if l.Allow("a", now) {
+		t.Error("Request over the burst allowed")
+	}
+	// Another peer has a bucket of its own
+	if !l.Allow("b", now) {
+		t.Error("Request of another peer refused")
+	}
+
+	// The bucket refills at the rate
+	now = now.Add(500 * time.Millisecond)
+	if !l.Allow("a", now) {
+		t.Error("Request refused after the bucket refilled")
+	}
+	if l.Allow("a", now) {
+		t.Error("Request allowed before the bucket refilled")
+	}
+
+	// A peer that has gone starts again with a full bucket
+	l.Forget("a")
+	for i := 0; i < 3; i++ {
+		if !l.Allow("a", now) {
+			t.Fatalf("Request %d of a new peer refused", i)
+		}
+	}
+}
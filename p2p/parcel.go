@@ -42,14 +42,20 @@ type ParcelCommandType uint16
 
 // Parcel commands -- all new commands should be added to the *end* of the list!
 const ( // iota is reset to 0
-	TypeHeartbeat    ParcelCommandType = iota // "Note, I'm still alive"
-	TypePing                                  // "Are you there?"
-	TypePong                                  // "yes, I'm here"
-	TypePeerRequest                           // "Please share some peers"
-	TypePeerResponse                          // "Here's some peers I know about."
-	TypeAlert                                 // network wide alerts (used in bitcoin to indicate criticalities)
-	TypeMessage                               // Application level message
-	TypeMessagePart                           // Application level message that was split into multiple parts
+	TypeHeartbeat         ParcelCommandType = iota // "Note, I'm still alive"
+	TypePing                                       // "Are you there?"
+	TypePong                                       // "yes, I'm here"
+	TypePeerRequest                                // "Please share some peers"
+	TypePeerResponse                               // "Here's some peers I know about."
+	TypeAlert                                      // network wide alerts (used in bitcoin to indicate criticalities)
+	TypeMessage                                    // Application level message
+	TypeMessagePart                                // Application level message that was split into multiple parts
+	TypeHeadersRequest                             // "Please send some directory block headers"
+	TypeHeadersResponse                            // "Here are the headers."
+	TypeChainHeadRequest                           // "Where is the head of this chain?"
+	TypeChainHeadResponse                          // "Here is the head."
+	TypeProofRequest                               // "Prove this entry is in the chain"
+	TypeProofResponse                              // "Here is the proof."
 )
 
 // CommandStrings is a Map of command ids to strings for easy printing of network comands
@@ -62,6 +68,13 @@ var CommandStrings = map[ParcelCommandType]string{
 	TypeAlert:        "Alert",         // network wide alerts (used in bitcoin to indicate criticalities)
 	TypeMessage:      "Message",       // Application level message
 	TypeMessagePart:  "MessagePart",   // Application level message that was split into multiple parts
+
+	TypeHeadersRequest:    "Headers-Request",    // "Please send some directory block headers"
+	TypeHeadersResponse:   "Headers-Response",   // "Here are the headers."
+	TypeChainHeadRequest:  "ChainHead-Request",  // "Where is the head of this chain?"
+	TypeChainHeadResponse: "ChainHead-Response", // "Here is the head."
+	TypeProofRequest:      "Proof-Request",      // "Prove this entry is in the chain"
+	TypeProofResponse:     "Proof-Response",     // "Here is the proof."
 }
 
 // MaxPayloadSize is the maximum bytes a message can be at the networking level.
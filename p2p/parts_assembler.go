@@ -46,7 +46,7 @@ func (assembler *PartsAssembler) handlePart(parcel Parcel) *Parcel {
 	}
 
 	partial.parts[parcel.Header.PartNo] = &parcel
-	partial.mostRecentPartReceived = time.Now()
+	partial.mostRecentPartReceived = Clock.Now()
 
 	// get an assembled parcel or nil if not yet ready
 	fullParcel := tryReassemblingMessage(partial)
@@ -98,8 +98,8 @@ func validateParcelPart(parcel Parcel, partial *PartialMessage) (isValid bool, e
 // drops the partial message
 func (assembler *PartsAssembler) cleanupOldPartialMessages() {
 	for appHash, partial := range assembler.messages {
-		timeWaiting := time.Since(partial.mostRecentPartReceived)
-		timeSinceFirst := time.Since(partial.firstPartReceived)
+		timeWaiting := Clock.Since(partial.mostRecentPartReceived)
+		timeSinceFirst := Clock.Since(partial.firstPartReceived)
 		if timeWaiting > MaxTimeWaitingForReassembly {
 			delete(assembler.messages, appHash)
 			note("PartsAssembler", "Dropping message %d after %s secs, time since first part: %s secs",
@@ -112,7 +112,7 @@ func (assembler *PartsAssembler) cleanupOldPartialMessages() {
 func createNewPartialMessage(parcel Parcel) *PartialMessage {
 	partial := new(PartialMessage)
 	partial.parts = make([]*Parcel, parcel.Header.PartsTotal)
-	partial.firstPartReceived = time.Now()
+	partial.firstPartReceived = Clock.Now()
 	return partial
 }
 
@@ -12,7 +12,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/log"
 )
 
 // This file contains the global variables and utility functions for the p2p network operation.  The global variables and constants can be tweaked here.
@@ -46,7 +49,7 @@ func BlockFreeChannelSend(channel chan interface{}, message interface{}) int {
 var (
 	CurrentLoggingLevel                  = Errors // Start at verbose because it takes a few seconds for the controller to adjust to what you set.
 	CurrentNetwork                       = TestNet
-	NetworkListenPort                    = "8108"
+	NetworkListenPort                    = constants.LookupNetwork("MAIN").P2PPort
 	BroadcastFlag                        = "<BROADCAST>"
 	RandomPeerFlag                       = "<RANDOMPEER>"
 	NodeID                        uint64 = 0           // Random number used for loopback protection
@@ -68,6 +71,10 @@ var (
 	PeerRequestInterval                  = time.Second * 180
 	PeerDiscoveryInterval                = time.Hour * 4
 
+	// Clock times the pings, redials and peer management.  Tests and
+	// simulations may set one they advance by hand.
+	Clock interfaces.IClock = primitives.WallClock{}
+
 	// Testing metrics
 	TotalMessagesRecieved       uint64
 	TotalMessagesSent           uint64
@@ -91,13 +98,13 @@ type NetworkID uint32
 // Network indicators.
 const (
 	// MainNet represents the production network
-	MainNet NetworkID = 0xfeedbeef
+	MainNet NetworkID = NetworkID(constants.MAIN_P2P_NETWORK_ID)
 
 	// TestNet represents a testing network
-	TestNet NetworkID = 0xdeadbeef
+	TestNet NetworkID = NetworkID(constants.TEST_P2P_NETWORK_ID)
 
 	// LocalNet represents any arbitrary/private network
-	LocalNet NetworkID = 0xbeaded
+	LocalNet NetworkID = NetworkID(constants.LOCAL_P2P_NETWORK_ID)
 )
 
 func (n *NetworkID) String() string {
@@ -134,6 +141,21 @@ var LoggingLevels = map[uint8]string{
 	Verbose:     "Verbose",     // Log everything
 }
 
+// structuredLevels are the levels the lines of each logging level are written at, which the p2p level of
+// factomd.conf filters in turn.
+var structuredLevels = map[uint8]log.Level{
+	Silence:     log.NoticeLvl,
+	Significant: log.WarningLvl,
+	Fatal:       log.CriticalLvl,
+	Errors:      log.ErrorLvl,
+	Notes:       log.InfoLvl,
+	Debugging:   log.DebugLvl,
+	Verbose:     log.DebugLvl,
+}
+
+// p2pLog is the structured logger logP writes to.
+var p2pLog = log.ForPackage(log.PackageP2P)
+
 func dot(dot string) {
 	if 4 < CurrentLoggingLevel {
 		switch dot {
@@ -175,8 +197,10 @@ func logP(level uint8, component string, format string, v ...interface{}) {
 	// fmt.Fprintf(os.Stdout, "%s, %s, %d, %s, (%s), %d/%d, %s \n", now.String(), host, os.Getpid(), component, levelStr, level, CurrentLoggingLevel, message)
 
 	now := time.Now().Format("2006-01-02 15:04:05")
-	if level <= CurrentLoggingLevel { // lower level means more severe. "Silence" level always printed, overriding silence.
-		fmt.Printf("%s, %s, %s \n", now, component, message)
+	// lower level means more severe. "Silence" level always printed, overriding silence.  Setting the p2p
+	// package to debug, as during an incident, prints every level.
+	if level <= CurrentLoggingLevel || p2pLog.Enabled(log.DebugLvl) {
+		p2pLog.WithFields(log.Fields{log.FieldNodeID: NodeID, "component": component}).Logf(structuredLevels[level], "%s", message)
 		// fmt.Fprintf(os.Stdout, "%s, %d, %s, (%s), %s\n", host, os.Getpid(), component, levelStr, message)
 	}
 	if level == Fatal {
@@ -7,7 +7,9 @@ package receipts
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/FactomProject/factomd/anchor"
 	"github.com/FactomProject/factomd/common/directoryBlock/dbInfo"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
@@ -20,6 +22,57 @@ type Receipt struct {
 	DirectoryBlockKeyMR    *primitives.Hash         `json:"directoryblockkeymr,omitempty"`
 	BitcoinTransactionHash *primitives.Hash         `json:"bitcointransactionhash,omitempty"`
 	BitcoinBlockHash       *primitives.Hash         `json:"bitcoinblockhash,omitempty"`
+	Anchors                []*AnchorPath            `json:"anchors,omitempty"`
+}
+
+// AnchorPath takes a receipt from the directory block on to a transaction of
+// another chain that anchors it.  Data is the part of the transaction that
+// holds the directory block key merkle root: the OP_RETURN output script in
+// Bitcoin, and the call data of setAnchor in Ethereum.
+type AnchorPath struct {
+	Chain           string `json:"chain"`
+	TransactionHash string `json:"transactionhash"`
+	BlockHeight     int64  `json:"blockheight"`
+	BlockHash       string `json:"blockhash"`
+	Data            string `json:"data"`
+	Verified        bool   `json:"verified"` // The transaction was looked up in the chain
+}
+
+func (a *AnchorPath) IsSameAs(b *AnchorPath) bool {
+	if b == nil {
+		return false
+	}
+	return *a == *b
+}
+
+// AnchorPaths returns the paths of the anchors of a directory block.
+func AnchorPaths(info *anchor.AnchorInfo) ([]*AnchorPath, error) {
+	keyMR, err := primitives.HexToHash(info.KeyMR)
+	if err != nil {
+		return nil, err
+	}
+	paths := []*AnchorPath{}
+	if info.Bitcoin != nil {
+		p := new(AnchorPath)
+		p.Chain = anchor.ChainBitcoin
+		p.TransactionHash = info.Bitcoin.TXID
+		p.BlockHeight = int64(info.Bitcoin.BlockHeight)
+		p.BlockHash = info.Bitcoin.BlockHash
+		p.Data = anchor.BitcoinOpReturn(info.DBHeight, keyMR)
+		p.Verified = info.BitcoinVerified
+		paths = append(paths, p)
+	}
+	if info.Ethereum != nil {
+		p := new(AnchorPath)
+		p.Chain = anchor.ChainEthereum
+		p.TransactionHash = info.Ethereum.TXID
+		p.BlockHeight = info.Ethereum.BlockHeight
+		p.BlockHash = info.Ethereum.BlockHash
+		p.Data = anchor.SetAnchorData(info.DBHeight, keyMR)
+		p.Verified = info.EthereumVerified
+		paths = append(paths, p)
+	}
+	return paths, nil
 }
 
 func (e *Receipt) TrimReceipt() {
@@ -109,6 +162,12 @@ func (e *Receipt) Validate() error {
 		return fmt.Errorf("DirectoryBlockKeyMR not found in branch")
 	}
 
+	for i, path := range e.Anchors {
+		if strings.HasSuffix(strings.ToLower(path.Data), e.DirectoryBlockKeyMR.String()) == false {
+			return fmt.Errorf("Anchor %v/%v does not hold the DirectoryBlockKeyMR", i, len(e.Anchors))
+		}
+	}
+
 	return nil
 }
 
@@ -202,6 +261,15 @@ func (e *Receipt) IsSameAs(r *Receipt) bool {
 		}
 	}
 
+	if len(e.Anchors) != len(r.Anchors) {
+		return false
+	}
+	for i := range e.Anchors {
+		if e.Anchors[i].IsSameAs(r.Anchors[i]) == false {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -391,6 +459,22 @@ func CreateReceipt(dbo interfaces.DBOverlaySimple, entryID interfaces.IHash) (*R
 		receipt.BitcoinBlockHash = dbi.BTCBlockHash.(*primitives.Hash)
 	}
 
+	//Anchors
+
+	if fetcher, ok := dbo.(anchor.AnchorInfoFetcher); ok {
+		info, err := fetcher.FetchAnchorInfo(dBlock.GetHeader().GetDBHeight())
+		if err != nil {
+			return nil, err
+		}
+		// Anchors of another key merkle root don't anchor this block
+		if info != nil && info.KeyMRMatches {
+			receipt.Anchors, err = AnchorPaths(info)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return receipt, nil
 }
 
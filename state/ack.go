@@ -130,6 +130,81 @@ func (s *State) getACKStatus(hash interfaces.IHash, useOldMsgs bool) (int, inter
 
 }
 
+// GetAckLocation returns how far a commit, entry or factoid transaction has
+// come: its status, and the height of the block and the minute it was
+// acknowledged in, or the height and KeyMR of the directory block it was saved
+// in.  The minute is -1 unless the status is AckStatusACK.
+func (s *State) GetAckLocation(hash interfaces.IHash) (status int, dbheight uint32, minute int, keymr interfaces.IHash, err error) {
+	if s.GetInvalidMsg(hash) != nil {
+		return constants.AckStatusInvalid, 0, -1, nil, nil
+	}
+
+	in, err := s.DB.FetchIncludedIn(hash)
+	if err != nil {
+		return 0, 0, -1, nil, err
+	}
+	if in != nil {
+		dbKeyMR, err := s.DB.FetchIncludedIn(in)
+		if err != nil {
+			return 0, 0, -1, nil, err
+		}
+		if dbKeyMR != nil {
+			dBlock, err := s.DB.FetchDBlock(dbKeyMR)
+			if err != nil {
+				return 0, 0, -1, nil, err
+			}
+			if dBlock != nil {
+				return constants.AckStatusDBlockConfirmed, dBlock.GetHeader().GetDBHeight(), -1, dBlock.GetKeyMR(), nil
+			}
+		}
+	}
+
+	if found, dbheight, minute := s.findAcked(hash); found {
+		return constants.AckStatusACK, dbheight, minute, nil, nil
+	}
+
+	status, _, _, _ = s.FetchHoldingMessageByHash(hash)
+	return status, 0, -1, nil, nil
+}
+
+// findAcked looks for an acknowledged message of the transaction in the
+// process lists, and returns the height and minute it was acknowledged in.
+func (s *State) findAcked(hash interfaces.IHash) (found bool, dbheight uint32, minute int) {
+	s.ProcessLists.Mutex.RLock()
+	defer s.ProcessLists.Mutex.RUnlock()
+
+	for _, pl := range s.ProcessLists.Lists {
+		if pl == nil {
+			continue
+		}
+		for _, vm := range pl.VMs {
+			for i, msg := range vm.List {
+				if msg == nil || i >= len(vm.ListAck) || vm.ListAck[i] == nil || !isMsgOfTx(msg, hash) {
+					continue
+				}
+				return true, pl.DBHeight, int(vm.ListAck[i].Minute)
+			}
+		}
+	}
+	return false, 0, -1
+}
+
+// isMsgOfTx is true if msg commits, reveals or sends the transaction of hash,
+// which are matched as in FetchHoldingMessageByHash.
+func isMsgOfTx(msg interfaces.IMsg, hash interfaces.IHash) bool {
+	switch m := msg.(type) {
+	case *messages.CommitChainMsg:
+		return hash.IsSameAs(m.CommitChain.GetSigHash())
+	case *messages.CommitEntryMsg:
+		return hash.IsSameAs(m.CommitEntry.GetSigHash())
+	case *messages.RevealEntryMsg:
+		return hash.IsSameAs(m.Entry.GetHash())
+	case *messages.FactoidTransaction:
+		return hash.IsSameAs(m.Transaction.GetSigHash())
+	}
+	return false
+}
+
 func (s *State) FetchHoldingMessageByHash(hash interfaces.IHash) (int, byte, interfaces.IMsg, error) {
 	q := s.LoadHoldingMap()
 	for _, h := range q {
@@ -190,6 +265,9 @@ func (s *State) FetchHoldingMessageByHash(hash interfaces.IHash) (int, byte, int
 
 		}
 	}
+	if m := s.FactoidMempool.Get(hash); m != nil {
+		return constants.AckStatusNotConfirmed, constants.FACTOID_TRANSACTION_MSG, m, nil
+	}
 	return constants.AckStatusUnknown, byte(0), nil, fmt.Errorf("Not Found")
 }
 
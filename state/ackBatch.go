@@ -0,0 +1,91 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+)
+
+// A leader with an AckBatchSize above 1 doesn't sign its acks one by one.
+// The acks of each VM wait, and go out together in an AckBatch under one
+// signature, once AckBatchSize of them are waiting or at the end of the pass
+// of the state loop that made them.  So a busy leader signs, and its
+// followers check, one signature per batch rather than one per entry, while
+// a quiet one adds no delay.  Followers take the acks out of the batch, and
+// relay the batch as a whole.
+
+// sendAck sends out an ack added to a process list.  An ack of a batch went
+// out with its batch, and our own unsigned acks wait for the next one.
+func (s *State) sendAck(ack *messages.Ack) {
+	switch {
+	case ack.GetBatch() != nil:
+	case ack.GetSignature() == nil:
+		s.batchAck(ack)
+	default:
+		ack.SendOut(s, ack)
+	}
+}
+
+// batchAck adds one of our unsigned acks to the batch of its VM.
+func (s *State) batchAck(ack *messages.Ack) {
+	if s.ackBatches == nil {
+		s.ackBatches = make(map[int][]*messages.Ack)
+	}
+	if pending := s.ackBatches[ack.VMIndex]; len(pending) > 0 {
+		last := pending[len(pending)-1]
+		if last.DBHeight == ack.DBHeight && ack.Height <= last.Height {
+			return // Already waiting
+		}
+		if last.DBHeight != ack.DBHeight || ack.Height != last.Height+1 {
+			s.flushAckBatch(ack.VMIndex)
+		}
+	}
+	s.ackBatches[ack.VMIndex] = append(s.ackBatches[ack.VMIndex], ack)
+	if len(s.ackBatches[ack.VMIndex]) >= s.AckBatchSize || len(s.ackBatches[ack.VMIndex]) >= messages.MaxAckBatch {
+		s.flushAckBatch(ack.VMIndex)
+	}
+}
+
+// flushAckBatches sends out the acks waiting in every VM.
+func (s *State) flushAckBatches() {
+	for vmIndex := range s.ackBatches {
+		s.flushAckBatch(vmIndex)
+	}
+}
+
+// flushAckBatch sends out the acks waiting in the VM, as one batch.  Should
+// the batch fail, they are signed and sent one by one.
+func (s *State) flushAckBatch(vmIndex int) {
+	pending := s.ackBatches[vmIndex]
+	if len(pending) == 0 {
+		return
+	}
+	delete(s.ackBatches, vmIndex)
+
+	batch, err := messages.NewAckBatch(s, pending)
+	if err != nil {
+		s.Logger.Warningf("Sending %d acks of VM %d one by one: %v", len(pending), vmIndex, err)
+		for _, ack := range pending {
+			ack.Sign(s)
+			ack.SendOut(s, ack)
+		}
+		return
+	}
+	batch.SendOut(s, batch)
+}
+
+// FollowerExecuteAckBatch executes each ack of the batch, and relays it.
+func (s *State) FollowerExecuteAckBatch(m interfaces.IMsg) {
+	batch, ok := m.(*messages.AckBatch)
+	if !ok {
+		return
+	}
+	batch.SendOut(s, batch)
+	s.noteLeaderOrigin(batch.LeaderChainID, batch.GetOrigin())
+	for _, ack := range batch.Acks {
+		s.FollowerExecuteAck(ack)
+	}
+}
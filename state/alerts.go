@@ -0,0 +1,85 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FactomProject/factomd/events"
+)
+
+// How long the node may have no peers before it alerts
+const IsolationAlertDelay = time.Minute
+
+// How long an alert sent just before the node stops may take
+const AlertFlushTimeout = 5 * time.Second
+
+// alertWatch is what the alerts that are raised after a while keep track of.
+// The sync fields are used by the state loop, and the isolation ones by the
+// network housekeeping.
+type alertWatch struct {
+	savedHeight      uint32
+	savedAt          time.Time
+	syncAlerted      bool
+	isolatedSince    time.Time
+	isolationAlerted bool
+}
+
+// Alert logs a critical event, and sends it to the event sinks that want it,
+// such as a webhook that pages the operators.
+func (s *State) Alert(event *events.Event, format string, args ...interface{}) {
+	event.Message = fmt.Sprintf(format, args...)
+	s.Log().WithField("alert", event.Type).Errorf("%s", event.Message)
+	s.Events.Emit(event)
+}
+
+// checkSyncStall alerts once no block has been saved for
+// AlertSyncStallMinutes, and again the next time it stalls after one is.
+func (s *State) checkSyncStall() {
+	if s.AlertSyncStallMinutes <= 0 {
+		return
+	}
+	w := &s.alertWatch
+	now := s.GetClock().Now()
+	saved := s.GetHighestSavedBlk()
+	if w.savedAt.IsZero() || saved != w.savedHeight {
+		w.savedHeight = saved
+		w.savedAt = now
+		w.syncAlerted = false
+		return
+	}
+	stall := time.Duration(s.AlertSyncStallMinutes) * time.Minute
+	if !w.syncAlerted && now.Sub(w.savedAt) >= stall {
+		w.syncAlerted = true
+		s.Alert(&events.Event{Type: events.SyncStalled, Height: saved},
+			"No block has been saved for %d minutes; the highest saved is %d, and the highest known %d",
+			int(now.Sub(w.savedAt).Minutes()), saved, s.GetHighestKnownBlock())
+	}
+}
+
+// CheckIsolation is told how many peers the node has, and alerts once it has
+// had none for IsolationAlertDelay.
+func (s *State) CheckIsolation(peers int) {
+	w := &s.alertWatch
+	if peers > 0 {
+		if w.isolationAlerted {
+			s.Log().WithField("alert", events.Isolated).Noticef("Connected to %d peers again", peers)
+		}
+		w.isolatedSince = time.Time{}
+		w.isolationAlerted = false
+		return
+	}
+	now := s.GetClock().Now()
+	if w.isolatedSince.IsZero() {
+		w.isolatedSince = now
+		return
+	}
+	if !w.isolationAlerted && now.Sub(w.isolatedSince) >= IsolationAlertDelay {
+		w.isolationAlerted = true
+		s.Alert(&events.Event{Type: events.Isolated, Height: s.GetHighestSavedBlk()},
+			"The node has had no peers for %d seconds", int(now.Sub(w.isolatedSince).Seconds()))
+	}
+}
@@ -15,7 +15,6 @@ import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/common/primitives/random"
-	"github.com/FactomProject/factomd/log"
 )
 
 type HistoricKey struct {
@@ -541,7 +540,7 @@ func (st *State) UpdateAuthorityFromABEntry(entry interfaces.IABEntry) error {
 		}
 		AuthorityIndex = st.isAuthorityChain(f.IdentityChainID)
 		if AuthorityIndex == -1 {
-			log.Println(f.IdentityChainID.String() + " Cannot be removed.  Not in Authorities List.")
+			st.Log().Warningf("%s cannot be removed, not in the authorities list", f.IdentityChainID.String())
 		} else {
 			st.RemoveAuthority(f.IdentityChainID)
 			IdentityIndex := st.isIdentityChain(f.IdentityChainID)
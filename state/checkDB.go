@@ -0,0 +1,306 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+// checkedBlock is what CheckDatabase needs to know about any block a
+// directory block points to.
+type checkedBlock interface {
+	GetDatabaseHeight() uint32
+	DatabasePrimaryIndex() interfaces.IHash
+	GetChainID() interfaces.IHash
+}
+
+// checkAuthorities is the authority set, replayed from the admin blocks, that
+// CheckDatabase verifies the DBSigs against.
+type checkAuthorities struct {
+	bootstrapKey []byte
+	servers      map[[32]byte]bool   // Federated and audit servers
+	feds         map[[32]byte]bool   // Federated servers, a majority of which must sign each block
+	keys         map[[32]byte][]byte // Identity chain -> signing key
+}
+
+// CheckDB checks the integrity of the node's configured database with
+// CheckDatabase.  The database is opened on its own, s.DB is not touched.
+func (s *State) CheckDB(out io.Writer) error {
+	dbase, err := s.openDB(s.DBType)
+	if err != nil {
+		return err
+	}
+	defer dbase.Close()
+
+	return CheckDatabase(databaseOverlay.NewOverlay(dbase), s.GetNetworkBootStrapKey(), out)
+}
+
+// CheckDatabase walks the directory blocks of the database from genesis to the
+// highest block, and verifies every keyMR, back-reference, child block and
+// DBSig on the way.  DBSigs must be made by the bootstrap key, or by the key of
+// an authority added in the admin blocks, and once there are federated
+// servers, a majority of them must sign each block.  It stops at the first
+// inconsistency and returns it, with the height and keyMR of the directory
+// block it was found in.  Progress is written to out.
+func CheckDatabase(dbo interfaces.DBOverlay, bootstrapKey interfaces.IHash, out io.Writer) error {
+	head, err := dbo.FetchDBlockHead()
+	if err != nil {
+		return err
+	}
+	if head == nil {
+		return fmt.Errorf("The database has no directory blocks")
+	}
+	top := head.GetDatabaseHeight()
+	fmt.Fprintf(out, "Checking directory blocks 0 to %d\n", top)
+
+	auths := new(checkAuthorities)
+	auths.bootstrapKey = bootstrapKey.Bytes()
+	auths.servers = map[[32]byte]bool{}
+	auths.feds = map[[32]byte]bool{}
+	auths.keys = map[[32]byte][]byte{}
+
+	var prev *checkBlockSet
+	sigs := 0
+	for height := uint32(0); height <= top; height++ {
+		set, n, err := checkDBlock(dbo, height, prev, auths)
+		if err != nil {
+			keyMR := "unknown"
+			if set.dblock != nil {
+				keyMR = set.dblock.GetKeyMR().String()
+			}
+			return fmt.Errorf("DBlock %d (keyMR %s): %v", height, keyMR, err)
+		}
+		sigs += n
+		prev = set
+
+		if height%1000 == 0 && height > 0 {
+			fmt.Fprintf(out, "  checked %d blocks\n", height)
+		}
+	}
+	fmt.Fprintf(out, "Checked %d directory blocks and %d DBSigs, no inconsistencies found\n", top+1, sigs)
+	return nil
+}
+
+// checkBlockSet is a directory block and the admin, entry credit and factoid
+// blocks it points to.
+type checkBlockSet struct {
+	dblock  interfaces.IDirectoryBlock
+	ablock  interfaces.IAdminBlock
+	ecblock interfaces.IEntryCreditBlock
+	fblock  interfaces.IFBlock
+}
+
+// checkDBlock checks the directory block at height, and everything it points
+// to, against the previous block set.  It returns the block set and the number
+// of DBSigs checked.
+func checkDBlock(dbo interfaces.DBOverlay, height uint32, prev *checkBlockSet, auths *checkAuthorities) (*checkBlockSet, int, error) {
+	set := new(checkBlockSet)
+
+	keyMR, err := dbo.FetchDBKeyMRByHeight(height)
+	if err != nil {
+		return set, 0, err
+	}
+	if keyMR == nil {
+		return set, 0, fmt.Errorf("No keyMR indexed at this height")
+	}
+	set.dblock, err = dbo.FetchDBlock(keyMR)
+	if err != nil {
+		return set, 0, err
+	}
+	if set.dblock == nil {
+		return set, 0, fmt.Errorf("Indexed keyMR %v not found", keyMR)
+	}
+
+	computed, err := set.dblock.BuildKeyMerkleRoot()
+	if err != nil {
+		return set, 0, err
+	}
+	if !computed.IsSameAs(keyMR) {
+		return set, 0, fmt.Errorf("KeyMR is indexed as %v, but the block hashes to %v", keyMR, computed)
+	}
+	var pdblock interfaces.IDirectoryBlock
+	if prev != nil {
+		pdblock = prev.dblock
+	}
+	err = directoryBlock.CheckBlockPairIntegrity(set.dblock, pdblock)
+	if err != nil {
+		return set, 0, fmt.Errorf("Back-reference: %v", err)
+	}
+
+	for _, entry := range set.dblock.GetDBEntries() {
+		var block checkedBlock
+		name := "EBlock"
+		chainID := entry.GetChainID().Bytes()
+		switch {
+		case bytes.Equal(chainID, constants.ADMIN_CHAINID):
+			name = "ABlock"
+			set.ablock, err = dbo.FetchABlock(entry.GetKeyMR())
+			if set.ablock != nil {
+				block = set.ablock
+			}
+		case bytes.Equal(chainID, constants.EC_CHAINID):
+			name = "ECBlock"
+			set.ecblock, err = dbo.FetchECBlock(entry.GetKeyMR())
+			if set.ecblock != nil {
+				block = set.ecblock
+			}
+		case bytes.Equal(chainID, constants.FACTOID_CHAINID):
+			name = "FBlock"
+			set.fblock, err = dbo.FetchFBlock(entry.GetKeyMR())
+			if set.fblock != nil {
+				block = set.fblock
+			}
+		default:
+			var eblock interfaces.IEntryBlock
+			eblock, err = dbo.FetchEBlock(entry.GetKeyMR())
+			if eblock != nil {
+				block = eblock
+			}
+		}
+		if err != nil {
+			return set, 0, err
+		}
+		if err = checkChildBlock(name, entry, block, height); err != nil {
+			return set, 0, err
+		}
+	}
+	if set.ablock == nil || set.ecblock == nil || set.fblock == nil {
+		return set, 0, fmt.Errorf("Missing an admin, entry credit or factoid block entry")
+	}
+
+	if prev != nil {
+		if err = adminBlock.CheckBlockPairIntegrity(set.ablock, prev.ablock); err != nil {
+			return set, 0, fmt.Errorf("ABlock back-reference: %v", err)
+		}
+		if err = entryCreditBlock.CheckBlockPairIntegrity(set.ecblock, prev.ecblock); err != nil {
+			return set, 0, fmt.Errorf("ECBlock back-reference: %v", err)
+		}
+		if err = factoid.CheckBlockPairIntegrity(set.fblock, prev.fblock); err != nil {
+			return set, 0, fmt.Errorf("FBlock back-reference: %v", err)
+		}
+	}
+
+	sigs, err := auths.checkAdminBlock(set.ablock, pdblock)
+	if err != nil {
+		return set, 0, err
+	}
+	return set, sigs, nil
+}
+
+// checkChildBlock checks that a block pointed to by a directory block entry
+// exists, has the keyMR and chain of the entry, and belongs to this height.
+func checkChildBlock(name string, entry interfaces.IDBEntry, block checkedBlock, height uint32) error {
+	if block == nil {
+		return fmt.Errorf("%s %v not found", name, entry.GetKeyMR())
+	}
+	if !block.DatabasePrimaryIndex().IsSameAs(entry.GetKeyMR()) {
+		return fmt.Errorf("%s %v hashes to %v", name, entry.GetKeyMR(), block.DatabasePrimaryIndex())
+	}
+	if !block.GetChainID().IsSameAs(entry.GetChainID()) {
+		return fmt.Errorf("%s %v is in chain %v, expected %v", name, entry.GetKeyMR(), block.GetChainID(), entry.GetChainID())
+	}
+	if block.GetDatabaseHeight() != height {
+		return fmt.Errorf("%s %v is at height %d", name, entry.GetKeyMR(), block.GetDatabaseHeight())
+	}
+	return nil
+}
+
+// checkAdminBlock verifies the DBSigs of an admin block, which sign the header
+// of the previous directory block, and applies the authority changes it makes.
+// Servers added, and keys set, in the block may sign it.  Servers removed in
+// the block may still sign it.  A majority of the federated servers before the
+// block must sign it.  It returns the number of DBSigs checked.
+func (a *checkAuthorities) checkAdminBlock(ablock interfaces.IAdminBlock, prev interfaces.IDirectoryBlock) (int, error) {
+	feds := map[[32]byte]bool{}
+	for id := range a.feds {
+		feds[id] = true
+	}
+
+	var removed []interfaces.IHash
+	for _, e := range ablock.GetABEntries() {
+		switch e.Type() {
+		case constants.TYPE_ADD_FED_SERVER:
+			if r, ok := e.(*adminBlock.AddFederatedServer); ok {
+				a.servers[r.IdentityChainID.Fixed()] = true
+				a.feds[r.IdentityChainID.Fixed()] = true
+			}
+		case constants.TYPE_ADD_AUDIT_SERVER:
+			if r, ok := e.(*adminBlock.AddAuditServer); ok {
+				a.servers[r.IdentityChainID.Fixed()] = true
+				delete(a.feds, r.IdentityChainID.Fixed())
+			}
+		case constants.TYPE_ADD_FED_SERVER_KEY:
+			if r, ok := e.(*adminBlock.AddFederatedServerSigningKey); ok {
+				key, err := r.PublicKey.MarshalBinary()
+				if err == nil {
+					a.keys[r.IdentityChainID.Fixed()] = key
+				}
+			}
+		case constants.TYPE_REMOVE_FED_SERVER:
+			if r, ok := e.(*adminBlock.RemoveFederatedServer); ok {
+				removed = append(removed, r.IdentityChainID)
+			}
+		}
+	}
+
+	sigs := 0
+	signed := map[[32]byte]bool{}
+	if prev != nil {
+		header, err := prev.GetHeader().MarshalBinary()
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range ablock.GetABEntries() {
+			if e.Type() != constants.TYPE_DB_SIGNATURE {
+				continue
+			}
+			dbsig, ok := e.(*adminBlock.DBSignatureEntry)
+			if !ok {
+				continue
+			}
+			if !dbsig.PrevDBSig.Verify(header) {
+				return 0, fmt.Errorf("DBSig of %v does not sign the previous block's header", dbsig.IdentityAdminChainID)
+			}
+			if !a.canSign(dbsig.IdentityAdminChainID, dbsig.PrevDBSig.GetKey()) {
+				return 0, fmt.Errorf("DBSig of %v is signed with key %x, which is not an authority key at this height", dbsig.IdentityAdminChainID, dbsig.PrevDBSig.GetKey())
+			}
+			if feds[dbsig.IdentityAdminChainID.Fixed()] {
+				signed[dbsig.IdentityAdminChainID.Fixed()] = true
+			}
+			sigs++
+		}
+		if len(feds) > 0 && len(signed) <= len(feds)/2 {
+			return 0, fmt.Errorf("Only %d of the %d federated servers signed the previous block", len(signed), len(feds))
+		}
+	}
+
+	for _, id := range removed {
+		delete(a.servers, id.Fixed())
+		delete(a.feds, id.Fixed())
+	}
+	return sigs, nil
+}
+
+// canSign returns true if key is the bootstrap key, or the signing key of the
+// authority with the given identity.
+func (a *checkAuthorities) canSign(identity interfaces.IHash, key []byte) bool {
+	if bytes.Compare(key, a.bootstrapKey) == 0 {
+		return true
+	}
+	if !a.servers[identity.Fixed()] {
+		return false
+	}
+	return bytes.Compare(key, a.keys[identity.Fixed()]) == 0
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestCheckDatabase(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	out := new(bytes.Buffer)
+
+	err := CheckDatabase(dbo, primitives.NewZeroHash(), out)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+
+	dblock, err := dbo.FetchDBlockByHeight(5)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if dblock == nil {
+		t.Fatalf("DBlock 5 not found")
+	}
+	err = dbo.Delete(databaseOverlay.ENTRYCREDITBLOCK, dblock.GetDBEntries()[1].GetKeyMR().Bytes())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	err = CheckDatabase(dbo, primitives.NewZeroHash(), out)
+	if err == nil {
+		t.Fatalf("Missing ECBlock not found")
+	}
+	if !strings.Contains(err.Error(), "DBlock 5 ") || !strings.Contains(err.Error(), "ECBlock") {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func TestCheckDatabaseDBSigs(t *testing.T) {
+	feds := []*primitives.PrivateKey{primitives.RandomPrivateKey(), primitives.RandomPrivateKey(), primitives.RandomPrivateKey()}
+	everyone := func(height int) []int { return []int{0, 1, 2} }
+
+	dbo := createSignedTestDatabase(t, feds, everyone, nil)
+	if err := CheckDatabase(dbo, primitives.NewZeroHash(), new(bytes.Buffer)); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	// A key that isn't an authority key signs block 3, in the admin block of 4
+	outsider := primitives.RandomPrivateKey()
+	dbo = createSignedTestDatabase(t, feds, everyone, func(height int) *primitives.PrivateKey {
+		if height == 4 {
+			return outsider
+		}
+		return nil
+	})
+	err := CheckDatabase(dbo, primitives.NewZeroHash(), new(bytes.Buffer))
+	if err == nil {
+		t.Fatalf("A DBSig of a key outside the authority set was not found")
+	}
+	if !strings.Contains(err.Error(), "DBlock 4 ") || !strings.Contains(err.Error(), "not an authority key") {
+		t.Errorf("Invalid error %v", err)
+	}
+
+	// Only one of the three federated servers signs block 4, in the admin
+	// block of 5
+	dbo = createSignedTestDatabase(t, feds, func(height int) []int {
+		if height == 5 {
+			return []int{1}
+		}
+		return []int{0, 1, 2}
+	}, nil)
+	err = CheckDatabase(dbo, primitives.NewZeroHash(), new(bytes.Buffer))
+	if err == nil {
+		t.Fatalf("A block signed by too few federated servers was not found")
+	}
+	if !strings.Contains(err.Error(), "DBlock 5 ") || !strings.Contains(err.Error(), "Only 1 of the 3") {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+// createSignedTestDatabase saves a test blockchain whose block 1 adds the
+// federated servers of the keys, and whose later admin blocks carry the DBSigs
+// of the servers signers returns for the height.  An outsider key returned for
+// a height signs for the first server too.
+func createSignedTestDatabase(t *testing.T, keys []*primitives.PrivateKey, signers func(height int) []int, outsider func(height int) *primitives.PrivateKey) *databaseOverlay.Overlay {
+	dbo := testHelper.CreateEmptyTestDatabaseOverlay()
+	ids := make([]interfaces.IHash, len(keys))
+	for i := range keys {
+		ids[i] = primitives.Sha([]byte{byte(i), 0xfe})
+	}
+
+	var prev *testHelper.BlockSet
+	for height := 0; height < 8; height++ {
+		set := testHelper.CreateTestBlockSet(prev)
+		switch {
+		case height == 1:
+			for i, id := range ids {
+				set.ABlock.AddABEntry(adminBlock.NewAddFederatedServer(id, uint32(height)))
+				set.ABlock.AddABEntry(adminBlock.NewAddFederatedServerSigningKey(id, 0, *keys[i].Pub, uint32(height)))
+			}
+		case height > 1:
+			header, err := prev.DBlock.GetHeader().MarshalBinary()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			for _, i := range signers(height) {
+				sig, err := adminBlock.NewDBSignatureEntry(ids[i], keys[i].Sign(header))
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+				set.ABlock.AddABEntry(sig)
+			}
+			if outsider != nil && outsider(height) != nil {
+				sig, err := adminBlock.NewDBSignatureEntry(ids[0], outsider(height).Sign(header))
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+				set.ABlock.AddABEntry(sig)
+			}
+		}
+		// The directory block points to the admin block as it now is
+		set.DBlock.GetDBEntries()[0].(*directoryBlock.DBEntry).KeyMR = set.ABlock.DatabasePrimaryIndex()
+
+		dbo.StartMultiBatch()
+		if err := dbo.ProcessABlockMultiBatch(set.ABlock); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := dbo.ProcessEBlockMultiBatch(set.EBlock, true); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := dbo.ProcessEBlockMultiBatch(set.AnchorEBlock, true); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := dbo.ProcessECBlockMultiBatch(set.ECBlock, false); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := dbo.ProcessFBlockMultiBatch(set.FBlock); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := dbo.ProcessDBlockMultiBatch(set.DBlock); err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, entry := range set.Entries {
+			if err := dbo.InsertEntryMultiBatch(entry); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+		if err := dbo.ExecuteMultiBatch(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		prev = set
+	}
+	return dbo
+}
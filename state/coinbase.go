@@ -0,0 +1,106 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// coinbasePayout is an output of a coinbase transaction.
+type coinbasePayout struct {
+	adr    [32]byte
+	amount int64
+}
+
+// CoinbasePayouts holds the outputs of the coinbase transactions that have
+// yet to mature, by the height of their block.  A payout is credited to its
+// address with its block, but can't be spent until constants.COINBASE_MATURITY
+// blocks later, and until then may be struck by a CoinbaseCancel in the admin
+// block.  The zero value is ready to use.
+type CoinbasePayouts struct {
+	mutex   sync.Mutex
+	payouts map[uint32][]*coinbasePayout // Struck payouts are nil
+}
+
+// matured is true if a payout of the block at the given height can be spent
+// in the block at the other.
+func matured(payoutHeight uint32, height uint32) bool {
+	return height >= payoutHeight+constants.COINBASE_MATURITY
+}
+
+// Add holds the outputs of the coinbase of the block at the given height.
+func (c *CoinbasePayouts) Add(height uint32, coinbase interfaces.ITransaction) {
+	outputs := coinbase.GetOutputs()
+	if len(outputs) == 0 {
+		return
+	}
+	payouts := make([]*coinbasePayout, len(outputs))
+	for i, output := range outputs {
+		payouts[i] = &coinbasePayout{adr: output.GetAddress().Fixed(), amount: int64(output.GetAmount())}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.payouts == nil {
+		c.payouts = make(map[uint32][]*coinbasePayout)
+	}
+	c.payouts[height] = payouts
+}
+
+// Reset drops all the payouts held.
+func (c *CoinbasePayouts) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.payouts = nil
+}
+
+// Mature drops the payouts that can be spent in the block at the given
+// height.  They can no longer be struck.
+func (c *CoinbasePayouts) Mature(height uint32) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for h := range c.payouts {
+		if matured(h, height) {
+			delete(c.payouts, h)
+		}
+	}
+}
+
+// Immature returns how much of the balance of an address is payouts that
+// can't be spent in the block at the given height.
+func (c *CoinbasePayouts) Immature(adr [32]byte, height uint32) int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var sum int64
+	for h, payouts := range c.payouts {
+		if matured(h, height) {
+			continue
+		}
+		for _, p := range payouts {
+			if p != nil && p.adr == adr {
+				sum += p.amount
+			}
+		}
+	}
+	return sum
+}
+
+// Cancel strikes the payout at the index of the coinbase of the block at the
+// given height, and returns its address and amount.  ok is false if no such
+// payout is held, as it has matured or was already struck.
+func (c *CoinbasePayouts) Cancel(height uint32, index uint32) (adr [32]byte, amount int64, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	payouts := c.payouts[height]
+	if int(index) >= len(payouts) || payouts[index] == nil {
+		return adr, 0, false
+	}
+	p := payouts[index]
+	payouts[index] = nil
+	return p.adr, p.amount, true
+}
@@ -0,0 +1,73 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/factoid"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestCoinbasePayouts(t *testing.T) {
+	adr := testHelper.NewFactoidAddress(0).Fixed()
+	coinbase := new(factoid.Transaction)
+	coinbase.AddOutput(testHelper.NewFactoidAddress(0), 100)
+	coinbase.AddOutput(testHelper.NewFactoidAddress(1), 200)
+	coinbase.AddOutput(testHelper.NewFactoidAddress(0), 300)
+
+	payouts := new(CoinbasePayouts)
+	payouts.Add(10, coinbase)
+	if v := payouts.Immature(adr, 11); v != 400 {
+		t.Errorf("Expected 400 immature, got %d", v)
+	}
+	if v := payouts.Immature(adr, 10+constants.COINBASE_MATURITY); v != 0 {
+		t.Errorf("Expected the payouts to mature, got %d immature", v)
+	}
+
+	cancelled, amount, ok := payouts.Cancel(10, 2)
+	if !ok || cancelled != adr || amount != 300 {
+		t.Errorf("Expected payout 2 to be struck, got %v %d", ok, amount)
+	}
+	if _, _, ok := payouts.Cancel(10, 2); ok {
+		t.Errorf("Struck a payout twice")
+	}
+	if _, _, ok := payouts.Cancel(10, 3); ok {
+		t.Errorf("Struck a payout that doesn't exist")
+	}
+	if v := payouts.Immature(adr, 11); v != 100 {
+		t.Errorf("Expected 100 immature, got %d", v)
+	}
+
+	payouts.Mature(10 + constants.COINBASE_MATURITY)
+	if _, _, ok := payouts.Cancel(10, 0); ok {
+		t.Errorf("Struck a payout that has matured")
+	}
+}
+
+func TestRebuildPayouts(t *testing.T) {
+	defer constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+
+	s := testHelper.CreateAndPopulateTestState()
+	fs := s.FactoidState.(*FactoidState)
+	adr := testHelper.NewFactoidAddress(0).Fixed()
+	top := uint32(testHelper.BlockCount - 1)
+
+	if err := fs.RebuildPayouts(top); err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := int64(testHelper.BlockCount) * int64(testHelper.DefaultCoinbaseAmount)
+	if v := fs.Payouts.Immature(adr, top+1); v != expected {
+		t.Errorf("Expected %d immature, got %d", expected, v)
+	}
+
+	fs.DBHeight = top + 1
+	balance := s.GetF(true, adr)
+	if v := fs.GetSpendableFactoidBalance(adr); v != balance-expected {
+		t.Errorf("Expected %d spendable, got %d", balance-expected, v)
+	}
+	constants.SetActivationNetwork(constants.NETWORK_MAIN)
+	if v := fs.GetSpendableFactoidBalance(adr); v != balance {
+		t.Errorf("Expected the whole balance spendable before the activation, got %d", v)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+)
+
+// Commits wait in a CommitBucket for their reveals.  A commit never revealed
+// can't be used once it is older than the window the replay filter protects,
+// so the bucket expires those, in the background, rather than holding them
+// forever.  The commits are kept in the database as well, so a node that
+// restarts still holds the commits paid for before it went down, and the
+// expired ones are pruned from there too.
+
+var (
+	CommitTTL            = Range * time.Minute // How long a commit may wait for its reveal
+	CommitExpiryInterval = time.Minute         // How often the bucket is expired and saved
+)
+
+type CommitBucket struct {
+	mutex   sync.Mutex
+	commits map[[32]byte]interfaces.IMsg
+	changed map[[32]byte]bool // Entry hashes changed since the last Flush
+}
+
+func NewCommitBucket() *CommitBucket {
+	b := new(CommitBucket)
+	b.commits = make(map[[32]byte]interfaces.IMsg)
+	b.changed = make(map[[32]byte]bool)
+	return b
+}
+
+// Get returns the commit for the entry hash, or nil.
+func (b *CommitBucket) Get(hash [32]byte) interfaces.IMsg {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.commits[hash]
+}
+
+func (b *CommitBucket) Put(hash [32]byte, msg interfaces.IMsg) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.commits[hash] = msg
+	b.changed[hash] = true
+}
+
+func (b *CommitBucket) Delete(hash [32]byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.commits[hash]; ok {
+		delete(b.commits, hash)
+		b.changed[hash] = true
+	}
+}
+
+func (b *CommitBucket) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.commits)
+}
+
+// Copy returns the commits by entry hash, in a map of their own.
+func (b *CommitBucket) Copy() map[[32]byte]interfaces.IMsg {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	commits := make(map[[32]byte]interfaces.IMsg, len(b.commits))
+	for k, v := range b.commits {
+		commits[k] = v
+	}
+	return commits
+}
+
+// Expire deletes the commits older than CommitTTL at the time, and returns
+// how many it deleted.
+func (b *CommitBucket) Expire(now interfaces.Timestamp) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	expired := 0
+	for k, msg := range b.commits {
+		if msg == nil || commitExpired(msg, now) {
+			delete(b.commits, k)
+			b.changed[k] = true
+			expired++
+		}
+	}
+	return expired
+}
+
+func commitExpired(msg interfaces.IMsg, now interfaces.Timestamp) bool {
+	t := msg.GetTimestamp()
+	if t == nil {
+		return true
+	}
+	return now.GetTimeMilli()-t.GetTimeMilli() > int64(CommitTTL/time.Millisecond)
+}
+
+// Flush writes the commits changed since the last Flush to the database,
+// deleting those no longer in the bucket.
+func (b *CommitBucket) Flush(db interfaces.DBOverlaySimple) error {
+	b.mutex.Lock()
+	changed := b.changed
+	b.changed = make(map[[32]byte]bool)
+	commits := make(map[[32]byte][]byte, len(changed))
+	for k := range changed {
+		commits[k] = nil
+		if msg := b.commits[k]; msg != nil {
+			data, err := msg.MarshalBinary()
+			if err == nil {
+				commits[k] = data
+			}
+		}
+	}
+	b.mutex.Unlock()
+
+	if err := db.SavePendingCommits(commits); err != nil {
+		// Try them again next time
+		b.mutex.Lock()
+		for k := range changed {
+			b.changed[k] = true
+		}
+		b.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Load adds the commits saved in the database to the bucket.  Those expired
+// at the time are left out, and deleted at the next Flush.
+func (b *CommitBucket) Load(db interfaces.DBOverlaySimple, now interfaces.Timestamp) error {
+	saved, err := db.FetchPendingCommits()
+	if err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for k, data := range saved {
+		msg, err := messages.UnmarshalMessage(data)
+		if err != nil || commitExpired(msg, now) {
+			b.changed[k] = true
+			continue
+		}
+		if _, ok := b.commits[k]; !ok {
+			b.commits[k] = msg
+		}
+	}
+	return nil
+}
+
+// expireCommits expires the commits, and saves those changed, every
+// CommitExpiryInterval.
+func (s *State) expireCommits() {
+	for {
+		s.GetClock().Sleep(CommitExpiryInterval)
+		if n := s.Commits.Expire(s.GetTimestamp()); n > 0 {
+			s.Logger.Infof("Expired %d commits never revealed", n)
+		}
+		if err := s.Commits.Flush(s.DB); err != nil {
+			s.Logger.Warningf("Could not save the pending commits: %v", err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	. "github.com/FactomProject/factomd/state"
+)
+
+func TestCommitBucketExpire(t *testing.T) {
+	dbo := databaseOverlay.NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	now := primitives.NewTimestampNow()
+	old := newCommitAt(1, now.GetTimeMilli()-int64(CommitTTL/time.Millisecond)-1000)
+	fresh := newCommitAt(2, now.GetTimeMilli()-1000)
+
+	b := NewCommitBucket()
+	b.Put(old.CommitEntry.EntryHash.Fixed(), old)
+	b.Put(fresh.CommitEntry.EntryHash.Fixed(), fresh)
+	if err := b.Flush(dbo); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := dbo.FetchPendingCommits(); len(saved) != 2 {
+		t.Errorf("Expected 2 commits saved, got %d", len(saved))
+	}
+
+	if n := b.Expire(now); n != 1 {
+		t.Errorf("Expected 1 commit expired, got %d", n)
+	}
+	if b.Get(old.CommitEntry.EntryHash.Fixed()) != nil || b.Get(fresh.CommitEntry.EntryHash.Fixed()) == nil {
+		t.Error("The wrong commit expired")
+	}
+	if err := b.Flush(dbo); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := dbo.FetchPendingCommits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[fresh.CommitEntry.EntryHash.Fixed()] == nil {
+		t.Errorf("Expected only the fresh commit saved, got %d", len(saved))
+	}
+
+	// A node restarting gets back the commit still waiting
+	b2 := NewCommitBucket()
+	if err := b2.Load(dbo, now); err != nil {
+		t.Fatal(err)
+	}
+	if b2.Len() != 1 || b2.Get(fresh.CommitEntry.EntryHash.Fixed()) == nil {
+		t.Error("Did not load the fresh commit")
+	}
+
+	// And expires it once it is too old
+	b3 := NewCommitBucket()
+	later := primitives.NewTimestampFromMilliseconds(uint64(now.GetTimeMilli() + int64(CommitTTL/time.Millisecond)))
+	if err := b3.Load(dbo, later); err != nil {
+		t.Fatal(err)
+	}
+	if b3.Len() != 0 {
+		t.Error("Loaded an expired commit")
+	}
+	if err := b3.Flush(dbo); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := dbo.FetchPendingCommits(); len(saved) != 0 {
+		t.Errorf("Expected the expired commit deleted, got %d saved", len(saved))
+	}
+}
+
+func newCommitAt(seed byte, milli int64) *messages.CommitEntryMsg {
+	ce := entryCreditBlock.NewCommitEntry()
+	var t [8]byte
+	binary.BigEndian.PutUint64(t[:], uint64(milli))
+	ce.MilliTime = new(primitives.ByteSlice6)
+	copy(ce.MilliTime[:], t[2:])
+	ce.EntryHash = primitives.Sha([]byte{seed})
+	ce.Credits = 1
+
+	m := new(messages.CommitEntryMsg)
+	m.CommitEntry = ce
+	return m
+}
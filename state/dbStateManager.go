@@ -20,8 +20,9 @@ import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
-	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/events"
 	"github.com/FactomProject/factomd/log"
+	"github.com/FactomProject/factomd/wsapi"
 )
 
 var _ = hex.EncodeToString
@@ -53,6 +54,10 @@ type DBState struct {
 	Signed      bool
 	Saved       bool
 
+	// Not marshalled, as CompleteDBState works them out again
+	Complete    bool  // Every piece of the block is present and matches the directory block
+	AskedPieces int64 // When the missing entry blocks were last asked for, in seconds
+
 	Added interfaces.Timestamp
 
 	FinalExchangeRate uint64
@@ -764,7 +769,7 @@ func (ds *DBState) String() string {
 	} else if ds.DirectoryBlock == nil {
 		str = "  Directory Block = <nil>\n"
 	} else {
-		str = fmt.Sprintf("%s      State: IsNew %5v ReadyToSave %5v Locked %5v Signed %5v Saved %5v\n", str, ds.IsNew, ds.ReadyToSave, ds.Locked, ds.Signed, ds.Saved)
+		str = fmt.Sprintf("%s      State: IsNew %5v ReadyToSave %5v Locked %5v Signed %5v Complete %5v Saved %5v\n", str, ds.IsNew, ds.ReadyToSave, ds.Locked, ds.Signed, ds.Complete, ds.Saved)
 		str = fmt.Sprintf("%s      DBlk Height   = %v \n", str, ds.DirectoryBlock.GetHeader().GetDBHeight())
 		str = fmt.Sprintf("%s      DBlock        = %x \n", str, ds.DirectoryBlock.GetHash().Bytes()[:5])
 		str = fmt.Sprintf("%s      ABlock        = %x \n", str, ds.AdminBlock.GetHash().Bytes()[:5])
@@ -1069,8 +1074,15 @@ func (list *DBStateList) ProcessBlocks(d *DBState) (progress bool) {
 	}
 	// Process the Factoid End of Block
 	fs := list.State.GetFactoidState()
+	var supply int64
+	if list.State.CheckInvariants {
+		supply = list.State.factoidSupply()
+	}
 	fs.AddTransactionBlock(d.FactoidBlock)
 	fs.AddECBlock(d.EntryCreditBlock)
+	if list.State.CheckInvariants {
+		list.State.checkBalanceInvariants(d, supply)
+	}
 
 	list.State.Balancehash = fs.GetBalanceHash(false)
 
@@ -1111,23 +1123,23 @@ func (list *DBStateList) ProcessBlocks(d *DBState) (progress bool) {
 	s := list.State
 	// Time out commits every now and again.
 	now := s.GetTimestamp()
-	for k, msg := range s.Commits {
+	for k, msg := range s.Commits.Copy() {
 		{
 			c, ok := msg.(*messages.CommitChainMsg)
 			if ok && !s.NoEntryYet(c.CommitChain.EntryHash, now) {
-				delete(s.Commits, k)
+				s.Commits.Delete(k)
 				continue
 			}
 		}
 		c, ok := msg.(*messages.CommitEntryMsg)
 		if ok && !s.NoEntryYet(c.CommitEntry.EntryHash, now) {
-			delete(s.Commits, k)
+			s.Commits.Delete(k)
 			continue
 		}
 
 		_, ok = s.Replay.Valid(constants.TIME_TEST, msg.GetRepeatHash().Fixed(), msg.GetTimestamp(), now)
 		if !ok {
-			delete(s.Commits, k)
+			s.Commits.Delete(k)
 		}
 	}
 
@@ -1166,6 +1178,157 @@ func (list *DBStateList) SignDB(d *DBState) (process bool) {
 	return true
 }
 
+// CompleteDBState marks a processed and signed block complete once every
+// piece of it is present and matches its directory block: the admin, factoid
+// and entry credit blocks, and every entry block it lists, from the dbstate,
+// the process list or the database.  Entry blocks that are missing are asked
+// for every few seconds.  Entry blocks and entries that aren't of the block
+// are dropped, the entries once the entry blocks are all here.  Entries that
+// are missing are left to the entry sync, as our peers may not have them
+// either.
+func (list *DBStateList) CompleteDBState(d *DBState) (progress bool) {
+	if d.Complete || !d.Signed || list.State.DB == nil {
+		return
+	}
+	// A repeat has been saved already
+	if d.Repeat {
+		d.Complete = true
+		return true
+	}
+	if !d.Locked {
+		return
+	}
+
+	dbheight := d.DirectoryBlock.GetHeader().GetDBHeight()
+	missing, err := list.checkDBStatePieces(d, list.State.ProcessLists.Get(dbheight))
+	if err != nil {
+		list.State.AddStatus(fmt.Sprintf("COMPLETEDBSTATE: The block at %d is not complete: %v", dbheight, err))
+		return
+	}
+	if len(missing) > 0 {
+		now := list.State.GetTimestamp().GetTimeSeconds()
+		if now-d.AskedPieces >= 5 {
+			d.AskedPieces = now
+			for _, keyMR := range missing {
+				list.State.askForEntryBlock(keyMR, dbheight)
+			}
+		}
+		return
+	}
+
+	d.Complete = true
+	return true
+}
+
+// checkDBStatePieces returns the entry blocks the directory block of the
+// dbstate lists that we don't have, and an error if one of its other blocks
+// doesn't match it.
+func (list *DBStateList) checkDBStatePieces(d *DBState, pl *ProcessList) ([]interfaces.IHash, error) {
+	if d.DirectoryBlock == nil || d.AdminBlock == nil || d.FactoidBlock == nil || d.EntryCreditBlock == nil {
+		return nil, fmt.Errorf("a block is missing")
+	}
+
+	have := map[[32]byte]interfaces.IEntryBlock{}
+	for _, eb := range d.EntryBlocks {
+		if keyMR, err := eb.KeyMR(); err == nil {
+			have[keyMR.Fixed()] = eb
+		}
+	}
+	if pl != nil {
+		for _, eb := range pl.NewEBlocks {
+			if keyMR, err := eb.KeyMR(); err == nil {
+				have[keyMR.Fixed()] = eb
+			}
+		}
+	}
+
+	var missing []interfaces.IHash
+	listed := map[[32]byte]bool{}
+	found := map[string]bool{}
+	for _, e := range d.DirectoryBlock.GetDBEntries() {
+		var block interfaces.DatabaseBatchable
+		chainID := e.GetChainID().Bytes()
+		switch {
+		case bytes.Equal(chainID, constants.ADMIN_CHAINID):
+			block = d.AdminBlock
+		case bytes.Equal(chainID, constants.FACTOID_CHAINID):
+			block = d.FactoidBlock
+		case bytes.Equal(chainID, constants.EC_CHAINID):
+			block = d.EntryCreditBlock
+		default:
+			listed[e.GetKeyMR().Fixed()] = true
+			if _, ok := have[e.GetKeyMR().Fixed()]; ok {
+				continue
+			}
+			eb, err := list.State.DB.FetchEBlock(e.GetKeyMR())
+			if err != nil {
+				return nil, err
+			}
+			if eb == nil {
+				missing = append(missing, e.GetKeyMR())
+			}
+			continue
+		}
+		if !block.DatabasePrimaryIndex().IsSameAs(e.GetKeyMR()) {
+			return nil, fmt.Errorf("the directory block lists %s for chain %x, not %s",
+				e.GetKeyMR().String(), chainID, block.DatabasePrimaryIndex().String())
+		}
+		found[string(chainID)] = true
+	}
+	if len(found) != 3 {
+		return nil, fmt.Errorf("the directory block lists %d of the admin, factoid and entry credit blocks", len(found))
+	}
+
+	ebs := []interfaces.IEntryBlock{}
+	hashes := map[[32]byte]bool{}
+	for _, eb := range d.EntryBlocks {
+		keyMR, err := eb.KeyMR()
+		if err != nil || !listed[keyMR.Fixed()] {
+			continue
+		}
+		ebs = append(ebs, eb)
+		for _, h := range eb.GetEntryHashes() {
+			hashes[h.Fixed()] = true
+		}
+	}
+	d.EntryBlocks = ebs
+
+	// The entries of the missing entry blocks are only known once they come
+	if len(missing) > 0 {
+		return missing, nil
+	}
+	entries := []interfaces.IEBEntry{}
+	for _, entry := range d.Entries {
+		if entry != nil && hashes[entry.GetHash().Fixed()] {
+			entries = append(entries, entry)
+		}
+	}
+	d.Entries = entries
+
+	return missing, nil
+}
+
+// AddEntryBlock adds an entry block we asked for to the dbstate at its height,
+// if that dbstate is not yet saved and lists it.  It returns true if the block
+// was added.
+func (list *DBStateList) AddEntryBlock(eblock interfaces.IEntryBlock) bool {
+	keyMR, err := eblock.KeyMR()
+	if err != nil {
+		return false
+	}
+	d := list.Get(int(eblock.GetHeader().GetDBHeight()))
+	if d == nil || d.Saved || d.DirectoryBlock == nil {
+		return false
+	}
+	for _, e := range d.DirectoryBlock.GetDBEntries() {
+		if e.GetKeyMR().IsSameAs(keyMR) {
+			d.EntryBlocks = append(d.EntryBlocks, eblock)
+			return true
+		}
+	}
+	return false
+}
+
 var nowish int64 = time.Now().Unix()
 
 func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
@@ -1173,7 +1336,12 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 	// Take the height, and some function of the identity chain, and use that to decide to trim.  That
 	// way, not all nodes in a simulation Trim() at the same time.
 
-	if !d.Signed || !d.ReadyToSave || list.State.DB == nil {
+	if !d.Signed || !d.ReadyToSave || !d.Complete || list.State.DB == nil {
+		return
+	}
+
+	// Saving halts on a fork, until the operator decides which side to take
+	if list.State.GetForkStatus() != nil {
 		return
 	}
 
@@ -1193,7 +1361,7 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 	}
 
 	if d.Saved {
-		Havedblk, err := list.State.DB.DoesKeyExist(databaseOverlay.DIRECTORYBLOCK, d.DirectoryBlock.GetKeyMR().Bytes())
+		Havedblk, err := list.State.DB.DoesDBlockExist(d.DirectoryBlock.GetKeyMR())
 		if err != nil || !Havedblk {
 			panic(fmt.Sprintf("Claimed to be found on %s DBHeight %d Hash %x",
 				list.State.FactomNodeName,
@@ -1209,8 +1377,24 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 		list.State.DB.Trim()
 	}
 
-	// Save
+	// Save.  Everything goes into one batch, written atomically at the end.  If
+	// anything panics before then, the batch is dropped, so none of the block
+	// is saved and the database isn't left locked.
 	list.State.DB.StartMultiBatch()
+	executing := false
+	defer func() {
+		if !executing {
+			list.State.DB.CancelMultiBatch()
+		}
+		if r := recover(); r != nil {
+			if _, ok := r.(invariantViolation); !ok {
+				list.State.Alert(&events.Event{Type: events.DBWriteFailure, Height: uint32(dbheight)},
+					"Saving the block at height %d failed: %v", dbheight, r)
+				list.State.Events.Flush(AlertFlushTimeout)
+			}
+			panic(r)
+		}
+	}()
 
 	if err := list.State.DB.ProcessABlockMultiBatch(d.AdminBlock); err != nil {
 		panic(err.Error())
@@ -1224,6 +1408,10 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 		panic(err.Error())
 	}
 
+	if err := list.State.DB.SaveStateDiffMultiBatch(d.AdminBlock, d.FactoidBlock, d.EntryCreditBlock); err != nil {
+		panic(err.Error())
+	}
+
 	pl := list.State.ProcessLists.Get(uint32(dbheight))
 
 	if len(d.EntryBlocks) > 0 {
@@ -1231,6 +1419,10 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 			if err := list.State.DB.ProcessEBlockMultiBatch(eb, true); err != nil {
 				panic(err.Error())
 			}
+			// The statistics are only reported, so a block is saved without them
+			if err := list.State.DB.SaveChainStatsMultiBatch(eb, d.Entries); err != nil {
+				list.State.Logger.Warningf("Could not count entry block %x in the chain statistics: %v", eb.DatabasePrimaryIndex().Bytes(), err)
+			}
 		}
 		for _, e := range d.Entries {
 			if err := list.State.DB.InsertEntryMultiBatch(e); err != nil {
@@ -1239,16 +1431,26 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 		}
 	}
 
+	var plEBlocks map[[32]byte]interfaces.IEntryBlock
 	if pl != nil {
+		plEBlocks = pl.NewEBlocks
 		for _, eb := range pl.NewEBlocks {
 			if err := list.State.DB.ProcessEBlockMultiBatch(eb, true); err != nil {
 				panic(err.Error())
 			}
 
+			entries := []interfaces.IEBEntry{}
 			for _, e := range eb.GetBody().GetEBEntries() {
-				if err := list.State.DB.InsertEntryMultiBatch(pl.GetNewEntry(e.Fixed())); err != nil {
+				entry := pl.GetNewEntry(e.Fixed())
+				if err := list.State.DB.InsertEntryMultiBatch(entry); err != nil {
 					panic(err.Error())
 				}
+				if entry != nil {
+					entries = append(entries, entry)
+				}
+			}
+			if err := list.State.DB.SaveChainStatsMultiBatch(eb, entries); err != nil {
+				list.State.Logger.Warningf("Could not count entry block %x in the chain statistics: %v", eb.DatabasePrimaryIndex().Bytes(), err)
 			}
 		}
 		pl.NewEBlocks = make(map[[32]byte]interfaces.IEntryBlock)
@@ -1262,9 +1464,30 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 		panic(err.Error())
 	}
 
+	executing = true
 	if err := list.State.DB.ExecuteMultiBatch(); err != nil {
 		panic(err.Error())
 	}
+	if err := list.State.updateBulkLoad(uint32(dbheight)); err != nil {
+		panic(err.Error())
+	}
+	if fs, ok := list.State.FactoidState.(*FactoidState); ok {
+		fs.Recent.AddBlock(d.FactoidBlock)
+	}
+	if list.State.CheckInvariants {
+		list.State.checkSavedInvariants(d, pl, plEBlocks)
+	}
+	wsapi.PublishDBState(list.State, d.DirectoryBlock, d.AdminBlock, d.FactoidBlock)
+	list.State.emitDBStateEvents(d.DirectoryBlock)
+	list.State.recordLiveness(d, pl)
+
+	timestamp := d.DirectoryBlock.GetHeader().GetTimestamp().GetTimeSeconds()
+	LastBlockTimestamp.Set(float64(timestamp))
+	if dbheight > 0 {
+		if dp := list.State.GetDBState(uint32(dbheight - 1)); dp != nil {
+			BlockTime.Observe(float64(timestamp - dp.DirectoryBlock.GetHeader().GetTimestamp().GetTimeSeconds()))
+		}
+	}
 
 	// Not activated.  Set to true if you want extra checking of the data saved to the database.
 	if false {
@@ -1302,6 +1525,10 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 	}
 
 	list.SavedHeight = uint32(dbheight)
+	if dbheight%CheckpointInterval == 0 && d.SaveStruct != nil {
+		list.State.checkpointState = d
+	}
+	list.State.traceBlockSaved(uint32(dbheight))
 	progress = true
 	d.ReadyToSave = false
 	d.Saved = true
@@ -1326,6 +1553,7 @@ func (list *DBStateList) UpdateState() (progress bool) {
 
 		progress = list.ProcessBlocks(d) || progress
 		progress = list.SignDB(d) || progress
+		progress = list.CompleteDBState(d) || progress
 		progress = list.SaveDBStateToDB(d) || progress
 
 		// Make sure we move forward the Adminblock state in the process lists
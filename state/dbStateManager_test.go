@@ -114,6 +114,48 @@ func TestSaveDBState(t *testing.T) {
 	os.RemoveAll("unit-test-db/")
 }
 
+func TestCompleteDBState(t *testing.T) {
+	s := testHelper.CreateEmptyTestState()
+	set := testHelper.CreateTestBlockSet(nil)
+	other := testHelper.CreateTestBlockSet(set)
+
+	d := new(DBState)
+	d.DirectoryBlock = set.DBlock
+	d.AdminBlock = set.ABlock
+	d.FactoidBlock = set.FBlock
+	d.EntryCreditBlock = set.ECBlock
+	d.EntryBlocks = []interfaces.IEntryBlock{set.EBlock, other.EBlock}
+	for _, e := range append(set.Entries, other.Entries...) {
+		d.Entries = append(d.Entries, e)
+	}
+	d.Signed = true
+	d.Locked = true
+
+	// The anchor entry block is missing
+	if s.DBStates.CompleteDBState(d) || d.Complete {
+		t.Errorf("A block missing an entry block was marked complete")
+	}
+	if len(d.EntryBlocks) != 1 {
+		t.Errorf("Kept %d entry blocks, not just the one of the block", len(d.EntryBlocks))
+	}
+
+	d.EntryBlocks = append(d.EntryBlocks, set.AnchorEBlock)
+	if !s.DBStates.CompleteDBState(d) || !d.Complete {
+		t.Errorf("A block with every piece was not marked complete")
+	}
+	if len(d.Entries) != len(set.Entries) {
+		t.Errorf("Kept %d entries, expected the %d of the block", len(d.Entries), len(set.Entries))
+	}
+
+	// A factoid block the directory block doesn't list
+	d2 := *d
+	d2.Complete = false
+	d2.FactoidBlock = other.FBlock
+	if s.DBStates.CompleteDBState(&d2) || d2.Complete {
+		t.Errorf("A block with the wrong factoid block was marked complete")
+	}
+}
+
 // Will verify a directory blc
 func verifyBlocks(s *State, dbstates []interfaces.IMsg) []string {
 	errs := make([]string, 0)
@@ -303,7 +345,13 @@ func createTestDBStateList(blockCount int, s *State) ([]interfaces.IMsg, []inter
 
 		prev.DBlock.SetDBEntries(ents)
 
-		answer[i] = messages.NewDBStateMsg(timestamp, prev.DBlock, prev.ABlock, prev.FBlock, prev.ECBlock, nil, nil, nil)
+		// A block is only saved with every entry block it lists
+		eblocks := []interfaces.IEntryBlock{prev.EBlock, prev.AnchorEBlock}
+		entries := []interfaces.IEBEntry{}
+		for _, e := range prev.Entries {
+			entries = append(entries, e)
+		}
+		answer[i] = messages.NewDBStateMsg(timestamp, prev.DBlock, prev.ABlock, prev.FBlock, prev.ECBlock, eblocks, entries, nil)
 		answer[i].(*messages.DBStateMsg).IgnoreSigs = true
 	}
 	return answer, adds
@@ -6,6 +6,13 @@ import (
 
 // Once a second at most, we check to see if we need to pull down some blocks to catch up.
 func (list *DBStateList) Catchup(justDoIt bool) {
+	// A new node starting at a trusted checkpoint has no blocks to catch
+	// up from until it has the snapshot of the checkpoint.
+	if list.State.AwaitingSnapshot {
+		list.State.askForSnapshot()
+		return
+	}
+
 	// We only check if we need updates once every so often.
 
 	now := list.State.GetTimestamp()
@@ -12,14 +12,13 @@ import (
 	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
-	"github.com/FactomProject/factomd/database/databaseOverlay"
 )
 
 func has(s *State, entry interfaces.IHash) bool {
 	if s.GetHighestKnownBlock()-s.GetHighestSavedBlk() > 100 {
 		time.Sleep(30 * time.Millisecond)
 	}
-	exists, _ := s.DB.DoesKeyExist(databaseOverlay.ENTRY, entry.Bytes())
+	exists, _ := s.DB.DoesEntryExist(entry)
 	return exists
 }
 
@@ -36,7 +35,7 @@ func (s *State) MakeMissingEntryRequests() {
 
 	for {
 
-		now := time.Now()
+		now := s.GetClock().Now()
 
 		newrequest := 0
 
@@ -152,7 +151,8 @@ func (s *State) GoSyncEntries() {
 	missingMap := make(map[[32]byte]interfaces.IHash)
 
 	// Once I have found all the entries, we quit searching so much for missing entries.
-	start := uint32(1)
+	// A database started at a checkpoint has no blocks below it to scan.
+	start := s.DatabaseBase + 1
 	entryMissing := 0
 
 	// If I find no missing entries, then the firstMissing will be -1
@@ -192,10 +192,16 @@ func (s *State) GoSyncEntries() {
 				}
 			}
 
+			s.EntryBlockDBHeightProcessing = scan
 			db := s.GetDirectoryBlockByHeight(scan)
 
 			// Wait for the database if we have to
 			for db == nil {
+				// The node started at a checkpoint while we scanned
+				if scan <= s.DatabaseBase {
+					scan = s.DatabaseBase
+					continue dirblkSearch
+				}
 				time.Sleep(1 * time.Second)
 				db = s.GetDirectoryBlockByHeight(scan)
 			}
@@ -207,9 +213,14 @@ func (s *State) GoSyncEntries() {
 
 				eBlock, _ := s.DB.FetchEBlock(ebKeyMR)
 
-				// Dont have an eBlock?  Huh. We can go on, but we can't advance.  We just wait until it
-				// does show up.
+				// Dont have an eBlock?  Huh. We can go on, but we can't advance.  We ask our peers for it,
+				// and wait until it does show up.
+				var asked time.Time
 				for eBlock == nil {
+					if now := s.GetClock().Now(); now.Sub(asked) > 5*time.Second {
+						asked = now
+						s.askForEntryBlock(ebKeyMR, scan)
+					}
 					time.Sleep(1 * time.Second)
 					eBlock, _ = s.DB.FetchEBlock(ebKeyMR)
 				}
@@ -221,7 +232,7 @@ func (s *State) GoSyncEntries() {
 					}
 
 					// Only update the replay hashes in the last 24 hours.
-					if time.Now().Unix()-db.GetTimestamp().GetTimeSeconds() < 24*60*60 {
+					if s.GetClock().Now().Unix()-db.GetTimestamp().GetTimeSeconds() < 24*60*60 {
 						ueh := new(EntryUpdate)
 						ueh.Hash = entryhash
 						ueh.Timestamp = db.GetTimestamp()
@@ -259,6 +270,11 @@ func (s *State) GoSyncEntries() {
 					}
 				}
 			}
+
+			// All the entry blocks up to here are in the database, if not all their entries
+			if scan > s.EntryBlockDBHeightComplete {
+				s.EntryBlockDBHeightComplete = scan
+			}
 		}
 		lastfirstmissing = firstMissing
 		if firstMissing < 0 {
@@ -270,3 +286,32 @@ func (s *State) GoSyncEntries() {
 
 	}
 }
+
+// GetMissingEntryBlocks returns a copy of the entry blocks asked for and not
+// yet received.
+func (s *State) GetMissingEntryBlocks() []MissingEntryBlock {
+	s.MissingEntryBlocksMutex.Lock()
+	defer s.MissingEntryBlocksMutex.Unlock()
+	return append([]MissingEntryBlock{}, s.MissingEntryBlocks...)
+}
+
+// askForEntryBlock asks our peers for an entry block of a saved directory
+// block that isn't in the database.  The block is saved when it comes, if it
+// is still in MissingEntryBlocks.
+func (s *State) askForEntryBlock(ebKeyMR interfaces.IHash, dbheight uint32) {
+	s.MissingEntryBlocksMutex.Lock()
+	listed := false
+	for _, missing := range s.MissingEntryBlocks {
+		if missing.EBHash.IsSameAs(ebKeyMR) {
+			listed = true
+			break
+		}
+	}
+	if !listed {
+		s.MissingEntryBlocks = append(s.MissingEntryBlocks, MissingEntryBlock{EBHash: ebKeyMR, DBHeight: dbheight})
+	}
+	s.MissingEntryBlocksMutex.Unlock()
+
+	request := messages.NewMissingData(s, ebKeyMR)
+	request.SendOut(s, request)
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/events"
+)
+
+// emitDBStateEvents sends the events of a saved directory block to the
+// event sinks, and a node-state event if the role of the node changed.
+func (s *State) emitDBStateEvents(dblock interfaces.IDirectoryBlock) {
+	if s.Events == nil {
+		return
+	}
+	height := dblock.GetDatabaseHeight()
+	s.Events.Emit(&events.Event{Type: events.DBlock, Height: height, KeyMR: dblock.GetKeyMR().String()})
+	for _, eb := range dblock.GetEBlockDBEntries() {
+		s.Events.Emit(&events.Event{Type: events.EBlock, Height: height, KeyMR: eb.GetKeyMR().String(), ChainID: eb.GetChainID().String()})
+	}
+
+	if role := s.GetNodeRole(); role != s.eventRole {
+		s.eventRole = role
+		s.Events.Emit(&events.Event{Type: events.NodeState, Height: height, State: role})
+	}
+}
+
+func (s *State) emitEntryReveal(dbheight uint32, entry interfaces.IEBEntry) {
+	if s.Events == nil {
+		return
+	}
+	s.Events.Emit(&events.Event{Type: events.EntryReveal, Height: dbheight, ChainID: entry.GetChainID().String(), EntryHash: entry.GetHash().String()})
+}
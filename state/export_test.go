@@ -0,0 +1,19 @@
+package state
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// The unexported steps of Process that the tests of package state_test drive.
+
+func (s *State) ExecuteMsg(vm *VM, msg interfaces.IMsg) bool {
+	return s.executeMsg(vm, msg)
+}
+
+func (s *State) AssembleFactoidTransactions(vm *VM) {
+	s.assembleFactoidTransactions(vm)
+}
+
+func (s *State) AskForEntryBlock(ebKeyMR interfaces.IHash, dbheight uint32) {
+	s.askForEntryBlock(ebKeyMR, dbheight)
+}
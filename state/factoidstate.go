@@ -14,6 +14,7 @@ import (
 	"runtime/debug"
 	"sort"
 
+	"github.com/FactomProject/factomd/common/adminBlock"
 	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/entryCreditBlock"
 	"github.com/FactomProject/factomd/common/factoid"
@@ -30,6 +31,8 @@ type FactoidState struct {
 	State        *State
 	CurrentBlock interfaces.IFBlock
 	Wallet       interfaces.ISCWallet
+	Payouts      CoinbasePayouts    // Coinbase outputs that have yet to mature
+	Recent       RecentTransactions // Transactions in recent blocks
 }
 
 var _ interfaces.IFactoidState = (*FactoidState)(nil)
@@ -180,13 +183,22 @@ func (fs *FactoidState) AddTransactionBlock(blk interfaces.IFBlock) error {
 		return err
 	}
 
+	// Entry credits are bought at the rate of the block
 	transactions := blk.GetTransactions()
+	rate := fs.purchaseRate(blk.GetDatabaseHeight(), blk.GetExchRate())
 	for _, trans := range transactions {
-		err := fs.UpdateTransaction(false, trans)
+		err := fs.updateTransaction(false, trans, rate)
 		if err != nil {
 			return err
 		}
 	}
+
+	// Hold the payouts of the coinbase until they mature
+	height := blk.GetDatabaseHeight()
+	fs.Payouts.Mature(height)
+	if len(transactions) > 0 {
+		fs.Payouts.Add(height, transactions[0])
+	}
 	fs.CurrentBlock = blk
 	//fs.State.SetFactoshisPerEC(blk.GetExchRate())
 
@@ -196,6 +208,13 @@ func (fs *FactoidState) AddTransactionBlock(blk interfaces.IFBlock) error {
 func (fs *FactoidState) AddECBlock(blk interfaces.IEntryCreditBlock) error {
 	transactions := blk.GetBody().GetEntries()
 
+	if !constants.IsActive(constants.ACTIVATION_EC_PURCHASE, blk.GetDatabaseHeight()) {
+		for _, trans := range transactions {
+			if trans.ECID() == entryCreditBlock.ECIDBalanceIncrease {
+				return fmt.Errorf("Entry credit purchase in EC block %d, before its activation", blk.GetDatabaseHeight())
+			}
+		}
+	}
 	for _, trans := range transactions {
 		err := fs.UpdateECTransaction(false, trans)
 		if err != nil {
@@ -235,48 +254,171 @@ func (fs *FactoidState) AddTransaction(index int, trans interfaces.ITransaction)
 	if err := fs.ValidateTransactionAge(trans); err != nil {
 		return err
 	}
-	if err := fs.UpdateTransaction(true, trans); err != nil {
+	rate := fs.purchaseRate(fs.DBHeight, fs.GetCurrentBlock().GetExchRate())
+	if err := fs.updateTransaction(true, trans, rate); err != nil {
 		return err
 	}
 	if err := fs.CurrentBlock.AddTransaction(trans); err != nil {
+		return err
+	}
+	// We assume validity has been done elsewhere.  We are maintaining the "seen" state of
+	// all transactions here.
+	fs.markSeen(constants.INTERNAL_REPLAY|constants.NETWORK_REPLAY, trans.GetSigHash(), trans.GetTimestamp())
+	fs.markSeen(constants.NETWORK_REPLAY|constants.NETWORK_REPLAY, trans.GetSigHash(), trans.GetTimestamp())
+
+	// Record each entry credit purchase in the EC block, in the minute it is
+	// made, so wallets can match the credits to the factoid transaction
+	pl := fs.State.ProcessLists.Get(fs.DBHeight)
+	if pl == nil || !constants.IsActive(constants.ACTIVATION_EC_PURCHASE, fs.DBHeight) {
+		return nil
+	}
+	for index, eo := range trans.GetECOutputs() {
+		incBal := entryCreditBlock.NewIncreaseBalance()
+		v := eo.GetAddress().Fixed()
+		incBal.ECPubKey = (*primitives.ByteSlice32)(&v)
+		incBal.NumEC = uint64(ecPurchased(eo.GetAmount(), rate))
+		incBal.TXID = trans.GetSigHash()
+		incBal.Index = uint64(index)
+		pl.EntryCreditBlock.GetBody().AddEntry(incBal)
+	}
+
+	return nil
+}
+
+func (fs *FactoidState) GetFactoidBalance(address [32]byte) int64 {
+	return fs.State.GetF(true, address)
+}
+
+// IsRecentTransaction is true if the transaction with the ID is in a block
+// recent enough that it could otherwise be replayed.
+func (fs *FactoidState) IsRecentTransaction(txid interfaces.IHash) bool {
+	return fs.Recent.Has(txid.Fixed())
+}
+
+// GetSpendableFactoidBalance returns the balance of an address less the
+// coinbase payouts to it that can't be spent in the current block.  The
+// payouts of the coinbase of the current block itself are never spendable.
+// Before coinbase maturity activates, the whole balance is spendable.  It
+// only reads the state, so the APIs may call it as well.
+func (fs *FactoidState) GetSpendableFactoidBalance(address [32]byte) int64 {
+	balance := fs.State.GetF(true, address)
+	if !constants.IsActive(constants.ACTIVATION_COINBASE_MATURITY, fs.DBHeight) {
+		return balance
+	}
+	immature := fs.Payouts.Immature(address, fs.DBHeight)
+	if fs.CurrentBlock != nil {
+		transactions := fs.CurrentBlock.GetTransactions()
+		if len(transactions) > 0 {
+			for _, output := range transactions[0].GetOutputs() {
+				if output.GetAddress().Fixed() == address {
+					immature += int64(output.GetAmount())
+				}
+			}
+		}
+	}
+	return balance - immature
+}
+
+// CancelCoinbasePayout strikes a coinbase payout that has yet to mature, and
+// takes it off the balance of its address.  Returns true if it was struck.
+func (fs *FactoidState) CancelCoinbasePayout(descriptorHeight uint32, index uint32) bool {
+	if !constants.IsActive(constants.ACTIVATION_COINBASE_MATURITY, fs.DBHeight) {
+		return false
+	}
+	adr, amount, ok := fs.Payouts.Cancel(descriptorHeight, index)
+	if !ok {
+		return false
+	}
+	fs.State.PutF(false, adr, fs.State.GetF(false, adr)-amount)
+
+	// The temporary balances were built on the permanent ones
+	pl := fs.State.ProcessLists.Get(fs.State.LLeaderHeight)
+	if pl != nil {
+		pl.FactoidBalancesTMutex.Lock()
+		if v, ok := pl.FactoidBalancesT[adr]; ok {
+			pl.FactoidBalancesT[adr] = v - amount
+		}
+		pl.FactoidBalancesTMutex.Unlock()
+	}
+	return true
+}
+
+// RebuildPayouts holds again the coinbase payouts that have yet to mature
+// after the block at the height, from the coinbases of the blocks before it,
+// and strikes those cancelled since.  A node restored from a saved state has
+// the balances the payouts were credited to, but not the payouts.
+func (fs *FactoidState) RebuildPayouts(height uint32) error {
+	fs.Payouts.Reset()
+	first := uint32(0)
+	if height > constants.COINBASE_MATURITY {
+		first = height - constants.COINBASE_MATURITY
+	}
+	var cancels []*adminBlock.CoinbaseCancel
+	for h := first; h <= height; h++ {
+		fblock, ablock, err := fs.blocksAt(h)
 		if err != nil {
 			return err
 		}
-		// We assume validity has been done elsewhere.  We are maintaining the "seen" state of
-		// all transactions here.
-		fs.State.Replay.IsTSValid(constants.INTERNAL_REPLAY|constants.NETWORK_REPLAY, trans.GetSigHash(), trans.GetTimestamp())
-		fs.State.Replay.IsTSValid(constants.NETWORK_REPLAY|constants.NETWORK_REPLAY, trans.GetSigHash(), trans.GetTimestamp())
-
-		for index, eo := range trans.GetECOutputs() {
-			pl := fs.State.ProcessLists.Get(fs.DBHeight)
-			incBal := entryCreditBlock.NewIncreaseBalance()
-			v := eo.GetAddress().Fixed()
-			incBal.ECPubKey = (*primitives.ByteSlice32)(&v)
-			incBal.NumEC = eo.GetAmount() / fs.GetCurrentBlock().GetExchRate()
-			incBal.TXID = trans.GetSigHash()
-			incBal.Index = uint64(index)
-			entries := pl.EntryCreditBlock.GetEntries()
-			i := 0
-			// Find the end of the last IncreaseBalance in this minute
-			for i < len(entries) {
-				if _, ok := entries[i].(*entryCreditBlock.IncreaseBalance); ok {
-					break
+		if fblock != nil && len(fblock.GetTransactions()) > 0 {
+			fs.Payouts.Add(h, fblock.GetTransactions()[0])
+		}
+		if ablock != nil {
+			for _, e := range ablock.GetABEntries() {
+				if c, ok := e.(*adminBlock.CoinbaseCancel); ok {
+					cancels = append(cancels, c)
 				}
-				i++
 			}
-			entries = append(entries, nil)
-			copy(entries[i+1:], entries[i:])
-			entries[i] = incBal
-			pl.EntryCreditBlock.GetBody().SetEntries(entries)
 		}
-
 	}
+	for _, c := range cancels {
+		fs.Payouts.Cancel(c.DescriptorHeight, c.DescriptorIndex)
+	}
+	fs.Payouts.Mature(height)
+	return nil
+}
 
+// RebuildRecent holds again the transactions of the saved blocks up to the
+// height that could still be replayed after it.  A transaction may be dated
+// up to TRANSACTION_POST_LIMIT after its block, so the blocks that far older
+// than the window are read as well.
+func (fs *FactoidState) RebuildRecent(height uint32) error {
+	var top int64
+	for h := int64(height); h >= 0; h-- {
+		fblock, err := fs.State.DB.FetchFBlockByHeight(uint32(h))
+		if err != nil {
+			return err
+		}
+		if fblock == nil {
+			continue
+		}
+		ts := fblock.GetCoinbaseTimestamp().GetTimeMilli()
+		if top == 0 {
+			top = ts
+		}
+		if top-ts > constants.TRANSACTION_PRIOR_LIMIT+constants.TRANSACTION_POST_LIMIT {
+			break
+		}
+		fs.Recent.AddBlock(fblock)
+	}
+	fs.Recent.Expire(top)
 	return nil
 }
 
-func (fs *FactoidState) GetFactoidBalance(address [32]byte) int64 {
-	return fs.State.GetF(true, address)
+// blocksAt returns the factoid and admin blocks at the height, from memory
+// if they are held, or else from the database.
+func (fs *FactoidState) blocksAt(height uint32) (interfaces.IFBlock, interfaces.IAdminBlock, error) {
+	if d := fs.State.DBStates.Get(int(height)); d != nil && d.FactoidBlock != nil && d.AdminBlock != nil {
+		return d.FactoidBlock, d.AdminBlock, nil
+	}
+	fblock, err := fs.State.DB.FetchFBlockByHeight(height)
+	if err != nil {
+		return nil, nil, err
+	}
+	ablock, err := fs.State.DB.FetchABlockByHeight(height)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fblock, ablock, nil
 }
 
 func (fs *FactoidState) GetECBalance(address [32]byte) int64 {
@@ -291,20 +433,24 @@ func (fs *FactoidState) UpdateECTransaction(rt bool, trans interfaces.IECBlockEn
 	case entryCreditBlock.ECIDMinuteNumber:
 		return nil
 
+	case entryCreditBlock.ECIDBalanceIncrease:
+		// The credits came with the factoid transaction that bought them
+		return nil
+
 	case entryCreditBlock.ECIDChainCommit:
 		t := trans.(*entryCreditBlock.CommitChain)
 		v := fs.State.GetE(rt, t.ECPubKey.Fixed()) - int64(t.Credits)
 		fs.State.PutE(rt, t.ECPubKey.Fixed(), v)
 		fs.State.NumTransactions++
-		fs.State.Replay.IsTSValid(constants.INTERNAL_REPLAY, t.GetSigHash(), t.GetTimestamp())
-		fs.State.Replay.IsTSValid(constants.NETWORK_REPLAY, t.GetSigHash(), t.GetTimestamp())
+		fs.markSeen(constants.INTERNAL_REPLAY, t.GetSigHash(), t.GetTimestamp())
+		fs.markSeen(constants.NETWORK_REPLAY, t.GetSigHash(), t.GetTimestamp())
 	case entryCreditBlock.ECIDEntryCommit:
 		t := trans.(*entryCreditBlock.CommitEntry)
 		v := fs.State.GetE(rt, t.ECPubKey.Fixed()) - int64(t.Credits)
 		fs.State.PutE(rt, t.ECPubKey.Fixed(), v)
 		fs.State.NumTransactions++
-		fs.State.Replay.IsTSValid(constants.INTERNAL_REPLAY, t.GetSigHash(), t.GetTimestamp())
-		fs.State.Replay.IsTSValid(constants.NETWORK_REPLAY, t.GetSigHash(), t.GetTimestamp())
+		fs.markSeen(constants.INTERNAL_REPLAY, t.GetSigHash(), t.GetTimestamp())
+		fs.markSeen(constants.NETWORK_REPLAY, t.GetSigHash(), t.GetTimestamp())
 	default:
 		return fmt.Errorf("Unknown EC Transaction")
 	}
@@ -314,6 +460,12 @@ func (fs *FactoidState) UpdateECTransaction(rt bool, trans interfaces.IECBlockEn
 
 // Assumes validation has already been done.
 func (fs *FactoidState) UpdateTransaction(rt bool, trans interfaces.ITransaction) error {
+	return fs.updateTransaction(rt, trans, fs.State.FactoshisPerEC)
+}
+
+// updateTransaction updates the balances with a transaction, buying entry
+// credits at the given rate, in factoshis per entry credit.
+func (fs *FactoidState) updateTransaction(rt bool, trans interfaces.ITransaction, rate uint64) error {
 	for _, input := range trans.GetInputs() {
 		adr := input.GetAddress().Fixed()
 		oldv := fs.State.GetF(rt, adr)
@@ -325,13 +477,32 @@ func (fs *FactoidState) UpdateTransaction(rt bool, trans interfaces.ITransaction
 		fs.State.PutF(rt, adr, oldv+int64(output.GetAmount()))
 	}
 	for _, ecOut := range trans.GetECOutputs() {
-		ecbal := int64(ecOut.GetAmount()) / int64(fs.State.FactoshisPerEC)
+		ecbal := ecPurchased(ecOut.GetAmount(), rate)
 		fs.State.PutE(rt, ecOut.GetAddress().Fixed(), fs.State.GetE(rt, ecOut.GetAddress().Fixed())+ecbal)
 	}
 	fs.State.NumTransactions++
 	return nil
 }
 
+// purchaseRate returns the rate entry credits are bought at in the factoid
+// block at the height, of which blockRate is the exchange rate.  Before
+// ACTIVATION_EC_PURCHASE, credits are bought at the rate the node holds.
+func (fs *FactoidState) purchaseRate(height uint32, blockRate uint64) uint64 {
+	if constants.IsActive(constants.ACTIVATION_EC_PURCHASE, height) {
+		return blockRate
+	}
+	return fs.State.FactoshisPerEC
+}
+
+// ecPurchased returns the entry credits the factoshis buy at the rate.  No
+// rate buys none, rather than dividing by zero.
+func ecPurchased(factoshis uint64, rate uint64) int64 {
+	if rate == 0 {
+		return 0
+	}
+	return int64(factoshis / rate)
+}
+
 // End of Block means packing the current block away, and setting
 // up the next
 func (fs *FactoidState) ProcessEndOfBlock(state interfaces.IState) {
@@ -374,13 +545,19 @@ func (fs *FactoidState) ProcessEndOfBlock(state interfaces.IState) {
 // Returns an error message about what is wrong with the transaction if it is
 // invalid, otherwise you are good to go.
 func (fs *FactoidState) Validate(index int, trans interfaces.ITransaction) error {
+	if fs.IsRecentTransaction(trans.GetSigHash()) {
+		return fmt.Errorf("%s", "Transaction is already in a block")
+	}
+	if err := factoid.CheckRCDsActive(trans, fs.DBHeight); err != nil {
+		return err
+	}
 	var sums = make(map[[32]byte]uint64, 10)  // Look at the sum of an address's inputs
 	for _, input := range trans.GetInputs() { //    to a transaction.
 		bal, err := factoid.ValidateAmounts(sums[input.GetAddress().Fixed()], input.GetAmount())
 		if err != nil {
 			return err
 		}
-		if int64(bal) > fs.State.GetF(true, input.GetAddress().Fixed()) {
+		if int64(bal) > fs.GetSpendableFactoidBalance(input.GetAddress().Fixed()) {
 			return fmt.Errorf("%s", "Not enough funds in input addresses for the transaction")
 		}
 		sums[input.GetAddress().Fixed()] = bal
@@ -388,3 +565,9 @@ func (fs *FactoidState) Validate(index int, trans interfaces.ITransaction) error
 
 	return nil
 }
+
+// markSeen records the transaction in the replay filter, at the time of the
+// node.
+func (fs *FactoidState) markSeen(mask int, hash interfaces.IHash, timestamp interfaces.Timestamp) {
+	fs.State.Replay.IsTSValid_(mask, hash.Fixed(), timestamp, primitives.NewTimestampFromClock(fs.State.GetClock()))
+}
@@ -11,10 +11,13 @@ import (
 	"testing"
 
 	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/common/primitives/random"
 	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
 )
 
 var fs interfaces.IFactoidState
@@ -190,6 +193,99 @@ func TestGetMapHash(t *testing.T) {
 	}
 }
 
+func TestECPurchase(t *testing.T) {
+	s := testHelper.CreateEmptyTestState()
+	s.FactoshisPerEC = 1000
+	ec := testHelper.NewFactoidAddress(1)
+
+	tx := new(factoid.Transaction)
+	tx.AddECOutput(ec, 10500)
+	err := s.GetFactoidState().UpdateTransaction(false, tx)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if v := s.GetE(false, ec.Fixed()); v != 10 {
+		t.Errorf("Expected 10 entry credits, got %v", v)
+	}
+
+	// The purchase recorded in the EC block is not credited again
+	ib := entryCreditBlock.NewIncreaseBalance()
+	v := ec.Fixed()
+	ib.ECPubKey = (*primitives.ByteSlice32)(&v)
+	ib.NumEC = 10
+	err = s.GetFactoidState().UpdateECTransaction(false, ib)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if v := s.GetE(false, ec.Fixed()); v != 10 {
+		t.Errorf("Expected 10 entry credits, got %v", v)
+	}
+
+	// No rate buys nothing
+	s.FactoshisPerEC = 0
+	err = s.GetFactoidState().UpdateTransaction(false, tx)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if v := s.GetE(false, ec.Fixed()); v != 10 {
+		t.Errorf("Expected 10 entry credits, got %v", v)
+	}
+}
+
+func TestECPurchaseActivation(t *testing.T) {
+	s := testHelper.CreateEmptyTestState()
+	s.FactoshisPerEC = 1000
+	ec := testHelper.NewFactoidAddress(2)
+
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(1), 100000)
+	tx.AddECOutput(ec, 10000)
+	tx.SetTimestamp(primitives.NewTimestampNow())
+	testHelper.SignFactoidTransaction(1, tx)
+
+	blk := factoid.NewFBlock(nil)
+	blk.SetExchRate(500)
+	if err := blk.AddCoinbase(factoid.GetCoinbase(primitives.NewTimestampNow())); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := blk.AddTransaction(tx); err != nil {
+		t.Fatalf("%v", err)
+	}
+	blk.(*factoid.FBlock).CalculateHashes()
+
+	ecBlock := entryCreditBlock.NewECBlock()
+	ib := entryCreditBlock.NewIncreaseBalance()
+	v := ec.Fixed()
+	ib.ECPubKey = (*primitives.ByteSlice32)(&v)
+	ecBlock.GetBody().AddEntry(ib)
+
+	// Before the activation, credits are bought at the node's rate, and EC
+	// blocks don't record purchases
+	constants.SetActivationNetwork(constants.NETWORK_MAIN)
+	defer constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+	if err := s.GetFactoidState().AddTransactionBlock(blk); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v := s.GetE(false, ec.Fixed()); v != 10 {
+		t.Errorf("Expected 10 entry credits at the node's rate, got %v", v)
+	}
+	if err := s.GetFactoidState().AddECBlock(ecBlock); err == nil {
+		t.Errorf("An EC block recorded a purchase before the activation")
+	}
+
+	// After it, at the block's
+	constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+	if err := s.GetFactoidState().AddTransactionBlock(blk); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v := s.GetE(false, ec.Fixed()); v != 30 {
+		t.Errorf("Expected 20 more entry credits at the block's rate, got %v", v)
+	}
+	if err := s.GetFactoidState().AddECBlock(ecBlock); err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
 /*
 func TestBalances(t *testing.T) {
 	s := testHelper.CreateEmptyTestState()
@@ -8,14 +8,55 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/rand"
-	"time"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 
 	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/events"
 )
 
+// GetElectionStatus returns the faults of the VMs of the current process
+// list, or nil if there isn't one yet.
+func (s *State) GetElectionStatus() *interfaces.ElectionStatus {
+	s.ProcessLists.Mutex.RLock()
+	defer s.ProcessLists.Mutex.RUnlock()
+
+	pl := s.ProcessLists.GetSafe(s.LLeaderHeight)
+	if pl == nil {
+		return nil
+	}
+	minute := s.CurrentMinute
+	if minute > 9 {
+		minute = 0
+	}
+
+	es := new(interfaces.ElectionStatus)
+	es.DBHeight = pl.DBHeight
+	es.Minute = s.CurrentMinute
+	es.AmINegotiator = pl.AmINegotiator
+	if fault := pl.CurrentFault(); fault != nil {
+		es.CurrentFault = fault
+	}
+	for i := 0; i < len(pl.FedServers) && i < len(pl.VMs); i++ {
+		vm := pl.VMs[i]
+		status := interfaces.VMStatus{
+			VMIndex:      i,
+			Height:       vm.Height,
+			ListLength:   len(vm.List),
+			LeaderMinute: vm.LeaderMinute,
+			Synced:       vm.Synced,
+			WhenFaulted:  vm.WhenFaulted,
+			FaultFlag:    vm.FaultFlag,
+		}
+		if fed := pl.ServerMap[minute][i]; fed < len(pl.FedServers) {
+			status.Leader = pl.FedServers[fed].GetChainID()
+		}
+		es.VMs = append(es.VMs, status)
+	}
+	return es
+}
+
 type FaultCore struct {
 	// The following 5 fields represent the "Core" of the message
 	// This should match the Core of FullServerFault messages
@@ -81,22 +122,29 @@ func markFault(pl *ProcessList, vmIndex int, faultReason int) {
 		return
 	}
 
-	now := time.Now().Unix()
+	now := pl.State.GetClock().Now().Unix()
 	vm := pl.VMs[vmIndex]
 
+	c := pl.State.CurrentMinute
+	if c > 9 {
+		c = 9
+	}
+	index := pl.ServerMap[c][vmIndex]
+
 	if vm.WhenFaulted == 0 {
 		// if we did not previously consider this VM faulted
 		// we simply mark it as faulted (by assigning it a nonzero WhenFaulted time)
 		// and keep track of the ProcessList height it has faulted at
 		vm.WhenFaulted = now
 		vm.FaultFlag = faultReason
-	}
 
-	c := pl.State.CurrentMinute
-	if c > 9 {
-		c = 9
+		if index < len(pl.FedServers) {
+			chainID := pl.FedServers[index].GetChainID().String()
+			pl.State.Alert(&events.Event{Type: events.LeaderFault, Height: pl.DBHeight, ChainID: chainID},
+				"The leader %s of VM %d is faulted at height %d, minute %d", chainID, vmIndex, pl.DBHeight, c)
+		}
 	}
-	index := pl.ServerMap[c][vmIndex]
+
 	if index < len(pl.FedServers) {
 		pl.FedServers[index].SetOnline(false)
 	}
@@ -109,7 +157,7 @@ func markNoFault(pl *ProcessList, vmIndex int) {
 	vm.FaultFlag = -1
 
 	nextIndex := (vmIndex + 1) % len(pl.FedServers)
-	if pl.VMs[nextIndex].FaultFlag > 0 {
+	if pl.VMs[nextIndex].FaultFlag == 1 {
 		markNoFault(pl, nextIndex)
 	}
 
@@ -150,7 +198,7 @@ func NegotiationCheck(pl *ProcessList) {
 		return
 	}
 
-	now := time.Now().Unix()
+	now := pl.State.GetClock().Now().Unix()
 	if now-prevVM.WhenFaulted < int64(pl.State.FaultTimeout) {
 		//It hasn't been long enough; wait a little longer
 		//before starting negotiation
@@ -175,7 +223,7 @@ func NegotiationCheck(pl *ProcessList) {
 func FaultCheck(pl *ProcessList) {
 	NegotiationCheck(pl)
 
-	now := time.Now().Unix()
+	now := pl.State.GetClock().Now().Unix()
 
 	currentFault := pl.CurrentFault()
 	if currentFault.IsNil() {
@@ -325,7 +373,7 @@ func CraftFullFault(pl *ProcessList, vmIndex int, height int) *messages.FullServ
 		prevFF = pl.System.List[pl.System.Height-1].(*messages.FullServerFault)
 	}
 
-	now := time.Now().Unix()
+	now := pl.State.GetClock().Now().Unix()
 
 	if faultState.IsNil() || (now-faultState.GetTimestamp().GetTimeSeconds() > int64(pl.State.FaultTimeout)) && !(faultState.HasEnoughSigs(pl.State) && faultState.GetPledgeDone()) {
 		sf = CraftFault(pl, vmIndex, height)
@@ -0,0 +1,258 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/events"
+)
+
+// Where a signed directory block was seen
+const (
+	ForkSourceLocal   = "local"   // The block this node has
+	ForkSourceDBSig   = "dbsig"   // Signed by a DBSig
+	ForkSourceDBState = "dbstate" // Signed in a DBState
+)
+
+// The heights below the highest watched that are kept
+const forkWatchDepth = 100
+
+// forkWatch holds the directory blocks seen signed at each recent height,
+// and the federated servers that signed each.  A height with two blocks each
+// signed by a federated server is a fork.  The block this node built is
+// watched too, without signers until the DBSigs for it come in, so a node
+// that merely built a different block than the network is not a fork; it is
+// healed by the DBSig tally.  Once a fork is found, no more blocks are saved,
+// until it is cleared.
+type forkWatch struct {
+	mutex   sync.Mutex
+	blocks  map[uint32][]*interfaces.ForkBlock
+	checked map[[32]byte]uint32 // Messages already checked, with their heights
+	highest uint32
+	fork    *interfaces.ForkStatus
+}
+
+// GetForkStatus returns the fork found, or nil if there is none.
+func (s *State) GetForkStatus() *interfaces.ForkStatus {
+	w := &s.forkWatch
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.fork
+}
+
+// ClearForkStatus drops the fork found, and the blocks watched at its height,
+// so that saving resumes.  The operator clears it once the node is on the
+// side it should be.
+func (s *State) ClearForkStatus() {
+	w := &s.forkWatch
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.fork == nil {
+		return
+	}
+	delete(w.blocks, w.fork.DBHeight)
+	w.fork = nil
+	ForkDetected.Set(0)
+}
+
+// WatchSignedBlock records the directory block with the key merkle root as
+// signed for the height by the signers.  If another block was signed for the
+// height by a federated server as well, it is a fork: it is logged, and block
+// saving halts.
+func (s *State) WatchSignedBlock(height uint32, keyMR interfaces.IHash, source string, signers ...string) {
+	w := &s.forkWatch
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.blocks == nil {
+		w.blocks = map[uint32][]*interfaces.ForkBlock{}
+		w.checked = map[[32]byte]uint32{}
+	}
+	if height > w.highest {
+		w.highest = height
+		w.prune()
+	}
+
+	var block *interfaces.ForkBlock
+	for _, b := range w.blocks[height] {
+		if b.KeyMR == keyMR.String() {
+			block = b
+		}
+	}
+	if block == nil {
+		block = &interfaces.ForkBlock{KeyMR: keyMR.String(), Source: source}
+		w.blocks[height] = append(w.blocks[height], block)
+	}
+	block.Signers = addSigners(block.Signers, signers)
+	if w.fork != nil || len(block.Signers) == 0 {
+		return
+	}
+
+	for _, other := range w.blocks[height] {
+		if other == block || len(other.Signers) == 0 {
+			continue
+		}
+		w.fork = &interfaces.ForkStatus{
+			DBHeight: height,
+			Blocks:   []interfaces.ForkBlock{*other, *block},
+			Detected: s.GetClock().Now(),
+		}
+		ForkDetected.Set(1)
+		s.Alert(&events.Event{Type: events.Fork, Height: height, KeyMR: block.KeyMR},
+			"Two directory blocks are signed for height %d: %s (%s, signed by %v) and %s (%s, signed by %v).  Saving has halted.",
+			height, other.KeyMR, other.Source, other.Signers, block.KeyMR, block.Source, block.Signers)
+		return
+	}
+}
+
+// prune drops the heights too far below the highest.
+func (w *forkWatch) prune() {
+	if w.highest < forkWatchDepth {
+		return
+	}
+	low := w.highest - forkWatchDepth
+	for height := range w.blocks {
+		if height < low {
+			delete(w.blocks, height)
+		}
+	}
+	for hash, height := range w.checked {
+		if height < low {
+			delete(w.checked, hash)
+		}
+	}
+}
+
+// watchedOther returns true if a block other than the one with the key
+// merkle root is watched at the height.
+func (s *State) watchedOther(height uint32, keyMR string) bool {
+	w := &s.forkWatch
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, block := range w.blocks[height] {
+		if block.KeyMR != keyMR {
+			return true
+		}
+	}
+	return false
+}
+
+// firstCheck returns true the first time it is asked about the message, as
+// messages in holding are executed over and over.
+func (s *State) firstCheck(msg interfaces.IMsg, height uint32) bool {
+	w := &s.forkWatch
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.checked == nil {
+		w.checked = map[[32]byte]uint32{}
+	}
+	hash := msg.GetMsgHash().Fixed()
+	if _, ok := w.checked[hash]; ok {
+		return false
+	}
+	w.checked[hash] = height
+	return true
+}
+
+func addSigners(signers []string, more []string) []string {
+Next:
+	for _, signer := range more {
+		for _, have := range signers {
+			if have == signer {
+				continue Next
+			}
+		}
+		signers = append(signers, signer)
+	}
+	return signers
+}
+
+// fedSigner returns the identity chain ID of the federated server at the
+// height that made the signature of the data, or "" if none did.
+func (s *State) fedSigner(data []byte, sig interfaces.IFullSignature, height uint32) string {
+	if sig == nil {
+		return ""
+	}
+	for _, fed := range s.GetFedServers(height) {
+		auth, _ := s.GetAuthority(fed.GetChainID())
+		if auth == nil {
+			continue
+		}
+		valid, err := auth.VerifySignature(data, sig.GetSignature())
+		if err == nil && valid {
+			return fed.GetChainID().String()
+		}
+	}
+	return ""
+}
+
+// headerKeyMR returns the key merkle root of the directory block with the
+// header, and the marshalled header.
+func headerKeyMR(header interfaces.IDirectoryBlockHeader) (interfaces.IHash, []byte, error) {
+	data, err := header.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	merkle := primitives.BuildMerkleTreeStore([]interfaces.IHash{primitives.Sha(data), header.GetBodyMR()})
+	return merkle[len(merkle)-1], data, nil
+}
+
+// checkDBSigFork watches the block a DBSig signs, against the block this
+// node has at its height.
+func (s *State) checkDBSigFork(dbs *messages.DirectoryBlockSignature, dblk interfaces.IDirectoryBlock) {
+	height := dblk.GetDatabaseHeight()
+	if dbs.DirectoryBlockHeader.GetDBHeight() != height || !s.firstCheck(dbs, height) {
+		return
+	}
+	keyMR, data, err := headerKeyMR(dbs.DirectoryBlockHeader)
+	if err != nil {
+		return
+	}
+	signer := s.fedSigner(data, dbs.DBSignature, dbs.DBHeight)
+	if signer == "" {
+		return
+	}
+	s.WatchSignedBlock(height, dblk.GetKeyMR(), ForkSourceLocal)
+	s.WatchSignedBlock(height, keyMR, ForkSourceDBSig, signer)
+}
+
+// checkDBStateFork watches the block of a DBState, against the block this
+// node has saved at its height, or those signed for it.  The signatures, of
+// which only those of federated servers count, are only checked if the
+// blocks differ, to spare the checks when catching up.
+func (s *State) checkDBStateFork(m *messages.DBStateMsg) {
+	height := m.DirectoryBlock.GetDatabaseHeight()
+	if height == 0 || !s.firstCheck(m, height) {
+		return
+	}
+	if height <= s.GetHighestSavedBlk() {
+		if dblk := s.GetDirectoryBlockByHeight(height); dblk != nil {
+			s.WatchSignedBlock(height, dblk.GetKeyMR(), ForkSourceLocal)
+		}
+	}
+	if !s.watchedOther(height, m.DirectoryBlock.GetKeyMR().String()) {
+		return
+	}
+
+	data, err := m.DirectoryBlock.GetHeader().MarshalBinary()
+	if err != nil {
+		return
+	}
+	var signers []string
+	for _, sig := range m.SignatureList.List {
+		if signer := s.fedSigner(data, sig, height); signer != "" {
+			signers = addSigners(signers, []string{signer})
+		}
+	}
+	if len(signers) == 0 {
+		return
+	}
+	s.WatchSignedBlock(height, m.DirectoryBlock.GetKeyMR(), ForkSourceDBState, signers...)
+}
@@ -0,0 +1,73 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/state"
+)
+
+func TestWatchSignedBlock(t *testing.T) {
+	s := new(State)
+	a := primitives.Sha([]byte("a"))
+	b := primitives.Sha([]byte("b"))
+
+	s.WatchSignedBlock(10, a, ForkSourceLocal)
+	s.WatchSignedBlock(10, a, ForkSourceDBSig, "fed1")
+	s.WatchSignedBlock(11, b, ForkSourceDBSig, "fed1")
+	if s.GetForkStatus() != nil {
+		t.Fatalf("Fork found without one: %v", s.GetForkStatus())
+	}
+
+	s.WatchSignedBlock(10, b, ForkSourceDBState, "fed2", "fed3")
+	fork := s.GetForkStatus()
+	if fork == nil {
+		t.Fatalf("Fork not found")
+	}
+	if fork.DBHeight != 10 || len(fork.Blocks) != 2 {
+		t.Fatalf("Wrong fork %v", fork)
+	}
+	if fork.Blocks[0].KeyMR != a.String() || len(fork.Blocks[0].Signers) != 1 || fork.Blocks[0].Signers[0] != "fed1" {
+		t.Errorf("Wrong first block %v", fork.Blocks[0])
+	}
+	if fork.Blocks[1].KeyMR != b.String() || fork.Blocks[1].Source != ForkSourceDBState || len(fork.Blocks[1].Signers) != 2 {
+		t.Errorf("Wrong second block %v", fork.Blocks[1])
+	}
+
+	// The first fork found is kept
+	s.WatchSignedBlock(11, a, ForkSourceDBSig, "fed2")
+	if s.GetForkStatus().DBHeight != 10 {
+		t.Errorf("Fork replaced by %v", s.GetForkStatus())
+	}
+
+	// Until it is cleared
+	s.ClearForkStatus()
+	if s.GetForkStatus() != nil {
+		t.Errorf("Fork not cleared")
+	}
+	s.WatchSignedBlock(10, b, ForkSourceDBSig, "fed4")
+	if s.GetForkStatus() != nil {
+		t.Errorf("Fork found again at the height cleared: %v", s.GetForkStatus())
+	}
+}
+
+func TestWatchSignedBlockUnsigned(t *testing.T) {
+	s := new(State)
+	a := primitives.Sha([]byte("a"))
+	b := primitives.Sha([]byte("b"))
+
+	// A node that built a different block than the one the network signs has
+	// not found a fork
+	s.WatchSignedBlock(10, a, ForkSourceLocal)
+	s.WatchSignedBlock(10, b, ForkSourceDBSig, "fed1")
+	s.WatchSignedBlock(10, b, ForkSourceDBSig, "fed2")
+	if s.GetForkStatus() != nil {
+		t.Fatalf("Fork found without one: %v", s.GetForkStatus())
+	}
+
+	// Unless a federated server signs its block as well
+	s.WatchSignedBlock(10, a, ForkSourceLocal, "fed3")
+	if fork := s.GetForkStatus(); fork == nil || fork.Blocks[0].KeyMR != b.String() || fork.Blocks[1].KeyMR != a.String() {
+		t.Errorf("Wrong fork %v", fork)
+	}
+}
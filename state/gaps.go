@@ -0,0 +1,169 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+)
+
+// When the list of a VM has a gap, entries missing below one we hold, the
+// serial hash chain can't be checked past it, and the VM stalls.  We ask for
+// the whole gap at once: first of the peer the acks of the leader of the VM
+// come to us from, which is the leader or the nearest peer to it, and then of
+// any peer.  The asks back off, doubling the wait each time, and if the gap
+// is still open after GapMaxAsks of them the VM is faulted, as one whose
+// leader is gone.
+
+const (
+	GapFirstWait   = 500   // Milliseconds to wait before asking again, the first time
+	GapMaxWait     = 16000 // The most milliseconds to wait between asks
+	GapLeaderAsks  = 2     // How many asks go toward the leader before other peers
+	GapMaxAsks     = 8     // How many asks before the VM is faulted
+	GapFaultReason = 2     // The FaultFlag of a VM faulted for a gap
+)
+
+// A Gap is a run of missing entries of a VM, below an entry we hold.
+type Gap struct {
+	VMIndex int
+	From    int   // The first missing height
+	To      int   // The last missing height
+	Found   int64 // When we found the gap, in milliseconds
+	Asked   int64 // When we last asked for it, in milliseconds
+	Asks    int   // How many times we asked for it
+}
+
+// Wait is how long to wait after the last ask before asking again.
+func (g *Gap) Wait() int64 {
+	w := int64(GapFirstWait)
+	for i := 1; i < g.Asks && w < GapMaxWait; i++ {
+		w *= 2
+	}
+	if w > GapMaxWait {
+		w = GapMaxWait
+	}
+	return w
+}
+
+// Escalated is true once the gap has gone unfilled for every ask.
+func (g *Gap) Escalated() bool {
+	return g.Asks >= GapMaxAsks
+}
+
+func (g *Gap) String() string {
+	return fmt.Sprintf("VM %d heights %d-%d, asked %d times", g.VMIndex, g.From, g.To, g.Asks)
+}
+
+// findGap returns the gap of the VM starting at from, whose entry is
+// missing.
+func findGap(vm *VM, vmIndex int, from int) (g *Gap) {
+	g = &Gap{VMIndex: vmIndex, From: from, To: from}
+	for g.To+1 < len(vm.List) && vm.List[g.To+1] == nil {
+		g.To++
+	}
+	return g
+}
+
+// AskForGap asks for the missing entries of the VM from the height, if it is
+// time to, and faults the VM once the gap has been asked for too often.
+func (p *ProcessList) AskForGap(vmIndex int, from int) {
+	now := p.State.GetTimestamp().GetTimeMilli()
+	vm := p.VMs[vmIndex]
+
+	if p.Gaps == nil {
+		p.Gaps = make(map[int]*Gap)
+	}
+	g := p.Gaps[vmIndex]
+	found := findGap(vm, vmIndex, from)
+	if g == nil || g.From != found.From {
+		found.Found = now
+		g = found
+		p.Gaps[vmIndex] = g
+	} else if found.To > g.To {
+		g.To = found.To
+	}
+
+	if g.Escalated() {
+		if vm.WhenFaulted == 0 {
+			p.State.AddStatus(fmt.Sprintf("GAP: Faulting the VM after asking for %s", g.String()))
+			markFault(p, vmIndex, GapFaultReason)
+		}
+		return
+	}
+	if g.Asks > 0 && now-g.Asked < g.Wait() {
+		return
+	}
+	if p.State.inMsgQueue.Length() >= constants.INMSGQUEUE_MED {
+		return
+	}
+
+	missingMsgRequest := messages.NewMissingMsg(p.State, vmIndex, p.DBHeight, uint32(g.From))
+	for h := g.From + 1; h <= g.To; h++ {
+		missingMsgRequest.AddHeight(uint32(h))
+	}
+	if g.Asks < GapLeaderAsks {
+		// Zero, should we not know the way to the leader, is any peer
+		missingMsgRequest.SetOrigin(p.State.leaderOrigin(p.vmLeader(vmIndex)))
+	}
+	missingMsgRequest.SendOut(p.State, missingMsgRequest)
+	p.State.MissingRequestAskCnt++
+
+	g.Asked = now
+	g.Asks++
+}
+
+// closeGaps forgets the gaps of the VM that have been filled, and returns
+// true if the VM still has one that has escalated to a fault.
+func (p *ProcessList) closeGaps(vmIndex int) bool {
+	g := p.Gaps[vmIndex]
+	if g == nil {
+		return false
+	}
+	vm := p.VMs[vmIndex]
+	for h := g.From; h <= g.To; h++ {
+		if h >= vm.Height && h < len(vm.List) && vm.List[h] == nil {
+			return g.Escalated()
+		}
+	}
+	delete(p.Gaps, vmIndex)
+	return false
+}
+
+// vmLeader returns the identity leading the VM this minute, or nil.
+func (p *ProcessList) vmLeader(vmIndex int) interfaces.IHash {
+	minute := p.State.CurrentMinute
+	if minute > 9 {
+		minute = 9
+	}
+	if fed := p.ServerMap[minute][vmIndex]; fed < len(p.FedServers) {
+		return p.FedServers[fed].GetChainID()
+	}
+	return nil
+}
+
+// noteLeaderOrigin remembers the peer an ack of the leader came to us from.
+// An ack is executed the first time it comes, so this is the peer with the
+// quickest way to the leader.
+func (s *State) noteLeaderOrigin(leader interfaces.IHash, origin int) {
+	if leader == nil || origin <= 0 {
+		return
+	}
+	if s.leaderOrigins == nil {
+		s.leaderOrigins = make(map[[32]byte]int)
+	}
+	s.leaderOrigins[leader.Fixed()] = origin
+}
+
+// leaderOrigin returns the origin of the peer toward the leader, or 0 if we
+// don't know it.
+func (s *State) leaderOrigin(leader interfaces.IHash) int {
+	if leader == nil {
+		return 0
+	}
+	return s.leaderOrigins[leader.Fixed()]
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestGapWait(t *testing.T) {
+	g := new(Gap)
+	var last int64
+	for g.Asks = 1; g.Asks < 20; g.Asks++ {
+		w := g.Wait()
+		if w < last || w > GapMaxWait {
+			t.Errorf("Wait of %d after %d asks, %d before", w, g.Asks, last)
+		}
+		last = w
+	}
+	if last != GapMaxWait {
+		t.Errorf("Expected the wait to reach %d, got %d", GapMaxWait, last)
+	}
+	if !g.Escalated() {
+		t.Error("Expected the gap to have escalated")
+	}
+}
+
+func TestAskForGap(t *testing.T) {
+	s := testHelper.CreateEmptyTestState()
+	s.IgnoreMissing = false
+	pl := NewProcessList(s, nil, 5)
+	vm := pl.VMs[0]
+	vm.List = []interfaces.IMsg{nil, nil, new(messages.EOM), nil}
+	vm.ListAck = make([]*messages.Ack, len(vm.List))
+
+	pl.AskForGap(0, 0)
+	g := pl.Gaps[0]
+	if g == nil || g.From != 0 || g.To != 1 || g.Asks != 1 {
+		t.Fatalf("Expected one ask for heights 0-1, got %v", g)
+	}
+
+	// Too soon to ask again
+	pl.AskForGap(0, 0)
+	if g.Asks != 1 {
+		t.Errorf("Asked again without waiting, %d asks", g.Asks)
+	}
+
+	g.Asks = GapMaxAsks
+	pl.AskForGap(0, 0)
+	if vm.WhenFaulted == 0 || vm.FaultFlag != GapFaultReason {
+		t.Errorf("Expected the VM faulted for its gap, when %d flag %d", vm.WhenFaulted, vm.FaultFlag)
+	}
+}
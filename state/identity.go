@@ -619,7 +619,7 @@ func UpdateMatryoshkaHash(entry interfaces.IEBEntry, initial bool, height uint32
 			// Add to admin block
 			status := st.Identities[IdentityIndex].Status
 			if !initial && statusIsFedOrAudit(status) && st.GetLeaderVM() == st.ComputeVMIndex(entry.GetChainID().Bytes()) {
-				//if st.LeaderPL.VMIndexFor(constants.ADMIN_CHAINID) == st.GetLeaderVM() {
+				//if st.LeaderPL.VMIndexFor(st.CurrentMinute, constants.ADMIN_CHAINID) == st.GetLeaderVM() {
 				msg := messages.NewChangeServerKeyMsg(st, chainID, constants.TYPE_ADD_MATRYOSHKA, 0, 0, mhash)
 				err := msg.(*messages.ChangeServerKeyMsg).Sign(st.serverPrivKey)
 				if err != nil {
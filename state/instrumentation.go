@@ -66,6 +66,28 @@ var (
 		Help: "Highest completed block, which may or may not be saved to the database",
 	})
 
+	// Block times
+	BlockTime = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_state_dblock_time_seconds",
+		Help: "Time between the timestamps of a saved directory block and the one before it",
+	})
+	LastBlockTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "factomd_state_dblock_last_timestamp_seconds",
+		Help: "Timestamp of the highest directory block saved to the database",
+	})
+
+	// Set to 1 once two directory blocks are found signed for the same height
+	ForkDetected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "factomd_state_fork_detected",
+		Help: "1 if two different directory blocks were signed for the same height, and saving has halted",
+	})
+
+	// Messages slower than SlowMessageThreshold to execute or process
+	SlowMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_state_slow_messages_total",
+		Help: "Messages that took longer than the slow message threshold to execute or process",
+	})
+
 	// TPS
 	TotalTransactionPerSecond = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "factomd_state_txrate_total_tps",
@@ -459,6 +481,12 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(HighestSaved)
 	prometheus.MustRegister(HighestCompleted)
 
+	// Block times
+	prometheus.MustRegister(BlockTime)
+	prometheus.MustRegister(LastBlockTimestamp)
+	prometheus.MustRegister(SlowMessages)
+	prometheus.MustRegister(ForkDetected)
+
 	// TPS
 	prometheus.MustRegister(TotalTransactionPerSecond)
 	prometheus.MustRegister(InstantTransactionPerSecond)
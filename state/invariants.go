@@ -0,0 +1,199 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/events"
+)
+
+// With CheckInvariants set, the node checks after each block that what must
+// always hold still does, and crashes with a dump of what it knows if not.
+// It is for test networks, where a bug is better found at the block that
+// caused it than many blocks later.
+
+// factoidSupply returns the factoids held by all the addresses, in factoshis.
+func (s *State) factoidSupply() int64 {
+	s.FactoidBalancesPMutex.Lock()
+	defer s.FactoidBalancesPMutex.Unlock()
+	var supply int64
+	for _, v := range s.FactoidBalancesP {
+		supply += v
+	}
+	return supply
+}
+
+// checkBalanceInvariants checks the balances once the factoid and entry
+// credit blocks have been applied to them: none is negative, and the
+// factoids held changed by just what the factoid block paid out, given what
+// they were before it.  Only the coinbase may pay out more than it takes in.
+func (s *State) checkBalanceInvariants(d *DBState, supplyBefore int64) {
+	dbheight := d.DirectoryBlock.GetHeader().GetDBHeight()
+
+	s.FactoidBalancesPMutex.Lock()
+	for adr, v := range s.FactoidBalancesP {
+		if v < 0 {
+			s.FactoidBalancesPMutex.Unlock()
+			s.invariantViolated(d, "The factoid address %x has a negative balance of %d", adr, v)
+		}
+	}
+	s.FactoidBalancesPMutex.Unlock()
+
+	s.ECBalancesPMutex.Lock()
+	for adr, v := range s.ECBalancesP {
+		if v < 0 {
+			s.ECBalancesPMutex.Unlock()
+			s.invariantViolated(d, "The entry credit address %x has a negative balance of %d", adr, v)
+		}
+	}
+	s.ECBalancesPMutex.Unlock()
+
+	// A block that doesn't validate is not applied to the balances
+	var paidOut int64
+	if d.FactoidBlock.Validate() == nil {
+		var err error
+		paidOut, err = FactoidsPaidOut(d.FactoidBlock)
+		if err != nil {
+			s.invariantViolated(d, "At height %d %v", dbheight, err)
+		}
+	}
+	if supply := s.factoidSupply(); supply != supplyBefore+paidOut {
+		s.invariantViolated(d, "The factoids held went from %d to %d at height %d, when the factoid block changed them by %d",
+			supplyBefore, supply, dbheight, paidOut)
+	}
+}
+
+// FactoidsPaidOut returns how much the factoids held change by with the
+// factoid block: what its transactions pay out, less what they take in.  It is
+// an error for any transaction but the coinbase to pay out more than it takes.
+func FactoidsPaidOut(fblock interfaces.IFBlock) (int64, error) {
+	var paidOut int64
+	for i, tx := range fblock.GetTransactions() {
+		var in, out int64
+		for _, input := range tx.GetInputs() {
+			in += int64(input.GetAmount())
+		}
+		for _, output := range tx.GetOutputs() {
+			out += int64(output.GetAmount())
+		}
+		if i > 0 && out > in {
+			return 0, fmt.Errorf("the transaction %s pays out %d factoshis more than it takes in", tx.GetSigHash().String(), out-in)
+		}
+		paidOut += out - in
+	}
+	return paidOut, nil
+}
+
+// checkSavedInvariants checks a block just saved: the database has it at its
+// height, along with the blocks it lists, and the entry blocks the process
+// list built for it; and the acknowledgements of the process list are each
+// chained to the one before.
+func (s *State) checkSavedInvariants(d *DBState, pl *ProcessList, plEBlocks map[[32]byte]interfaces.IEntryBlock) {
+	dblock := d.DirectoryBlock
+	dbheight := dblock.GetHeader().GetDBHeight()
+
+	keyMR, err := s.DB.FetchDBKeyMRByHeight(dbheight)
+	if err != nil || keyMR == nil || !keyMR.IsSameAs(dblock.GetKeyMR()) {
+		s.invariantViolated(d, "The database has %v at height %d, not the directory block %s just saved (%v)",
+			keyMR, dbheight, dblock.GetKeyMR().String(), err)
+	}
+
+	listed := map[[32]byte]bool{}
+	for _, e := range dblock.GetDBEntries() {
+		listed[e.GetKeyMR().Fixed()] = true
+		var found bool
+		chainID := e.GetChainID().Bytes()
+		switch {
+		case bytes.Equal(chainID, constants.ADMIN_CHAINID):
+			b, err := s.DB.FetchABlock(e.GetKeyMR())
+			found = err == nil && b != nil
+		case bytes.Equal(chainID, constants.FACTOID_CHAINID):
+			b, err := s.DB.FetchFBlock(e.GetKeyMR())
+			found = err == nil && b != nil
+		case bytes.Equal(chainID, constants.EC_CHAINID):
+			b, err := s.DB.FetchECBlock(e.GetKeyMR())
+			found = err == nil && b != nil
+		default:
+			b, err := s.DB.FetchEBlock(e.GetKeyMR())
+			found = err == nil && b != nil
+		}
+		if !found {
+			s.invariantViolated(d, "The block %s of chain %x, listed by the directory block at height %d, is not in the database",
+				e.GetKeyMR().String(), chainID, dbheight)
+		}
+	}
+
+	for _, eb := range plEBlocks {
+		keyMR, err := eb.KeyMR()
+		if err != nil {
+			s.invariantViolated(d, "An entry block of the process list at height %d has no KeyMR: %v", dbheight, err)
+		}
+		if !listed[keyMR.Fixed()] {
+			s.invariantViolated(d, "The entry block %s of the process list at height %d is not in its directory block",
+				keyMR.String(), dbheight)
+		}
+		for _, h := range eb.GetBody().GetEBEntries() {
+			if ok, err := s.DB.DoesEntryExist(h); err != nil || !ok {
+				s.invariantViolated(d, "The entry %s of the entry block %s at height %d is not in the database",
+					h.String(), keyMR.String(), dbheight)
+			}
+		}
+	}
+
+	if pl == nil {
+		return
+	}
+	for i, vm := range pl.VMs {
+		for j := 0; j < vm.Height && j < len(vm.ListAck); j++ {
+			ack := vm.ListAck[j]
+			if ack == nil {
+				s.invariantViolated(d, "VM %d at height %d has processed a message %d with no acknowledgement", i, dbheight, j)
+			}
+			if ack.DBHeight != dbheight || ack.Height != uint32(j) {
+				s.invariantViolated(d, "VM %d at height %d has the acknowledgement for height %d, position %d in position %d",
+					i, dbheight, ack.DBHeight, ack.Height, j)
+			}
+			if j == 0 {
+				continue
+			}
+			serial, err := primitives.CreateHash(vm.ListAck[j-1].MessageHash, ack.MessageHash)
+			if err != nil || !serial.IsSameAs(ack.SerialHash) {
+				s.invariantViolated(d, "VM %d at height %d has an acknowledgement in position %d with the serial hash %v, not %v",
+					i, dbheight, j, ack.SerialHash, serial)
+			}
+		}
+	}
+}
+
+// invariantViolated prints what the node knows of the block and its state,
+// raises an alert, and crashes.
+func (s *State) invariantViolated(d *DBState, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	dbheight := d.DirectoryBlock.GetHeader().GetDBHeight()
+
+	dump := new(bytes.Buffer)
+	fmt.Fprintf(dump, "\n=== Invariant violated on %s: %s ===\n", s.FactomNodeName, msg)
+	fmt.Fprintf(dump, "%s\n", s.String())
+	fmt.Fprintf(dump, "Directory block:\n%s\n", d.DirectoryBlock.String())
+	if pl := s.ProcessLists.Get(dbheight); pl != nil {
+		fmt.Fprintf(dump, "Process list:\n%s\n", pl.String())
+	}
+	fmt.Fprintf(dump, "Factoid supply: %d  Balance hash: %v\n", s.factoidSupply(), s.Balancehash)
+	os.Stderr.WriteString(dump.String())
+
+	s.Alert(&events.Event{Type: events.InvariantViolated, Height: dbheight}, "%s", msg)
+	s.Events.Flush(AlertFlushTimeout)
+	panic(invariantViolation(fmt.Sprintf("Invariant violated on %s at height %d: %s", s.FactomNodeName, dbheight, msg)))
+}
+
+// invariantViolation is what the node panics with when an invariant doesn't
+// hold, so that it isn't taken for a failure to save the block.
+type invariantViolation string
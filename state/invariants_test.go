@@ -0,0 +1,34 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestFactoidsPaidOut(t *testing.T) {
+	fblock := testHelper.CreateTestFactoidBlock(nil).(*factoid.FBlock)
+	in, err := fblock.GetTransactions()[1].GetInput(0)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// The coinbase pays out, and the purchase of entry credits takes in
+	paidOut, err := FactoidsPaidOut(fblock)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if expected := int64(testHelper.DefaultCoinbaseAmount) - int64(in.GetAmount()); paidOut != expected {
+		t.Errorf("Paid out %d, expected %d", paidOut, expected)
+	}
+
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(1), 1)
+	tx.AddOutput(testHelper.NewFactoidAddress(2), 2)
+	fblock.Transactions = append(fblock.Transactions, tx)
+	if _, err := FactoidsPaidOut(fblock); err == nil {
+		t.Errorf("A transaction paying out more than it takes in was let through")
+	}
+}
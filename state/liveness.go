@@ -0,0 +1,53 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/liveness"
+)
+
+// recordLiveness has an audit server of the saved block record what it saw
+// of the federated servers in it, if the node records liveness.  Blocks
+// saved without their process list, while syncing, weren't seen, and aren't
+// recorded.
+func (s *State) recordLiveness(d *DBState, pl *ProcessList) {
+	if s.Liveness == nil || pl == nil || !s.Liveness.Due(pl.DBHeight) {
+		return
+	}
+	if found, _ := pl.GetAuditServerIndexHash(s.IdentityChainID); !found {
+		return
+	}
+	s.Liveness.Record(livenessObservation(s, d, pl))
+}
+
+func livenessObservation(s *State, d *DBState, pl *ProcessList) *liveness.Observation {
+	signed := make(map[[32]byte]bool)
+	if d.AdminBlock != nil {
+		for _, e := range d.AdminBlock.GetABEntries() {
+			if sig, ok := e.(*adminBlock.DBSignatureEntry); ok {
+				signed[sig.IdentityAdminChainID.Fixed()] = true
+			}
+		}
+	}
+
+	o := new(liveness.Observation)
+	o.Height = pl.DBHeight
+	o.Auditor = s.IdentityChainID.String()
+	o.Time = s.GetTimestamp().GetTimeMilli()
+	for _, fed := range pl.FedServers {
+		chainID := fed.GetChainID()
+		server := liveness.Server{ChainID: chainID.String(), VMIndex: -1}
+		server.SignedPrevious = signed[chainID.Fixed()]
+		if found, vmIndex := pl.GetVirtualServers(0, chainID); found && vmIndex < len(pl.VMs) {
+			vm := pl.VMs[vmIndex]
+			server.VMIndex = vmIndex
+			server.Messages = vm.Height
+			server.Faulted = vm.WhenFaulted > 0
+		}
+		o.Servers = append(o.Servers, server)
+	}
+	return o
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
 )
 
 var _ = fmt.Print
@@ -36,6 +37,22 @@ func LoadDatabase(s *State) {
 		blkCnt = head.GetHeader().GetDBHeight()
 	}
 
+	// A node that started at a checkpoint starts from it again, as nothing
+	// below it is in the database
+	var base uint32
+	if head != nil {
+		if base, err = s.loadCheckpoint(); err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("%20s Could not load the checkpoint the database starts at: %s\n", s.FactomNodeName, err.Error()))
+		}
+	}
+
+	// The transactions of the blocks saved before a restart can't be replayed
+	if fs, ok := s.FactoidState.(*FactoidState); ok && head != nil {
+		if err := fs.RebuildRecent(blkCnt); err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("%20s Could not load the recent transactions: %s\n", s.FactomNodeName, err.Error()))
+		}
+	}
+
 	last := time.Now()
 
 	//msg, err := s.LoadDBState(blkCnt)
@@ -43,6 +60,9 @@ func LoadDatabase(s *State) {
 	if start > 10 {
 		start = start - 10
 	}
+	if base > 0 && start <= base {
+		start = base + 1
+	}
 
 	for i := int(start); i <= int(blkCnt); i++ {
 		if i > 0 && i%1000 == 0 {
@@ -74,6 +94,33 @@ func LoadDatabase(s *State) {
 		s.Print("\r", "\\|/-"[i%4:i%4+1])
 	}
 
+	if blkCnt > 0 {
+		if err := s.RestoreProcessList(blkCnt); err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("%20s Could not restore the process list: %s\n", s.FactomNodeName, err.Error()))
+		}
+	}
+
+	// Commits paid for before a restart still wait for their reveals
+	if err := s.Commits.Load(s.DB, s.GetTimestamp()); err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("%20s Could not load the pending commits: %s\n", s.FactomNodeName, err.Error()))
+	}
+	go s.expireCommits()
+
+	// The anchors of the anchor records are looked up, and the state diffs of
+	// the blocks saved before they were kept are recorded, out of the way of
+	// saving blocks
+	if dbo, ok := s.DB.(*databaseOverlay.Overlay); ok {
+		go dbo.VerifyAnchorInfo()
+		go dbo.IndexStateDiffs()
+	}
+
+	if head == nil && s.StartAt != nil {
+		// The blocks start at the trusted checkpoint, once a peer sends it
+		s.AwaitingSnapshot = true
+		s.Println(fmt.Sprintf("New database on %s, starting at block %d", s.FactomNodeName, s.StartAt.DBHeight))
+		return
+	}
+
 	if blkCnt == 0 {
 		s.Println("\n***********************************")
 		s.Println("******* New Database **************")
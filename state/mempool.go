@@ -0,0 +1,263 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// Defaults for the factoid transaction mempool
+const (
+	MempoolMaxTransactions = 10000
+	MempoolMaxBytes        = 10 * 1024 * 1024
+	MempoolExpiry          = time.Hour
+)
+
+// The errors Mempool.Add returns for transactions it does not take
+var (
+	ErrMempoolDuplicate = fmt.Errorf("Transaction is already in the mempool")
+	ErrMempoolFull      = fmt.Errorf("Mempool is full")
+)
+
+// mempoolTx is a transaction waiting in the mempool.
+type mempoolTx struct {
+	msg   *messages.FactoidTransaction
+	size  int
+	rate  uint64 // Fee paid, in factoshis per KB
+	added time.Time
+}
+
+// Mempool holds the factoid transactions that have been validated, but are
+// not yet in a block, for the leader to assemble the factoid block from.
+// Transactions are unique by transaction ID, and come out of the pool in the
+// order they went in, save that a transaction spending from an address
+// another pooled transaction pays to waits until that one is in the block.
+// When the pool is full, a transaction paying a higher fee rate evicts those
+// paying the lowest.
+type Mempool struct {
+	mutex sync.Mutex
+	txs   map[[32]byte]*mempoolTx
+	order []*mempoolTx // Oldest first
+	bytes int
+
+	MaxTransactions int           // The most transactions held
+	MaxBytes        int           // The most bytes of transactions held
+	Expiry          time.Duration // How long a transaction may wait for a block
+	Clock           interfaces.IClock
+}
+
+func NewMempool(maxTransactions int, maxBytes int, expiry time.Duration) *Mempool {
+	m := new(Mempool)
+	m.txs = make(map[[32]byte]*mempoolTx)
+	m.MaxTransactions = maxTransactions
+	m.MaxBytes = maxBytes
+	m.Expiry = expiry
+	m.Clock = primitives.WallClock{}
+	return m
+}
+
+// Add puts a validated transaction in the pool, if it pays the fee it owes
+// at the given exchange rate.  If the pool is full, the transactions paying
+// a lower fee rate are evicted to make room, lowest first.
+func (m *Mempool) Add(msg *messages.FactoidTransaction, factoshisPerEC uint64) error {
+	err := factoid.ValidateFee(msg.Transaction, factoshisPerEC)
+	if err != nil {
+		return err
+	}
+	data, err := msg.Transaction.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	paid, err := feePaid(msg.Transaction)
+	if err != nil {
+		return err
+	}
+	txid := msg.Transaction.GetSigHash().Fixed()
+	tx := &mempoolTx{msg: msg, size: len(data), rate: paid * 1024 / uint64(len(data))}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.txs[txid]; ok {
+		return ErrMempoolDuplicate
+	}
+	if tx.size > m.MaxBytes {
+		return ErrMempoolFull
+	}
+	for len(m.order) >= m.MaxTransactions || m.bytes+tx.size > m.MaxBytes {
+		var lowest *mempoolTx
+		for _, pooled := range m.order {
+			if lowest == nil || pooled.rate < lowest.rate {
+				lowest = pooled
+			}
+		}
+		if lowest == nil || lowest.rate >= tx.rate {
+			return ErrMempoolFull
+		}
+		m.removeIf(func(pooled *mempoolTx) bool { return pooled == lowest })
+	}
+	tx.added = m.Clock.Now()
+	m.txs[txid] = tx
+	m.order = append(m.order, tx)
+	m.bytes += tx.size
+	return nil
+}
+
+// feePaid returns what the transaction pays beyond its outputs, in
+// factoshis.
+func feePaid(trans interfaces.ITransaction) (uint64, error) {
+	inputs, err := trans.TotalInputs()
+	if err != nil {
+		return 0, err
+	}
+	outputs, err := trans.TotalOutputs()
+	if err != nil {
+		return 0, err
+	}
+	ecs, err := trans.TotalECs()
+	if err != nil {
+		return 0, err
+	}
+	if inputs < outputs+ecs {
+		return 0, nil
+	}
+	return inputs - outputs - ecs, nil
+}
+
+// Pays returns true if a transaction in the pool pays to the factoid
+// address.
+func (m *Mempool) Pays(address [32]byte) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, tx := range m.order {
+		for _, output := range tx.msg.Transaction.GetOutputs() {
+			if output.GetAddress().Fixed() == address {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Get returns the pooled transaction with the given transaction ID, or nil.
+func (m *Mempool) Get(txid interfaces.IHash) *messages.FactoidTransaction {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if tx, ok := m.txs[txid.Fixed()]; ok {
+		return tx.msg
+	}
+	return nil
+}
+
+// Len returns the number of transactions in the pool.
+func (m *Mempool) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.order)
+}
+
+// Bytes returns the size of the transactions in the pool.
+func (m *Mempool) Bytes() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.bytes
+}
+
+// Expire drops the transactions that have waited longer than the Expiry,
+// and returns how many were dropped.
+func (m *Mempool) Expire(now time.Time) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.removeIf(func(tx *mempoolTx) bool {
+		return now.Sub(tx.added) > m.Expiry
+	})
+}
+
+// Next takes out of the pool the transactions that can go into the block
+// now, in order, given the balances of the factoid addresses.  A transaction
+// stays in the pool if its inputs are not yet funded, counting what the
+// transactions ahead of it spend, or if it spends from an address that a
+// transaction still in the pool pays to.
+func (m *Mempool) Next(balance func(address [32]byte) int64) []*messages.FactoidTransaction {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	spent := make(map[[32]byte]int64)
+	paid := make(map[[32]byte]bool) // Addresses paid by waiting transactions
+	var next []*messages.FactoidTransaction
+
+	m.removeIf(func(tx *mempoolTx) bool {
+		trans := tx.msg.Transaction
+		ready := true
+		needs := make(map[[32]byte]int64)
+		for _, input := range trans.GetInputs() {
+			adr := input.GetAddress().Fixed()
+			needs[adr] += int64(input.GetAmount())
+			if paid[adr] {
+				ready = false
+			}
+		}
+		for adr, amount := range needs {
+			if balance(adr)-spent[adr] < amount {
+				ready = false
+			}
+		}
+		if !ready {
+			for _, output := range trans.GetOutputs() {
+				paid[output.GetAddress().Fixed()] = true
+			}
+			return false
+		}
+		for adr, amount := range needs {
+			spent[adr] += amount
+		}
+		next = append(next, tx.msg)
+		return true
+	})
+	return next
+}
+
+// Drain takes every transaction out of the pool, in order.
+func (m *Mempool) Drain() []*messages.FactoidTransaction {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var all []*messages.FactoidTransaction
+	m.removeIf(func(tx *mempoolTx) bool {
+		all = append(all, tx.msg)
+		return true
+	})
+	return all
+}
+
+// removeIf drops the transactions, in order, for which remove is true, and
+// returns how many were dropped.  The caller holds the mutex.
+func (m *Mempool) removeIf(remove func(tx *mempoolTx) bool) int {
+	kept := m.order[:0]
+	for _, tx := range m.order {
+		if remove(tx) {
+			delete(m.txs, tx.msg.Transaction.GetSigHash().Fixed())
+			m.bytes -= tx.size
+			continue
+		}
+		kept = append(kept, tx)
+	}
+	for i := len(kept); i < len(m.order); i++ {
+		m.order[i] = nil
+	}
+	dropped := len(m.order) - len(kept)
+	m.order = kept
+	return dropped
+}
@@ -0,0 +1,198 @@
+package state_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func newMempoolTx(from uint64, to uint64, amount uint64) *messages.FactoidTransaction {
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(from), amount)
+	tx.AddOutput(testHelper.NewFactoidAddress(to), amount)
+	msg := new(messages.FactoidTransaction)
+	msg.Transaction = tx
+	return msg
+}
+
+func TestMempoolAdd(t *testing.T) {
+	m := NewMempool(2, MempoolMaxBytes, MempoolExpiry)
+	a := newMempoolTx(0, 1, 100)
+	if err := m.Add(a, 0); err != nil {
+		t.Errorf("%v", err)
+	}
+	if err := m.Add(newMempoolTx(0, 1, 100), 0); err != ErrMempoolDuplicate {
+		t.Errorf("Expected a duplicate, got %v", err)
+	}
+	if err := m.Add(newMempoolTx(0, 1, 200), 0); err != nil {
+		t.Errorf("%v", err)
+	}
+	if err := m.Add(newMempoolTx(0, 1, 300), 0); err != ErrMempoolFull {
+		t.Errorf("Expected a full mempool, got %v", err)
+	}
+	if m.Len() != 2 {
+		t.Errorf("Expected 2 transactions, got %d", m.Len())
+	}
+	if m.Get(a.Transaction.GetSigHash()) != a {
+		t.Errorf("Expected to find the transaction by its ID")
+	}
+
+	small := NewMempool(10, 1, MempoolExpiry)
+	if err := small.Add(a, 0); err != ErrMempoolFull {
+		t.Errorf("Expected a full mempool, got %v", err)
+	}
+}
+
+func TestMempoolFee(t *testing.T) {
+	m := NewMempool(10, MempoolMaxBytes, MempoolExpiry)
+	if err := m.Add(newMempoolTx(0, 1, 100), 1000); err == nil {
+		t.Errorf("Expected a transaction paying no fee to be rejected")
+	}
+
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(0), 100000)
+	tx.AddOutput(testHelper.NewFactoidAddress(1), 100)
+	msg := new(messages.FactoidTransaction)
+	msg.Transaction = tx
+	fee, err := tx.CalculateFee(1000)
+	if err != nil || fee > 100000-100 {
+		t.Errorf("Expected the fee to be covered, got %d, %v", fee, err)
+	}
+	if err := m.Add(msg, 1000); err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestMempoolExpire(t *testing.T) {
+	m := NewMempool(10, MempoolMaxBytes, time.Minute)
+	m.Add(newMempoolTx(0, 1, 100), 0)
+	if n := m.Expire(time.Now()); n != 0 {
+		t.Errorf("Expected nothing to expire, got %d", n)
+	}
+	if n := m.Expire(time.Now().Add(2 * time.Minute)); n != 1 || m.Len() != 0 || m.Bytes() != 0 {
+		t.Errorf("Expected the transaction to expire, got %d, %d left", n, m.Len())
+	}
+}
+
+func TestMempoolNext(t *testing.T) {
+	m := NewMempool(10, MempoolMaxBytes, MempoolExpiry)
+	a := newMempoolTx(0, 1, 100) // Funded
+	b := newMempoolTx(1, 2, 150) // Needs a
+	c := newMempoolTx(0, 3, 50)  // Funded, after a
+	d := newMempoolTx(0, 3, 60)  // Overdraws 0, after a and c
+	for _, tx := range []*messages.FactoidTransaction{a, b, c, d} {
+		m.Add(tx, 0)
+	}
+
+	balances := map[[32]byte]int64{
+		testHelper.NewFactoidAddress(0).Fixed(): 200,
+		testHelper.NewFactoidAddress(1).Fixed(): 60,
+	}
+	balance := func(address [32]byte) int64 { return balances[address] }
+
+	next := m.Next(balance)
+	if len(next) != 2 || next[0] != a || next[1] != c {
+		t.Errorf("Expected a and c, got %d transactions", len(next))
+	}
+	if m.Len() != 2 {
+		t.Errorf("Expected b and d to wait, got %d", m.Len())
+	}
+
+	// Once a and c are in the block, b is funded
+	balances[testHelper.NewFactoidAddress(0).Fixed()] = 50
+	balances[testHelper.NewFactoidAddress(1).Fixed()] = 160
+	next = m.Next(balance)
+	if len(next) != 1 || next[0] != b {
+		t.Errorf("Expected b, got %d transactions", len(next))
+	}
+	if all := m.Drain(); len(all) != 1 || all[0] != d || m.Len() != 0 {
+		t.Errorf("Expected d to be drained")
+	}
+}
+
+func TestMempoolEvict(t *testing.T) {
+	m := NewMempool(2, MempoolMaxBytes, MempoolExpiry)
+	low := newMempoolTx(0, 1, 100)
+	m.Add(low, 0)
+	m.Add(newMempoolTx(0, 1, 200), 0)
+
+	paying := newMempoolTx(2, 3, 100)
+	paying.Transaction.(*factoid.Transaction).Inputs[0].SetAmount(5000)
+	if err := m.Add(paying, 0); err != nil {
+		t.Fatalf("A transaction paying a fee did not evict one paying none: %v", err)
+	}
+	if m.Len() != 2 || m.Get(paying.Transaction.GetSigHash()) == nil {
+		t.Errorf("Expected the paying transaction in the pool")
+	}
+	if m.Get(low.Transaction.GetSigHash()) != nil && m.Get(newMempoolTx(0, 1, 200).Transaction.GetSigHash()) != nil {
+		t.Errorf("Expected a transaction paying no fee evicted")
+	}
+	if err := m.Add(newMempoolTx(0, 1, 300), 0); err != ErrMempoolFull {
+		t.Errorf("A transaction paying no fee evicted one paying more, got %v", err)
+	}
+	if !m.Pays(testHelper.NewFactoidAddress(3).Fixed()) || m.Pays(testHelper.NewFactoidAddress(2).Fixed()) {
+		t.Errorf("Wrong addresses paid by the pool")
+	}
+}
+
+// newSignedTx returns a transaction of an amount from an address to another,
+// signed, and paying the fee at the exchange rate of the state.
+func newSignedTx(s *State, from uint64, to uint64, amount uint64) *messages.FactoidTransaction {
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(from), amount)
+	tx.AddOutput(testHelper.NewFactoidAddress(to), amount)
+	tx.SetTimestamp(s.GetTimestamp())
+	fee, err := tx.CalculateFee(s.GetFactoshisPerEC())
+	if err != nil {
+		panic(err)
+	}
+	tx.Inputs[0].SetAmount(amount + fee*2)
+	testHelper.SignFactoidTransaction(from, tx)
+	msg := new(messages.FactoidTransaction)
+	msg.Transaction = tx
+	msg.SetLocal(true)
+	return msg
+}
+
+func TestExecuteMsgMempool(t *testing.T) {
+	s := testHelper.CreateAndPopulateTestState()
+	s.ProcessLists.Mutex.Lock()
+	defer s.ProcessLists.Mutex.Unlock()
+	defer func() { s.RunLeader, s.Leader = false, false }()
+
+	s.RunLeader, s.Leader, s.Saving, s.Syncing = true, true, false, false
+	vm := &VM{List: []interfaces.IMsg{nil}, Height: 1}
+	unfunded := testHelper.NewFactoidAddress(7).Fixed()
+	if s.FactoidState.GetSpendableFactoidBalance(unfunded) != 0 {
+		t.Fatalf("Address 7 is funded")
+	}
+
+	// Unfunded, and nothing in the pool funds it, so it waits in holding
+	a := newSignedTx(s, 7, 8, 100)
+	s.ExecuteMsg(vm, a)
+	if s.FactoidMempool.Len() != 0 || s.Holding[a.GetMsgHash().Fixed()] == nil {
+		t.Errorf("An unfunded transaction went into the mempool")
+	}
+
+	// Unfunded, but a pooled transaction pays its address
+	if err := s.FactoidMempool.Add(newMempoolTx(9, 7, 1000000), 0); err != nil {
+		t.Fatal(err)
+	}
+	b := newSignedTx(s, 7, 8, 200)
+	s.ExecuteMsg(vm, b)
+	if s.FactoidMempool.Get(b.Transaction.GetSigHash()) == nil {
+		t.Errorf("A transaction funded by the mempool is not in it")
+	}
+
+	// Another node leads the factoid VM, so the pool waits in holding for its acks
+	s.Leader = false
+	s.AssembleFactoidTransactions(vm)
+	if s.FactoidMempool.Len() != 0 || s.Holding[b.GetMsgHash().Fixed()] == nil {
+		t.Errorf("The mempool was not drained into holding")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+// ParseMigrateDB parses the argument of the migratedb flag, of the form
+// "from=bolt to=ldb", and returns the normalized source and destination types.
+func ParseMigrateDB(arg string) (from string, to string, err error) {
+	for _, f := range strings.Fields(strings.Replace(arg, ",", " ", -1)) {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return "", "", fmt.Errorf("Invalid migratedb argument %q, expected from=<db> to=<db>", f)
+		}
+		switch strings.ToLower(kv[0]) {
+		case "from":
+			from = NormalizeDBType(kv[1])
+		case "to":
+			to = NormalizeDBType(kv[1])
+		default:
+			return "", "", fmt.Errorf("Invalid migratedb argument %q, expected from=<db> to=<db>", f)
+		}
+	}
+
+	if from == "" || to == "" {
+		return "", "", fmt.Errorf("migratedb needs both from=<db> and to=<db>")
+	}
+	for _, t := range []string{from, to} {
+		if t != "LDB" && t != "Bolt" {
+			return "", "", fmt.Errorf("Cannot migrate with database type %q, only ldb and bolt are persistent", t)
+		}
+	}
+	if from == to {
+		return "", "", fmt.Errorf("migratedb source and destination are both %s", from)
+	}
+	return from, to, nil
+}
+
+// openDB opens the persistent database of the given (normalized) type at the
+// location given by the configuration, for the configured network.
+func (s *State) openDB(dbType string) (interfaces.IDatabase, error) {
+	if err := s.setNetworkNumber(); err != nil {
+		return nil, err
+	}
+	switch dbType {
+	case "LDB":
+		return s.openLevelDB()
+	case "Bolt":
+		return s.openBoltDB()
+	}
+	return nil, fmt.Errorf("Cannot open database type %q", dbType)
+}
+
+// MigrateDB copies every bucket and key of the from database into the to
+// database, then reads everything back to verify the copy.  Progress is
+// written to out.  The node's own database (s.DB) is not touched.
+func (s *State) MigrateDB(from, to string, out io.Writer) error {
+	src, err := s.openDB(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := s.openDB(to)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	fmt.Fprintf(out, "Migrating database from %s to %s\n", from, to)
+	progress := snapshotProgress(out)
+
+	copied, err := databaseOverlay.CopyDatabase(src, dst, progress)
+	if err != nil {
+		return fmt.Errorf("Migration failed after %d keys: %v", copied, err)
+	}
+	fmt.Fprintf(out, "Copied %d keys, verifying\n", copied)
+
+	checked, err := databaseOverlay.VerifyCopy(src, dst, progress)
+	if err != nil {
+		return fmt.Errorf("Verification failed after %d keys: %v", checked, err)
+	}
+	fmt.Fprintf(out, "Verified %d keys, migration from %s to %s complete\n", checked, from, to)
+	return nil
+}
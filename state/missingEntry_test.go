@@ -7,7 +7,10 @@ package state_test
 import (
 	"testing"
 
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
 	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
 )
 
 func TestMissingEntryBlockMarshalUnmarshal(t *testing.T) {
@@ -51,3 +54,47 @@ func TestMissingEntryMarshalUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestMissingEntryBlockTracking(t *testing.T) {
+	s := testHelper.CreateAndPopulateTestState()
+
+	var height uint32
+	var ebKeyMR interfaces.IHash
+	for h := uint32(1); h <= s.GetHighestSavedBlk() && ebKeyMR == nil; h++ {
+		dblock, err := s.DB.FetchDBlockByHeight(h)
+		if err != nil || dblock == nil {
+			t.Fatalf("No directory block %d: %v", h, err)
+		}
+		if hashes := dblock.GetEntryHashes(); len(hashes) > 3 {
+			height, ebKeyMR = h, hashes[3]
+		}
+	}
+	if ebKeyMR == nil {
+		t.Fatal("No entry block in the test blocks")
+	}
+	eblock, err := s.DB.FetchEBlock(ebKeyMR)
+	if err != nil || eblock == nil {
+		t.Fatalf("No entry block %s: %v", ebKeyMR.String(), err)
+	}
+
+	// Asking twice lists the block once
+	s.AskForEntryBlock(ebKeyMR, height)
+	s.AskForEntryBlock(ebKeyMR, height)
+	missing := s.GetMissingEntryBlocks()
+	if len(missing) != 1 || !missing[0].EBHash.IsSameAs(ebKeyMR) || missing[0].DBHeight != height {
+		t.Fatalf("Missing entry blocks are %v, expected %s at %d", missing, ebKeyMR.String(), height)
+	}
+
+	// Some other entry block doesn't complete it
+	other, _ := testHelper.CreateTestEntryBlockWithContentN(nil, 12345)
+	s.FollowerExecuteDataResponse(messages.NewDataResponse(s, other, 1, other.DatabasePrimaryIndex()))
+	if len(s.GetMissingEntryBlocks()) != 1 {
+		t.Errorf("An entry block not asked for completed the missing one")
+	}
+
+	// The block asked for does
+	s.FollowerExecuteDataResponse(messages.NewDataResponse(s, eblock, 1, ebKeyMR))
+	if missing := s.GetMissingEntryBlocks(); len(missing) != 0 {
+		t.Errorf("The entry block is still missing: %v", missing)
+	}
+}
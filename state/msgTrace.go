@@ -0,0 +1,132 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// The stages of a message that message tracing times
+const (
+	TraceReceived  = "received"  // From a peer
+	TraceValidated = "validated" // Found valid, and executed as leader or follower
+	TraceAcked     = "acked"     // Added to the process list with its ack
+	TraceExecuted  = "executed"  // Processed into the block being built
+	TraceSaved     = "saved"     // The block it is in was saved
+)
+
+// The most messages traced at once
+const maxTracedMessages = 10000
+
+// msgTrace is what is known of a traced message.
+type msgTrace struct {
+	height uint32 // Of the block it was acked into
+	acked  bool
+	stages []interfaces.MsgTraceStage
+}
+
+// msgTraces holds the stages the messages seen have passed, while message
+// tracing is on, to find where messages stall.
+type msgTraces struct {
+	mutex   sync.Mutex
+	enabled bool
+	traces  map[[32]byte]*msgTrace
+	order   [][32]byte // Oldest first, so the oldest is dropped when full
+}
+
+// SetMsgTracing turns message tracing on or off.  Turning it off drops the
+// traces.
+func (s *State) SetMsgTracing(on bool) {
+	t := &s.msgTraces
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.enabled = on
+	if !on {
+		t.traces = nil
+		t.order = nil
+	}
+}
+
+// GetMsgTrace returns the stages the message has passed, in order, or nil if
+// it isn't traced.
+func (s *State) GetMsgTrace(msgHash interfaces.IHash) []interfaces.MsgTraceStage {
+	t := &s.msgTraces
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	trace, ok := t.traces[msgHash.Fixed()]
+	if !ok {
+		return nil
+	}
+	return append([]interfaces.MsgTraceStage(nil), trace.stages...)
+}
+
+// TraceMsg times a stage of the message, if message tracing is on.  Only the
+// first time the message reaches a stage is kept.
+func (s *State) TraceMsg(msg interfaces.IMsg, stage string) {
+	s.traceMsg(msg, stage, 0)
+}
+
+// traceMsgAcked times the ack of the message into the block at the height,
+// which is then saved with the block.
+func (s *State) traceMsgAcked(msg interfaces.IMsg, height uint32) {
+	s.traceMsg(msg, TraceAcked, height)
+}
+
+func (s *State) traceMsg(msg interfaces.IMsg, stage string, height uint32) {
+	t := &s.msgTraces
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.enabled {
+		return
+	}
+
+	hash := msg.GetMsgHash().Fixed()
+	if t.traces == nil {
+		t.traces = map[[32]byte]*msgTrace{}
+	}
+	trace, ok := t.traces[hash]
+	if !ok {
+		if len(t.order) >= maxTracedMessages {
+			delete(t.traces, t.order[0])
+			t.order = t.order[1:]
+		}
+		trace = new(msgTrace)
+		t.traces[hash] = trace
+		t.order = append(t.order, hash)
+	}
+	// Messages are validated again each time holding is looked at, so only
+	// the first time is kept
+	for _, seen := range trace.stages {
+		if seen.Stage == stage {
+			return
+		}
+	}
+	if stage == TraceAcked {
+		trace.height = height
+		trace.acked = true
+	}
+	trace.stages = append(trace.stages, interfaces.MsgTraceStage{Stage: stage, Time: s.GetClock().Now()})
+}
+
+// traceBlockSaved times the saving of the messages acked into the block at
+// the height.
+func (s *State) traceBlockSaved(height uint32) {
+	t := &s.msgTraces
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.enabled {
+		return
+	}
+
+	now := s.GetClock().Now()
+	for _, trace := range t.traces {
+		if trace.acked && trace.height == height {
+			trace.stages = append(trace.stages, interfaces.MsgTraceStage{Stage: TraceSaved, Time: now})
+			trace.acked = false // Saved once
+		}
+	}
+}
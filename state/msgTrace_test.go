@@ -0,0 +1,39 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/state"
+)
+
+func TestMsgTrace(t *testing.T) {
+	s := new(State)
+	eom := new(messages.EOM)
+	eom.Timestamp = primitives.NewTimestampNow()
+	eom.ChainID = primitives.NewZeroHash()
+	eom.DBHeight = 10
+
+	s.TraceMsg(eom, TraceReceived)
+	if s.GetMsgTrace(eom.GetMsgHash()) != nil {
+		t.Errorf("Message traced with tracing off")
+	}
+
+	s.SetMsgTracing(true)
+	s.TraceMsg(eom, TraceReceived)
+	s.TraceMsg(eom, TraceValidated)
+	s.TraceMsg(eom, TraceValidated)
+	stages := s.GetMsgTrace(eom.GetMsgHash())
+	if len(stages) != 2 || stages[0].Stage != TraceReceived || stages[1].Stage != TraceValidated {
+		t.Fatalf("Wrong stages %v", stages)
+	}
+	if stages[1].Time.Before(stages[0].Time) {
+		t.Errorf("Stages out of order %v", stages)
+	}
+
+	s.SetMsgTracing(false)
+	if s.GetMsgTrace(eom.GetMsgHash()) != nil {
+		t.Errorf("Trace kept with tracing off")
+	}
+}
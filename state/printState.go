@@ -40,6 +40,8 @@ func PrintState(state *State) {
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "BoltDBPath", state.BoltDBPath)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "LogLevel", state.LogLevel)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "ConsoleLogLevel", state.ConsoleLogLevel)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "LogFormat", state.LogFormat)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "PackageLogLevels", state.PackageLogLevels)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "NodeMode", state.NodeMode)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "DBType", state.DBType)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "CloneDBType", state.CloneDBType)
@@ -95,6 +97,7 @@ func PrintState(state *State) {
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "inMsgQueue", state.inMsgQueue)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "apiQueue", state.apiQueue)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "ackQueue", state.ackQueue)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "prioritizedMsgQueue", state.prioritizedMsgQueue)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "msgQueue", state.msgQueue)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "ShutdownChan", state.ShutdownChan)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "JournalFile", state.JournalFile)
@@ -134,6 +137,9 @@ func PrintState(state *State) {
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "DBSigDone", state.DBSigDone)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "DBSigSys", state.DBSigSys)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "KeepMismatch", state.KeepMismatch)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "CheckInvariants", state.CheckInvariants)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "FollowerOnly", state.FollowerOnly)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "AckBatchSize", state.AckBatchSize)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "DBSigFails", state.DBSigFails)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "Saving", state.Saving)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "Syncing", state.Syncing)
@@ -150,7 +156,7 @@ func PrintState(state *State) {
 	//str = fmt.Sprintf("%s %35s = %+v\n", str, "Holding", state.Holding)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "XReview", state.XReview)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "Acks", state.Acks)
-	str = fmt.Sprintf("%s %35s = %+v\n", str, "Commits", state.Commits)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "Commits", state.Commits.Copy())
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "InvalidMessages", state.InvalidMessages)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "InvalidMessagesMutex", state.InvalidMessagesMutex)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "AuditHeartBeats", state.AuditHeartBeats)
@@ -181,7 +187,7 @@ func PrintState(state *State) {
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "MissingEntryBlockRepeat", state.MissingEntryBlockRepeat)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "EntryBlockDBHeightComplete", state.EntryBlockDBHeightComplete)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "EntryBlockDBHeightProcessing", state.EntryBlockDBHeightProcessing)
-	str = fmt.Sprintf("%s %35s = %+v\n", str, "MissingEntryBlocks", state.MissingEntryBlocks)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "MissingEntryBlocks", state.GetMissingEntryBlocks())
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "MissingEntryRepeat", state.MissingEntryRepeat)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "EntryDBHeightComplete", state.EntryDBHeightComplete)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "EntryHeightComplete", state.EntryDBHeightComplete)
@@ -6,9 +6,11 @@ package state
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"encoding/binary"
 
@@ -65,6 +67,7 @@ type ProcessList struct {
 	State        *State
 	VMs          []*VM       // Process list for each server (up to 32)
 	ServerMap    [10][64]int // Map of FedServers to all Servers for each minute
+	vmSeed       []byte      // The seed of VMIndex for this block, once known
 	System       VM          // System Faults and other system wide messages
 	SysHighest   int
 	diffSigTally int /* Tally of how many VMs have provided different
@@ -108,6 +111,8 @@ type ProcessList struct {
 	Requests map[[32]byte]*Request
 	//Requests map[[20]byte]*Request
 	NextHeightToProcess [64]int
+
+	Gaps map[int]*Gap // Runs of missing entries we are asking for, by VM.  See gaps.go
 }
 
 var _ interfaces.IProcessList = (*ProcessList)(nil)
@@ -138,7 +143,7 @@ type VM struct {
 	Signed      bool  // We have signed the previous block.
 	WhenFaulted int64 // WhenFaulted is a timestamp of when this VM was faulted
 	// vm.WhenFaulted serves as a bool flag (if > 0, the vm is currently considered faulted)
-	FaultFlag int // FaultFlag tracks what the VM was faulted for (0 = EOM missing, 1 = negotiation issue)
+	FaultFlag int // FaultFlag tracks what the VM was faulted for (0 = EOM missing, 1 = negotiation issue, 2 = gap never filled)
 }
 
 func (p *ProcessList) Clear() {
@@ -231,18 +236,105 @@ func (p *ProcessList) Complete() bool {
 	return true
 }
 
-// Returns the Virtual Server index for this hash for the given minute
-func (p *ProcessList) VMIndexFor(hash []byte) int {
+// VMIndexFor returns the VM the messages of a chain go to in a minute of the
+// block, or -1 if it can't be known yet.  The admin, factoid and entry credit
+// chains count as chains.  See VMIndex; before it activates, a chain goes to
+// the VM of the sum of its bytes.
+func (p *ProcessList) VMIndexFor(minute int, hash []byte) int {
 	if p.State.OneLeader {
 		return 0
 	}
+	if !constants.IsActive(constants.ACTIVATION_VM_INDEX, p.DBHeight) {
+		v := uint64(0)
+		for _, b := range hash {
+			v += uint64(b)
+		}
+		return int(v % uint64(len(p.FedServers)))
+	}
+	seed := p.vmIndexSeed()
+	if seed == nil {
+		return -1
+	}
+	if minute > 9 {
+		minute = 9
+	}
+	return VMIndex(hash, minute, seed, len(p.FedServers))
+}
 
-	v := uint64(0)
-	for _, b := range hash {
-		v += uint64(b)
+// vmIndexChain returns the chain whose VM a message goes to, or nil for
+// messages that are not routed by chain (EOMs, DBSigs, faults).
+func vmIndexChain(m interfaces.IMsg) []byte {
+	switch msg := m.(type) {
+	case *messages.RevealEntryMsg:
+		return msg.Entry.GetChainID().Bytes()
+	case *messages.CommitChainMsg, *messages.CommitEntryMsg:
+		return constants.EC_CHAINID
+	case *messages.FactoidTransaction:
+		return constants.FACTOID_CHAINID
+	case *messages.AddServerMsg, *messages.RemoveServerMsg, *messages.ChangeServerKeyMsg:
+		return constants.ADMIN_CHAINID
+	}
+	return nil
+}
+
+// ValidVMIndex returns whether the ack puts the message in the VM VMIndexFor
+// gives for its chain in the minute of the ack, the minute the leader routed
+// it by, and whether that can be known yet.  A message whose VM can't be
+// known waits, rather than being taken or tossed, as nodes would disagree.
+func (p *ProcessList) ValidVMIndex(ack *messages.Ack, m interfaces.IMsg) (valid bool, known bool) {
+	chain := vmIndexChain(m)
+	if chain == nil || p.State.OneLeader || !constants.IsActive(constants.ACTIVATION_VM_INDEX, p.DBHeight) {
+		return true, true
 	}
-	r := int(v % uint64(len(p.FedServers)))
-	return r
+	vm := p.VMIndexFor(int(ack.Minute), chain)
+	if vm < 0 {
+		return false, false
+	}
+	return vm == ack.VMIndex, true
+}
+
+// VMIndex is the VM of a chain in a minute of a block with vms VMs: the hash
+// of the chain ID, the minute and the seed of the block, modulo vms.  The
+// seed is the keyMR of the directory block two before, the last one every
+// node has saved by the time the block starts, so that all nodes agree, while
+// no one can know where a chain goes long in advance.  Chains move from VM to
+// VM each minute, spreading a busy chain over the leaders.
+func VMIndex(chainID []byte, minute int, seed []byte, vms int) int {
+	if vms <= 0 {
+		return 0
+	}
+	h := sha256.New()
+	h.Write(chainID)
+	h.Write([]byte{byte(minute)})
+	h.Write(seed)
+	sum := h.Sum(nil)
+	return int(binary.BigEndian.Uint64(sum[:8]) % uint64(vms))
+}
+
+// vmIndexSeed returns the seed of VMIndex for the block, or nil until it is
+// known.  It is taken from the directory block two before, held in memory or
+// saved.  Blocks 0 and 1 have no seed.
+func (p *ProcessList) vmIndexSeed() []byte {
+	if p.vmSeed != nil {
+		return p.vmSeed
+	}
+	if p.DBHeight < 2 {
+		p.vmSeed = []byte{}
+		return p.vmSeed
+	}
+	if d := p.State.DBStates.Get(int(p.DBHeight - 2)); d != nil && d.DirectoryBlock != nil {
+		p.vmSeed = d.DirectoryBlock.GetKeyMR().Bytes()
+		return p.vmSeed
+	}
+	if p.State.DB == nil {
+		return nil
+	}
+	keyMR, err := p.State.DB.FetchDBKeyMRByHeight(p.DBHeight - 2)
+	if err != nil || keyMR == nil {
+		return nil
+	}
+	p.vmSeed = keyMR.Bytes()
+	return p.vmSeed
 }
 
 func SortServers(servers []interfaces.IServer) []interfaces.IServer {
@@ -310,7 +402,7 @@ func (p *ProcessList) SortDBSigs() {
 
 // Returns the Federated Server responsible for this hash in this minute
 func (p *ProcessList) FedServerFor(minute int, hash []byte) interfaces.IServer {
-	vs := p.VMIndexFor(hash)
+	vs := p.VMIndexFor(minute, hash)
 	if vs < 0 {
 		return nil
 	}
@@ -724,7 +816,10 @@ func (p *ProcessList) Process(state *State) (progress bool) {
 	for i := 0; i < len(p.FedServers); i++ {
 		vm := p.VMs[i]
 
-		if !p.State.Syncing {
+		if p.closeGaps(i) {
+			// A VM stays faulted until the gap it was faulted for is filled
+			markFault(p, i, GapFaultReason)
+		} else if !p.State.Syncing {
 			markNoFault(p, i)
 		} else {
 			if !vm.Synced {
@@ -754,7 +849,7 @@ func (p *ProcessList) Process(state *State) (progress bool) {
 		for j := vm.Height; j < len(vm.List); j++ {
 			if vm.List[j] == nil {
 				//p.State.AddStatus(fmt.Sprintf("ProcessList.go Process: Found nil list at vm %d vm height %d ", i, j))
-				p.Ask(i, j, 0, 3)
+				p.AskForGap(i, j)
 				break VMListLoop
 			}
 
@@ -811,7 +906,11 @@ func (p *ProcessList) Process(state *State) (progress bool) {
 				// If we can't process this entry (i.e. returns false) then we can't process any more.
 				p.NextHeightToProcess[i] = j + 1
 				msg := vm.List[j]
-				if msg.Process(p.DBHeight, state) { // Try and Process this entry
+				start := time.Now()
+				processed := msg.Process(p.DBHeight, state) // Try and Process this entry
+				state.checkSlowMsg(msg, StepProcess, start)
+				if processed {
+					state.TraceMsg(msg, TraceExecuted)
 					vm.heartBeat = 0
 					vm.Height = j + 1 // Don't process it again if the process worked.
 
@@ -956,6 +1055,13 @@ func (p *ProcessList) AddToProcessList(ack *messages.Ack, m interfaces.IMsg) {
 		delete(p.State.Acks, ack.GetHash().Fixed())
 	}
 
+	if valid, known := p.ValidVMIndex(ack, m); !known {
+		return // Waits in holding until the VM can be known
+	} else if !valid {
+		toss("acked in the wrong VM")
+		return
+	}
+
 	now := p.State.GetTimestamp()
 
 	vm := p.VMs[ack.VMIndex]
@@ -999,6 +1105,7 @@ func (p *ProcessList) AddToProcessList(ack *messages.Ack, m interfaces.IMsg) {
 
 	delete(p.State.Acks, m.GetMsgHash().Fixed())
 	delete(p.State.Holding, m.GetMsgHash().Fixed())
+	p.State.traceMsgAcked(m, ack.DBHeight)
 
 	// Both the ack and the message hash to the same GetHash()
 	m.SetLocal(false)
@@ -1006,7 +1113,7 @@ func (p *ProcessList) AddToProcessList(ack *messages.Ack, m interfaces.IMsg) {
 	ack.SetPeer2Peer(false)
 	m.SetPeer2Peer(false)
 
-	ack.SendOut(p.State, ack)
+	p.State.sendAck(ack)
 	m.SendOut(p.State, m)
 
 	for len(vm.List) <= int(ack.Height) {
@@ -1132,6 +1239,7 @@ func (p *ProcessList) Reset() bool {
 	p.System.Height = 0
 	p.Requests = make(map[[32]byte]*Request)
 	//pl.Requests = make(map[[20]byte]*Request)
+	p.Gaps = make(map[int]*Gap)
 
 	p.FactoidBalancesT = map[[32]byte]int64{}
 	p.ECBalancesT = map[[32]byte]int64{}
@@ -1240,6 +1348,7 @@ func NewProcessList(state interfaces.IState, previous *ProcessList, dbheight uin
 	pl.AuditServers = make([]interfaces.IServer, 0)
 	pl.Requests = make(map[[32]byte]*Request)
 	//pl.Requests = make(map[[20]byte]*Request)
+	pl.Gaps = make(map[int]*Gap)
 
 	pl.FactoidBalancesT = map[[32]byte]int64{}
 	pl.ECBalancesT = map[[32]byte]int64{}
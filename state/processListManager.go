@@ -7,6 +7,7 @@ package state
 import (
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 )
@@ -20,6 +21,10 @@ type ProcessLists struct {
 	Lists        []*ProcessList // Pointer to the ProcessList structure for each DBHeight under construction
 	SetString    bool
 	Str          string
+
+	// Held by the state loop while it processes.  The APIs that read the
+	// lists from outside the loop take the read lock, and use GetSafe.
+	Mutex sync.RWMutex
 }
 
 func (lists *ProcessLists) LastList() *ProcessList {
@@ -0,0 +1,169 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// The messages and acks of the block being built are saved at the end of
+// each minute, so a node restarted mid-block replays them and rejoins at the
+// minute it left, rather than waiting for the block and being faulted.
+
+// ProcessListFilename is the file holding the saved process list of the
+// current block, next to the database.  Map databases have none.
+func (s *State) ProcessListFilename() string {
+	switch s.DBType {
+	case "LDB":
+		return s.LdbPath + "/" + s.Network + "/processlist.dat"
+	case "Bolt":
+		return s.BoltDBPath + "/" + s.Network + "/processlist.dat"
+	}
+	return ""
+}
+
+// MarshalProcessList writes the height of the process list followed by each
+// ack and its message, VM by VM, in the order they were processed.
+func MarshalProcessList(p *ProcessList) ([]byte, error) {
+	buf := new(primitives.Buffer)
+	if err := buf.PushUInt32(p.DBHeight); err != nil {
+		return nil, err
+	}
+
+	var pairs [][2]interfaces.IMsg
+	for _, vm := range p.VMs {
+		for i, msg := range vm.List {
+			if msg == nil || i >= len(vm.ListAck) || vm.ListAck[i] == nil {
+				continue
+			}
+			pairs = append(pairs, [2]interfaces.IMsg{vm.ListAck[i], msg})
+		}
+	}
+
+	if err := buf.PushUInt32(uint32(len(pairs))); err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		for _, m := range pair {
+			b, err := m.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			if err = buf.PushBytes(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+// UnmarshalProcessList reads what MarshalProcessList wrote.
+func UnmarshalProcessList(p []byte) (dbheight uint32, acks []*messages.Ack, msgs []interfaces.IMsg, err error) {
+	buf := primitives.NewBuffer(p)
+	if dbheight, err = buf.PopUInt32(); err != nil {
+		return
+	}
+	l, err := buf.PopUInt32()
+	if err != nil {
+		return
+	}
+	if int(l) > len(p)/2 {
+		err = fmt.Errorf("Process list of %d messages is longer than its data", l)
+		return
+	}
+	for i := 0; i < int(l); i++ {
+		var b []byte
+		var m interfaces.IMsg
+		if b, err = buf.PopBytes(); err != nil {
+			return
+		}
+		if m, err = messages.UnmarshalMessage(b); err != nil {
+			return
+		}
+		ack, ok := m.(*messages.Ack)
+		if !ok {
+			err = fmt.Errorf("Expected an ack at %d, found message type %d", i, m.Type())
+			return
+		}
+		if b, err = buf.PopBytes(); err != nil {
+			return
+		}
+		if m, err = messages.UnmarshalMessage(b); err != nil {
+			return
+		}
+		acks = append(acks, ack)
+		msgs = append(msgs, m)
+	}
+	return
+}
+
+// SaveProcessList saves the process list of the block being built.  It is
+// written to a temporary file and renamed, so a crash leaves either the old
+// file or the new one.
+func (s *State) SaveProcessList() error {
+	filename := s.ProcessListFilename()
+	if filename == "" || s.LeaderPL == nil {
+		return nil
+	}
+	b, err := MarshalProcessList(s.LeaderPL)
+	if err != nil {
+		return err
+	}
+	h := primitives.Sha(b)
+	b = append(h.Bytes(), b...)
+
+	if err = SaveToFile(b, filename+".tmp"); err != nil {
+		return err
+	}
+	return os.Rename(filename+".tmp", filename)
+}
+
+// RestoreProcessList queues the saved acks and messages of the block after
+// the last one in the database, to be processed once the blocks are loaded.
+// A file for any other block is stale and ignored.
+func (s *State) RestoreProcessList(highestSaved uint32) error {
+	filename := s.ProcessListFilename()
+	if filename == "" {
+		return nil
+	}
+	b, err := LoadFromFile(filename)
+	if err != nil || len(b) < 32 {
+		return nil
+	}
+	h := primitives.NewZeroHash()
+	b, err = h.UnmarshalBinaryData(b)
+	if err != nil {
+		return err
+	}
+	if !h.IsSameAs(primitives.Sha(b)) {
+		return fmt.Errorf("Saved process list %s is corrupt", filename)
+	}
+
+	dbheight, acks, msgs, err := UnmarshalProcessList(b)
+	if err != nil {
+		return err
+	}
+	if dbheight != highestSaved+1 {
+		return nil
+	}
+
+	for i, ack := range acks {
+		// Our own acks from before the restart carry the old salt, and
+		// would be taken for another node running with our identity.
+		ack.Response = true
+		// The acks were checked before they were saved, and those of a
+		// batch have no signature of their own.
+		ack.SetAuthValid()
+		s.InMsgQueue().Enqueue(ack)
+		s.InMsgQueue().Enqueue(msgs[i])
+	}
+	s.Println(fmt.Sprintf("Restored %d messages of block %d from %s", len(acks), dbheight, filename))
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/state"
+	. "github.com/FactomProject/factomd/testHelper"
+)
+
+func TestMarshalProcessList(t *testing.T) {
+	s := CreateAndPopulateTestState()
+	pl := s.ProcessLists.Get(s.LLeaderHeight)
+
+	eom := new(messages.EOM)
+	eom.Timestamp = primitives.NewTimestampNow()
+	eom.ChainID = s.IdentityChainID
+	ack := new(messages.Ack)
+	ack.DBHeight = pl.DBHeight
+	ack.Timestamp = eom.Timestamp
+	ack.LeaderChainID = s.IdentityChainID
+	ack.MessageHash = eom.GetMsgHash()
+	ack.SerialHash = ack.MessageHash
+	pl.VMs[0].List = append(pl.VMs[0].List, eom)
+	pl.VMs[0].ListAck = append(pl.VMs[0].ListAck, ack)
+
+	b, err := MarshalProcessList(pl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbheight, acks, msgs, err := UnmarshalProcessList(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dbheight != pl.DBHeight {
+		t.Errorf("Expected height %d, got %d", pl.DBHeight, dbheight)
+	}
+	if len(acks) != 1 || len(msgs) != 1 {
+		t.Fatalf("Expected 1 ack and message, got %d and %d", len(acks), len(msgs))
+	}
+	if !acks[0].MessageHash.IsSameAs(msgs[0].GetMsgHash()) {
+		t.Error("The restored ack does not match its message")
+	}
+
+	if _, _, _, err = UnmarshalProcessList(b[:len(b)-1]); err == nil {
+		t.Error("Expected an error unmarshalling a truncated process list")
+	}
+}
@@ -4,6 +4,16 @@
 
 package state_test
 
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
 /*
 import (
 	"fmt"
@@ -23,3 +33,65 @@ func TestFedServer(t *testing.T) {
 	pl.AddFedServer(primitives.NewHash([]byte("three")))
 }
 */
+
+func TestVMIndex(t *testing.T) {
+	chain := primitives.Sha([]byte("chain")).Bytes()
+	seed := primitives.Sha([]byte("seed")).Bytes()
+
+	if VMIndex(chain, 3, seed, 0) != 0 {
+		t.Error("Expected VM 0 with no VMs")
+	}
+
+	moved := false
+	for minute := 0; minute < 10; minute++ {
+		vm := VMIndex(chain, minute, seed, 5)
+		if vm < 0 || vm >= 5 {
+			t.Errorf("VM %d out of range in minute %d", vm, minute)
+		}
+		if VMIndex(chain, minute, seed, 5) != vm {
+			t.Errorf("VM of minute %d is not deterministic", minute)
+		}
+		if vm != VMIndex(chain, 0, seed, 5) {
+			moved = true
+		}
+	}
+	if !moved {
+		t.Error("Expected the chain to move between VMs over the minutes")
+	}
+}
+
+func TestValidVMIndex(t *testing.T) {
+	defer constants.SetActivationNetwork(constants.NETWORK_LOCAL)
+
+	s := testHelper.CreateAndPopulateTestState()
+	pl := NewProcessList(s, nil, 5)
+	pl.AddFedServer(primitives.Sha([]byte("fed1")))
+	pl.AddFedServer(primitives.Sha([]byte("fed2")))
+
+	msg := new(messages.CommitEntryMsg)
+	ack := new(messages.Ack)
+	ack.Minute = 3
+	ack.VMIndex = pl.VMIndexFor(3, constants.EC_CHAINID)
+	if ack.VMIndex < 0 {
+		t.Fatalf("The VM of block 5 is not known")
+	}
+	if valid, known := pl.ValidVMIndex(ack, msg); !valid || !known {
+		t.Errorf("Ack in the right VM is not valid")
+	}
+	ack.VMIndex = (ack.VMIndex + 1) % 3
+	if valid, known := pl.ValidVMIndex(ack, msg); valid || !known {
+		t.Errorf("Ack in the wrong VM is valid")
+	}
+
+	// The seed of a block two past the last one held is not known
+	future := NewProcessList(s, pl, uint32(testHelper.BlockCount+2))
+	if valid, known := future.ValidVMIndex(ack, msg); valid || known {
+		t.Errorf("The VM of a block with no seed is known")
+	}
+
+	// Before the activation, any VM is taken
+	constants.SetActivationNetwork(constants.NETWORK_MAIN)
+	if valid, known := pl.ValidVMIndex(ack, msg); !valid || !known {
+		t.Errorf("Ack checked before the activation")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// RecentTransactions holds the IDs of the factoid transactions in recent
+// blocks, so that a transaction in one block can't be replayed into a later
+// one.  The replay filter only knows the messages this node has processed
+// itself, within its own window; this knows every block saved, and holds a
+// transaction until its timestamp is too old for any block to take it.  It is
+// rebuilt from the blocks in the database when the node starts.  The zero
+// value is ready to use.
+type RecentTransactions struct {
+	mutex sync.Mutex
+	txs   map[[32]byte]int64 // Timestamps of the transactions, in milliseconds
+}
+
+// Add holds a transaction that has gone into a block.
+func (r *RecentTransactions) Add(trans interfaces.ITransaction) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.txs == nil {
+		r.txs = make(map[[32]byte]int64)
+	}
+	r.txs[trans.GetSigHash().Fixed()] = trans.GetTimestamp().GetTimeMilli()
+}
+
+// AddBlock holds the transactions of a factoid block once it is saved, and
+// drops those too old to go into a block after it.
+func (r *RecentTransactions) AddBlock(blk interfaces.IFBlock) {
+	for i, trans := range blk.GetTransactions() {
+		if i > 0 { // Past the coinbase
+			r.Add(trans)
+		}
+	}
+	r.Expire(blk.GetCoinbaseTimestamp().GetTimeMilli())
+}
+
+// Has is true if the transaction with the ID is in a recent block.
+func (r *RecentTransactions) Has(txid [32]byte) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, ok := r.txs[txid]
+	return ok
+}
+
+// Expire drops the transactions too old to go into a block with the
+// timestamp, in milliseconds, and returns how many were dropped.
+func (r *RecentTransactions) Expire(blockTime int64) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	dropped := 0
+	for txid, ts := range r.txs {
+		if blockTime-ts > constants.TRANSACTION_PRIOR_LIMIT {
+			delete(r.txs, txid)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Len returns the number of transactions held.
+func (r *RecentTransactions) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.txs)
+}
@@ -0,0 +1,58 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestRecentTransactions(t *testing.T) {
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(0), 100)
+	tx.AddOutput(testHelper.NewFactoidAddress(1), 100)
+	tx.SetTimestamp(primitives.NewTimestampFromMilliseconds(1000000))
+
+	recent := new(RecentTransactions)
+	if recent.Has(tx.GetSigHash().Fixed()) {
+		t.Errorf("Found a transaction not yet added")
+	}
+	recent.Add(tx)
+	if !recent.Has(tx.GetSigHash().Fixed()) {
+		t.Errorf("Transaction not found")
+	}
+
+	// Held as long as a block could take it
+	if n := recent.Expire(1000000 + constants.TRANSACTION_PRIOR_LIMIT); n != 0 || !recent.Has(tx.GetSigHash().Fixed()) {
+		t.Errorf("Transaction dropped while it could still be replayed")
+	}
+	if n := recent.Expire(1000001 + constants.TRANSACTION_PRIOR_LIMIT); n != 1 || recent.Len() != 0 {
+		t.Errorf("Expected the transaction to be dropped, got %d, %d left", n, recent.Len())
+	}
+}
+
+func TestRebuildRecent(t *testing.T) {
+	s := testHelper.CreateAndPopulateTestState()
+	fs := s.FactoidState.(*FactoidState)
+
+	// Rebuilt from the database as the state loaded it
+	count := 0
+	for h := 0; h < testHelper.BlockCount; h++ {
+		fblock, err := s.DB.FetchFBlockByHeight(uint32(h))
+		if err != nil || fblock == nil {
+			t.Fatalf("No factoid block %d: %v", h, err)
+		}
+		for _, tx := range fblock.GetTransactions()[1:] {
+			if !fs.IsRecentTransaction(tx.GetSigHash()) {
+				t.Errorf("Transaction %x of block %d is not held", tx.GetSigHash().Bytes(), h)
+			}
+			count++
+		}
+	}
+	if count == 0 || fs.Recent.Len() != count {
+		t.Errorf("Expected %d transactions held, got %d", count, fs.Recent.Len())
+	}
+}
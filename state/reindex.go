@@ -0,0 +1,35 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+// Reindex drops and rebuilds every index of the node's configured database from
+// the blocks and entries in it.  The database is opened on its own, s.DB is
+// not touched.  Progress is written to out.
+func (s *State) Reindex(out io.Writer) error {
+	dbase, err := s.openDB(s.DBType)
+	if err != nil {
+		return err
+	}
+	defer dbase.Close()
+
+	fmt.Fprintf(out, "Rebuilding the indexes of the %s database\n", s.DBType)
+	n, err := databaseOverlay.NewOverlay(dbase).Reindex(func(height uint32, top uint32) {
+		if height%1000 == 0 && height > 0 {
+			fmt.Fprintf(out, "  reindexed %d of %d blocks\n", height, top+1)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("Reindex failed after %d directory blocks: %v", n, err)
+	}
+	fmt.Fprintf(out, "Reindexed %d directory blocks\n", n)
+	return nil
+}
@@ -50,6 +50,21 @@ func RandomReplay() *Replay {
 	return r
 }
 
+// Stats returns the number of hashes in each bucket.
+func (r *Replay) Stats() *interfaces.ReplayStats {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	stats := new(interfaces.ReplayStats)
+	stats.Basetime = r.Basetime
+	stats.Center = r.Center
+	for _, b := range r.Buckets {
+		stats.Buckets = append(stats.Buckets, len(b))
+		stats.Hashes += len(b)
+	}
+	return stats
+}
+
 func (r *Replay) Init() {
 	for i := range r.Buckets {
 		if r.Buckets[i] == nil {
@@ -177,3 +177,26 @@ func TestMarshalUnmarshalReplay(t *testing.T) {
 		}
 	}
 }
+
+func TestReplayStats(t *testing.T) {
+	r := new(Replay)
+	now := primitives.NewTimestampNow()
+	for i := 0; i < 10; i++ {
+		r.IsTSValid_(constants.INTERNAL_REPLAY, primitives.RandomHash().Fixed(), now, now)
+	}
+
+	stats := r.Stats()
+	if stats.Hashes != 10 {
+		t.Errorf("Got %d hashes, not 10", stats.Hashes)
+	}
+	if stats.Center != r.Center || stats.Basetime != r.Basetime {
+		t.Errorf("Wrong time - %v", stats)
+	}
+	sum := 0
+	for _, b := range stats.Buckets {
+		sum += b
+	}
+	if sum != stats.Hashes {
+		t.Errorf("Buckets hold %d hashes, not %d", sum, stats.Hashes)
+	}
+}
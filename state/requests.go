@@ -0,0 +1,61 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+)
+
+// The most API requests whose messages are traced at once
+const maxTracedRequests = 1000
+
+// requestTraces ties the messages submitted through the API to the
+// correlation IDs of the requests that submitted them, for the State log.
+type requestTraces struct {
+	mutex sync.Mutex
+	ids   map[[32]byte]string
+	order [][32]byte // Oldest first, so the oldest is dropped when full
+}
+
+// TraceRequest logs what becomes of a message an API request submits, under
+// the ID of the request.
+func (s *State) TraceRequest(msgHash interfaces.IHash, requestID string) {
+	t := &s.requestTraces
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.ids == nil {
+		t.ids = map[[32]byte]string{}
+	}
+	if len(t.order) >= maxTracedRequests {
+		delete(t.ids, t.order[0])
+		t.order = t.order[1:]
+	}
+	t.ids[msgHash.Fixed()] = requestID
+	t.order = append(t.order, msgHash.Fixed())
+}
+
+// requestIDOf returns the ID of the API request that submitted a message, or
+// "" if it wasn't submitted through the API.
+func (s *State) requestIDOf(msg interfaces.IMsg) string {
+	t := &s.requestTraces
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.ids[msg.GetMsgHash().Fixed()]
+}
+
+// logRequestMsg logs a step of a message submitted through the API, with the
+// ID of the request.
+func (s *State) logRequestMsg(msg interfaces.IMsg, step string) {
+	if s.Logger == nil {
+		return
+	}
+	if id := s.requestIDOf(msg); id != "" {
+		s.Logger.Infof("api_message id=%s type=%s hash=%s step=%s", id, messages.MessageName(msg.Type()), msg.GetMsgHash().String(), step)
+	}
+}
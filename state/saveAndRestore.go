@@ -379,10 +379,9 @@ func SaveFactomdState(state *State, d *DBState) (ss *SaveState) {
 	//	ss.Acks[k] = state.Acks[k]
 	//}
 
-	ss.Commits = make(map[[32]byte]interfaces.IMsg)
-	for k, c := range state.Commits {
-		ss.Commits[k] = c
-	}
+	ss.Commits = state.Commits.Copy()
+
+	ss.MissingEntryBlocks = state.GetMissingEntryBlocks()
 
 	ss.InvalidMessages = make(map[[32]byte]interfaces.IMsg)
 	for k := range state.InvalidMessages {
@@ -608,6 +607,11 @@ func (ss *SaveState) RestoreFactomdState(state *State) { //, d *DBState) {
 	state.Authorities = append(state.Authorities[:0], ss.Authorities...)
 	state.AuthorityServerCount = ss.AuthorityServerCount
 
+	// The entry sync asks again for any entry block still missing
+	state.MissingEntryBlocksMutex.Lock()
+	state.MissingEntryBlocks = append(state.MissingEntryBlocks[:0], ss.MissingEntryBlocks...)
+	state.MissingEntryBlocksMutex.Unlock()
+
 	state.LLeaderHeight = ss.LLeaderHeight
 	state.Leader = ss.Leader
 	state.LeaderVMIndex = ss.LeaderVMIndex
@@ -642,9 +646,9 @@ func (ss *SaveState) RestoreFactomdState(state *State) { //, d *DBState) {
 		state.Acks[k] = ss.Acks[k]
 	}
 
-	state.Commits = make(map[[32]byte]interfaces.IMsg)
+	state.Commits = NewCommitBucket()
 	for k, c := range ss.Commits {
-		state.Commits[k] = c
+		state.Commits.Put(k, c)
 	}
 
 	state.InvalidMessages = make(map[[32]byte]interfaces.IMsg)
@@ -660,6 +664,14 @@ func (ss *SaveState) RestoreFactomdState(state *State) { //, d *DBState) {
 	state.FERChangePrice = ss.FERChangePrice
 	state.FERPriority = ss.FERPriority
 	state.FERPrioritySetHeight = ss.FERPrioritySetHeight
+
+	// The coinbase payouts yet to mature are not saved, but taken again from
+	// the blocks
+	if fs, ok := state.FactoidState.(*FactoidState); ok {
+		if err := fs.RebuildPayouts(ss.DBHeight); err != nil {
+			state.Logger.Warningf("Could not rebuild the coinbase payouts at %d: %v", ss.DBHeight, err)
+		}
+	}
 }
 
 func (ss *SaveState) MarshalBinary() ([]byte, error) {
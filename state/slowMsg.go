@@ -0,0 +1,45 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/log"
+)
+
+// The steps of a message that are timed against SlowMessageThreshold
+const (
+	StepFollowerExecute = "FollowerExecute"
+	StepLeaderExecute   = "LeaderExecute"
+	StepProcess         = "Process"
+)
+
+// checkSlowMsg logs the message if the step of it, begun at start, took
+// longer than SlowMessageThreshold.
+func (s *State) checkSlowMsg(msg interfaces.IMsg, step string, start time.Time) {
+	if s.SlowMessageThreshold <= 0 {
+		return
+	}
+	took := time.Since(start)
+	if took < s.SlowMessageThreshold {
+		return
+	}
+	SlowMessages.Inc()
+
+	// Only marshalled now it is known to be slow
+	size := 0
+	if data, err := msg.MarshalBinary(); err == nil {
+		size = len(data)
+	}
+	s.Log().WithFields(log.Fields{
+		log.FieldMsgType: messages.MessageName(msg.Type()),
+		"hash":           msg.GetMsgHash().String(),
+		"size":           size,
+		"step":           step,
+	}).Warningf("Slow message took %v, over the threshold of %v", took, s.SlowMessageThreshold)
+}
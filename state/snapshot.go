@@ -0,0 +1,94 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+// snapshotProgress returns a CopyProgress that reports each bucket to out.
+func snapshotProgress(out io.Writer) databaseOverlay.CopyProgress {
+	return func(bucket []byte, keys int, done bool) {
+		if done {
+			fmt.Fprintf(out, "  bucket %x: %d keys\n", bucket, keys)
+		} else if keys%(100*databaseOverlay.CopyBatchSize) == 0 {
+			fmt.Fprintf(out, "  bucket %x: %d keys...\n", bucket, keys)
+		}
+	}
+}
+
+// ExportDB writes the node's configured database to a snapshot file at path.
+// An existing file is not overwritten.  s.DB is not touched.
+func (s *State) ExportDB(path string, out io.Writer) error {
+	dbase, err := s.openDB(s.DBType)
+	if err != nil {
+		return err
+	}
+	defer dbase.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Exporting %s database to %s\n", s.DBType, path)
+	n, err := databaseOverlay.ExportDatabase(dbase, f, snapshotProgress(out))
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("Export failed after %d keys: %v", n, err)
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Exported %d keys to %s\n", n, path)
+	return nil
+}
+
+// ImportDB loads a snapshot file at path into the node's configured database.
+// The snapshot is verified in full before anything is written, and the
+// database must not already hold any chains.  s.DB is not touched.
+func (s *State) ImportDB(path string, out io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(out, "Verifying snapshot %s\n", path)
+	n, err := databaseOverlay.ReadSnapshot(f, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	dbase, err := s.openDB(s.DBType)
+	if err != nil {
+		return err
+	}
+	defer dbase.Close()
+
+	chains, err := dbase.ListAllKeys(databaseOverlay.CHAIN_HEAD)
+	if err != nil {
+		return err
+	}
+	if len(chains) > 0 {
+		return fmt.Errorf("The %s database already holds %d chains, import into an empty database", s.DBType, len(chains))
+	}
+
+	fmt.Fprintf(out, "Importing %d keys into the %s database\n", n, s.DBType)
+	imported, err := databaseOverlay.ImportDatabase(f, dbase, snapshotProgress(out))
+	if err != nil {
+		return fmt.Errorf("Import failed after %d keys: %v", imported, err)
+	}
+	fmt.Fprintf(out, "Imported %d keys from %s\n", imported, path)
+	return nil
+}
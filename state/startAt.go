@@ -0,0 +1,317 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// A new node may start at a block the operator trusts, in place of the
+// genesis block, when it has no need of the history before it.  It asks its
+// peers for the snapshot of the block: the blocks at that height and the
+// SaveState after them, with the balances, identities and servers.  The
+// directory block must have the trusted keyMR, and the balances, servers and
+// authorities the trusted snapshot hash, or the snapshot is thrown away.  Then
+// the node follows the network from the next block as if it had saved every
+// block before.
+
+// Peers keep the snapshot of the last block at a multiple of
+// CheckpointInterval, beside those of the last few blocks, so that a
+// checkpoint stays usable for about a week.
+const CheckpointInterval = 1000
+
+// TrustedCheckpoint is the block of the StartAt values of the config.
+type TrustedCheckpoint struct {
+	DBHeight     uint32
+	KeyMR        interfaces.IHash
+	SnapshotHash interfaces.IHash
+}
+
+func NewTrustedCheckpoint(dbheight uint32, keyMR string, snapshotHash string) (*TrustedCheckpoint, error) {
+	c := new(TrustedCheckpoint)
+	c.DBHeight = dbheight
+	var err error
+	if c.KeyMR, err = primitives.HexToHash(keyMR); err != nil {
+		return nil, fmt.Errorf("StartAtKeyMR: %v", err)
+	}
+	if c.SnapshotHash, err = primitives.HexToHash(snapshotHash); err != nil {
+		return nil, fmt.Errorf("StartAtSnapshotHash: %v", err)
+	}
+	return c, nil
+}
+
+// SnapshotHash is the hash of what a node starting at the SaveState trusts
+// it for: its height, its factoid and entry credit balances, in address
+// order, the chain IDs of its federated and audit servers, its identities
+// and authorities, in chain ID order, and its exchange rate.  What differs
+// from node to node, such as the messages held, is left out, so that every
+// node gives the same hash for a block.
+func (ss *SaveState) SnapshotHash() (interfaces.IHash, error) {
+	buf := primitives.NewBuffer(nil)
+	if err := buf.PushUInt32(ss.DBHeight); err != nil {
+		return nil, err
+	}
+	if err := PushBalanceMap(buf, ss.FactoidBalancesP); err != nil {
+		return nil, err
+	}
+	if err := PushBalanceMap(buf, ss.ECBalancesP); err != nil {
+		return nil, err
+	}
+	for _, servers := range [][]interfaces.IServer{ss.FedServers, ss.AuditServers} {
+		if err := buf.PushVarInt(uint64(len(servers))); err != nil {
+			return nil, err
+		}
+		for _, server := range SortServers(append([]interfaces.IServer{}, servers...)) {
+			if err := buf.Push(server.GetChainID().Bytes()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	identities := append([]*Identity{}, ss.Identities...)
+	sort.Slice(identities, func(i, j int) bool {
+		return bytes.Compare(identities[i].IdentityChainID.Bytes(), identities[j].IdentityChainID.Bytes()) < 0
+	})
+	if err := buf.PushVarInt(uint64(len(identities))); err != nil {
+		return nil, err
+	}
+	for _, v := range identities {
+		if err := buf.PushBinaryMarshallable(v); err != nil {
+			return nil, err
+		}
+	}
+	authorities := append([]*Authority{}, ss.Authorities...)
+	sort.Slice(authorities, func(i, j int) bool {
+		return bytes.Compare(authorities[i].AuthorityChainID.Bytes(), authorities[j].AuthorityChainID.Bytes()) < 0
+	})
+	if err := buf.PushVarInt(uint64(len(authorities))); err != nil {
+		return nil, err
+	}
+	for _, v := range authorities {
+		if err := buf.PushBinaryMarshallable(v); err != nil {
+			return nil, err
+		}
+	}
+	if err := buf.PushVarInt(uint64(ss.AuthorityServerCount)); err != nil {
+		return nil, err
+	}
+
+	if err := buf.PushUInt64(ss.FactoshisPerEC); err != nil {
+		return nil, err
+	}
+	return primitives.Sha(buf.DeepCopyBytes()), nil
+}
+
+// snapshotStates returns the saved dbstates we can give the snapshot of.
+func (s *State) snapshotStates() []*DBState {
+	var states []*DBState
+	if s.checkpointState != nil {
+		states = append(states, s.checkpointState)
+	}
+	for _, d := range s.DBStates.DBStates {
+		if d != nil && d.Saved && d.SaveStruct != nil && d != s.checkpointState {
+			states = append(states, d)
+		}
+	}
+	return states
+}
+
+// GetStateSnapshot returns the marshalled dbstate at the height, with its
+// SaveState, or nil if we no longer have it.
+func (s *State) GetStateSnapshot(dbheight uint32) []byte {
+	for _, d := range s.snapshotStates() {
+		if d.DirectoryBlock.GetHeader().GetDBHeight() != dbheight {
+			continue
+		}
+		b, err := d.MarshalBinary()
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+	return nil
+}
+
+// GetCheckpoints returns the blocks peers may start at with our snapshots.
+func (s *State) GetCheckpoints() []*interfaces.Checkpoint {
+	var checkpoints []*interfaces.Checkpoint
+	for _, d := range s.snapshotStates() {
+		hash, err := d.SaveStruct.SnapshotHash()
+		if err != nil {
+			continue
+		}
+		checkpoints = append(checkpoints, &interfaces.Checkpoint{
+			DBHeight:     d.DirectoryBlock.GetHeader().GetDBHeight(),
+			KeyMR:        d.DirectoryBlock.GetKeyMR().String(),
+			SnapshotHash: hash.String(),
+		})
+	}
+	return checkpoints
+}
+
+// askForSnapshot asks our peers for the snapshot of StartAt, every few
+// seconds until one comes.
+func (s *State) askForSnapshot() {
+	now := s.GetTimestamp().GetTimeSeconds()
+	if now-s.snapshotAsked < 6 {
+		return
+	}
+	s.snapshotAsked = now
+	msg := messages.NewStateSnapshotRequest(s, s.StartAt.DBHeight)
+	msg.SendOut(s, msg)
+}
+
+// FollowerExecuteStateSnapshot starts from the snapshot, if we are waiting
+// for one and it is of our trusted checkpoint.
+func (s *State) FollowerExecuteStateSnapshot(m interfaces.IMsg) {
+	msg, ok := m.(*messages.StateSnapshotResponse)
+	if !ok || !s.AwaitingSnapshot || msg.DBHeight != s.StartAt.DBHeight {
+		return
+	}
+
+	d := new(DBState)
+	if err := d.UnmarshalBinary(msg.Snapshot); err != nil {
+		s.AddStatus(fmt.Sprintf("STARTAT: Bad snapshot from %s: %v", msg.GetNetworkOrigin(), err))
+		return
+	}
+	if err := s.checkSnapshot(d); err != nil {
+		s.AddStatus(fmt.Sprintf("STARTAT: Snapshot from %s rejected: %v", msg.GetNetworkOrigin(), err))
+		return
+	}
+	if err := s.startAtSnapshot(d); err != nil {
+		panic(fmt.Sprintf("Could not start at block %d: %v", s.StartAt.DBHeight, err))
+	}
+}
+
+// checkSnapshot returns an error unless the dbstate is the block of StartAt,
+// whole, with the saved state of its snapshot hash.
+func (s *State) checkSnapshot(d *DBState) error {
+	if d.DirectoryBlock == nil || d.SaveStruct == nil {
+		return fmt.Errorf("no directory block or saved state")
+	}
+	if d.DirectoryBlock.GetHeader().GetDBHeight() != s.StartAt.DBHeight || d.SaveStruct.DBHeight != s.StartAt.DBHeight {
+		return fmt.Errorf("the snapshot is of block %d", d.DirectoryBlock.GetHeader().GetDBHeight())
+	}
+	if keyMR := d.DirectoryBlock.GetKeyMR(); !keyMR.IsSameAs(s.StartAt.KeyMR) {
+		return fmt.Errorf("the directory block has keyMR %s", keyMR.String())
+	}
+	hash, err := d.SaveStruct.SnapshotHash()
+	if err != nil {
+		return err
+	}
+	if !hash.IsSameAs(s.StartAt.SnapshotHash) {
+		return fmt.Errorf("the saved state has snapshot hash %s", hash.String())
+	}
+	// The entry blocks of the block are not needed, only the others
+	if _, err := s.DBStates.checkDBStatePieces(d, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// startAtSnapshot saves the blocks of the snapshot, and restores the state
+// after them, as if every block before had been saved.  The snapshot is saved
+// too, for the node to start from again when it restarts.
+func (s *State) startAtSnapshot(d *DBState) error {
+	snapshot, err := d.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := s.DB.SaveCheckpoint(snapshot); err != nil {
+		return err
+	}
+
+	s.DB.StartMultiBatch()
+	if err := s.DB.ProcessABlockMultiBatch(d.AdminBlock); err != nil {
+		s.DB.CancelMultiBatch()
+		return err
+	}
+	if err := s.DB.ProcessFBlockMultiBatch(d.FactoidBlock); err != nil {
+		s.DB.CancelMultiBatch()
+		return err
+	}
+	if err := s.DB.ProcessECBlockMultiBatch(d.EntryCreditBlock, false); err != nil {
+		s.DB.CancelMultiBatch()
+		return err
+	}
+	if err := s.DB.ProcessDBlockMultiBatch(d.DirectoryBlock); err != nil {
+		s.DB.CancelMultiBatch()
+		return err
+	}
+	if err := s.DB.ExecuteMultiBatch(); err != nil {
+		return err
+	}
+
+	s.restoreCheckpoint(d)
+	s.AwaitingSnapshot = false
+	s.AddStatus(fmt.Sprintf("STARTAT: Started at block %d, keyMR %s", s.DatabaseBase, s.StartAt.KeyMR.String()))
+	return nil
+}
+
+// loadCheckpoint restores the state after the block the database starts at,
+// if the node started at a checkpoint, and returns the height of the block.
+// It returns 0 for a database that starts at the genesis block.
+func (s *State) loadCheckpoint() (uint32, error) {
+	snapshot, err := s.DB.FetchCheckpoint()
+	if err != nil || snapshot == nil {
+		return 0, err
+	}
+	d := new(DBState)
+	if err := d.UnmarshalBinary(snapshot); err != nil {
+		return 0, err
+	}
+	if d.DirectoryBlock == nil || d.SaveStruct == nil {
+		return 0, fmt.Errorf("the saved checkpoint has no directory block or saved state")
+	}
+	s.restoreCheckpoint(d)
+	return s.DatabaseBase, nil
+}
+
+// restoreCheckpoint makes the saved block of the snapshot the first block of
+// the node, with the state after it.  The entries of the blocks are synced
+// from the next block on.
+func (s *State) restoreCheckpoint(d *DBState) {
+	ht := d.DirectoryBlock.GetHeader().GetDBHeight()
+
+	// Nothing below the checkpoint is built or kept
+	s.DatabaseBase = ht
+	s.ProcessLists.DBHeightBase = ht
+	s.ProcessLists.Lists = nil
+	s.DBStates.Base = ht
+	s.DBStates.DBStates = nil
+	d.SaveStruct.RestoreFactomdState(s)
+
+	d.IsNew = false
+	d.Locked = true
+	d.Signed = true
+	d.Complete = true
+	d.ReadyToSave = false
+	d.Saved = true
+	d.EntryBlocks = nil
+	d.Entries = nil
+	s.DBStates.DBStates = []*DBState{d}
+	s.DBStates.ProcessHeight = ht
+	s.DBStates.SavedHeight = ht
+	s.DBStates.Complete = 0
+	s.DBStatesReceived = nil
+	s.DBStatesReceivedBase = int(ht)
+
+	s.EntryDBHeightComplete = ht
+	s.EntryBlockDBHeightComplete = ht
+	s.EntryBlockDBHeightProcessing = ht + 1
+	s.EntryDBHeightProcessing = ht + 1
+	s.MissingEntryBlocksMutex.Lock()
+	s.MissingEntryBlocks = nil
+	s.MissingEntryBlocksMutex.Unlock()
+
+	s.LeaderPL = s.ProcessLists.Get(s.LLeaderHeight)
+	s.checkpointState = d
+}
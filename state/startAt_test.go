@@ -0,0 +1,144 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestNewTrustedCheckpoint(t *testing.T) {
+	hash := "3b6a27bcceb6a42d62a3a8d02a6f0d73653215771de243a63ac048a18b59da29"
+	c, err := NewTrustedCheckpoint(1000, hash, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DBHeight != 1000 || c.KeyMR.String() != hash || c.SnapshotHash.String() != hash {
+		t.Errorf("Wrong checkpoint %d %s %s", c.DBHeight, c.KeyMR.String(), c.SnapshotHash.String())
+	}
+	if _, err = NewTrustedCheckpoint(1000, "abc", hash); err == nil {
+		t.Error("Expected an error for a bad keyMR")
+	}
+	if _, err = NewTrustedCheckpoint(1000, hash, ""); err == nil {
+		t.Error("Expected an error for a missing snapshot hash")
+	}
+}
+
+func TestSnapshotHash(t *testing.T) {
+	ss := new(SaveState)
+	ss.Init()
+	ss.DBHeight = 1000
+	ss.FactoidBalancesP[[32]byte{1}] = 100
+	ss.FactoidBalancesP[[32]byte{2}] = 200
+	ss.ECBalancesP[[32]byte{3}] = 300
+
+	h1, err := ss.SnapshotHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := ss.SnapshotHash()
+	if !h1.IsSameAs(h2) {
+		t.Error("The snapshot hash is not deterministic")
+	}
+
+	ss.ECBalancesP[[32]byte{3}] = 301
+	h3, _ := ss.SnapshotHash()
+	if h1.IsSameAs(h3) {
+		t.Error("The snapshot hash did not change with a balance")
+	}
+
+	ss.ECBalancesP[[32]byte{3}] = 300
+	ss.DBHeight = 1001
+	h4, _ := ss.SnapshotHash()
+	if h1.IsSameAs(h4) {
+		t.Error("The snapshot hash did not change with the height")
+	}
+
+	ss.DBHeight = 1000
+	ss.FedServers = append(ss.FedServers, &Server{ChainID: primitives.Sha([]byte("fed"))})
+	h5, _ := ss.SnapshotHash()
+	if h1.IsSameAs(h5) {
+		t.Error("The snapshot hash did not change with the federated servers")
+	}
+
+	ss.FedServers = nil
+	auth := new(Authority)
+	auth.AuthorityChainID = primitives.Sha([]byte("fed"))
+	auth.ManagementChainID = primitives.Sha([]byte("management"))
+	auth.MatryoshkaHash = primitives.Sha([]byte("matryoshka"))
+	ss.Authorities = append(ss.Authorities, auth)
+	h6, err := ss.SnapshotHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1.IsSameAs(h6) {
+		t.Error("The snapshot hash did not change with the authorities")
+	}
+}
+
+func TestStartAtRestart(t *testing.T) {
+	src := testHelper.CreateAndPopulateTestState()
+	ht := src.GetHighestSavedBlk()
+	d := src.DBStates.Get(int(ht))
+	if d == nil {
+		t.Fatalf("No dbstate at %d", ht)
+	}
+	d.SaveStruct = SaveFactomdState(src, d)
+	if d.SaveStruct == nil {
+		t.Fatalf("No saved state at %d", ht)
+	}
+	hash, err := d.SaveStruct.SnapshotHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new node starts at the block
+	s := newStartAtState(databaseOverlay.NewOverlay(new(mapdb.MapDB)))
+	s.StartAt = &TrustedCheckpoint{DBHeight: ht, KeyMR: d.DirectoryBlock.GetKeyMR(), SnapshotHash: hash}
+	LoadDatabase(s)
+	if !s.AwaitingSnapshot {
+		t.Fatal("The new node is not waiting for the snapshot")
+	}
+	msg := new(messages.StateSnapshotResponse)
+	msg.DBHeight = ht
+	msg.Snapshot = snapshot
+	s.FollowerExecuteStateSnapshot(msg)
+	if s.AwaitingSnapshot || s.DatabaseBase != ht {
+		t.Fatalf("The node did not start at %d", ht)
+	}
+
+	// And starts from it again on restarting, without StartAt
+	again := newStartAtState(s.DB)
+	LoadDatabase(again)
+	if again.AwaitingSnapshot {
+		t.Error("The restarted node waits for a snapshot")
+	}
+	if again.DatabaseBase != ht || again.GetHighestSavedBlk() != ht {
+		t.Errorf("The restarted node is at %d from %d, expected %d", again.GetHighestSavedBlk(), again.DatabaseBase, ht)
+	}
+	if again.EntryDBHeightComplete != ht || again.EntryBlockDBHeightComplete != ht {
+		t.Errorf("The restarted node syncs entries from %d and %d, expected %d", again.EntryDBHeightComplete, again.EntryBlockDBHeightComplete, ht)
+	}
+}
+
+func newStartAtState(db interfaces.DBOverlaySimple) *State {
+	s := new(State)
+	s.DB = db
+	s.LoadConfig("", "")
+	s.Network = "LOCAL"
+	s.Init()
+	return s
+}
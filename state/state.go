@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -18,18 +19,24 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 
+	"github.com/FactomProject/factomd/anchor"
 	"github.com/FactomProject/factomd/common/adminBlock"
 	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
-	"github.com/FactomProject/factomd/database/databaseOverlay"
-	//"github.com/FactomProject/factomd/database/hybridDB"
 	"github.com/FactomProject/factomd/database/boltdb"
+	"github.com/FactomProject/factomd/database/cacheDB"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/hybridDB"
 	"github.com/FactomProject/factomd/database/leveldb"
 	"github.com/FactomProject/factomd/database/mapdb"
+	"github.com/FactomProject/factomd/database/splitDB"
+	"github.com/FactomProject/factomd/events"
+	"github.com/FactomProject/factomd/liveness"
 	"github.com/FactomProject/factomd/log"
 	"github.com/FactomProject/factomd/p2p"
+	"github.com/FactomProject/factomd/telemetry"
 	"github.com/FactomProject/factomd/util"
 	"github.com/FactomProject/factomd/wsapi"
 
@@ -55,12 +62,25 @@ type State struct {
 	BoltDBPath        string
 	LogLevel          string
 	ConsoleLogLevel   string
+	LogFormat         string            // Of the structured console logs, text or json
+	PackageLogLevels  map[string]string // Console log level of each package
 	NodeMode          string
 	DBType            string
 	CloneDBType       string
 	ExportData        bool
 	ExportDataSubpath string
 
+	// Hex key of the database encryption, empty if the database isn't encrypted
+	DBEncryptionKey     string
+	DBEncryptionKeyFile string
+	DBCacheSize         int // Values in the read cache of LDB and Bolt databases, 0 for none
+
+	// Directory of the entries database, empty to keep the entries with the blocks
+	EntryDBPath string
+
+	BulkLoadBlocks int  // Blocks behind the network before saving without a sync per block, 0 for never
+	bulkLoading    bool // True while the database is in bulk load mode
+
 	DBStatesSent            []*interfaces.DBStateSent
 	DBStatesReceivedBase    int
 	DBStatesReceived        []*messages.DBStateMsg
@@ -76,6 +96,8 @@ type State struct {
 	ControlPanelChannel     chan DisplayState
 	ControlPanelDataRequest bool // If true, update Display state
 
+	dumpRequests chan chan *StateDump // Asks the state loop for a StateDump
+
 	// Network Configuration
 	Network                 string
 	MainNetworkPort         string
@@ -138,11 +160,13 @@ type State struct {
 	timerMsgQueue          chan interfaces.IMsg
 	TimeOffset             interfaces.Timestamp
 	MaxTimeOffset          interfaces.Timestamp
+	Clock                  interfaces.IClock // The time of the node: the wall clock, unless a test or simulation sets one
 	networkOutMsgQueue     NetOutMsgQueue
 	networkInvalidMsgQueue chan interfaces.IMsg
 	inMsgQueue             InMsgMSGQueue
 	apiQueue               chan interfaces.IMsg
 	ackQueue               chan interfaces.IMsg
+	prioritizedMsgQueue    chan interfaces.IMsg
 	msgQueue               chan interfaces.IMsg
 
 	ShutdownChan chan int // For gracefully halting Factom
@@ -155,14 +179,77 @@ type State struct {
 	serverPendingPubKeys  []*primitives.PublicKey
 
 	// RPC connection config
-	RpcUser     string
-	RpcPass     string
-	RpcAuthHash []byte
+	RpcUser        string
+	RpcPass        string
+	RpcAuthHash    []byte
+	RpcToken       string
+	RpcAdminToken  string
+	RpcPublicReads bool
 
 	FactomdTLSEnable   bool
 	factomdTLSKeyFile  string
 	factomdTLSCertFile string
 	FactomdLocations   string
+	CorsDomains        []string
+	CorsMethods        string
+	ApiReadRateLimit   int // Requests a second per client, 0 for no limit
+	ApiSubmitRateLimit int
+	ApiSunsets         map[string]time.Time // When API versions will be removed
+	GRPCPort           int                  // 0 if the gRPC API isn't served
+
+	// Profiling, served on localhost by the engine
+	ProfilerEnabled   bool
+	ProfilerBlockRate int
+	ProfilePath       string // Where the profile API method writes
+
+	// Supply statistics, kept up to date in the background if enabled
+	RichListEnabled  bool
+	RichListSize     int
+	supplyStats      *interfaces.SupplyStats
+	supplyStatsMutex sync.Mutex
+
+	// Anchoring of directory blocks in Ethereum
+	AnchorConfig anchor.Config
+
+	// Anonymous reports of the version and height of the node
+	TelemetryConfig telemetry.Config
+
+	// Outside brokers that events are sent to
+	EventSinks map[string]*events.SinkConfig
+
+	// Keys that downstream clients call the API with, by name
+	ApiKeys map[string]*interfaces.ApiKey
+
+	// Faults injected into the network, by peer, for testing
+	NetworkFaults map[string]*p2p.FaultConfig
+
+	// The API requests that submitted messages, for the log
+	requestTraces requestTraces
+
+	// The stages of the messages seen, while MessageTracing is on
+	MessageTracing bool
+	msgTraces      msgTraces
+
+	// Messages that take longer to execute or process are logged; 0 for none
+	SlowMessageThreshold time.Duration
+
+	// The directory blocks signed at recent heights, to find forks
+	forkWatch forkWatch
+
+	// The event sinks
+	Events    *events.Emitter
+	eventRole string // The node-state last sent
+
+	// Alerts sent to the event sinks
+	AlertSyncStallMinutes int
+	alertWatch            alertWatch
+
+	// As an audit server, record the liveness of the federated servers to the
+	// chain every LivenessBlocks blocks, paid for by the entry credit key
+	LivenessChainID string
+	LivenessECKey   string
+	LivenessBlocks  int
+	Liveness        *liveness.Recorder // nil if the node doesn't record liveness
 
 	// Server State
 	StartDelay      int64 // Time in Milliseconds since the last DBState was applied
@@ -203,6 +290,32 @@ type State struct {
 	// when a majority of leaders disagree with the hash we have via DBSigs
 	KeepMismatch bool
 
+	// Never lead or audit, even if the identity of the config is in the
+	// authority set.  The node runs as a random identity instead, so that a
+	// standby of an authority can't sign for it.
+	FollowerOnly bool
+
+	// As a leader, sign and send acks in batches of up to this many, rather
+	// than one by one.  See ackBatch.go
+	AckBatchSize int
+	ackBatches   map[int][]*messages.Ack // Our acks waiting for a batch, by VM
+
+	// The origin of the peer the acks of each leader last came from, to ask
+	// for the gaps in its VM.  See gaps.go
+	leaderOrigins map[[32]byte]int
+
+	// Start a new database at this block in place of the genesis block, from
+	// a snapshot our peers send.  See startAt.go
+	StartAt          *TrustedCheckpoint
+	AwaitingSnapshot bool     // The database is new, and waits for the snapshot of StartAt
+	DatabaseBase     uint32   // The block the database starts at: 0, or the checkpoint it started at
+	snapshotAsked    int64    // When we last asked for the snapshot, in seconds
+	checkpointState  *DBState // The last saved block at a multiple of CheckpointInterval
+
+	// Check after each block that balances, acknowledgements and the saved
+	// blocks are as they must be, and crash if not.  See invariants.go
+	CheckInvariants bool
+
 	DBSigFails int // Keep track of how many blockhash mismatches we've had to correct
 
 	Saving  bool // True if we are in the process of saving to the database
@@ -224,7 +337,10 @@ type State struct {
 	Holding       map[[32]byte]interfaces.IMsg // Hold Messages
 	XReview       []interfaces.IMsg            // After the EOM, we must review the messages in Holding
 	Acks          map[[32]byte]interfaces.IMsg // Hold Acknowledgemets
-	Commits       map[[32]byte]interfaces.IMsg // Commit Messages
+	Commits       *CommitBucket                // Commit Messages
+
+	// For Leader
+	FactoidMempool *Mempool // Factoid transactions waiting for the leader to put them in a block
 
 	InvalidMessages      map[[32]byte]interfaces.IMsg
 	InvalidMessagesMutex sync.RWMutex
@@ -290,7 +406,8 @@ type State struct {
 	// DBlock Height at which we have started asking for entry blocks
 	EntryBlockDBHeightProcessing uint32
 	// Entry Blocks we don't have that we are asking our neighbors for
-	MissingEntryBlocks []MissingEntryBlock
+	MissingEntryBlocks      []MissingEntryBlock
+	MissingEntryBlocksMutex sync.Mutex
 
 	MissingEntryRepeat interfaces.Timestamp
 	// DBlock Height at which node has a complete set of eblocks+entries
@@ -340,7 +457,7 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	newState := new(State)
 	number := fmt.Sprintf("%02d", cloneNumber)
 
-	simConfigPath := util.GetHomeDir() + "/.factom/m2/simConfig/"
+	simConfigPath := util.GetDataDir() + "/simConfig/"
 	configfile := fmt.Sprintf("%sfactomd%03d.conf", simConfigPath, cloneNumber)
 
 	if cloneNumber == 1 {
@@ -365,14 +482,24 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	}
 
 	newState.FactomNodeName = s.Prefix + "FNode" + number
+	newState.Clock = s.Clock // The simulated nodes share one time
 	newState.FactomdVersion = s.FactomdVersion
 	newState.DropRate = s.DropRate
 	newState.LdbPath = s.LdbPath + "/Sim" + number
 	newState.JournalFile = s.LogPath + "/journal" + number + ".log"
 	newState.Journaling = s.Journaling
 	newState.BoltDBPath = s.BoltDBPath + "/Sim" + number
+	newState.DBEncryptionKey = s.DBEncryptionKey
+	newState.DBEncryptionKeyFile = s.DBEncryptionKeyFile
+	newState.DBCacheSize = s.DBCacheSize
+	newState.BulkLoadBlocks = s.BulkLoadBlocks
+	if s.EntryDBPath != "" {
+		newState.EntryDBPath = s.EntryDBPath + "/Sim" + number
+	}
 	newState.LogLevel = s.LogLevel
 	newState.ConsoleLogLevel = s.ConsoleLogLevel
+	newState.LogFormat = s.LogFormat
+	newState.PackageLogLevels = s.PackageLogLevels
 	newState.NodeMode = "FULL"
 	newState.CloneDBType = s.CloneDBType
 	newState.DBType = s.CloneDBType
@@ -429,11 +556,37 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	newState.RpcUser = s.RpcUser
 	newState.RpcPass = s.RpcPass
 	newState.RpcAuthHash = s.RpcAuthHash
+	newState.RpcToken = s.RpcToken
+	newState.RpcAdminToken = s.RpcAdminToken
+	newState.RpcPublicReads = s.RpcPublicReads
 
 	newState.FactomdTLSEnable = s.FactomdTLSEnable
 	newState.factomdTLSKeyFile = s.factomdTLSKeyFile
 	newState.factomdTLSCertFile = s.factomdTLSCertFile
 	newState.FactomdLocations = s.FactomdLocations
+	newState.CorsDomains = s.CorsDomains
+	newState.CorsMethods = s.CorsMethods
+	newState.ApiReadRateLimit = s.ApiReadRateLimit
+	newState.ApiSubmitRateLimit = s.ApiSubmitRateLimit
+	newState.ApiSunsets = s.ApiSunsets
+	newState.GRPCPort = s.GRPCPort
+	newState.ProfilerEnabled = s.ProfilerEnabled
+	newState.ProfilerBlockRate = s.ProfilerBlockRate
+	newState.ProfilePath = s.ProfilePath
+	newState.RichListEnabled = s.RichListEnabled
+	newState.RichListSize = s.RichListSize
+	newState.MessageTracing = s.MessageTracing
+	newState.SlowMessageThreshold = s.SlowMessageThreshold
+	newState.AlertSyncStallMinutes = s.AlertSyncStallMinutes
+	newState.LivenessChainID = s.LivenessChainID
+	newState.LivenessECKey = s.LivenessECKey
+	newState.LivenessBlocks = s.LivenessBlocks
+	newState.AnchorConfig = s.AnchorConfig
+	newState.TelemetryConfig = s.TelemetryConfig
+	newState.EventSinks = s.EventSinks
+	newState.NetworkFaults = s.NetworkFaults
+	newState.CheckInvariants = s.CheckInvariants
+	newState.ApiKeys = s.ApiKeys
 
 	switch newState.DBType {
 	case "LDB":
@@ -521,6 +674,22 @@ func (s *State) GetRpcAuthHash() []byte {
 	return s.RpcAuthHash
 }
 
+func (s *State) GetRpcToken() string {
+	return s.RpcToken
+}
+
+func (s *State) GetRpcAdminToken() string {
+	return s.RpcAdminToken
+}
+
+func (s *State) GetApiKeys() map[string]*interfaces.ApiKey {
+	return s.ApiKeys
+}
+
+func (s *State) GetRpcPublicReads() bool {
+	return s.RpcPublicReads
+}
+
 func (s *State) GetTlsInfo() (bool, string, string) {
 	return s.FactomdTLSEnable, s.factomdTLSKeyFile, s.factomdTLSCertFile
 }
@@ -529,6 +698,26 @@ func (s *State) GetFactomdLocations() string {
 	return s.FactomdLocations
 }
 
+func (s *State) GetCorsDomains() []string {
+	return s.CorsDomains
+}
+
+func (s *State) GetCorsMethods() string {
+	return s.CorsMethods
+}
+
+func (s *State) GetApiRateLimits() (int, int) {
+	return s.ApiReadRateLimit, s.ApiSubmitRateLimit
+}
+
+func (s *State) GetApiSunsets() map[string]time.Time {
+	return s.ApiSunsets
+}
+
+func (s *State) GetProfilerInfo() (bool, string) {
+	return s.ProfilerEnabled, s.ProfilePath
+}
+
 func (s *State) GetCurrentMinute() int {
 	return s.CurrentMinute
 }
@@ -575,22 +764,51 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		fmt.Printf("\n\nNetwork : %s\n", s.Network)
 
 		networkName := strings.ToLower(s.Network) + "-"
-		// TODO: improve the paths after milestone 1
-		cfg.App.LdbPath = cfg.App.HomeDir + networkName + cfg.App.LdbPath
-		cfg.App.BoltDBPath = cfg.App.HomeDir + networkName + cfg.App.BoltDBPath
-		cfg.App.DataStorePath = cfg.App.HomeDir + networkName + cfg.App.DataStorePath
-		cfg.Log.LogPath = cfg.App.HomeDir + networkName + cfg.Log.LogPath
-		cfg.App.ExportDataSubpath = cfg.App.HomeDir + networkName + cfg.App.ExportDataSubpath
-		cfg.App.PeersFile = cfg.App.HomeDir + networkName + cfg.App.PeersFile
+		if util.DataDir != "" {
+			// -datadir keeps all the files of the network in its directory
+			paths := util.GetDataPaths(s.Network)
+			cfg.App.LdbPath = filepath.Join(paths.Database, "ldb")
+			cfg.App.BoltDBPath = filepath.Join(paths.Database, "bolt")
+			if cfg.App.EntryDBPath != "" {
+				cfg.App.EntryDBPath = filepath.Join(paths.Database, "entries")
+			}
+			cfg.App.DataStorePath = paths.Export + "/"
+			cfg.Log.LogPath = paths.Logs
+			cfg.App.ExportDataSubpath = paths.Export + "/"
+			cfg.App.ProfilePath = paths.Profiles + "/"
+			cfg.App.PeersFile = paths.Peers
+			if cfg.App.FastBootLocation == cfg.App.HomeDir {
+				cfg.App.FastBootLocation = paths.Checkpoints
+			}
+		} else {
+			// TODO: improve the paths after milestone 1
+			cfg.App.LdbPath = cfg.App.HomeDir + networkName + cfg.App.LdbPath
+			cfg.App.BoltDBPath = cfg.App.HomeDir + networkName + cfg.App.BoltDBPath
+			if cfg.App.EntryDBPath != "" {
+				cfg.App.EntryDBPath = cfg.App.HomeDir + networkName + cfg.App.EntryDBPath
+			}
+			cfg.App.DataStorePath = cfg.App.HomeDir + networkName + cfg.App.DataStorePath
+			cfg.Log.LogPath = cfg.App.HomeDir + networkName + cfg.Log.LogPath
+			cfg.App.ExportDataSubpath = cfg.App.HomeDir + networkName + cfg.App.ExportDataSubpath
+			cfg.App.ProfilePath = cfg.App.HomeDir + networkName + cfg.App.ProfilePath
+			cfg.App.PeersFile = cfg.App.HomeDir + networkName + cfg.App.PeersFile
+		}
 		cfg.App.ControlPanelFilesPath = cfg.App.HomeDir + cfg.App.ControlPanelFilesPath
 
 		s.LogPath = cfg.Log.LogPath + s.Prefix
 		s.LdbPath = cfg.App.LdbPath + s.Prefix
 		s.BoltDBPath = cfg.App.BoltDBPath + s.Prefix
+		s.DBEncryptionKey = cfg.App.DBEncryptionKey
+		s.DBEncryptionKeyFile = cfg.App.DBEncryptionKeyFile
+		s.DBCacheSize = cfg.App.DBCacheSize
+		s.EntryDBPath = cfg.App.EntryDBPath
+		s.BulkLoadBlocks = cfg.App.BulkLoadBlocks
 		s.LogLevel = cfg.Log.LogLevel
 		s.ConsoleLogLevel = cfg.Log.ConsoleLogLevel
+		s.LogFormat = cfg.Log.LogFormat
+		s.PackageLogLevels = cfg.PackageLogLevels()
 		s.NodeMode = cfg.App.NodeMode
-		s.DBType = cfg.App.DBType
+		s.DBType = NormalizeDBType(cfg.App.DBType)
 		s.ExportData = cfg.App.ExportData // bool
 		s.ExportDataSubpath = cfg.App.ExportDataSubpath
 		s.MainNetworkPort = cfg.App.MainNetworkPort
@@ -602,6 +820,9 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		s.TestSpecialPeers = cfg.App.TestSpecialPeers
 		s.CustomBootstrapIdentity = cfg.App.CustomBootstrapIdentity
 		s.CustomBootstrapKey = cfg.App.CustomBootstrapKey
+		if err := cfg.RegisterCustomNetworks(); err != nil {
+			fmt.Println(err)
+		}
 		s.LocalNetworkPort = cfg.App.LocalNetworkPort
 		s.LocalSeedURL = cfg.App.LocalSeedURL
 		s.LocalSpecialPeers = cfg.App.LocalSpecialPeers
@@ -612,16 +833,79 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		s.ControlPanelPort = cfg.App.ControlPanelPort
 		s.RpcUser = cfg.App.FactomdRpcUser
 		s.RpcPass = cfg.App.FactomdRpcPass
+		s.RpcToken = cfg.App.FactomdRpcToken
+		s.RpcAdminToken = cfg.App.FactomdAdminToken
+		s.RpcPublicReads = cfg.App.FactomdRpcPublicReads
 		s.StateSaverStruct.FastBoot = cfg.App.FastBoot
 		s.StateSaverStruct.FastBootLocation = cfg.App.FastBootLocation
 
 		s.FactomdTLSEnable = cfg.App.FactomdTlsEnabled
+		keyDir := cfg.App.HomeDir
+		if util.DataDir != "" {
+			keyDir = util.GetDataPaths(s.Network).Keys + "/"
+		}
 		if cfg.App.FactomdTlsPrivateKey == "/full/path/to/factomdAPIpriv.key" {
-			s.factomdTLSKeyFile = fmt.Sprint(cfg.App.HomeDir, "factomdAPIpriv.key")
+			s.factomdTLSKeyFile = fmt.Sprint(keyDir, "factomdAPIpriv.key")
 		}
 		if cfg.App.FactomdTlsPublicCert == "/full/path/to/factomdAPIpub.cert" {
-			s.factomdTLSCertFile = fmt.Sprint(cfg.App.HomeDir, "factomdAPIpub.cert")
+			s.factomdTLSCertFile = fmt.Sprint(keyDir, "factomdAPIpub.cert")
+		}
+		s.CorsDomains = nil
+		for _, domain := range strings.Split(cfg.App.CorsDomains, ",") {
+			if domain = strings.TrimSpace(domain); domain != "" {
+				s.CorsDomains = append(s.CorsDomains, domain)
+			}
 		}
+		s.CorsMethods = cfg.App.CorsMethods
+		s.ApiReadRateLimit = cfg.App.ApiReadRateLimit
+		s.ApiSubmitRateLimit = cfg.App.ApiSubmitRateLimit
+		s.ApiSunsets = map[string]time.Time{}
+		for _, sunset := range strings.Split(cfg.App.ApiSunsets, ",") {
+			if sunset = strings.TrimSpace(sunset); sunset == "" {
+				continue
+			}
+			parts := strings.SplitN(sunset, "=", 2)
+			if len(parts) == 2 {
+				if date, err := time.Parse("2006-01-02", strings.TrimSpace(parts[1])); err == nil {
+					s.ApiSunsets[strings.TrimSpace(parts[0])] = date
+					continue
+				}
+			}
+			fmt.Printf("Invalid ApiSunsets entry %q, expected \"<version>=<yyyy-mm-dd>\"\n", sunset)
+		}
+		s.GRPCPort = cfg.App.GRPCPort
+		s.AnchorConfig = cfg.Anchor
+		s.TelemetryConfig = cfg.Telemetry
+		s.EventSinks = cfg.EventSink
+		s.NetworkFaults = cfg.NetworkFault
+		s.ApiKeys = map[string]*interfaces.ApiKey{}
+		for name, c := range cfg.ApiKey {
+			if c.Key == "" {
+				fmt.Printf("ApiKey %q has no Key, and is left out\n", name)
+				continue
+			}
+			key := new(interfaces.ApiKey)
+			key.Name = name
+			key.Key = c.Key
+			key.RateLimit = c.RateLimit
+			for _, method := range strings.Split(c.Methods, ",") {
+				if method = strings.TrimSpace(method); method != "" {
+					key.Methods = append(key.Methods, strings.ToLower(method))
+				}
+			}
+			s.ApiKeys[name] = key
+		}
+		s.ProfilerEnabled = cfg.App.ProfilerEnabled
+		s.ProfilerBlockRate = cfg.App.ProfilerBlockRate
+		s.ProfilePath = cfg.App.ProfilePath
+		s.RichListEnabled = cfg.App.RichListEnabled
+		s.RichListSize = cfg.App.RichListSize
+		s.MessageTracing = cfg.App.MessageTracing
+		s.SlowMessageThreshold = time.Duration(cfg.App.SlowMessageThreshold) * time.Millisecond
+		s.AlertSyncStallMinutes = cfg.App.AlertSyncStallMinutes
+		s.LivenessChainID = cfg.App.LivenessChainID
+		s.LivenessECKey = cfg.App.LivenessECKey
+		s.LivenessBlocks = cfg.App.LivenessBlocks
 		externalIP := strings.Split(cfg.Walletd.FactomdLocation, ":")[0]
 		if externalIP != "localhost" {
 			s.FactomdLocations = externalIP
@@ -645,6 +929,17 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		} else {
 			s.IdentityChainID = identity
 		}
+		s.FollowerOnly = cfg.App.FollowerOnly
+		s.AckBatchSize = cfg.App.AckBatchSize
+		messages.RevealEntryCompressOver = cfg.App.CompressEntriesOver
+		s.dropAuthorityIdentity()
+		if cfg.App.StartAtHeight > 0 {
+			s.StartAt, err = NewTrustedCheckpoint(cfg.App.StartAtHeight, cfg.App.StartAtKeyMR, cfg.App.StartAtSnapshotHash)
+			if err != nil {
+				s.StartAt = nil
+				fmt.Fprintf(os.Stderr, "Ignoring StartAtHeight: %v\n", err)
+			}
+		}
 	} else {
 		s.LogPath = "database/"
 		s.LdbPath = "database/ldb"
@@ -655,24 +950,26 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		s.DBType = "Map"
 		s.ExportData = false
 		s.ExportDataSubpath = "data/export"
+		s.ProfilePath = "profiles/"
 		s.Network = "TEST"
-		s.MainNetworkPort = "8108"
 		s.PeersFile = "peers.json"
-		s.MainSeedURL = "https://raw.githubusercontent.com/FactomProject/factomproject.github.io/master/seed/mainseed.txt"
-		s.MainSpecialPeers = ""
-		s.TestNetworkPort = "8109"
-		s.TestSeedURL = "https://raw.githubusercontent.com/FactomProject/factomproject.github.io/master/seed/testseed.txt"
-		s.TestSpecialPeers = ""
-		s.LocalNetworkPort = "8110"
-		s.LocalSeedURL = "https://raw.githubusercontent.com/FactomProject/factomproject.github.io/master/seed/localseed.txt"
-		s.LocalSpecialPeers = ""
+		main, test, local := constants.LookupNetwork("MAIN"), constants.LookupNetwork("TEST"), constants.LookupNetwork("LOCAL")
+		s.MainNetworkPort = main.P2PPort
+		s.MainSeedURL = main.SeedURL
+		s.MainSpecialPeers = main.SpecialPeers
+		s.TestNetworkPort = test.P2PPort
+		s.TestSeedURL = test.SeedURL
+		s.TestSpecialPeers = test.SpecialPeers
+		s.LocalNetworkPort = local.P2PPort
+		s.LocalSeedURL = local.SeedURL
+		s.LocalSpecialPeers = local.SpecialPeers
 
 		s.LocalServerPrivKey = "4c38c72fc5cdad68f13b74674d3ffb1f3d63a112710868c9b08946553448d26d"
 		s.FactoshisPerEC = 006666
 		s.FERChainId = "111111118d918a8be684e0dac725493a75862ef96d2d3f43f84b26969329bf03"
 		s.ExchangeRateAuthorityPublicKey = "3b6a27bcceb6a42d62a3a8d02a6f0d73653215771de243a63ac048a18b59da29"
 		s.DirectoryBlockInSeconds = 6
-		s.PortNumber = 8088
+		s.PortNumber = test.APIPort
 		s.ControlPanelPort = 8090
 		s.ControlPanelSetting = 1
 
@@ -732,22 +1029,45 @@ func (s *State) Init() {
 	}
 
 	log.SetLevel(s.ConsoleLogLevel)
+	log.SetFormat(s.LogFormat)
+	for pkg, level := range s.PackageLogLevels {
+		log.SetPackageLevel(pkg, level)
+	}
+
+	s.SetMsgTracing(s.MessageTracing)
+
+	var err error
+	s.Events, err = events.NewEmitter(s.FactomNodeName, s.EventSinks)
+	if err != nil {
+		fmt.Println(err)
+	}
+	if s.LivenessChainID != "" {
+		s.Liveness, err = liveness.NewRecorder(s, s.LivenessChainID, s.LivenessECKey, s.LivenessBlocks)
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
 
+	if s.Clock == nil {
+		s.Clock = primitives.WallClock{}
+	}
 	s.ControlPanelChannel = make(chan DisplayState, 20)
 	s.tickerQueue = make(chan int, 100)                        //ticks from a clock
 	s.timerMsgQueue = make(chan interfaces.IMsg, 100)          //incoming eom notifications, used by leaders
 	s.TimeOffset = new(primitives.Timestamp)                   //interfaces.Timestamp(int64(rand.Int63() % int64(time.Microsecond*10)))
 	s.networkInvalidMsgQueue = make(chan interfaces.IMsg, 100) //incoming message queue from the network messages
 	s.InvalidMessages = make(map[[32]byte]interfaces.IMsg, 0)
-	s.networkOutMsgQueue = NewNetOutMsgQueue(1000)      //Messages to be broadcast to the network
-	s.inMsgQueue = NewInMsgQueue(10000)                 //incoming message queue for factom application messages
-	s.apiQueue = make(chan interfaces.IMsg, 100)        //incoming message queue from the API
-	s.ackQueue = make(chan interfaces.IMsg, 100)        //queue of Leadership messages
-	s.msgQueue = make(chan interfaces.IMsg, 400)        //queue of Follower messages
-	s.ShutdownChan = make(chan int, 1)                  //Channel to gracefully shut down.
-	s.MissingEntries = make(chan *MissingEntry, 1000)   //Entries I discover are missing from the database
-	s.UpdateEntryHash = make(chan *EntryUpdate, 10000)  //Handles entry hashes and updating Commit maps.
-	s.WriteEntry = make(chan interfaces.IEBEntry, 3000) //Entries to be written to the database
+	s.networkOutMsgQueue = NewNetOutMsgQueue(1000)          //Messages to be broadcast to the network
+	s.inMsgQueue = NewInMsgQueue(10000)                     //incoming message queue for factom application messages
+	s.apiQueue = make(chan interfaces.IMsg, 100)            //incoming message queue from the API
+	s.ackQueue = make(chan interfaces.IMsg, 100)            //queue of Leadership messages
+	s.prioritizedMsgQueue = make(chan interfaces.IMsg, 100) //queue of Consensus messages, processed ahead of Follower messages
+	s.msgQueue = make(chan interfaces.IMsg, 400)            //queue of Follower messages
+	s.ShutdownChan = make(chan int, 1)                      //Channel to gracefully shut down.
+	s.MissingEntries = make(chan *MissingEntry, 1000)       //Entries I discover are missing from the database
+	s.UpdateEntryHash = make(chan *EntryUpdate, 10000)      //Handles entry hashes and updating Commit maps.
+	s.WriteEntry = make(chan interfaces.IEBEntry, 3000)     //Entries to be written to the database
+	s.FactoidMempool = NewMempool(MempoolMaxTransactions, MempoolMaxBytes, MempoolExpiry)
 
 	if s.Journaling {
 		f, err := os.Create(s.JournalFile)
@@ -763,7 +1083,8 @@ func (s *State) Init() {
 	// Set up maps for the followers
 	s.Holding = make(map[[32]byte]interfaces.IMsg)
 	s.Acks = make(map[[32]byte]interfaces.IMsg)
-	s.Commits = make(map[[32]byte]interfaces.IMsg)
+	s.Commits = NewCommitBucket()
+	s.dumpRequests = make(chan chan *StateDump, 1)
 
 	// Setup the FactoidState and Validation Service that holds factoid and entry credit balances
 	s.FactoidBalancesP = map[[32]byte]int64{}
@@ -796,9 +1117,15 @@ func (s *State) Init() {
 		s.Println("   |       Leader Node       |")
 		s.Print("   +-------------------------+\n\n")
 	default:
-		panic("Bad Node Mode (must be FULL or SERVER)")
+		panic(fmt.Sprintf("Bad NodeMode %q in factomd.conf (must be FULL or SERVER)", s.NodeMode))
 	}
 
+	//Network, which the database is opened for
+	if err := s.setNetworkNumber(); err != nil {
+		panic(err.Error())
+	}
+	constants.SetActivationNetwork(s.NetworkNumber)
+
 	//Database
 	switch s.DBType {
 	case "LDB":
@@ -814,25 +1141,15 @@ func (s *State) Init() {
 			panic(fmt.Sprintf("Error initializing the database: %v", err))
 		}
 	default:
-		panic("No Database type specified")
+		panic(fmt.Sprintf("Unknown database type %q (must be LDB, Bolt, or Map)", s.DBType))
 	}
 
 	if s.ExportData {
 		s.DB.SetExportData(s.ExportDataSubpath)
 	}
 
-	//Network
-	switch s.Network {
-	case "MAIN":
-		s.NetworkNumber = constants.NETWORK_MAIN
-	case "TEST":
-		s.NetworkNumber = constants.NETWORK_TEST
-	case "LOCAL":
-		s.NetworkNumber = constants.NETWORK_LOCAL
-	case "CUSTOM":
-		s.NetworkNumber = constants.NETWORK_CUSTOM
-	default:
-		panic("Bad value for Network in factomd.conf")
+	if err := s.DB.CheckSchema(); err != nil {
+		panic(fmt.Sprintf("Error initializing the database: %v", err))
 	}
 
 	s.Println("\nRunning on the ", s.Network, "Network")
@@ -854,7 +1171,7 @@ func (s *State) Init() {
 		s.ExchangeRateAuthorityPublicKey = "3b6a27bcceb6a42d62a3a8d02a6f0d73653215771de243a63ac048a18b59da29"
 	}
 	// end of FER removal
-	s.starttime = time.Now()
+	s.starttime = s.GetClock().Now()
 
 	if s.StateSaverStruct.FastBoot {
 		d, err := s.DB.FetchDBlockHead()
@@ -1049,11 +1366,9 @@ func (s *State) LoadDBState(dbheight uint32) (interfaces.IMsg, error) {
 			if err == nil && eBlock != nil {
 				eBlocks = append(eBlocks, eBlock)
 				if s.Needed(eBlock) {
-					for _, e := range eBlock.GetEntryHashes() {
-						entry, err := s.DB.FetchEntry(e)
-						if err == nil && entry != nil {
-							entries = append(entries, entry)
-						}
+					ebEntries, err := s.DB.FetchEBlockEntries(eBlock)
+					if err == nil {
+						entries = append(entries, ebEntries...)
 					}
 				}
 			}
@@ -1174,13 +1489,13 @@ func (s *State) LoadHoldingMap() map[[32]byte]interfaces.IMsg {
 func (s *State) fillHoldingMap() {
 	// once a second is often enough to rebuild the Ack list exposed to api
 
-	if s.HoldingLast < time.Now().Unix() {
+	if s.HoldingLast < s.GetClock().Now().Unix() {
 
 		localMap := make(map[[32]byte]interfaces.IMsg)
 		for i, msg := range s.Holding {
 			localMap[i] = msg
 		}
-		s.HoldingLast = time.Now().Unix()
+		s.HoldingLast = s.GetClock().Now().Unix()
 		s.HoldingMutex.Lock()
 		defer s.HoldingMutex.Unlock()
 		s.HoldingMap = localMap
@@ -1199,24 +1514,37 @@ func (s *State) LoadAcksMap() map[[32]byte]interfaces.IMsg {
 
 }
 
+// GetReplayStats describes the replay filter, for the debug API.  The filter
+// has a lock of its own.
+func (s *State) GetReplayStats() *interfaces.ReplayStats {
+	return s.Replay.Stats()
+}
+
 // this is executed in the state maintenance processes where the Acks queue is in scope and can be queried
 //  This is what fills the AcksMap requested in LoadAcksMap
 func (s *State) fillAcksMap() {
 	// once a second is often enough to rebuild the Ack list exposed to api
-	if s.AcksLast < time.Now().Unix() {
+	if s.AcksLast < s.GetClock().Now().Unix() {
 		localMap := make(map[[32]byte]interfaces.IMsg)
 		for i, msg := range s.Acks {
 			localMap[i] = msg
 		}
-		s.AcksLast = time.Now().Unix()
+		s.AcksLast = s.GetClock().Now().Unix()
 		s.AcksMutex.Lock()
 		defer s.AcksMutex.Unlock()
 		s.AcksMap = localMap
 	}
 }
 
+// ackMinute returns the minute the i'th message of a VM was acknowledged in.
+func ackMinute(vm *VM, i int) int {
+	if i < len(vm.ListAck) && vm.ListAck[i] != nil {
+		return int(vm.ListAck[i].Minute)
+	}
+	return -1
+}
+
 func (s *State) GetPendingEntries(params interface{}) []interfaces.IPendingEntry {
-	fmt.Println("GetPendingEntries")
 	resp := make([]interfaces.IPendingEntry, 0)
 	pls := s.ProcessLists.Lists
 	var cc messages.CommitChainMsg
@@ -1229,7 +1557,12 @@ func (s *State) GetPendingEntries(params interface{}) []interfaces.IPendingEntry
 		if pl != nil {
 			if pl.DBHeight > LastComplete {
 				for _, v := range pl.VMs {
-					for _, plmsg := range v.List {
+					for j, plmsg := range v.List {
+						if plmsg == nil {
+							continue
+						}
+						tmp.DBHeight = pl.DBHeight
+						tmp.Minute = ackMinute(v, j)
 						if plmsg.Type() == constants.COMMIT_CHAIN_MSG { //5
 							enb, err := plmsg.MarshalBinary()
 							if err != nil {
@@ -1248,7 +1581,7 @@ func (s *State) GetPendingEntries(params interface{}) []interfaces.IPendingEntry
 								tmp.Status = "AckStatusDBlockConfirmed"
 							}
 
-							if util.IsInPendingEntryList(resp, tmp) {
+							if !util.IsInPendingEntryList(resp, tmp) {
 								resp = append(resp, tmp)
 							}
 						} else if plmsg.Type() == constants.COMMIT_ENTRY_MSG { //6
@@ -1315,6 +1648,8 @@ func (s *State) GetPendingEntries(params interface{}) []interfaces.IPendingEntry
 
 			tmp.ChainID = re.Entry.GetChainID()
 			tmp.Status = "AckStatusNotConfirmed"
+			tmp.DBHeight = 0
+			tmp.Minute = -1
 			if !util.IsInPendingEntryList(resp, tmp) {
 				resp = append(resp, tmp)
 			}
@@ -1334,31 +1669,33 @@ func (s *State) GetPendingTransactions(params interface{}) []interfaces.IPending
 		if pl != nil {
 			// ignore old process lists
 			if pl.DBHeight > currentHeightComplete {
-				cb := pl.State.FactoidState.GetCurrentBlock()
-				ct := cb.GetTransactions()
-				for _, tran := range ct {
-					var tmp interfaces.IPendingTransaction
-					tmp.TransactionID = tran.GetSigHash()
-					if tran.GetBlockHeight() > 0 {
-						tmp.Status = "AckStatusDBlockConfirmed"
-					} else {
+				for _, v := range pl.VMs {
+					for j, plmsg := range v.List {
+						if plmsg == nil || plmsg.Type() != constants.FACTOID_TRANSACTION_MSG {
+							continue
+						}
+						tran := plmsg.(*messages.FactoidTransaction).GetTransaction()
+						var tmp interfaces.IPendingTransaction
+						tmp.TransactionID = tran.GetSigHash()
 						tmp.Status = "AckStatusACK"
-					}
-					if params.(string) == "" {
-						flgFound = true
-					} else {
-						flgFound = tran.HasUserAddress(params.(string))
-					}
-					if flgFound == true {
-						//working through multiple process lists.  Is this transaction already in the list?
-						for _, pt := range resp {
-							if pt.TransactionID.String() == tmp.TransactionID.String() {
-								flgFound = false
-							}
+						tmp.DBHeight = pl.DBHeight
+						tmp.Minute = ackMinute(v, j)
+						if params.(string) == "" {
+							flgFound = true
+						} else {
+							flgFound = tran.HasUserAddress(params.(string))
 						}
-						//  flag was true to be added to the list and not already in the list
 						if flgFound == true {
-							resp = append(resp, tmp)
+							//working through multiple process lists.  Is this transaction already in the list?
+							for _, pt := range resp {
+								if pt.TransactionID.String() == tmp.TransactionID.String() {
+									flgFound = false
+								}
+							}
+							//  flag was true to be added to the list and not already in the list
+							if flgFound == true {
+								resp = append(resp, tmp)
+							}
 						}
 					}
 				}
@@ -1382,7 +1719,8 @@ func (s *State) GetPendingTransactions(params interface{}) []interfaces.IPending
 			var tmp interfaces.IPendingTransaction
 			tmp.TransactionID = tempTran.GetSigHash()
 			tmp.Status = "AckStatusNotConfirmed"
-			flgFound = tempTran.HasUserAddress(params.(string))
+			tmp.Minute = -1
+			flgFound = params.(string) == "" || tempTran.HasUserAddress(params.(string))
 
 			if flgFound == true {
 				//working through multiple process lists.  Is this transaction already in the list?
@@ -1597,13 +1935,16 @@ func (s *State) UpdateState() (progress bool) {
 	}
 
 	// Update our TPS every ~ 3 seconds at the earliest
-	if s.lasttime.Before(time.Now().Add(-3 * time.Second)) {
+	if s.lasttime.Before(s.GetClock().Now().Add(-3 * time.Second)) {
 		s.CalculateTransactionRate()
 	}
+	s.checkSyncStall()
 
 	// check to see ig a holding queue list request has been made
 	s.fillHoldingMap()
 	s.fillAcksMap()
+	s.answerDumpRequests()
+	s.flushAckBatches()
 
 entryHashProcessing:
 	for {
@@ -1613,7 +1954,7 @@ entryHashProcessing:
 			s.Replay.SetHashNow(constants.REVEAL_REPLAY, e.Hash.Fixed(), e.Timestamp)
 			// If the save worked, then remove any commit that might be around.
 			if !s.Replay.IsHashUnique(constants.REVEAL_REPLAY, e.Hash.Fixed()) {
-				delete(s.Commits, e.Hash.Fixed())
+				s.Commits.Delete(e.Hash.Fixed())
 			}
 		default:
 			break entryHashProcessing
@@ -1690,6 +2031,23 @@ func (s *State) IsLeader() bool {
 	return s.Leader
 }
 
+// GetNodeRole returns "leader" if this node is a federated server in the
+// current process list, "audit" if it is an audit server, and "follower"
+// otherwise.
+func (s *State) GetNodeRole() string {
+	pl := s.ProcessLists.Get(s.LLeaderHeight)
+	if pl == nil {
+		return "follower"
+	}
+	if found, _ := pl.GetFedServerIndexHash(s.GetIdentityChainID()); found {
+		return "leader"
+	}
+	if found, _ := pl.GetAuditServerIndexHash(s.GetIdentityChainID()); found {
+		return "audit"
+	}
+	return "follower"
+}
+
 func (s *State) GetVirtualServers(dbheight uint32, minute int, identityChainID interfaces.IHash) (bool, int) {
 	pl := s.ProcessLists.Get(dbheight)
 	return pl.GetVirtualServers(minute, identityChainID)
@@ -1711,6 +2069,14 @@ func (s *State) SetIdentityChainID(chainID interfaces.IHash) {
 	s.IdentityChainID = chainID
 }
 
+// dropAuthorityIdentity gives a FollowerOnly node a random identity in place
+// of the one it was given, so that it is never in the authority set.
+func (s *State) dropAuthorityIdentity() {
+	if s.FollowerOnly {
+		s.IdentityChainID = primitives.RandomHash()
+	}
+}
+
 func (s *State) GetDirectoryBlockInSeconds() int {
 	return s.DirectoryBlockInSeconds
 }
@@ -1760,6 +2126,15 @@ func (s *State) initServerKeys() {
 	s.serverPubKey = s.serverPrivKey.Pub
 }
 
+// Log returns the structured logger of the state, with the node and the
+// height it is at.
+func (s *State) Log() *log.Logger {
+	return log.ForPackage(log.PackageState).WithFields(log.Fields{
+		log.FieldNodeID: s.FactomNodeName,
+		log.FieldHeight: s.LLeaderHeight,
+	})
+}
+
 func (s *State) LogInfo(args ...interface{}) {
 	s.Logger.Info(args...)
 }
@@ -1783,7 +2158,21 @@ func (s *State) GetTimestamp() interfaces.Timestamp {
 		fmt.Println("^^^^^^^^ IsReplying is true")
 		return s.ReplayTimestamp
 	}
-	return primitives.NewTimestampNow()
+	return primitives.NewTimestampFromClock(s.GetClock())
+}
+
+// GetClock returns the clock the node takes the time from.
+func (s *State) GetClock() interfaces.IClock {
+	if s.Clock == nil {
+		return primitives.WallClock{}
+	}
+	return s.Clock
+}
+
+// SetClock sets the clock of the node, such as a primitives.FakeClock that a
+// test advances by hand.
+func (s *State) SetClock(c interfaces.IClock) {
+	s.Clock = c
 }
 
 func (s *State) GetTimeOffset() interfaces.Timestamp {
@@ -1839,6 +2228,10 @@ func (s *State) AckQueue() chan interfaces.IMsg {
 	return s.ackQueue
 }
 
+func (s *State) PrioritizedMsgQueue() chan interfaces.IMsg {
+	return s.prioritizedMsgQueue
+}
+
 func (s *State) MsgQueue() chan interfaces.IMsg {
 	return s.msgQueue
 }
@@ -1912,6 +2305,23 @@ func (s *State) GetNetworkName() string {
 	return "" // Shouldn't ever get here
 }
 
+// setNetworkNumber sets NetworkNumber from the configured Network.
+func (s *State) setNetworkNumber() error {
+	switch s.Network {
+	case "MAIN":
+		s.NetworkNumber = constants.NETWORK_MAIN
+	case "TEST":
+		s.NetworkNumber = constants.NETWORK_TEST
+	case "LOCAL":
+		s.NetworkNumber = constants.NETWORK_LOCAL
+	case "CUSTOM":
+		s.NetworkNumber = constants.NETWORK_CUSTOM
+	default:
+		return fmt.Errorf("Bad value %q for Network in factomd.conf (must be MAIN, TEST, LOCAL or CUSTOM)", s.Network)
+	}
+	return nil
+}
+
 func (s *State) GetNetworkID() uint32 {
 	switch s.NetworkNumber {
 	case constants.NETWORK_MAIN:
@@ -1997,6 +2407,17 @@ func (s *State) InitLevelDB() error {
 		return nil
 	}
 
+	dbase, err := s.openLevelDB()
+	if err != nil {
+		return err
+	}
+
+	s.DB = databaseOverlay.NewOverlay(s.cacheDB(dbase))
+	return nil
+}
+
+// openLevelDB opens, or creates, the LevelDB database for this node's network.
+func (s *State) openLevelDB() (interfaces.IDatabase, error) {
 	path := s.LdbPath + "/" + s.Network + "/" + "factoid_level.db"
 
 	s.Println("Database:", path)
@@ -2006,12 +2427,90 @@ func (s *State) InitLevelDB() error {
 	if err != nil || dbase == nil {
 		dbase, err = leveldb.NewLevelDB(path, true)
 		if err != nil {
-			return err
+			return nil, err
+		}
+	}
+	split, err := s.splitDB(dbase, "LDB")
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encryptDB(split)
+	if err != nil {
+		split.Close()
+		return nil, err
+	}
+	return s.namespaceDB(encrypted)
+}
+
+// splitDB keeps the entries in a database of the given type under EntryDBPath,
+// apart from dbase, if an entries path is configured.  Otherwise it returns
+// dbase as is.  dbase is closed if the entries database can't be opened.
+func (s *State) splitDB(dbase interfaces.IDatabase, dbType string) (interfaces.IDatabase, error) {
+	if s.EntryDBPath == "" {
+		return dbase, nil
+	}
+	path := s.EntryDBPath + "/" + s.Network + "/"
+	s.Println("Entry database path is", path)
+
+	var entries interfaces.IDatabase
+	switch dbType {
+	case "LDB":
+		ldb, err := leveldb.NewLevelDB(path+"entries_level.db", false)
+		if err != nil || ldb == nil {
+			ldb, err = leveldb.NewLevelDB(path+"entries_level.db", true)
+			if err != nil {
+				dbase.Close()
+				return nil, err
+			}
 		}
+		entries = ldb
+	case "Bolt":
+		os.MkdirAll(path, 0777)
+		bolt := new(boltdb.BoltDB)
+		bolt.Init(nil, path+"FactomEntries.db")
+		entries = bolt
+	default:
+		dbase.Close()
+		return nil, fmt.Errorf("Cannot keep entries in a %q database", dbType)
 	}
+	return splitDB.NewSplitDB(dbase, entries, databaseOverlay.IsEntryBucket), nil
+}
 
-	s.DB = databaseOverlay.NewOverlay(dbase)
-	return nil
+// encryptDB puts an encrypting HybridDB in front of dbase if a database
+// encryption key is configured, and returns dbase as is otherwise.
+func (s *State) encryptDB(dbase interfaces.IDatabase) (interfaces.IDatabase, error) {
+	key, err := hybridDB.LoadEncryptionKey(s.DBEncryptionKey, s.DBEncryptionKeyFile)
+	if err != nil || key == nil {
+		return dbase, err
+	}
+	s.Println("Database values are encrypted")
+	return hybridDB.NewEncryptedHybridDB(dbase, key)
+}
+
+// namespaceDB returns the namespace of this node's network in dbase, which is
+// closed if the namespace can't be opened.  A database is shared by the
+// custom networks, which all keep it under the same Network name.
+func (s *State) namespaceDB(dbase interfaces.IDatabase) (interfaces.IDatabase, error) {
+	namespace, err := databaseOverlay.NetworkNamespace(dbase, s.GetNetworkID())
+	if err != nil {
+		dbase.Close()
+		return nil, err
+	}
+	return namespace, nil
+}
+
+// NormalizeDBType maps the accepted spellings of a database type onto the names
+// used internally: "LDB", "Bolt", or "Map".  Unknown types are returned as is.
+func NormalizeDBType(dbType string) string {
+	switch strings.ToLower(strings.TrimSpace(dbType)) {
+	case "ldb", "leveldb":
+		return "LDB"
+	case "bolt", "boltdb":
+		return "Bolt"
+	case "map", "mapdb":
+		return "Map"
+	}
+	return dbType
 }
 
 func (s *State) InitBoltDB() error {
@@ -2019,6 +2518,54 @@ func (s *State) InitBoltDB() error {
 		return nil
 	}
 
+	dbase, err := s.openBoltDB()
+	if err != nil {
+		return err
+	}
+
+	s.DB = databaseOverlay.NewOverlay(s.cacheDB(dbase))
+	return nil
+}
+
+// cacheDB puts a read cache of DBCacheSize values in front of dbase, if the
+// cache size isn't 0.
+func (s *State) cacheDB(dbase interfaces.IDatabase) interfaces.IDatabase {
+	if s.DBCacheSize <= 0 {
+		return dbase
+	}
+	return cacheDB.NewCacheDB(dbase, s.DBCacheSize)
+}
+
+// bulkLoadSyncInterval is the number of blocks saved between flushes to disk
+// while bulk loading.
+const bulkLoadSyncInterval = 1000
+
+// updateBulkLoad puts the database in bulk load mode while the node is more
+// than BulkLoadBlocks behind the highest block it knows of, and takes it out
+// once it catches up.  While bulk loading, the database is flushed to disk
+// every bulkLoadSyncInterval blocks, so a crash loses at most that many.
+func (s *State) updateBulkLoad(saved uint32) error {
+	if s.BulkLoadBlocks <= 0 {
+		return nil
+	}
+	behind := s.GetHighestKnownBlock() > saved+uint32(s.BulkLoadBlocks)
+	switch {
+	case behind && !s.bulkLoading:
+		s.bulkLoading = true
+		s.Println("Bulk loading the database from height", saved, "to", s.GetHighestKnownBlock())
+		return s.DB.SetBulkLoad(true)
+	case !behind && s.bulkLoading:
+		s.bulkLoading = false
+		s.Println("Bulk loading of the database done at height", saved)
+		return s.DB.SetBulkLoad(false)
+	case s.bulkLoading && saved%bulkLoadSyncInterval == 0:
+		return s.DB.SetBulkLoad(true)
+	}
+	return nil
+}
+
+// openBoltDB opens, or creates, the Bolt database for this node's network.
+func (s *State) openBoltDB() (interfaces.IDatabase, error) {
 	path := s.BoltDBPath + "/" + s.Network + "/"
 
 	s.Println("Database Path for", s.FactomNodeName, "is", path)
@@ -2026,8 +2573,16 @@ func (s *State) InitBoltDB() error {
 
 	dbase := new(boltdb.BoltDB)
 	dbase.Init(nil, path+"FactomBolt.db")
-	s.DB = databaseOverlay.NewOverlay(dbase)
-	return nil
+	split, err := s.splitDB(dbase, "Bolt")
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encryptDB(split)
+	if err != nil {
+		split.Close()
+		return nil, err
+	}
+	return s.namespaceDB(encrypted)
 }
 
 func (s *State) InitMapDB() error {
@@ -2101,15 +2656,15 @@ func (s *State) SetStringConsensus() {
 //		totalTPS	: Transaction rate over life of node (totaltime / totaltrans)
 //		instantTPS	: Transaction rate weighted over last 3 seconds
 func (s *State) CalculateTransactionRate() (totalTPS float64, instantTPS float64) {
-	runtime := time.Since(s.starttime)
-	shorttime := time.Since(s.lasttime)
+	runtime := s.GetClock().Since(s.starttime)
+	shorttime := s.GetClock().Since(s.lasttime)
 	total := s.FactoidTrans + s.NewEntryChains + s.NewEntries
 	tps := float64(total) / float64(runtime.Seconds())
 	TotalTransactionPerSecond.Set(tps) // Prometheus
 	if shorttime > time.Second*3 {
 		delta := (s.FactoidTrans + s.NewEntryChains + s.NewEntries) - s.transCnt
 		s.tps = ((float64(delta) / float64(shorttime.Seconds())) + 2*s.tps) / 3
-		s.lasttime = time.Now()
+		s.lasttime = s.GetClock().Now()
 		s.transCnt = total                     // transactions accounted for
 		InstantTransactionPerSecond.Set(s.tps) // Prometheus
 	}
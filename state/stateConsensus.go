@@ -43,28 +43,46 @@ func (s *State) executeMsg(vm *VM, msg interfaces.IMsg) (ret bool) {
 		}
 	}
 
-	switch msg.Validate(s) {
+	if dbstate, ok := msg.(*messages.DBStateMsg); ok {
+		s.checkDBStateFork(dbstate)
+	}
+
+	valid := msg.Validate(s)
+	leader := s.leaderReady(vm) && (msg.IsLocal() || msg.GetVMIndex() == s.LeaderVMIndex)
+	ft, isFactoid := msg.(*messages.FactoidTransaction)
+	switch valid {
 	case 1:
-		if s.RunLeader &&
-			s.Leader &&
-			!s.Saving &&
-			vm != nil && int(vm.Height) == len(vm.List) &&
-			(!s.Syncing || !vm.Synced) &&
-			(msg.IsLocal() || msg.GetVMIndex() == s.LeaderVMIndex) &&
-			s.LeaderPL.DBHeight+1 >= s.GetHighestKnownBlock() {
+		s.logRequestMsg(msg, "execute")
+		s.TraceMsg(msg, TraceValidated)
+		if leader {
 			if len(vm.List) == 0 {
 				s.SendDBSig(s.LLeaderHeight, s.LeaderVMIndex)
 				s.XReview = append(s.XReview, msg)
+			} else if isFactoid {
+				s.logRequestMsg(msg, "mempool")
+				s.addToMempool(ft)
 			} else {
+				start := time.Now()
 				msg.LeaderExecute(s)
+				s.checkSlowMsg(msg, StepLeaderExecute, start)
 			}
 		} else {
+			start := time.Now()
 			msg.FollowerExecute(s)
+			s.checkSlowMsg(msg, StepFollowerExecute, start)
 		}
 		ret = true
 	case 0:
+		// A factoid transaction the leader can't validate yet waits in the
+		// mempool if it is waiting on one there to fund it.
+		if leader && isFactoid && s.fundedByMempool(ft) && s.addToMempool(ft) {
+			s.logRequestMsg(msg, "mempool")
+			break
+		}
+		s.logRequestMsg(msg, "holding")
 		s.Holding[msg.GetMsgHash().Fixed()] = msg
 	default:
+		s.logRequestMsg(msg, "invalid")
 		s.Holding[msg.GetMsgHash().Fixed()] = msg
 		if !msg.SentInvlaid() {
 			msg.MarkSentInvalid(true)
@@ -76,6 +94,76 @@ func (s *State) executeMsg(vm *VM, msg interfaces.IMsg) (ret bool) {
 
 }
 
+// leaderReady is true if this node leads a VM and may ack messages for it now.
+func (s *State) leaderReady(vm *VM) bool {
+	return s.RunLeader &&
+		s.Leader &&
+		!s.Saving &&
+		vm != nil && int(vm.Height) == len(vm.List) &&
+		(!s.Syncing || !vm.Synced) &&
+		s.LeaderPL.DBHeight+1 >= s.GetHighestKnownBlock()
+}
+
+// addToMempool puts a factoid transaction in the mempool, and returns
+// whether it is there.
+func (s *State) addToMempool(msg *messages.FactoidTransaction) bool {
+	err := s.FactoidMempool.Add(msg, s.GetFactoshisPerEC())
+	if err != nil && err != ErrMempoolDuplicate {
+		if s.Logger != nil {
+			s.Logger.Warningf("Dropped factoid transaction %s: %v", msg.Transaction.GetSigHash().String(), err)
+		}
+		return false
+	}
+	return true
+}
+
+// fundedByMempool returns true if each input of the transaction the balance
+// of its address doesn't cover is paid to by a transaction in the mempool.
+func (s *State) fundedByMempool(msg *messages.FactoidTransaction) bool {
+	needs := make(map[[32]byte]int64)
+	for _, input := range msg.Transaction.GetInputs() {
+		needs[input.GetAddress().Fixed()] += int64(input.GetAmount())
+	}
+	for adr, amount := range needs {
+		if s.FactoidState.GetSpendableFactoidBalance(adr) < amount && !s.FactoidMempool.Pays(adr) {
+			return false
+		}
+	}
+	return true
+}
+
+// assembleFactoidTransactions acks the factoid transactions in the mempool
+// that can go into the block now, if this node leads the factoid VM.  If
+// another node leads it, the transactions wait in holding for its acks.
+func (s *State) assembleFactoidTransactions(vm *VM) {
+	s.FactoidMempool.Expire(s.GetClock().Now())
+	if s.FactoidMempool.Len() == 0 {
+		return
+	}
+
+	if !s.Leader || s.ComputeVMIndex(constants.FACTOID_CHAINID) != s.LeaderVMIndex {
+		for _, msg := range s.FactoidMempool.Drain() {
+			msg.ComputeVMIndex(s)
+			start := time.Now()
+			msg.FollowerExecute(s)
+			s.checkSlowMsg(msg, StepFollowerExecute, start)
+		}
+		return
+	}
+	if !s.leaderReady(vm) || len(vm.List) == 0 {
+		return
+	}
+
+	balance := s.FactoidState.GetSpendableFactoidBalance
+	for _, msg := range s.FactoidMempool.Next(balance) {
+		if msg.Validate(s) == 1 {
+			start := time.Now()
+			msg.LeaderExecute(s)
+			s.checkSlowMsg(msg, StepLeaderExecute, start)
+		}
+	}
+}
+
 func (s *State) Process() (progress bool) {
 
 	if s.ResetRequest {
@@ -167,9 +255,20 @@ ackLoop:
 		}
 	}
 
-	// Process inbound messages
+	// Process inbound messages.  Consensus messages always go ahead of
+	// commits, reveals, and transactions, so user load can't hold up the
+	// closing of a block.
 emptyLoop:
 	for room() {
+		select {
+		case msg := <-s.prioritizedMsgQueue:
+			if s.executeMsg(vm, msg) && !msg.IsPeer2Peer() {
+				msg.SendOut(s, msg)
+			}
+			continue
+		default:
+		}
+
 		select {
 		case msg := <-s.msgQueue:
 
@@ -181,6 +280,8 @@ emptyLoop:
 		}
 	}
 
+	s.assembleFactoidTransactions(vm)
+
 	// Reprocess any stalled messages, but not so much compared inbound messages
 	// Process last first
 skipreview:
@@ -445,11 +546,15 @@ func (s *State) FollowerExecuteAck(msg interfaces.IMsg) {
 	if pl == nil {
 		return
 	}
+	if ack.VMIndex < 0 || ack.VMIndex >= len(pl.VMs) {
+		return
+	}
 	list := pl.VMs[ack.VMIndex].List
 	if len(list) > int(ack.Height) && list[ack.Height] != nil {
 		return
 	}
 
+	s.noteLeaderOrigin(ack.LeaderChainID, ack.GetOrigin())
 	s.Acks[ack.GetHash().Fixed()] = ack
 	m, _ := s.Holding[ack.GetHash().Fixed()]
 	if m != nil {
@@ -613,6 +718,12 @@ func (s *State) FollowerExecuteMMR(m interfaces.IMsg) {
 	}
 
 	ack, ok := mmr.AckResponse.(*messages.Ack)
+	if batch, isBatch := mmr.AckResponse.(*messages.AckBatch); isBatch && mmr.MsgResponse != nil {
+		// A batched ack comes in the batch that signs for it
+		if batch.Validate(s) == 1 {
+			ack, ok = batch.AckOf(mmr.MsgResponse.GetMsgHash())
+		}
+	}
 
 	// If we don't need this message, we don't have to do everything else.
 	if !ok || ack.Validate(s) == -1 {
@@ -671,15 +782,21 @@ func (s *State) FollowerExecuteDataResponse(m interfaces.IMsg) {
 			return
 		}
 
+		s.MissingEntryBlocksMutex.Lock()
+		defer s.MissingEntryBlocksMutex.Unlock()
 		for i, missing := range s.MissingEntryBlocks {
 			eb := missing.EBHash
 			if !eb.IsSameAs(ebKeyMR) {
 				continue
 			}
 
-			db, err := s.DB.FetchDBlockByHeight(eblock.GetHeader().GetDBHeight())
-			if err != nil || db == nil {
-				return
+			// A block not yet saved takes it, to be saved with the rest of it
+			added := s.DBStates.AddEntryBlock(eblock)
+			if !added {
+				db, err := s.DB.FetchDBlockByHeight(eblock.GetHeader().GetDBHeight())
+				if err != nil || db == nil {
+					return
+				}
 			}
 
 			var missing []MissingEntryBlock
@@ -687,7 +804,9 @@ func (s *State) FollowerExecuteDataResponse(m interfaces.IMsg) {
 			missing = append(missing, s.MissingEntryBlocks[i+1:]...)
 			s.MissingEntryBlocks = missing
 
-			s.DB.ProcessEBlockBatch(eblock, true)
+			if !added {
+				s.DB.ProcessEBlockBatch(eblock, true)
+			}
 
 			break
 		}
@@ -730,6 +849,14 @@ func (s *State) FollowerExecuteMissingMsg(msg interfaces.IMsg) {
 	for _, h := range m.ProcessListHeight {
 		missingmsg, ackMsg, err := s.LoadSpecificMsgAndAck(m.DBHeight, m.VMIndex, h)
 
+		// A batched ack is answered with the batch that signs for it
+		if ack, ok := ackMsg.(*messages.Ack); ok && ack.GetSignature() == nil {
+			if ack.GetBatch() == nil {
+				continue // Our own ack, not yet sent in a batch
+			}
+			ackMsg = ack.GetBatch()
+		}
+
 		if missingmsg != nil && ackMsg != nil && err == nil {
 			// If I don't have this message, ignore.
 			msgResponse := messages.NewMissingMsgResponse(s, missingmsg, ackMsg)
@@ -773,7 +900,7 @@ func (s *State) FollowerExecuteRevealEntry(m interfaces.IMsg) {
 
 	if ack != nil {
 		m.SendOut(s, m)
-		ack.SendOut(s, ack)
+		s.sendAck(ack)
 		m.SetLeaderChainID(ack.GetLeaderChainID())
 		m.SetMinute(ack.Minute)
 
@@ -781,7 +908,7 @@ func (s *State) FollowerExecuteRevealEntry(m interfaces.IMsg) {
 		pl.AddToProcessList(ack, m)
 
 		msg := m.(*messages.RevealEntryMsg)
-		delete(s.Commits, msg.Entry.GetHash().Fixed())
+		s.Commits.Delete(msg.Entry.GetHash().Fixed())
 		// Okay the Reveal has been recorded.  Record this as an entry that cannot be duplicated.
 		s.Replay.IsTSValid_(constants.REVEAL_REPLAY, msg.Entry.GetHash().Fixed(), msg.Timestamp, s.GetTimestamp())
 
@@ -952,7 +1079,7 @@ func (s *State) LeaderExecuteRevealEntry(m interfaces.IMsg) {
 	} else {
 		// Okay the Reveal has been recorded.  Record this as an entry that cannot be duplicated.
 		s.Replay.IsTSValid_(constants.REVEAL_REPLAY, eh.Fixed(), m.GetTimestamp(), now)
-		delete(s.Commits, eh.Fixed())
+		s.Commits.Delete(eh.Fixed())
 	}
 }
 
@@ -1062,7 +1189,7 @@ func (s *State) ProcessRevealEntry(dbheight uint32, m interfaces.IMsg) bool {
 
 	chainID := msg.Entry.GetChainID()
 
-	delete(s.Commits, msg.Entry.GetHash().Fixed())
+	s.Commits.Delete(msg.Entry.GetHash().Fixed())
 
 	eb := s.GetNewEBlocks(dbheight, chainID)
 	eb_db := s.GetNewEBlocks(dbheight-1, chainID)
@@ -1087,6 +1214,7 @@ func (s *State) ProcessRevealEntry(dbheight uint32, m interfaces.IMsg) bool {
 
 		s.IncEntryChains()
 		s.IncEntries()
+		s.emitEntryReveal(dbheight, msg.Entry)
 		return true
 	}
 
@@ -1118,6 +1246,7 @@ func (s *State) ProcessRevealEntry(dbheight uint32, m interfaces.IMsg) bool {
 	LoadIdentityByEntry(msg.Entry, s, dbheight, false)
 
 	s.IncEntries()
+	s.emitEntryReveal(dbheight, msg.Entry)
 	return true
 }
 
@@ -1273,10 +1402,9 @@ func (s *State) ProcessEOM(dbheight uint32, msg interfaces.IMsg) bool {
 
 		s.FactoidState.EndOfPeriod(int(e.Minute))
 
-		ecblk := pl.EntryCreditBlock
-		ecbody := ecblk.GetBody()
-		mn := entryCreditBlock.NewMinuteNumber(e.Minute + 1)
-		ecbody.AddEntry(mn)
+		// Record the end of the minute in the EC block, so the entries can
+		// be ordered by minute when the block is read back.
+		pl.EntryCreditBlock.GetBody().AddEndOfMinuteMarker(e.Minute + 1)
 
 		if !s.Leader {
 			s.CurrentMinute = int(e.Minute)
@@ -1294,6 +1422,9 @@ func (s *State) ProcessEOM(dbheight uint32, msg interfaces.IMsg) bool {
 			}
 			s.LeaderPL = s.ProcessLists.Get(s.LLeaderHeight)
 			s.Leader, s.LeaderVMIndex = s.LeaderPL.GetVirtualServers(s.CurrentMinute, s.IdentityChainID)
+			if err := s.SaveProcessList(); err != nil && s.Logger != nil {
+				s.Logger.Warningf("Could not save the process list of block %d: %v", s.LLeaderHeight, err)
+			}
 		case s.CurrentMinute == 10:
 			eBlocks := []interfaces.IEntryBlock{}
 			entries := []interfaces.IEBEntry{}
@@ -1355,11 +1486,11 @@ func (s *State) ProcessEOM(dbheight uint32, msg interfaces.IMsg) bool {
 			s.Saving = true
 		}
 
-		for k, v := range s.Commits {
+		for k, v := range s.Commits.Copy() {
 			if v != nil {
 				_, ok := s.Replay.Valid(constants.TIME_TEST, v.GetRepeatHash().Fixed(), v.GetTimestamp(), s.GetTimestamp())
 				if !ok {
-					delete(s.Commits, k)
+					s.Commits.Delete(k)
 				}
 			}
 		}
@@ -1391,6 +1522,7 @@ func (s *State) CheckForIDChange() {
 		}
 		s.LocalServerPrivKey = config.App.LocalServerPrivKey
 		s.initServerKeys()
+		s.dropAuthorityIdentity()
 	}
 }
 
@@ -1483,6 +1615,7 @@ func (s *State) ProcessDBSig(dbheight uint32, msg interfaces.IMsg) bool {
 
 		if dbs.DirectoryBlockHeader.GetBodyMR().Fixed() != dblk.GetHeader().GetBodyMR().Fixed() {
 			pl.IncrementDiffSigTally()
+			s.checkDBSigFork(dbs, dblk)
 			return false
 		}
 
@@ -1502,6 +1635,7 @@ func (s *State) ProcessDBSig(dbheight uint32, msg interfaces.IMsg) bool {
 
 		dbs.Matches = true
 		s.AddDBSig(dbheight, dbs.ServerIdentityChainID, dbs.DBSignature)
+		s.WatchSignedBlock(dblk.GetDatabaseHeight(), dblk.GetKeyMR(), ForkSourceLocal, dbs.ServerIdentityChainID.String())
 
 		dbs.Processed = true
 		s.DBSigProcessed++
@@ -1683,10 +1817,10 @@ func (s *State) ProcessFullServerFault(dbheight uint32, msg interfaces.IMsg) boo
 				pl.State.AddAuthorityDelta(authorityDeltaString)
 				//s.AddStatus(authorityDeltaString)
 
-				pl.State.LastFaultAction = time.Now().Unix()
+				pl.State.LastFaultAction = s.GetClock().Now().Unix()
 				markNoFault(pl, fullFault.GetVMIndex())
 				nextIndex := (int(fullFault.VMIndex) + 1) % len(pl.FedServers)
-				if pl.VMs[nextIndex].FaultFlag > 0 {
+				if pl.VMs[nextIndex].FaultFlag == 1 {
 					markNoFault(pl, nextIndex)
 				}
 
@@ -1753,7 +1887,7 @@ func (s *State) ProcessFullServerFault(dbheight uint32, msg interfaces.IMsg) boo
 
 		if s.Leader || s.IdentityChainID.IsSameAs(fullFault.AuditServerID) {
 			if !fullFault.GetMyVoteTallied() {
-				now := time.Now().Unix()
+				now := s.GetClock().Now().Unix()
 				if now-fullFault.LastMatch > 5 && int(now-s.LastTiebreak) > s.FaultTimeout/2 {
 					if fullFault.SigTally(s) >= len(pl.FedServers)-1 {
 						s.LastTiebreak = now
@@ -1798,7 +1932,7 @@ func (s *State) SendHeartBeat() {
 		if auditServer.GetChainID().IsSameAs(s.IdentityChainID) {
 			hb := new(messages.Heartbeat)
 			hb.DBHeight = s.LLeaderHeight
-			hb.Timestamp = primitives.NewTimestampNow()
+			hb.Timestamp = primitives.NewTimestampFromClock(s.GetClock())
 			hb.SecretNumber = s.GetSalt(hb.Timestamp)
 			hb.DBlockHash = dbstate.DBHash
 			hb.IdentityChainID = s.IdentityChainID
@@ -1855,14 +1989,15 @@ func (s *State) PutNewEntries(dbheight uint32, hash interfaces.IHash, e interfac
 
 // Returns the oldest, not processed, Commit received
 func (s *State) NextCommit(hash interfaces.IHash) interfaces.IMsg {
-	c := s.Commits[hash.Fixed()]
+	c := s.Commits.Get(hash.Fixed())
 	return c
 }
 
 func (s *State) PutCommit(hash interfaces.IHash, msg interfaces.IMsg) {
-	e, ok1 := s.Commits[hash.Fixed()].(*messages.CommitEntryMsg)
+	c := s.Commits.Get(hash.Fixed())
+	e, ok1 := c.(*messages.CommitEntryMsg)
 	m, ok1b := msg.(*messages.CommitEntryMsg)
-	ec, ok2 := s.Commits[hash.Fixed()].(*messages.CommitChainMsg)
+	ec, ok2 := c.(*messages.CommitChainMsg)
 	mc, ok2b := msg.(*messages.CommitEntryMsg)
 
 	// Keep the most entry credits.
@@ -1871,7 +2006,7 @@ func (s *State) PutCommit(hash interfaces.IHash, msg interfaces.IMsg) {
 	case ok1 && ok1b && e.CommitEntry.Credits > m.CommitEntry.Credits:
 	case ok2 && ok2b && ec.CommitChain.Credits > mc.CommitEntry.Credits:
 	default:
-		s.Commits[hash.Fixed()] = msg
+		s.Commits.Put(hash.Fixed(), msg)
 	}
 }
 
@@ -1989,7 +2124,17 @@ func (s *State) PutE(rt bool, adr [32]byte, v int64) {
 // Returns the Virtual Server Index for this hash if this server is the leader;
 // returns -1 if we are not the leader for this hash
 func (s *State) ComputeVMIndex(hash []byte) int {
-	return s.LeaderPL.VMIndexFor(hash)
+	return s.LeaderPL.VMIndexFor(s.vmIndexMinute(), hash)
+}
+
+// vmIndexMinute returns the minute messages are routed to VMs by.  A leader
+// routes by the minute of its own VM, the minute its acks carry, which is
+// what the followers check the VM of an ack against.
+func (s *State) vmIndexMinute() int {
+	if s.Leader && s.LeaderVMIndex >= 0 && s.LeaderVMIndex < len(s.LeaderPL.VMs) {
+		return s.LeaderPL.VMs[s.LeaderVMIndex].LeaderMinute
+	}
+	return s.CurrentMinute
 }
 
 func (s *State) GetDBHeightComplete() uint32 {
@@ -2038,7 +2183,10 @@ func (s *State) NewAck(msg interfaces.IMsg, balanceHash interfaces.IHash) interf
 		ack.SerialHash, _ = primitives.CreateHash(last.MessageHash, ack.MessageHash)
 	}
 
-	ack.Sign(s)
+	// Batched acks are signed by their batch
+	if s.AckBatchSize <= 1 {
+		ack.Sign(s)
+	}
 
 	return ack
 }
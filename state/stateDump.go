@@ -0,0 +1,267 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+)
+
+// A StateDump is the consensus state of the node at a moment, for looking
+// into a stall after the fact.  The state loop builds it, between two of its
+// passes, so that it is consistent.  If the loop doesn't answer, the dump is
+// built from outside it, without the commits, and marked Partial.
+type StateDump struct {
+	Node    string
+	Time    time.Time
+	Partial bool // The state loop did not answer, so the dump may be torn
+
+	LLeaderHeight  uint32
+	CurrentMinute  int
+	Leader         bool
+	LeaderVMIndex  int
+	HighestSaved   uint32
+	HighestKnown   uint32
+	EOM            bool
+	EOMDone        bool
+	EOMProcessed   int
+	EOMLimit       int
+	DBSig          bool
+	DBSigDone      bool
+	DBSigProcessed int
+	DBSigLimit     int
+	Syncing        bool
+	Saving         bool
+
+	ProcessLists []*ProcessListDump
+	Holding      []*MsgDump
+	Acks         []*MsgDump
+	Commits      []*MsgDump
+	Replay       *interfaces.ReplayStats
+	Authorities  []*AuthorityDump
+}
+
+type ProcessListDump struct {
+	DBHeight     uint32
+	FedServers   []string
+	AuditServers []string
+	VMs          []*VMDump
+}
+
+type VMDump struct {
+	VMIndex      int
+	Leader       string // In the current minute
+	Height       int    // Of the messages processed
+	LeaderMinute int
+	Synced       bool
+	Signed       bool
+	WhenFaulted  int64
+	Messages     []*MsgDump
+}
+
+type MsgDump struct {
+	Type   string
+	Hash   string
+	Height int  // In its VM, or -1 out of a process list
+	Acked  bool // An ack for it is in the process list
+	Msg    string
+}
+
+type AuthorityDump struct {
+	ChainID string
+	Status  string
+}
+
+// DumpStateTimeout is how long DumpState waits for the state loop.
+var DumpStateTimeout = 10 * time.Second
+
+// DumpState writes the consensus state to a timestamped JSON file in the log
+// directory, and returns the name of the file.
+func (s *State) DumpState() (string, error) {
+	var dump *StateDump
+	answer := make(chan *StateDump, 1)
+	select {
+	case s.dumpRequests <- answer:
+		select {
+		case dump = <-answer:
+		case <-time.After(DumpStateTimeout):
+		}
+	default:
+	}
+	if dump == nil {
+		dump = s.buildStateDump(true)
+	}
+
+	dir := s.LogPath
+	if dir == "" || dir == "stdout" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("state-%s-%s.json", s.FactomNodeName, dump.Time.UTC().Format("20060102-150405")))
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err = SaveToFile(data, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// answerDumpRequests builds the dumps asked for.  It is called by the state
+// loop, where the state may be read safely.
+func (s *State) answerDumpRequests() {
+	for {
+		select {
+		case answer := <-s.dumpRequests:
+			answer <- s.buildStateDump(false)
+		default:
+			return
+		}
+	}
+}
+
+func (s *State) buildStateDump(partial bool) *StateDump {
+	d := new(StateDump)
+	d.Node = s.FactomNodeName
+	d.Time = s.GetClock().Now()
+	d.Partial = partial
+
+	d.LLeaderHeight = s.LLeaderHeight
+	d.CurrentMinute = s.CurrentMinute
+	d.Leader = s.Leader
+	d.LeaderVMIndex = s.LeaderVMIndex
+	d.HighestSaved = s.GetHighestSavedBlk()
+	d.HighestKnown = s.GetHighestKnownBlock()
+	d.EOM = s.EOM
+	d.EOMDone = s.EOMDone
+	d.EOMProcessed = s.EOMProcessed
+	d.EOMLimit = s.EOMLimit
+	d.DBSig = s.DBSig
+	d.DBSigDone = s.DBSigDone
+	d.DBSigProcessed = s.DBSigProcessed
+	d.DBSigLimit = s.DBSigLimit
+	d.Syncing = s.Syncing
+	d.Saving = s.Saving
+
+	for _, pl := range s.ProcessLists.Lists {
+		if pl != nil {
+			d.ProcessLists = append(d.ProcessLists, dumpProcessList(pl, s.CurrentMinute))
+		}
+	}
+
+	// From outside the state loop, only the copies made for the API are safe
+	holding, acks := s.Holding, s.Acks
+	if partial {
+		holding, acks = s.LoadHoldingMap(), s.LoadAcksMap()
+	} else {
+		d.Commits = dumpMsgMap(s.Commits.Copy())
+	}
+	d.Holding = dumpMsgMap(holding)
+	d.Acks = dumpMsgMap(acks)
+
+	d.Replay = s.GetReplayStats()
+	for _, auth := range s.Authorities {
+		d.Authorities = append(d.Authorities, &AuthorityDump{
+			ChainID: auth.AuthorityChainID.String(),
+			Status:  identityStatusName(auth.Status),
+		})
+	}
+	return d
+}
+
+func dumpProcessList(pl *ProcessList, minute int) *ProcessListDump {
+	if minute > 9 {
+		minute = 9
+	}
+	d := new(ProcessListDump)
+	d.DBHeight = pl.DBHeight
+	for _, fed := range pl.FedServers {
+		d.FedServers = append(d.FedServers, fed.GetChainID().String())
+	}
+	for _, aud := range pl.AuditServers {
+		d.AuditServers = append(d.AuditServers, aud.GetChainID().String())
+	}
+	for i, vm := range pl.VMs {
+		if i >= len(pl.FedServers) {
+			break
+		}
+		v := &VMDump{
+			VMIndex:      i,
+			Height:       vm.Height,
+			LeaderMinute: vm.LeaderMinute,
+			Synced:       vm.Synced,
+			Signed:       vm.Signed,
+			WhenFaulted:  vm.WhenFaulted,
+		}
+		if fed := pl.ServerMap[minute][i]; fed < len(pl.FedServers) {
+			v.Leader = pl.FedServers[fed].GetChainID().String()
+		}
+		for h, msg := range vm.List {
+			if msg == nil {
+				v.Messages = append(v.Messages, &MsgDump{Type: "missing", Height: h})
+				continue
+			}
+			m := dumpMsg(msg)
+			m.Height = h
+			m.Acked = h < len(vm.ListAck) && vm.ListAck[h] != nil
+			v.Messages = append(v.Messages, m)
+		}
+		d.VMs = append(d.VMs, v)
+	}
+	return d
+}
+
+func dumpMsg(msg interfaces.IMsg) *MsgDump {
+	m := &MsgDump{Type: messages.MessageName(msg.Type()), Height: -1, Msg: msg.String()}
+	if hash := msg.GetMsgHash(); hash != nil {
+		m.Hash = hash.String()
+	}
+	return m
+}
+
+// dumpMsgMap dumps the messages of a map in the order of their hashes.
+func dumpMsgMap(msgs map[[32]byte]interfaces.IMsg) []*MsgDump {
+	var dumps []*MsgDump
+	for _, msg := range msgs {
+		if msg != nil {
+			dumps = append(dumps, dumpMsg(msg))
+		}
+	}
+	sort.Slice(dumps, func(i, j int) bool { return dumps[i].Hash < dumps[j].Hash })
+	return dumps
+}
+
+func identityStatusName(status uint8) string {
+	switch status {
+	case constants.IDENTITY_UNASSIGNED:
+		return "unassigned"
+	case constants.IDENTITY_FEDERATED_SERVER:
+		return "federated"
+	case constants.IDENTITY_AUDIT_SERVER:
+		return "audit"
+	case constants.IDENTITY_FULL:
+		return "full"
+	case constants.IDENTITY_PENDING_FEDERATED_SERVER:
+		return "pending federated"
+	case constants.IDENTITY_PENDING_AUDIT_SERVER:
+		return "pending audit"
+	case constants.IDENTITY_PENDING_FULL:
+		return "pending full"
+	case constants.IDENTITY_SKELETON:
+		return "skeleton"
+	}
+	return fmt.Sprintf("unknown %d", status)
+}
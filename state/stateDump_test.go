@@ -0,0 +1,55 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestDumpState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dumpstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := testHelper.CreateAndPopulateTestState()
+	s.LogPath = dir
+	DumpStateTimeout = 10 * time.Millisecond
+	defer func() { DumpStateTimeout = 10 * time.Second }()
+
+	// The state loop isn't running, so the dump is built from outside it
+	file, err := s.DumpState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := new(StateDump)
+	if err = json.Unmarshal(data, dump); err != nil {
+		t.Fatal(err)
+	}
+	if !dump.Partial {
+		t.Error("Expected a partial dump")
+	}
+	if dump.Node != s.FactomNodeName || dump.LLeaderHeight != s.LLeaderHeight {
+		t.Errorf("Wrong dump of %s at %d", dump.Node, dump.LLeaderHeight)
+	}
+	if len(dump.ProcessLists) == 0 {
+		t.Error("Expected the process lists in the dump")
+	}
+	if len(dump.Authorities) != len(s.Authorities) {
+		t.Errorf("Expected %d authorities, found %d", len(s.Authorities), len(dump.Authorities))
+	}
+}
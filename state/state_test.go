@@ -6,11 +6,16 @@ package state_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
 	//"github.com/FactomProject/factomd/common/constants"
-	//"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/log"
 	"github.com/FactomProject/factomd/state"
 	. "github.com/FactomProject/factomd/state"
@@ -90,6 +95,49 @@ func TestLoadHoldingMap(t *testing.T) {
 	}
 }
 
+func TestPendingMinute(t *testing.T) {
+	s := testHelper.CreateAndPopulateTestState()
+	// Far enough ahead that the node doesn't try to process it
+	pl := s.ProcessLists.Get(s.GetDBHeightComplete() + 5)
+	vm := pl.VMs[0]
+
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(0), 1000)
+	ftm := new(messages.FactoidTransaction)
+	ftm.Transaction = tx
+	ccm := new(messages.CommitChainMsg)
+	ccm.CommitChain = entryCreditBlock.NewCommitChain()
+
+	vm.List = append(vm.List, ftm, ccm)
+	vm.ListAck = append(vm.ListAck, &messages.Ack{Minute: 3}, &messages.Ack{Minute: 5})
+
+	found := false
+	for _, p := range s.GetPendingTransactions("") {
+		if p.TransactionID.IsSameAs(tx.GetSigHash()) {
+			found = true
+			if p.Minute != 3 || p.DBHeight != pl.DBHeight {
+				t.Errorf("Transaction pending at %d-%d, not %d-3", p.DBHeight, p.Minute, pl.DBHeight)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Transaction is not pending")
+	}
+
+	found = false
+	for _, p := range s.GetPendingEntries("") {
+		if p.EntryHash.IsSameAs(ccm.CommitChain.EntryHash) {
+			found = true
+			if p.Minute != 5 || p.DBHeight != pl.DBHeight {
+				t.Errorf("Commit pending at %d-%d, not %d-5", p.DBHeight, p.Minute, pl.DBHeight)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Commit is not pending")
+	}
+}
+
 func TestLoadAcksMap(t *testing.T) {
 	state := testHelper.CreateAndPopulateTestState()
 
@@ -137,6 +185,26 @@ func TestClone(t *testing.T) {
 	}
 }
 
+func TestClock(t *testing.T) {
+	s := testHelper.CreateAndPopulateTestState()
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := primitives.NewFakeClock(start)
+	s.SetClock(clock)
+
+	if s.GetTimestamp().GetTimeMilli() != start.UnixNano()/1e6 {
+		t.Errorf("Timestamp %v is not the time of the clock", s.GetTimestamp())
+	}
+	clock.Advance(time.Minute)
+	if s.GetTimestamp().GetTimeMilli() != start.Add(time.Minute).UnixNano()/1e6 {
+		t.Errorf("Timestamp %v did not follow the clock", s.GetTimestamp())
+	}
+
+	s2 := s.Clone(1).(*State)
+	if s2.GetClock() != s.GetClock() {
+		t.Errorf("Clone does not share the clock")
+	}
+}
+
 /*
 func TestBootStrappingIdentity(t *testing.T) {
 	state := testHelper.CreateEmptyTestState()
@@ -182,3 +250,78 @@ func TestBootStrappingIdentity(t *testing.T) {
 
 }
 */
+
+func TestNormalizeDBType(t *testing.T) {
+	tests := map[string]string{
+		"LDB":     "LDB",
+		"ldb":     "LDB",
+		"LevelDB": "LDB",
+		"Bolt":    "Bolt",
+		"boltdb":  "Bolt",
+		" map ":   "Map",
+		"MAP":     "Map",
+		"Other":   "Other",
+	}
+	for in, out := range tests {
+		if NormalizeDBType(in) != out {
+			t.Errorf("NormalizeDBType(%q) = %q, expected %q", in, NormalizeDBType(in), out)
+		}
+	}
+}
+
+func TestParseMigrateDB(t *testing.T) {
+	from, to, err := ParseMigrateDB("from=bolt to=ldb")
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if from != "Bolt" || to != "LDB" {
+		t.Errorf("Invalid result %v %v", from, to)
+	}
+
+	from, to, err = ParseMigrateDB("to=Bolt,from=LevelDB")
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if from != "LDB" || to != "Bolt" {
+		t.Errorf("Invalid result %v %v", from, to)
+	}
+
+	for _, arg := range []string{"", "from=bolt", "from=bolt to=bolt", "from=map to=ldb", "from=bolt to=x", "bolt ldb", "src=bolt to=ldb"} {
+		_, _, err = ParseMigrateDB(arg)
+		if err == nil {
+			t.Errorf("No error for %q", arg)
+		}
+	}
+}
+
+func TestFollowerOnly(t *testing.T) {
+	identity := "888888001750ede0eff4b05f0c3f557890b256450cabbb84cada937f9c258327"
+	config := "[app]\nIdentityChainID = " + identity + "\n"
+
+	load := func(config string) *State {
+		f, err := ioutil.TempFile("", "factomd.conf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		f.WriteString(config)
+		f.Close()
+
+		s := new(State)
+		s.LoadConfig(f.Name(), "LOCAL")
+		return s
+	}
+
+	s := load(config)
+	if s.FollowerOnly || s.GetIdentityChainID().String() != identity {
+		t.Errorf("The node runs as %s, not the identity of its config", s.GetIdentityChainID().String())
+	}
+
+	s = load(config + "FollowerOnly = true\n")
+	if !s.FollowerOnly {
+		t.Errorf("FollowerOnly was not read from the config")
+	}
+	if s.GetIdentityChainID().String() == identity {
+		t.Errorf("A follower only node runs as the identity of its config")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// DefaultRichListSize is the number of balances in the rich list if
+// RichListSize isn't set.
+const DefaultRichListSize = 100
+
+// SupplyStatsInterval is how often the supply statistics are checked for a
+// new block.
+var SupplyStatsInterval = 10 * time.Second
+
+// ComputeSupplyStats totals the factoid and entry credit balances, and picks
+// the n largest factoid balances, largest first.  Addresses with the same
+// balance are in byte order, so every node lists them alike.
+func ComputeSupplyStats(height uint32, fct map[[32]byte]int64, ec map[[32]byte]int64, n int) *interfaces.SupplyStats {
+	stats := new(interfaces.SupplyStats)
+	stats.Height = height
+
+	rich := make([]interfaces.AddressBalance, 0, len(fct))
+	for adr, balance := range fct {
+		stats.FactoidSupply += balance
+		if balance != 0 {
+			stats.FactoidAddresses++
+			rich = append(rich, interfaces.AddressBalance{Address: adr, Balance: balance})
+		}
+	}
+	for _, balance := range ec {
+		stats.ECSupply += balance
+		if balance != 0 {
+			stats.ECAddresses++
+		}
+	}
+
+	sort.Sort(byBalance(rich))
+	if len(rich) > n {
+		rich = rich[:n]
+	}
+	stats.RichList = rich
+	return stats
+}
+
+type byBalance []interfaces.AddressBalance
+
+func (b byBalance) Len() int      { return len(b) }
+func (b byBalance) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byBalance) Less(i, j int) bool {
+	if b[i].Balance != b[j].Balance {
+		return b[i].Balance > b[j].Balance
+	}
+	return bytes.Compare(b[i].Address[:], b[j].Address[:]) < 0
+}
+
+// RunSupplyStats keeps the supply statistics up to date, working them out
+// again from the permanent balances whenever a new block has been saved.  It
+// never returns, so is run as a goroutine, and only if RichListEnabled.
+func (s *State) RunSupplyStats() {
+	var last uint32
+	first := true
+	for {
+		height := s.GetHighestSavedBlk()
+		if first || height != last {
+			s.updateSupplyStats(height)
+			first = false
+			last = height
+		}
+		time.Sleep(SupplyStatsInterval)
+	}
+}
+
+func (s *State) updateSupplyStats(height uint32) {
+	n := s.RichListSize
+	if n <= 0 {
+		n = DefaultRichListSize
+	}
+
+	// Copy the balances, so the maps are locked only as long as it takes
+	s.FactoidBalancesPMutex.Lock()
+	fct := make(map[[32]byte]int64, len(s.FactoidBalancesP))
+	for k, v := range s.FactoidBalancesP {
+		fct[k] = v
+	}
+	s.FactoidBalancesPMutex.Unlock()
+
+	s.ECBalancesPMutex.Lock()
+	ec := make(map[[32]byte]int64, len(s.ECBalancesP))
+	for k, v := range s.ECBalancesP {
+		ec[k] = v
+	}
+	s.ECBalancesPMutex.Unlock()
+
+	stats := ComputeSupplyStats(height, fct, ec, n)
+
+	s.supplyStatsMutex.Lock()
+	s.supplyStats = stats
+	s.supplyStatsMutex.Unlock()
+}
+
+// GetSupplyStats returns the latest supply statistics, or nil if they are
+// off or not yet worked out.
+func (s *State) GetSupplyStats() *interfaces.SupplyStats {
+	s.supplyStatsMutex.Lock()
+	defer s.supplyStatsMutex.Unlock()
+	return s.supplyStats
+}
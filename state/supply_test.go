@@ -0,0 +1,54 @@
+package state_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/state"
+)
+
+func TestComputeSupplyStats(t *testing.T) {
+	fct := map[[32]byte]int64{}
+	ec := map[[32]byte]int64{}
+	for i := 0; i < 10; i++ {
+		var adr [32]byte
+		adr[0] = byte(i)
+		fct[adr] = int64(i%5) * 100 // Two addresses of each balance, two empty
+		ec[adr] = int64(i)
+	}
+
+	stats := ComputeSupplyStats(7, fct, ec, 3)
+	if stats.Height != 7 {
+		t.Errorf("Height is %d, expected 7", stats.Height)
+	}
+	if stats.FactoidSupply != 2000 {
+		t.Errorf("Factoid supply is %d, expected 2000", stats.FactoidSupply)
+	}
+	if stats.ECSupply != 45 {
+		t.Errorf("EC supply is %d, expected 45", stats.ECSupply)
+	}
+	if stats.FactoidAddresses != 8 {
+		t.Errorf("%d factoid addresses, expected 8", stats.FactoidAddresses)
+	}
+	if stats.ECAddresses != 9 {
+		t.Errorf("%d EC addresses, expected 9", stats.ECAddresses)
+	}
+
+	if len(stats.RichList) != 3 {
+		t.Fatalf("Rich list has %d balances, expected 3", len(stats.RichList))
+	}
+	expected := []struct {
+		adr     byte
+		balance int64
+	}{{4, 400}, {9, 400}, {3, 300}}
+	for i, e := range expected {
+		b := stats.RichList[i]
+		if b.Address[0] != e.adr || b.Balance != e.balance {
+			t.Errorf("Rich list %d is %x with %d, expected %x with %d", i, b.Address[0], b.Balance, e.adr, e.balance)
+		}
+	}
+
+	stats = ComputeSupplyStats(7, fct, ec, 100)
+	if len(stats.RichList) != 8 {
+		t.Errorf("Rich list has %d balances, expected 8", len(stats.RichList))
+	}
+}
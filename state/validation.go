@@ -6,6 +6,7 @@ package state
 
 import (
 	"fmt"
+	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"time"
@@ -31,7 +32,9 @@ func (state *State) ValidatorLoop() {
 		for i := 0; i < 10; i++ {
 			// Process any messages we might have queued up.
 			for i = 0; i < 10; i++ {
+				state.ProcessLists.Mutex.Lock()
 				p, b := state.Process(), state.UpdateState()
+				state.ProcessLists.Mutex.Unlock()
 				if !p && !b {
 					break
 				}
@@ -72,6 +75,8 @@ func (state *State) ValidatorLoop() {
 			}
 			if _, ok := msg.(*messages.Ack); ok {
 				state.ackQueue <- msg
+			} else if IsPrioritizedMsg(msg) {
+				state.prioritizedMsgQueue <- msg
 			} else {
 				state.msgQueue <- msg
 			}
@@ -79,6 +84,21 @@ func (state *State) ValidatorLoop() {
 	}
 }
 
+// IsPrioritizedMsg returns true for the consensus messages that have to be
+// processed ahead of commits, reveals, and transactions.  Acks have their
+// own queue.
+func IsPrioritizedMsg(msg interfaces.IMsg) bool {
+	switch msg.Type() {
+	case constants.EOM_MSG,
+		constants.DIRECTORY_BLOCK_SIGNATURE_MSG,
+		constants.FED_SERVER_FAULT_MSG,
+		constants.AUDIT_SERVER_FAULT_MSG,
+		constants.FULL_SERVER_FAULT_MSG:
+		return true
+	}
+	return false
+}
+
 type Timer struct {
 	lastMin      int
 	lastDBHeight uint32
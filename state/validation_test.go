@@ -0,0 +1,41 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	. "github.com/FactomProject/factomd/state"
+)
+
+func TestIsPrioritizedMsg(t *testing.T) {
+	prioritized := []interfaces.IMsg{
+		new(messages.EOM),
+		new(messages.DirectoryBlockSignature),
+		new(messages.ServerFault),
+		new(messages.AuditServerFault),
+		new(messages.FullServerFault),
+	}
+	for _, msg := range prioritized {
+		if !IsPrioritizedMsg(msg) {
+			t.Errorf("%v should be prioritized", messages.MessageName(msg.Type()))
+		}
+	}
+
+	normal := []interfaces.IMsg{
+		new(messages.CommitChainMsg),
+		new(messages.CommitEntryMsg),
+		new(messages.RevealEntryMsg),
+		new(messages.FactoidTransaction),
+		new(messages.MissingMsg),
+	}
+	for _, msg := range normal {
+		if IsPrioritizedMsg(msg) {
+			t.Errorf("%v should not be prioritized", messages.MessageName(msg.Type()))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+// Package telemetry reports, if the operator opts in, what version of
+// factomd a node runs and where it is at, so that the stewards of the network
+// know how far a new version has spread before they activate a protocol
+// change.  The reports hold nothing that identifies the node.
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/log"
+)
+
+var telemetryLog = log.ForPackage("telemetry")
+
+const DefaultIntervalMinutes = 60
+
+// Config is the [Telemetry] section of factomd.conf.  Nothing is sent unless
+// Enabled.
+type Config struct {
+	Enabled         bool
+	URL             string // Where the reports are POSTed
+	IntervalMinutes int
+}
+
+// Report is what is sent, as JSON.
+type Report struct {
+	Version string `json:"version"`
+	Network string `json:"network"` // MAIN, TEST, LOCAL or CUSTOM
+	Height  uint32 `json:"height"`  // Of the highest saved directory block
+	Peers   int    `json:"peers"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+}
+
+// Reporter sends a report of a node every IntervalMinutes.
+type Reporter struct {
+	Config Config
+	State  interfaces.IState
+	Peers  func() int // The number of peers; nil for none
+
+	client *http.Client
+}
+
+// NewReporter returns the reporter of the node, or an error if the URL of
+// the config isn't an http or https one.
+func NewReporter(c Config, state interfaces.IState, peers func() int) (*Reporter, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Invalid telemetry URL %q", c.URL)
+	}
+	if c.IntervalMinutes <= 0 {
+		c.IntervalMinutes = DefaultIntervalMinutes
+	}
+	r := new(Reporter)
+	r.Config = c
+	r.State = state
+	r.Peers = peers
+	r.client = &http.Client{Timeout: 30 * time.Second}
+	return r, nil
+}
+
+// VersionString returns the dotted form of a factomd version number, as the
+// properties API gives it, such as 0.4.2.2 for 4002002.
+func VersionString(v int) string {
+	return fmt.Sprintf("%d.%d.%d.%d", v/1000000000, v%1000000000/1000000, v%1000000/1000, v%1000)
+}
+
+// Collect returns the report of the node as it is now.
+func (r *Reporter) Collect() *Report {
+	report := new(Report)
+	report.Version = VersionString(r.State.GetFactomdVersion())
+	report.Network = r.State.GetNetworkName()
+	report.Height = r.State.GetHighestSavedBlk()
+	if r.Peers != nil {
+		report.Peers = r.Peers()
+	}
+	report.OS = runtime.GOOS
+	report.Arch = runtime.GOARCH
+	return report
+}
+
+// Send POSTs the report.
+func (r *Reporter) Send(report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Post(r.Config.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Telemetry endpoint answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Run sends a report every IntervalMinutes, the first one after the first
+// interval, so that a node that is restarting over and over isn't counted
+// each time.  It never returns, so is run as a goroutine.
+func (r *Reporter) Run() {
+	for {
+		time.Sleep(time.Duration(r.Config.IntervalMinutes) * time.Minute)
+		if err := r.Send(r.Collect()); err != nil {
+			telemetryLog.Errorf("Could not send the report: %v", err)
+		}
+	}
+}
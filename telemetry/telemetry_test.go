@@ -0,0 +1,53 @@
+package telemetry_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	. "github.com/FactomProject/factomd/telemetry"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestVersionString(t *testing.T) {
+	if v := VersionString(4002002); v != "0.4.2.2" {
+		t.Errorf("Version string is %s", v)
+	}
+}
+
+func TestReporter(t *testing.T) {
+	reports := make(chan *Report, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := new(Report)
+		if err := json.NewDecoder(r.Body).Decode(report); err != nil {
+			t.Errorf("%v", err)
+		}
+		reports <- report
+	}))
+	defer server.Close()
+
+	state := testHelper.CreateAndPopulateTestState()
+	r, err := NewReporter(Config{Enabled: true, URL: server.URL}, state, func() int { return 8 })
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if r.Config.IntervalMinutes != DefaultIntervalMinutes {
+		t.Errorf("Interval is %d", r.Config.IntervalMinutes)
+	}
+
+	if err := r.Send(r.Collect()); err != nil {
+		t.Fatalf("%v", err)
+	}
+	report := <-reports
+	if report.Version != VersionString(constants.FACTOMD_VERSION) || report.Height != state.GetHighestSavedBlk() ||
+		report.Peers != 8 || report.OS != runtime.GOOS || report.Arch != runtime.GOARCH {
+		t.Errorf("Wrong report %+v", report)
+	}
+
+	if _, err := NewReporter(Config{Enabled: true}, state, nil); err == nil {
+		t.Errorf("No error without a URL")
+	}
+}
@@ -0,0 +1,91 @@
+package testHelper
+
+//Blocks and entries of mainnet, as they were marshalled, for checking that
+//changes to the marshalling still read and write real data byte for byte.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// GoldenIndex is the name of the file listing the golden blocks of a
+// directory.
+const GoldenIndex = "golden.json"
+
+// GoldenBlock is a block or entry of mainnet, and the indexes it was saved
+// under.
+type GoldenBlock struct {
+	File   string // The marshalled block, in the directory of the index
+	Kind   string // dblock, ablock, fblock, ecblock, eblock or entry
+	Height uint32
+	KeyMR  string // The DatabasePrimaryIndex
+	Hash   string // The DatabaseSecondaryIndex, empty for entries
+	Data   []byte `json:"-"`
+}
+
+// IndexedBlock is what a golden block unmarshals to.
+type IndexedBlock interface {
+	interfaces.BinaryMarshallable
+	GetDatabaseHeight() uint32
+	DatabasePrimaryIndex() interfaces.IHash
+	DatabaseSecondaryIndex() interfaces.IHash
+}
+
+// LoadGoldenBlocks reads the golden blocks listed in the index of the
+// directory, such as testdata/mainnet.
+func LoadGoldenBlocks(dir string) ([]*GoldenBlock, error) {
+	index, err := ioutil.ReadFile(filepath.Join(dir, GoldenIndex))
+	if err != nil {
+		return nil, err
+	}
+	var blocks []*GoldenBlock
+	if err := json.Unmarshal(index, &blocks); err != nil {
+		return nil, fmt.Errorf("%s: %v", GoldenIndex, err)
+	}
+	for _, b := range blocks {
+		b.Data, err = ioutil.ReadFile(filepath.Join(dir, b.File))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// Unmarshal returns the block unmarshalled as its kind, and an error if any
+// of the data is left over.
+func (g *GoldenBlock) Unmarshal() (IndexedBlock, error) {
+	var block IndexedBlock
+	switch g.Kind {
+	case "dblock":
+		block = directoryBlock.NewDirectoryBlock(nil)
+	case "ablock":
+		block = adminBlock.NewAdminBlock(nil)
+	case "fblock":
+		block = factoid.NewFBlock(nil)
+	case "ecblock":
+		block = entryCreditBlock.NewECBlock()
+	case "eblock":
+		block = entryBlock.NewEBlock()
+	case "entry":
+		block = entryBlock.NewEntry()
+	default:
+		return nil, fmt.Errorf("%s: unknown kind %q", g.File, g.Kind)
+	}
+	rest, err := block.UnmarshalBinaryData(g.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", g.File, err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%s: %d bytes left over", g.File, len(rest))
+	}
+	return block, nil
+}
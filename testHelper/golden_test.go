@@ -0,0 +1,68 @@
+package testHelper_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	. "github.com/FactomProject/factomd/testHelper"
+)
+
+func TestGoldenBlocks(t *testing.T) {
+	golden, err := LoadGoldenBlocks("testdata/mainnet")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(golden) == 0 {
+		t.Fatalf("No golden blocks in testdata/mainnet")
+	}
+
+	keyMRs := map[string]map[uint32]string{}
+	for _, g := range golden {
+		block, err := g.Unmarshal()
+		if err != nil {
+			t.Errorf("%v", err)
+			continue
+		}
+		if g.Kind != "entry" && block.GetDatabaseHeight() != g.Height {
+			t.Errorf("%s: height %d, expected %d", g.File, block.GetDatabaseHeight(), g.Height)
+		}
+		if keyMR := block.DatabasePrimaryIndex().String(); keyMR != g.KeyMR {
+			t.Errorf("%s: KeyMR %s, expected %s", g.File, keyMR, g.KeyMR)
+		}
+		if g.Hash != "" && block.DatabaseSecondaryIndex().String() != g.Hash {
+			t.Errorf("%s: hash %s, expected %s", g.File, block.DatabaseSecondaryIndex().String(), g.Hash)
+		}
+
+		data, err := block.MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: %v", g.File, err)
+		} else if !bytes.Equal(data, g.Data) {
+			t.Errorf("%s: marshals to different bytes\n got %x\nwant %x", g.File, data, g.Data)
+		}
+
+		// Blocks of consecutive heights must link to each other
+		if keyMRs[g.Kind] == nil {
+			keyMRs[g.Kind] = map[uint32]string{}
+		}
+		keyMRs[g.Kind][g.Height] = g.KeyMR
+		if g.Height == 0 {
+			continue
+		}
+		prev, ok := keyMRs[g.Kind][g.Height-1]
+		if !ok {
+			continue
+		}
+		switch b := block.(type) {
+		case *directoryBlock.DirectoryBlock:
+			if b.GetHeader().GetPrevKeyMR().String() != prev {
+				t.Errorf("%s: does not link to the directory block before it", g.File)
+			}
+		case *entryBlock.EBlock:
+			if b.GetHeader().GetPrevKeyMR().String() != prev {
+				t.Errorf("%s: does not link to the entry block before it", g.File)
+			}
+		}
+	}
+}
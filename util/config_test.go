@@ -1,6 +1,9 @@
 package util_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/FactomProject/factomd/util"
@@ -180,3 +183,81 @@ WalletdLocation                       = "localhost:8089"
 	}
 
 }
+
+func TestLoadEventSinks(t *testing.T) {
+	var config string = `
+	[EventSink "indexer"]
+	Kind    = tcp
+	Address = "localhost:8040"
+	Events  = "dblock, eblock"
+
+	[EventSink "archive"]
+	Kind    = tcp
+	Address = "localhost:8041"
+	Format  = protobuf
+	`
+
+	cfg := new(FactomdConfig)
+	err := gcfg.ReadStringInto(cfg, config)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(cfg.EventSink) != 2 {
+		t.Fatalf("Read %d event sinks, not 2", len(cfg.EventSink))
+	}
+	if s := cfg.EventSink["indexer"]; s == nil || s.Kind != "tcp" || s.Address != "localhost:8040" || s.Events != "dblock, eblock" {
+		t.Errorf("Wrong sink read - %v", s)
+	}
+	if s := cfg.EventSink["archive"]; s == nil || s.Format != "protobuf" {
+		t.Errorf("Wrong sink read - %v", s)
+	}
+}
+
+func TestLoadApiKeys(t *testing.T) {
+	var config string = `
+	[ApiKey "explorer"]
+	Key       = "secret"
+	RateLimit = 5
+	Methods   = "heights, entry"
+	`
+
+	cfg := new(FactomdConfig)
+	err := gcfg.ReadStringInto(cfg, config)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if k := cfg.ApiKey["explorer"]; k == nil || k.Key != "secret" || k.RateLimit != 5 || k.Methods != "heights, entry" {
+		t.Errorf("Wrong key read - %v", k)
+	}
+}
+
+func TestWriteDefaultConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "factomd")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+	DataDir = filepath.Join(dir, "data")
+	defer func() { DataDir = "" }()
+
+	filename := ConfigFilename()
+	if filename != filepath.Join(dir, "data", "factomd.conf") {
+		t.Errorf("Wrong config filename - %v", filename)
+	}
+	written, err := WriteDefaultConfig(filename)
+	if err != nil || !written {
+		t.Fatalf("Default config not written - %v", err)
+	}
+	cfg := ReadConfig(filename)
+	if cfg.App.Network != "MAIN" || cfg.App.PortNumber != 8088 {
+		t.Errorf("Wrong defaults read - %v %v", cfg.App.Network, cfg.App.PortNumber)
+	}
+	if cfg.App.HomeDir != GetDataDir()+"/" {
+		t.Errorf("Wrong HomeDir - %v", cfg.App.HomeDir)
+	}
+
+	written, err = WriteDefaultConfig(filename)
+	if err != nil || written {
+		t.Errorf("Existing config overwritten - %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CustomNet is the -customnet name of the node, which names the directory of
+// a custom network under -datadir.
+var CustomNet string
+
+// DataPaths are where a node started with -datadir keeps its files, in a
+// directory for each network, so that one volume holds all of them:
+//
+//	<datadir>/factomd.conf
+//	<datadir>/<network>/database/ldb, bolt and entries
+//	<datadir>/<network>/peers.json
+//	<datadir>/<network>/logs/
+//	<datadir>/<network>/checkpoints/   The FastBoot saves
+//	<datadir>/<network>/keys/          The generated TLS key and certificate
+//	<datadir>/<network>/export/ and profiles/
+//
+// The database paths of the config, LdbPath and BoltDBPath, are not used.
+type DataPaths struct {
+	Network     string
+	Database    string
+	Peers       string
+	Logs        string
+	Checkpoints string
+	Keys        string
+	Export      string
+	Profiles    string
+}
+
+// GetDataPaths returns the paths of the network under the data directory.
+// The network is MAIN, TEST, LOCAL or CUSTOM; a custom network is in a
+// directory of its -customnet name.
+func GetDataPaths(network string) *DataPaths {
+	name := strings.ToLower(network)
+	if name == "custom" && CustomNet != "" {
+		name = "custom-" + CustomNet
+	}
+	dir := filepath.Join(GetDataDir(), name)
+
+	p := new(DataPaths)
+	p.Network = dir
+	p.Database = filepath.Join(dir, "database")
+	p.Peers = filepath.Join(dir, "peers.json")
+	p.Logs = filepath.Join(dir, "logs")
+	p.Checkpoints = filepath.Join(dir, "checkpoints")
+	p.Keys = filepath.Join(dir, "keys")
+	p.Export = filepath.Join(dir, "export")
+	p.Profiles = filepath.Join(dir, "profiles")
+	return p
+}
+
+// Create makes the directories of the layout.  The keys are only readable
+// by the node.
+func (p *DataPaths) Create() error {
+	for _, dir := range []string{p.Database, p.Logs, p.Checkpoints, p.Export, p.Profiles} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(p.Keys, 0700)
+}
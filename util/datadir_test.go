@@ -0,0 +1,54 @@
+package util_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/FactomProject/factomd/util"
+)
+
+func TestGetDataPaths(t *testing.T) {
+	DataDir = "/data"
+	defer func() { DataDir, CustomNet = "", "" }()
+
+	p := GetDataPaths("MAIN")
+	if p.Network != "/data/main" || p.Database != "/data/main/database" || p.Peers != "/data/main/peers.json" {
+		t.Errorf("Wrong paths of MAIN - %+v", p)
+	}
+	if p.Logs != "/data/main/logs" || p.Checkpoints != "/data/main/checkpoints" || p.Keys != "/data/main/keys" {
+		t.Errorf("Wrong paths of MAIN - %+v", p)
+	}
+
+	CustomNet = "private"
+	if p := GetDataPaths("CUSTOM"); p.Network != "/data/custom-private" {
+		t.Errorf("Wrong directory of a custom network - %v", p.Network)
+	}
+}
+
+func TestCreateDataPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "factomd")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+	DataDir = dir
+	defer func() { DataDir = "" }()
+
+	p := GetDataPaths("TEST")
+	if err := p.Create(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, d := range []string{p.Database, p.Logs, p.Checkpoints, p.Keys, p.Export, p.Profiles} {
+		if info, err := os.Stat(d); err != nil || !info.IsDir() {
+			t.Errorf("%s not made - %v", d, err)
+		}
+	}
+	if info, _ := os.Stat(p.Keys); info.Mode().Perm() != 0700 {
+		t.Errorf("Keys readable by others - %v", info.Mode())
+	}
+	if filepath.Dir(p.Network) != dir {
+		t.Errorf("Network directory not in the data directory - %v", p.Network)
+	}
+}
@@ -33,6 +33,21 @@ func Trace(params ...string) {
 	log.Printf("TRACE: %s line %d %s file: %s\n", timestamp, line, f.Name(), file)
 }
 
+// PrefixLimit returns the smallest key that is greater than every key starting
+// with prefix, for use as the limit of a key range.  Returns nil if there is no
+// such key (the prefix is all 0xFF), meaning the range has no upper limit.
+func PrefixLimit(prefix []byte) []byte {
+	limit := make([]byte, len(prefix))
+	copy(limit, prefix)
+	for i := len(limit) - 1; i >= 0; i-- {
+		if limit[i] < 0xFF {
+			limit[i]++
+			return limit[:i+1]
+		}
+	}
+	return nil
+}
+
 // Calculate the entry credits needed for the entry
 func EntryCost(b []byte) (uint8, error) {
 	// caulculaate the length exluding the header size 35 for Milestone 1
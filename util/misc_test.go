@@ -87,3 +87,21 @@ func randomIPendingEntry(nilSpot int) *interfaces.IPendingEntry {
 
 	return entry
 }
+
+func TestPrefixLimit(t *testing.T) {
+	tests := []struct {
+		prefix []byte
+		limit  []byte
+	}{
+		{[]byte{0x01, 0x02}, []byte{0x01, 0x03}},
+		{[]byte{0x01, 0xFF}, []byte{0x02}},
+		{[]byte{0xFF, 0xFF}, nil},
+		{[]byte{}, nil},
+	}
+	for _, v := range tests {
+		limit := PrefixLimit(v.prefix)
+		if primitives.AreBytesEqual(limit, v.limit) == false {
+			t.Errorf("Invalid limit for %x - %x, expected %x", v.prefix, limit, v.limit)
+		}
+	}
+}
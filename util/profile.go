@@ -0,0 +1,60 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+)
+
+// MaxProfileSeconds bounds how long a CPU profile may run.
+var MaxProfileSeconds = 300
+
+// WriteProfile writes a profile of the running node to a new file in dir,
+// named after its kind and the time, and returns the file name.  The kind is
+// one of cpu, heap, goroutine or block.  A cpu profile is taken over the given
+// seconds; the others are written at once, with what the runtime has recorded.
+func WriteProfile(dir string, kind string, seconds int) (string, error) {
+	var profile *pprof.Profile
+	switch kind {
+	case "cpu":
+		if seconds < 1 || seconds > MaxProfileSeconds {
+			return "", fmt.Errorf("seconds must be from 1 to %d", MaxProfileSeconds)
+		}
+	case "heap", "goroutine", "block":
+		profile = pprof.Lookup(kind)
+	default:
+		return "", fmt.Errorf("unknown profile %q, expected cpu, heap, goroutine or block", kind)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("%s-%s.pprof", kind, time.Now().UTC().Format("20060102-150405")))
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if profile != nil {
+		err = profile.WriteTo(f, 0)
+	} else {
+		err = pprof.StartCPUProfile(f)
+		if err == nil {
+			time.Sleep(time.Duration(seconds) * time.Second)
+			pprof.StopCPUProfile()
+		}
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
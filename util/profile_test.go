@@ -0,0 +1,43 @@
+package util_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/FactomProject/factomd/util"
+)
+
+func TestWriteProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, kind := range []string{"heap", "goroutine", "block"} {
+		name, err := WriteProfile(dir, kind, 0)
+		if err != nil {
+			t.Errorf("%s profile - %v", kind, err)
+			continue
+		}
+		if filepath.Dir(name) != dir || !strings.HasPrefix(filepath.Base(name), kind+"-") {
+			t.Errorf("Wrong file for %s profile - %v", kind, name)
+		}
+		if info, err := os.Stat(name); err != nil || info.Size() == 0 {
+			t.Errorf("Empty %s profile - %v", kind, err)
+		}
+	}
+
+	if _, err := WriteProfile(dir, "cpu", 0); err == nil {
+		t.Errorf("No error for a cpu profile of 0 seconds")
+	}
+	if _, err := WriteProfile(dir, "cpu", MaxProfileSeconds+1); err == nil {
+		t.Errorf("No error for a cpu profile over MaxProfileSeconds")
+	}
+	if _, err := WriteProfile(dir, "mutex", 0); err == nil {
+		t.Errorf("No error for an unknown profile")
+	}
+}
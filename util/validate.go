@@ -0,0 +1,265 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package util
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/log"
+)
+
+// ConfigError is a problem with a value of factomd.conf.
+type ConfigError struct {
+	Key     string // Section.Key, such as App.Network
+	Value   interface{}
+	Problem string // What is wrong, and what values are accepted
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s = %q: %s", e.Key, fmt.Sprint(e.Value), e.Problem)
+}
+
+// Validate checks the values of the config, as they are once the node has
+// loaded it, and returns every problem found.  The network is the one the
+// node joins, which the command line may set apart from App.Network.
+func (s *FactomdConfig) Validate(network string) []*ConfigError {
+	var problems []*ConfigError
+	problem := func(key string, value interface{}, format string, args ...interface{}) {
+		problems = append(problems, &ConfigError{Key: key, Value: value, Problem: fmt.Sprintf(format, args...)})
+	}
+	oneOf := func(key string, value string, accepted ...string) {
+		for _, a := range accepted {
+			if value == a {
+				return
+			}
+		}
+		problem(key, value, "must be one of %s", strings.Join(accepted, ", "))
+	}
+
+	if network == "" {
+		network = s.App.Network
+	}
+	oneOf("App.Network", network, "MAIN", "TEST", "LOCAL", "CUSTOM")
+	oneOf("App.NodeMode", s.App.NodeMode, "FULL", "SERVER")
+	oneOf("App.ControlPanelSetting", s.App.ControlPanelSetting, "disabled", "readonly", "readwrite")
+	switch strings.ToLower(strings.TrimSpace(s.App.DBType)) {
+	case "ldb", "leveldb", "bolt", "boltdb", "map", "mapdb":
+	default:
+		problem("App.DBType", s.App.DBType, "must be one of LDB, Bolt, Map")
+	}
+
+	for key, port := range map[string]int{"App.PortNumber": s.App.PortNumber, "App.ControlPanelPort": s.App.ControlPanelPort} {
+		if port < 1 || port > 65535 {
+			problem(key, port, "must be a port number, from 1 to 65535")
+		}
+	}
+	if s.App.GRPCPort < 0 || s.App.GRPCPort > 65535 {
+		problem("App.GRPCPort", s.App.GRPCPort, "must be a port number, from 1 to 65535, or 0 to not serve gRPC")
+	}
+	for key, port := range map[string]string{
+		"App.MainNetworkPort":  s.App.MainNetworkPort,
+		"App.TestNetworkPort":  s.App.TestNetworkPort,
+		"App.LocalNetworkPort": s.App.LocalNetworkPort,
+	} {
+		if !validPort(port) {
+			problem(key, port, "must be a port number, from 1 to 65535")
+		}
+	}
+	for key, peers := range map[string]string{
+		"App.MainSpecialPeers":  s.App.MainSpecialPeers,
+		"App.TestSpecialPeers":  s.App.TestSpecialPeers,
+		"App.LocalSpecialPeers": s.App.LocalSpecialPeers,
+	} {
+		if bad := badPeers(peers); len(bad) > 0 {
+			problem(key, peers, "not an address and port: %s (peers are like 127.0.0.1:8108, separated by spaces)", strings.Join(bad, ", "))
+		}
+	}
+	for key, seed := range map[string]string{
+		"App.MainSeedURL":  s.App.MainSeedURL,
+		"App.TestSeedURL":  s.App.TestSeedURL,
+		"App.LocalSeedURL": s.App.LocalSeedURL,
+	} {
+		if u, err := url.Parse(seed); seed != "" && (err != nil || u.Scheme == "" || u.Host == "") {
+			problem(key, seed, "must be a URL, such as https://example.com/seed.txt")
+		}
+	}
+
+	for name, network := range s.CustomNetwork {
+		key := fmt.Sprintf("CustomNetwork.%s.", name)
+		switch strings.ToUpper(name) {
+		case "MAIN", "TEST", "LOCAL", "CUSTOM":
+			problem(key[:len(key)-1], name, "must be the -customnet name of a custom network")
+		}
+		if network.P2PPort != "" && !validPort(network.P2PPort) {
+			problem(key+"P2PPort", network.P2PPort, "must be empty, or a port number from 1 to 65535")
+		}
+		if bad := badPeers(network.SpecialPeers); len(bad) > 0 {
+			problem(key+"SpecialPeers", network.SpecialPeers, "not an address and port: %s (peers are like 127.0.0.1:8108, separated by spaces)", strings.Join(bad, ", "))
+		}
+	}
+
+	for key, path := range map[string]string{"App.HomeDir": s.App.HomeDir, "Log.LogPath": s.Log.LogPath} {
+		if err := writableDir(path); err != nil {
+			problem(key, path, "%v", err)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(s.App.DBType)) {
+	case "ldb", "leveldb":
+		if err := writableDir(s.App.LdbPath); err != nil {
+			problem("App.LdbPath", s.App.LdbPath, "%v", err)
+		}
+	case "bolt", "boltdb":
+		if err := writableDir(s.App.BoltDBPath); err != nil {
+			problem("App.BoltDBPath", s.App.BoltDBPath, "%v", err)
+		}
+	}
+	if s.App.FastBoot {
+		if err := writableDir(s.App.FastBootLocation); err != nil {
+			problem("App.FastBootLocation", s.App.FastBootLocation, "%v", err)
+		}
+	}
+
+	if s.App.AckBatchSize < 0 || s.App.AckBatchSize > 1000 {
+		problem("App.AckBatchSize", s.App.AckBatchSize, "must be from 0 to 1000")
+	}
+	if s.App.CompressEntriesOver < 0 {
+		problem("App.CompressEntriesOver", s.App.CompressEntriesOver, "must not be negative")
+	}
+
+	if s.App.StartAtHeight > 0 {
+		for key, hash := range map[string]string{
+			"App.StartAtKeyMR":        s.App.StartAtKeyMR,
+			"App.StartAtSnapshotHash": s.App.StartAtSnapshotHash,
+		} {
+			if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+				problem(key, hash, "must be a hash of 64 hex digits when StartAtHeight is set")
+			}
+		}
+	}
+
+	if s.App.LivenessChainID != "" {
+		if b, err := hex.DecodeString(s.App.LivenessChainID); err != nil || len(b) != 32 {
+			problem("App.LivenessChainID", s.App.LivenessChainID, "must be empty, or a chain ID of 64 hex digits")
+		}
+		if !primitives.ValidateECPrivateUserStr(s.App.LivenessECKey) {
+			problem("App.LivenessECKey", "", "must be an entry credit private key (Es...) when LivenessChainID is set")
+		}
+		if s.App.LivenessBlocks < 1 {
+			problem("App.LivenessBlocks", s.App.LivenessBlocks, "must be at least 1")
+		}
+	}
+
+	if _, err := log.ParseLevel(s.Log.LogLevel); err != nil {
+		problem("Log.LogLevel", s.Log.LogLevel, "must be one of debug, info, notice, warning, error, critical, alert, emergency, none")
+	}
+	oneOf("Log.ConsoleLogLevel", strings.ToLower(s.Log.ConsoleLogLevel), "debug", "standard")
+	oneOf("Log.LogFormat", strings.ToLower(s.Log.LogFormat), "text", "json")
+	for key, level := range map[string]string{
+		"Log.P2PLogLevel":   s.Log.P2PLogLevel,
+		"Log.StateLogLevel": s.Log.StateLogLevel,
+		"Log.WsapiLogLevel": s.Log.WsapiLogLevel,
+		"Log.DBLogLevel":    s.Log.DBLogLevel,
+	} {
+		if _, err := log.ParseLevel(level); level != "" && err != nil {
+			problem(key, level, "must be empty, or one of debug, info, notice, warning, error, critical, alert, emergency, none")
+		}
+	}
+
+	if s.Telemetry.Enabled {
+		if u, err := url.Parse(s.Telemetry.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problem("Telemetry.URL", s.Telemetry.URL, "must be an http or https URL when Telemetry is Enabled")
+		}
+	}
+	if s.Anchor.EthereumEnabled {
+		oneOf("Anchor.EthereumGasPolicy", s.Anchor.EthereumGasPolicy, "", "node", "fixed")
+		if s.Anchor.EthereumContract == "" {
+			problem("Anchor.EthereumContract", s.Anchor.EthereumContract, "must be set when EthereumEnabled")
+		}
+	}
+	for name, fault := range s.NetworkFault {
+		key := fmt.Sprintf("NetworkFault.%s.", name)
+		for field, p := range map[string]float64{
+			"Drop":      fault.Drop,
+			"Delay":     fault.Delay,
+			"Duplicate": fault.Duplicate,
+			"Reorder":   fault.Reorder,
+			"Corrupt":   fault.Corrupt,
+		} {
+			if p < 0 || p > 1 {
+				problem(key+field, p, "must be a probability from 0 to 1")
+			}
+		}
+		if fault.MaxDelayMs < 0 {
+			problem(key+"MaxDelayMs", fault.MaxDelayMs, "must not be negative")
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+	return problems
+}
+
+// validPort returns whether the string is a port number.
+func validPort(port string) bool {
+	p, err := strconv.Atoi(port)
+	return err == nil && p > 0 && p <= 65535
+}
+
+// badPeers returns the peers of a special peers string that are not an
+// address and a port, split as the p2p controller splits them.
+func badPeers(peers string) []string {
+	var bad []string
+	fields := strings.FieldsFunc(peers, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c) && !unicode.IsPunct(c)
+	})
+	for _, peer := range fields {
+		ipPort := strings.Split(peer, ":")
+		if len(ipPort) != 2 || ipPort[0] == "" || !validPort(ipPort[1]) {
+			bad = append(bad, peer)
+		}
+	}
+	return bad
+}
+
+// writableDir returns an error unless the directory can be written, or
+// created in the closest directory above it that exists.
+func writableDir(path string) error {
+	if path == "" {
+		return fmt.Errorf("must be a directory")
+	}
+	dir := filepath.Clean(path)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no directory of the path exists")
+		}
+		dir = parent
+	}
+	f, err := ioutil.TempFile(dir, ".factomd")
+	if err != nil {
+		return fmt.Errorf("%s is not writable", dir)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
@@ -0,0 +1,47 @@
+package util_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/FactomProject/factomd/p2p"
+	. "github.com/FactomProject/factomd/util"
+)
+
+func TestValidateConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "factomd-config")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := ReadConfig(dir + "/factomd.conf")
+	cfg.App.HomeDir = dir
+	cfg.App.LdbPath = dir + "/ldb"
+	cfg.App.FastBootLocation = dir
+	cfg.Log.LogPath = dir + "/log"
+	if problems := cfg.Validate(""); len(problems) != 0 {
+		t.Errorf("The default config has problems: %v", problems)
+	}
+
+	cfg.App.NodeMode = "LEADER"
+	cfg.App.PortNumber = 70000
+	cfg.App.MainSpecialPeers = "1.2.3.4:8108 5.6.7.8"
+	cfg.Log.P2PLogLevel = "loud"
+	cfg.NetworkFault = map[string]*p2p.FaultConfig{"default": {Drop: 2}}
+	cfg.App.StartAtHeight = 1000
+	cfg.App.StartAtKeyMR = "abc"
+	cfg.App.AckBatchSize = 5000
+	cfg.App.LivenessChainID = "abc"
+	problems := cfg.Validate("MAINNET")
+	keys := []string{"App.AckBatchSize", "App.LivenessChainID", "App.LivenessECKey", "App.MainSpecialPeers", "App.Network", "App.NodeMode", "App.PortNumber", "App.StartAtKeyMR", "App.StartAtSnapshotHash", "Log.P2PLogLevel", "NetworkFault.default.Drop"}
+	if len(problems) != len(keys) {
+		t.Fatalf("Expected %d problems, got %v", len(keys), problems)
+	}
+	for i, key := range keys {
+		if problems[i].Key != key {
+			t.Errorf("Expected a problem with %s, got %v", key, problems[i])
+		}
+	}
+}
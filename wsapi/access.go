@@ -0,0 +1,171 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/web"
+)
+
+// AccessLevel is who may call an API method.
+type AccessLevel int
+
+const (
+	AccessPublic        AccessLevel = iota // Anyone, if FactomdRpcPublicReads is set
+	AccessAuthenticated                    // Clients with the RPC login or the RPC token
+	AccessAdmin                            // Clients with the admin token, or local clients if there is none
+)
+
+// MethodAccess holds the v2 methods that need more than AccessPublic.  The
+// debug API is AccessAdmin as a whole, and the v1 API is AccessAuthenticated
+// for POSTs.
+var MethodAccess = map[string]AccessLevel{
+	"commit-chain":     AccessAuthenticated,
+	"commit-entry":     AccessAuthenticated,
+	"reveal-chain":     AccessAuthenticated,
+	"reveal-entry":     AccessAuthenticated,
+	"factoid-submit":   AccessAuthenticated,
+	"send-raw-message": AccessAdmin,
+	"profile":          AccessAdmin,
+	"api-key-usage":    AccessAdmin,
+	"dump-state":       AccessAdmin,
+}
+
+// apiIsOpen is true if no login or API key is configured, so that anyone may
+// call anything but the admin methods.
+func apiIsOpen(state interfaces.IState) bool {
+	return state.GetRpcUser() == "" && state.GetRpcToken() == "" && state.GetRpcAdminToken() == "" &&
+		len(state.GetApiKeys()) == 0
+}
+
+// clientAccess returns the highest level the credentials of a request give.
+// Without a login, token or API key the authenticated methods are open to
+// everyone.  The admin methods need the admin token, or, without one, a
+// client authenticated other than with an API key that connects from the
+// loopback interface.
+func clientAccess(state interfaces.IState, r *http.Request) AccessLevel {
+	level := presentedAccess(state, r)
+	if level == AccessPublic && state.GetRpcUser() == "" && state.GetRpcToken() == "" && len(state.GetApiKeys()) == 0 {
+		level = AccessAuthenticated
+	}
+	if level == AccessAuthenticated && state.GetRpcAdminToken() == "" && apiKeyOf(state, r) == nil && isLoopback(r) {
+		level = AccessAdmin
+	}
+	return level
+}
+
+// isLoopback is true if the request comes from the loopback interface.
+func isLoopback(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// presentedAccess returns the level the credentials of a request prove on
+// their own, which is AccessPublic if they match no configured secret.
+func presentedAccess(state interfaces.IState, r *http.Request) AccessLevel {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return AccessPublic
+	}
+	if adminToken := state.GetRpcAdminToken(); adminToken != "" && sameSecret(auth, "Bearer "+adminToken) {
+		return AccessAdmin
+	}
+	if state.GetRpcUser() != "" && checkAuthHeader(state, r) == nil {
+		return AccessAuthenticated
+	}
+	if token := state.GetRpcToken(); token != "" && sameSecret(auth, "Bearer "+token) {
+		return AccessAuthenticated
+	}
+	if apiKeyOf(state, r) != nil {
+		return AccessAuthenticated
+	}
+	return AccessPublic
+}
+
+// sameSecret compares hashes, as ConstantTimeCompare only takes a constant
+// time for slices of the same size.
+func sameSecret(presented, correct string) bool {
+	p := sha256.Sum256([]byte(presented))
+	c := sha256.Sum256([]byte(correct))
+	return subtle.ConstantTimeCompare(p[:], c[:]) == 1
+}
+
+// checkAccess answers 401 or 403, and returns false, unless the client may
+// call something that needs the given level.  It then checks the client's
+// rate limit.  api names the API in the log, and is what an API key must be
+// allowed to call.
+func checkAccess(state interfaces.IState, ctx *web.Context, needed AccessLevel, api string) bool {
+	return checkMethodAccess(state, ctx, needed, api, strings.ToLower(api))
+}
+
+// checkMethodAccess is checkAccess for a method of an API, which is what an
+// API key must be allowed to call, and what its use is counted under.
+func checkMethodAccess(state interfaces.IState, ctx *web.Context, needed AccessLevel, api string, method string) bool {
+	if apiIsOpen(state) && needed < AccessAdmin {
+		return checkRateLimit(state, ctx, needed)
+	}
+	required := needed
+	if required == AccessPublic && !state.GetRpcPublicReads() {
+		required = AccessAuthenticated
+	}
+	have := clientAccess(state, ctx.Request)
+	key := apiKeyOf(state, ctx.Request)
+	if have >= required && apiKeyAllows(key, method) {
+		if !checkRateLimit(state, ctx, needed) {
+			countApiKeyUse(key, method, apiKeyRateLimited)
+			return false
+		}
+		countApiKeyUse(key, method, apiKeyAllowed)
+		return true
+	}
+	countApiKeyUse(key, method, apiKeyDenied)
+
+	remoteIP := strings.Split(ctx.Request.RemoteAddr, ":")[0]
+	fmt.Printf("Unauthorized %s API client connection attempt from %s\n", api, remoteIP)
+	if have == AccessPublic {
+		ctx.ResponseWriter.Header().Add("WWW-Authenticate", `Basic realm="factomd RPC"`)
+		http.Error(ctx.ResponseWriter, "401 Unauthorized.", http.StatusUnauthorized)
+	} else {
+		http.Error(ctx.ResponseWriter, "403 Forbidden.", http.StatusForbidden)
+	}
+	return false
+}
+
+// MethodAllowed returns true if a client presenting the Authorization header
+// may call the v2 method.  It is for the gRPC API, which takes the same
+// credentials in its metadata; use of an API key is counted as for /v2.  The
+// address of a gRPC client isn't known here, so the admin methods need the
+// admin token.
+func MethodAllowed(state interfaces.IState, authorization string, method string) bool {
+	required := MethodAccess[method]
+	if apiIsOpen(state) && required < AccessAdmin {
+		return true
+	}
+	r := &http.Request{Header: http.Header{}}
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	if required == AccessPublic && !state.GetRpcPublicReads() {
+		required = AccessAuthenticated
+	}
+	key := apiKeyOf(state, r)
+	if clientAccess(state, r) >= required && apiKeyAllows(key, method) {
+		countApiKeyUse(key, method, apiKeyAllowed)
+		return true
+	}
+	countApiKeyUse(key, method, apiKeyDenied)
+	return false
+}
@@ -0,0 +1,119 @@
+package wsapi_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestMethodAccess(t *testing.T) {
+	context := testHelper.CreateWebContext()
+	s := testHelper.CreateAndPopulateTestState()
+	context.Server.Env["state"] = s
+	s.RpcUser = "user"
+	s.RpcPass = "pass"
+	login := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	h := sha256.Sum256([]byte(login))
+	s.RpcAuthHash = h[:]
+	s.RpcToken = "token"
+	s.RpcAdminToken = "admin"
+
+	call := func(method, auth string) int {
+		testHelper.ClearContextResponseWriter(context)
+		body, _ := primitives.NewJSON2Request(method, 1, nil).JSONString()
+		r, err := http.NewRequest("POST", "/v2", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		context.Request = r
+		HandleV2(context)
+		return getCode(context)
+	}
+	denied := func(code int) bool {
+		return code == http.StatusUnauthorized || code == http.StatusForbidden
+	}
+
+	toTest := []struct {
+		method, auth string
+		publicReads  bool
+		ok           bool
+	}{
+		{"properties", "", false, false},
+		{"properties", "", true, true},
+		{"properties", login, false, true},
+		{"properties", "Bearer token", false, true},
+		{"properties", "Bearer wrong", false, false},
+		{"commit-chain", "", true, false},
+		{"commit-chain", login, true, true},
+		{"send-raw-message", login, true, false},
+		{"send-raw-message", "Bearer admin", true, true},
+		{"profile", login, true, false},
+		{"dump-state", login, true, false},
+		{"dump-state", "Bearer token", true, false},
+	}
+	for _, tt := range toTest {
+		s.RpcPublicReads = tt.publicReads
+		code := call(tt.method, tt.auth)
+		if denied(code) == tt.ok {
+			t.Errorf("%s with %q (public reads %v) got %d", tt.method, tt.auth, tt.publicReads, code)
+		}
+	}
+}
+
+func TestAdminAccessWithoutCredentials(t *testing.T) {
+	context := testHelper.CreateWebContext()
+	s := testHelper.CreateAndPopulateTestState()
+	context.Server.Env["state"] = s
+
+	call := func(method, remote string) int {
+		testHelper.ClearContextResponseWriter(context)
+		body, _ := primitives.NewJSON2Request(method, 1, nil).JSONString()
+		r, err := http.NewRequest("POST", "/v2", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		r.RemoteAddr = remote
+		context.Request = r
+		HandleV2(context)
+		return getCode(context)
+	}
+	denied := func(code int) bool {
+		return code == http.StatusUnauthorized || code == http.StatusForbidden
+	}
+
+	toTest := []struct {
+		method, remote string
+		ok             bool
+	}{
+		{"properties", "203.0.113.5:8088", true},
+		{"commit-chain", "203.0.113.5:8088", true},
+		{"send-raw-message", "203.0.113.5:8088", false},
+		{"dump-state", "203.0.113.5:8088", false},
+		{"api-key-usage", "203.0.113.5:8088", false},
+		{"send-raw-message", "127.0.0.1:8088", true},
+		{"dump-state", "[::1]:8088", true},
+	}
+	for _, tt := range toTest {
+		code := call(tt.method, tt.remote)
+		if denied(code) == tt.ok {
+			t.Errorf("%s from %s got %d", tt.method, tt.remote, code)
+		}
+	}
+
+	// The gRPC API doesn't know the address of its clients
+	if MethodAllowed(s, "", "send-raw-message") {
+		t.Error("send-raw-message is allowed over gRPC without credentials")
+	}
+	if !MethodAllowed(s, "", "properties") {
+		t.Error("properties is denied over gRPC without credentials")
+	}
+}
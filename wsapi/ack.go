@@ -423,6 +423,52 @@ func HandleV2EntryACK(state interfaces.IState, params interface{}) (interface{},
 	return answer, nil
 }
 
+// HandleV2Ack tells how far a commit, entry or factoid transaction has come
+// into the blockchain, with where it is: the minute of the process list it
+// was acknowledged in, or the directory block it was saved in.
+func HandleV2Ack(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallAck.Observe(float64(time.Since(n).Nanoseconds()))
+
+	ackReq := new(AckLocationRequest)
+	err := MapToObject(params, ackReq)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	h, err := primitives.NewShaHashFromStr(ackReq.Hash)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+
+	status, dbheight, minute, keymr, err := state.GetAckLocation(h)
+	if err != nil {
+		return nil, NewInternalError()
+	}
+
+	answer := new(AckLocation)
+	answer.Hash = h.String()
+	switch status {
+	case constants.AckStatusInvalid:
+		answer.Stage = AckStageInvalid
+	case constants.AckStatusUnknown:
+		answer.Stage = AckStageUnknown
+	case constants.AckStatusNotConfirmed:
+		answer.Stage = AckStageHolding
+	case constants.AckStatusACK:
+		answer.Stage = AckStageProcessList
+		answer.DBHeight = dbheight
+		answer.Minute = &minute
+	case constants.AckStatusDBlockConfirmed:
+		answer.Stage = AckStageDBlock
+		answer.DBHeight = dbheight
+		answer.KeyMR = keymr.String()
+	default:
+		return nil, NewInternalError()
+	}
+	return answer, nil
+}
+
 func DecodeTransactionToHashes(fullTransaction string) (eTxID string, ecTxID string) {
 	//fmt.Printf("DecodeTransactionToHashes - %v\n", fullTransaction)
 	b, err := hex.DecodeString(fullTransaction)
@@ -462,6 +508,18 @@ type AckRequest struct {
 	FullTransaction string `json:"fulltransaction,omitempty"`
 }
 
+type AckLocationRequest struct {
+	Hash string `json:"hash"` // Commit txid, entry hash or factoid txid
+}
+
+type AckLocation struct {
+	Hash     string `json:"hash"`
+	Stage    string `json:"stage"`
+	DBHeight uint32 `json:"dbheight,omitempty"` // Of the process list or the directory block
+	Minute   *int   `json:"minute,omitempty"`   // In the process list
+	KeyMR    string `json:"keymr,omitempty"`    // Of the directory block
+}
+
 type FactoidTxStatus struct {
 	TxID string `json:"txid"`
 	GeneralTransactionData
@@ -505,3 +563,12 @@ const (
 	AckStatus1Minute         = "1Minute"
 	AckStatusDBlockConfirmed = "DBlockConfirmed"
 )
+
+// The stages of the ack method
+const (
+	AckStageInvalid     = "invalid"      // Rejected by the node
+	AckStageUnknown     = "unknown"      // Not seen by the node
+	AckStageHolding     = "holding"      // Waiting to be acknowledged
+	AckStageProcessList = "process-list" // Acknowledged in a minute of the block being built
+	AckStageDBlock      = "dblock"       // Saved in a directory block
+)
@@ -361,3 +361,56 @@ func TestHandleV2EntryACK(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleV2Ack(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	blocks := testHelper.CreateFullTestBlockSet()
+
+	check := func(hash string, block *testHelper.BlockSet) {
+		r, jError := HandleV2Ack(state, AckLocationRequest{Hash: hash})
+		if jError != nil {
+			t.Errorf("%v", jError)
+			return
+		}
+		resp, ok := r.(*AckLocation)
+		if ok == false {
+			t.Error("Invalid response type returned")
+			return
+		}
+		if resp.Stage != AckStageDBlock {
+			t.Errorf("Invalid stage returned for %v - %v", hash, resp.Stage)
+		}
+		if resp.DBHeight != uint32(block.Height) || resp.KeyMR != block.DBlock.GetKeyMR().String() {
+			t.Errorf("Invalid directory block returned for %v - %v %v", hash, resp.DBHeight, resp.KeyMR)
+		}
+		if resp.Minute != nil {
+			t.Errorf("Minute returned for a saved transaction - %v", *resp.Minute)
+		}
+	}
+
+	for _, block := range blocks {
+		for _, e := range block.Entries {
+			check(e.GetHash().String(), block)
+		}
+		for _, tx := range block.ECBlock.GetEntries() {
+			if tx.ECID() == entryCreditBlock.ECIDChainCommit || tx.ECID() == entryCreditBlock.ECIDEntryCommit {
+				check(tx.GetSigHash().String(), block)
+			}
+		}
+		for _, tx := range block.FBlock.GetTransactions() {
+			check(tx.GetSigHash().String(), block)
+		}
+	}
+
+	r, jError := HandleV2Ack(state, AckLocationRequest{Hash: "00000000000000000000000000000000000000000000000000000000000000ff"})
+	if jError != nil {
+		t.Errorf("%v", jError)
+	} else if resp := r.(*AckLocation); resp.Stage != AckStageUnknown || resp.DBHeight != 0 || resp.KeyMR != "" {
+		t.Errorf("Invalid answer for an unknown hash - %v", resp)
+	}
+
+	_, jError = HandleV2Ack(state, AckLocationRequest{Hash: "not a hash"})
+	if jError == nil {
+		t.Errorf("No error for an invalid hash")
+	}
+}
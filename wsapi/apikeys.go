@@ -0,0 +1,111 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// ApiKeyUsage counts the requests made with an API key since the node started.
+type ApiKeyUsage struct {
+	Requests    map[string]int64 `json:"requests"`    // Allowed requests by v2 method, or API
+	Denied      int64            `json:"denied"`      // Requests for what the key may not call
+	RateLimited int64            `json:"ratelimited"` // Requests over the RateLimit of the key
+	LastUsed    int64            `json:"lastused"`    // Unix seconds, 0 if never
+}
+
+// What became of a request made with an API key
+const (
+	apiKeyAllowed = iota
+	apiKeyDenied
+	apiKeyRateLimited
+)
+
+// The usage of the API keys, by name
+var apiKeyUsage = map[string]*ApiKeyUsage{}
+var apiKeyUsageMutex sync.Mutex
+
+// apiKeyOf returns the API key a request presents, or nil if none.
+func apiKeyOf(state interfaces.IState, r *http.Request) *interfaces.ApiKey {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return nil
+	}
+	for _, key := range state.GetApiKeys() {
+		if sameSecret(auth, "Bearer "+key.Key) {
+			return key
+		}
+	}
+	return nil
+}
+
+// apiKeyAllows is true if the key may call the method, or if there is no
+// key, as then only the level of the client matters.
+func apiKeyAllows(key *interfaces.ApiKey, method string) bool {
+	if key == nil || key.Methods == nil {
+		return true
+	}
+	for _, m := range key.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func countApiKeyUse(key *interfaces.ApiKey, method string, result int) {
+	if key == nil {
+		return
+	}
+	apiKeyUsageMutex.Lock()
+	defer apiKeyUsageMutex.Unlock()
+
+	u := apiKeyUsage[key.Name]
+	if u == nil {
+		u = new(ApiKeyUsage)
+		u.Requests = map[string]int64{}
+		apiKeyUsage[key.Name] = u
+	}
+	switch result {
+	case apiKeyAllowed:
+		u.Requests[method]++
+	case apiKeyDenied:
+		u.Denied++
+	case apiKeyRateLimited:
+		u.RateLimited++
+	}
+	u.LastUsed = time.Now().Unix()
+}
+
+// HandleV2ApiKeyUsage returns the usage of every configured API key.
+func HandleV2ApiKeyUsage(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallApiKeyUsage.Observe(float64(time.Since(n).Nanoseconds()))
+
+	apiKeyUsageMutex.Lock()
+	defer apiKeyUsageMutex.Unlock()
+
+	resp := new(ApiKeyUsageResponse)
+	resp.Keys = map[string]*ApiKeyUsage{}
+	for name := range state.GetApiKeys() {
+		u := new(ApiKeyUsage)
+		u.Requests = map[string]int64{}
+		if used := apiKeyUsage[name]; used != nil {
+			u.Denied = used.Denied
+			u.RateLimited = used.RateLimited
+			u.LastUsed = used.LastUsed
+			for method, count := range used.Requests {
+				u.Requests[method] = count
+			}
+		}
+		resp.Keys[name] = u
+	}
+	return resp, nil
+}
@@ -0,0 +1,84 @@
+package wsapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestApiKeys(t *testing.T) {
+	context := testHelper.CreateWebContext()
+	s := testHelper.CreateAndPopulateTestState()
+	context.Server.Env["state"] = s
+	port := s.GetPort()
+	s.ApiKeys = map[string]*interfaces.ApiKey{
+		"explorer": {Name: "explorer", Key: "explorer-key", Methods: []string{"heights", "properties"}},
+		"wallet":   {Name: "wallet", Key: "wallet-key", RateLimit: 1},
+	}
+
+	defer func(burst int) {
+		RateLimitBurst = burst
+		SetRateLimits(port, nil)
+	}(RateLimitBurst)
+	RateLimitBurst = 1
+	SetRateLimits(port, NewRateLimits(0, 0))
+
+	call := func(method, key string) int {
+		testHelper.ClearContextResponseWriter(context)
+		body, _ := primitives.NewJSON2Request(method, 1, nil).JSONString()
+		r, err := http.NewRequest("POST", "/v2", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+		context.Request = r
+		HandleV2(context)
+		if code := getCode(context); code != 0 {
+			return code
+		}
+		return http.StatusOK
+	}
+
+	toTest := []struct {
+		method, key string
+		code        int
+	}{
+		{"properties", "", http.StatusUnauthorized},
+		{"properties", "wrong-key", http.StatusUnauthorized},
+		{"properties", "explorer-key", http.StatusOK},
+		{"heights", "explorer-key", http.StatusOK},
+		{"commit-chain", "explorer-key", http.StatusForbidden},
+		{"send-raw-message", "wallet-key", http.StatusForbidden},
+		{"properties", "wallet-key", http.StatusOK},
+		{"properties", "wallet-key", http.StatusTooManyRequests},
+	}
+	for _, tt := range toTest {
+		if code := call(tt.method, tt.key); code != tt.code {
+			t.Errorf("%s with %q got %d, expected %d", tt.method, tt.key, code, tt.code)
+		}
+	}
+
+	resp, jsonError := HandleV2ApiKeyUsage(s, nil)
+	if jsonError != nil {
+		t.Fatalf("%v", jsonError)
+	}
+	keys := resp.(*ApiKeyUsageResponse).Keys
+	if len(keys) != 2 {
+		t.Fatalf("Usage of %d keys, expected 2", len(keys))
+	}
+	explorer := keys["explorer"]
+	if explorer.Requests["properties"] != 1 || explorer.Requests["heights"] != 1 || explorer.Denied != 1 {
+		t.Errorf("Wrong usage of explorer - %v", explorer)
+	}
+	wallet := keys["wallet"]
+	if wallet.Requests["properties"] != 1 || wallet.Denied != 1 || wallet.RateLimited != 1 || wallet.LastUsed == 0 {
+		t.Errorf("Wrong usage of wallet - %v", wallet)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"net/http"
+)
+
+// corsHandler lets browsers call the API from pages of other origins, such
+// as explorers and web wallets.  It adds the CORS headers to the responses
+// for the origins it allows, and answers their preflight requests itself.
+type corsHandler struct {
+	handler http.Handler
+	origins map[string]bool // "*" allows any origin
+	methods string
+}
+
+// NewCORSHandler wraps the API for the origins in CorsDomains.  Without any,
+// it returns handler, and browsers only allow calls from the node's own pages.
+func NewCORSHandler(handler http.Handler, origins []string, methods string) http.Handler {
+	if len(origins) == 0 {
+		return handler
+	}
+	c := new(corsHandler)
+	c.handler = handler
+	c.origins = map[string]bool{}
+	for _, origin := range origins {
+		c.origins[origin] = true
+	}
+	c.methods = methods
+	return c
+}
+
+func (c *corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !(c.origins["*"] || c.origins[origin]) {
+		c.handler.ServeHTTP(w, r)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Add("Vary", "Origin")
+	h.Set("Access-Control-Allow-Methods", c.methods)
+	h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	h.Set("Access-Control-Expose-Headers", "Factomd-Api-Version, Deprecation, Sunset, Link, Retry-After")
+
+	if r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	c.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,59 @@
+package wsapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestCORSHandler(t *testing.T) {
+	called := false
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	handler := NewCORSHandler(api, []string{"https://explorer.example.com"}, "GET, POST, OPTIONS")
+
+	serve := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		called = false
+		r, err := http.NewRequest(method, "/v2", nil)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if preflight {
+			r.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("POST", "https://explorer.example.com", false)
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://explorer.example.com" || !called {
+		t.Errorf("Allowed origin got %v, called %v", w.Header(), called)
+	}
+
+	w = serve("OPTIONS", "https://explorer.example.com", true)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "GET, POST, OPTIONS" || called {
+		t.Errorf("Preflight got %d %v, called %v", w.Code, w.Header(), called)
+	}
+
+	w = serve("POST", "https://evil.example.com", false)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" || !called {
+		t.Errorf("Other origin got %v, called %v", w.Header(), called)
+	}
+
+	handler = NewCORSHandler(api, []string{"*"}, "GET")
+	w = serve("GET", "https://any.example.com", false)
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://any.example.com" {
+		t.Errorf("Any origin got %v", w.Header())
+	}
+
+	if NewCORSHandler(api, nil, "GET") == nil {
+		t.Errorf("No handler without origins")
+	}
+}
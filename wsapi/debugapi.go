@@ -5,14 +5,15 @@
 package wsapi
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net/http"
-	"strings"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/loadgen"
+	"github.com/FactomProject/factomd/log"
 	"github.com/FactomProject/factomd/util"
 	"github.com/FactomProject/web"
 )
@@ -22,23 +23,7 @@ func HandleDebug(ctx *web.Context) {
 	state := ctx.Server.Env["state"].(interfaces.IState)
 	ServersMutex.Unlock()
 
-	if err := checkAuthHeader(state, ctx.Request); err != nil {
-		remoteIP := ""
-		remoteIP += strings.Split(ctx.Request.RemoteAddr, ":")[0]
-		fmt.Printf(
-			"Unauthorized V2 API client connection attempt from %s\n",
-			remoteIP,
-		)
-		ctx.ResponseWriter.Header().Add(
-			"WWW-Authenticate",
-			`Basic realm="factomd RPC"`,
-		)
-		http.Error(
-			ctx.ResponseWriter,
-			"401 Unauthorized.",
-			http.StatusUnauthorized,
-		)
-
+	if !checkAccess(state, ctx, AccessAdmin, "debug") {
 		return
 	}
 
@@ -54,6 +39,7 @@ func HandleDebug(ctx *web.Context) {
 		return
 	}
 
+	setRequestMethod(ctx.Request, j.Method)
 	jsonResp, jsonError := HandleDebugRequest(state, j)
 
 	if jsonError != nil {
@@ -75,6 +61,9 @@ func HandleDebugRequest(
 	var jsonError *primitives.JSONError
 	params := j.Params
 	switch j.Method {
+	case "acks":
+		resp, jsonError = HandleAcks(state, params)
+		break
 	case "audit-servers":
 		resp, jsonError = HandleAuditServers(state, params)
 		break
@@ -84,15 +73,30 @@ func HandleDebugRequest(
 	case "configuration":
 		resp, jsonError = HandleConfig(state, params)
 		break
+	case "checkpoints":
+		resp, jsonError = HandleCheckpoints(state, params)
+		break
+	case "clear-fork":
+		resp, jsonError = HandleClearFork(state, params)
+		break
+	case "compact-database":
+		resp, jsonError = HandleCompactDatabase(state, params)
+		break
 	case "current-minute":
 		resp, jsonError = HandleCurrentMinute(state, params)
 		break
+	case "database-stats":
+		resp, jsonError = HandleDatabaseStats(state, params)
+		break
 	case "delay":
 		resp, jsonError = HandleDelay(state, params)
 		break
 	case "set-delay":
 		resp, jsonError = HandleSetDelay(state, params)
 		break
+	case "election-status":
+		resp, jsonError = HandleElectionStatus(state, params)
+		break
 	case "drop-rate":
 		resp, jsonError = HandleDropRate(state, params)
 		break
@@ -102,9 +106,21 @@ func HandleDebugRequest(
 	case "federated-servers":
 		resp, jsonError = HandleFedServers(state, params)
 		break
+	case "log-levels":
+		resp, jsonError = HandleLogLevels(state, params)
+		break
+	case "set-log-level":
+		resp, jsonError = HandleSetLogLevel(state, params)
+		break
 	case "holding-queue":
 		resp, jsonError = HandleHoldingQueue(state, params)
 		break
+	case "message-trace":
+		resp, jsonError = HandleMessageTrace(state, params)
+		break
+	case "set-message-tracing":
+		resp, jsonError = HandleSetMessageTracing(state, params)
+		break
 	case "messages":
 		resp, jsonError = HandleMessages(state, params)
 		break
@@ -120,9 +136,21 @@ func HandleDebugRequest(
 	case "process-list":
 		resp, jsonError = HandleProcessList(state, params)
 		break
+	case "replay-stats":
+		resp, jsonError = HandleReplayStats(state, params)
+		break
 	case "reload-configuration":
 		resp, jsonError = HandleReloadConfig(state, params)
 		break
+	case "load-generator":
+		resp, jsonError = HandleLoadGenerator(state, params)
+		break
+	case "start-load-generator":
+		resp, jsonError = HandleStartLoadGenerator(state, params)
+		break
+	case "stop-load-generator":
+		resp, jsonError = HandleStopLoadGenerator(state, params)
+		break
 	default:
 		jsonError = NewMethodNotFoundError()
 		break
@@ -139,6 +167,24 @@ func HandleDebugRequest(
 	return jsonResp, nil
 }
 
+func HandleAcks(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Acks []interfaces.IMsg
+	}
+	r := new(ret)
+
+	for _, v := range state.LoadAcksMap() {
+		r.Acks = append(r.Acks, v)
+	}
+	return r, nil
+}
+
 func HandleAuditServers(
 	state interfaces.IState,
 	params interface{},
@@ -197,6 +243,81 @@ func HandleCurrentMinute(
 	return r, nil
 }
 
+func HandleDatabaseStats(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type bucket struct {
+		Bucket    string `json:"bucket"` // In hex, as the names of entry buckets are chain IDs
+		Keys      int    `json:"keys"`
+		KeyBytes  int64  `json:"keybytes"`
+		DataBytes int64  `json:"databytes"`
+	}
+	type ret struct {
+		Buckets    []bucket `json:"buckets"`
+		TotalKeys  int      `json:"totalkeys"`
+		TotalBytes int64    `json:"totalbytes"`
+	}
+	r := new(ret)
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	stats, err := dbase.FetchDatabaseStats()
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	for _, s := range stats {
+		r.Buckets = append(r.Buckets, bucket{hex.EncodeToString(s.Bucket), s.Keys, s.KeyBytes, s.DataBytes})
+		r.TotalKeys += s.Keys
+		r.TotalBytes += s.KeyBytes + s.DataBytes
+	}
+	return r, nil
+}
+
+func HandleCompactDatabase(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Message string `json:"message"`
+	}
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	if err := dbase.Compact(); err != nil {
+		return nil, NewCustomInternalError(err.Error())
+	}
+	return ret{"Database compacted"}, nil
+}
+
+// HandleClearFork clears the fork the node found, so that it saves blocks
+// again.  The operator calls it once the node is on the side it should be.
+func HandleClearFork(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Message string `json:"message"`
+	}
+
+	if state.GetForkStatus() == nil {
+		return ret{"No fork to clear"}, nil
+	}
+	state.ClearForkStatus()
+	return ret{"Fork cleared"}, nil
+}
+
 func HandleDelay(
 	state interfaces.IState,
 	params interface{},
@@ -276,6 +397,20 @@ func HandleSetDropRate(
 	return r, nil
 }
 
+func HandleElectionStatus(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	es := state.GetElectionStatus()
+	if es == nil {
+		return nil, NewCustomInternalError("No process list yet")
+	}
+	return es, nil
+}
+
 func HandleFedServers(
 	state interfaces.IState,
 	params interface{},
@@ -292,6 +427,53 @@ func HandleFedServers(
 	return r, nil
 }
 
+func HandleLogLevels(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Levels map[string]string
+	}
+	r := new(ret)
+
+	r.Levels = log.PackageLevels()
+	return r, nil
+}
+
+// HandleSetLogLevel sets the console log level of a package on the running
+// node, until the node restarts or the config is reloaded.  An empty level
+// puts the package back at the default.
+func HandleSetLogLevel(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Levels map[string]string
+	}
+	r := new(ret)
+
+	req := new(SetLogLevelRequest)
+	err := MapToObject(params, req)
+	if err != nil || req.Package == "" {
+		return nil, NewInvalidParamsError()
+	}
+
+	err = log.ChangePackageLevel(req.Package, req.Level)
+	if err != nil {
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+	apiLog.Noticef("Log level of %s set to %q", req.Package, req.Level)
+
+	r.Levels = log.PackageLevels()
+	return r, nil
+}
+
 func HandleHoldingQueue(
 	state interfaces.IState,
 	params interface{},
@@ -327,6 +509,62 @@ func HandleMessages(
 	return r, nil
 }
 
+// HandleMessageTrace returns when the message with the hash reached each
+// stage on its way into a block, while message tracing is on.
+func HandleMessageTrace(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Hash   string
+		Stages []interfaces.MsgTraceStage
+	}
+	r := new(ret)
+
+	hashkey := new(HashRequest)
+	err := MapToObject(params, hashkey)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	h, err := primitives.HexToHash(hashkey.Hash)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+
+	r.Hash = h.String()
+	r.Stages = state.GetMsgTrace(h)
+	if r.Stages == nil {
+		return nil, NewObjectNotFoundError()
+	}
+	return r, nil
+}
+
+func HandleSetMessageTracing(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Enabled bool
+	}
+	r := new(ret)
+
+	req := new(SetMessageTracingRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	state.SetMsgTracing(req.Enabled)
+	r.Enabled = req.Enabled
+	return r, nil
+}
+
 func HandleNetworkInfo(
 	state interfaces.IState,
 	params interface{},
@@ -392,6 +630,29 @@ func HandleProcessList(
 	return r, nil
 }
 
+func HandleCheckpoints(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Checkpoints []*interfaces.Checkpoint
+	}
+	return &ret{Checkpoints: state.GetCheckpoints()}, nil
+}
+
+func HandleReplayStats(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	return state.GetReplayStats(), nil
+}
+
 func HandleReloadConfig(
 	state interfaces.IState,
 	params interface{},
@@ -405,6 +666,57 @@ func HandleReloadConfig(
 	return state.GetCfg(), nil
 }
 
+// HandleLoadGenerator returns what the load generator last started on the
+// node has submitted, and how long it took to be acknowledged and confirmed.
+func HandleLoadGenerator(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	g := loadgen.Running(state)
+	if g == nil {
+		return nil, NewCustomInternalError("No load generator has been started")
+	}
+	return g.Stats(), nil
+}
+
+// HandleStartLoadGenerator starts submitting chains, entries and factoid
+// transactions paid for by the sand address of the test networks, at the
+// rate of the params, in place of any load generator already running.
+func HandleStartLoadGenerator(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	config := loadgen.DefaultConfig
+	if params != nil {
+		if err := MapToObject(params, &config); err != nil {
+			return nil, NewInvalidParamsError()
+		}
+	}
+
+	g, err := loadgen.Start(state, config)
+	if err != nil {
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+	return g.Stats(), nil
+}
+
+func HandleStopLoadGenerator(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	loadgen.Stop(state)
+	return HandleLoadGenerator(state, params)
+}
+
 type SetDelayRequest struct {
 	Delay int64 `json:"delay"`
 }
@@ -412,3 +724,12 @@ type SetDelayRequest struct {
 type SetDropRateRequest struct {
 	DropRate int `json:"droprate"`
 }
+
+type SetLogLevelRequest struct {
+	Package string `json:"package"`
+	Level   string `json:"level"`
+}
+
+type SetMessageTracingRequest struct {
+	Enabled bool `json:"enabled"`
+}
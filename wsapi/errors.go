@@ -59,6 +59,9 @@ func NewUnableToDecodeTransactionError() *primitives.JSONError {
 func NewInvalidTransactionError() *primitives.JSONError {
 	return primitives.NewJSONError(-32602, "Invalid params", "Invalid Transaction")
 }
+func NewRejectedTransactionError(reason error) *primitives.JSONError {
+	return primitives.NewJSONError(-32602, "Invalid params", "Invalid Transaction: "+reason.Error())
+}
 func NewInvalidHashError() *primitives.JSONError {
 	return primitives.NewJSONError(-32602, "Invalid params", "Invalid Hash")
 }
@@ -0,0 +1,786 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/web"
+)
+
+// The /graphql endpoint answers GraphQL queries over the blocks in the
+// database, so that explorers can fetch a directory block with its entry
+// blocks and their entries, or a transaction with its addresses, in one
+// request.  It takes a POST of
+//
+//	{"query": "...", "variables": {...}}
+//
+// Queries may have arguments, aliases and variables, but not fragments or
+// directives, and there are no mutations.  The schema is
+//
+//	type Query {
+//		directoryBlock(keyMR: String, height: Int): DirectoryBlock  # The head without either
+//		entryBlock(keyMR: String!): EntryBlock
+//		chainHead(chainID: String!): EntryBlock
+//		entry(hash: String!): Entry
+//		factoidBlock(keyMR: String, height: Int): FactoidBlock
+//		transaction(txID: String!): Transaction
+//	}
+//	type DirectoryBlock {
+//		keyMR: String!  height: Int!  timestamp: Int!  prevKeyMR: String!
+//		entryBlocks: [EntryBlock!]!  factoidBlock: FactoidBlock
+//	}
+//	type EntryBlock {
+//		keyMR: String!  chainID: String!  height: Int!  sequence: Int!  prevKeyMR: String!
+//		entries: [Entry!]!  directoryBlock: DirectoryBlock
+//	}
+//	type Entry { hash: String!  chainID: String!  content: String!  extIDs: [String!]!  entryBlock: EntryBlock }
+//	type FactoidBlock {
+//		keyMR: String!  height: Int!  exchangeRate: Int!  prevKeyMR: String!
+//		transactions: [Transaction!]!  directoryBlock: DirectoryBlock
+//	}
+//	type Transaction {
+//		txID: String!  timestamp: Int!  inputs: [Address!]!  outputs: [Address!]!  ecOutputs: [Address!]!
+//		factoidBlock: FactoidBlock
+//	}
+//	type Address { address: String!  amount: Int! }
+//
+// Hashes and content are hex, and timestamps are Unix seconds.
+//
+// So that one request can't tie up the node, a POST may be no larger than
+// GraphQLMaxBodySize, selection sets may nest no deeper than GraphQLMaxDepth,
+// and a query may resolve no more than GraphQLMaxComplexity fields, counting
+// those of each object in a list.
+
+var (
+	GraphQLMaxBodySize   int64 = 64 * 1024 // Bytes in a POST
+	GraphQLMaxDepth            = 8         // Nested selection sets
+	GraphQLMaxComplexity       = 10000     // Fields resolved by a query
+)
+
+type GraphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message string `json:"message"`
+}
+
+func HandleGraphQL(ctx *web.Context) {
+	n := time.Now()
+	defer HandleAPICallGraphQL.Observe(float64(time.Since(n).Nanoseconds()))
+	ServersMutex.Lock()
+	state := ctx.Server.Env["state"].(interfaces.IState)
+	ServersMutex.Unlock()
+
+	if !checkAccess(state, ctx, AccessPublic, "GraphQL") {
+		return
+	}
+
+	ctx.ResponseWriter.Header().Set("Content-Type", "application/json")
+	req := new(GraphQLRequest)
+	body, err := ioutil.ReadAll(io.LimitReader(ctx.Request.Body, GraphQLMaxBodySize+1))
+	if err == nil {
+		err = json.Unmarshal(body, req)
+	}
+	var resp *GraphQLResponse
+	if int64(len(body)) > GraphQLMaxBodySize {
+		ctx.WriteHeader(http.StatusRequestEntityTooLarge)
+		resp = &GraphQLResponse{Errors: []GraphQLError{{Message: fmt.Sprintf("Request is larger than %d bytes", GraphQLMaxBodySize)}}}
+	} else if err != nil {
+		ctx.WriteHeader(http.StatusBadRequest)
+		resp = &GraphQLResponse{Errors: []GraphQLError{{Message: "Invalid request"}}}
+	} else {
+		resp = ExecuteGraphQL(state, req)
+	}
+
+	p, err := json.Marshal(resp)
+	if err != nil {
+		wsLog.Error(err)
+		return
+	}
+	ctx.Write(p)
+}
+
+// ExecuteGraphQL runs a query.  The first error stops it, and the response
+// then has no data.
+func ExecuteGraphQL(state interfaces.IState, req *GraphQLRequest) *GraphQLResponse {
+	p := &gqlParser{src: req.Query, vars: req.Variables}
+	fields, err := p.document()
+	if err != nil {
+		return &GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}}
+	}
+
+	q := new(gqlQuery)
+	q.dbase = state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	data, err := q.execute("Query", nil, fields)
+	if err != nil {
+		return &GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}}
+	}
+	return &GraphQLResponse{Data: data}
+}
+
+// gqlField is a field of a selection set, with the fields selected from its
+// value if that is an object.
+type gqlField struct {
+	Alias  string
+	Name   string
+	Args   map[string]interface{}
+	Fields []*gqlField
+}
+
+// gqlParser parses the subset of GraphQL that ExecuteGraphQL runs.
+type gqlParser struct {
+	src   string
+	pos   int
+	vars  map[string]interface{}
+	depth int // Of the selection set being parsed
+}
+
+func (p *gqlParser) skip() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next character that isn't space, or 0 at the end.
+func (p *gqlParser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *gqlParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("Expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *gqlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Syntax error at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func (p *gqlParser) name() (string, error) {
+	if !isNameStart(p.peek()) {
+		return "", p.errorf("Expected a name")
+	}
+	start := p.pos
+	for p.pos < len(p.src) && (isNameStart(p.src[p.pos]) || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	return p.src[start:p.pos], nil
+}
+
+// document parses a single query, and returns its selection set.
+func (p *gqlParser) document() ([]*gqlField, error) {
+	if isNameStart(p.peek()) {
+		op, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if op != "query" {
+			return nil, fmt.Errorf("Only queries are supported, not %s", op)
+		}
+		if isNameStart(p.peek()) {
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+		}
+		// The types of the variables aren't checked
+		if p.peek() == '(' {
+			for p.pos < len(p.src) && p.src[p.pos] != ')' {
+				p.pos++
+			}
+			if err := p.expect(')'); err != nil {
+				return nil, err
+			}
+		}
+	}
+	fields, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.errorf("Only one operation is supported")
+	}
+	return fields, nil
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlField, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	if p.depth++; p.depth > GraphQLMaxDepth {
+		return nil, fmt.Errorf("Query is nested deeper than %d", GraphQLMaxDepth)
+	}
+	defer func() { p.depth-- }()
+	var fields []*gqlField
+	for p.peek() != '}' {
+		if p.peek() == '.' {
+			return nil, p.errorf("Fragments are not supported")
+		}
+		f, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	p.pos++
+	return fields, nil
+}
+
+func (p *gqlParser) field() (*gqlField, error) {
+	f := new(gqlField)
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.Alias, f.Name = name, name
+	if p.peek() == ':' {
+		p.pos++
+		if f.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek() == '(' {
+		p.pos++
+		f.Args = map[string]interface{}{}
+		for p.peek() != ')' {
+			arg, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			if f.Args[arg], err = p.value(); err != nil {
+				return nil, err
+			}
+		}
+		p.pos++
+	}
+	if p.peek() == '{' {
+		if f.Fields, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// value parses a string, integer, boolean or null, or a variable.
+func (p *gqlParser) value() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '$':
+		p.pos++
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return p.vars[name], nil
+	case c == '"':
+		start := p.pos
+		for p.pos++; p.pos < len(p.src) && p.src[p.pos] != '"'; p.pos++ {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+		}
+		p.pos++
+		if p.pos > len(p.src) {
+			return nil, p.errorf("Unterminated string")
+		}
+		s, err := strconv.Unquote(p.src[start:p.pos])
+		if err != nil {
+			return nil, p.errorf("Invalid string")
+		}
+		return s, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos++; p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9'; p.pos++ {
+		}
+		i, err := strconv.ParseInt(p.src[start:p.pos], 10, 64)
+		if err != nil {
+			return nil, p.errorf("Invalid integer")
+		}
+		return float64(i), nil
+	case isNameStart(c):
+		name, _ := p.name()
+		switch name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, p.errorf("Expected a value")
+}
+
+// gqlObject is a value of one of the object types of the schema.  A nil v
+// is null.
+type gqlObject struct {
+	typ string
+	v   interface{}
+}
+
+// gqlResolver returns the value of a field of an object, which is a scalar,
+// a gqlObject or a []gqlObject.
+type gqlResolver func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error)
+
+type gqlQuery struct {
+	dbase    interfaces.DBOverlaySimple
+	resolved int // Fields resolved so far, against GraphQLMaxComplexity
+}
+
+// gqlResult keeps the fields of an object in the order they were asked for.
+type gqlResult struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (r *gqlResult) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		value, err := json.Marshal(r.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (q *gqlQuery) execute(typ string, v interface{}, fields []*gqlField) (*gqlResult, error) {
+	r := &gqlResult{values: map[string]interface{}{}}
+	for _, f := range fields {
+		if q.resolved++; q.resolved > GraphQLMaxComplexity {
+			return nil, fmt.Errorf("Query resolves more than %d fields", GraphQLMaxComplexity)
+		}
+		var value interface{}
+		if f.Name == "__typename" {
+			value = typ
+		} else {
+			resolve := gqlTypes[typ][f.Name]
+			if resolve == nil {
+				return nil, fmt.Errorf("Cannot query field %q on type %s", f.Name, typ)
+			}
+			resolved, err := resolve(q, v, f.Args)
+			if err != nil {
+				return nil, err
+			}
+			if value, err = q.complete(f, resolved); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := r.values[f.Alias]; !ok {
+			r.keys = append(r.keys, f.Alias)
+		}
+		r.values[f.Alias] = value
+	}
+	return r, nil
+}
+
+// complete runs the selection set of a field on its value.
+func (q *gqlQuery) complete(f *gqlField, value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case gqlObject:
+		if len(f.Fields) == 0 {
+			return nil, fmt.Errorf("Field %q of type %s must have a selection of subfields", f.Name, value.typ)
+		}
+		if value.v == nil {
+			return nil, nil
+		}
+		return q.execute(value.typ, value.v, f.Fields)
+	case []gqlObject:
+		list := []interface{}{}
+		for _, o := range value {
+			r, err := q.complete(f, o)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, r)
+		}
+		return list, nil
+	}
+	if len(f.Fields) > 0 {
+		return nil, fmt.Errorf("Field %q is a scalar and can't have subfields", f.Name)
+	}
+	return value, nil
+}
+
+// gqlHashArg returns a hash argument, or nil if there is none.
+func gqlHashArg(args map[string]interface{}, name string) (interfaces.IHash, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("Argument %q must be a string", name)
+	}
+	h, err := primitives.HexToHash(s)
+	if err != nil {
+		return nil, fmt.Errorf("Argument %q is not a valid hash", name)
+	}
+	return h, nil
+}
+
+func gqlRequiredHashArg(args map[string]interface{}, name string) (interfaces.IHash, error) {
+	h, err := gqlHashArg(args, name)
+	if err == nil && h == nil {
+		err = fmt.Errorf("Argument %q is required", name)
+	}
+	return h, err
+}
+
+// gqlHeightArg returns a block height argument, and whether there is one.
+// Numbers from variables are float64 like those from the query.
+func gqlHeightArg(args map[string]interface{}, name string) (uint32, bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f < 0 || f > float64(^uint32(0)) || f != float64(uint32(f)) {
+		return 0, false, fmt.Errorf("Argument %q must be a block height", name)
+	}
+	return uint32(f), true, nil
+}
+
+func isBasicChain(chainID interfaces.IHash) bool {
+	b := chainID.Bytes()
+	return bytes.Equal(b, constants.ADMIN_CHAINID) || bytes.Equal(b, constants.EC_CHAINID) || bytes.Equal(b, constants.FACTOID_CHAINID)
+}
+
+func gqlDBlock(dblock interfaces.IDirectoryBlock, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if dblock == nil {
+		return gqlObject{typ: "DirectoryBlock"}, nil
+	}
+	return gqlObject{"DirectoryBlock", dblock}, nil
+}
+
+func gqlEBlock(eblock interfaces.IEntryBlock, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if eblock == nil {
+		return gqlObject{typ: "EntryBlock"}, nil
+	}
+	return gqlObject{"EntryBlock", eblock}, nil
+}
+
+func gqlFBlock(fblock interfaces.IFBlock, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if fblock == nil {
+		return gqlObject{typ: "FactoidBlock"}, nil
+	}
+	return gqlObject{"FactoidBlock", fblock}, nil
+}
+
+// gqlAddress is an input or output of a transaction.
+type gqlAddress struct {
+	address interfaces.ITransAddress
+	ec      bool
+}
+
+func gqlAddresses(addresses []interfaces.ITransAddress, ec bool) []gqlObject {
+	list := []gqlObject{}
+	for _, a := range addresses {
+		list = append(list, gqlObject{"Address", &gqlAddress{a, ec}})
+	}
+	return list
+}
+
+var gqlTypes = map[string]map[string]gqlResolver{
+	"Query": {
+		"directoryBlock": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			keyMR, err := gqlHashArg(args, "keyMR")
+			if err != nil {
+				return nil, err
+			}
+			height, byHeight, err := gqlHeightArg(args, "height")
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case keyMR != nil:
+				return gqlDBlock(q.dbase.FetchDBlock(keyMR))
+			case byHeight:
+				return gqlDBlock(q.dbase.FetchDBlockByHeight(height))
+			}
+			return gqlDBlock(q.dbase.FetchDBlockHead())
+		},
+		"entryBlock": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			keyMR, err := gqlRequiredHashArg(args, "keyMR")
+			if err != nil {
+				return nil, err
+			}
+			return gqlEBlock(q.dbase.FetchEBlock(keyMR))
+		},
+		"chainHead": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			chainID, err := gqlRequiredHashArg(args, "chainID")
+			if err != nil {
+				return nil, err
+			}
+			keyMR, err := q.dbase.FetchHeadIndexByChainID(chainID)
+			if err != nil || keyMR == nil {
+				return gqlEBlock(nil, err)
+			}
+			return gqlEBlock(q.dbase.FetchEBlock(keyMR))
+		},
+		"entry": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			hash, err := gqlRequiredHashArg(args, "hash")
+			if err != nil {
+				return nil, err
+			}
+			entry, err := q.dbase.FetchEntry(hash)
+			if err != nil {
+				return nil, err
+			}
+			if entry == nil {
+				return gqlObject{typ: "Entry"}, nil
+			}
+			return gqlObject{"Entry", entry}, nil
+		},
+		"factoidBlock": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			keyMR, err := gqlHashArg(args, "keyMR")
+			if err != nil {
+				return nil, err
+			}
+			height, byHeight, err := gqlHeightArg(args, "height")
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case keyMR != nil:
+				return gqlFBlock(q.dbase.FetchFBlock(keyMR))
+			case byHeight:
+				return gqlFBlock(q.dbase.FetchFBlockByHeight(height))
+			}
+			return nil, fmt.Errorf("Argument \"keyMR\" or \"height\" is required")
+		},
+		"transaction": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			txID, err := gqlRequiredHashArg(args, "txID")
+			if err != nil {
+				return nil, err
+			}
+			tx, err := q.dbase.FetchFactoidTransaction(txID)
+			if err != nil {
+				return nil, err
+			}
+			if tx == nil {
+				return gqlObject{typ: "Transaction"}, nil
+			}
+			return gqlObject{"Transaction", tx}, nil
+		},
+	},
+	"DirectoryBlock": {
+		"keyMR": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IDirectoryBlock).GetKeyMR().String(), nil
+		},
+		"height": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IDirectoryBlock).GetDatabaseHeight(), nil
+		},
+		"timestamp": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IDirectoryBlock).GetHeader().GetTimestamp().GetTimeSeconds(), nil
+		},
+		"prevKeyMR": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IDirectoryBlock).GetHeader().GetPrevKeyMR().String(), nil
+		},
+		"entryBlocks": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			list := []gqlObject{}
+			for _, e := range v.(interfaces.IDirectoryBlock).GetDBEntries() {
+				if isBasicChain(e.GetChainID()) {
+					continue
+				}
+				eblock, err := q.dbase.FetchEBlock(e.GetKeyMR())
+				if err != nil {
+					return nil, err
+				}
+				if eblock != nil {
+					list = append(list, gqlObject{"EntryBlock", eblock})
+				}
+			}
+			return list, nil
+		},
+		"factoidBlock": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			for _, e := range v.(interfaces.IDirectoryBlock).GetDBEntries() {
+				if bytes.Equal(e.GetChainID().Bytes(), constants.FACTOID_CHAINID) {
+					return gqlFBlock(q.dbase.FetchFBlock(e.GetKeyMR()))
+				}
+			}
+			return gqlFBlock(nil, nil)
+		},
+	},
+	"EntryBlock": {
+		"keyMR": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			keyMR, err := v.(interfaces.IEntryBlock).KeyMR()
+			if err != nil {
+				return nil, err
+			}
+			return keyMR.String(), nil
+		},
+		"chainID": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IEntryBlock).GetHeader().GetChainID().String(), nil
+		},
+		"height": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IEntryBlock).GetHeader().GetDBHeight(), nil
+		},
+		"sequence": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IEntryBlock).GetHeader().GetEBSequence(), nil
+		},
+		"prevKeyMR": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IEntryBlock).GetHeader().GetPrevKeyMR().String(), nil
+		},
+		"entries": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			entries, err := q.dbase.FetchEBlockEntries(v.(interfaces.IEntryBlock))
+			if err != nil {
+				return nil, err
+			}
+			list := []gqlObject{}
+			for _, entry := range entries {
+				list = append(list, gqlObject{"Entry", entry})
+			}
+			return list, nil
+		},
+		"directoryBlock": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return gqlDBlock(q.dbase.FetchDBlockByHeight(v.(interfaces.IEntryBlock).GetHeader().GetDBHeight()))
+		},
+	},
+	"Entry": {
+		"hash": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IEBEntry).GetHash().String(), nil
+		},
+		"chainID": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IEBEntry).GetChainID().String(), nil
+		},
+		"content": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return hex.EncodeToString(v.(interfaces.IEBEntry).GetContent()), nil
+		},
+		"extIDs": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			extIDs := []string{}
+			for _, id := range v.(interfaces.IEBEntry).ExternalIDs() {
+				extIDs = append(extIDs, hex.EncodeToString(id))
+			}
+			return extIDs, nil
+		},
+		"entryBlock": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			keyMR, err := q.dbase.FetchIncludedIn(v.(interfaces.IEBEntry).GetHash())
+			if err != nil || keyMR == nil {
+				return gqlEBlock(nil, err)
+			}
+			return gqlEBlock(q.dbase.FetchEBlock(keyMR))
+		},
+	},
+	"FactoidBlock": {
+		"keyMR": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IFBlock).GetKeyMR().String(), nil
+		},
+		"height": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IFBlock).GetDatabaseHeight(), nil
+		},
+		"exchangeRate": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IFBlock).GetExchRate(), nil
+		},
+		"prevKeyMR": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.IFBlock).GetPrevKeyMR().String(), nil
+		},
+		"transactions": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			list := []gqlObject{}
+			for _, tx := range v.(interfaces.IFBlock).GetTransactions() {
+				list = append(list, gqlObject{"Transaction", tx})
+			}
+			return list, nil
+		},
+		"directoryBlock": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return gqlDBlock(q.dbase.FetchDBlockByHeight(v.(interfaces.IFBlock).GetDatabaseHeight()))
+		},
+	},
+	"Transaction": {
+		"txID": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.ITransaction).GetSigHash().String(), nil
+		},
+		"timestamp": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(interfaces.ITransaction).GetTimestamp().GetTimeSeconds(), nil
+		},
+		"inputs": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return gqlAddresses(v.(interfaces.ITransaction).GetInputs(), false), nil
+		},
+		"outputs": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return gqlAddresses(v.(interfaces.ITransaction).GetOutputs(), false), nil
+		},
+		"ecOutputs": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return gqlAddresses(v.(interfaces.ITransaction).GetECOutputs(), true), nil
+		},
+		"factoidBlock": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			keyMR, err := q.dbase.FetchIncludedIn(v.(interfaces.ITransaction).GetSigHash())
+			if err != nil || keyMR == nil {
+				return gqlFBlock(nil, err)
+			}
+			return gqlFBlock(q.dbase.FetchFBlock(keyMR))
+		},
+	},
+	"Address": {
+		"address": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			a := v.(*gqlAddress)
+			if a.ec {
+				return primitives.ConvertECAddressToUserStr(a.address.GetAddress()), nil
+			}
+			return primitives.ConvertFctAddressToUserStr(a.address.GetAddress()), nil
+		},
+		"amount": func(q *gqlQuery, v interface{}, args map[string]interface{}) (interface{}, error) {
+			return v.(*gqlAddress).address.GetAmount(), nil
+		},
+	},
+}
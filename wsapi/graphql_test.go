@@ -0,0 +1,142 @@
+package wsapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestExecuteGraphQL(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	blocks := testHelper.CreateFullTestBlockSet()
+
+	query := `query Blocks($height: Int) {
+		block: directoryBlock(height: $height) {
+			keyMR
+			height
+			factoidBlock { keyMR transactions { txID inputs { address amount } } }
+			entryBlocks { chainID entries { hash entryBlock { keyMR } } }
+		}
+	}`
+	for i, block := range blocks {
+		resp := ExecuteGraphQL(state, &GraphQLRequest{Query: query, Variables: map[string]interface{}{"height": float64(i)}})
+		if len(resp.Errors) > 0 {
+			t.Fatalf("%v", resp.Errors)
+		}
+		p, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		var r struct {
+			Data struct {
+				Block struct {
+					KeyMR        string
+					Height       int
+					FactoidBlock struct {
+						KeyMR        string
+						Transactions []struct {
+							TxID string
+						}
+					}
+					EntryBlocks []struct {
+						Entries []struct {
+							Hash       string
+							EntryBlock struct {
+								KeyMR string
+							}
+						}
+					}
+				}
+			}
+		}
+		if err := json.Unmarshal(p, &r); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		b := r.Data.Block
+		if b.KeyMR != block.DBlock.DatabasePrimaryIndex().String() || b.Height != i {
+			t.Errorf("Wrong directory block %d - %s", i, p)
+		}
+		if b.FactoidBlock.KeyMR != block.FBlock.DatabasePrimaryIndex().String() {
+			t.Errorf("Wrong factoid block %d - %s", i, p)
+		}
+		if len(b.FactoidBlock.Transactions) != len(block.FBlock.GetTransactions()) {
+			t.Errorf("Got %d transactions in block %d, not %d", len(b.FactoidBlock.Transactions), i, len(block.FBlock.GetTransactions()))
+		}
+		for _, eblock := range b.EntryBlocks {
+			for _, entry := range eblock.Entries {
+				if entry.EntryBlock.KeyMR == "" {
+					t.Errorf("Entry %s has no entry block", entry.Hash)
+				}
+			}
+		}
+	}
+}
+
+func TestExecuteGraphQLErrors(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	queries := []string{
+		`{ directoryBlock(height: 0) { keyMR `,
+		`{ directoryBlock(height: 0) { noSuchField } }`,
+		`{ directoryBlock(height: 0) }`,
+		`{ directoryBlock(height: 0) { keyMR { hash } } }`,
+		`{ directoryBlock(height: -1) { keyMR } }`,
+		`{ entry(hash: "not a hash") { content } }`,
+		`{ entryBlock { keyMR } }`,
+		`{ directoryBlock { ...Fields } }`,
+		`mutation { directoryBlock { keyMR } }`,
+	}
+	for _, query := range queries {
+		resp := ExecuteGraphQL(state, &GraphQLRequest{Query: query})
+		if len(resp.Errors) == 0 || resp.Data != nil {
+			t.Errorf("No error for %s", query)
+		}
+	}
+
+	resp := ExecuteGraphQL(state, &GraphQLRequest{Query: `{ directoryBlock(height: 100000) { keyMR } }`})
+	if len(resp.Errors) > 0 {
+		t.Errorf("%v", resp.Errors)
+	}
+	p, _ := json.Marshal(resp)
+	if string(p) != `{"data":{"directoryBlock":null}}` {
+		t.Errorf("Got %s for a missing block", p)
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	// Depth
+	deep := `{ directoryBlock(height: 0) { entryBlocks { directoryBlock { entryBlocks { directoryBlock { entryBlocks { directoryBlock { entryBlocks { keyMR } } } } } } } } }`
+	resp := ExecuteGraphQL(state, &GraphQLRequest{Query: deep})
+	if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, "nested deeper") {
+		t.Errorf("No depth error for a query %d deep - %v", GraphQLMaxDepth+1, resp.Errors)
+	}
+
+	// Complexity
+	query := `{ directoryBlock(height: 1) { keyMR height timestamp prevKeyMR entryBlocks { keyMR } } }`
+	if resp := ExecuteGraphQL(state, &GraphQLRequest{Query: query}); len(resp.Errors) > 0 {
+		t.Fatalf("%v", resp.Errors)
+	}
+	defer func(max int) { GraphQLMaxComplexity = max }(GraphQLMaxComplexity)
+	GraphQLMaxComplexity = 5
+	resp = ExecuteGraphQL(state, &GraphQLRequest{Query: query})
+	if len(resp.Errors) == 0 || resp.Data != nil || !strings.Contains(resp.Errors[0].Message, "more than 5 fields") {
+		t.Errorf("No complexity error - %v", resp.Errors)
+	}
+
+	// Body size
+	context := testHelper.CreateWebContext()
+	body := `{"query": "{ directoryBlock { keyMR } }", "variables": {"pad": "` + strings.Repeat("x", int(GraphQLMaxBodySize)) + `"}}`
+	context.Request = httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	HandleGraphQL(context)
+	if code := getCode(context); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Got code %d for a body too large", code)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/web"
+)
+
+// Statuses of the health check
+const (
+	HealthOK   = "ok"
+	HealthFork = "fork" // Two directory blocks were signed for a height; no more blocks are saved
+)
+
+// HealthResponse is what /healthz answers.
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Fork   *interfaces.ForkStatus `json:"fork,omitempty"`
+}
+
+// Health returns the HTTP status and the answer of the health check of the
+// node: 200 while it is well, and 503 once it found a fork.
+func Health(state interfaces.IState) (int, *HealthResponse) {
+	if fork := state.GetForkStatus(); fork != nil {
+		return http.StatusServiceUnavailable, &HealthResponse{Status: HealthFork, Fork: fork}
+	}
+	return http.StatusOK, &HealthResponse{Status: HealthOK}
+}
+
+// HandleHealthz serves the health check, for load balancers and monitoring.
+// It needs no login, as the probes that call it have none.
+func HandleHealthz(ctx *web.Context) {
+	ServersMutex.Lock()
+	state := ctx.Server.Env["state"].(interfaces.IState)
+	ServersMutex.Unlock()
+
+	status, resp := Health(state)
+	ctx.ResponseWriter.Header().Set("Content-Type", "application/json")
+	ctx.WriteHeader(status)
+	if p, err := json.Marshal(resp); err == nil {
+		ctx.Write(p)
+	}
+}
@@ -0,0 +1,32 @@
+package wsapi_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestHealth(t *testing.T) {
+	s := testHelper.CreateAndPopulateTestState()
+
+	status, resp := Health(s)
+	if status != http.StatusOK || resp.Status != HealthOK || resp.Fork != nil {
+		t.Errorf("Wrong health %d %v", status, resp)
+	}
+
+	s.WatchSignedBlock(1, primitives.Sha([]byte("a")), state.ForkSourceLocal, "fed1")
+	s.WatchSignedBlock(1, primitives.Sha([]byte("b")), state.ForkSourceDBSig, "fed2")
+	status, resp = Health(s)
+	if status != http.StatusServiceUnavailable || resp.Status != HealthFork || resp.Fork == nil || resp.Fork.DBHeight != 1 {
+		t.Errorf("Wrong health %d %v", status, resp)
+	}
+
+	s.ClearForkStatus()
+	if status, resp = Health(s); status != http.StatusOK || resp.Fork != nil {
+		t.Errorf("Wrong health once the fork is cleared %d %v", status, resp)
+	}
+}
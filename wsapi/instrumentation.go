@@ -5,11 +5,27 @@ import (
 )
 
 var (
+	// Request rates
+	APIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "factomd_wsapi_requests_total",
+		Help: "Requests to the API by version, or other for the unversioned paths",
+	}, []string{"version"})
+
+	HandleV2APIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "factomd_wsapi_v2_requests_total",
+		Help: "Calls to the v2 API by method and result",
+	}, []string{"method", "result"})
+
 	HandleV2APICallGeneral = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "factomd_wsapi_v2_api_general_call_ns",
 		Help: "Time it takes to compelete a call",
 	})
 
+	HandleAPICallGraphQL = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_graphql_call_ns",
+		Help: "Time it takes to compelete a graphql query",
+	})
+
 	HandleV2APICallChainHead = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "factomd_wsapi_v2_api_call_chainhead_ns",
 		Help: "Time it takes to compelete a chainhead",
@@ -60,11 +76,41 @@ var (
 		Help: "Time it takes to compelete a fabal",
 	})
 
+	HandleV2APICallMultiBal = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_multibal_ns",
+		Help: "Time it takes to compelete a multibal",
+	})
+
+	HandleV2APICallBalanceAtHeight = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_balanceatheight_ns",
+		Help: "Time it takes to compelete a balanceatheight",
+	})
+
+	HandleV2APICallChainStats = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_chainstats_ns",
+		Help: "Time it takes to compelete a chainstats",
+	})
+
+	HandleV2APICallSupply = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_supply_ns",
+		Help: "Time it takes to compelete a supply",
+	})
+
 	HandleV2APICallFctTx = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "factomd_wsapi_v2_api_call_fcttx_ns",
 		Help: "Time it takes to compelete a fcttx",
 	})
 
+	HandleV2APICallFctFee = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_fctfee_ns",
+		Help: "Time it takes to compelete a fctfee",
+	})
+
+	HandleV2APICallComposeTx = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_composetx_ns",
+		Help: "Time it takes to compelete a composetx",
+	})
+
 	HandleV2APICallHeights = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "factomd_wsapi_v2_api_call_heights_ns",
 		Help: "Time it takes to compelete a heights",
@@ -85,6 +131,11 @@ var (
 		Help: "Time it takes to compelete a ",
 	})
 
+	HandleV2APICallAnchors = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_anchors_ns",
+		Help: "Time it takes to compelete an anchors",
+	})
+
 	HandleV2APICallRevealEntry = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "factomd_wsapi_v2_api_call_reventry_ns",
 		Help: "Time it takes to compelete a revealentry",
@@ -100,6 +151,11 @@ var (
 		Help: "Time it takes to compelete a entryack",
 	})
 
+	HandleV2APICallAck = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_ack_ns",
+		Help: "Time it takes to compelete an ack",
+	})
+
 	HandleV2APICall = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "factomd_wsapi_v2_api_call__ns",
 		Help: "Time it takes to compelete a ",
@@ -145,6 +201,21 @@ var (
 		Help: "Time it takes to compelete a ablockbyheight",
 	})
 
+	HandleV2APICallABlock = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_ablock_ns",
+		Help: "Time it takes to compelete an ablock",
+	})
+
+	HandleV2APICallFBlock = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_fblock_ns",
+		Help: "Time it takes to compelete an fblock",
+	})
+
+	HandleV2APICallECBlock = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_ecblock_ns",
+		Help: "Time it takes to compelete an ecblock",
+	})
+
 	HandleV2APICallAuthorities = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "factomd_wsapi_v2_api_call_auths_ns",
 		Help: "Time it takes to compelete an auths ",
@@ -154,6 +225,36 @@ var (
 		Name: "factomd_wsapi_v2_api_call_tpsrate_ns",
 		Help: "Time it takes to compelete a tpsrate",
 	})
+
+	HandleV2APICallChainIDs = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_chainids_ns",
+		Help: "Time it takes to compelete a chainids",
+	})
+
+	HandleV2APICallChainEntries = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_chainentries_ns",
+		Help: "Time it takes to compelete a chainentries",
+	})
+
+	HandleV2APICallBlockTxs = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_blocktxs_ns",
+		Help: "Time it takes to compelete a blocktxs",
+	})
+
+	HandleV2APICallProfile = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_profile_ns",
+		Help: "Time it takes to compelete a profile",
+	})
+
+	HandleV2APICallApiKeyUsage = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_apikeyusage_ns",
+		Help: "Time it takes to compelete an apikeyusage",
+	})
+
+	HandleV2APICallDumpState = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_dumpstate_ns",
+		Help: "Time it takes to compelete a dumpstate",
+	})
 )
 
 var registered = false
@@ -166,7 +267,10 @@ func RegisterPrometheus() {
 	}
 	registered = true
 
+	prometheus.MustRegister(APIRequests)
+	prometheus.MustRegister(HandleV2APIRequests)
 	prometheus.MustRegister(HandleV2APICallGeneral)
+	prometheus.MustRegister(HandleAPICallGraphQL)
 	prometheus.MustRegister(HandleV2APICallChainHead)
 	prometheus.MustRegister(HandleV2APICallCommitChain)
 	prometheus.MustRegister(HandleV2APICallCommitEntry)
@@ -177,14 +281,22 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(HandleV2APICallECBal)
 	prometheus.MustRegister(HandleV2APICallECRate)
 	prometheus.MustRegister(HandleV2APICallFABal)
+	prometheus.MustRegister(HandleV2APICallMultiBal)
+	prometheus.MustRegister(HandleV2APICallBalanceAtHeight)
+	prometheus.MustRegister(HandleV2APICallChainStats)
+	prometheus.MustRegister(HandleV2APICallSupply)
 	prometheus.MustRegister(HandleV2APICallFctTx)
+	prometheus.MustRegister(HandleV2APICallFctFee)
+	prometheus.MustRegister(HandleV2APICallComposeTx)
 	prometheus.MustRegister(HandleV2APICallHeights)
 	prometheus.MustRegister(HandleV2APICallProp)
 	prometheus.MustRegister(HandleV2APICallRawData)
 	prometheus.MustRegister(HandleV2APICallReceipt)
+	prometheus.MustRegister(HandleV2APICallAnchors)
 	prometheus.MustRegister(HandleV2APICallRevealEntry)
 	prometheus.MustRegister(HandleV2APICallFctAck)
 	prometheus.MustRegister(HandleV2APICallEntryAck)
+	prometheus.MustRegister(HandleV2APICallAck)
 	prometheus.MustRegister(HandleV2APICall)
 	prometheus.MustRegister(HandleV2APICallPendingEntries)
 	prometheus.MustRegister(HandleV2APICallPendingTxs)
@@ -194,6 +306,15 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(HandleV2APICallECBlockByHeight)
 	prometheus.MustRegister(HandleV2APICallFblockByHeight)
 	prometheus.MustRegister(HandleV2APICallABlockByHeight)
+	prometheus.MustRegister(HandleV2APICallABlock)
+	prometheus.MustRegister(HandleV2APICallFBlock)
+	prometheus.MustRegister(HandleV2APICallECBlock)
 	prometheus.MustRegister(HandleV2APICallAuthorities)
 	prometheus.MustRegister(HandleV2APICallTpsRate)
+	prometheus.MustRegister(HandleV2APICallChainIDs)
+	prometheus.MustRegister(HandleV2APICallChainEntries)
+	prometheus.MustRegister(HandleV2APICallBlockTxs)
+	prometheus.MustRegister(HandleV2APICallProfile)
+	prometheus.MustRegister(HandleV2APICallApiKeyUsage)
+	prometheus.MustRegister(HandleV2APICallDumpState)
 }
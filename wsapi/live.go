@@ -0,0 +1,250 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/web"
+	"golang.org/x/net/websocket"
+)
+
+// The /live WebSocket pushes an event to its clients for every directory block
+// the node saves, and for the entry blocks, factoid transactions and authority
+// changes in it.  Entry block events are only sent for the chains a client
+// subscribed to, by sending
+//
+//   {"subscribe":["<chainid>", ...]}
+//
+// and {"unsubscribe":[...]} to stop.  A client that doesn't keep up with the
+// events is disconnected rather than skipping some.
+
+// Event types of the /live WebSocket
+const (
+	LiveDBlock      = "dblock"
+	LiveEBlock      = "eblock"
+	LiveTransaction = "transaction"
+	LiveAuthority   = "authority"
+)
+
+// LiveBufferSize is the number of events queued for a client before it is
+// disconnected.
+var LiveBufferSize = 1000
+
+type LiveEvent struct {
+	Event   string `json:"event"`
+	Height  uint32 `json:"height"`
+	KeyMR   string `json:"keymr,omitempty"`
+	ChainID string `json:"chainid,omitempty"`
+	TxID    string `json:"txid,omitempty"`
+	// For authority events: what happened to the identity ("add-federated",
+	// "add-audit" or "remove-federated")
+	Change string `json:"change,omitempty"`
+}
+
+type LiveRequest struct {
+	Subscribe   []string `json:"subscribe,omitempty"`
+	Unsubscribe []string `json:"unsubscribe,omitempty"`
+}
+
+type liveClient struct {
+	events chan *LiveEvent
+	mutex  sync.Mutex
+	chains map[string]bool // Chains subscribed to
+}
+
+func (c *liveClient) subscribed(chainID string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.chains[chainID]
+}
+
+type liveFeed struct {
+	mutex   sync.Mutex
+	clients map[*liveClient]bool
+}
+
+// The feeds of the nodes, which are many in a simulation
+var liveFeeds = map[interfaces.IState]*liveFeed{}
+var liveFeedsMutex sync.Mutex
+
+func getLiveFeed(state interfaces.IState) *liveFeed {
+	liveFeedsMutex.Lock()
+	defer liveFeedsMutex.Unlock()
+
+	feed := liveFeeds[state]
+	if feed == nil {
+		feed = &liveFeed{clients: map[*liveClient]bool{}}
+		liveFeeds[state] = feed
+	}
+	return feed
+}
+
+func (f *liveFeed) add() *liveClient {
+	c := &liveClient{events: make(chan *LiveEvent, LiveBufferSize), chains: map[string]bool{}}
+	f.mutex.Lock()
+	f.clients[c] = true
+	f.mutex.Unlock()
+	return c
+}
+
+// remove drops a client, and closes its events.  It does nothing if the client
+// was already removed.
+func (f *liveFeed) remove(c *liveClient) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.clients[c] {
+		delete(f.clients, c)
+		close(c.events)
+	}
+}
+
+func (f *liveFeed) publish(events []*LiveEvent) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for c := range f.clients {
+	events:
+		for _, e := range events {
+			if e.Event == LiveEBlock && !c.subscribed(e.ChainID) {
+				continue
+			}
+			select {
+			case c.events <- e:
+			default:
+				delete(f.clients, c)
+				close(c.events)
+				break events
+			}
+		}
+	}
+}
+
+// PublishDBState sends the events of a saved directory block to the /live
+// clients of the node.
+func PublishDBState(state interfaces.IState, dblock interfaces.IDirectoryBlock, ablock interfaces.IAdminBlock, fblock interfaces.IFBlock) {
+	feed := getLiveFeed(state)
+	feed.mutex.Lock()
+	empty := len(feed.clients) == 0
+	feed.mutex.Unlock()
+	if empty {
+		return
+	}
+	feed.publish(dbStateEvents(dblock, ablock, fblock))
+}
+
+func dbStateEvents(dblock interfaces.IDirectoryBlock, ablock interfaces.IAdminBlock, fblock interfaces.IFBlock) []*LiveEvent {
+	height := dblock.GetDatabaseHeight()
+	events := []*LiveEvent{{Event: LiveDBlock, Height: height, KeyMR: dblock.GetKeyMR().String()}}
+
+	for _, eb := range dblock.GetEBlockDBEntries() {
+		events = append(events, &LiveEvent{Event: LiveEBlock, Height: height, KeyMR: eb.GetKeyMR().String(), ChainID: eb.GetChainID().String()})
+	}
+
+	if fblock != nil {
+		for _, tx := range fblock.GetTransactions() {
+			events = append(events, &LiveEvent{Event: LiveTransaction, Height: height, TxID: tx.GetSigHash().String()})
+		}
+	}
+
+	if ablock != nil {
+		for _, entry := range ablock.GetABEntries() {
+			var change string
+			var identity interfaces.IHash
+			switch e := entry.(type) {
+			case *adminBlock.AddFederatedServer:
+				change, identity = "add-federated", e.IdentityChainID
+			case *adminBlock.AddAuditServer:
+				change, identity = "add-audit", e.IdentityChainID
+			case *adminBlock.RemoveFederatedServer:
+				change, identity = "remove-federated", e.IdentityChainID
+			default:
+				continue
+			}
+			events = append(events, &LiveEvent{Event: LiveAuthority, Height: height, ChainID: identity.String(), Change: change})
+		}
+	}
+	return events
+}
+
+// LiveSubscription receives the /live events of a node outside the WebSocket,
+// for the gRPC API.  Like a WebSocket client, it gets the entry blocks of its
+// chains only, and its Events are closed if it doesn't keep up.
+type LiveSubscription struct {
+	feed   *liveFeed
+	client *liveClient
+}
+
+// SubscribeLive returns a subscription to the events of the node, with the
+// entry blocks of the chains.  It must be closed when no longer read.
+func SubscribeLive(state interfaces.IState, chainIDs []string) *LiveSubscription {
+	feed := getLiveFeed(state)
+	c := feed.add()
+	c.mutex.Lock()
+	for _, chainID := range chainIDs {
+		c.chains[strings.ToLower(chainID)] = true
+	}
+	c.mutex.Unlock()
+	return &LiveSubscription{feed: feed, client: c}
+}
+
+func (s *LiveSubscription) Events() <-chan *LiveEvent {
+	return s.client.events
+}
+
+func (s *LiveSubscription) Close() {
+	s.feed.remove(s.client)
+}
+
+func HandleLive(ctx *web.Context) {
+	ServersMutex.Lock()
+	state := ctx.Server.Env["state"].(interfaces.IState)
+	ok := checkHttpPasswordOkV1(state, ctx)
+	ServersMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	websocket.Handler(func(ws *websocket.Conn) {
+		serveLive(state, ws)
+	}).ServeHTTP(ctx.ResponseWriter, ctx.Request)
+}
+
+// serveLive writes the events of the node to ws until either side closes.
+func serveLive(state interfaces.IState, ws *websocket.Conn) {
+	defer ws.Close()
+
+	feed := getLiveFeed(state)
+	c := feed.add()
+	defer feed.remove(c)
+
+	// Read subscriptions until the client goes away
+	go func() {
+		defer feed.remove(c)
+		for {
+			req := new(LiveRequest)
+			if err := websocket.JSON.Receive(ws, req); err != nil {
+				return
+			}
+			c.mutex.Lock()
+			for _, chainID := range req.Subscribe {
+				c.chains[strings.ToLower(chainID)] = true
+			}
+			for _, chainID := range req.Unsubscribe {
+				delete(c.chains, strings.ToLower(chainID))
+			}
+			c.mutex.Unlock()
+		}
+	}()
+
+	for e := range c.events {
+		if err := websocket.JSON.Send(ws, e); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package wsapi_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+	"github.com/FactomProject/web"
+	"golang.org/x/net/websocket"
+)
+
+func TestLive(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	server := web.NewServer()
+	server.Env["state"] = state
+	server.Get("/live", HandleLive)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/live"
+	ws, err := websocket.Dial(url, "", ts.URL)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer ws.Close()
+
+	chainID := testHelper.GetChainID().String()
+	err = websocket.JSON.Send(ws, &LiveRequest{Subscribe: []string{strings.ToUpper(chainID)}})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	set := testHelper.CreateTestBlockSet(nil)
+	PublishDBState(state, set.DBlock, set.ABlock, set.FBlock)
+
+	events := []*LiveEvent{}
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		e := new(LiveEvent)
+		if err := websocket.JSON.Receive(ws, e); err != nil {
+			break
+		}
+		events = append(events, e)
+	}
+
+	if len(events) == 0 || events[0].Event != LiveDBlock || events[0].KeyMR != set.DBlock.GetKeyMR().String() {
+		t.Fatalf("The first event is not the directory block: %v", events)
+	}
+	eblocks := 0
+	for _, e := range events {
+		if e.Event != LiveEBlock {
+			continue
+		}
+		eblocks++
+		if e.ChainID != chainID {
+			t.Errorf("Got an entry block of chain %s, which is not subscribed", e.ChainID)
+		}
+	}
+	if eblocks != 1 {
+		t.Errorf("Got %d entry blocks of the subscribed chain, expected 1", eblocks)
+	}
+}
@@ -13,6 +13,8 @@ var (
 	rpcLog    *log.FLogger
 	serverLog *log.FLogger
 	wsLog     *log.FLogger
+
+	apiLog = log.ForPackage(log.PackageWsapi) // Console log
 )
 
 func InitLogs(logPath, logLevel string) {
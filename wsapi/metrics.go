@@ -0,0 +1,27 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/web"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HandleMetrics serves the Prometheus metrics of the node on the API port, so
+// that a scraper needs no other port opened to it.  They are the same as on
+// the metrics port of the node (9876).  The metrics need an authenticated
+// client, as they tell about the load and peers of the node.
+func HandleMetrics(ctx *web.Context) {
+	ServersMutex.Lock()
+	state := ctx.Server.Env["state"].(interfaces.IState)
+	ok := checkAccess(state, ctx, AccessAuthenticated, "metrics")
+	ServersMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	prometheus.Handler().ServeHTTP(ctx.ResponseWriter, ctx.Request)
+}
@@ -0,0 +1,66 @@
+package wsapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	m := new(dto.Metric)
+	if err := c.Write(m); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestAPIRequestsCounted(t *testing.T) {
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := NewVersionHandler(api, map[string]time.Time{})
+
+	v2 := counterValue(t, APIRequests.WithLabelValues("v2"))
+	other := counterValue(t, APIRequests.WithLabelValues("other"))
+	for _, path := range []string{"/v2", "/v2", "/metrics"} {
+		r, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if c := counterValue(t, APIRequests.WithLabelValues("v2")); c != v2+2 {
+		t.Errorf("Counted %v v2 requests, not %v", c, v2+2)
+	}
+	if c := counterValue(t, APIRequests.WithLabelValues("other")); c != other+1 {
+		t.Errorf("Counted %v other requests, not %v", c, other+1)
+	}
+}
+
+func TestHandleV2RequestsCounted(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	ok := counterValue(t, HandleV2APIRequests.WithLabelValues("heights", "ok"))
+	unknown := counterValue(t, HandleV2APIRequests.WithLabelValues("unknown", "error"))
+
+	j := primitives.NewJSON2Request("heights", 1, nil)
+	if _, err := HandleV2Request(state, j); err != nil {
+		t.Fatalf("%v", err)
+	}
+	j = primitives.NewJSON2Request("no-such-method", 2, nil)
+	if _, err := HandleV2Request(state, j); err == nil {
+		t.Errorf("No error for an unknown method")
+	}
+
+	if c := counterValue(t, HandleV2APIRequests.WithLabelValues("heights", "ok")); c != ok+1 {
+		t.Errorf("Counted %v heights calls, not %v", c, ok+1)
+	}
+	if c := counterValue(t, HandleV2APIRequests.WithLabelValues("unknown", "error")); c != unknown+1 {
+		t.Errorf("Counted %v unknown calls, not %v", c, unknown+1)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/web"
+)
+
+// RateLimitBurst is how many seconds worth of requests a client may make at
+// once after being idle.
+var RateLimitBurst = 10
+
+// The most clients a limiter tracks before dropping the idle ones
+const maxRateLimitClients = 10000
+
+// RateLimits are the limits of an API server, one for the methods that read
+// and one for the methods that submit or administer.
+type RateLimits struct {
+	reads   *rateLimiter
+	submits *rateLimiter
+
+	// The limiters of the API keys with a RateLimit, by name
+	keysMutex sync.Mutex
+	keys      map[string]*rateLimiter
+}
+
+// NewRateLimits returns the limits for a number of requests a second per
+// client.  A rate of 0 is no limit.
+func NewRateLimits(readRate, submitRate int) *RateLimits {
+	l := new(RateLimits)
+	l.reads = newRateLimiter(readRate)
+	l.submits = newRateLimiter(submitRate)
+	return l
+}
+
+// The limits of the API servers, by port
+var rateLimits = map[int]*RateLimits{}
+var rateLimitsMutex sync.Mutex
+
+// SetRateLimits sets the limits of the API server on a port.  nil is no limit.
+func SetRateLimits(port int, limits *RateLimits) {
+	rateLimitsMutex.Lock()
+	defer rateLimitsMutex.Unlock()
+	rateLimits[port] = limits
+}
+
+func getRateLimits(port int) *RateLimits {
+	rateLimitsMutex.Lock()
+	defer rateLimitsMutex.Unlock()
+	return rateLimits[port]
+}
+
+// keyLimiter returns the limiter of an API key with a RateLimit, which it
+// makes again if the limit was changed.
+func (l *RateLimits) keyLimiter(key *interfaces.ApiKey) *rateLimiter {
+	l.keysMutex.Lock()
+	defer l.keysMutex.Unlock()
+	if l.keys == nil {
+		l.keys = map[string]*rateLimiter{}
+	}
+	k := l.keys[key.Name]
+	if k == nil || k.rate != float64(key.RateLimit) {
+		k = newRateLimiter(key.RateLimit)
+		l.keys[key.Name] = k
+	}
+	return k
+}
+
+// rateLimiter is a token bucket per client.
+type rateLimiter struct {
+	mutex   sync.Mutex
+	rate    float64 // Tokens added a second
+	burst   float64 // Most tokens a bucket holds
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time // When tokens was last brought up to date
+}
+
+func newRateLimiter(rate int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	l := new(rateLimiter)
+	l.rate = float64(rate)
+	l.burst = float64(rate * RateLimitBurst)
+	l.buckets = map[string]*tokenBucket{}
+	return l
+}
+
+// take spends a token of the client.  If it has none, take returns false,
+// and how long until it has one.  A nil limiter always has tokens.
+func (l *rateLimiter) take(client string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b := l.buckets[client]
+	if b == nil {
+		if len(l.buckets) >= maxRateLimitClients {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *rateLimiter) refill(b *tokenBucket, now time.Time) float64 {
+	return math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+}
+
+// prune drops the clients whose buckets are full again, as they are no
+// different from new clients.
+func (l *rateLimiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// rateLimitClient returns who a request counts against: the credentials if
+// they are valid, else the address it came from.
+func rateLimitClient(state interfaces.IState, r *http.Request) string {
+	if presentedAccess(state, r) > AccessPublic {
+		h := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+		return "auth " + hex.EncodeToString(h[:])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// checkRateLimit answers 429, and returns false, if the client made too many
+// requests for methods of the given level.  An API key with a RateLimit has
+// that limit for all its requests instead.
+func checkRateLimit(state interfaces.IState, ctx *web.Context, needed AccessLevel) bool {
+	limits := getRateLimits(state.GetPort())
+	if limits == nil {
+		return true
+	}
+	l := limits.reads
+	if needed > AccessPublic {
+		l = limits.submits
+	}
+	if key := apiKeyOf(state, ctx.Request); key != nil && key.RateLimit > 0 {
+		l = limits.keyLimiter(key)
+	}
+	if l == nil {
+		return true
+	}
+
+	ok, retry := l.take(rateLimitClient(state, ctx.Request), time.Now())
+	if ok {
+		return true
+	}
+	ctx.ResponseWriter.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	http.Error(ctx.ResponseWriter, "429 Too Many Requests.", http.StatusTooManyRequests)
+	return false
+}
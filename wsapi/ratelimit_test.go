@@ -0,0 +1,56 @@
+package wsapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestRateLimits(t *testing.T) {
+	context := testHelper.CreateWebContext()
+	s := testHelper.CreateAndPopulateTestState()
+	context.Server.Env["state"] = s
+	port := s.GetPort()
+
+	defer func(burst int) {
+		RateLimitBurst = burst
+		SetRateLimits(port, nil)
+	}(RateLimitBurst)
+	RateLimitBurst = 1
+	SetRateLimits(port, NewRateLimits(1, 0))
+
+	call := func(method, remote string) *testHelper.TestResponseWriter {
+		testHelper.ClearContextResponseWriter(context)
+		body, _ := primitives.NewJSON2Request(method, 1, nil).JSONString()
+		r, err := http.NewRequest("POST", "/v2", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		r.RemoteAddr = remote
+		context.Request = r
+		HandleV2(context)
+		return context.ResponseWriter.(*testHelper.TestResponseWriter)
+	}
+
+	if w := call("properties", "10.0.0.1:1000"); w.HeaderCode == http.StatusTooManyRequests {
+		t.Errorf("First request was limited")
+	}
+	w := call("properties", "10.0.0.1:1001")
+	if w.HeaderCode != http.StatusTooManyRequests {
+		t.Errorf("Second request got %d, expected %d", w.HeaderCode, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After is %q", w.Header().Get("Retry-After"))
+	}
+
+	if w := call("properties", "10.0.0.2:1000"); w.HeaderCode == http.StatusTooManyRequests {
+		t.Errorf("Another client was limited")
+	}
+	if w := call("commit-chain", "10.0.0.1:1002"); w.HeaderCode == http.StatusTooManyRequests {
+		t.Errorf("Submit was limited with no submit limit")
+	}
+}
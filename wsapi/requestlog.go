@@ -0,0 +1,198 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+// RequestIDHeader carries the correlation ID of an API request.  A client may
+// send its own, to find its requests in the logs of the node; every response
+// has the ID it was logged under.
+const RequestIDHeader = "X-Request-Id"
+
+// The most characters of an ID sent by a client
+const maxRequestIDLength = 64
+
+// requestInfo is what the handlers of a request tell the log about it.
+type requestInfo struct {
+	id     string
+	method string // The v2 or debug method, if any
+}
+
+type requestInfoKey struct{}
+
+// requestLogHandler gives each API request a correlation ID, and logs it
+// once answered, in the RPC log at the info level, as key=value pairs:
+//
+//	api_request id=5f0c8a1e93b2d417 method=commit-chain http=POST path="/v2" client=10.0.0.1 code=200 latency_ms=3
+type requestLogHandler struct {
+	handler http.Handler
+}
+
+// NewRequestLogHandler wraps the API for request logging.
+func NewRequestLogHandler(handler http.Handler) http.Handler {
+	h := new(requestLogHandler)
+	h.handler = handler
+	return h
+}
+
+func (h *requestLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	info := new(requestInfo)
+	info.id = requestID(r)
+	w.Header().Set(RequestIDHeader, info.id)
+
+	rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+	h.handler.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+	if rpcLog == nil {
+		return
+	}
+	method := info.method
+	if method == "" {
+		method = "-"
+	}
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	rpcLog.Infof("api_request id=%s method=%s http=%s path=%q client=%s code=%d latency_ms=%d",
+		info.id, method, r.Method, r.URL.Path, client, rec.code, time.Since(start).Nanoseconds()/int64(time.Millisecond))
+}
+
+// requestID returns the ID the client sent, if it is short and plain enough
+// to log, or else a new random one.
+func requestID(r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if id != "" && len(id) <= maxRequestIDLength && strings.IndexFunc(id, notIDRune) < 0 {
+		return id
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func notIDRune(c rune) bool {
+	return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.')
+}
+
+func getRequestInfo(r *http.Request) *requestInfo {
+	info, _ := r.Context().Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// setRequestMethod logs the method a request called.
+func setRequestMethod(r *http.Request, method string) {
+	if info := getRequestInfo(r); info != nil {
+		info.method = method
+	}
+}
+
+// tracedState is the state as a request sees it, so that the messages the
+// request submits are traced with its ID in the State log.
+type tracedState struct {
+	interfaces.IState
+	requestID string
+}
+
+// requestState returns the state for the handlers of a request.
+func requestState(state interfaces.IState, r *http.Request) interfaces.IState {
+	info := getRequestInfo(r)
+	if info == nil {
+		return state
+	}
+	return &tracedState{IState: state, requestID: info.id}
+}
+
+// traceMessage ties a message about to be submitted to the request, if any,
+// that submits it.
+func traceMessage(state interfaces.IState, msg interfaces.IMsg) {
+	if t, ok := state.(*tracedState); ok {
+		t.TraceRequest(msg.GetMsgHash(), t.requestID)
+	}
+}
+
+// GetAndLockDB gives the handlers of a request the database, with the reads
+// they make logged under the ID of the request, at the debug level:
+//
+//	api_db id=5f0c8a1e93b2d417 op=Get bucket="DBlock" latency_us=41
+func (t *tracedState) GetAndLockDB() interfaces.DBOverlaySimple {
+	db := t.IState.GetAndLockDB()
+	o, ok := db.(*databaseOverlay.Overlay)
+	if !ok || rpcLog == nil {
+		return db
+	}
+	return databaseOverlay.NewOverlay(&tracedDB{IDatabase: o.DB, requestID: t.requestID})
+}
+
+// tracedDB logs the reads of a request from the database under it.
+type tracedDB struct {
+	interfaces.IDatabase
+	requestID string
+}
+
+func (db *tracedDB) log(op string, bucket []byte, start time.Time) {
+	rpcLog.Debugf("api_db id=%s op=%s bucket=%q latency_us=%d",
+		db.requestID, op, bucket, time.Since(start).Nanoseconds()/int64(time.Microsecond))
+}
+
+func (db *tracedDB) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	defer db.log("Get", bucket, time.Now())
+	return db.IDatabase.Get(bucket, key, destination)
+}
+
+func (db *tracedDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	defer db.log("GetAll", bucket, time.Now())
+	return db.IDatabase.GetAll(bucket, sample)
+}
+
+func (db *tracedDB) ListAllKeys(bucket []byte) ([][]byte, error) {
+	defer db.log("ListAllKeys", bucket, time.Now())
+	return db.IDatabase.ListAllKeys(bucket)
+}
+
+func (db *tracedDB) DoesKeyExist(bucket, key []byte) (bool, error) {
+	defer db.log("DoesKeyExist", bucket, time.Now())
+	return db.IDatabase.DoesKeyExist(bucket, key)
+}
+
+// statusRecorder keeps the status code written, and still lets the live API
+// take over the connection.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.code = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T can't be hijacked", s.ResponseWriter)
+	}
+	s.code = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
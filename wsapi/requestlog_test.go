@@ -0,0 +1,78 @@
+package wsapi_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestRequestLogHandlerIDs(t *testing.T) {
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := NewRequestLogHandler(api)
+
+	req := httptest.NewRequest("POST", "/v2", nil)
+	req.Header.Set(RequestIDHeader, "client-id.42")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Expected code %d, got %d", http.StatusTeapot, w.Code)
+	}
+	if id := w.Header().Get(RequestIDHeader); id != "client-id.42" {
+		t.Errorf("Expected the ID of the client, got %q", id)
+	}
+
+	for _, bad := range []string{"", "has space", "a\nb", string(make([]byte, 65))} {
+		req = httptest.NewRequest("POST", "/v2", nil)
+		if bad != "" {
+			req.Header[RequestIDHeader] = []string{bad}
+		}
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		id := w.Header().Get(RequestIDHeader)
+		if id == "" || id == bad {
+			t.Errorf("Expected a new ID for %q, got %q", bad, id)
+		}
+	}
+}
+
+func TestRequestLogDBReads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "requestlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logPath := filepath.Join(dir, "rpc.log")
+	InitLogs(logPath, "debug")
+
+	context := testHelper.CreateWebContext()
+	handler := NewRequestLogHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		context.Request = r
+		HandleV2(context)
+	}))
+	body, _ := primitives.NewJSON2Request("dblock-by-height", 1, map[string]int64{"height": 1}).JSONString()
+	req := httptest.NewRequest("POST", "/v2", bytes.NewBufferString(body))
+	req.Header.Set(RequestIDHeader, "db-reads")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	logged, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(logged), "api_db id=db-reads op=") {
+		t.Errorf("The database reads were not logged with the request ID:\n%s", logged)
+	}
+	if !strings.Contains(string(logged), "api_request id=db-reads method=dblock-by-height") {
+		t.Errorf("The request was not logged:\n%s", logged)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/web"
+)
+
+// The REST routes answer plain GETs on resources with the result of the
+// matching v2 method, as the same JSON the v2 API returns.  Unlike the older
+// v1 routes, errors get a matching HTTP status, with the v2 error as the body.
+
+func HandleRESTDBlock(ctx *web.Context, keymr string) {
+	ServersMutex.Lock()
+	defer ServersMutex.Unlock()
+
+	state := ctx.Server.Env["state"].(interfaces.IState)
+	if !checkHttpPasswordOkV1(state, ctx) {
+		return
+	}
+
+	returnREST(ctx, state, "directory-block", KeyMRRequest{KeyMR: keymr})
+}
+
+func HandleRESTDBlockByHeight(ctx *web.Context, height string) {
+	ServersMutex.Lock()
+	defer ServersMutex.Unlock()
+
+	state := ctx.Server.Env["state"].(interfaces.IState)
+	if !checkHttpPasswordOkV1(state, ctx) {
+		return
+	}
+
+	h, err := strconv.ParseUint(height, 10, 32)
+	if err != nil {
+		returnRESTError(ctx, NewInvalidParamsError())
+		return
+	}
+
+	dbase := state.GetAndLockDB()
+	keymr, err := dbase.FetchDBKeyMRByHeight(uint32(h))
+	state.UnlockDB()
+	if err != nil {
+		returnRESTError(ctx, NewInternalDatabaseError())
+		return
+	}
+	if keymr == nil {
+		returnRESTError(ctx, NewBlockNotFoundError())
+		return
+	}
+
+	returnREST(ctx, state, "directory-block", KeyMRRequest{KeyMR: keymr.String()})
+}
+
+func HandleRESTEntry(ctx *web.Context, hash string) {
+	ServersMutex.Lock()
+	defer ServersMutex.Unlock()
+
+	state := ctx.Server.Env["state"].(interfaces.IState)
+	if !checkHttpPasswordOkV1(state, ctx) {
+		return
+	}
+
+	returnREST(ctx, state, "entry", HashRequest{Hash: hash})
+}
+
+func HandleRESTChainHead(ctx *web.Context, chainid string) {
+	ServersMutex.Lock()
+	defer ServersMutex.Unlock()
+
+	state := ctx.Server.Env["state"].(interfaces.IState)
+	if !checkHttpPasswordOkV1(state, ctx) {
+		return
+	}
+
+	returnREST(ctx, state, "chain-head", ChainIDRequest{ChainID: chainid})
+}
+
+// returnREST runs a v2 method and writes its result, or its error.
+func returnREST(ctx *web.Context, state interfaces.IState, method string, params interface{}) {
+	req := primitives.NewJSON2Request(method, 1, params)
+	jsonResp, jsonError := HandleV2Request(state, req)
+	if jsonError != nil {
+		returnRESTError(ctx, jsonError)
+		return
+	}
+
+	p, err := json.Marshal(jsonResp.Result)
+	if err != nil {
+		wsLog.Error(err)
+		returnRESTError(ctx, NewInternalError())
+		return
+	}
+	ctx.ResponseWriter.Header().Set("Content-Type", "application/json")
+	ctx.Write(p)
+}
+
+func returnRESTError(ctx *web.Context, jsonError *primitives.JSONError) {
+	ctx.ResponseWriter.Header().Set("Content-Type", "application/json")
+	ctx.WriteHeader(restStatus(jsonError))
+	if p, err := json.Marshal(jsonError); err == nil {
+		ctx.Write(p)
+	}
+}
+
+// restStatus maps a v2 error onto an HTTP status.
+func restStatus(jsonError *primitives.JSONError) int {
+	switch jsonError.Code {
+	case -32008, -32009: // Not found, missing chain head
+		return http.StatusNotFound
+	case -32700, -32600, -32602: // Parse error, invalid request, invalid params
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
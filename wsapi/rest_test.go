@@ -0,0 +1,79 @@
+package wsapi_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+	"github.com/FactomProject/web"
+)
+
+func TestHandleRESTDBlock(t *testing.T) {
+	context := testHelper.CreateWebContext()
+
+	HandleRESTDBlock(context, testHelper.DBlockHeadPrimaryIndex)
+	if code := getCode(context); code != 0 && code != http.StatusOK {
+		t.Errorf("Status %d for the head dblock", code)
+	}
+	if strings.Contains(testHelper.GetBody(context), testHelper.ABlockHeadPrimaryIndex) == false {
+		t.Errorf("%v", testHelper.GetBody(context))
+	}
+
+	testHelper.ClearContextResponseWriter(context)
+	HandleRESTDBlock(context, primitives.NewZeroHash().String())
+	if code := getCode(context); code != http.StatusNotFound {
+		t.Errorf("Status %d for a missing dblock, expected %d", code, http.StatusNotFound)
+	}
+
+	testHelper.ClearContextResponseWriter(context)
+	HandleRESTDBlock(context, "not a hash")
+	if code := getCode(context); code != http.StatusBadRequest {
+		t.Errorf("Status %d for a bad keymr, expected %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleRESTDBlockByHeight(t *testing.T) {
+	context := testHelper.CreateWebContext()
+
+	HandleRESTDBlockByHeight(context, "0")
+	if code := getCode(context); code != 0 && code != http.StatusOK {
+		t.Errorf("Status %d for dblock 0", code)
+	}
+	if strings.Contains(testHelper.GetBody(context), "\"sequencenumber\":0") == false {
+		t.Errorf("%v", testHelper.GetBody(context))
+	}
+
+	testHelper.ClearContextResponseWriter(context)
+	HandleRESTDBlockByHeight(context, "100000")
+	if code := getCode(context); code != http.StatusNotFound {
+		t.Errorf("Status %d for a missing height, expected %d", code, http.StatusNotFound)
+	}
+
+	testHelper.ClearContextResponseWriter(context)
+	HandleRESTDBlockByHeight(context, "-1")
+	if code := getCode(context); code != http.StatusBadRequest {
+		t.Errorf("Status %d for a bad height, expected %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleRESTChainHead(t *testing.T) {
+	context := testHelper.CreateWebContext()
+
+	HandleRESTChainHead(context, "000000000000000000000000000000000000000000000000000000000000000d")
+	if strings.Contains(testHelper.GetBody(context), testHelper.DBlockHeadPrimaryIndex) == false {
+		t.Errorf("Invalid directory block head: %v", testHelper.GetBody(context))
+	}
+
+	testHelper.ClearContextResponseWriter(context)
+	HandleRESTEntry(context, primitives.NewZeroHash().String())
+	if code := getCode(context); code != http.StatusNotFound {
+		t.Errorf("Status %d for a missing entry, expected %d", code, http.StatusNotFound)
+	}
+}
+
+func getCode(context *web.Context) int {
+	return context.ResponseWriter.(*testHelper.TestResponseWriter).HeaderCode
+}
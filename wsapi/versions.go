@@ -0,0 +1,57 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ApiVersions are the versions of the API, oldest first, each mounted under
+// its own path so that clients pick theirs, and a breaking change can be made
+// in a new version while wallets move over.
+var ApiVersions = []string{"v1", "v2"}
+
+// versionHandler tells the clients of an API version that is being retired
+// when it will be removed, with the Deprecation and Sunset headers, and links
+// the version to move to.  Every versioned response says its version in
+// Factomd-Api-Version.
+type versionHandler struct {
+	handler http.Handler
+	sunsets map[string]time.Time
+}
+
+// NewVersionHandler wraps the API for the sunset dates of its versions.
+func NewVersionHandler(handler http.Handler, sunsets map[string]time.Time) http.Handler {
+	v := new(versionHandler)
+	v.handler = handler
+	v.sunsets = sunsets
+	return v
+}
+
+func (v *versionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	version := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+	label := "other"
+	for i, known := range ApiVersions {
+		if version != known {
+			continue
+		}
+		label = version
+		h := w.Header()
+		h.Set("Factomd-Api-Version", version)
+		if sunset, ok := v.sunsets[version]; ok {
+			h.Set("Deprecation", "true")
+			h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			if i+1 < len(ApiVersions) {
+				successor := ApiVersions[len(ApiVersions)-1]
+				h.Add("Link", "</"+successor+">; rel=\"successor-version\"")
+			}
+		}
+		break
+	}
+	APIRequests.WithLabelValues(label).Inc()
+	v.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,47 @@
+package wsapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestVersionHandler(t *testing.T) {
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	sunset := time.Date(2018, 6, 30, 0, 0, 0, 0, time.UTC)
+	handler := NewVersionHandler(api, map[string]time.Time{"v1": sunset})
+
+	serve := func(path string) http.Header {
+		r, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Header()
+	}
+
+	h := serve("/v1/heights/")
+	if h.Get("Factomd-Api-Version") != "v1" || h.Get("Deprecation") != "true" {
+		t.Errorf("Wrong headers for v1 - %v", h)
+	}
+	if h.Get("Sunset") != "Sat, 30 Jun 2018 00:00:00 GMT" {
+		t.Errorf("Wrong sunset for v1 - %v", h.Get("Sunset"))
+	}
+	if h.Get("Link") != `</v2>; rel="successor-version"` {
+		t.Errorf("Wrong link for v1 - %v", h.Get("Link"))
+	}
+
+	h = serve("/v2")
+	if h.Get("Factomd-Api-Version") != "v2" || h.Get("Deprecation") != "" || h.Get("Sunset") != "" {
+		t.Errorf("Wrong headers for v2 - %v", h)
+	}
+
+	h = serve("/live")
+	if h.Get("Factomd-Api-Version") != "" {
+		t.Errorf("Version header outside the versioned API - %v", h)
+	}
+}
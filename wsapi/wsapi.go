@@ -13,6 +13,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
@@ -23,7 +24,6 @@ import (
 	"github.com/FactomProject/btcutil/certs"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
-	"github.com/FactomProject/factomd/log"
 	"github.com/FactomProject/web"
 )
 
@@ -34,6 +34,10 @@ const (
 var Servers map[int]*web.Server
 var ServersMutex sync.Mutex
 
+// The listeners of the servers, as they are served through NewVersionHandler,
+// NewCORSHandler and NewRequestLogHandler rather than run by themselves
+var listeners = map[int]net.Listener{}
+
 func Start(state interfaces.IState) {
 	RegisterPrometheus()
 	var server *web.Server
@@ -83,8 +87,18 @@ func Start(state interfaces.IState) {
 		server.Get("/v1/fblock-by-height/([^/]+)", HandleFBlockByHeight)
 		server.Get("/v1/ablock-by-height/([^/]+)", HandleABlockByHeight)
 
+		server.Get("/v1/dblock/height/([^/]+)", HandleRESTDBlockByHeight)
+		server.Get("/v1/dblock/([^/]+)", HandleRESTDBlock)
+		server.Get("/v1/entry/([^/]+)", HandleRESTEntry)
+		server.Get("/v1/chain/([^/]+)/head", HandleRESTChainHead)
+
+		server.Get("/live", HandleLive)
+
 		server.Post("/v2", HandleV2)
 		server.Get("/v2", HandleV2)
+		server.Post("/graphql", HandleGraphQL)
+		server.Get("/metrics", HandleMetrics)
+		server.Get("/healthz", HandleHealthz)
 
 		// start the debugging api if we are not on the main network
 		if state.GetNetworkName() != "MAIN" {
@@ -92,9 +106,14 @@ func Start(state interfaces.IState) {
 			server.Get("/debug", HandleDebug)
 		}
 
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", state.GetPort()))
+		if err != nil {
+			panic(fmt.Sprintf("could not start API server with error: %v", err))
+		}
+
 		tlsIsEnabled, tlsPrivate, tlsPublic := state.GetTlsInfo()
 		if tlsIsEnabled {
-			log.Print("Starting encrypted API server")
+			apiLog.WithField("port", state.GetPort()).Infof("Starting encrypted API server")
 			if !fileExists(tlsPrivate) && !fileExists(tlsPublic) {
 				err := genCertPair(tlsPublic, tlsPrivate, state.GetFactomdLocations())
 				if err != nil {
@@ -109,12 +128,16 @@ func Start(state interfaces.IState) {
 				Certificates: []tls.Certificate{keypair},
 				MinVersion:   tls.VersionTLS12,
 			}
-			go server.RunTLS(fmt.Sprintf(":%d", state.GetPort()), tlsConfig)
+			listener = tls.NewListener(listener, tlsConfig)
 
 		} else {
-			log.Print("Starting API server")
-			go server.Run(fmt.Sprintf(":%d", state.GetPort()))
+			apiLog.WithField("port", state.GetPort()).Infof("Starting API server")
 		}
+		listeners[state.GetPort()] = listener
+		SetRateLimits(state.GetPort(), NewRateLimits(state.GetApiRateLimits()))
+		handler := NewVersionHandler(server, state.GetApiSunsets())
+		handler = NewCORSHandler(handler, state.GetCorsDomains(), state.GetCorsMethods())
+		go http.Serve(listener, NewRequestLogHandler(handler))
 	}
 }
 
@@ -135,7 +158,7 @@ func Stop(state interfaces.IState) {
 	ServersMutex.Lock()
 	defer ServersMutex.Unlock()
 
-	Servers[state.GetPort()].Close()
+	listeners[state.GetPort()].Close()
 }
 
 func handleV1Error(ctx *web.Context, err *primitives.JSONError) {
@@ -258,7 +281,7 @@ func HandleCommitChain(ctx *web.Context) {
 
 	state := ctx.Server.Env["state"].(interfaces.IState)
 
-	if !checkHttpPasswordOkV1(state, ctx) {
+	if !checkAccess(state, ctx, AccessAuthenticated, "V1") {
 		return
 	}
 
@@ -277,7 +300,7 @@ func HandleCommitChain(ctx *web.Context) {
 	}
 	param := MessageRequest{Message: c.CommitChainMsg}
 	req := primitives.NewJSON2Request("commit-chain", 1, param)
-	_, jsonError := HandleV2Request(state, req)
+	_, jsonError := HandleV2Request(requestState(state, ctx.Request), req)
 
 	if jsonError != nil {
 		returnV1(ctx, nil, jsonError)
@@ -300,7 +323,7 @@ func HandleCommitEntry(ctx *web.Context) {
 
 	state := ctx.Server.Env["state"].(interfaces.IState)
 
-	if !checkHttpPasswordOkV1(state, ctx) {
+	if !checkAccess(state, ctx, AccessAuthenticated, "V1") {
 		return
 	}
 
@@ -324,7 +347,7 @@ func HandleCommitEntry(ctx *web.Context) {
 	param := MessageRequest{Message: c.CommitEntryMsg}
 	req := primitives.NewJSON2Request("commit-entry", 1, param)
 
-	_, jsonError := HandleV2Request(state, req)
+	_, jsonError := HandleV2Request(requestState(state, ctx.Request), req)
 	if jsonError != nil {
 		returnV1(ctx, nil, jsonError)
 		return
@@ -342,7 +365,7 @@ func HandleRevealEntry(ctx *web.Context) {
 
 	state := ctx.Server.Env["state"].(interfaces.IState)
 
-	if !checkHttpPasswordOkV1(state, ctx) {
+	if !checkAccess(state, ctx, AccessAuthenticated, "V1") {
 		return
 	}
 
@@ -364,7 +387,7 @@ func HandleRevealEntry(ctx *web.Context) {
 	param := EntryRequest{Entry: e.Entry}
 	req := primitives.NewJSON2Request("reveal-entry", 1, param)
 
-	_, jsonError := HandleV2Request(state, req)
+	_, jsonError := HandleV2Request(requestState(state, ctx.Request), req)
 	if jsonError != nil {
 		returnV1(ctx, nil, jsonError)
 		return
@@ -684,7 +707,7 @@ func HandleFactoidSubmit(ctx *web.Context) {
 
 	state := ctx.Server.Env["state"].(interfaces.IState)
 
-	if !checkHttpPasswordOkV1(state, ctx) {
+	if !checkAccess(state, ctx, AccessAuthenticated, "V1") {
 		return
 	}
 
@@ -703,7 +726,7 @@ func HandleFactoidSubmit(ctx *web.Context) {
 	param := TransactionRequest{Transaction: t.Transaction}
 	req := primitives.NewJSON2Request("factoid-submit", 1, param)
 
-	jsonResp, jsonError := HandleV2Request(state, req)
+	jsonResp, jsonError := HandleV2Request(requestState(state, ctx.Request), req)
 	if jsonError != nil {
 		returnV1(ctx, nil, jsonError)
 		return
@@ -870,16 +893,10 @@ func checkAuthHeader(state interfaces.IState, r *http.Request) error {
 	return nil
 }
 
+// checkHttpPasswordOkV1 checks the access to the v1 methods that read.  The
+// ones that submit check for AccessAuthenticated themselves.
 func checkHttpPasswordOkV1(state interfaces.IState, ctx *web.Context) bool {
-	if err := checkAuthHeader(state, ctx.Request); err != nil {
-		remoteIP := ""
-		remoteIP += strings.Split(ctx.Request.RemoteAddr, ":")[0]
-		fmt.Printf("Unauthorized V1 API client connection attempt from %s\n", remoteIP)
-		ctx.ResponseWriter.Header().Add("WWW-Authenticate", `Basic realm="factomd RPC"`)
-		http.Error(ctx.ResponseWriter, "401 Unauthorized.", http.StatusUnauthorized)
-		return false
-	}
-	return true
+	return checkAccess(state, ctx, AccessPublic, "V1")
 }
 
 func fileExists(name string) bool {
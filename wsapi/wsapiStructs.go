@@ -5,6 +5,7 @@
 package wsapi
 
 import (
+	"github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/receipts"
@@ -15,22 +16,48 @@ type FactoidSubmitResponse struct {
 	TxID    string `json:"txid"`
 }
 
+type FactoidFeeResponse struct {
+	Fee   uint64                 `json:"fee"`   // What the transaction owes, in factoshis
+	Parts factoid.TransactionFee `json:"parts"` // The fee by what it is charged for
+	Paid  uint64                 `json:"paid"`  // What the inputs leave over the outputs
+	Rate  uint64                 `json:"rate"`  // The factoshis per entry credit the fee is at
+}
+
+// ComposeTransactionResponse is the unsigned transaction, with the fee and
+// the data each input signs, or once signed, the transaction to submit.
+type ComposeTransactionResponse struct {
+	Transaction string          `json:"transaction"`
+	TxID        string          `json:"txid"`
+	Fee         uint64          `json:"fee,omitempty"`
+	ToSign      []ComposeToSign `json:"tosign,omitempty"`
+	Signed      bool            `json:"signed"`
+}
+
+type ComposeToSign struct {
+	Address string `json:"address"`
+	Data    string `json:"data"` // The hex bytes the key of the address signs
+}
+
 type CommitChainResponse struct {
-	Message string `json:"message"`
-	TxID    string `json:"txid"`
+	Message     string `json:"message"`
+	TxID        string `json:"txid"`
+	EntryHash   string `json:"entryhash"`
+	ChainIDHash string `json:"chainidhash"`
 }
 
 type RevealChainResponse struct {
 }
 
 type CommitEntryResponse struct {
-	Message string `json:"message"`
-	TxID    string `json:"txid"`
+	Message   string `json:"message"`
+	TxID      string `json:"txid"`
+	EntryHash string `json:"entryhash"`
 }
 
 type RevealEntryResponse struct {
 	Message   string `json:"message"`
 	EntryHash string `json:"entryhash"`
+	ChainID   string `json:"chainid"`
 }
 
 type DirectoryBlockResponse struct {
@@ -100,17 +127,113 @@ type FactoidBalanceResponse struct {
 	Balance int64 `json:"balance"`
 }
 
+type BalanceAtHeightResponse struct {
+	Address string `json:"address"`
+	Height  int64  `json:"height"`
+	Balance int64  `json:"balance"`
+}
+
+type ChainStatsResponse struct {
+	ChainID     string `json:"chainid"`
+	Entries     uint64 `json:"entries"`
+	Bytes       uint64 `json:"bytes"`
+	EBlocks     uint32 `json:"entryblocks"`
+	FirstHeight uint32 `json:"firstheight"`
+	LastHeight  uint32 `json:"lastheight"`
+}
+
+type AnchorsResponse struct {
+	Height  int64          `json:"directoryblockheight"`
+	KeyMR   string         `json:"directoryblockkeymr"`
+	Anchors []*ChainAnchor `json:"anchors"`
+}
+
+type ChainAnchor struct {
+	Chain         string `json:"chain"`
+	TxID          string `json:"txid"`
+	BlockHeight   int64  `json:"blockheight"`
+	BlockHash     string `json:"blockhash"`
+	Confirmations int64  `json:"confirmations,omitempty"` // Left out if there's no node to ask
+	Verified      bool   `json:"verified"`
+}
+
+type SupplyResponse struct {
+	Height           int64             `json:"height"`
+	FactoidSupply    int64             `json:"factoidsupply"`
+	ECSupply         int64             `json:"ecsupply"`
+	FactoidAddresses int               `json:"factoidaddresses"`
+	ECAddresses      int               `json:"ecaddresses"`
+	RichList         []RichListBalance `json:"richlist"`
+}
+
+type RichListBalance struct {
+	Address string `json:"address"`
+	Balance int64  `json:"balance"`
+}
+
+// The responses that page a list have a Next cursor if there may be more.
+
+type ChainIDsResponse struct {
+	ChainIDs []string `json:"chainids"`
+	Next     string   `json:"next,omitempty"`
+}
+
+type ChainEntriesResponse struct {
+	Entries []ChainEntry `json:"entries"`
+	Next    string       `json:"next,omitempty"`
+}
+
+type ChainEntry struct {
+	EntryHash string   `json:"entryhash"`
+	DBHeight  uint32   `json:"dbheight"`
+	Content   string   `json:"content"`
+	ExtIDs    []string `json:"extids"`
+}
+
+type BlockTransactionsResponse struct {
+	Transactions []interfaces.ITransaction `json:"transactions"`
+	Total        int                       `json:"total"`
+	Next         string                    `json:"next,omitempty"`
+}
+
+type MultipleBalancesResponse struct {
+	Balances []AddressBalance `json:"balances"`
+}
+
+type AddressBalance struct {
+	Address string `json:"address"`
+	Balance int64  `json:"balance"`
+	Error   string `json:"error,omitempty"`
+}
+
 type EntryCreditRateResponse struct {
 	Rate int64 `json:"rate"`
 }
 
 type PropertiesResponse struct {
-	FactomdVersion string `json:"factomdversion"`
-	ApiVersion     string `json:"factomdapiversion"`
+	FactomdVersion  string `json:"factomdversion"`
+	ApiVersion      string `json:"factomdapiversion"`
+	ProtocolVersion int    `json:"protocolversion"`
+	NetworkName     string `json:"networkname"`
+	NodeRole        string `json:"noderole"`
 }
 
 type SendRawMessageResponse struct {
-	Message string `json:"message"`
+	Message     string `json:"message"`
+	MessageType string `json:"messagetype"`
+	MessageHash string `json:"messagehash"`
+}
+
+type ProfileResponse struct {
+	File string `json:"file"`
+}
+
+type DumpStateResponse struct {
+	File string `json:"file"`
+}
+
+type ApiKeyUsageResponse struct {
+	Keys map[string]*ApiKeyUsage `json:"keys"` // By name
 }
 
 type TransactionRateResponse struct {
@@ -196,6 +319,8 @@ type TransactionResponse struct {
 	IncludedInDirectoryBlock string `json:"includedindirectoryblock"`
 	//The DBlock height
 	IncludedInDirectoryBlockHeight int64 `json:"includedindirectoryblockheight"`
+	//The directory blocks saved since, the including one counted; 0 while pending
+	Confirmations int64 `json:"confirmations"`
 }
 
 type BlockHeightResponse struct {
@@ -212,6 +337,15 @@ type AddressRequest struct {
 	Address string `json:"address"`
 }
 
+type MultipleBalancesRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+type BalanceAtHeightRequest struct {
+	Address string `json:"address"`
+	Height  int64  `json:"height"`
+}
+
 type HeightRequest struct {
 	Height int64 `json:"height"`
 }
@@ -232,6 +366,25 @@ type KeyMRRequest struct {
 	KeyMR string `json:"keymr"`
 }
 
+// PageRequest asks for a page of a list.  Cursor is empty for the first page,
+// and the Next of the previous page for the others.
+type PageRequest struct {
+	Limit  int    `json:"limit,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ChainEntriesRequest struct {
+	ChainID string `json:"chainid"`
+	Limit   int    `json:"limit,omitempty"`
+	Cursor  string `json:"cursor,omitempty"`
+}
+
+type BlockTransactionsRequest struct {
+	KeyMR  string `json:"keymr"`
+	Limit  int    `json:"limit,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
 type KeyRequest struct {
 	Key string `json:"key"`
 }
@@ -255,6 +408,32 @@ type TransactionRequest struct {
 	Transaction string `json:"transaction"`
 }
 
+// ComposeTransactionRequest either has the inputs and outputs of a
+// transaction to compose, or the unsigned transaction composed from them and
+// a signature for each of its inputs.
+type ComposeTransactionRequest struct {
+	Inputs      []ComposeAmount    `json:"inputs,omitempty"`
+	Outputs     []ComposeAmount    `json:"outputs,omitempty"`
+	ECOutputs   []ComposeAmount    `json:"ecoutputs,omitempty"` // In entry credits
+	Transaction string             `json:"transaction,omitempty"`
+	Signatures  []ComposeSignature `json:"signatures,omitempty"`
+}
+
+type ComposeAmount struct {
+	Address string `json:"address"`
+	Amount  uint64 `json:"amount"`
+}
+
+type ComposeSignature struct {
+	PublicKey string `json:"publickey"`
+	Signature string `json:"signature"`
+}
+
 type SendRawMessageRequest struct {
 	Message string `json:"message"`
 }
+
+type ProfileRequest struct {
+	Type    string `json:"type"`    // cpu, heap, goroutine or block
+	Seconds int    `json:"seconds"` // For cpu
+}
@@ -9,11 +9,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/FactomProject/factomd/anchor"
 	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/entryBlock"
 	"github.com/FactomProject/factomd/common/entryCreditBlock"
@@ -21,12 +22,19 @@ import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/p2p"
 	"github.com/FactomProject/factomd/receipts"
+	"github.com/FactomProject/factomd/util"
 	"github.com/FactomProject/web"
 )
 
 const API_VERSION string = "2.0"
 
+// MaxPageSize is the most items the methods that page their lists return at
+// once, and what they return if the request doesn't set a limit.
+var MaxPageSize = 100
+
 func HandleV2(ctx *web.Context) {
 	n := time.Now()
 	defer HandleV2APICallGeneral.Observe(float64(time.Since(n).Nanoseconds()))
@@ -34,16 +42,6 @@ func HandleV2(ctx *web.Context) {
 	state := ctx.Server.Env["state"].(interfaces.IState)
 	ServersMutex.Unlock()
 
-	if err := checkAuthHeader(state, ctx.Request); err != nil {
-		remoteIP := ""
-		remoteIP += strings.Split(ctx.Request.RemoteAddr, ":")[0]
-		fmt.Printf("Unauthorized V2 API client connection attempt from %s\n", remoteIP)
-		ctx.ResponseWriter.Header().Add("WWW-Authenticate", `Basic realm="factomd RPC"`)
-		http.Error(ctx.ResponseWriter, "401 Unauthorized.", http.StatusUnauthorized)
-
-		return
-	}
-
 	body, err := ioutil.ReadAll(ctx.Request.Body)
 	if err != nil {
 		HandleV2Error(ctx, nil, NewInvalidRequestError())
@@ -56,7 +54,12 @@ func HandleV2(ctx *web.Context) {
 		return
 	}
 
-	jsonResp, jsonError := HandleV2Request(state, j)
+	setRequestMethod(ctx.Request, j.Method)
+	if !checkMethodAccess(state, ctx, MethodAccess[j.Method], "V2", j.Method) {
+		return
+	}
+
+	jsonResp, jsonError := HandleV2Request(requestState(state, ctx.Request), j)
 
 	if jsonError != nil {
 		HandleV2Error(ctx, j, jsonError)
@@ -70,6 +73,7 @@ func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*prim
 	var resp interface{}
 	var jsonError *primitives.JSONError
 	params := j.Params
+	method := j.Method
 	switch j.Method {
 	case "chain-head":
 		resp, jsonError = HandleV2ChainHead(state, params)
@@ -101,9 +105,27 @@ func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*prim
 	case "factoid-balance":
 		resp, jsonError = HandleV2FactoidBalance(state, params)
 		break
+	case "multiple-balances":
+		resp, jsonError = HandleV2MultipleBalances(state, params)
+		break
+	case "balance-at-height":
+		resp, jsonError = HandleV2BalanceAtHeight(state, params)
+		break
+	case "chain-stats":
+		resp, jsonError = HandleV2ChainStats(state, params)
+		break
+	case "supply":
+		resp, jsonError = HandleV2Supply(state, params)
+		break
 	case "factoid-submit":
 		resp, jsonError = HandleV2FactoidSubmit(state, params)
 		break
+	case "factoid-fee":
+		resp, jsonError = HandleV2FactoidFee(state, params)
+		break
+	case "compose-transaction":
+		resp, jsonError = HandleV2ComposeTransaction(state, params)
+		break
 	case "heights":
 		resp, jsonError = HandleV2Heights(state, params)
 		break
@@ -116,6 +138,9 @@ func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*prim
 	case "receipt":
 		resp, jsonError = HandleV2Receipt(state, params)
 		break
+	case "anchors":
+		resp, jsonError = HandleV2Anchors(state, params)
+		break
 	case "reveal-chain":
 		resp, jsonError = HandleV2RevealChain(state, params)
 		break
@@ -125,6 +150,9 @@ func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*prim
 	case "factoid-ack":
 		resp, jsonError = HandleV2FactoidACK(state, params)
 		break
+	case "ack":
+		resp, jsonError = HandleV2Ack(state, params)
+		break
 	case "entry-ack":
 		resp, jsonError = HandleV2EntryACK(state, params)
 		break
@@ -152,17 +180,41 @@ func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*prim
 	case "ablock-by-height":
 		resp, jsonError = HandleV2ABlockByHeight(state, params)
 		break
+	case "admin-block":
+		resp, jsonError = HandleV2AdminBlock(state, params)
+		break
+	case "factoid-block":
+		resp, jsonError = HandleV2FactoidBlock(state, params)
+		break
+	case "entrycredit-block":
+		resp, jsonError = HandleV2EntryCreditBlock(state, params)
+		break
 	case "authorities":
 		resp, jsonError = HandleAuthorities(state, params)
 	case "tps-rate":
 		resp, jsonError = HandleV2TransactionRate(state, params)
+	case "chain-ids":
+		resp, jsonError = HandleV2ChainIDs(state, params)
+	case "chain-entries":
+		resp, jsonError = HandleV2ChainEntries(state, params)
+	case "block-transactions":
+		resp, jsonError = HandleV2BlockTransactions(state, params)
+	case "profile":
+		resp, jsonError = HandleV2Profile(state, params)
+	case "api-key-usage":
+		resp, jsonError = HandleV2ApiKeyUsage(state, params)
+	case "dump-state":
+		resp, jsonError = HandleV2DumpState(state, params)
 	default:
 		jsonError = NewMethodNotFoundError()
+		method = "unknown"
 		break
 	}
 	if jsonError != nil {
+		HandleV2APIRequests.WithLabelValues(method, "error").Inc()
 		return nil, jsonError
 	}
+	HandleV2APIRequests.WithLabelValues(method, "ok").Inc()
 
 	jsonResp := primitives.NewJSON2Response()
 	jsonResp.ID = j.ID
@@ -318,6 +370,132 @@ func HandleV2ABlockByHeight(state interfaces.IState, params interface{}) (interf
 	return resp, nil
 }
 
+func HandleV2AdminBlock(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallABlock.Observe(float64(time.Since(n).Nanoseconds()))
+
+	keymr := new(KeyMRRequest)
+	err := MapToObject(params, keymr)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	h, err := primitives.HexToHash(keymr.KeyMR)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	block, err := dbase.FetchABlock(h)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if block == nil {
+		return nil, NewBlockNotFoundError()
+	}
+
+	raw, err := block.MarshalBinary()
+	if err != nil {
+		return nil, NewInternalError()
+	}
+
+	resp := new(BlockHeightResponse)
+	b, err := ObjectToJStruct(block)
+	if err != nil {
+		return nil, NewInternalError()
+	}
+	resp.ABlock = b
+	resp.RawData = hex.EncodeToString(raw)
+
+	return resp, nil
+}
+
+func HandleV2FactoidBlock(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallFBlock.Observe(float64(time.Since(n).Nanoseconds()))
+
+	keymr := new(KeyMRRequest)
+	err := MapToObject(params, keymr)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	h, err := primitives.HexToHash(keymr.KeyMR)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	block, err := dbase.FetchFBlock(h)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if block == nil {
+		return nil, NewBlockNotFoundError()
+	}
+
+	raw, err := block.MarshalBinary()
+	if err != nil {
+		return nil, NewInternalError()
+	}
+
+	resp := new(BlockHeightResponse)
+	b, err := ObjectToJStruct(block)
+	if err != nil {
+		return nil, NewInternalError()
+	}
+	resp.FBlock = b
+	resp.RawData = hex.EncodeToString(raw)
+
+	return resp, nil
+}
+
+func HandleV2EntryCreditBlock(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallECBlock.Observe(float64(time.Since(n).Nanoseconds()))
+
+	keymr := new(KeyMRRequest)
+	err := MapToObject(params, keymr)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	h, err := primitives.HexToHash(keymr.KeyMR)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	block, err := dbase.FetchECBlock(h)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if block == nil {
+		return nil, NewBlockNotFoundError()
+	}
+
+	raw, err := block.MarshalBinary()
+	if err != nil {
+		return nil, NewInternalError()
+	}
+
+	resp := new(BlockHeightResponse)
+	b, err := ObjectToJStruct(block)
+	if err != nil {
+		return nil, NewInternalError()
+	}
+	resp.ECBlock = b
+	resp.RawData = hex.EncodeToString(raw)
+
+	return resp, nil
+}
+
 func HandleV2Error(ctx *web.Context, j *primitives.JSON2Request, err *primitives.JSONError) {
 	resp := primitives.NewJSON2Response()
 	if j != nil {
@@ -381,15 +559,22 @@ func HandleV2CommitChain(state interfaces.IState, params interface{}) (interface
 			return nil, NewInvalidCommitChainError()
 		}
 	}
+	// Don't queue a commit the network will only throw away
+	if !commit.IsValid() {
+		return nil, NewInvalidCommitChainError()
+	}
 
 	msg := new(messages.CommitChainMsg)
 	msg.CommitChain = commit
+	traceMessage(state, msg)
 	state.APIQueue() <- msg
 	state.IncECCommits()
 
 	resp := new(CommitChainResponse)
 	resp.Message = "Chain Commit Success"
 	resp.TxID = commit.GetSigHash().String()
+	resp.EntryHash = commit.EntryHash.String()
+	resp.ChainIDHash = commit.ChainIDHash.String()
 
 	return resp, nil
 }
@@ -417,15 +602,20 @@ func HandleV2CommitEntry(state interfaces.IState, params interface{}) (interface
 			return nil, NewInvalidCommitEntryError()
 		}
 	}
+	if !commit.IsValid() {
+		return nil, NewInvalidCommitEntryError()
+	}
 
 	msg := new(messages.CommitEntryMsg)
 	msg.CommitEntry = commit
+	traceMessage(state, msg)
 	state.APIQueue() <- msg
 	state.IncECommits()
 
 	resp := new(CommitEntryResponse)
 	resp.Message = "Entry Commit Success"
 	resp.TxID = commit.GetSigHash().String()
+	resp.EntryHash = commit.EntryHash.String()
 
 	return resp, nil
 }
@@ -453,11 +643,13 @@ func HandleV2RevealEntry(state interfaces.IState, params interface{}) (interface
 	msg := new(messages.RevealEntryMsg)
 	msg.Entry = entry
 	msg.Timestamp = state.GetTimestamp()
+	traceMessage(state, msg)
 	state.APIQueue() <- msg
 
 	resp := new(RevealEntryResponse)
 	resp.Message = "Entry Reveal Success"
 	resp.EntryHash = entry.GetHash().String()
+	resp.ChainID = entry.GetChainID().String()
 
 	return resp, nil
 }
@@ -556,6 +748,80 @@ func HandleV2Receipt(state interfaces.IState, params interface{}) (interface{},
 	return resp, nil
 }
 
+// HandleV2Anchors returns where the directory block at a height is anchored,
+// as the anchor chain records it.  Confirmations are only given for the
+// chains with a node to verify anchors against.
+func HandleV2Anchors(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallAnchors.Observe(float64(time.Since(n).Nanoseconds()))
+
+	heightRequest := new(HeightRequest)
+	err := MapToObject(params, heightRequest)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	if heightRequest.Height < 0 {
+		return nil, NewCustomInvalidParamsError("Height must not be negative")
+	}
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	fetcher, ok := dbase.(anchor.AnchorInfoFetcher)
+	if !ok {
+		return nil, NewCustomInternalError("The database doesn't index anchors")
+	}
+	keyMR, err := dbase.FetchDBKeyMRByHeight(uint32(heightRequest.Height))
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if keyMR == nil {
+		return nil, NewBlockNotFoundError()
+	}
+	info, err := fetcher.FetchAnchorInfo(uint32(heightRequest.Height))
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+
+	resp := new(AnchorsResponse)
+	resp.Height = heightRequest.Height
+	resp.KeyMR = keyMR.String()
+	resp.Anchors = []*ChainAnchor{}
+	// Anchors of another key merkle root don't anchor this block
+	if info == nil || !info.KeyMRMatches {
+		return resp, nil
+	}
+
+	var btc, eth int64
+	if databaseOverlay.AnchorVerifier != nil {
+		btc, eth, err = databaseOverlay.AnchorVerifier.Confirmations(info)
+		if err != nil {
+			return nil, NewCustomInternalError(err.Error())
+		}
+	}
+	if info.Bitcoin != nil {
+		a := new(ChainAnchor)
+		a.Chain = anchor.ChainBitcoin
+		a.TxID = info.Bitcoin.TXID
+		a.BlockHeight = int64(info.Bitcoin.BlockHeight)
+		a.BlockHash = info.Bitcoin.BlockHash
+		a.Confirmations = btc
+		a.Verified = info.BitcoinVerified
+		resp.Anchors = append(resp.Anchors, a)
+	}
+	if info.Ethereum != nil {
+		a := new(ChainAnchor)
+		a.Chain = anchor.ChainEthereum
+		a.TxID = info.Ethereum.TXID
+		a.BlockHeight = info.Ethereum.BlockHeight
+		a.BlockHash = info.Ethereum.BlockHash
+		a.Confirmations = eth
+		a.Verified = info.EthereumVerified
+		resp.Anchors = append(resp.Anchors, a)
+	}
+	return resp, nil
+}
+
 func HandleV2DirectoryBlock(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
 	n := time.Now()
 	defer HandleV2APICallDBlock.Observe(float64(time.Since(n).Nanoseconds()))
@@ -823,8 +1089,14 @@ func HandleV2FactoidSubmit(state interfaces.IState, params interface{}) (interfa
 		return nil, NewUnableToDecodeTransactionError()
 	}
 
+	err = validateFactoidTransaction(state, msg.Transaction)
+	if err != nil {
+		return nil, NewRejectedTransactionError(err)
+	}
+
 	state.IncFCTSubmits()
 
+	traceMessage(state, msg)
 	state.APIQueue() <- msg
 
 	resp := new(FactoidSubmitResponse)
@@ -834,6 +1106,261 @@ func HandleV2FactoidSubmit(state interfaces.IState, params interface{}) (interfa
 	return resp, nil
 }
 
+// HandleV2FactoidFee returns the fee a transaction owes at the current
+// exchange rate, and what it pays.  The transaction need not be signed, so a
+// wallet can price it before signing; the signatures its RCDs call for are
+// charged for all the same.
+func HandleV2FactoidFee(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallFctFee.Observe(float64(time.Since(n).Nanoseconds()))
+
+	t := new(TransactionRequest)
+	err := MapToObject(params, t)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	p, err := hex.DecodeString(t.Transaction)
+	if err != nil {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+	trans := new(factoid.Transaction)
+	_, err = trans.UnmarshalBinaryData(p)
+	if err != nil {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+
+	rate := state.GetFactoshisPerEC()
+	fee, err := trans.CalculateFeeParts(rate)
+	if err != nil {
+		return nil, NewRejectedTransactionError(err)
+	}
+	inputs, err := trans.TotalInputs()
+	if err != nil {
+		return nil, NewRejectedTransactionError(err)
+	}
+	outputs, err := trans.TotalOutputs()
+	if err != nil {
+		return nil, NewRejectedTransactionError(err)
+	}
+	ecs, err := trans.TotalECs()
+	if err != nil {
+		return nil, NewRejectedTransactionError(err)
+	}
+
+	resp := new(FactoidFeeResponse)
+	resp.Fee = fee.Total()
+	resp.Parts = fee
+	if inputs > outputs+ecs {
+		resp.Paid = inputs - outputs - ecs
+	}
+	resp.Rate = rate
+	return resp, nil
+}
+
+// HandleV2ComposeTransaction builds a transaction to be signed on another
+// machine, in two steps.  Given the inputs, the outputs and the entry credits
+// to buy, it returns the unsigned transaction, with the fee added to the
+// first input, and the data each input must sign.  Given that unsigned
+// transaction back with a public key and signature for each input, in the
+// order of the inputs, it returns the signed transaction, ready for
+// factoid-submit.  Only single signature (RCD 1) inputs are composed.
+func HandleV2ComposeTransaction(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallComposeTx.Observe(float64(time.Since(n).Nanoseconds()))
+
+	req := new(ComposeTransactionRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	if req.Transaction != "" {
+		return signComposedTransaction(req)
+	}
+	return composeTransaction(state, req)
+}
+
+func composeTransaction(state interfaces.IState, req *ComposeTransactionRequest) (interface{}, *primitives.JSONError) {
+	if len(req.Inputs) == 0 {
+		return nil, NewCustomInvalidParamsError("A transaction needs at least one input")
+	}
+	rate := state.GetFactoshisPerEC()
+
+	trans := new(factoid.Transaction)
+	trans.SetTimestamp(primitives.NewTimestampNow())
+	for _, in := range req.Inputs {
+		if !primitives.ValidateFUserStr(in.Address) {
+			return nil, NewInvalidAddressError()
+		}
+		trans.AddInput(factoid.NewAddress(primitives.ConvertUserStrToAddress(in.Address)), in.Amount)
+	}
+	for _, out := range req.Outputs {
+		if !primitives.ValidateFUserStr(out.Address) {
+			return nil, NewInvalidAddressError()
+		}
+		trans.AddOutput(factoid.NewAddress(primitives.ConvertUserStrToAddress(out.Address)), out.Amount)
+	}
+	for _, out := range req.ECOutputs { // The amounts are in entry credits
+		if !primitives.ValidateECUserStr(out.Address) {
+			return nil, NewInvalidAddressError()
+		}
+		trans.AddECOutput(factoid.NewAddress(primitives.ConvertUserStrToAddress(out.Address)), out.Amount*rate)
+	}
+
+	// Adding the fee to the first input can make the transaction longer,
+	// which can raise the fee, so add it until it stays the same
+	first := trans.GetInputs()[0]
+	var fee uint64
+	for {
+		first.SetAmount(req.Inputs[0].Amount + fee)
+		f, err := composedFee(trans, rate)
+		if err != nil {
+			return nil, NewRejectedTransactionError(err)
+		}
+		if f == fee {
+			break
+		}
+		fee = f
+	}
+
+	unsigned, err := trans.MarshalBinarySig()
+	if err != nil {
+		return nil, NewInternalError()
+	}
+	resp := new(ComposeTransactionResponse)
+	resp.Transaction = hex.EncodeToString(unsigned)
+	resp.TxID = trans.GetSigHash().String()
+	resp.Fee = fee
+	for _, in := range req.Inputs {
+		resp.ToSign = append(resp.ToSign, ComposeToSign{Address: in.Address, Data: resp.Transaction})
+	}
+	return resp, nil
+}
+
+func signComposedTransaction(req *ComposeTransactionRequest) (interface{}, *primitives.JSONError) {
+	unsigned, err := hex.DecodeString(req.Transaction)
+	if err != nil {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+	keys := make([][]byte, len(req.Signatures))
+	sigs := make([][]byte, len(req.Signatures))
+	for i, s := range req.Signatures {
+		keys[i], err = hex.DecodeString(s.PublicKey)
+		if err != nil || len(keys[i]) != constants.ADDRESS_LENGTH {
+			return nil, NewCustomInvalidParamsError(fmt.Sprintf("Signature %d needs a 32 byte public key", i))
+		}
+		sigs[i], err = hex.DecodeString(s.Signature)
+		if err != nil || len(sigs[i]) != constants.SIGNATURE_LENGTH {
+			return nil, NewCustomInvalidParamsError(fmt.Sprintf("Signature %d needs a 64 byte signature", i))
+		}
+	}
+
+	trans, err := assembleTransaction(unsigned, keys, sigs)
+	if err != nil {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+	for i, input := range trans.GetInputs() {
+		adr, err := trans.GetRCDs()[i].GetAddress()
+		if err != nil {
+			return nil, NewInternalError()
+		}
+		if !adr.IsSameAs(input.GetAddress()) {
+			return nil, NewCustomInvalidParamsError(fmt.Sprintf("The public key of signature %d is not that of input %d", i, i))
+		}
+	}
+	err = trans.ValidateSignatures()
+	if err != nil {
+		return nil, NewRejectedTransactionError(err)
+	}
+
+	raw, err := trans.MarshalBinary()
+	if err != nil {
+		return nil, NewInternalError()
+	}
+	resp := new(ComposeTransactionResponse)
+	resp.Transaction = hex.EncodeToString(raw)
+	resp.TxID = trans.GetSigHash().String()
+	resp.Signed = true
+	return resp, nil
+}
+
+// assembleTransaction appends an RCD 1 and its signature for each input to
+// the data the inputs sign, and returns the transaction that makes.
+func assembleTransaction(unsigned []byte, keys [][]byte, sigs [][]byte) (*factoid.Transaction, error) {
+	data := append([]byte{}, unsigned...)
+	for i := range keys {
+		rcd, err := factoid.NewRCD_1(keys[i]).MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		sig := new(factoid.FactoidSignature)
+		err = sig.SetSignature(sigs[i])
+		if err != nil {
+			return nil, err
+		}
+		block := new(factoid.SignatureBlock)
+		block.AddSignature(sig)
+		sigData, err := block.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, rcd...)
+		data = append(data, sigData...)
+	}
+
+	trans := new(factoid.Transaction)
+	rest, err := trans.UnmarshalBinaryData(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("The transaction has %d inputs, but %d signatures", len(trans.GetInputs()), len(keys))
+	}
+	return trans, nil
+}
+
+// composedFee returns the fee a transaction will owe once each input has its
+// RCD 1 and signature.  They are the same size whatever the key, so blank
+// ones stand in for them.
+func composedFee(trans *factoid.Transaction, rate uint64) (uint64, error) {
+	unsigned, err := trans.MarshalBinarySig()
+	if err != nil {
+		return 0, err
+	}
+	n := len(trans.GetInputs())
+	keys := make([][]byte, n)
+	sigs := make([][]byte, n)
+	for i := range keys {
+		keys[i] = make([]byte, constants.ADDRESS_LENGTH)
+		sigs[i] = make([]byte, constants.SIGNATURE_LENGTH)
+	}
+	signed, err := assembleTransaction(unsigned, keys, sigs)
+	if err != nil {
+		return 0, err
+	}
+	return signed.CalculateFee(rate)
+}
+
+// validateFactoidTransaction returns why the network would reject a
+// transaction, or nil if it would take it as things stand.
+func validateFactoidTransaction(state interfaces.IState, trans interfaces.ITransaction) error {
+	err := trans.Validate(1)
+	if err != nil {
+		return err
+	}
+	err = trans.ValidateSignatures()
+	if err != nil {
+		return err
+	}
+
+	err = factoid.ValidateFee(trans, state.GetFactoshisPerEC())
+	if err != nil {
+		return err
+	}
+
+	return state.GetFactoidState().Validate(1, trans)
+}
+
 func HandleV2FactoidBalance(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
 	n := time.Now()
 	defer HandleV2APICallFABal.Observe(float64(time.Since(n).Nanoseconds()))
@@ -867,6 +1394,310 @@ func HandleV2FactoidBalance(state interfaces.IState, params interface{}) (interf
 	return resp, nil
 }
 
+// HandleV2MultipleBalances returns the balances of many factoid and entry
+// credit addresses at once.  The addresses must be human readable (FA... or
+// EC...), as a hex address could be either.  A bad address gets an error
+// rather than failing the whole request.
+func HandleV2MultipleBalances(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallMultiBal.Observe(float64(time.Since(n).Nanoseconds()))
+
+	req := new(MultipleBalancesRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	resp := new(MultipleBalancesResponse)
+	resp.Balances = make([]AddressBalance, len(req.Addresses))
+	fs := state.GetFactoidState()
+	for i, address := range req.Addresses {
+		b := &resp.Balances[i]
+		b.Address = address
+		if primitives.ValidateFUserStr(address) {
+			b.Balance = fs.GetFactoidBalance(factoid.NewAddress(primitives.ConvertUserStrToAddress(address)).Fixed())
+		} else if primitives.ValidateECUserStr(address) {
+			b.Balance = fs.GetECBalance(factoid.NewAddress(primitives.ConvertUserStrToAddress(address)).Fixed())
+		} else {
+			b.Error = "Invalid Address"
+		}
+	}
+	return resp, nil
+}
+
+// HandleV2BalanceAtHeight returns the balance a factoid or entry credit
+// address had once the blocks at a height were saved.  The address must be
+// human readable (FA... or EC...), as a hex address could be either.
+func HandleV2BalanceAtHeight(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallBalanceAtHeight.Observe(float64(time.Since(n).Nanoseconds()))
+
+	req := new(BalanceAtHeightRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	if req.Height < 0 || req.Height > int64(state.GetHighestSavedBlk()) {
+		return nil, NewCustomInvalidParamsError("Height is not of a saved block")
+	}
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	resp := new(BalanceAtHeightResponse)
+	resp.Address = req.Address
+	resp.Height = req.Height
+	if primitives.ValidateFUserStr(req.Address) {
+		address := factoid.NewAddress(primitives.ConvertUserStrToAddress(req.Address))
+		resp.Balance, err = dbase.FetchFactoidBalanceAtHeight(address, uint32(req.Height))
+	} else if primitives.ValidateECUserStr(req.Address) {
+		address := factoid.NewAddress(primitives.ConvertUserStrToAddress(req.Address))
+		resp.Balance, err = dbase.FetchECBalanceAtHeight(address, uint32(req.Height))
+	} else {
+		return nil, NewInvalidAddressError()
+	}
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	return resp, nil
+}
+
+// HandleV2ChainStats returns how many entries and bytes the saved entry
+// blocks of a chain hold, and the heights of its first and last blocks.
+func HandleV2ChainStats(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallChainStats.Observe(float64(time.Since(n).Nanoseconds()))
+
+	chainid := new(ChainIDRequest)
+	err := MapToObject(params, chainid)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	h, err := primitives.HexToHash(chainid.ChainID)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	stats, err := dbase.FetchChainStats(h)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if stats == nil {
+		return nil, NewMissingChainHeadError()
+	}
+
+	resp := new(ChainStatsResponse)
+	resp.ChainID = h.String()
+	resp.Entries = stats.Entries
+	resp.Bytes = stats.Bytes
+	resp.EBlocks = stats.EBlocks
+	resp.FirstHeight = stats.FirstHeight
+	resp.LastHeight = stats.LastHeight
+	return resp, nil
+}
+
+// HandleV2Supply returns the total factoid and entry credit supply, and the
+// largest factoid balances, as of the last block the supply statistics saw.
+func HandleV2Supply(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallSupply.Observe(float64(time.Since(n).Nanoseconds()))
+
+	stats := state.GetSupplyStats()
+	if stats == nil {
+		return nil, NewCustomInternalError("Supply statistics are not available, they are off (see RichListEnabled) or not yet worked out")
+	}
+
+	resp := new(SupplyResponse)
+	resp.Height = int64(stats.Height)
+	resp.FactoidSupply = stats.FactoidSupply
+	resp.ECSupply = stats.ECSupply
+	resp.FactoidAddresses = stats.FactoidAddresses
+	resp.ECAddresses = stats.ECAddresses
+	resp.RichList = make([]RichListBalance, len(stats.RichList))
+	for i, b := range stats.RichList {
+		resp.RichList[i].Address = primitives.ConvertFctAddressToUserStr(factoid.NewAddress(b.Address[:]))
+		resp.RichList[i].Balance = b.Balance
+	}
+	return resp, nil
+}
+
+// pageLimit returns the limit of a page request, as bounded by MaxPageSize.
+func pageLimit(limit int) int {
+	if limit <= 0 || limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return limit
+}
+
+// HandleV2ChainIDs lists the entry chains in byte order.  The cursor is the
+// last chain ID of the previous page.
+func HandleV2ChainIDs(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallChainIDs.Observe(float64(time.Since(n).Nanoseconds()))
+
+	page := new(PageRequest)
+	err := MapToObject(params, page)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	var after interfaces.IHash
+	if page.Cursor != "" {
+		after, err = primitives.HexToHash(page.Cursor)
+		if err != nil {
+			return nil, NewInvalidParamsError()
+		}
+	}
+	limit := pageLimit(page.Limit)
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	ids, err := dbase.FetchChainIDs(after, limit)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+
+	resp := new(ChainIDsResponse)
+	resp.ChainIDs = []string{}
+	for _, id := range ids {
+		resp.ChainIDs = append(resp.ChainIDs, id.String())
+	}
+	if len(ids) == limit {
+		resp.Next = ids[len(ids)-1].String()
+	}
+	return resp, nil
+}
+
+// HandleV2ChainEntries lists the entries of a chain, oldest first.  The
+// cursor is "<dbheight>-<index>": the directory block height of the entry
+// block to go on from, and the index of the entry in it.
+func HandleV2ChainEntries(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallChainEntries.Observe(float64(time.Since(n).Nanoseconds()))
+
+	page := new(ChainEntriesRequest)
+	err := MapToObject(params, page)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	chainID, err := primitives.HexToHash(page.ChainID)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+	var height uint32
+	var index int
+	if page.Cursor != "" {
+		_, err = fmt.Sscanf(page.Cursor, "%d-%d", &height, &index)
+		if err != nil || index < 0 {
+			return nil, NewInvalidParamsError()
+		}
+	}
+	limit := pageLimit(page.Limit)
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	// Every block holds at least one entry
+	blocks, err := dbase.FetchEBlocksByChainFrom(chainID, height, limit)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if len(blocks) == 0 && page.Cursor == "" {
+		return nil, NewMissingChainHeadError()
+	}
+
+	resp := new(ChainEntriesResponse)
+	resp.Entries = []ChainEntry{}
+	for i, block := range blocks {
+		if len(resp.Entries) == limit {
+			resp.Next = fmt.Sprintf("%d-0", block.GetDatabaseHeight())
+			break
+		}
+		entries, err := dbase.FetchEBlockEntries(block)
+		if err != nil {
+			return nil, NewInternalDatabaseError()
+		}
+		if i > 0 || block.GetDatabaseHeight() != height {
+			index = 0
+		}
+		for ; index < len(entries) && len(resp.Entries) < limit; index++ {
+			e := ChainEntry{
+				EntryHash: entries[index].GetHash().String(),
+				DBHeight:  block.GetDatabaseHeight(),
+				Content:   hex.EncodeToString(entries[index].GetContent()),
+				ExtIDs:    []string{},
+			}
+			for _, v := range entries[index].ExternalIDs() {
+				e.ExtIDs = append(e.ExtIDs, hex.EncodeToString(v))
+			}
+			resp.Entries = append(resp.Entries, e)
+		}
+		if index < len(entries) {
+			resp.Next = fmt.Sprintf("%d-%d", block.GetDatabaseHeight(), index)
+			break
+		}
+	}
+	// There may be more blocks than were fetched
+	if resp.Next == "" && len(blocks) == limit {
+		resp.Next = fmt.Sprintf("%d-0", blocks[len(blocks)-1].GetDatabaseHeight()+1)
+	}
+	return resp, nil
+}
+
+// HandleV2BlockTransactions lists the transactions of a factoid block.  The
+// cursor is the index of the first transaction to return.
+func HandleV2BlockTransactions(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallBlockTxs.Observe(float64(time.Since(n).Nanoseconds()))
+
+	page := new(BlockTransactionsRequest)
+	err := MapToObject(params, page)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	h, err := primitives.HexToHash(page.KeyMR)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+	offset := 0
+	if page.Cursor != "" {
+		offset, err = strconv.Atoi(page.Cursor)
+		if err != nil || offset < 0 {
+			return nil, NewInvalidParamsError()
+		}
+	}
+	limit := pageLimit(page.Limit)
+
+	dbase := state.GetAndLockDB()
+	defer state.UnlockDB()
+
+	block, err := dbase.FetchFBlock(h)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if block == nil {
+		return nil, NewBlockNotFoundError()
+	}
+
+	txs := block.GetTransactions()
+	resp := new(BlockTransactionsResponse)
+	resp.Total = len(txs)
+	resp.Transactions = []interfaces.ITransaction{}
+	if offset < len(txs) {
+		end := offset + limit
+		if end < len(txs) {
+			resp.Next = strconv.Itoa(end)
+		} else {
+			end = len(txs)
+		}
+		resp.Transactions = txs[offset:end]
+	}
+	return resp, nil
+}
+
 func HandleV2Heights(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
 	n := time.Now()
 	defer HandleV2APICallHeights.Observe(float64(time.Since(n).Nanoseconds()))
@@ -929,6 +1760,9 @@ func HandleV2Properties(state interfaces.IState, params interface{}) (interface{
 	p := new(PropertiesResponse)
 	p.FactomdVersion = vtos(state.GetFactomdVersion())
 	p.ApiVersion = API_VERSION
+	p.ProtocolVersion = int(p2p.ProtocolVersion)
+	p.NetworkName = state.GetNetworkName()
+	p.NodeRole = state.GetNodeRole()
 	return p, nil
 }
 
@@ -946,15 +1780,27 @@ func HandleV2SendRawMessage(state interfaces.IState, params interface{}) (interf
 		return nil, NewInvalidParamsError()
 	}
 
-	_, msg, err := messages.UnmarshalMessageData(data)
+	rest, msg, err := messages.UnmarshalMessageData(data)
 	if err != nil {
-		return nil, NewInvalidParamsError()
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+	if msg == nil || len(rest) > 0 {
+		return nil, NewCustomInvalidParamsError("Not a single message")
 	}
 
-	state.APIQueue() <- msg
+	// The API queue is read with the messages from peers, and like them
+	// goes through the replay filter into the input queue
+	traceMessage(state, msg)
+	select {
+	case state.APIQueue() <- msg:
+	default:
+		return nil, NewCustomInternalError("The API queue is full")
+	}
 
 	resp := new(SendRawMessageResponse)
 	resp.Message = "Successfully sent the message"
+	resp.MessageType = messages.MessageName(msg.Type())
+	resp.MessageHash = msg.GetMsgHash().String()
 
 	return resp, nil
 }
@@ -1020,16 +1866,60 @@ func HandleV2GetTranasction(state interfaces.IState, params interface{}) (interf
 		}
 	}
 
-	blockHash, err := dbase.FetchIncludedIn(h)
-	if err != nil {
-		return nil, NewInternalError()
-	}
-
 	answer := new(TransactionResponse)
 	answer.ECTranasction = ecTx
 	answer.FactoidTransaction = fTx
 	answer.Entry = e
 
+	if e != nil {
+		// Entries are indexed with their directory block height, so the
+		// blocks don't have to be loaded
+		eBlockKeyMR, _, dBlockHeight, err := dbase.FetchEntryLocation(h)
+		if err != nil {
+			return nil, NewInternalError()
+		}
+		if eBlockKeyMR != nil {
+			dBlockKeyMR, err := dbase.FetchDBKeyMRByHeight(dBlockHeight)
+			if err != nil {
+				return nil, NewInternalError()
+			}
+			if dBlockKeyMR != nil {
+				answer.IncludedInTransactionBlock = eBlockKeyMR.String()
+				answer.IncludedInDirectoryBlock = dBlockKeyMR.String()
+				answer.IncludedInDirectoryBlockHeight = int64(dBlockHeight)
+				answer.Confirmations = confirmations(state, dBlockHeight)
+				return answer, nil
+			}
+		}
+	}
+
+	if fTx != nil {
+		// Factoid transactions are indexed by transaction ID, so exchanges
+		// tracking deposits needn't wait on a search of the blocks
+		fBlockKeyMR, _, dBlockHeight, err := dbase.FetchTransactionLocation(h)
+		if err != nil {
+			return nil, NewInternalError()
+		}
+		if fBlockKeyMR != nil {
+			dBlockKeyMR, err := dbase.FetchDBKeyMRByHeight(dBlockHeight)
+			if err != nil {
+				return nil, NewInternalError()
+			}
+			if dBlockKeyMR != nil {
+				answer.IncludedInTransactionBlock = fBlockKeyMR.String()
+				answer.IncludedInDirectoryBlock = dBlockKeyMR.String()
+				answer.IncludedInDirectoryBlockHeight = int64(dBlockHeight)
+				answer.Confirmations = confirmations(state, dBlockHeight)
+				return answer, nil
+			}
+		}
+	}
+
+	blockHash, err := dbase.FetchIncludedIn(h)
+	if err != nil {
+		return nil, NewInternalError()
+	}
+
 	if blockHash == nil {
 		// this is a pending transaction.  It is not yet in a transaction or directory block
 		answer.IncludedInDirectoryBlock = ""
@@ -1050,10 +1940,21 @@ func HandleV2GetTranasction(state interfaces.IState, params interface{}) (interf
 		return nil, NewInternalError()
 	}
 	answer.IncludedInDirectoryBlockHeight = int64(dBlock.GetDatabaseHeight())
+	answer.Confirmations = confirmations(state, dBlock.GetDatabaseHeight())
 
 	return answer, nil
 }
 
+// confirmations returns the number of directory blocks saved from the given
+// height on, the block at the height included.
+func confirmations(state interfaces.IState, dBlockHeight uint32) int64 {
+	highest := state.GetHighestSavedBlk()
+	if highest < dBlockHeight {
+		return 0
+	}
+	return int64(highest-dBlockHeight) + 1
+}
+
 func HandleV2TransactionRate(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
 	n := time.Now()
 	defer HandleV2APICallTpsRate.Observe(float64(time.Since(n).Nanoseconds()))
@@ -1067,3 +1968,47 @@ func HandleV2TransactionRate(state interfaces.IState, params interface{}) (inter
 	r.InstantTransactionRate = instant
 	return r, nil
 }
+
+// HandleV2Profile writes a profile of the node to the ProfilePath of
+// factomd.conf, for diagnosing stalls.  A cpu profile holds the call for the
+// seconds it runs.
+func HandleV2Profile(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallProfile.Observe(float64(time.Since(n).Nanoseconds()))
+
+	enabled, path := state.GetProfilerInfo()
+	if !enabled {
+		return nil, NewCustomInvalidParamsError("Profiling is off, set ProfilerEnabled in factomd.conf")
+	}
+
+	req := new(ProfileRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	file, err := util.WriteProfile(path, req.Type, req.Seconds)
+	if err != nil {
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+
+	resp := new(ProfileResponse)
+	resp.File = file
+	return resp, nil
+}
+
+// HandleV2DumpState writes the consensus state of the node to a JSON file in
+// its log directory, for looking into a stall after the fact.
+func HandleV2DumpState(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallDumpState.Observe(float64(time.Since(n).Nanoseconds()))
+
+	file, err := state.DumpState()
+	if err != nil {
+		return nil, NewCustomInternalError(err.Error())
+	}
+
+	resp := new(DumpStateResponse)
+	resp.File = file
+	return resp, nil
+}
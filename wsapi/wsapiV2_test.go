@@ -2,13 +2,16 @@ package wsapi_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/receipts"
 	"github.com/FactomProject/factomd/testHelper"
@@ -242,6 +245,38 @@ func TestHandleV2CommitChain(t *testing.T) {
 	}
 }
 
+func TestHandleV2CommitChainSignature(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	signed := "00015507b2f70bd0165d9fa19a28cfaafb6bc82f538955a98c7b7e60d79fbf92655c1bff1c76466cb3bc3f3cc68d8b2c111f4f24c88d9c031b4124395c940e5e2c5ea496e8aaa2f5c956749fc3eba4acc60fd485fb100e601070a44fcce54ff358d606698547340b3b6a27bcceb6a42d62a3a8d02a6f0d73653215771de243a63ac048a18b59da2946c901273e616bdbb166c535b26d0d446bc69b22c887c534297c7d01b2ac120237086112b5ef34fc6474e5e941d60aa054b465d4d770d7f850169170ef39150b"
+
+	req := primitives.NewJSON2Request("commit-chain", 0, &MessageRequest{Message: signed})
+	resp, jErr := HandleV2Request(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	respObj := resp.Result.(*CommitChainResponse)
+	if respObj.TxID != "76e123d133a841fe3e08c5e3f3d392f8431f2d7668890c03f003f541efa8fc61" {
+		t.Errorf("Wrong TxID - %v", respObj.TxID)
+	}
+	if respObj.EntryHash != signed[142:206] {
+		t.Errorf("Wrong EntryHash - %v", respObj.EntryHash)
+	}
+	if len(state.APIQueue()) != 1 {
+		t.Errorf("Commit was not queued")
+	}
+	<-state.APIQueue()
+
+	// Change the last byte of the signature
+	forged := signed[:len(signed)-2] + "00"
+	req = primitives.NewJSON2Request("commit-chain", 0, &MessageRequest{Message: forged})
+	if _, jErr := HandleV2Request(state, req); jErr == nil {
+		t.Errorf("Commit with a bad signature was accepted")
+	}
+	if len(state.APIQueue()) != 0 {
+		t.Errorf("Commit with a bad signature was queued")
+	}
+}
+
 func TestHandleV2GetReceipt(t *testing.T) {
 	state := testHelper.CreateAndPopulateTestState()
 	//Start(state)
@@ -398,3 +433,477 @@ func TestHandleV2GetTranasction(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleV2BlocksByKeyMR(t *testing.T) {
+	blockSet := testHelper.CreateTestBlockSet(nil)
+	state := testHelper.CreateAndPopulateTestState()
+
+	toTest := map[string]interfaces.DatabaseBatchable{
+		"admin-block":       blockSet.ABlock,
+		"factoid-block":     blockSet.FBlock.(interfaces.DatabaseBatchable),
+		"entrycredit-block": blockSet.ECBlock.(interfaces.DatabaseBatchable),
+	}
+	for method, block := range toTest {
+		raw, err := block.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		for _, h := range []interfaces.IHash{block.DatabasePrimaryIndex(), block.DatabaseSecondaryIndex()} {
+			req := primitives.NewJSON2Request(method, 1, &KeyMRRequest{KeyMR: h.String()})
+			resp, jErr := HandleV2Request(state, req)
+			if jErr != nil {
+				t.Errorf("%s %v: %v", method, h, jErr)
+				continue
+			}
+			if resp.Result.(*BlockHeightResponse).RawData != primitives.EncodeBinary(raw) {
+				t.Errorf("%s %v returned the wrong block", method, h)
+			}
+		}
+
+		req := primitives.NewJSON2Request(method, 1, &KeyMRRequest{KeyMR: primitives.NewZeroHash().String()})
+		if _, jErr := HandleV2Request(state, req); jErr == nil {
+			t.Errorf("%s found a block that doesn't exist", method)
+		}
+	}
+}
+
+func TestHandleV2FactoidSubmitRejects(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	rate := state.GetFactoshisPerEC()
+
+	newTx := func(amount, fee uint64, sign bool) string {
+		tx := new(factoid.Transaction)
+		tx.AddInput(testHelper.NewFactoidAddress(0), amount+fee)
+		tx.AddOutput(testHelper.NewFactoidAddress(1), amount)
+		tx.SetTimestamp(primitives.NewTimestampNow())
+		if sign {
+			testHelper.SignFactoidTransaction(0, tx)
+		}
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		return primitives.EncodeBinary(raw)
+	}
+
+	toTest := map[string]string{
+		"unsigned":         newTx(1, 100*rate, false),
+		"no fee":           newTx(1, 0, true),
+		"not enough funds": newTx(1<<60, 100*rate, true),
+	}
+	for name, tx := range toTest {
+		req := primitives.NewJSON2Request("factoid-submit", 0, &TransactionRequest{Transaction: tx})
+		_, jErr := HandleV2Request(state, req)
+		if jErr == nil {
+			t.Errorf("A transaction with %s was accepted", name)
+			continue
+		}
+		if reason, _ := jErr.Data.(string); !strings.HasPrefix(reason, "Invalid Transaction: ") {
+			t.Errorf("No reason given for rejecting a transaction with %s - %v", name, jErr.Data)
+		}
+	}
+	if len(state.APIQueue()) != 0 {
+		t.Errorf("A rejected transaction was queued")
+	}
+}
+
+func TestHandleV2FactoidFee(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	rate := state.GetFactoshisPerEC()
+
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(0), 1+100*rate)
+	tx.AddOutput(testHelper.NewFactoidAddress(1), 1)
+	tx.SetTimestamp(primitives.NewTimestampNow())
+	testHelper.SignFactoidTransaction(0, tx)
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	req := primitives.NewJSON2Request("factoid-fee", 0, &TransactionRequest{Transaction: primitives.EncodeBinary(raw)})
+	resp, jErr := HandleV2Request(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	fee := resp.Result.(*FactoidFeeResponse)
+	expected, _ := tx.CalculateFee(rate)
+	if fee.Fee != expected || fee.Parts.Total() != expected {
+		t.Errorf("Expected a fee of %d, got %d", expected, fee.Fee)
+	}
+	if fee.Parts.Size != rate || fee.Parts.Outputs != 10*rate || fee.Parts.Signatures != rate {
+		t.Errorf("Wrong fee parts %+v at rate %d", fee.Parts, rate)
+	}
+	if fee.Paid != 100*rate || fee.Rate != rate {
+		t.Errorf("Expected %d paid at rate %d, got %d at %d", 100*rate, rate, fee.Paid, fee.Rate)
+	}
+}
+
+func TestHandleV2MultipleBalances(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	fa := primitives.ConvertFctAddressToUserStr(testHelper.NewFactoidAddress(0))
+	ec := primitives.ConvertECAddressToUserStr(testHelper.NewECAddress(0))
+	addresses := []string{fa, ec, "not an address"}
+
+	req := primitives.NewJSON2Request("multiple-balances", 0, &MultipleBalancesRequest{Addresses: addresses})
+	resp, jErr := HandleV2Request(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	balances := resp.Result.(*MultipleBalancesResponse).Balances
+	if len(balances) != len(addresses) {
+		t.Fatalf("Got %d balances for %d addresses", len(balances), len(addresses))
+	}
+	for i, b := range balances {
+		if b.Address != addresses[i] {
+			t.Errorf("Balance %d is for %v, not %v", i, b.Address, addresses[i])
+		}
+	}
+
+	single, jErr := HandleV2FactoidBalance(state, &AddressRequest{Address: fa})
+	if jErr != nil {
+		t.Errorf("%v", jErr)
+	} else if balances[0].Balance != single.(*FactoidBalanceResponse).Balance || balances[0].Error != "" {
+		t.Errorf("Wrong factoid balance - %v vs %v", balances[0], single)
+	}
+	single, jErr = HandleV2EntryCreditBalance(state, &AddressRequest{Address: ec})
+	if jErr != nil {
+		t.Errorf("%v", jErr)
+	} else if balances[1].Balance != single.(*EntryCreditBalanceResponse).Balance || balances[1].Error != "" {
+		t.Errorf("Wrong entry credit balance - %v vs %v", balances[1], single)
+	}
+	if balances[2].Error == "" {
+		t.Errorf("No error for an invalid address")
+	}
+}
+
+func TestHandleV2BlockTransactionsPages(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	blocks := testHelper.CreateFullTestBlockSet()
+
+	for _, block := range blocks {
+		txs := block.FBlock.GetTransactions()
+		req := &BlockTransactionsRequest{KeyMR: block.FBlock.DatabasePrimaryIndex().String(), Limit: 1}
+		var got []interfaces.ITransaction
+		for pages := 0; ; pages++ {
+			if pages > len(txs) {
+				t.Fatalf("Paging did not end")
+			}
+			resp, jErr := HandleV2BlockTransactions(state, req)
+			if jErr != nil {
+				t.Fatalf("%v", jErr)
+			}
+			r := resp.(*BlockTransactionsResponse)
+			if len(r.Transactions) > 1 {
+				t.Errorf("Got %d transactions with a limit of 1", len(r.Transactions))
+			}
+			if r.Total != len(txs) {
+				t.Errorf("Got a total of %d, not %d", r.Total, len(txs))
+			}
+			got = append(got, r.Transactions...)
+			if r.Next == "" {
+				break
+			}
+			req.Cursor = r.Next
+		}
+		if len(got) != len(txs) {
+			t.Fatalf("Got %d transactions, not %d", len(got), len(txs))
+		}
+		for i := range txs {
+			if got[i].GetFullHash().IsSameAs(txs[i].GetFullHash()) == false {
+				t.Errorf("Transaction %d is wrong", i)
+			}
+		}
+	}
+
+	_, jErr := HandleV2BlockTransactions(state, &BlockTransactionsRequest{KeyMR: blocks[0].FBlock.DatabasePrimaryIndex().String(), Cursor: "-1"})
+	if jErr == nil {
+		t.Errorf("A negative cursor was accepted")
+	}
+}
+
+func TestHandleV2ChainIDsPages(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	resp, jErr := HandleV2ChainIDs(state, &PageRequest{})
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	all := resp.(*ChainIDsResponse)
+	if len(all.ChainIDs) < 2 {
+		t.Fatalf("Got %d chains, expected the test chain and the anchor chain at least", len(all.ChainIDs))
+	}
+	if all.Next != "" {
+		t.Errorf("Got a next cursor %s with every chain in the page", all.Next)
+	}
+	for i := 1; i < len(all.ChainIDs); i++ {
+		if all.ChainIDs[i-1] >= all.ChainIDs[i] {
+			t.Errorf("Chain IDs out of order at %d: %s, %s", i, all.ChainIDs[i-1], all.ChainIDs[i])
+		}
+	}
+
+	// One at a time gives the same chains
+	req := &PageRequest{Limit: 1}
+	var got []string
+	for pages := 0; ; pages++ {
+		if pages > len(all.ChainIDs) {
+			t.Fatalf("Paging did not end")
+		}
+		resp, jErr := HandleV2ChainIDs(state, req)
+		if jErr != nil {
+			t.Fatalf("%v", jErr)
+		}
+		r := resp.(*ChainIDsResponse)
+		if len(r.ChainIDs) > 1 {
+			t.Errorf("Got %d chains with a limit of 1", len(r.ChainIDs))
+		}
+		got = append(got, r.ChainIDs...)
+		if r.Next == "" {
+			break
+		}
+		req.Cursor = r.Next
+	}
+	if strings.Join(got, ",") != strings.Join(all.ChainIDs, ",") {
+		t.Errorf("Paged chains %v, expected %v", got, all.ChainIDs)
+	}
+
+	_, jErr = HandleV2ChainIDs(state, &PageRequest{Cursor: "not hex"})
+	if jErr == nil {
+		t.Errorf("An invalid cursor was accepted")
+	}
+}
+
+func TestHandleV2ChainEntriesPages(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	blocks := testHelper.CreateFullTestBlockSet()
+	chainID := blocks[0].EBlock.GetChainID()
+
+	all, err := state.GetAndLockDB().FetchAllEntriesByChainID(chainID)
+	state.UnlockDB()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	resp, jErr := HandleV2ChainEntries(state, &ChainEntriesRequest{ChainID: chainID.String()})
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	whole := resp.(*ChainEntriesResponse)
+	if len(whole.Entries) != len(all) {
+		t.Errorf("Got %d entries, the chain has %d", len(whole.Entries), len(all))
+	}
+	for i := 1; i < len(whole.Entries); i++ {
+		if whole.Entries[i-1].DBHeight > whole.Entries[i].DBHeight {
+			t.Errorf("Entries out of order at %d", i)
+		}
+	}
+
+	// Pages of every size give the same entries, in the same order
+	for limit := 1; limit <= 3; limit++ {
+		req := &ChainEntriesRequest{ChainID: chainID.String(), Limit: limit}
+		var got []ChainEntry
+		for pages := 0; ; pages++ {
+			if pages > len(whole.Entries)+len(blocks) {
+				t.Fatalf("Paging did not end with a limit of %d", limit)
+			}
+			resp, jErr := HandleV2ChainEntries(state, req)
+			if jErr != nil {
+				t.Fatalf("%v", jErr)
+			}
+			r := resp.(*ChainEntriesResponse)
+			if len(r.Entries) > limit {
+				t.Errorf("Got %d entries with a limit of %d", len(r.Entries), limit)
+			}
+			got = append(got, r.Entries...)
+			if r.Next == "" {
+				break
+			}
+			req.Cursor = r.Next
+		}
+		if len(got) != len(whole.Entries) {
+			t.Fatalf("Got %d entries with a limit of %d, not %d", len(got), limit, len(whole.Entries))
+		}
+		for i := range got {
+			if got[i].EntryHash != whole.Entries[i].EntryHash || got[i].DBHeight != whole.Entries[i].DBHeight {
+				t.Errorf("Entry %d is wrong with a limit of %d", i, limit)
+			}
+		}
+	}
+
+	_, jErr = HandleV2ChainEntries(state, &ChainEntriesRequest{ChainID: primitives.RandomHash().String()})
+	if jErr == nil {
+		t.Errorf("A chain that doesn't exist was accepted")
+	}
+	_, jErr = HandleV2ChainEntries(state, &ChainEntriesRequest{ChainID: chainID.String(), Cursor: "1-x"})
+	if jErr == nil {
+		t.Errorf("An invalid cursor was accepted")
+	}
+	_, jErr = HandleV2ChainEntries(state, &ChainEntriesRequest{ChainID: "not hex"})
+	if jErr == nil {
+		t.Errorf("An invalid chain ID was accepted")
+	}
+}
+
+func TestHandleV2Properties(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	resp, jErr := HandleV2Properties(state, nil)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	p := resp.(*PropertiesResponse)
+	if p.ApiVersion != API_VERSION {
+		t.Errorf("Wrong API version - %v", p.ApiVersion)
+	}
+	if p.NetworkName != state.GetNetworkName() {
+		t.Errorf("Wrong network name - %v", p.NetworkName)
+	}
+	if p.NodeRole != "leader" && p.NodeRole != "audit" && p.NodeRole != "follower" {
+		t.Errorf("Unknown node role - %v", p.NodeRole)
+	}
+	if p.ProtocolVersion == 0 {
+		t.Errorf("No protocol version")
+	}
+}
+
+func TestHandleV2SendRawMessage(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	msg := messages.NewRevealEntryMsg()
+	msg.Entry = testHelper.CreateTestEntry(1)
+	msg.Timestamp = primitives.NewTimestampNow()
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	resp, jErr := HandleV2SendRawMessage(state, &SendRawMessageRequest{Message: hex.EncodeToString(data)})
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	r := resp.(*SendRawMessageResponse)
+	if r.MessageHash != msg.GetMsgHash().String() {
+		t.Errorf("Wrong message hash %v", r.MessageHash)
+	}
+	select {
+	case queued := <-state.APIQueue():
+		if queued.GetMsgHash().IsSameAs(msg.GetMsgHash()) == false {
+			t.Errorf("Wrong message queued")
+		}
+	default:
+		t.Errorf("The message wasn't queued")
+	}
+
+	bad := []string{"not hex", "", hex.EncodeToString(append(data, 0))}
+	for _, b := range bad {
+		if _, jErr := HandleV2SendRawMessage(state, &SendRawMessageRequest{Message: b}); jErr == nil {
+			t.Errorf("No error for %q", b)
+		}
+	}
+}
+
+func TestHandleV2ComposeTransaction(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	rate := state.GetFactoshisPerEC()
+
+	fa := primitives.ConvertFctAddressToUserStr(testHelper.NewFactoidAddress(0))
+	compose := &ComposeTransactionRequest{
+		Inputs:    []ComposeAmount{{Address: fa, Amount: 1000 + 5*rate}},
+		Outputs:   []ComposeAmount{{Address: primitives.ConvertFctAddressToUserStr(testHelper.NewFactoidAddress(1)), Amount: 1000}},
+		ECOutputs: []ComposeAmount{{Address: primitives.ConvertECAddressToUserStr(testHelper.NewECAddress(1)), Amount: 5}},
+	}
+	resp, jErr := HandleV2Request(state, primitives.NewJSON2Request("compose-transaction", 0, compose))
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	unsigned := resp.Result.(*ComposeTransactionResponse)
+	if unsigned.Signed || unsigned.Fee == 0 || len(unsigned.ToSign) != 1 {
+		t.Fatalf("Bad unsigned transaction %+v", unsigned)
+	}
+	if unsigned.ToSign[0].Address != fa || unsigned.ToSign[0].Data != unsigned.Transaction {
+		t.Errorf("Bad data to sign %+v", unsigned.ToSign[0])
+	}
+
+	// Sign the data as an offline wallet would
+	data, err := hex.DecodeString(unsigned.ToSign[0].Data)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	_, pub, _ := testHelper.NewFactoidAddressStrings(0)
+	sig := primitives.Sign(testHelper.NewPrivKey(0), data)[:64]
+
+	sign := &ComposeTransactionRequest{
+		Transaction: unsigned.Transaction,
+		Signatures:  []ComposeSignature{{PublicKey: pub, Signature: hex.EncodeToString(sig)}},
+	}
+	resp, jErr = HandleV2Request(state, primitives.NewJSON2Request("compose-transaction", 0, sign))
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	signed := resp.Result.(*ComposeTransactionResponse)
+	if !signed.Signed || signed.TxID != unsigned.TxID {
+		t.Errorf("Bad signed transaction %+v", signed)
+	}
+
+	raw, err := hex.DecodeString(signed.Transaction)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	tx := new(factoid.Transaction)
+	_, err = tx.UnmarshalBinaryData(raw)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := tx.ValidateSignatures(); err != nil {
+		t.Errorf("%v", err)
+	}
+	fee, _ := tx.CalculateFee(rate)
+	if fee != unsigned.Fee {
+		t.Errorf("Fee is %d, expected %d", unsigned.Fee, fee)
+	}
+	inputs, _ := tx.TotalInputs()
+	if inputs != 1000+5*rate+fee {
+		t.Errorf("Inputs are %d, expected %d", inputs, 1000+5*rate+fee)
+	}
+
+	// A signature by the wrong key is refused
+	_, pub, _ = testHelper.NewFactoidAddressStrings(1)
+	sign.Signatures[0].PublicKey = pub
+	_, jErr = HandleV2Request(state, primitives.NewJSON2Request("compose-transaction", 0, sign))
+	if jErr == nil {
+		t.Errorf("No error on a signature by the wrong key")
+	}
+}
+
+func TestHandleV2Anchors(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+
+	resp, jErr := HandleV2Anchors(state, map[string]interface{}{"height": 1})
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	anchors := resp.(*AnchorsResponse)
+	keyMR, _ := state.GetAndLockDB().FetchDBKeyMRByHeight(1)
+	state.UnlockDB()
+	if anchors.Height != 1 || anchors.KeyMR != keyMR.String() {
+		t.Errorf("Wrong block %d %s", anchors.Height, anchors.KeyMR)
+	}
+	if len(anchors.Anchors) != 1 || anchors.Anchors[0].Chain != "bitcoin" || anchors.Anchors[0].BlockHeight != 1 {
+		t.Errorf("Wrong anchors %v", anchors.Anchors)
+	}
+
+	// The last block isn't anchored yet
+	resp, jErr = HandleV2Anchors(state, map[string]interface{}{"height": testHelper.BlockCount - 1})
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	if len(resp.(*AnchorsResponse).Anchors) != 0 {
+		t.Errorf("Anchors of a block not anchored")
+	}
+
+	if _, jErr = HandleV2Anchors(state, map[string]interface{}{"height": testHelper.BlockCount + 5}); jErr == nil {
+		t.Errorf("No error for a block not found")
+	}
+}